@@ -49,10 +49,42 @@ type PackageVersionAnalysisResult struct {
 	ExclusionID     string
 	ExclusionReason string
 
+	// Name of the analyzer whose verdict decided Action, when several
+	// analyzers were combined. Empty for a single analyzer.
+	DecidedBy string
+
+	// Popular package this one appears to impersonate, for verdicts of the
+	// typosquatting analyzer. Shown to the user as "did you mean".
+	SuspectedTarget string
+
+	// Known vulnerabilities affecting the version, for verdicts of the
+	// vulnerability policy analyzer.
+	Vulnerabilities []Vulnerability
+
+	// Local heuristic that flagged the version (HeuristicInstallScript or
+	// HeuristicTyposquat). Such a verdict is a suspicion judged from
+	// metadata, not a malware verdict.
+	Heuristic string
+
 	// Analyzer specific data
 	Data any
 }
 
+// Heuristics whose verdicts are reported apart from malware.
+const (
+	HeuristicInstallScript = "install-script"
+	HeuristicTyposquat     = "typosquat"
+)
+
+// Vulnerability is a published advisory that affects a package version.
+type Vulnerability struct {
+	ID           string
+	Aliases      []string
+	Severity     string
+	Summary      string
+	ReferenceURL string
+}
+
 // Contract for implementing package version specific analyzers
 type PackageVersionAnalyzer interface {
 	Analyzer
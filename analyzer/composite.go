@@ -0,0 +1,267 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CompositeStrategy decides how the verdicts of a CompositeAnalyzer's
+// analyzers combine into one.
+type CompositeStrategy string
+
+const (
+	// CompositeStrategyAnyBlock takes the most severe verdict any analyzer
+	// returned: one block blocks the package.
+	CompositeStrategyAnyBlock CompositeStrategy = "any_block"
+
+	// CompositeStrategyMajority takes the most severe verdict more than half
+	// of the analyzers agree on. A block vote also counts towards confirm, so
+	// a block and a confirm out of three analyzers ask the user.
+	CompositeStrategyMajority CompositeStrategy = "majority"
+
+	// CompositeStrategyFirstDefinitive takes the first block or allow verdict
+	// in analyzer order. Confirm and unknown verdicts defer to the analyzers
+	// after them, and decide only when no analyzer is definitive.
+	CompositeStrategyFirstDefinitive CompositeStrategy = "first_definitive"
+)
+
+// CompositeAnalyzer runs several analyzers concurrently and combines their
+// verdicts by a CompositeStrategy. The combined result is a copy of the
+// deciding analyzer's, with DecidedBy naming it and the summaries of the
+// analyzers that agree with the verdict merged in.
+//
+// An analyzer that fails is left out of the vote. Analysis fails only when
+// every analyzer fails or abstains, so the analysis.on_failure policy still
+// applies when no verdict exists at all. A NotFound error votes allow, as it does
+// for a single analyzer in the proxy: the package is simply not known.
+type CompositeAnalyzer struct {
+	analyzers []PackageVersionAnalyzer
+	strategy  CompositeStrategy
+}
+
+var _ PackageVersionAnalyzer = (*CompositeAnalyzer)(nil)
+
+// NewCompositeAnalyzer combines analyzers, in priority order, by strategy.
+func NewCompositeAnalyzer(strategy CompositeStrategy, analyzers ...PackageVersionAnalyzer) (*CompositeAnalyzer, error) {
+	switch strategy {
+	case CompositeStrategyAnyBlock, CompositeStrategyMajority, CompositeStrategyFirstDefinitive:
+	default:
+		return nil, fmt.Errorf("unknown composite strategy: %q", strategy)
+	}
+
+	if len(analyzers) == 0 {
+		return nil, fmt.Errorf("composite analyzer needs at least one analyzer")
+	}
+
+	return &CompositeAnalyzer{analyzers: analyzers, strategy: strategy}, nil
+}
+
+func (c *CompositeAnalyzer) Name() string {
+	names := make([]string, 0, len(c.analyzers))
+	for _, a := range c.analyzers {
+		names = append(names, a.Name())
+	}
+
+	return "composite(" + strings.Join(names, ",") + ")"
+}
+
+// compositeVote is one analyzer's answer.
+type compositeVote struct {
+	analyzer string
+	result   *PackageVersionAnalysisResult
+	err      error
+}
+
+func (c *CompositeAnalyzer) Analyze(ctx context.Context, pkg *packagev1.PackageVersion) (*PackageVersionAnalysisResult, error) {
+	votes := make([]compositeVote, len(c.analyzers))
+
+	var wg sync.WaitGroup
+	for i, a := range c.analyzers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := a.Analyze(ctx, pkg)
+			switch {
+			case isNotFound(err):
+				result, err = &PackageVersionAnalysisResult{PackageVersion: pkg, Action: ActionAllow}, nil
+			case err == nil && result == nil:
+				err = fmt.Errorf("analyzer returned no result")
+			}
+			votes[i] = compositeVote{analyzer: a.Name(), result: result, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var answered []compositeVote
+	var errs []error
+	for _, vote := range votes {
+		if vote.err != nil {
+			log.Warnf("analyzer %s failed for %s@%s, leaving it out of the verdict: %v",
+				vote.analyzer, pkg.GetPackage().GetName(), pkg.GetVersion(), vote.err)
+			errs = append(errs, fmt.Errorf("%s: %w", vote.analyzer, vote.err))
+			continue
+		}
+		answered = append(answered, vote)
+	}
+
+	if len(answered) == 0 {
+		return nil, fmt.Errorf("all analyzers failed: %w", errors.Join(errs...))
+	}
+
+	var action Action
+	switch c.strategy {
+	case CompositeStrategyMajority:
+		action = majorityAction(answered)
+	case CompositeStrategyFirstDefinitive:
+		action = firstDefinitiveAction(answered)
+	default:
+		action = mostSevereAction(answered)
+	}
+
+	// Analyzers that only ever flag, such as the vulnerability policy,
+	// abstain on a clean package. Abstentions alone are no verdict when
+	// another analyzer failed, so the failure policy applies.
+	if action == ActionUnknown && len(errs) > 0 {
+		return nil, fmt.Errorf("no analyzer returned a verdict: %w", errors.Join(errs...))
+	}
+
+	return mergeVotes(pkg, action, answered), nil
+}
+
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.NotFound
+}
+
+// actionSeverity orders actions from least to most severe. Unknown ranks
+// below allow so that any real verdict outweighs it.
+func actionSeverity(action Action) int {
+	switch action {
+	case ActionAllow:
+		return 1
+	case ActionConfirm:
+		return 2
+	case ActionBlock:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func mostSevereAction(votes []compositeVote) Action {
+	action := ActionUnknown
+	for _, vote := range votes {
+		if actionSeverity(vote.result.Action) > actionSeverity(action) {
+			action = vote.result.Action
+		}
+	}
+
+	return action
+}
+
+// majorityAction counts unknown verdicts as abstentions. A verdict wins when
+// more than half of the remaining votes are that severe or more.
+func majorityAction(votes []compositeVote) Action {
+	var blocks, confirms, voters int
+	for _, vote := range votes {
+		switch vote.result.Action {
+		case ActionBlock:
+			blocks++
+		case ActionConfirm:
+			confirms++
+		case ActionAllow:
+		default:
+			continue
+		}
+		voters++
+	}
+
+	switch {
+	case voters == 0:
+		return ActionUnknown
+	case 2*blocks > voters:
+		return ActionBlock
+	case 2*(blocks+confirms) > voters:
+		return ActionConfirm
+	default:
+		return ActionAllow
+	}
+}
+
+func firstDefinitiveAction(votes []compositeVote) Action {
+	for _, vote := range votes {
+		if vote.result.Action == ActionBlock || vote.result.Action == ActionAllow {
+			return vote.result.Action
+		}
+	}
+
+	return mostSevereAction(votes)
+}
+
+// mergeVotes builds the combined result for action from the first vote that
+// returned it. Summaries and malware flags of every agreeing vote are merged
+// so the report shows all analyzers that backed the verdict.
+func mergeVotes(pkg *packagev1.PackageVersion, action Action, votes []compositeVote) *PackageVersionAnalysisResult {
+	var agreeing []compositeVote
+	for _, vote := range votes {
+		if vote.result.Action == action {
+			agreeing = append(agreeing, vote)
+		}
+	}
+
+	deciding := agreeing[0]
+	merged := *deciding.result
+	merged.DecidedBy = deciding.analyzer
+	if merged.PackageVersion == nil {
+		merged.PackageVersion = pkg
+	}
+
+	var summaries, labelled []string
+	for _, vote := range agreeing {
+		if vote.result.Summary != "" {
+			summaries = append(summaries, vote.result.Summary)
+			labelled = append(labelled, vote.analyzer+": "+vote.result.Summary)
+		}
+		if merged.ReferenceURL == "" {
+			merged.ReferenceURL = vote.result.ReferenceURL
+		}
+		if merged.SuspectedTarget == "" {
+			merged.SuspectedTarget = vote.result.SuspectedTarget
+		}
+		// The verdict is a heuristic one only if every agreeing analyzer is
+		// a heuristic.
+		if vote.result.Heuristic == "" {
+			merged.Heuristic = ""
+		}
+		if len(merged.Vulnerabilities) == 0 {
+			merged.Vulnerabilities = vote.result.Vulnerabilities
+		}
+		if action == ActionBlock || action == ActionConfirm {
+			merged.IsMalware = merged.IsMalware || vote.result.IsMalware
+			merged.IsVerified = merged.IsVerified || vote.result.IsVerified
+		}
+	}
+
+	switch len(summaries) {
+	case 0:
+	case 1:
+		merged.Summary = summaries[0]
+	default:
+		merged.Summary = strings.Join(labelled, "; ")
+	}
+
+	return &merged
+}
@@ -0,0 +1,276 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// namedAnalyzer gives a fake analyzer a distinct name, so tests can tell
+// which contributor decided a composite verdict.
+type namedAnalyzer struct {
+	*fakePackageVersionAnalyzer
+	name string
+}
+
+func (n namedAnalyzer) Name() string { return n.name }
+
+func verdict(name string, action Action, summary string) namedAnalyzer {
+	return namedAnalyzer{
+		fakePackageVersionAnalyzer: &fakePackageVersionAnalyzer{result: &PackageVersionAnalysisResult{
+			Action:       action,
+			Summary:      summary,
+			IsMalware:    action == ActionBlock,
+			ReferenceURL: "https://example.com/" + name,
+		}},
+		name: name,
+	}
+}
+
+func failing(name string) namedAnalyzer {
+	return namedAnalyzer{
+		fakePackageVersionAnalyzer: &fakePackageVersionAnalyzer{err: errors.New("unavailable")},
+		name:                       name,
+	}
+}
+
+func TestNewCompositeAnalyzer_Validation(t *testing.T) {
+	_, err := NewCompositeAnalyzer("unanimous", verdict("a", ActionAllow, ""))
+	assert.ErrorContains(t, err, "unknown composite strategy")
+
+	_, err = NewCompositeAnalyzer(CompositeStrategyAnyBlock)
+	assert.Error(t, err)
+}
+
+func TestCompositeAnalyzer_Strategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  CompositeStrategy
+		analyzers []PackageVersionAnalyzer
+		action    Action
+		decidedBy string
+	}{
+		{
+			name:      "any_block blocks on a single block",
+			strategy:  CompositeStrategyAnyBlock,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionAllow, ""), verdict("b", ActionBlock, "bad"), verdict("c", ActionConfirm, "odd")},
+			action:    ActionBlock,
+			decidedBy: "b",
+		},
+		{
+			name:      "any_block allows when all allow",
+			strategy:  CompositeStrategyAnyBlock,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionAllow, ""), verdict("b", ActionAllow, "")},
+			action:    ActionAllow,
+			decidedBy: "a",
+		},
+		{
+			name:      "majority allows a minority block",
+			strategy:  CompositeStrategyMajority,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionBlock, "bad"), verdict("b", ActionAllow, ""), verdict("c", ActionAllow, "")},
+			action:    ActionAllow,
+			decidedBy: "b",
+		},
+		{
+			name:      "majority blocks a majority block",
+			strategy:  CompositeStrategyMajority,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionAllow, ""), verdict("b", ActionBlock, "bad"), verdict("c", ActionBlock, "bad")},
+			action:    ActionBlock,
+			decidedBy: "b",
+		},
+		{
+			name:      "majority counts block towards confirm",
+			strategy:  CompositeStrategyMajority,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionBlock, "bad"), verdict("b", ActionConfirm, "odd"), verdict("c", ActionAllow, "")},
+			action:    ActionConfirm,
+			decidedBy: "b",
+		},
+		{
+			name:      "majority tie does not flag",
+			strategy:  CompositeStrategyMajority,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionBlock, "bad"), verdict("b", ActionAllow, "")},
+			action:    ActionAllow,
+			decidedBy: "b",
+		},
+		{
+			name:      "majority ignores unknown votes",
+			strategy:  CompositeStrategyMajority,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionUnknown, ""), verdict("b", ActionBlock, "bad")},
+			action:    ActionBlock,
+			decidedBy: "b",
+		},
+		{
+			name:      "first_definitive takes the first allow",
+			strategy:  CompositeStrategyFirstDefinitive,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionAllow, ""), verdict("b", ActionBlock, "bad")},
+			action:    ActionAllow,
+			decidedBy: "a",
+		},
+		{
+			name:      "first_definitive skips confirm",
+			strategy:  CompositeStrategyFirstDefinitive,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionConfirm, "odd"), verdict("b", ActionBlock, "bad")},
+			action:    ActionBlock,
+			decidedBy: "b",
+		},
+		{
+			name:      "first_definitive falls back to confirm",
+			strategy:  CompositeStrategyFirstDefinitive,
+			analyzers: []PackageVersionAnalyzer{verdict("a", ActionUnknown, ""), verdict("b", ActionConfirm, "odd")},
+			action:    ActionConfirm,
+			decidedBy: "b",
+		},
+		{
+			name:      "failed analyzers are left out",
+			strategy:  CompositeStrategyFirstDefinitive,
+			analyzers: []PackageVersionAnalyzer{failing("a"), verdict("b", ActionBlock, "bad")},
+			action:    ActionBlock,
+			decidedBy: "b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			composite, err := NewCompositeAnalyzer(tt.strategy, tt.analyzers...)
+			require.NoError(t, err)
+
+			pkg := makePkgVersion("pkg", "1.0.0")
+			result, err := composite.Analyze(context.Background(), pkg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.action, result.Action)
+			assert.Equal(t, tt.decidedBy, result.DecidedBy)
+			assert.Equal(t, "https://example.com/"+tt.decidedBy, result.ReferenceURL)
+			assert.Equal(t, pkg, result.PackageVersion)
+		})
+	}
+}
+
+func TestCompositeAnalyzer_NotFoundVotesAllow(t *testing.T) {
+	notFound := namedAnalyzer{
+		fakePackageVersionAnalyzer: &fakePackageVersionAnalyzer{err: wrapGrpcError(codes.NotFound)},
+		name:                       "malysis",
+	}
+
+	composite, err := NewCompositeAnalyzer(CompositeStrategyMajority, notFound, verdict("b", ActionAllow, ""), verdict("c", ActionBlock, "bad"))
+	require.NoError(t, err)
+
+	result, err := composite.Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, ActionAllow, result.Action)
+	assert.Equal(t, "malysis", result.DecidedBy)
+}
+
+func TestCompositeAnalyzer_RunsEveryAnalyzer(t *testing.T) {
+	a, b := verdict("a", ActionAllow, ""), verdict("b", ActionAllow, "")
+
+	composite, err := NewCompositeAnalyzer(CompositeStrategyFirstDefinitive, a, b)
+	require.NoError(t, err)
+
+	_, err = composite.Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), a.calls.Load())
+	assert.Equal(t, int64(1), b.calls.Load())
+}
+
+func TestCompositeAnalyzer_MergesAgreeingContributors(t *testing.T) {
+	feed := verdict("feed", ActionBlock, "Listed as MAL-2024-1")
+	feed.result.IsVerified = true
+	feed.result.ReferenceURL = ""
+	malysis := verdict("malysis", ActionBlock, "Exfiltrates credentials")
+	other := verdict("other", ActionAllow, "No issues")
+
+	composite, err := NewCompositeAnalyzer(CompositeStrategyAnyBlock, feed, malysis, other)
+	require.NoError(t, err)
+
+	result, err := composite.Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "feed", result.DecidedBy)
+	assert.Equal(t, "feed: Listed as MAL-2024-1; malysis: Exfiltrates credentials", result.Summary)
+	assert.Equal(t, "https://example.com/malysis", result.ReferenceURL)
+	assert.True(t, result.IsMalware)
+	assert.True(t, result.IsVerified)
+
+	// The contributor's own result is not modified.
+	assert.Empty(t, feed.result.DecidedBy)
+}
+
+func TestCompositeAnalyzer_KeepsSuspectedTarget(t *testing.T) {
+	typosquat := verdict("typosquat", ActionConfirm, "Did you mean lodash?")
+	typosquat.result.SuspectedTarget = "lodash"
+
+	composite, err := NewCompositeAnalyzer(CompositeStrategyAnyBlock,
+		verdict("plugin", ActionConfirm, "Not on the allow list"), typosquat)
+	require.NoError(t, err)
+
+	result, err := composite.Analyze(context.Background(), makePkgVersion("lodahs", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "plugin", result.DecidedBy)
+	assert.Equal(t, "lodash", result.SuspectedTarget)
+}
+
+func TestCompositeAnalyzer_HeuristicOnlyWhenEveryAgreeingVoteIs(t *testing.T) {
+	typosquat := verdict("typosquat", ActionConfirm, "Did you mean lodash?")
+	typosquat.result.Heuristic = HeuristicTyposquat
+
+	composite, err := NewCompositeAnalyzer(CompositeStrategyAnyBlock, typosquat, verdict("feed", ActionAllow, ""))
+	require.NoError(t, err)
+	result, err := composite.Analyze(context.Background(), makePkgVersion("lodahs", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, HeuristicTyposquat, result.Heuristic)
+
+	composite, err = NewCompositeAnalyzer(CompositeStrategyAnyBlock,
+		verdict("plugin", ActionConfirm, "Not on the allow list"), typosquat)
+	require.NoError(t, err)
+	result, err = composite.Analyze(context.Background(), makePkgVersion("lodahs", "1.0.0"))
+	require.NoError(t, err)
+	assert.Empty(t, result.Heuristic)
+}
+
+func TestCompositeAnalyzer_SingleSummaryIsNotLabelled(t *testing.T) {
+	composite, err := NewCompositeAnalyzer(CompositeStrategyAnyBlock,
+		verdict("a", ActionBlock, ""), verdict("b", ActionBlock, "Exfiltrates credentials"))
+	require.NoError(t, err)
+
+	result, err := composite.Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", result.DecidedBy)
+	assert.Equal(t, "Exfiltrates credentials", result.Summary)
+}
+
+func TestCompositeAnalyzer_AllFailed(t *testing.T) {
+	composite, err := NewCompositeAnalyzer(CompositeStrategyAnyBlock, failing("a"), failing("b"))
+	require.NoError(t, err)
+
+	_, err = composite.Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "a: unavailable")
+	assert.ErrorContains(t, err, "b: unavailable")
+}
+
+func TestCompositeAnalyzer_AbstainedAndFailed(t *testing.T) {
+	composite, err := NewCompositeAnalyzer(CompositeStrategyAnyBlock, failing("malysis"), verdict("vulnerabilities", ActionUnknown, ""))
+	require.NoError(t, err)
+
+	_, err = composite.Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	assert.ErrorContains(t, err, "malysis: unavailable")
+}
+
+func TestCompositeAnalyzer_KeepsVulnerabilities(t *testing.T) {
+	vulns := verdict("vulnerabilities", ActionBlock, "GHSA-1 (critical)")
+	vulns.result.IsMalware = false
+	vulns.result.Vulnerabilities = []Vulnerability{{ID: "GHSA-1", Severity: "critical"}}
+
+	composite, err := NewCompositeAnalyzer(CompositeStrategyAnyBlock, verdict("malysis", ActionAllow, ""), vulns)
+	require.NoError(t, err)
+
+	result, err := composite.Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "vulnerabilities", result.DecidedBy)
+	assert.False(t, result.IsMalware)
+	require.Len(t, result.Vulnerabilities, 1)
+	assert.Equal(t, "GHSA-1", result.Vulnerabilities[0].ID)
+}
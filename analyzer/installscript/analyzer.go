@@ -0,0 +1,135 @@
+// Package installscript flags npm package versions whose install lifecycle
+// scripts look like a supply chain attack: a preinstall, install,
+// postinstall or prepare script added in a release when the previous version
+// had none, or a script that downloads or runs code. It judges from the
+// packuments the proxy already fetches, so it needs no service and catches a
+// compromised release before any central verdict exists.
+package installscript
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/Masterminds/semver"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+)
+
+const analyzerName = "npm-install-scripts"
+
+// Analyzer answers from the scripts recorded in its Store. It has no opinion,
+// ActionUnknown, on packages of other ecosystems and on versions whose
+// packument it has not seen, such as ones npm installed from its own cache.
+type Analyzer struct {
+	store            *Store
+	newScriptAction  analyzer.Action
+	suspiciousAction analyzer.Action
+}
+
+var (
+	_ analyzer.PackageVersionAnalyzer = (*Analyzer)(nil)
+	_ analyzer.NpmPackumentObserver   = (*Analyzer)(nil)
+)
+
+func New(store *Store, cfg config.InstallScriptsConfig) *Analyzer {
+	return &Analyzer{
+		store:            store,
+		newScriptAction:  toAction(cfg.NewScriptAction()),
+		suspiciousAction: toAction(cfg.SuspiciousScriptAction()),
+	}
+}
+
+func (a *Analyzer) Name() string {
+	return analyzerName
+}
+
+// ObserveNpmPackument records the scripts of a packument the npm interceptor
+// fetched.
+func (a *Analyzer) ObserveNpmPackument(name string, packument []byte) {
+	if err := a.store.ObservePackument(name, packument); err != nil {
+		log.Warnf("install-script analyzer: %v", err)
+	}
+}
+
+func (a *Analyzer) Analyze(_ context.Context, pkg *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	unknown := &analyzer.PackageVersionAnalysisResult{PackageVersion: pkg, Action: analyzer.ActionUnknown}
+	if pkg.GetPackage().GetEcosystem() != packagev1.Ecosystem_ECOSYSTEM_NPM {
+		return unknown, nil
+	}
+
+	versions, ok := a.store.Versions(pkg.GetPackage().GetName())
+	if !ok {
+		return unknown, nil
+	}
+
+	version := pkg.GetVersion()
+	scripts, ok := versions[version]
+	if !ok {
+		return unknown, nil
+	}
+
+	if findings := findSuspicious(scripts); len(findings) > 0 {
+		f := findings[0]
+		return &analyzer.PackageVersionAnalysisResult{
+			PackageVersion: pkg,
+			Action:         a.suspiciousAction,
+			Summary:        fmt.Sprintf("%s script %s: %s", f.script, f.description, excerpt(f.command)),
+			Heuristic:      analyzer.HeuristicInstallScript,
+		}, nil
+	}
+
+	if len(scripts) > 0 {
+		if previous, ok := previousVersion(versions, version); ok && len(versions[previous]) == 0 {
+			return &analyzer.PackageVersionAnalysisResult{
+				PackageVersion: pkg,
+				Action:         a.newScriptAction,
+				Summary: fmt.Sprintf("%s adds %s, %s had no install scripts",
+					version, strings.Join(scriptNames(scripts), ", "), previous),
+				Heuristic: analyzer.HeuristicInstallScript,
+			}, nil
+		}
+	}
+
+	return &analyzer.PackageVersionAnalysisResult{PackageVersion: pkg, Action: analyzer.ActionAllow}, nil
+}
+
+// previousVersion returns the highest version below version. A stable
+// release is compared with stable releases only, since prereleases often
+// carry build tooling the release does not.
+func previousVersion(versions map[string]map[string]string, version string) (string, bool) {
+	current, err := semver.NewVersion(version)
+	if err != nil {
+		return "", false
+	}
+
+	var previous *semver.Version
+	var previousRaw string
+	for raw := range versions {
+		v, err := semver.NewVersion(raw)
+		if err != nil || !v.LessThan(current) {
+			continue
+		}
+		if current.Prerelease() == "" && v.Prerelease() != "" {
+			continue
+		}
+		if previous == nil || v.GreaterThan(previous) {
+			previous, previousRaw = v, raw
+		}
+	}
+
+	return previousRaw, previous != nil
+}
+
+func toAction(action string) analyzer.Action {
+	switch action {
+	case config.HeuristicActionAllow:
+		return analyzer.ActionAllow
+	case config.HeuristicActionConfirm:
+		return analyzer.ActionConfirm
+	default:
+		return analyzer.ActionBlock
+	}
+}
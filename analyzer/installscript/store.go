@@ -0,0 +1,64 @@
+package installscript
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// maxStorePackages bounds the packages a long-running proxy remembers.
+const maxStorePackages = 5000
+
+// Store remembers the lifecycle scripts of every version of the npm packages
+// whose packuments the proxy has seen. It keeps only the scripts, not the
+// packuments.
+type Store struct {
+	mu       sync.RWMutex
+	packages map[string]map[string]map[string]string
+}
+
+func NewStore() *Store {
+	return &Store{packages: map[string]map[string]map[string]string{}}
+}
+
+// packument is the part of an npm packument the store reads.
+type packument struct {
+	Versions map[string]struct {
+		Scripts map[string]string `json:"scripts"`
+	} `json:"versions"`
+}
+
+// ObservePackument records the lifecycle scripts of each version listed in a
+// full (application/json) packument. An abbreviated packument carries no
+// scripts and must not be observed.
+func (s *Store) ObservePackument(name string, body []byte) error {
+	var doc packument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("failed to parse packument of %s: %w", name, err)
+	}
+
+	versions := make(map[string]map[string]string, len(doc.Versions))
+	for version, manifest := range doc.Versions {
+		versions[version] = lifecycleOnly(manifest.Scripts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.packages[name]; !ok && len(s.packages) >= maxStorePackages {
+		s.packages = map[string]map[string]map[string]string{}
+	}
+	s.packages[name] = versions
+
+	return nil
+}
+
+// Versions returns the lifecycle scripts of each known version of a package,
+// keyed by version. ok is false when no packument of the package was seen.
+func (s *Store) Versions(name string) (versions map[string]map[string]string, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions, ok = s.packages[name]
+	return versions, ok
+}
@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"context"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+)
+
+// localFeedFirstAnalyzer checks a local malware feed before the next
+// analyzer. A feed verdict of malware is final; everything else, including
+// a failed feed lookup, is left to next. The feed is checked ahead of any
+// cache next keeps, so a newly imported record takes effect immediately.
+type localFeedFirstAnalyzer struct {
+	feed PackageVersionAnalyzer
+	next PackageVersionAnalyzer
+}
+
+// NewLocalFeedFirstAnalyzer composes a local feed analyzer in front of next.
+func NewLocalFeedFirstAnalyzer(feed, next PackageVersionAnalyzer) PackageVersionAnalyzer {
+	return &localFeedFirstAnalyzer{feed: feed, next: next}
+}
+
+func (a *localFeedFirstAnalyzer) Name() string {
+	return a.feed.Name() + "+" + a.next.Name()
+}
+
+func (a *localFeedFirstAnalyzer) Analyze(ctx context.Context, pkg *packagev1.PackageVersion) (*PackageVersionAnalysisResult, error) {
+	result, err := a.feed.Analyze(ctx, pkg)
+	switch {
+	case err != nil:
+		log.Warnf("local malware feed unavailable, falling back to %s: %v", a.next.Name(), err)
+	case result.IsMalware:
+		log.Debugf("local malware feed hit: %s@%s (%s)",
+			pkg.GetPackage().GetName(), pkg.GetVersion(), result.AnalysisID)
+		return result, nil
+	}
+
+	return a.next.Analyze(ctx, pkg)
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalFeedFirstAnalyzer_FeedHitIsFinal(t *testing.T) {
+	feed := &fakePackageVersionAnalyzer{result: &PackageVersionAnalysisResult{
+		Action: ActionBlock, IsMalware: true, AnalysisID: "MAL-2024-1",
+	}}
+	next := &fakePackageVersionAnalyzer{result: &PackageVersionAnalysisResult{Action: ActionAllow}}
+
+	result, err := NewLocalFeedFirstAnalyzer(feed, next).Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, ActionBlock, result.Action)
+	assert.Equal(t, "MAL-2024-1", result.AnalysisID)
+	assert.Equal(t, int64(0), next.calls.Load())
+}
+
+func TestLocalFeedFirstAnalyzer_FallsThroughToNext(t *testing.T) {
+	tests := []struct {
+		name string
+		feed *fakePackageVersionAnalyzer
+	}{
+		{"feed miss", &fakePackageVersionAnalyzer{result: &PackageVersionAnalysisResult{Action: ActionAllow}}},
+		{"feed error", &fakePackageVersionAnalyzer{err: errors.New("db locked")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakePackageVersionAnalyzer{result: &PackageVersionAnalysisResult{
+				Action: ActionConfirm, IsMalware: true,
+			}}
+
+			result, err := NewLocalFeedFirstAnalyzer(tt.feed, next).Analyze(context.Background(), makePkgVersion("pkg", "1.0.0"))
+			require.NoError(t, err)
+			assert.Equal(t, ActionConfirm, result.Action)
+			assert.Equal(t, int64(1), next.calls.Load())
+		})
+	}
+}
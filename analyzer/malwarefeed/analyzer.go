@@ -0,0 +1,60 @@
+package malwarefeed
+
+import (
+	"context"
+	"fmt"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+)
+
+const analyzerName = "local-malware-feed"
+
+// Analyzer answers malware verdicts from the imported feed alone. A listed
+// version is blocked; anything else is allowed, since the feed only knows
+// about packages reported as malicious.
+type Analyzer struct {
+	feed *Feed
+}
+
+var _ analyzer.PackageVersionAnalyzer = (*Analyzer)(nil)
+
+func NewAnalyzer(feed *Feed) *Analyzer {
+	return &Analyzer{feed: feed}
+}
+
+func (a *Analyzer) Name() string {
+	return analyzerName
+}
+
+func (a *Analyzer) Analyze(ctx context.Context, pkg *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	entry, found, err := a.feed.Lookup(ctx, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("local malware feed lookup failed: %w", err)
+	}
+
+	if !found {
+		return &analyzer.PackageVersionAnalysisResult{
+			PackageVersion: pkg,
+			Action:         analyzer.ActionAllow,
+			Summary:        "Not listed in the local malware feed",
+		}, nil
+	}
+
+	summary := entry.Summary
+	if summary == "" {
+		summary = "Listed as malicious in the local malware feed"
+	}
+
+	// Feed records are published reports of confirmed malicious packages,
+	// so a match is treated as a verified verdict.
+	return &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: pkg,
+		AnalysisID:     entry.ID,
+		ReferenceURL:   entry.ReferenceURL,
+		Action:         analyzer.ActionBlock,
+		Summary:        summary,
+		IsMalware:      true,
+		IsVerified:     true,
+	}, nil
+}
@@ -0,0 +1,143 @@
+package malwarefeed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/localdb"
+	"github.com/safedep/pmg/analyzer/osv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeed(t *testing.T) *Feed {
+	t.Helper()
+	mgr := localdb.New(localdb.Config{Dir: t.TempDir(), FileName: "pmg.db"})
+	t.Cleanup(func() { require.NoError(t, mgr.Close()) })
+	store, err := mgr.Store(context.Background(), Descriptor())
+	require.NoError(t, err)
+	return New(store)
+}
+
+func pkg(eco packagev1.Ecosystem, name, version string) *packagev1.PackageVersion {
+	pv := &packagev1.PackageVersion{}
+	pv.SetPackage(&packagev1.Package{})
+	pv.GetPackage().SetName(name)
+	pv.GetPackage().SetEcosystem(eco)
+	pv.SetVersion(version)
+	return pv
+}
+
+func records(rs ...*osv.Record) osv.RecordReader {
+	return func(visit func(*osv.Record) error) error {
+		for _, r := range rs {
+			if err := visit(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func malRecord(id, ecosystem, name string, modified time.Time, versions ...string) *osv.Record {
+	affected := osv.Affected{Package: osv.AffectedPackage{Ecosystem: ecosystem, Name: name}, Versions: versions}
+	if len(versions) == 0 {
+		affected.Ranges = []osv.Range{{Type: "SEMVER", Events: []osv.Event{{Introduced: "0"}}}}
+	}
+	return &osv.Record{ID: id, Modified: modified, Summary: "Malicious code in " + name, Affected: []osv.Affected{affected}}
+}
+
+func TestImportAndLookup(t *testing.T) {
+	f := newTestFeed(t)
+	ctx := context.Background()
+	modified := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	result, err := f.Import(ctx, "feed.zip", records(
+		malRecord("MAL-2024-1", "npm", "evil-pkg", modified),
+		malRecord("MAL-2024-2", "PyPI", "Evil_Lib", modified, "1.0.0", "1.0.1"),
+		malRecord("MAL-2024-3", "Debian", "evil", modified),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Imported: 2, Skipped: 1}, result)
+
+	entry, found, err := f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "evil-pkg", "9.9.9"))
+	require.NoError(t, err)
+	require.True(t, found, "introduced 0 covers every version")
+	assert.Equal(t, "MAL-2024-1", entry.ID)
+	assert.Equal(t, "https://osv.dev/vulnerability/MAL-2024-1", entry.ReferenceURL)
+
+	_, found, err = f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_PYPI, "evil-lib", "1.0.1"))
+	require.NoError(t, err)
+	assert.True(t, found, "PyPI names match after normalization")
+
+	_, found, err = f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_PYPI, "evil-lib", "1.0.2"))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, found, err = f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.0.0"))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	stats, err := f.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 2, stats.Packages)
+	assert.Equal(t, "feed.zip", stats.LastSource)
+	assert.False(t, stats.LastImport.IsZero())
+}
+
+func TestImportIsIncremental(t *testing.T) {
+	f := newTestFeed(t)
+	ctx := context.Background()
+	older := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+
+	_, err := f.Import(ctx, "v1", records(malRecord("MAL-2024-1", "npm", "evil-pkg", newer, "1.0.0")))
+	require.NoError(t, err)
+
+	// An older copy of the record does not roll the stored one back.
+	result, err := f.Import(ctx, "v0", records(malRecord("MAL-2024-1", "npm", "evil-pkg", older, "2.0.0")))
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Unchanged: 1}, result)
+
+	_, found, err := f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "evil-pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// A withdrawn record removes the entry.
+	withdrawn := malRecord("MAL-2024-1", "npm", "evil-pkg", newer.Add(time.Hour), "1.0.0")
+	withdrawnAt := newer.Add(time.Hour)
+	withdrawn.Withdrawn = &withdrawnAt
+
+	result, err = f.Import(ctx, "v2", records(withdrawn))
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Withdrawn: 1}, result)
+
+	_, found, err = f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "evil-pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestAnalyzer(t *testing.T) {
+	f := newTestFeed(t)
+	ctx := context.Background()
+
+	_, err := f.Import(ctx, "feed", records(malRecord("MAL-2024-7", "Packagist", "Evil/Lib", time.Now(), "1.0.0")))
+	require.NoError(t, err)
+
+	a := NewAnalyzer(f)
+	assert.Equal(t, "local-malware-feed", a.Name())
+
+	result, err := a.Analyze(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, "evil/lib", "v1.0.0"))
+	require.NoError(t, err)
+	assert.True(t, result.IsMalware)
+	assert.True(t, result.IsVerified)
+	assert.Equal(t, "MAL-2024-7", result.AnalysisID)
+	assert.Equal(t, "Malicious code in Evil/Lib", result.Summary)
+
+	result, err = a.Analyze(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, "evil/lib", "1.0.1"))
+	require.NoError(t, err)
+	assert.False(t, result.IsMalware)
+}
@@ -0,0 +1,26 @@
+package analyzer
+
+// NpmPackumentObserver is implemented by analyzers that judge npm packages
+// from their packument. The npm interceptor hands every packument it fetches
+// to the observers of its analyzer, before the tarballs it lists are
+// analyzed.
+type NpmPackumentObserver interface {
+	ObserveNpmPackument(name string, packument []byte)
+}
+
+// NpmPackumentObservers returns the analyzers within a, a itself included,
+// that observe npm packuments.
+func NpmPackumentObservers(a PackageVersionAnalyzer) []NpmPackumentObserver {
+	var observers []NpmPackumentObserver
+	if observer, ok := a.(NpmPackumentObserver); ok {
+		observers = append(observers, observer)
+	}
+
+	if composite, ok := a.(*CompositeAnalyzer); ok {
+		for _, child := range composite.analyzers {
+			observers = append(observers, NpmPackumentObservers(child)...)
+		}
+	}
+
+	return observers
+}
@@ -0,0 +1,186 @@
+// Package osv reads OSV-format advisory records, as published by osv.dev and
+// OpenSSF's malicious-packages repository, and matches package versions
+// against their affected ranges. The local feeds store what they need of a
+// record; this package owns only the format.
+package osv
+
+import (
+	"strings"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+)
+
+// Record is the subset of an OSV record the local feeds store. OpenSSF's
+// malicious-packages repository publishes one MAL-* record per malicious
+// package; osv.dev exports vulnerabilities and malware in the same format.
+type Record struct {
+	ID        string     `json:"id"`
+	Modified  time.Time  `json:"modified"`
+	Withdrawn *time.Time `json:"withdrawn,omitempty"`
+	Aliases   []string   `json:"aliases"`
+	Summary   string     `json:"summary"`
+	Details   string     `json:"details"`
+
+	Severity         []Severity       `json:"severity"`
+	DatabaseSpecific DatabaseSpecific `json:"database_specific"`
+
+	Affected   []Affected  `json:"affected"`
+	References []Reference `json:"references"`
+}
+
+type Affected struct {
+	Package  AffectedPackage `json:"package"`
+	Ranges   []Range         `json:"ranges"`
+	Versions []string        `json:"versions"`
+}
+
+type AffectedPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// Severity is a scored severity, such as a CVSS vector.
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// DatabaseSpecific holds the fields of database_specific the feeds read.
+// GitHub advisories carry a qualitative severity there.
+type DatabaseSpecific struct {
+	Severity string `json:"severity"`
+}
+
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ReferenceURL returns the link shown for a record: its first reference, or
+// the record on osv.dev.
+func (r *Record) ReferenceURL() string {
+	for _, ref := range r.References {
+		if ref.URL != "" {
+			return ref.URL
+		}
+	}
+	return "https://osv.dev/vulnerability/" + r.ID
+}
+
+// Ecosystems maps OSV ecosystem names to the ecosystems PMG analyzes.
+// Records for any other ecosystem are skipped on import.
+var Ecosystems = map[string]packagev1.Ecosystem{
+	"npm":       packagev1.Ecosystem_ECOSYSTEM_NPM,
+	"PyPI":      packagev1.Ecosystem_ECOSYSTEM_PYPI,
+	"Go":        packagev1.Ecosystem_ECOSYSTEM_GO,
+	"crates.io": packagev1.Ecosystem_ECOSYSTEM_CARGO,
+	"RubyGems":  packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS,
+	"Maven":     packagev1.Ecosystem_ECOSYSTEM_MAVEN,
+	"NuGet":     packagev1.Ecosystem_ECOSYSTEM_NUGET,
+	"Packagist": packagev1.Ecosystem_ECOSYSTEM_PACKAGIST,
+}
+
+// NormalizeName returns the form a package name is stored and looked up in,
+// so feed records match however a registry or package manager spells it.
+func NormalizeName(ecosystem packagev1.Ecosystem, name string) string {
+	switch ecosystem {
+	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
+		// PEP 503: runs of -, _ and . are equivalent.
+		name = strings.ToLower(name)
+		return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		}), "-")
+	case packagev1.Ecosystem_ECOSYSTEM_CARGO:
+		return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	case packagev1.Ecosystem_ECOSYSTEM_NUGET, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST:
+		return strings.ToLower(name)
+	default:
+		return name
+	}
+}
+
+// VersionsEqual compares versions ignoring case and a leading "v", which
+// registries and lock files apply inconsistently.
+func VersionsEqual(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v"))
+}
+
+// Span is one introduced..fixed or introduced..last_affected span of an OSV
+// range.
+type Span struct {
+	Introduced   string
+	Fixed        string
+	LastAffected string
+}
+
+// RangeSpans pairs the events of an OSV range into spans. Events are
+// listed in order; each introduced starts a span the next fixed or
+// last_affected closes.
+func RangeSpans(r Range) []Span {
+	var spans []Span
+	var open *Span
+	for _, event := range r.Events {
+		switch {
+		case event.Introduced != "":
+			if open != nil {
+				spans = append(spans, *open)
+			}
+			open = &Span{Introduced: event.Introduced}
+		case event.Fixed != "" && open != nil:
+			open.Fixed = event.Fixed
+			spans = append(spans, *open)
+			open = nil
+		case event.LastAffected != "" && open != nil:
+			open.LastAffected = event.LastAffected
+			spans = append(spans, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		spans = append(spans, *open)
+	}
+	return spans
+}
+
+// Contains reports whether version of a package in ecosystem falls in the
+// span. A span introduced at "0" with no upper bound covers every version,
+// which is how most malicious package records are written. Other bounds are
+// compared in the ecosystem's own version order; a version that does not
+// parse is not matched, leaving it to the record's explicit versions list.
+func (s Span) Contains(ecosystem packagev1.Ecosystem, version string) bool {
+	unbounded := s.Fixed == "" && s.LastAffected == ""
+	if (s.Introduced == "0" || s.Introduced == "") && unbounded {
+		return true
+	}
+
+	if s.Introduced != "0" && s.Introduced != "" {
+		c, ok := compareVersions(ecosystem, version, s.Introduced)
+		if !ok || c < 0 {
+			return false
+		}
+	}
+
+	if s.Fixed != "" {
+		c, ok := compareVersions(ecosystem, version, s.Fixed)
+		return ok && c < 0
+	}
+
+	if s.LastAffected != "" {
+		c, ok := compareVersions(ecosystem, version, s.LastAffected)
+		return ok && c <= 0
+	}
+
+	return true
+}
@@ -0,0 +1,144 @@
+package osv
+
+import (
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	cases := []struct {
+		ecosystem packagev1.Ecosystem
+		in, want  string
+	}{
+		{packagev1.Ecosystem_ECOSYSTEM_PYPI, "Typing_Extensions", "typing-extensions"},
+		{packagev1.Ecosystem_ECOSYSTEM_PYPI, "zope.interface", "zope-interface"},
+		{packagev1.Ecosystem_ECOSYSTEM_CARGO, "Serde_JSON", "serde-json"},
+		{packagev1.Ecosystem_ECOSYSTEM_NUGET, "Newtonsoft.Json", "newtonsoft.json"},
+		{packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, "Symfony/Console", "symfony/console"},
+		{packagev1.Ecosystem_ECOSYSTEM_NPM, "@Scope/pkg", "@Scope/pkg"},
+		{packagev1.Ecosystem_ECOSYSTEM_MAVEN, "org.example:Lib", "org.example:Lib"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, NormalizeName(tc.ecosystem, tc.in), tc.in)
+	}
+}
+
+func TestRangeSpans(t *testing.T) {
+	spans := RangeSpans(Range{Type: "SEMVER", Events: []Event{
+		{Introduced: "0"}, {Fixed: "1.2.0"},
+		{Introduced: "2.0.0"}, {LastAffected: "2.0.3"},
+		{Introduced: "3.0.0"},
+	}})
+
+	assert.Equal(t, []Span{
+		{Introduced: "0", Fixed: "1.2.0"},
+		{Introduced: "2.0.0", LastAffected: "2.0.3"},
+		{Introduced: "3.0.0"},
+	}, spans)
+}
+
+func TestSpanContains(t *testing.T) {
+	cases := []struct {
+		name    string
+		span    Span
+		version string
+		want    bool
+	}{
+		{"all versions", Span{Introduced: "0"}, "not-semver", true},
+		{"below fixed", Span{Introduced: "0", Fixed: "1.2.0"}, "1.1.9", true},
+		{"at fixed", Span{Introduced: "0", Fixed: "1.2.0"}, "1.2.0", false},
+		{"at last affected", Span{Introduced: "2.0.0", LastAffected: "2.0.3"}, "2.0.3", true},
+		{"before introduced", Span{Introduced: "2.0.0", LastAffected: "2.0.3"}, "1.9.0", false},
+		{"open upper bound", Span{Introduced: "3.0.0"}, "4.1.0", true},
+		{"unparseable version with bound", Span{Introduced: "0", Fixed: "1.0.0"}, "dev-main", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.span.Contains(packagev1.Ecosystem_ECOSYSTEM_NPM, tc.version))
+		})
+	}
+}
+
+func TestSpanContains_PyPI(t *testing.T) {
+	cases := []struct {
+		span    Span
+		version string
+		want    bool
+	}{
+		{Span{Introduced: "0", Fixed: "2.0.0rc1"}, "2.0.0b3", true},
+		{Span{Introduced: "0", Fixed: "2.0.0rc1"}, "2.0.0", false},
+		{Span{Introduced: "0", Fixed: "2.0.0a1"}, "2.0.0.dev1", true},
+		{Span{Introduced: "1.0", Fixed: "1.1"}, "1.0.post1", true},
+		{Span{Introduced: "1.0", Fixed: "1.1"}, "1.0.0", true},
+		{Span{Introduced: "1.0", Fixed: "1.1"}, "1.1.0", false},
+		{Span{Introduced: "1.0", Fixed: "1.1"}, "1!0.5", false},
+		{Span{Introduced: "2.4", LastAffected: "2.31"}, "2.30.0+ubuntu1", true},
+		{Span{Introduced: "2.4", LastAffected: "2.31"}, "2.31.0+ubuntu1", false},
+		{Span{Introduced: "2.4", LastAffected: "2.31"}, "2.10", true},
+		{Span{Introduced: "0", Fixed: "1.0"}, "not a version", false},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, tc.span.Contains(packagev1.Ecosystem_ECOSYSTEM_PYPI, tc.version), tc.version)
+	}
+}
+
+func TestSpanContains_Maven(t *testing.T) {
+	cases := []struct {
+		span    Span
+		version string
+		want    bool
+	}{
+		{Span{Introduced: "0", Fixed: "1.0"}, "1.0-SNAPSHOT", true},
+		{Span{Introduced: "0", Fixed: "1.0"}, "1.0.RELEASE", false},
+		{Span{Introduced: "0", Fixed: "1.0"}, "1.0.0", false},
+		{Span{Introduced: "2.0-m1", Fixed: "2.0"}, "2.0-rc1", true},
+		{Span{Introduced: "2.0-m1", Fixed: "2.0"}, "2.0-alpha-1", false},
+		{Span{Introduced: "2.0", Fixed: "2.0.1"}, "2.0-sp1", true},
+		{Span{Introduced: "2.13.0", Fixed: "2.13.4.2"}, "2.13.4.1", true},
+		{Span{Introduced: "2.13.0", Fixed: "2.13.4.2"}, "2.13.10", false},
+		{Span{Introduced: "5.3.0", LastAffected: "5.3.17"}, "5.3.17.RELEASE", true},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, tc.span.Contains(packagev1.Ecosystem_ECOSYSTEM_MAVEN, tc.version), tc.version)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		ecosystem packagev1.Ecosystem
+		a, b      string
+		want      int
+	}{
+		{packagev1.Ecosystem_ECOSYSTEM_NUGET, "1.2.3.4", "1.2.3", 1},
+		{packagev1.Ecosystem_ECOSYSTEM_NUGET, "1.2.3-Beta.2", "1.2.3-beta.10", -1},
+		{packagev1.Ecosystem_ECOSYSTEM_NUGET, "1.2.3-rc.1", "1.2.3+build", -1},
+		{packagev1.Ecosystem_ECOSYSTEM_NUGET, "1.2", "1.2.0.0", 0},
+		{packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, "1.0.0.rc1", "1.0.0", -1},
+		{packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, "1.0.0-beta", "1.0.0.a", 1},
+		{packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, "7.0.8.1", "7.0.10", -1},
+		{packagev1.Ecosystem_ECOSYSTEM_PYPI, "1.0rc1", "1.0c1", 0},
+		{packagev1.Ecosystem_ECOSYSTEM_PYPI, "1.0.dev1", "1.0a1.dev1", -1},
+		{packagev1.Ecosystem_ECOSYSTEM_PYPI, "1.0.post1.dev1", "1.0.post1", -1},
+		{packagev1.Ecosystem_ECOSYSTEM_MAVEN, "1.0-alpha1", "1.0-a1", 0},
+		{packagev1.Ecosystem_ECOSYSTEM_MAVEN, "1.0-cr1", "1.0-rc1", 0},
+		{packagev1.Ecosystem_ECOSYSTEM_CARGO, "1.0.0-alpha", "1.0.0", -1},
+	}
+
+	for _, tc := range cases {
+		got, ok := compareVersions(tc.ecosystem, tc.a, tc.b)
+		assert.True(t, ok, tc.a)
+		assert.Equal(t, tc.want, got, "%s %s %s", tc.ecosystem, tc.a, tc.b)
+	}
+}
+
+func TestVersionsEqual(t *testing.T) {
+	assert.True(t, VersionsEqual("v1.2.3", "1.2.3"))
+	assert.True(t, VersionsEqual("1.0.0-Beta", "1.0.0-beta"))
+	assert.False(t, VersionsEqual("1.2.3", "1.2.4"))
+}
@@ -0,0 +1,135 @@
+package osv
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/safedep/dry/log"
+)
+
+// maxRecordSize bounds a single OSV record file. Records are a few KB;
+// anything larger is not a record.
+const maxRecordSize = 4 << 20
+
+// RecordReader calls visit for every record of a feed. It stops at and
+// returns the first error visit returns.
+type RecordReader func(visit func(*Record) error) error
+
+// Open returns a reader for the feed at path: a directory tree of OSV JSON
+// files, such as a checkout of OpenSSF's malicious-packages repository, a
+// zip of one, such as an osv.dev ecosystem export, or a single JSON file.
+func Open(path string) (RecordReader, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case info.IsDir():
+		return readDir(path), nil
+	case strings.EqualFold(filepath.Ext(path), ".zip"):
+		return readZip(path), nil
+	case strings.EqualFold(filepath.Ext(path), ".json"):
+		return readFile(path), nil
+	default:
+		return nil, fmt.Errorf("%s is not a directory, .zip or .json file", path)
+	}
+}
+
+func readDir(root string) RecordReader {
+	return func(visit func(*Record) error) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// Skip .git and other hidden directories of a checkout.
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !isRecordFile(d.Name()) {
+				return nil
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = file.Close() }()
+
+			return visitRecord(path, file, visit)
+		})
+	}
+}
+
+func readZip(path string) RecordReader {
+	return func(visit func(*Record) error) error {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = archive.Close() }()
+
+		for _, entry := range archive.File {
+			if entry.FileInfo().IsDir() || !isRecordFile(filepath.Base(entry.Name)) {
+				continue
+			}
+
+			if err := visitZipEntry(entry, visit); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func visitZipEntry(entry *zip.File, visit func(*Record) error) error {
+	file, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", entry.Name, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return visitRecord(entry.Name, file, visit)
+}
+
+func readFile(path string) RecordReader {
+	return func(visit func(*Record) error) error {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+
+		return visitRecord(path, file, visit)
+	}
+}
+
+func isRecordFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json") && !strings.HasPrefix(name, ".")
+}
+
+// visitRecord decodes one OSV record. Files that are not OSV records, such
+// as package.json files in a checkout, are logged and skipped rather than
+// failing the whole import.
+func visitRecord(name string, r io.Reader, visit func(*Record) error) error {
+	var record Record
+	if err := json.NewDecoder(io.LimitReader(r, maxRecordSize)).Decode(&record); err != nil {
+		log.Warnf("osv: skipping %s: %v", name, err)
+		return nil
+	}
+	if record.ID == "" || record.Modified.IsZero() {
+		log.Debugf("osv: skipping %s: not an OSV record", name)
+		return nil
+	}
+
+	return visit(&record)
+}
@@ -0,0 +1,76 @@
+package osv
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecordJSON = `{
+  "id": "MAL-2024-1",
+  "modified": "2024-05-01T00:00:00Z",
+  "summary": "Malicious code in evil-pkg (npm)",
+  "affected": [{"package": {"ecosystem": "npm", "name": "evil-pkg"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]}]
+}`
+
+func collect(t *testing.T, read RecordReader) []string {
+	t.Helper()
+	var ids []string
+	require.NoError(t, read(func(r *Record) error {
+		ids = append(ids, r.ID)
+		return nil
+	}))
+	return ids
+}
+
+func TestOpenDirectory(t *testing.T) {
+	dir := t.TempDir()
+	recordDir := filepath.Join(dir, "osv", "malicious", "npm", "evil-pkg")
+	require.NoError(t, os.MkdirAll(recordDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(recordDir, "MAL-2024-1.json"), []byte(testRecordJSON), 0o644))
+
+	// Non-record JSON and hidden directories are skipped.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "tooling"}`), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "MAL-2024-9.json"), []byte(testRecordJSON), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{`), 0o644))
+
+	read, err := Open(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MAL-2024-1"}, collect(t, read))
+}
+
+func TestOpenZip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "npm.zip")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	archive := zip.NewWriter(file)
+	w, err := archive.Create("MAL-2024-1.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(testRecordJSON))
+	require.NoError(t, err)
+	_, err = archive.Create("docs/")
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	require.NoError(t, file.Close())
+
+	read, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MAL-2024-1"}, collect(t, read))
+}
+
+func TestOpenRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.tar.gz")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	_, err := Open(path)
+	assert.Error(t, err)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
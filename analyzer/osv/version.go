@@ -0,0 +1,503 @@
+package osv
+
+import (
+	"regexp"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/Masterminds/semver"
+)
+
+// compareVersions orders a and b the way ecosystem orders its versions, as
+// OSV ECOSYSTEM ranges require. ok is false when either does not parse as a
+// version of the ecosystem; such a version is neither inside nor outside a
+// range.
+func compareVersions(ecosystem packagev1.Ecosystem, a, b string) (int, bool) {
+	switch ecosystem {
+	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
+		return comparePEP440(a, b)
+	case packagev1.Ecosystem_ECOSYSTEM_MAVEN:
+		return compareMaven(a, b)
+	case packagev1.Ecosystem_ECOSYSTEM_NUGET:
+		return compareNuGet(a, b)
+	case packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS:
+		return compareGem(a, b)
+	default:
+		return compareSemver(a, b)
+	}
+}
+
+// compareSemver compares npm, crates.io, Go and Packagist versions.
+func compareSemver(a, b string) (int, bool) {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return 0, false
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return 0, false
+	}
+	return va.Compare(vb), true
+}
+
+// compareNumeric compares two runs of decimal digits by value. Versions may
+// carry numbers wider than an int, such as dates.
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareInt(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pep440Pattern is the version scheme of PEP 440, as packaging implements
+// it, including the alternative spellings it normalizes.
+var pep440Pattern = regexp.MustCompile(`(?i)^\s*v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(?P<pre_l>alpha|beta|preview|pre|rc|a|b|c)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?\s*$`)
+
+// pep440Version is a parsed PEP 440 version. Absent pre, post and dev parts
+// have rank pep440Absent.
+type pep440Version struct {
+	epoch   string
+	release []string
+	preRank int
+	pre     string
+	post    string
+	hasPost bool
+	dev     string
+	hasDev  bool
+	local   []string
+}
+
+// Pre-release ranks. A release without a pre-release sorts after all of
+// them; a dev release of it sorts before all of them.
+const (
+	pep440DevOnly = -1
+	pep440Alpha   = 0
+	pep440Beta    = 1
+	pep440RC      = 2
+	pep440Absent  = 3
+)
+
+func parsePEP440(version string) (pep440Version, bool) {
+	match := pep440Pattern.FindStringSubmatch(version)
+	if match == nil {
+		return pep440Version{}, false
+	}
+	group := func(name string) string {
+		return match[pep440Pattern.SubexpIndex(name)]
+	}
+
+	v := pep440Version{epoch: group("epoch"), release: strings.Split(group("release"), ".")}
+	for len(v.release) > 1 && strings.TrimLeft(v.release[len(v.release)-1], "0") == "" {
+		v.release = v.release[:len(v.release)-1]
+	}
+
+	switch strings.ToLower(group("pre_l")) {
+	case "a", "alpha":
+		v.preRank = pep440Alpha
+	case "b", "beta":
+		v.preRank = pep440Beta
+	case "c", "rc", "pre", "preview":
+		v.preRank = pep440RC
+	default:
+		v.preRank = pep440Absent
+	}
+	v.pre = group("pre_n")
+
+	switch {
+	case group("post_n1") != "":
+		v.post, v.hasPost = group("post_n1"), true
+	case group("post_l") != "":
+		v.post, v.hasPost = group("post_n2"), true
+	}
+
+	if group("dev_l") != "" {
+		v.dev, v.hasDev = group("dev_n"), true
+		if v.preRank == pep440Absent && !v.hasPost {
+			v.preRank = pep440DevOnly
+		}
+	}
+
+	if local := group("local"); local != "" {
+		v.local = strings.FieldsFunc(strings.ToLower(local), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+
+	return v, true
+}
+
+// comparePEP440 compares PyPI versions by the rules of PEP 440.
+func comparePEP440(a, b string) (int, bool) {
+	va, ok := parsePEP440(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := parsePEP440(b)
+	if !ok {
+		return 0, false
+	}
+
+	if c := compareNumeric(va.epoch, vb.epoch); c != 0 {
+		return c, true
+	}
+	for i := 0; i < max(len(va.release), len(vb.release)); i++ {
+		if c := compareNumeric(segmentAt(va.release, i), segmentAt(vb.release, i)); c != 0 {
+			return c, true
+		}
+	}
+	if c := compareInt(va.preRank, vb.preRank); c != 0 {
+		return c, true
+	}
+	if c := compareNumeric(va.pre, vb.pre); c != 0 {
+		return c, true
+	}
+	if va.hasPost != vb.hasPost {
+		return boolOrder(va.hasPost), true
+	}
+	if c := compareNumeric(va.post, vb.post); c != 0 {
+		return c, true
+	}
+	// A dev release sorts before the release it leads up to.
+	if va.hasDev != vb.hasDev {
+		return -boolOrder(va.hasDev), true
+	}
+	if c := compareNumeric(va.dev, vb.dev); c != 0 {
+		return c, true
+	}
+	return compareLocal(va.local, vb.local), true
+}
+
+// compareLocal orders PEP 440 local version labels. Numeric segments sort
+// after alphanumeric ones; a longer label sorts after its prefix.
+func compareLocal(a, b []string) int {
+	for i := 0; i < min(len(a), len(b)); i++ {
+		aNum, bNum := isDigits(a[i]), isDigits(b[i])
+		switch {
+		case aNum && bNum:
+			if c := compareNumeric(a[i], b[i]); c != 0 {
+				return c
+			}
+		case aNum != bNum:
+			return boolOrder(aNum)
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(a), len(b))
+}
+
+func segmentAt(segments []string, i int) string {
+	if i < len(segments) {
+		return segments[i]
+	}
+	return "0"
+}
+
+// boolOrder is 1 when a is true, otherwise -1; callers have already checked
+// that the two sides differ.
+func boolOrder(a bool) int {
+	if a {
+		return 1
+	}
+	return -1
+}
+
+// mavenToken is one item of a Maven version: a number or a qualifier, and
+// the separator before it.
+type mavenToken struct {
+	separator byte
+	number    string
+	qualifier string
+	isNumber  bool
+}
+
+// mavenQualifierRanks orders the well-known Maven qualifiers. The empty
+// qualifier is a release; unknown qualifiers sort after sp, alphabetically.
+var mavenQualifierRanks = map[string]int{
+	"alpha":     0,
+	"beta":      1,
+	"milestone": 2,
+	"rc":        3,
+	"snapshot":  4,
+	"":          5,
+	"sp":        6,
+}
+
+// parseMaven splits a Maven version into tokens as ComparableVersion does:
+// at '.' and '-', and where digits and letters meet.
+func parseMaven(version string) []mavenToken {
+	version = strings.ToLower(strings.TrimSpace(version))
+
+	var tokens []mavenToken
+	separator := byte('.')
+	start := 0
+	flush := func(end int) {
+		text := version[start:end]
+		token := mavenToken{separator: separator}
+		if isDigits(text) {
+			token.number, token.isNumber = text, true
+		} else {
+			token.qualifier = text
+		}
+		tokens = append(tokens, token)
+	}
+
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.' || c == '-':
+			flush(i)
+			separator, start = c, i+1
+		case i > start && isDigitByte(c) != isDigitByte(version[i-1]):
+			flush(i)
+			separator, start = '-', i
+		}
+	}
+	flush(len(version))
+
+	for i, token := range tokens {
+		if token.isNumber {
+			continue
+		}
+		qualifier := token.qualifier
+		// a1, b2 and m3 are short for alpha-1, beta-2 and milestone-3.
+		if next := i + 1; next < len(tokens) && tokens[next].isNumber && tokens[next].separator == '-' {
+			switch qualifier {
+			case "a":
+				qualifier = "alpha"
+			case "b":
+				qualifier = "beta"
+			case "m":
+				qualifier = "milestone"
+			}
+		}
+		switch qualifier {
+		case "ga", "final", "release":
+			qualifier = ""
+		case "cr":
+			qualifier = "rc"
+		}
+		tokens[i].qualifier = qualifier
+	}
+
+	// Trailing zeros and release qualifiers do not change the version:
+	// 1.0.0 is 1 and 1.0-ga is 1.0.
+	for len(tokens) > 1 && isMavenNull(tokens[len(tokens)-1]) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isMavenNull(token mavenToken) bool {
+	if token.isNumber {
+		return strings.TrimLeft(token.number, "0") == ""
+	}
+	return token.qualifier == ""
+}
+
+// compareMaven compares Maven versions the way Maven's ComparableVersion
+// orders them. Any string is a Maven version, so it only fails on an empty
+// one.
+func compareMaven(a, b string) (int, bool) {
+	if strings.TrimSpace(a) == "" || strings.TrimSpace(b) == "" {
+		return 0, false
+	}
+
+	ta, tb := parseMaven(a), parseMaven(b)
+	for i := 0; i < max(len(ta), len(tb)); i++ {
+		var c int
+		switch {
+		case i >= len(ta):
+			c = -compareMavenToNull(tb[i])
+		case i >= len(tb):
+			c = compareMavenToNull(ta[i])
+		default:
+			c = compareMavenTokens(ta[i], tb[i])
+		}
+		if c != 0 {
+			return c, true
+		}
+	}
+	return 0, true
+}
+
+// compareMavenToNull compares a token with a missing one, which counts as 0
+// or as a release.
+func compareMavenToNull(token mavenToken) int {
+	if token.isNumber {
+		return compareNumeric(token.number, "0")
+	}
+	return compareMavenQualifiers(token.qualifier, "")
+}
+
+func compareMavenTokens(a, b mavenToken) int {
+	switch {
+	case a.isNumber && b.isNumber:
+		return compareNumeric(a.number, b.number)
+	case a.isNumber != b.isNumber:
+		// A number sorts after a qualifier: 1.0.1 > 1.0-sp.
+		return boolOrder(a.isNumber)
+	default:
+		return compareMavenQualifiers(a.qualifier, b.qualifier)
+	}
+}
+
+func compareMavenQualifiers(a, b string) int {
+	rankA, knownA := mavenQualifierRanks[a]
+	rankB, knownB := mavenQualifierRanks[b]
+	switch {
+	case knownA && knownB:
+		return compareInt(rankA, rankB)
+	case knownA != knownB:
+		return -boolOrder(knownA)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// compareNuGet compares NuGet versions: up to four numeric parts, then an
+// optional SemVer 2 pre-release compared case-insensitively. Build metadata
+// is ignored.
+func compareNuGet(a, b string) (int, bool) {
+	releaseA, preA, ok := parseNuGet(a)
+	if !ok {
+		return 0, false
+	}
+	releaseB, preB, ok := parseNuGet(b)
+	if !ok {
+		return 0, false
+	}
+
+	for i := 0; i < 4; i++ {
+		if c := compareNumeric(segmentAt(releaseA, i), segmentAt(releaseB, i)); c != 0 {
+			return c, true
+		}
+	}
+
+	switch {
+	case preA == "" && preB == "":
+		return 0, true
+	case preA == "" || preB == "":
+		return boolOrder(preA == ""), true
+	}
+	return comparePrerelease(strings.Split(preA, "."), strings.Split(preB, ".")), true
+}
+
+func parseNuGet(version string) (release []string, prerelease string, ok bool) {
+	version, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(version)), "+")
+	version, prerelease, _ = strings.Cut(version, "-")
+
+	release = strings.Split(version, ".")
+	if len(release) > 4 {
+		return nil, "", false
+	}
+	for _, part := range release {
+		if !isDigits(part) {
+			return nil, "", false
+		}
+	}
+	return release, prerelease, true
+}
+
+// comparePrerelease compares SemVer 2 pre-release identifiers: numeric ones
+// by value and before alphanumeric ones.
+func comparePrerelease(a, b []string) int {
+	for i := 0; i < min(len(a), len(b)); i++ {
+		aNum, bNum := isDigits(a[i]), isDigits(b[i])
+		switch {
+		case aNum && bNum:
+			if c := compareNumeric(a[i], b[i]); c != 0 {
+				return c
+			}
+		case aNum != bNum:
+			return -boolOrder(aNum)
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(a), len(b))
+}
+
+var (
+	gemVersionPattern = regexp.MustCompile(`^[0-9]+(?:\.[0-9a-zA-Z]+)*(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+	gemSegmentPattern = regexp.MustCompile(`[0-9]+|[a-z]+`)
+)
+
+// compareGem compares RubyGems versions as Gem::Version does. A letter
+// segment marks a pre-release and sorts before any number.
+func compareGem(a, b string) (int, bool) {
+	sa, ok := gemSegments(a)
+	if !ok {
+		return 0, false
+	}
+	sb, ok := gemSegments(b)
+	if !ok {
+		return 0, false
+	}
+
+	for i := 0; i < max(len(sa), len(sb)); i++ {
+		left, right := segmentAt(sa, i), segmentAt(sb, i)
+		leftNum, rightNum := isDigits(left), isDigits(right)
+		switch {
+		case leftNum && rightNum:
+			if c := compareNumeric(left, right); c != 0 {
+				return c, true
+			}
+		case leftNum != rightNum:
+			return boolOrder(leftNum), true
+		default:
+			if c := strings.Compare(left, right); c != 0 {
+				return c, true
+			}
+		}
+	}
+	return 0, true
+}
+
+func gemSegments(version string) ([]string, bool) {
+	version = strings.TrimSpace(version)
+	if !gemVersionPattern.MatchString(version) {
+		return nil, false
+	}
+	version = strings.ReplaceAll(strings.ToLower(version), "-", ".pre.")
+	return gemSegmentPattern.FindAllString(version, -1), true
+}
@@ -0,0 +1,242 @@
+// Package plugin runs out-of-process analyzers: executables or local services
+// that answer a package version with a verdict over a small JSON protocol. It
+// lets teams plug their own package intelligence, such as an internal
+// allow/deny service, into PMG without changing PMG.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/sony/gobreaker/v2"
+)
+
+const (
+	defaultTimeout  = 5 * time.Second
+	defaultCacheTTL = 10 * time.Minute
+
+	// breakerCooldown is how long a tripped plugin is skipped before PMG
+	// tries it again, matching the interceptors' analyzer circuit breaker.
+	breakerCooldown = 30 * time.Second
+
+	// maxCacheEntries bounds the verdict cache of a long-running proxy.
+	maxCacheEntries = 10000
+)
+
+// Analyzer wraps a plugin as a PackageVersionAnalyzer. Each request is bounded
+// by the plugin timeout, verdicts are cached per package version, and a
+// circuit breaker stops calling a plugin that keeps failing.
+type Analyzer struct {
+	name      string
+	transport transport
+	timeout   time.Duration
+	breaker   *gobreaker.CircuitBreaker[*analyzer.PackageVersionAnalysisResult]
+	cache     *verdictCache
+}
+
+var _ analyzer.PackageVersionAnalyzer = (*Analyzer)(nil)
+
+// New creates the analyzer for a configured plugin. It does not contact the
+// plugin; use Health for that.
+func New(cfg config.AnalyzerPluginConfig) (*Analyzer, error) {
+	name := strings.TrimSpace(cfg.Name)
+	switch name {
+	case "":
+		return nil, fmt.Errorf("analyzer plugin has no name")
+	case config.AnalyzerMalysis, config.AnalyzerLocalFeed, config.AnalyzerInstallScripts, config.AnalyzerTyposquat:
+		return nil, fmt.Errorf("analyzer plugin %q: name is reserved for a built-in analyzer", name)
+	}
+
+	var t transport
+	switch {
+	case cfg.Command != "" && cfg.Socket != "":
+		return nil, fmt.Errorf("analyzer plugin %q: set either command or socket, not both", name)
+	case cfg.Command != "":
+		t = &commandTransport{command: cfg.Command, args: cfg.Args}
+	case cfg.Socket != "":
+		t = newSocketTransport(cfg.Socket)
+	default:
+		return nil, fmt.Errorf("analyzer plugin %q: command or socket is required", name)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	var cache *verdictCache
+	switch {
+	case cfg.CacheTTL == 0:
+		cache = newVerdictCache(defaultCacheTTL)
+	case cfg.CacheTTL > 0:
+		cache = newVerdictCache(cfg.CacheTTL)
+	}
+
+	return &Analyzer{
+		name:      name,
+		transport: t,
+		timeout:   timeout,
+		cache:     cache,
+		breaker: gobreaker.NewCircuitBreaker[*analyzer.PackageVersionAnalysisResult](gobreaker.Settings{
+			Name:        "analyzer-plugin-" + name,
+			MaxRequests: 1,
+			Timeout:     breakerCooldown,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= 3
+			},
+			OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+				log.Infof("Circuit breaker %s: %s -> %s", name, from, to)
+			},
+		}),
+	}, nil
+}
+
+func (a *Analyzer) Name() string {
+	return "plugin:" + a.name
+}
+
+func (a *Analyzer) Analyze(ctx context.Context, pkg *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	key := cacheKey(pkg)
+	if result, ok := a.cache.get(key); ok {
+		log.Debugf("plugin %s cache hit: %s", a.name, key)
+		return result, nil
+	}
+
+	result, err := a.breaker.Execute(func() (*analyzer.PackageVersionAnalysisResult, error) {
+		return a.query(ctx, pkg)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("analyzer plugin %s: %w", a.name, err)
+	}
+
+	a.cache.set(key, result)
+	return result, nil
+}
+
+func (a *Analyzer) query(ctx context.Context, pkg *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	request, err := newAnalyzeRequest(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	raw, err := a.transport.roundTrip(ctx, MethodAnalyze, request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := parseResponse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := toAction(resp.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	return &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: pkg,
+		AnalysisID:     resp.AnalysisID,
+		ReferenceURL:   resp.ReferenceURL,
+		Action:         action,
+		Summary:        resp.Summary,
+		IsMalware:      resp.IsMalware,
+		IsVerified:     resp.IsVerified,
+	}, nil
+}
+
+// Health sends the plugin a health request. It bypasses the cache and the
+// circuit breaker, so it reports the plugin's current state.
+func (a *Analyzer) Health(ctx context.Context) error {
+	request, err := newHealthRequest()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	raw, err := a.transport.roundTrip(ctx, MethodHealth, request)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseResponse(raw)
+	return err
+}
+
+func cacheKey(pkg *packagev1.PackageVersion) string {
+	return pkg.GetPackage().GetEcosystem().String() + "/" + pkg.GetPackage().GetName() + "@" + pkg.GetVersion()
+}
+
+// verdictCache keeps plugin verdicts in memory for a TTL. A nil cache never
+// hits.
+type verdictCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]verdictCacheEntry
+}
+
+type verdictCacheEntry struct {
+	result  *analyzer.PackageVersionAnalysisResult
+	expires time.Time
+}
+
+func newVerdictCache(ttl time.Duration) *verdictCache {
+	return &verdictCache{ttl: ttl, now: time.Now, entries: map[string]verdictCacheEntry{}}
+}
+
+func (c *verdictCache) get(key string) (*analyzer.PackageVersionAnalysisResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.result, true
+}
+
+func (c *verdictCache) set(key string, result *analyzer.PackageVersionAnalysisResult) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			c.entries = map[string]verdictCacheEntry{}
+		}
+	}
+
+	c.entries[key] = verdictCacheEntry{result: result, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,268 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func npmPackage(name, version string) *packagev1.PackageVersion {
+	return &packagev1.PackageVersion{
+		Package: &packagev1.Package{
+			Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM,
+			Name:      name,
+		},
+		Version: version,
+	}
+}
+
+// shellPlugin configures a command plugin that runs script with sh.
+func shellPlugin(t *testing.T, script string) config.AnalyzerPluginConfig {
+	t.Helper()
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+
+	return config.AnalyzerPluginConfig{Name: "test", Command: "/bin/sh", Args: []string{"-c", script}}
+}
+
+// fakeTransport answers every request with response and records requests.
+type fakeTransport struct {
+	calls    atomic.Int64
+	requests [][]byte
+	response string
+	err      error
+}
+
+func (f *fakeTransport) roundTrip(_ context.Context, _ string, request []byte) ([]byte, error) {
+	f.calls.Add(1)
+	f.requests = append(f.requests, request)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte(f.response), nil
+}
+
+func newTestAnalyzer(t *testing.T, transport transport, cacheTTL time.Duration) *Analyzer {
+	t.Helper()
+
+	a, err := New(config.AnalyzerPluginConfig{Name: "test", Command: "unused", CacheTTL: cacheTTL})
+	require.NoError(t, err)
+	a.transport = transport
+	return a
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AnalyzerPluginConfig
+		err  string
+	}{
+		{"no name", config.AnalyzerPluginConfig{Command: "x"}, "no name"},
+		{"reserved name", config.AnalyzerPluginConfig{Name: "malysis", Command: "x"}, "reserved"},
+		{"no transport", config.AnalyzerPluginConfig{Name: "p"}, "command or socket is required"},
+		{"both transports", config.AnalyzerPluginConfig{Name: "p", Command: "x", Socket: "/tmp/s"}, "not both"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestAnalyzer_MapsResponse(t *testing.T) {
+	ft := &fakeTransport{response: `{"action":"block","summary":"Denied by policy","reference_url":"https://deny.example/1","analysis_id":"D-1","is_malware":true}`}
+	a := newTestAnalyzer(t, ft, -1)
+
+	pkg := npmPackage("evil", "1.0.0")
+	result, err := a.Analyze(context.Background(), pkg)
+	require.NoError(t, err)
+
+	assert.Equal(t, analyzer.ActionBlock, result.Action)
+	assert.Equal(t, "Denied by policy", result.Summary)
+	assert.Equal(t, "https://deny.example/1", result.ReferenceURL)
+	assert.Equal(t, "D-1", result.AnalysisID)
+	assert.True(t, result.IsMalware)
+	assert.Equal(t, pkg, result.PackageVersion)
+	assert.Equal(t, "plugin:test", a.Name())
+
+	var req map[string]any
+	require.NoError(t, json.Unmarshal(ft.requests[0], &req))
+	assert.Equal(t, float64(ProtocolVersion), req["protocol_version"])
+	assert.Equal(t, MethodAnalyze, req["method"])
+	assert.Equal(t, map[string]any{
+		"package": map[string]any{"ecosystem": "ECOSYSTEM_NPM", "name": "evil"},
+		"version": "1.0.0",
+	}, req["package_version"])
+}
+
+func TestAnalyzer_InvalidResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"not json", "oops"},
+		{"missing action", `{"summary":"x"}`},
+		{"unknown action", `{"action":"deny"}`},
+		{"plugin error", `{"action":"allow","error":"backend down"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAnalyzer(t, &fakeTransport{response: tt.response}, -1)
+
+			_, err := a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAnalyzer_CachesVerdicts(t *testing.T) {
+	ft := &fakeTransport{response: `{"action":"allow"}`}
+	a := newTestAnalyzer(t, ft, time.Minute)
+
+	now := time.Now()
+	a.cache.now = func() time.Time { return now }
+
+	for range 3 {
+		_, err := a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), ft.calls.Load())
+
+	_, err := a.Analyze(context.Background(), npmPackage("pkg", "2.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), ft.calls.Load())
+
+	now = now.Add(2 * time.Minute)
+	_, err = a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), ft.calls.Load())
+}
+
+func TestAnalyzer_CacheDisabled(t *testing.T) {
+	ft := &fakeTransport{response: `{"action":"allow"}`}
+	a := newTestAnalyzer(t, ft, -1)
+
+	for range 2 {
+		_, err := a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(2), ft.calls.Load())
+}
+
+func TestAnalyzer_CircuitBreakerTrips(t *testing.T) {
+	ft := &fakeTransport{response: "oops"}
+	a := newTestAnalyzer(t, ft, -1)
+
+	for range 5 {
+		_, err := a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, int64(3), ft.calls.Load())
+}
+
+func TestAnalyzer_CommandPlugin(t *testing.T) {
+	cfg := shellPlugin(t, `read req; case "$req" in *'"name":"evil"'*) echo '{"action":"block","summary":"denied"}';; *) echo '{"action":"allow"}';; esac`)
+	a, err := New(cfg)
+	require.NoError(t, err)
+
+	result, err := a.Analyze(context.Background(), npmPackage("evil", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionBlock, result.Action)
+	assert.Equal(t, "denied", result.Summary)
+
+	result, err = a.Analyze(context.Background(), npmPackage("lodash", "4.17.21"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionAllow, result.Action)
+
+	assert.NoError(t, a.Health(context.Background()))
+}
+
+func TestAnalyzer_CommandPluginFailure(t *testing.T) {
+	a, err := New(shellPlugin(t, `echo "cannot reach service" >&2; exit 3`))
+	require.NoError(t, err)
+
+	_, err = a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "cannot reach service")
+}
+
+func TestAnalyzer_CommandPluginTimeout(t *testing.T) {
+	cfg := shellPlugin(t, `exec sleep 5`)
+	cfg.Timeout = 100 * time.Millisecond
+	a, err := New(cfg)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 4*time.Second)
+}
+
+func TestAnalyzer_SocketPlugin(t *testing.T) {
+	dir, err := os.MkdirTemp("", "pmg-plugin")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var paths []string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+
+		var req Request
+		if err := json.Unmarshal(body, &req); err != nil || req.ProtocolVersion != ProtocolVersion {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"action":"confirm","summary":"not on the allow list"}`))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	a, err := New(config.AnalyzerPluginConfig{Name: "allowlist", Socket: socket})
+	require.NoError(t, err)
+
+	require.NoError(t, a.Health(context.Background()))
+
+	result, err := a.Analyze(context.Background(), npmPackage("pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionConfirm, result.Action)
+	assert.Equal(t, "not on the allow list", result.Summary)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/v1/health", "/v1/analyze"}, paths)
+}
+
+func TestAnalyzer_SocketPluginUnavailable(t *testing.T) {
+	a, err := New(config.AnalyzerPluginConfig{Name: "allowlist", Socket: filepath.Join(t.TempDir(), "missing.sock")})
+	require.NoError(t, err)
+
+	assert.Error(t, a.Health(context.Background()))
+}
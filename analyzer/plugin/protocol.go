@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ProtocolVersion is the version of the plugin protocol PMG speaks. It is
+// sent with every request so a plugin can reject versions it does not know.
+const ProtocolVersion = 1
+
+// Protocol methods.
+const (
+	MethodAnalyze = "analyze"
+	MethodHealth  = "health"
+)
+
+// Plugin actions, as returned in Response.Action.
+const (
+	ActionAllow   = "allow"
+	ActionConfirm = "confirm"
+	ActionBlock   = "block"
+
+	// ActionUnknown means the plugin has no opinion on the package. Combined
+	// with other analyzers it abstains.
+	ActionUnknown = "unknown"
+)
+
+// Request is the JSON document PMG sends to a plugin. PackageVersion is the
+// protobuf JSON form of a packagev1.PackageVersion, with proto field names:
+//
+//	{"protocol_version": 1, "method": "analyze",
+//	 "package_version": {"package": {"ecosystem": "ECOSYSTEM_NPM", "name": "lodash"}, "version": "4.17.21"}}
+//
+// A health request carries no package.
+type Request struct {
+	ProtocolVersion int             `json:"protocol_version"`
+	Method          string          `json:"method"`
+	PackageVersion  json.RawMessage `json:"package_version,omitempty"`
+}
+
+// Response is the JSON document a plugin answers with. A plugin that cannot
+// produce a verdict sets Error; PMG then treats the analysis as failed.
+type Response struct {
+	Action       string `json:"action"`
+	Summary      string `json:"summary,omitempty"`
+	ReferenceURL string `json:"reference_url,omitempty"`
+	AnalysisID   string `json:"analysis_id,omitempty"`
+	IsMalware    bool   `json:"is_malware,omitempty"`
+	IsVerified   bool   `json:"is_verified,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+var packageVersionMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+func newAnalyzeRequest(pkg *packagev1.PackageVersion) ([]byte, error) {
+	encoded, err := packageVersionMarshaler.Marshal(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode package version: %w", err)
+	}
+
+	return json.Marshal(Request{
+		ProtocolVersion: ProtocolVersion,
+		Method:          MethodAnalyze,
+		PackageVersion:  encoded,
+	})
+}
+
+func newHealthRequest() ([]byte, error) {
+	return json.Marshal(Request{ProtocolVersion: ProtocolVersion, Method: MethodHealth})
+}
+
+// parseResponse decodes a plugin response. A response reporting an error is
+// returned as an error.
+func parseResponse(data []byte) (*Response, error) {
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("invalid plugin response: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin reported an error: %s", resp.Error)
+	}
+
+	return &resp, nil
+}
+
+// toAction maps a plugin action to an analyzer action. An empty or
+// unrecognized action is an invalid response: a plugin that meant to block
+// must not be read as allowing.
+func toAction(action string) (analyzer.Action, error) {
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case ActionAllow:
+		return analyzer.ActionAllow, nil
+	case ActionConfirm:
+		return analyzer.ActionConfirm, nil
+	case ActionBlock:
+		return analyzer.ActionBlock, nil
+	case ActionUnknown:
+		return analyzer.ActionUnknown, nil
+	default:
+		return analyzer.ActionUnknown, fmt.Errorf("invalid plugin action: %q", action)
+	}
+}
@@ -0,0 +1,121 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxResponseSize caps a plugin response. A verdict is a few hundred bytes;
+// anything near this size is a broken plugin.
+const maxResponseSize = 1 << 20
+
+// transport delivers one request to a plugin and returns its raw response.
+type transport interface {
+	roundTrip(ctx context.Context, method string, request []byte) ([]byte, error)
+}
+
+// commandTransport runs the plugin executable once per request, writing the
+// request to its stdin and reading the response from its stdout. A non-zero
+// exit status is a failure.
+type commandTransport struct {
+	command string
+	args    []string
+}
+
+func (t *commandTransport) roundTrip(ctx context.Context, _ string, request []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, t.command, t.args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &limitedBuffer{buf: &stdout, remaining: maxResponseSize}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, remaining: 4096}
+	// Children of the plugin may hold stdout open after it is killed on
+	// timeout; do not wait for them.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("plugin command %s: %w", t.command, ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin command %s: %w: %s", t.command, err, msg)
+		}
+		return nil, fmt.Errorf("plugin command %s: %w", t.command, err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps the first bytes written to it and discards the rest,
+// so a runaway plugin cannot exhaust memory.
+type limitedBuffer struct {
+	buf       *bytes.Buffer
+	remaining int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if l.remaining <= 0 {
+		return n, nil
+	}
+	if len(p) > l.remaining {
+		p = p[:l.remaining]
+	}
+	l.remaining -= len(p)
+	l.buf.Write(p)
+	return n, nil
+}
+
+// socketTransport talks to a long-running plugin that serves HTTP on a Unix
+// socket. Each request is a POST to /v1/{method} with the request document as
+// its body.
+type socketTransport struct {
+	socket string
+	client *http.Client
+}
+
+func newSocketTransport(socket string) *socketTransport {
+	dialer := &net.Dialer{}
+	return &socketTransport{
+		socket: socket,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+func (t *socketTransport) roundTrip(ctx context.Context, method string, request []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://plugin/v1/"+method, bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("plugin socket %s: %w", t.socket, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("plugin socket %s: %w", t.socket, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("plugin socket %s returned HTTP %d", t.socket, resp.StatusCode)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,103 @@
+// Package typosquat flags package names that resemble a popular package:
+// one or two edits away, look-alike characters, different separators or a
+// confused npm scope. Developer typos and package names hallucinated by AI
+// coding agents both land on such names, which attackers register ahead of
+// time. The popular packages are embedded lists for npm and PyPI, which
+// configuration can extend.
+package typosquat
+
+import (
+	"context"
+	"fmt"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+)
+
+const analyzerName = "typosquat"
+
+// Analyzer checks npm and PyPI package names against the popular packages of
+// their ecosystem. It has no opinion, ActionUnknown, on other ecosystems.
+type Analyzer struct {
+	indexes map[packagev1.Ecosystem]*Index
+	action  analyzer.Action
+}
+
+var _ analyzer.PackageVersionAnalyzer = (*Analyzer)(nil)
+
+// New loads the popular package lists. It fails when a configured extra list
+// cannot be read.
+func New(cfg config.TyposquatConfig) (*Analyzer, error) {
+	npm, err := loadIndex("lists/npm.txt", "lists/npm-known.txt", cfg.NpmList, normalizeNpm)
+	if err != nil {
+		return nil, fmt.Errorf("npm package list: %w", err)
+	}
+
+	pypi, err := loadIndex("lists/pypi.txt", "lists/pypi-known.txt", cfg.PypiList, normalizePypi)
+	if err != nil {
+		return nil, fmt.Errorf("pypi package list: %w", err)
+	}
+
+	return &Analyzer{
+		indexes: map[packagev1.Ecosystem]*Index{
+			packagev1.Ecosystem_ECOSYSTEM_NPM:  npm,
+			packagev1.Ecosystem_ECOSYSTEM_PYPI: pypi,
+		},
+		action: toAction(cfg.ActionName()),
+	}, nil
+}
+
+func (a *Analyzer) Name() string {
+	return analyzerName
+}
+
+func (a *Analyzer) Analyze(_ context.Context, pkg *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	idx, ok := a.indexes[pkg.GetPackage().GetEcosystem()]
+	if !ok {
+		return &analyzer.PackageVersionAnalysisResult{PackageVersion: pkg, Action: analyzer.ActionUnknown}, nil
+	}
+
+	name := pkg.GetPackage().GetName()
+	match, ok := idx.Lookup(name)
+	if !ok {
+		return &analyzer.PackageVersionAnalysisResult{PackageVersion: pkg, Action: analyzer.ActionAllow}, nil
+	}
+
+	return &analyzer.PackageVersionAnalysisResult{
+		PackageVersion:  pkg,
+		Action:          a.action,
+		Summary:         fmt.Sprintf("%s %s. Did you mean %s?", name, describe(match), match.Target),
+		SuspectedTarget: match.Target,
+		Heuristic:       analyzer.HeuristicTyposquat,
+	}, nil
+}
+
+func describe(m Match) string {
+	switch m.Rule {
+	case ruleScope:
+		return fmt.Sprintf("differs from the popular package %s only in its scope", m.Target)
+	case ruleSeparator:
+		return fmt.Sprintf("differs from the popular package %s only in separators", m.Target)
+	case ruleHomoglyph:
+		return fmt.Sprintf("uses look-alike characters for the popular package %s", m.Target)
+	case ruleEditDistance:
+		if m.Distance == 1 {
+			return fmt.Sprintf("is one character away from the popular package %s", m.Target)
+		}
+		return fmt.Sprintf("is %d characters away from the popular package %s", m.Distance, m.Target)
+	default:
+		return fmt.Sprintf("resembles the popular package %s", m.Target)
+	}
+}
+
+func toAction(action string) analyzer.Action {
+	switch action {
+	case config.HeuristicActionAllow:
+		return analyzer.ActionAllow
+	case config.HeuristicActionConfirm:
+		return analyzer.ActionConfirm
+	default:
+		return analyzer.ActionBlock
+	}
+}
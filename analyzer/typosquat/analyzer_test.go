@@ -0,0 +1,144 @@
+package typosquat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makePackage(ecosystem packagev1.Ecosystem, name string) *packagev1.PackageVersion {
+	return &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: ecosystem, Name: name},
+		Version: "1.0.0",
+	}
+}
+
+func TestAnalyzer_Lookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		ecosystem packagev1.Ecosystem
+		pkg       string
+		target    string
+		rule      string
+	}{
+		{"popular npm package", packagev1.Ecosystem_ECOSYSTEM_NPM, "lodash", "", ""},
+		{"unrelated npm package", packagev1.Ecosystem_ECOSYSTEM_NPM, "my-internal-lib", "", ""},
+		{"short names are not compared", packagev1.Ecosystem_ECOSYSTEM_NPM, "exp", "", ""},
+		{"transposition", packagev1.Ecosystem_ECOSYSTEM_NPM, "lodahs", "lodash", ruleEditDistance},
+		{"missing character", packagev1.Ecosystem_ECOSYSTEM_NPM, "expres", "express", ruleEditDistance},
+		{"digit for letter", packagev1.Ecosystem_ECOSYSTEM_NPM, "1odash", "lodash", ruleHomoglyph},
+		{"letter pair for letter", packagev1.Ecosystem_ECOSYSTEM_NPM, "rnongoose", "mongoose", ruleHomoglyph},
+		{"dropped separator", packagev1.Ecosystem_ECOSYSTEM_NPM, "reactdom", "react-dom", ruleSeparator},
+		{"flattened scope", packagev1.Ecosystem_ECOSYSTEM_NPM, "types-node", "@types/node", ruleScope},
+		{"invented scope", packagev1.Ecosystem_ECOSYSTEM_NPM, "@react/dom", "react-dom", ruleScope},
+		{"misspelled scope", packagev1.Ecosystem_ECOSYSTEM_NPM, "@typess/node", "@types/node", ruleEditDistance},
+		{"popular pypi package in another spelling", packagev1.Ecosystem_ECOSYSTEM_PYPI, "Python_Dateutil", "", ""},
+		{"pypi typo", packagev1.Ecosystem_ECOSYSTEM_PYPI, "reqeusts", "requests", ruleEditDistance},
+		{"pypi dropped separator", packagev1.Ecosystem_ECOSYSTEM_PYPI, "pythondateutil", "python-dateutil", ruleSeparator},
+		{"pypi homoglyph", packagev1.Ecosystem_ECOSYSTEM_PYPI, "djang0", "django", ruleHomoglyph},
+		{"established npm look-alike", packagev1.Ecosystem_ECOSYSTEM_NPM, "expresso", "", ""},
+		{"established npm look-alike with suffix", packagev1.Ecosystem_ECOSYSTEM_NPM, "dotenvx", "", ""},
+		{"established npm look-alike of openai", packagev1.Ecosystem_ECOSYSTEM_NPM, "openapi", "", ""},
+		{"established pypi look-alike", packagev1.Ecosystem_ECOSYSTEM_PYPI, "scapy", "", ""},
+		{"established pypi look-alike of openai", packagev1.Ecosystem_ECOSYSTEM_PYPI, "openapi", "", ""},
+		{"established pypi short name", packagev1.Ecosystem_ECOSYSTEM_PYPI, "boto", "", ""},
+	}
+
+	a, err := New(config.TyposquatConfig{})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := a.indexes[tt.ecosystem].Lookup(tt.pkg)
+			assert.Equal(t, tt.target != "", ok)
+			assert.Equal(t, tt.target, match.Target)
+			assert.Equal(t, tt.rule, match.Rule)
+		})
+	}
+}
+
+func TestAnalyzer_Verdict(t *testing.T) {
+	a, err := New(config.TyposquatConfig{})
+	require.NoError(t, err)
+
+	result, err := a.Analyze(context.Background(), makePackage(packagev1.Ecosystem_ECOSYSTEM_NPM, "lodahs"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionConfirm, result.Action)
+	assert.Equal(t, "lodash", result.SuspectedTarget)
+	assert.Equal(t, analyzer.HeuristicTyposquat, result.Heuristic)
+	assert.Equal(t, "lodahs is one character away from the popular package lodash. Did you mean lodash?", result.Summary)
+
+	result, err = a.Analyze(context.Background(), makePackage(packagev1.Ecosystem_ECOSYSTEM_NPM, "lodash"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionAllow, result.Action)
+	assert.Empty(t, result.SuspectedTarget)
+
+	result, err = a.Analyze(context.Background(), makePackage(packagev1.Ecosystem_ECOSYSTEM_GO, "github.com/stretchr/testfiy"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionUnknown, result.Action)
+}
+
+func TestAnalyzer_ConfiguredAction(t *testing.T) {
+	a, err := New(config.TyposquatConfig{Action: "block"})
+	require.NoError(t, err)
+
+	result, err := a.Analyze(context.Background(), makePackage(packagev1.Ecosystem_ECOSYSTEM_PYPI, "reqeusts"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionBlock, result.Action)
+}
+
+func TestAnalyzer_ExtraList(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "npm.txt")
+	require.NoError(t, os.WriteFile(list, []byte("# internal packages\nacme-internal-utils\n"), 0o600))
+
+	a, err := New(config.TyposquatConfig{NpmList: list})
+	require.NoError(t, err)
+
+	match, ok := a.indexes[packagev1.Ecosystem_ECOSYSTEM_NPM].Lookup("acme-internl-util")
+	assert.True(t, ok)
+	assert.Equal(t, "acme-internal-utils", match.Target)
+	assert.Equal(t, 2, match.Distance)
+
+	_, err = New(config.TyposquatConfig{PypiList: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+}
+
+func TestEmbeddedLists(t *testing.T) {
+	for _, list := range []string{"npm", "pypi"} {
+		idx, err := loadIndex("lists/"+list+".txt", "lists/"+list+"-known.txt", "", normalizeNpm)
+		require.NoError(t, err)
+		assert.Greater(t, idx.Len(), 100, list)
+
+		// A known-good package is not itself a popular one.
+		for name := range idx.knownGood {
+			assert.False(t, idx.known[name], name)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		limit    int
+		expected int
+	}{
+		{"kitten", "sitting", 3, 3},
+		{"lodash", "lodahs", 1, 1},
+		{"react", "react", 1, 0},
+		{"abcdef", "uvwxyz", 2, 3},
+		{"ab", "abcd", 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.expected, editDistance(tt.a, tt.b, tt.limit))
+		})
+	}
+}
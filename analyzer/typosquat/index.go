@@ -0,0 +1,313 @@
+package typosquat
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// The embedded lists are plain text, one package name per line, most popular
+// first. Refresh them from registry download statistics; order decides which
+// target is suggested when a name resembles several.
+//
+// The known lists name established packages that resemble a popular one,
+// such as scapy and scipy. They are never flagged.
+//
+//go:embed lists/npm.txt lists/pypi.txt lists/npm-known.txt lists/pypi-known.txt
+var embeddedLists embed.FS
+
+// Rules that decide a name resembles a popular package, in the order they are
+// tried.
+const (
+	ruleScope        = "scope"
+	ruleSeparator    = "separator"
+	ruleHomoglyph    = "homoglyph"
+	ruleEditDistance = "edit_distance"
+)
+
+// minSimilarLength is the shortest popular name compared by homoglyph and edit
+// distance: shorter names are one edit away from too many others.
+const minSimilarLength = 5
+
+// longNameLength is the length from which a name two edits away from a
+// popular package is also flagged.
+const longNameLength = 12
+
+// Match is a popular package a name resembles.
+type Match struct {
+	Target   string
+	Rule     string
+	Distance int
+}
+
+// Index holds the popular packages of one ecosystem, and the established
+// packages that resemble one of them.
+type Index struct {
+	normalize func(string) string
+	names     []string
+	known     map[string]bool
+	knownGood map[string]bool
+}
+
+// newIndex builds an index from the lists, in order. Names are normalized
+// with normalize and deduplicated.
+func newIndex(normalize func(string) string, lists ...io.Reader) (*Index, error) {
+	idx := &Index{normalize: normalize, known: map[string]bool{}, knownGood: map[string]bool{}}
+	for _, list := range lists {
+		err := readList(list, func(line string) {
+			name := normalize(line)
+			if idx.known[name] {
+				return
+			}
+			idx.known[name] = true
+			idx.names = append(idx.names, name)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return idx, nil
+}
+
+// addKnownGood adds the established packages in list, which are never
+// flagged.
+func (idx *Index) addKnownGood(list io.Reader) error {
+	return readList(list, func(line string) {
+		idx.knownGood[idx.normalize(line)] = true
+	})
+}
+
+// readList calls add with each name in list, skipping blank lines and
+// comments.
+func readList(list io.Reader, add func(string)) error {
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		add(line)
+	}
+	return scanner.Err()
+}
+
+// loadIndex builds the index of the embedded popular and known lists,
+// extended by the names in extraFile when it is set.
+func loadIndex(embedded, embeddedKnown, extraFile string, normalize func(string) string) (*Index, error) {
+	list, err := embeddedLists.Open(embedded)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = list.Close() }()
+
+	lists := []io.Reader{list}
+	if extraFile != "" {
+		extra, err := os.Open(extraFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open package list: %w", err)
+		}
+		defer func() { _ = extra.Close() }()
+		lists = append(lists, extra)
+	}
+
+	idx, err := newIndex(normalize, lists...)
+	if err != nil {
+		return nil, err
+	}
+
+	knownList, err := embeddedLists.Open(embeddedKnown)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = knownList.Close() }()
+
+	if err := idx.addKnownGood(knownList); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Len returns the number of popular packages.
+func (idx *Index) Len() int {
+	return len(idx.names)
+}
+
+// Lookup returns the popular package name resembles. ok is false for a
+// popular or known-good package itself and for a name that resembles none.
+func (idx *Index) Lookup(name string) (match Match, ok bool) {
+	name = idx.normalize(name)
+	if idx.known[name] || idx.knownGood[name] {
+		return Match{}, false
+	}
+
+	if target, ok := idx.scopeConfusion(name); ok {
+		return Match{Target: target, Rule: ruleScope}, true
+	}
+
+	stripped := stripSeparators(name)
+	for _, popular := range idx.names {
+		if stripSeparators(popular) == stripped {
+			return Match{Target: popular, Rule: ruleSeparator}, true
+		}
+	}
+
+	if utf8.RuneCountInString(name) < minSimilarLength {
+		return Match{}, false
+	}
+
+	canonical := canonicalGlyphs(name)
+	for _, popular := range idx.names {
+		if utf8.RuneCountInString(popular) >= minSimilarLength && canonicalGlyphs(popular) == canonical {
+			return Match{Target: popular, Rule: ruleHomoglyph}, true
+		}
+	}
+
+	best := Match{}
+	for _, popular := range idx.names {
+		maxDistance := allowedDistance(popular)
+		if maxDistance == 0 {
+			continue
+		}
+
+		if d := editDistance(name, popular, maxDistance); d <= maxDistance && (best.Target == "" || d < best.Distance) {
+			best = Match{Target: popular, Rule: ruleEditDistance, Distance: d}
+		}
+	}
+
+	return best, best.Target != ""
+}
+
+// scopeConfusion matches a scoped npm name against the popular package its
+// scope and name spell unscoped ("@react/dom" for react-dom), and an unscoped
+// name against the popular scoped package it flattens ("types-node" for
+// @types/node).
+func (idx *Index) scopeConfusion(name string) (string, bool) {
+	if scope, bare, ok := splitScope(name); ok {
+		for _, sep := range []string{"-", ".", "_", ""} {
+			if flat := scope + sep + bare; idx.known[flat] {
+				return flat, true
+			}
+		}
+		return "", false
+	}
+
+	for _, popular := range idx.names {
+		scope, bare, ok := splitScope(popular)
+		if !ok {
+			continue
+		}
+		for _, sep := range []string{"-", ".", "_", ""} {
+			if name == scope+sep+bare {
+				return popular, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func splitScope(name string) (scope, bare string, ok bool) {
+	if !strings.HasPrefix(name, "@") {
+		return "", "", false
+	}
+
+	scope, bare, ok = strings.Cut(name[1:], "/")
+	return scope, bare, ok && scope != "" && bare != ""
+}
+
+func allowedDistance(popular string) int {
+	switch n := utf8.RuneCountInString(popular); {
+	case n >= longNameLength:
+		return 2
+	case n >= minSimilarLength:
+		return 1
+	default:
+		return 0
+	}
+}
+
+var separators = regexp.MustCompile(`[-_.]+`)
+
+func stripSeparators(name string) string {
+	return separators.ReplaceAllString(name, "")
+}
+
+// normalizeNpm lowercases a name; npm names are case-insensitive in
+// practice, since new ones must be lowercase.
+func normalizeNpm(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// normalizePypi normalizes a name per PEP 503, under which "-", "_" and "."
+// are interchangeable.
+func normalizePypi(name string) string {
+	return separators.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+}
+
+// glyphReplacer folds characters that read alike: digits for letters, letter
+// pairs for single letters, and Cyrillic and Greek look-alikes of Latin
+// letters.
+var glyphReplacer = strings.NewReplacer(
+	"rn", "m", "vv", "w", "cl", "d",
+	"0", "o", "1", "l", "i", "l", "|", "l", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "9", "g",
+	"а", "a", "е", "e", "о", "o", "р", "p", "с", "c", "у", "y", "х", "x", "і", "l", "ј", "j", "ѕ", "s",
+	"α", "a", "ο", "o", "ρ", "p", "ν", "v", "κ", "k", "ι", "l",
+)
+
+func canonicalGlyphs(name string) string {
+	return glyphReplacer.Replace(name)
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and transpositions of adjacent
+// characters. It returns limit+1 as soon as the distance exceeds limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return min(prev[len(rb)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package vulnfeed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+)
+
+const analyzerName = "vulnerabilities"
+
+// Analyzer applies the vulnerabilities policy to the imported advisories. A
+// version whose most severe applicable advisory reaches the block threshold
+// is blocked, one reaching the confirm threshold asks the user. Anything
+// else is no verdict: the analyzer abstains and leaves malware verdicts to
+// the analyzers it runs alongside.
+type Analyzer struct {
+	feed    *Feed
+	block   Severity
+	confirm Severity
+	ignore  []config.VulnerabilityIgnore
+	now     func() time.Time
+}
+
+var _ analyzer.PackageVersionAnalyzer = (*Analyzer)(nil)
+
+func NewAnalyzer(feed *Feed, cfg config.VulnerabilitiesConfig) *Analyzer {
+	return &Analyzer{
+		feed:    feed,
+		block:   threshold(cfg.BlockSeverityLevel()),
+		confirm: threshold(cfg.ConfirmSeverityLevel()),
+		ignore:  cfg.Ignore,
+		now:     time.Now,
+	}
+}
+
+// threshold maps a normalized severity level to the lowest Severity it
+// matches. "none" maps to a threshold no advisory reaches.
+func threshold(level string) Severity {
+	if level == config.VulnerabilitySeverityNone {
+		return SeverityCritical + 1
+	}
+	return ParseSeverity(level)
+}
+
+func (a *Analyzer) Name() string {
+	return analyzerName
+}
+
+func (a *Analyzer) Analyze(ctx context.Context, pkg *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	entries, err := a.feed.Lookup(ctx, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("vulnerability lookup failed: %w", err)
+	}
+
+	var applicable []Entry
+	for _, entry := range entries {
+		if ignore, ok := a.ignored(entry); ok {
+			log.Debugf("Ignoring %s for %s@%s: %s", entry.ID, pkg.GetPackage().GetName(), pkg.GetVersion(), ignore.Reason)
+			continue
+		}
+		applicable = append(applicable, entry)
+	}
+
+	result := &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: pkg,
+		Action:         analyzer.ActionUnknown,
+	}
+	if len(applicable) == 0 {
+		return result, nil
+	}
+
+	// Lookup returns the most severe advisory first.
+	worst := applicable[0]
+	for _, entry := range applicable {
+		result.Vulnerabilities = append(result.Vulnerabilities, analyzer.Vulnerability{
+			ID:           entry.ID,
+			Aliases:      entry.Aliases,
+			Severity:     entry.Severity.String(),
+			Summary:      entry.Summary,
+			ReferenceURL: entry.ReferenceURL,
+		})
+	}
+
+	switch {
+	case worst.Severity == SeverityUnknown:
+	case worst.Severity >= a.block:
+		result.Action = analyzer.ActionBlock
+	case worst.Severity >= a.confirm:
+		result.Action = analyzer.ActionConfirm
+	}
+
+	result.AnalysisID = worst.ID
+	result.ReferenceURL = worst.ReferenceURL
+	result.Summary = summarize(applicable)
+	return result, nil
+}
+
+// ignored returns the active ignore entry matching the advisory's ID or one
+// of its aliases.
+func (a *Analyzer) ignored(entry Entry) (config.VulnerabilityIgnore, bool) {
+	now := a.now()
+	for _, ignore := range a.ignore {
+		id := strings.TrimSpace(ignore.ID)
+		if id == "" {
+			continue
+		}
+
+		matches := strings.EqualFold(id, entry.ID)
+		for _, alias := range entry.Aliases {
+			matches = matches || strings.EqualFold(id, alias)
+		}
+		if matches && ignore.Active(now) {
+			return ignore, true
+		}
+	}
+
+	return config.VulnerabilityIgnore{}, false
+}
+
+// summarize describes the advisories, most severe first, for the block
+// message and the confirmation prompt.
+func summarize(entries []Entry) string {
+	worst := entries[0]
+	summary := fmt.Sprintf("%s (%s)", worst.ID, worst.Severity)
+	if worst.Summary != "" {
+		summary += ": " + worst.Summary
+	}
+
+	if len(entries) == 1 {
+		return "Known vulnerability " + summary
+	}
+
+	return fmt.Sprintf("%d known vulnerabilities, most severe %s", len(entries), summary)
+}
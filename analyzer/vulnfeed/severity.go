@@ -0,0 +1,192 @@
+package vulnfeed
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/safedep/pmg/analyzer/osv"
+)
+
+// Severity is the qualitative severity of an advisory, ordered from least
+// to most severe.
+type Severity int
+
+const (
+	// SeverityUnknown is an advisory with no severity PMG can read. It
+	// never reaches a policy threshold.
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSeverity reads a severity name, case-insensitively. GitHub's
+// "moderate" is medium.
+func ParseSeverity(s string) Severity {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return SeverityLow
+	case "medium", "moderate":
+		return SeverityMedium
+	case "high":
+		return SeverityHigh
+	case "critical":
+		return SeverityCritical
+	default:
+		return SeverityUnknown
+	}
+}
+
+// RecordSeverity returns the severity of an OSV record: the qualitative
+// severity in database_specific when the database publishes one, as GitHub
+// does, otherwise the rating of its CVSS v3 base score.
+func RecordSeverity(record *osv.Record) Severity {
+	if s := ParseSeverity(record.DatabaseSpecific.Severity); s != SeverityUnknown {
+		return s
+	}
+
+	for _, severity := range record.Severity {
+		if severity.Type != "CVSS_V3" {
+			continue
+		}
+		if score, ok := cvss3BaseScore(severity.Score); ok {
+			return cvssRating(score)
+		}
+	}
+
+	return SeverityUnknown
+}
+
+// cvssRating maps a CVSS base score to its qualitative rating. A score of
+// 0.0 is rated none, which no threshold reaches.
+func cvssRating(score float64) Severity {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityUnknown
+	}
+}
+
+// cvss3Weights are the CVSS v3 base metric weights. Privileges Required is
+// weighted by scope and handled separately.
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvss3BaseScore computes the base score of a CVSS v3.0 or v3.1 vector, such
+// as CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H, following the v3.1
+// specification.
+func cvss3BaseScore(vector string) (float64, bool) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "CVSS:3") {
+		return 0, false
+	}
+
+	metrics := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, false
+		}
+		metrics[key] = value
+	}
+
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, false
+	}
+
+	var pr float64
+	switch metrics["PR"] {
+	case "N":
+		pr = 0.85
+	case "L":
+		pr = 0.62
+		if changed {
+			pr = 0.68
+		}
+	case "H":
+		pr = 0.27
+		if changed {
+			pr = 0.5
+		}
+	default:
+		return 0, false
+	}
+
+	values := make(map[string]float64, len(cvss3Weights))
+	for metric, weights := range cvss3Weights {
+		w, ok := weights[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		values[metric] = w
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * values["AV"] * values["AC"] * pr * values["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// roundUp is the CVSS v3.1 Roundup: the smallest number with one decimal
+// place that is not less than x, computed without floating point drift.
+func roundUp(x float64) float64 {
+	scaled := int64(math.Round(x * 100000))
+	if scaled%10000 == 0 {
+		return float64(scaled) / 100000
+	}
+	return float64(scaled/10000+1) / 10
+}
+
+// sortEntries orders entries most severe first, then by ID, so the advisory
+// shown for a version is stable.
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Severity != entries[j].Severity {
+			return entries[i].Severity > entries[j].Severity
+		}
+		return entries[i].ID < entries[j].ID
+	})
+}
@@ -0,0 +1,342 @@
+// Package vulnfeed is a localdb-backed store of vulnerability advisories
+// imported from an OSV database export, such as osv.dev's per-ecosystem
+// dumps. It answers known-vulnerability lookups for the exact version the
+// proxy serves, without network access. Like malwarefeed, it owns only its
+// localdb module schema.
+package vulnfeed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/localdb"
+	"github.com/safedep/pmg/analyzer/osv"
+)
+
+const moduleName = "vuln_feed"
+
+// ecosystems are the ecosystems vulnerabilities are imported for. Advisories
+// for other ecosystems are skipped.
+var ecosystems = map[packagev1.Ecosystem]bool{
+	packagev1.Ecosystem_ECOSYSTEM_NPM:  true,
+	packagev1.Ecosystem_ECOSYSTEM_PYPI: true,
+	packagev1.Ecosystem_ECOSYSTEM_GO:   true,
+}
+
+// Descriptor is the localdb module contract. Migrations are append-only —
+// never edit or reorder an existing entry.
+func Descriptor() localdb.Descriptor {
+	return localdb.Descriptor{
+		Name: moduleName,
+		Migrations: []string{
+			`CREATE TABLE vuln_feed_entries (
+				id            TEXT    NOT NULL PRIMARY KEY,
+				aliases       TEXT,
+				summary       TEXT,
+				severity      TEXT    NOT NULL,
+				reference_url TEXT,
+				modified      INTEGER NOT NULL
+			)`,
+			// version is set for an explicitly listed version; range rows
+			// leave it NULL and carry the span bounds instead.
+			`CREATE TABLE vuln_feed_affected (
+				entry_id      TEXT NOT NULL,
+				ecosystem     TEXT NOT NULL,
+				name          TEXT NOT NULL,
+				version       TEXT,
+				introduced    TEXT,
+				fixed         TEXT,
+				last_affected TEXT
+			)`,
+			`CREATE INDEX vuln_feed_affected_package ON vuln_feed_affected (ecosystem, name)`,
+			`CREATE TABLE vuln_feed_imports (
+				imported_at INTEGER NOT NULL,
+				source      TEXT    NOT NULL,
+				records     INTEGER NOT NULL
+			)`,
+		},
+	}
+}
+
+type Feed struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func New(store *localdb.Store) *Feed {
+	return &Feed{db: store.DB(), now: time.Now}
+}
+
+// Entry is an advisory that affects a package version.
+type Entry struct {
+	ID           string
+	Aliases      []string
+	Summary      string
+	Severity     Severity
+	ReferenceURL string
+}
+
+type Stats struct {
+	Entries    int
+	Packages   int
+	LastImport time.Time
+	LastSource string
+}
+
+func (f *Feed) Stats(ctx context.Context) (Stats, error) {
+	var s Stats
+	if err := f.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM vuln_feed_entries`).Scan(&s.Entries); err != nil {
+		return Stats{}, err
+	}
+	if err := f.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM (SELECT DISTINCT ecosystem, name FROM vuln_feed_affected)`).
+		Scan(&s.Packages); err != nil {
+		return Stats{}, err
+	}
+
+	var importedAt int64
+	err := f.db.QueryRowContext(ctx,
+		`SELECT imported_at, source FROM vuln_feed_imports ORDER BY imported_at DESC LIMIT 1`).
+		Scan(&importedAt, &s.LastSource)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return Stats{}, err
+	default:
+		s.LastImport = time.Unix(importedAt, 0)
+	}
+	return s, nil
+}
+
+// ImportResult counts what an import did with the records it read.
+type ImportResult struct {
+	// Imported records were added or replaced an older copy.
+	Imported int
+
+	// Withdrawn records were removed from the feed.
+	Withdrawn int
+
+	// Unchanged records were already stored at the same or a newer
+	// modification time.
+	Unchanged int
+
+	// Skipped records are malware reports, which belong in the malware
+	// feed, or list no npm, PyPI or Go package.
+	Skipped int
+}
+
+// Import stores the records read from source in one transaction, with the
+// same incremental semantics as the malware feed: a record replaces the
+// stored copy of the same id unless that copy is newer, and a withdrawn
+// record removes it. A failed import leaves the feed as it was.
+func (f *Feed) Import(ctx context.Context, source string, read osv.RecordReader) (ImportResult, error) {
+	var result ImportResult
+
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = read(func(record *osv.Record) error {
+		return importRecord(ctx, tx, record, &result)
+	})
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO vuln_feed_imports (imported_at, source, records) VALUES (?, ?, ?)`,
+		f.now().Unix(), source, result.Imported+result.Withdrawn); err != nil {
+		return ImportResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ImportResult{}, err
+	}
+	return result, nil
+}
+
+func importRecord(ctx context.Context, tx *sql.Tx, record *osv.Record, result *ImportResult) error {
+	// osv.dev exports malware reports alongside vulnerabilities.
+	if strings.HasPrefix(record.ID, "MAL-") {
+		result.Skipped++
+		return nil
+	}
+
+	var stored int64
+	err := tx.QueryRowContext(ctx, `SELECT modified FROM vuln_feed_entries WHERE id=?`, record.ID).Scan(&stored)
+	exists := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if exists && stored > record.Modified.Unix() {
+		result.Unchanged++
+		return nil
+	}
+
+	if record.Withdrawn != nil {
+		if exists {
+			if err := deleteEntry(ctx, tx, record.ID); err != nil {
+				return err
+			}
+			result.Withdrawn++
+		}
+		return nil
+	}
+
+	if exists && stored == record.Modified.Unix() {
+		result.Unchanged++
+		return nil
+	}
+
+	type affectedRow struct {
+		ecosystem, name                          string
+		version, introduced, fixed, lastAffected sql.NullString
+	}
+
+	var rows []affectedRow
+	for _, affected := range record.Affected {
+		ecosystem, ok := osv.Ecosystems[affected.Package.Ecosystem]
+		if !ok || !ecosystems[ecosystem] || affected.Package.Name == "" {
+			continue
+		}
+		eco, name := ecosystem.String(), osv.NormalizeName(ecosystem, affected.Package.Name)
+
+		for _, version := range affected.Versions {
+			rows = append(rows, affectedRow{ecosystem: eco, name: name, version: nullString(version)})
+		}
+		for _, r := range affected.Ranges {
+			// GIT ranges are commit hashes, which never match a
+			// registry version.
+			if r.Type == "GIT" {
+				continue
+			}
+			for _, span := range osv.RangeSpans(r) {
+				rows = append(rows, affectedRow{ecosystem: eco, name: name,
+					introduced: nullString(span.Introduced), fixed: nullString(span.Fixed),
+					lastAffected: nullString(span.LastAffected)})
+			}
+		}
+	}
+
+	if len(rows) == 0 {
+		result.Skipped++
+		return nil
+	}
+
+	if exists {
+		if err := deleteEntry(ctx, tx, record.ID); err != nil {
+			return err
+		}
+	}
+
+	summary := record.Summary
+	if summary == "" {
+		summary = firstLine(record.Details)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO vuln_feed_entries (id, aliases, summary, severity, reference_url, modified) VALUES (?, ?, ?, ?, ?, ?)`,
+		record.ID, strings.Join(record.Aliases, ","), summary, RecordSeverity(record).String(),
+		record.ReferenceURL(), record.Modified.Unix()); err != nil {
+		return fmt.Errorf("store %s: %w", record.ID, err)
+	}
+
+	for _, row := range rows {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO vuln_feed_affected (entry_id, ecosystem, name, version, introduced, fixed, last_affected)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			record.ID, row.ecosystem, row.name, row.version, row.introduced, row.fixed, row.lastAffected); err != nil {
+			return fmt.Errorf("store %s: %w", record.ID, err)
+		}
+	}
+
+	result.Imported++
+	return nil
+}
+
+func deleteEntry(ctx context.Context, tx *sql.Tx, id string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM vuln_feed_affected WHERE entry_id=?`, id); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM vuln_feed_entries WHERE id=?`, id)
+	return err
+}
+
+// Lookup returns every advisory that affects the package version, most
+// severe first.
+func (f *Feed) Lookup(ctx context.Context, pkg *packagev1.PackageVersion) ([]Entry, error) {
+	ecosystem := pkg.GetPackage().GetEcosystem()
+	if !ecosystems[ecosystem] {
+		return nil, nil
+	}
+
+	name := osv.NormalizeName(ecosystem, pkg.GetPackage().GetName())
+	version := pkg.GetVersion()
+
+	rows, err := f.db.QueryContext(ctx,
+		`SELECT a.entry_id, a.version, a.introduced, a.fixed, a.last_affected,
+		        e.aliases, e.summary, e.severity, e.reference_url
+		 FROM vuln_feed_affected a JOIN vuln_feed_entries e ON e.id = a.entry_id
+		 WHERE a.ecosystem=? AND a.name=?`,
+		ecosystem.String(), name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []Entry
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var entry Entry
+		var listed, introduced, fixed, lastAffected, aliases, summary, severity, referenceURL sql.NullString
+		if err := rows.Scan(&entry.ID, &listed, &introduced, &fixed, &lastAffected,
+			&aliases, &summary, &severity, &referenceURL); err != nil {
+			return nil, err
+		}
+		if seen[entry.ID] {
+			continue
+		}
+
+		matched := false
+		if listed.Valid {
+			matched = osv.VersionsEqual(listed.String, version)
+		} else {
+			span := osv.Span{Introduced: introduced.String, Fixed: fixed.String, LastAffected: lastAffected.String}
+			matched = span.Contains(ecosystem, version)
+		}
+		if !matched {
+			continue
+		}
+
+		seen[entry.ID] = true
+		entry.Summary, entry.ReferenceURL = summary.String, referenceURL.String
+		entry.Severity = ParseSeverity(severity.String)
+		if aliases.String != "" {
+			entry.Aliases = strings.Split(aliases.String, ",")
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortEntries(entries)
+	return entries, nil
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return s
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
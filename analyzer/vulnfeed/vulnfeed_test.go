@@ -0,0 +1,190 @@
+package vulnfeed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/localdb"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/analyzer/osv"
+	"github.com/safedep/pmg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeed(t *testing.T) *Feed {
+	t.Helper()
+	mgr := localdb.New(localdb.Config{Dir: t.TempDir(), FileName: "pmg.db"})
+	t.Cleanup(func() { require.NoError(t, mgr.Close()) })
+	store, err := mgr.Store(context.Background(), Descriptor())
+	require.NoError(t, err)
+	return New(store)
+}
+
+func pkg(eco packagev1.Ecosystem, name, version string) *packagev1.PackageVersion {
+	pv := &packagev1.PackageVersion{}
+	pv.SetPackage(&packagev1.Package{})
+	pv.GetPackage().SetName(name)
+	pv.GetPackage().SetEcosystem(eco)
+	pv.SetVersion(version)
+	return pv
+}
+
+func records(rs ...*osv.Record) osv.RecordReader {
+	return func(visit func(*osv.Record) error) error {
+		for _, r := range rs {
+			if err := visit(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// vulnRecord is an advisory for name, introduced..fixed, with a GitHub
+// qualitative severity.
+func vulnRecord(id, ecosystem, name, severity, introduced, fixed string) *osv.Record {
+	return &osv.Record{
+		ID:               id,
+		Aliases:          []string{"CVE-" + id},
+		Modified:         time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Summary:          "Flaw in " + name,
+		DatabaseSpecific: osv.DatabaseSpecific{Severity: severity},
+		Affected: []osv.Affected{{
+			Package: osv.AffectedPackage{Ecosystem: ecosystem, Name: name},
+			Ranges: []osv.Range{{Type: "SEMVER", Events: []osv.Event{
+				{Introduced: introduced}, {Fixed: fixed},
+			}}},
+		}},
+	}
+}
+
+func TestImportAndLookup(t *testing.T) {
+	f := newTestFeed(t)
+	ctx := context.Background()
+
+	malware := vulnRecord("MAL-2024-1", "npm", "evil", "CRITICAL", "0", "")
+	result, err := f.Import(ctx, "npm.zip", records(
+		vulnRecord("GHSA-1", "npm", "lodash", "HIGH", "4.0.0", "4.17.21"),
+		vulnRecord("GHSA-2", "npm", "lodash", "CRITICAL", "4.17.0", "4.17.12"),
+		vulnRecord("GHSA-3", "PyPI", "Django_Rest", "MODERATE", "0", "3.0.0"),
+		vulnRecord("GHSA-4", "RubyGems", "rails", "HIGH", "0", "7.0.0"),
+		malware,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Imported: 3, Skipped: 2}, result)
+
+	entries, err := f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "lodash", "4.17.10"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "GHSA-2", entries[0].ID, "most severe first")
+	assert.Equal(t, SeverityCritical, entries[0].Severity)
+	assert.Equal(t, []string{"CVE-GHSA-2"}, entries[0].Aliases)
+	assert.Equal(t, "GHSA-1", entries[1].ID)
+
+	entries, err = f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "lodash", "4.17.21"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the fixed version is not affected")
+
+	entries, err = f.Lookup(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_PYPI, "django-rest", "2.1.0"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, SeverityMedium, entries[0].Severity)
+
+	stats, err := f.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Entries)
+	assert.Equal(t, 2, stats.Packages)
+	assert.Equal(t, "npm.zip", stats.LastSource)
+}
+
+func TestRecordSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   osv.Record
+		severity Severity
+	}{
+		{"database severity", osv.Record{DatabaseSpecific: osv.DatabaseSpecific{Severity: "MODERATE"}}, SeverityMedium},
+		{"cvss critical", osv.Record{Severity: []osv.Severity{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}}}, SeverityCritical},
+		{"cvss scope changed", osv.Record{Severity: []osv.Severity{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N"}}}, SeverityMedium},
+		{"cvss low", osv.Record{Severity: []osv.Severity{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:L/I:N/A:N"}}}, SeverityLow},
+		{"cvss v4 is not scored", osv.Record{Severity: []osv.Severity{{Type: "CVSS_V4", Score: "CVSS:4.0/AV:N"}}}, SeverityUnknown},
+		{"malformed vector", osv.Record{Severity: []osv.Severity{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N"}}}, SeverityUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.severity, RecordSeverity(&tt.record))
+		})
+	}
+}
+
+func TestAnalyzer(t *testing.T) {
+	f := newTestFeed(t)
+	ctx := context.Background()
+
+	_, err := f.Import(ctx, "feed", records(
+		vulnRecord("GHSA-crit", "npm", "critical-pkg", "CRITICAL", "0", "2.0.0"),
+		vulnRecord("GHSA-high", "npm", "high-pkg", "HIGH", "0", "2.0.0"),
+		vulnRecord("GHSA-low", "npm", "low-pkg", "LOW", "0", "2.0.0"),
+		vulnRecord("GHSA-ignored", "npm", "ignored-pkg", "CRITICAL", "0", "2.0.0"),
+		vulnRecord("GHSA-expired", "npm", "expired-pkg", "CRITICAL", "0", "2.0.0"),
+	))
+	require.NoError(t, err)
+
+	a := NewAnalyzer(f, config.VulnerabilitiesConfig{
+		Ignore: []config.VulnerabilityIgnore{
+			{ID: "cve-ghsa-ignored", Reason: "not reachable"},
+			{ID: "GHSA-expired", Reason: "waiting for a fix", Expires: "2024-01-31"},
+		},
+	})
+	assert.Equal(t, "vulnerabilities", a.Name())
+
+	tests := []struct {
+		name   string
+		action analyzer.Action
+	}{
+		{"critical-pkg", analyzer.ActionBlock},
+		{"high-pkg", analyzer.ActionConfirm},
+		{"low-pkg", analyzer.ActionUnknown},
+		{"ignored-pkg", analyzer.ActionUnknown},
+		{"expired-pkg", analyzer.ActionBlock},
+		{"clean-pkg", analyzer.ActionUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := a.Analyze(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, tt.name, "1.0.0"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.action, result.Action)
+			assert.False(t, result.IsMalware)
+		})
+	}
+
+	result, err := a.Analyze(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "critical-pkg", "1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "GHSA-crit", result.AnalysisID)
+	assert.Equal(t, "Known vulnerability GHSA-crit (critical): Flaw in critical-pkg", result.Summary)
+	require.Len(t, result.Vulnerabilities, 1)
+	assert.Equal(t, "critical", result.Vulnerabilities[0].Severity)
+
+	result, err = a.Analyze(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_NPM, "critical-pkg", "2.0.0"))
+	require.NoError(t, err)
+	assert.Empty(t, result.Vulnerabilities)
+}
+
+func TestAnalyzerThresholdNone(t *testing.T) {
+	f := newTestFeed(t)
+	ctx := context.Background()
+
+	_, err := f.Import(ctx, "feed", records(vulnRecord("GHSA-crit", "Go", "github.com/acme/lib", "CRITICAL", "0", "1.5.0")))
+	require.NoError(t, err)
+
+	a := NewAnalyzer(f, config.VulnerabilitiesConfig{BlockSeverity: "none", ConfirmSeverity: "critical"})
+
+	result, err := a.Analyze(ctx, pkg(packagev1.Ecosystem_ECOSYSTEM_GO, "github.com/acme/lib", "v1.4.0"))
+	require.NoError(t, err)
+	assert.Equal(t, analyzer.ActionConfirm, result.Action)
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/safedep/dry/log"
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/localstore"
+	pmgscan "github.com/safedep/pmg/internal/scan"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCommand returns the `pmg audit` command tree.
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Check packages that are already installed",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return cmd.Help() },
+	}
+	cmd.AddCommand(newInstalledCommand())
+	return cmd
+}
+
+func newInstalledCommand() *cobra.Command {
+	var (
+		concurrency int
+		goModCache  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "installed [path...]",
+		Short: "Check every installed package version against the analyzer",
+		Long: "Walk each path (default: the current directory) for installed packages: the\n" +
+			"package.json of every package in node_modules and the METADATA of every\n" +
+			"*.dist-info in a virtualenv's site-packages. The Go module cache is checked too.\n\n" +
+			"Packages installed before PMG was adopted, or with --insecure-installation, were\n" +
+			"never analyzed. This finds the ones with a malicious or vulnerable verdict. The\n" +
+			"command exits non-zero when any version violates policy.",
+		// Failures here are user-facing policy outcomes, not usage errors.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			roots := args
+			if len(roots) == 0 {
+				roots = []string{"."}
+			}
+
+			goModCacheDir := ""
+			if goModCache {
+				goModCacheDir = pmgscan.GoModuleCacheDir()
+			}
+
+			if err := runAuditInstalled(cmd.Context(), config.Get(), roots, goModCacheDir, concurrency, os.Stdout); err != nil {
+				ui.ErrorExit(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&concurrency, "concurrency", pmgscan.DefaultConcurrency,
+		"Number of package versions to analyze at once")
+	cmd.Flags().BoolVar(&goModCache, "go-mod-cache", true,
+		"Check the modules downloaded to the Go module cache")
+	return cmd
+}
+
+// runAuditInstalled checks the packages installed under roots and, unless
+// goModCacheDir is empty, in the Go module cache. The dependency cooldown is
+// left out: it decides whether a new version may be installed, and these
+// already are.
+func runAuditInstalled(ctx context.Context, cfg *config.RuntimeConfig, roots []string, goModCacheDir string,
+	concurrency int, out io.Writer,
+) error {
+	var packages []pmgscan.Package
+	for _, root := range roots {
+		found, err := pmgscan.InstalledPackages(root)
+		if err != nil {
+			return usefulerror.NewUsefulError().
+				WithCode(errcodes.NotFound).
+				WithHumanError(fmt.Sprintf("Cannot audit %s", root)).
+				WithHelp("Pass a directory containing node_modules or a virtualenv").
+				Wrap(err)
+		}
+		packages = append(packages, found...)
+	}
+
+	sources := len(roots)
+	if goModCacheDir != "" {
+		found, err := pmgscan.GoModuleCachePackages(goModCacheDir)
+		if err != nil {
+			log.Warnf("Failed to read the Go module cache at %s: %v", goModCacheDir, err)
+		} else if len(found) > 0 {
+			packages = append(packages, found...)
+			sources++
+		}
+	}
+
+	localDB := localstore.NewManager(cfg)
+	defer func() {
+		if cerr := localDB.Close(); cerr != nil {
+			log.Warnf("failed to close localdb: %v", cerr)
+		}
+	}()
+
+	packageAnalyzer, err := flows.BuildAnalyzer(ctx, cfg, localDB)
+	if err != nil {
+		return fmt.Errorf("failed to create analyzer: %w", err)
+	}
+
+	packages = pmgscan.Merge(packages)
+	ui.StartSpinner(fmt.Sprintf("Auditing %d installed package version(s)", len(packages)))
+	findings, err := pmgscan.NewScanner(packageAnalyzer, pmgscan.ScannerConfig{
+		Concurrency:  concurrency,
+		SkipCooldown: true,
+	}).Scan(ctx, packages)
+	ui.StopSpinner()
+	if err != nil {
+		return err
+	}
+
+	if err := ui.ScanReport(out, findings, sources); err != nil {
+		return err
+	}
+	return auditExitError(findings)
+}
+
+// auditExitError fails the audit when any installed version violates policy.
+func auditExitError(findings []pmgscan.Finding) error {
+	violations := pmgscan.Violations(findings)
+	if violations == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%d installed package version(s) violate policy", violations)
+	return usefulerror.NewUsefulError().
+		WithCode(errcodes.ScanPolicyViolation).
+		WithHumanError(msg).
+		WithMsg(msg).
+		WithHelp("Remove or upgrade the listed versions, then rotate any credentials the machine holds if one is malicious")
+}
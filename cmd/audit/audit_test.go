@@ -0,0 +1,20 @@
+package audit
+
+import (
+	"testing"
+
+	pmgscan "github.com/safedep/pmg/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditExitError(t *testing.T) {
+	assert.NoError(t, auditExitError([]pmgscan.Finding{{Verdict: pmgscan.VerdictClean}}))
+
+	err := auditExitError([]pmgscan.Finding{
+		{Verdict: pmgscan.VerdictMalicious, Violation: true},
+		{Verdict: pmgscan.VerdictTrusted},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 installed package version(s)")
+}
@@ -0,0 +1,39 @@
+package composer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/safedep/pmg/internal/analytics"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/safedep/pmg/packagemanager"
+	"github.com/spf13/cobra"
+)
+
+func NewComposerCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "composer [command] [package]",
+		Short:              "Guard composer package downloads",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := executeComposerFlow(cmd.Context(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func executeComposerFlow(ctx context.Context, args []string) error {
+	analytics.TrackCommandComposer()
+
+	packageManager, err := packagemanager.NewComposerPackageManager(packagemanager.DefaultComposerPackageManagerConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create composer package manager: %w", err)
+	}
+
+	return flows.RunProxy(ctx, packageManager, args)
+}
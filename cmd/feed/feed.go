@@ -0,0 +1,219 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/safedep/dry/localdb"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer/malwarefeed"
+	"github.com/safedep/pmg/analyzer/osv"
+	"github.com/safedep/pmg/analyzer/vulnfeed"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/localstore"
+	"github.com/spf13/cobra"
+)
+
+// NewFeedCommand returns the `pmg feed` command tree.
+func NewFeedCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "feed",
+		Short: "Manage the local malicious package feed and vulnerability database",
+		Long: "Import OSV-format malicious package records, such as OpenSSF's malicious-packages\n" +
+			"repository or an osv.dev export, for malware analysis without network access.\n" +
+			"Set analysis.local_feed to \"first\" or \"only\" to use the feed.\n\n" +
+			"With --vulnerabilities, import an OSV vulnerability export (npm, PyPI, Go) for the\n" +
+			"vulnerability policy instead. Set vulnerabilities.enabled to use it.",
+		RunE: func(cmd *cobra.Command, _ []string) error { return cmd.Help() },
+	}
+	cmd.AddCommand(newImportCommand())
+	cmd.AddCommand(newStatusCommand())
+	return cmd
+}
+
+func newImportCommand() *cobra.Command {
+	var vulnerabilities bool
+
+	cmd := &cobra.Command{
+		Use:          "import <dir|zip>",
+		Short:        "Import OSV records from a directory, zip or JSON file",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if vulnerabilities {
+				return runImportVulnerabilities(cmd.Context(), config.Get(), args[0], os.Stdout)
+			}
+			return runImport(cmd.Context(), config.Get(), args[0], os.Stdout)
+		},
+	}
+
+	cmd.Flags().BoolVar(&vulnerabilities, "vulnerabilities", false,
+		"Import vulnerability advisories into the vulnerability database instead of the malware feed")
+	return cmd
+}
+
+func newStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "status",
+		Short:        "Show the local feed and vulnerability database: path, entries and last import",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runStatus(cmd.Context(), config.Get(), os.Stdout)
+		},
+	}
+}
+
+func openFeed(ctx context.Context, mgr localdb.Manager) (*malwarefeed.Feed, error) {
+	store, err := mgr.Store(ctx, malwarefeed.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	return malwarefeed.New(store), nil
+}
+
+func openVulnFeed(ctx context.Context, mgr localdb.Manager) (*vulnfeed.Feed, error) {
+	store, err := mgr.Store(ctx, vulnfeed.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	return vulnfeed.New(store), nil
+}
+
+func closeManager(mgr localdb.Manager) {
+	if cerr := mgr.Close(); cerr != nil {
+		log.Warnf("failed to close localdb: %v", cerr)
+	}
+}
+
+func runImport(ctx context.Context, cfg *config.RuntimeConfig, path string, out io.Writer) error {
+	read, err := osv.Open(path)
+	if err != nil {
+		return fmt.Errorf("open feed: %w", err)
+	}
+
+	mgr := localstore.NewManager(cfg)
+	defer closeManager(mgr)
+
+	feed, err := openFeed(ctx, mgr)
+	if err != nil {
+		return fmt.Errorf("open local feed: %w", err)
+	}
+
+	source, err := filepath.Abs(path)
+	if err != nil {
+		source = path
+	}
+
+	result, err := feed.Import(ctx, source, read)
+	if err != nil {
+		return fmt.Errorf("import feed: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(out, "Imported:  %d\nWithdrawn: %d\nUnchanged: %d\nSkipped:   %d\n",
+		result.Imported, result.Withdrawn, result.Unchanged, result.Skipped); err != nil {
+		return err
+	}
+
+	if cfg.Config.Analysis.LocalFeedMode() == config.AnalysisLocalFeedOff {
+		if _, err := fmt.Fprintln(out, "The local feed is not used until analysis.local_feed is set to \"first\" or \"only\"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runImportVulnerabilities(ctx context.Context, cfg *config.RuntimeConfig, path string, out io.Writer) error {
+	read, err := osv.Open(path)
+	if err != nil {
+		return fmt.Errorf("open vulnerability database: %w", err)
+	}
+
+	mgr := localstore.NewManager(cfg)
+	defer closeManager(mgr)
+
+	feed, err := openVulnFeed(ctx, mgr)
+	if err != nil {
+		return fmt.Errorf("open vulnerability database: %w", err)
+	}
+
+	source, err := filepath.Abs(path)
+	if err != nil {
+		source = path
+	}
+
+	result, err := feed.Import(ctx, source, read)
+	if err != nil {
+		return fmt.Errorf("import vulnerabilities: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(out, "Imported:  %d\nWithdrawn: %d\nUnchanged: %d\nSkipped:   %d\n",
+		result.Imported, result.Withdrawn, result.Unchanged, result.Skipped); err != nil {
+		return err
+	}
+
+	if !cfg.Config.Vulnerabilities.Enabled {
+		if _, err := fmt.Fprintln(out, "The vulnerability database is not used until vulnerabilities.enabled is set."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runStatus(ctx context.Context, cfg *config.RuntimeConfig, out io.Writer) error {
+	dbPath := filepath.Join(cfg.LocalDBDir(), cfg.LocalDBFileName())
+
+	// Report an absent DB file without creating it.
+	var stats malwarefeed.Stats
+	var vulnStats vulnfeed.Stats
+	if _, err := os.Stat(dbPath); err == nil {
+		mgr := localstore.NewManager(cfg)
+		defer closeManager(mgr)
+
+		feed, err := openFeed(ctx, mgr)
+		if err != nil {
+			return fmt.Errorf("open local feed: %w", err)
+		}
+
+		stats, err = feed.Stats(ctx)
+		if err != nil {
+			return fmt.Errorf("read local feed: %w", err)
+		}
+
+		vulnFeed, err := openVulnFeed(ctx, mgr)
+		if err != nil {
+			return fmt.Errorf("open vulnerability database: %w", err)
+		}
+
+		vulnStats, err = vulnFeed.Stats(ctx)
+		if err != nil {
+			return fmt.Errorf("read vulnerability database: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("open local feed: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(out, "Path:        %s\nMode:        %s\nEntries:     %d\nPackages:    %d\nLast import: %s\n",
+		dbPath, cfg.Config.Analysis.LocalFeedMode(), stats.Entries, stats.Packages,
+		lastImport(stats.LastImport, stats.LastSource)); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(out, "\nVulnerabilities\nEnabled:     %t\nEntries:     %d\nPackages:    %d\nLast import: %s\n",
+		cfg.Config.Vulnerabilities.Enabled, vulnStats.Entries, vulnStats.Packages,
+		lastImport(vulnStats.LastImport, vulnStats.LastSource)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func lastImport(at time.Time, source string) string {
+	if at.IsZero() {
+		return "never"
+	}
+	return fmt.Sprintf("%s from %s", at.Format(time.RFC3339), source)
+}
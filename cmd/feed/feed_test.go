@@ -0,0 +1,74 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safedep/pmg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatus_NoFile(t *testing.T) {
+	t.Setenv("PMG_CACHE_DIR", t.TempDir())
+	config.Reload()
+	cfg := config.Get()
+
+	var out bytes.Buffer
+	require.NoError(t, runStatus(context.Background(), cfg, &out))
+	assert.Contains(t, out.String(), "Entries:     0")
+	assert.Contains(t, out.String(), "Last import: never")
+}
+
+func TestRunImportThenStatus(t *testing.T) {
+	t.Setenv("PMG_CACHE_DIR", t.TempDir())
+	config.Reload()
+	cfg := config.Get()
+
+	dir := t.TempDir()
+	record := `{"id": "MAL-2024-1", "modified": "2024-05-01T00:00:00Z",
+	  "affected": [{"package": {"ecosystem": "npm", "name": "evil-pkg"}}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "MAL-2024-1.json"), []byte(record), 0o644))
+
+	var out bytes.Buffer
+	require.NoError(t, runImport(context.Background(), cfg, dir, &out))
+	assert.Contains(t, out.String(), "Imported:  1")
+
+	out.Reset()
+	require.NoError(t, runStatus(context.Background(), cfg, &out))
+	assert.Contains(t, out.String(), "Entries:     1")
+	assert.Contains(t, out.String(), dir)
+}
+
+func TestRunImportVulnerabilitiesThenStatus(t *testing.T) {
+	t.Setenv("PMG_CACHE_DIR", t.TempDir())
+	config.Reload()
+	cfg := config.Get()
+
+	dir := t.TempDir()
+	record := `{"id": "GHSA-1", "modified": "2024-05-01T00:00:00Z",
+	  "database_specific": {"severity": "CRITICAL"},
+	  "affected": [{"package": {"ecosystem": "npm", "name": "lodash"}, "versions": ["4.17.10"]}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "GHSA-1.json"), []byte(record), 0o644))
+
+	var out bytes.Buffer
+	require.NoError(t, runImportVulnerabilities(context.Background(), cfg, dir, &out))
+	assert.Contains(t, out.String(), "Imported:  1")
+	assert.Contains(t, out.String(), "vulnerabilities.enabled")
+
+	out.Reset()
+	require.NoError(t, runStatus(context.Background(), cfg, &out))
+	assert.Contains(t, out.String(), "Entries:     0", "the malware feed is untouched")
+	assert.Contains(t, out.String(), "Vulnerabilities\nEnabled:     false\nEntries:     1")
+}
+
+func TestRunImport_MissingPath(t *testing.T) {
+	t.Setenv("PMG_CACHE_DIR", t.TempDir())
+	config.Reload()
+
+	err := runImport(context.Background(), config.Get(), filepath.Join(t.TempDir(), "missing"), &bytes.Buffer{})
+	assert.Error(t, err)
+}
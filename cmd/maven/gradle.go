@@ -0,0 +1,57 @@
+package maven
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/safedep/pmg/internal/analytics"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/safedep/pmg/packagemanager"
+	"github.com/spf13/cobra"
+)
+
+func NewGradleCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "gradle [task...]",
+		Short:              "Guard gradle build tool",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			analytics.TrackCommandGradle()
+			err := executeGradleFlow(cmd.Context(), packagemanager.DefaultGradlePackageManagerConfig(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// NewGradlewCommand guards the project's Gradle wrapper, ./gradlew in the
+// current directory.
+func NewGradlewCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "gradlew [task...]",
+		Short:              "Guard the ./gradlew Gradle wrapper",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			analytics.TrackCommandGradlew()
+			err := executeGradleFlow(cmd.Context(), packagemanager.DefaultGradleWrapperPackageManagerConfig(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func executeGradleFlow(ctx context.Context, config packagemanager.MavenPackageManagerConfig, args []string) error {
+	packageManager, err := packagemanager.NewMavenPackageManager(config)
+	if err != nil {
+		return fmt.Errorf("failed to create %s package manager: %w", config.CommandName, err)
+	}
+
+	return flows.RunProxy(ctx, packageManager, args)
+}
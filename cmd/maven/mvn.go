@@ -0,0 +1,38 @@
+package maven
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/safedep/pmg/internal/analytics"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/safedep/pmg/packagemanager"
+	"github.com/spf13/cobra"
+)
+
+func NewMvnCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "mvn [goal...]",
+		Short:              "Guard mvn build tool",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := executeMvnFlow(cmd.Context(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func executeMvnFlow(ctx context.Context, args []string) error {
+	analytics.TrackCommandMvn()
+	packageManager, err := packagemanager.NewMavenPackageManager(packagemanager.DefaultMavenPackageManagerConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create mvn package manager: %w", err)
+	}
+
+	return flows.RunProxy(ctx, packageManager, args)
+}
@@ -0,0 +1,90 @@
+package nuget
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/analytics"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/safedep/pmg/packagemanager"
+	"github.com/safedep/pmg/proxy/certmanager"
+	"github.com/safedep/pmg/truststore"
+	"github.com/spf13/cobra"
+)
+
+func NewDotnetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "dotnet [command] [package]",
+		Short:              "Guard dotnet NuGet package downloads",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			analytics.TrackCommandDotnet()
+			err := executeNuGetFlow(cmd.Context(), packagemanager.DefaultDotnetPackageManagerConfig(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func executeNuGetFlow(ctx context.Context, pmConfig packagemanager.NuGetPackageManagerConfig, args []string) error {
+	packageManager, err := packagemanager.NewNuGetPackageManager(pmConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create %s package manager: %w", pmConfig.CommandName, err)
+	}
+
+	// Report a config problem before the CA trust check, as `pmg go` does.
+	if err := config.RejectRemovedProxyOptOut(); err != nil {
+		return err
+	}
+
+	if err := requireTrustedCA(); err != nil {
+		return err
+	}
+
+	return flows.RunProxy(ctx, packageManager, args)
+}
+
+// requireTrustedCA fails fast when .NET cannot trust PMG's MITM CA. .NET
+// verifies TLS against the OS trust store on macOS and Windows and ignores
+// SSL_CERT_FILE there; on Linux it reads the injected bundle through OpenSSL.
+func requireTrustedCA() error {
+	if !truststore.UserScopeSupported() {
+		return nil
+	}
+
+	if _, err := certmanager.LoadCA(config.Get().ConfigDir()); err != nil {
+		return errNuGetCertNotTrusted(err)
+	}
+
+	user, system, err := truststore.Status(certmanager.CACommonName)
+	if err != nil {
+		return errNuGetCertNotTrusted(err)
+	}
+
+	if !user && !system {
+		return errNuGetCertNotTrusted(nil)
+	}
+
+	return nil
+}
+
+func errNuGetCertNotTrusted(cause error) error {
+	err := usefulerror.NewUsefulError().
+		WithCode(errcodes.CertTrustStore).
+		WithHumanError(".NET ignores PMG's injected CA bundle on this OS; the PMG proxy CA must be trusted in the OS trust store.").
+		WithHelp("Run `pmg setup cert install` to install and trust the PMG proxy CA, then retry.").
+		WithMsg("pmg proxy CA is not trusted in the OS trust store")
+
+	if cause != nil {
+		return err.Wrap(cause)
+	}
+
+	return err
+}
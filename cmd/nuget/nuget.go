@@ -0,0 +1,25 @@
+package nuget
+
+import (
+	"github.com/safedep/pmg/internal/analytics"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/safedep/pmg/packagemanager"
+	"github.com/spf13/cobra"
+)
+
+func NewNuGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "nuget [command] [package]",
+		Short:              "Guard nuget package manager",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			analytics.TrackCommandNuGet()
+			err := executeNuGetFlow(cmd.Context(), packagemanager.DefaultNuGetPackageManagerConfig(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
@@ -15,6 +15,7 @@ import (
 var (
 	daemonFlag             bool
 	logFileFlag            string
+	recordSBOMFlag         bool
 	foregroundInternalFlag bool
 )
 
@@ -35,6 +36,8 @@ func newStartCommand() *cobra.Command {
 	cmd.Flags().StringVar(&srv.ListenHost, "host", srv.ListenHost, "Host to bind")
 	cmd.Flags().IntVar(&srv.ListenPort, "port", srv.ListenPort, "Port to bind (0 = a random free port)")
 	cmd.Flags().StringVar(&logFileFlag, "log-file", "", "File for the daemon's output (default: <cache-dir>/proxy.log)")
+	cmd.Flags().BoolVar(&recordSBOMFlag, "record-sbom", false,
+		"Record the package versions the proxy delivers, for pmg proxy stop --sbom")
 	cmd.Flags().BoolVar(&foregroundInternalFlag, "foreground-internal", false, "Internal: run the foreground server (used by --daemon)")
 	if err := cmd.Flags().MarkHidden("foreground-internal"); err != nil {
 		panic(err)
@@ -55,7 +58,7 @@ func runStart(cmd *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	if err := proxyserver.Run(cmd.Context(), cfg, statePath, host, port); err != nil {
+	if err := proxyserver.Run(cmd.Context(), cfg, statePath, host, port, recordSBOMFlag); err != nil {
 		ui.ErrorExit(err)
 	}
 	return nil
@@ -99,10 +102,14 @@ func startDaemon(cmd *cobra.Command, cfg *config.RuntimeConfig, statePath, host
 
 func daemonArgs(cmd *cobra.Command, statePath, host string, port int) []string {
 	args := append([]string{}, config.ChangedConfigFlagArgs(cmd)...)
-	return append(args,
+	args = append(args,
 		"proxy", "start", "--foreground-internal",
 		"--state", statePath,
 		"--host", host,
 		"--port", strconv.Itoa(port),
 	)
+	if recordSBOMFlag {
+		args = append(args, "--record-sbom")
+	}
+	return args
 }
@@ -66,4 +66,9 @@ func TestDaemonArgsPrependsChangedConfigFlags(t *testing.T) {
 		"--host", "127.0.0.1",
 		"--port", "9000",
 	}, got)
+
+	recordSBOMFlag = true
+	t.Cleanup(func() { recordSBOMFlag = false })
+	require.NoError(t, root.Execute())
+	assert.Equal(t, "--record-sbom", got[len(got)-1])
 }
@@ -2,12 +2,14 @@ package proxy
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/safedep/dry/usefulerror"
 	"github.com/safedep/pmg/config"
 	"github.com/safedep/pmg/errcodes"
 	"github.com/safedep/pmg/internal/proxyserver"
+	"github.com/safedep/pmg/internal/sbom"
 	"github.com/safedep/pmg/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -31,6 +33,15 @@ func runStop(_ *cobra.Command, _ []string) error {
 	cfg := config.Get()
 	statePath := proxyserver.ResolveStatePath(stateFlag, cfg.CacheDir())
 
+	// Reject a bad --sbom-format while the proxy is still running.
+	var sbomFormat sbom.Format
+	if cfg.SBOMPath != "" {
+		var err error
+		if sbomFormat, err = sbom.ParseFormat(cfg.SBOMFormat); err != nil {
+			ui.ErrorExit(err)
+		}
+	}
+
 	res, err := proxyserver.Stop(statePath)
 	if err != nil {
 		ui.ErrorExit(err)
@@ -49,6 +60,14 @@ func runStop(_ *cobra.Command, _ []string) error {
 		}
 	}
 
+	// Write the SBOM before the violation gate: a run that blocked packages
+	// still installed the ones it let through.
+	if cfg.SBOMPath != "" {
+		if serr := writeStopSBOM(res, cfg.SBOMPath, sbomFormat, os.Stdout); serr != nil {
+			ui.ErrorExit(serr)
+		}
+	}
+
 	// On a policy violation the framed error states the blocked count, so exit
 	// here before the plain summary to avoid stating the count twice.
 	if verr := stopExitError(res, failOnViolation); verr != nil {
@@ -66,6 +85,35 @@ func runStop(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+// writeStopSBOM writes the package versions the proxy delivered during its
+// lifetime to path. Without the final state, or when the proxy was started
+// without --record-sbom, the fetched list is unknown, and an empty SBOM would
+// misreport the run.
+func writeStopSBOM(res proxyserver.StopResult, path string, format sbom.Format, out io.Writer) error {
+	if res.StateVerified && !res.RecordSBOM {
+		msg := "The proxy was started without --record-sbom, so it recorded no packages; no SBOM was written"
+		return usefulerror.NewUsefulError().
+			WithCode(errcodes.InvalidArgument).
+			WithHumanError(msg).
+			WithMsg(msg).
+			WithHelp("Start the proxy with pmg proxy start --record-sbom to produce an SBOM")
+	}
+	if !res.StateVerified {
+		msg := "Proxy shut down but the fetched packages could not be read; no SBOM was written"
+		return usefulerror.NewUsefulError().
+			WithCode(errcodes.NotFound).
+			WithHumanError(msg).
+			WithMsg(msg).
+			WithHelp("The proxy may have crashed; re-run the install under a new proxy to produce an SBOM")
+	}
+
+	if err := sbom.WriteFile(path, format, res.Fetched); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "Wrote SBOM of %d fetched package version(s) to %s\n", len(res.Fetched), path)
+	return err
+}
+
 // stopExitError maps a stop result to the command's exit status. With
 // --fail-on-violation: any blocked package fails, and an unverifiable final
 // state (e.g. a crashed proxy) fails closed — a security gate must not pass on
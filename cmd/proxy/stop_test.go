@@ -1,9 +1,16 @@
 package proxy
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/models"
 	"github.com/safedep/pmg/internal/proxyserver"
+	"github.com/safedep/pmg/internal/sbom"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -36,3 +43,38 @@ func TestStopExitError(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestWriteStopSBOM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sbom.json")
+	res := proxyserver.StopResult{
+		StateVerified: true,
+		RecordSBOM:    true,
+		Fetched: []models.FetchedPackage{{
+			Ecosystem: "ECOSYSTEM_NPM",
+			Name:      "left-pad",
+			Version:   "1.3.0",
+			Registry:  "registry.npmjs.org",
+			Artifacts: []models.FetchedArtifact{{URL: "https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz"}},
+		}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeStopSBOM(res, path, sbom.FormatCycloneDX, &out))
+	assert.Contains(t, out.String(), "1 fetched package version(s)")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "pkg:npm/left-pad@1.3.0")
+
+	err = writeStopSBOM(proxyserver.StopResult{}, path, sbom.FormatCycloneDX, &out)
+	usefulErr, ok := usefulerror.AsUsefulError(err)
+	require.True(t, ok)
+	assert.Equal(t, errcodes.NotFound, usefulErr.Code())
+
+	// A proxy started without --record-sbom has nothing to write.
+	err = writeStopSBOM(proxyserver.StopResult{StateVerified: true}, path, sbom.FormatCycloneDX, &out)
+	usefulErr, ok = usefulerror.AsUsefulError(err)
+	require.True(t, ok)
+	assert.Equal(t, errcodes.InvalidArgument, usefulErr.Code())
+	assert.Contains(t, err.Error(), "--record-sbom")
+}
@@ -0,0 +1,118 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/safedep/dry/log"
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/localstore"
+	"github.com/safedep/pmg/internal/scan"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// NewReviewCommand returns the `pmg review` command.
+func NewReviewCommand() *cobra.Command {
+	var (
+		base        string
+		head        string
+		staged      bool
+		concurrency int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "review --base <ref>",
+		Short: "Check only the package versions a change to lockfiles brings in",
+		Long: "Diff the lockfiles of the git repository between --base and the working tree\n" +
+			"(or --head, or the staged changes with --staged) and check the package versions that are added or changed against\n" +
+			"the policy PMG enforces at install time, cooldown included. Unchanged versions\n" +
+			"are not analyzed, so a bump in a large lockfile is reviewed on its own.\n\n" +
+			"The lockfiles pmg scan supports are reviewed. The command exits non-zero when\n" +
+			"any changed version violates policy; \"pmg setup git-hook\" runs it before\n" +
+			"each commit or push.",
+		Args: cobra.NoArgs,
+		// Failures here are user-facing policy outcomes, not usage errors.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := runReview(cmd.Context(), config.Get(), ".", base, head, staged, concurrency, os.Stdout); err != nil {
+				ui.ErrorExit(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&base, "base", "", "Git ref to diff against, e.g. HEAD or origin/main")
+	cmd.Flags().StringVar(&head, "head", "", "Git ref to review (default: the working tree)")
+	cmd.Flags().BoolVar(&staged, "staged", false, "Review the staged changes instead of the working tree")
+	cmd.Flags().BoolVar(&staged, "cached", false, "Synonym for --staged")
+	cmd.Flags().IntVar(&concurrency, "concurrency", scan.DefaultConcurrency,
+		"Number of package versions to analyze at once")
+	_ = cmd.MarkFlagRequired("base")
+	cmd.MarkFlagsMutuallyExclusive("head", "staged", "cached")
+	return cmd
+}
+
+func runReview(ctx context.Context, cfg *config.RuntimeConfig, dir, base, head string, staged bool, concurrency int, out io.Writer) error {
+	changes, lockfiles, err := scan.GitDiff(dir, base, head, staged)
+	if err != nil {
+		return usefulerror.NewUsefulError().
+			WithCode(errcodes.InvalidArgument).
+			WithHumanError(fmt.Sprintf("Cannot diff lockfiles against %s", base)).
+			WithHelp("Run pmg review inside a git repository and pass a ref that exists, e.g. --base origin/main").
+			Wrap(err)
+	}
+
+	var findings []scan.Finding
+	if len(changes) > 0 {
+		localDB := localstore.NewManager(cfg)
+		defer func() {
+			if cerr := localDB.Close(); cerr != nil {
+				log.Warnf("failed to close localdb: %v", cerr)
+			}
+		}()
+
+		packageAnalyzer, err := flows.BuildAnalyzer(ctx, cfg, localDB)
+		if err != nil {
+			return fmt.Errorf("failed to create analyzer: %w", err)
+		}
+
+		packages := make([]scan.Package, 0, len(changes))
+		for _, change := range changes {
+			packages = append(packages, change.Package)
+		}
+		packages = scan.Merge(packages)
+
+		ui.StartSpinner(fmt.Sprintf("Reviewing %d changed package version(s)", len(packages)))
+		findings, err = scan.NewScanner(packageAnalyzer, scan.ScannerConfig{Concurrency: concurrency}).Scan(ctx, packages)
+		ui.StopSpinner()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ui.ReviewReport(out, scan.Reviews(changes, findings), len(lockfiles)); err != nil {
+		return err
+	}
+	return reviewExitError(findings)
+}
+
+// reviewExitError fails the review when any changed version violates policy.
+func reviewExitError(findings []scan.Finding) error {
+	violations := scan.Violations(findings)
+	if violations == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%d changed package version(s) violate policy", violations)
+	return usefulerror.NewUsefulError().
+		WithCode(errcodes.ScanPolicyViolation).
+		WithHumanError(msg).
+		WithMsg(msg).
+		WithHelp("Pin a different version of the listed packages, or trust them in trusted_packages")
+}
@@ -0,0 +1,40 @@
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewExitError(t *testing.T) {
+	t.Run("no violations -> nil", func(t *testing.T) {
+		err := reviewExitError([]scan.Finding{
+			{Verdict: scan.VerdictClean},
+			{Verdict: scan.VerdictUnverified},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("violations -> error", func(t *testing.T) {
+		err := reviewExitError([]scan.Finding{
+			{Verdict: scan.VerdictCooldown, Violation: true},
+			{Verdict: scan.VerdictClean},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 changed package version(s)")
+	})
+}
+
+func TestRunReviewOutsideRepository(t *testing.T) {
+	t.Setenv("GIT_CEILING_DIRECTORIES", t.TempDir())
+	err := runReview(context.Background(), config.Get(), t.TempDir(), "HEAD", "", false, 1, nil)
+	usefulErr, ok := usefulerror.AsUsefulError(err)
+	require.True(t, ok)
+	assert.Equal(t, errcodes.InvalidArgument, usefulErr.Code())
+}
@@ -0,0 +1,38 @@
+package rubygems
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/safedep/pmg/internal/analytics"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/safedep/pmg/packagemanager"
+	"github.com/spf13/cobra"
+)
+
+func NewBundleCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "bundle [action] [gem]",
+		Short:              "Guard bundler package manager",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := executeBundleFlow(cmd.Context(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func executeBundleFlow(ctx context.Context, args []string) error {
+	analytics.TrackCommandBundle()
+	packageManager, err := packagemanager.NewRubyGemsPackageManager(packagemanager.DefaultBundlePackageManagerConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create bundle package manager: %w", err)
+	}
+
+	return flows.RunProxy(ctx, packageManager, args)
+}
@@ -0,0 +1,38 @@
+package rubygems
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/safedep/pmg/internal/analytics"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/safedep/pmg/packagemanager"
+	"github.com/spf13/cobra"
+)
+
+func NewGemCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                "gem [action] [gem]",
+		Short:              "Guard gem package manager",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := executeGemFlow(cmd.Context(), args)
+			if err != nil {
+				ui.ExitFromCommandError(err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func executeGemFlow(ctx context.Context, args []string) error {
+	analytics.TrackCommandGem()
+	packageManager, err := packagemanager.NewRubyGemsPackageManager(packagemanager.DefaultGemPackageManagerConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create gem package manager: %w", err)
+	}
+
+	return flows.RunProxy(ctx, packageManager, args)
+}
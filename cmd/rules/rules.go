@@ -0,0 +1,189 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/contentscan"
+	"github.com/spf13/cobra"
+)
+
+// NewRulesCommand returns the `pmg rules` command tree.
+func NewRulesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Manage the rule packs of the artifact content scanner",
+		Long: "Rule packs are YAML files of string, regex and file path rules evaluated against\n" +
+			"the files of every npm tarball, PyPI wheel or sdist, and Go module zip PMG downloads.\n" +
+			"A matching rule blocks the download or asks for confirmation. Packs are read from\n" +
+			"the rules directory (content_scan.rules_dir), so they can be shipped and updated\n" +
+			"without network access.",
+		RunE: func(cmd *cobra.Command, _ []string) error { return cmd.Help() },
+	}
+	cmd.AddCommand(newListCommand())
+	cmd.AddCommand(newImportCommand())
+	cmd.AddCommand(newRemoveCommand())
+	return cmd
+}
+
+func newListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        "List the installed rule packs and their rules",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runList(config.Get(), os.Stdout)
+		},
+	}
+}
+
+func newImportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "import <file>",
+		Short:        "Validate a rule pack and install it into the rules directory",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(config.Get(), args[0], os.Stdout)
+		},
+	}
+}
+
+func newRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "remove <pack>",
+		Short:        "Remove an installed rule pack by name",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRemove(config.Get(), args[0], os.Stdout)
+		},
+	}
+}
+
+func runList(cfg *config.RuntimeConfig, out io.Writer) error {
+	dir := cfg.ContentRulesDir()
+	packs, loadErr := contentscan.LoadDir(dir)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rules directory: %s\n", dir)
+	if !cfg.Config.ContentScan.Enabled {
+		fmt.Fprintln(&b, "Content scanning is disabled; set content_scan.enabled to use these packs.")
+	}
+
+	if len(packs) == 0 {
+		fmt.Fprintln(&b, "No rule packs installed.")
+	}
+
+	for _, pack := range packs {
+		fmt.Fprintf(&b, "\n%s (%s, %d rule(s))\n", pack.Name, filepath.Base(pack.Source), len(pack.Rules))
+		if pack.Description != "" {
+			fmt.Fprintf(&b, "  %s\n", pack.Description)
+		}
+		for _, rule := range pack.Rules {
+			fmt.Fprintf(&b, "  - %s [%s]", rule.Name, rule.Action)
+			if rule.Description != "" {
+				fmt.Fprintf(&b, " %s", rule.Description)
+			}
+			fmt.Fprintln(&b)
+		}
+	}
+
+	if loadErr != nil {
+		fmt.Fprintf(&b, "\nNot loaded:\n%s\n", loadErr)
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+var packFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// packFileName is the file a pack is installed as, derived from its name.
+func packFileName(name string) string {
+	return strings.Trim(packFileNameUnsafe.ReplaceAllString(name, "-"), "-.") + ".yml"
+}
+
+func runImport(cfg *config.RuntimeConfig, path string, out io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read rule pack: %w", err)
+	}
+
+	pack, err := contentscan.ParsePack(data, path)
+	if err != nil {
+		return fmt.Errorf("invalid rule pack %s: %w", path, err)
+	}
+
+	fileName := packFileName(pack.Name)
+	if fileName == ".yml" {
+		return fmt.Errorf("rule pack name %q has no usable characters for a file name", pack.Name)
+	}
+
+	dir := cfg.ContentRulesDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create rules directory: %w", err)
+	}
+
+	// A pack imported again replaces the installed copy, wherever that was.
+	installed, _ := contentscan.LoadDir(dir)
+	for _, existing := range installed {
+		if existing.Name == pack.Name && filepath.Base(existing.Source) != fileName {
+			if err := os.Remove(existing.Source); err != nil {
+				return fmt.Errorf("replace rule pack %s: %w", existing.Source, err)
+			}
+		}
+	}
+
+	target := filepath.Join(dir, fileName)
+	tmp, err := os.CreateTemp(dir, ".import-*.tmp")
+	if err != nil {
+		return fmt.Errorf("write rule pack: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write rule pack: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write rule pack: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("install rule pack: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(out, "Installed rule pack %s (%d rule(s)) to %s\n", pack.Name, len(pack.Rules), target); err != nil {
+		return err
+	}
+
+	if !cfg.Config.ContentScan.Enabled {
+		if _, err := fmt.Fprintln(out, "Content scanning is disabled; set content_scan.enabled to use this pack."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runRemove(cfg *config.RuntimeConfig, name string, out io.Writer) error {
+	packs, _ := contentscan.LoadDir(cfg.ContentRulesDir())
+	for _, pack := range packs {
+		if pack.Name != name {
+			continue
+		}
+
+		if err := os.Remove(pack.Source); err != nil {
+			return fmt.Errorf("remove rule pack: %w", err)
+		}
+		_, err := fmt.Fprintf(out, "Removed rule pack %s (%s)\n", pack.Name, pack.Source)
+		return err
+	}
+
+	return errors.New("no installed rule pack named " + name)
+}
@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safedep/pmg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPack = `
+name: campaign 2026/10
+description: Indicators of a test campaign
+rules:
+  - name: token_stealer
+    description: Posts npm tokens to a webhook
+    strings:
+      - text: NPM_TOKEN
+`
+
+func TestRunList_NoPacks(t *testing.T) {
+	t.Setenv("PMG_CONFIG_DIR", t.TempDir())
+	config.Reload()
+	cfg := config.Get()
+
+	var out bytes.Buffer
+	require.NoError(t, runList(cfg, &out))
+	assert.Contains(t, out.String(), filepath.Join(cfg.ConfigDir(), "rules"))
+	assert.Contains(t, out.String(), "No rule packs installed.")
+}
+
+func TestRunImportListRemove(t *testing.T) {
+	t.Setenv("PMG_CONFIG_DIR", t.TempDir())
+	config.Reload()
+	cfg := config.Get()
+
+	source := filepath.Join(t.TempDir(), "pack.yml")
+	require.NoError(t, os.WriteFile(source, []byte(testPack), 0o644))
+
+	var out bytes.Buffer
+	require.NoError(t, runImport(cfg, source, &out))
+	installed := filepath.Join(cfg.ContentRulesDir(), "campaign-2026-10.yml")
+	assert.Contains(t, out.String(), "Installed rule pack campaign 2026/10 (1 rule(s)) to "+installed)
+	assert.FileExists(t, installed)
+
+	// Importing the pack again replaces it.
+	out.Reset()
+	require.NoError(t, runImport(cfg, source, &out))
+
+	out.Reset()
+	require.NoError(t, runList(cfg, &out))
+	assert.Contains(t, out.String(), "campaign 2026/10 (campaign-2026-10.yml, 1 rule(s))")
+	assert.Contains(t, out.String(), "- token_stealer [block] Posts npm tokens to a webhook")
+	assert.NotContains(t, out.String(), "Not loaded")
+
+	out.Reset()
+	require.NoError(t, runRemove(cfg, "campaign 2026/10", &out))
+	assert.NoFileExists(t, installed)
+	assert.Error(t, runRemove(cfg, "campaign 2026/10", &out))
+}
+
+func TestRunImport_RejectsInvalidPack(t *testing.T) {
+	t.Setenv("PMG_CONFIG_DIR", t.TempDir())
+	config.Reload()
+	cfg := config.Get()
+
+	source := filepath.Join(t.TempDir(), "broken.yml")
+	require.NoError(t, os.WriteFile(source, []byte("name: broken\nrules: [{name: a, condition: 2 of them, paths: [x]}]"), 0o644))
+
+	var out bytes.Buffer
+	err := runImport(cfg, source, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "condition")
+
+	entries, _ := os.ReadDir(cfg.ContentRulesDir())
+	assert.Empty(t, entries)
+}
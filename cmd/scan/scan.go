@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/safedep/dry/log"
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/flows"
+	"github.com/safedep/pmg/internal/localstore"
+	pmgscan "github.com/safedep/pmg/internal/scan"
+	"github.com/safedep/pmg/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// NewScanCommand returns the `pmg scan` command.
+func NewScanCommand() *cobra.Command {
+	var concurrency int
+
+	cmd := &cobra.Command{
+		Use:   "scan [path]",
+		Short: "Check the packages pinned by lockfiles without installing them",
+		Long: "Find the lockfiles under path (default: the current directory) and check every\n" +
+			"pinned package version against the policy PMG enforces at install time: trusted\n" +
+			"packages, malware and vulnerability analysis, and the dependency cooldown.\n\n" +
+			"Supported: package-lock.json, npm-shrinkwrap.json, pnpm-lock.yaml, yarn.lock,\n" +
+			"bun.lock, requirements*.txt, poetry.lock, uv.lock, Pipfile.lock and go.sum.\n" +
+			"path may also name a single lockfile. The command exits non-zero when any\n" +
+			"version violates policy.",
+		Args: cobra.MaximumNArgs(1),
+		// Failures here are user-facing policy outcomes, not usage errors.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := "."
+			if len(args) > 0 {
+				root = args[0]
+			}
+			if err := runScan(cmd.Context(), config.Get(), root, concurrency, os.Stdout); err != nil {
+				ui.ErrorExit(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&concurrency, "concurrency", pmgscan.DefaultConcurrency,
+		"Number of package versions to analyze at once")
+	return cmd
+}
+
+func runScan(ctx context.Context, cfg *config.RuntimeConfig, root string, concurrency int, out io.Writer) error {
+	paths, err := pmgscan.Discover(root)
+	if err != nil {
+		return usefulerror.NewUsefulError().
+			WithCode(errcodes.NotFound).
+			WithHumanError(fmt.Sprintf("Cannot scan %s", root)).
+			WithHelp("Pass a project directory or a lockfile").
+			Wrap(err)
+	}
+	if len(paths) == 0 {
+		msg := fmt.Sprintf("No lockfiles found under %s", root)
+		return usefulerror.NewUsefulError().
+			WithCode(errcodes.NotFound).
+			WithHumanError(msg).
+			WithMsg(msg).
+			WithHelp("Run pmg scan in a project with a supported lockfile; see pmg scan --help")
+	}
+
+	packages, err := pmgscan.LoadLockfiles(paths)
+	if err != nil {
+		return usefulerror.NewUsefulError().
+			WithCode(errcodes.PackageParseFailed).
+			WithHumanError(err.Error()).
+			WithHelp("Check that the lockfile is complete and was written by a supported package manager").
+			Wrap(err)
+	}
+
+	localDB := localstore.NewManager(cfg)
+	defer func() {
+		if cerr := localDB.Close(); cerr != nil {
+			log.Warnf("failed to close localdb: %v", cerr)
+		}
+	}()
+
+	packageAnalyzer, err := flows.BuildAnalyzer(ctx, cfg, localDB)
+	if err != nil {
+		return fmt.Errorf("failed to create analyzer: %w", err)
+	}
+
+	packages = pmgscan.Merge(packages)
+	ui.StartSpinner(fmt.Sprintf("Scanning %d package version(s)", len(packages)))
+	findings, err := pmgscan.NewScanner(packageAnalyzer, pmgscan.ScannerConfig{Concurrency: concurrency}).Scan(ctx, packages)
+	ui.StopSpinner()
+	if err != nil {
+		return err
+	}
+
+	if err := ui.ScanReport(out, findings, len(paths)); err != nil {
+		return err
+	}
+	return scanExitError(findings)
+}
+
+// scanExitError fails the scan when any version violates policy.
+func scanExitError(findings []pmgscan.Finding) error {
+	violations := pmgscan.Violations(findings)
+	if violations == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%d package version(s) violate policy", violations)
+	return usefulerror.NewUsefulError().
+		WithCode(errcodes.ScanPolicyViolation).
+		WithHumanError(msg).
+		WithMsg(msg).
+		WithHelp("Upgrade or remove the listed versions, or trust them in trusted_packages")
+}
@@ -0,0 +1,37 @@
+package scan
+
+import (
+	"context"
+	"testing"
+
+	"github.com/safedep/pmg/config"
+	pmgscan "github.com/safedep/pmg/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanExitError(t *testing.T) {
+	t.Run("no violations -> nil", func(t *testing.T) {
+		err := scanExitError([]pmgscan.Finding{
+			{Verdict: pmgscan.VerdictClean},
+			{Verdict: pmgscan.VerdictUnverified},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("violations -> error", func(t *testing.T) {
+		err := scanExitError([]pmgscan.Finding{
+			{Verdict: pmgscan.VerdictMalicious, Violation: true},
+			{Verdict: pmgscan.VerdictCooldown, Violation: true},
+			{Verdict: pmgscan.VerdictClean},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2 package version(s)")
+	})
+}
+
+func TestRunScanWithoutLockfiles(t *testing.T) {
+	err := runScan(context.Background(), config.Get(), t.TempDir(), 1, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No lockfiles found")
+}
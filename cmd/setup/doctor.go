@@ -1,6 +1,7 @@
 package setup
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer/plugin"
 	"github.com/safedep/pmg/config"
 	"github.com/safedep/pmg/internal/alias"
 	"github.com/safedep/pmg/internal/doctor"
@@ -35,6 +37,7 @@ const (
 	checkProtectionPip      = "protection-pip"
 	checkCA                 = "ca-cert"
 	checkSystemBinary       = "system-binary"
+	checkAnalyzerPlugin     = "analyzer-plugin"
 
 	aliasesInstalledMessage = "Shell aliases installed"
 
@@ -71,7 +74,8 @@ func executeDoctorChecks(out io.Writer, jsonOut bool) error {
 
 	coreResults := runCoreChecks(cfg)
 	protectionResults := runProtectionChecks(coreResults)
-	allResults := append(coreResults, protectionResults...)
+	allResults := append(coreResults, runAnalyzerPluginChecks(cfg)...)
+	allResults = append(allResults, protectionResults...)
 
 	if jsonOut {
 		if err := writeStatusJSON(out, buildDoctorReport(cfg, allResults)); err != nil {
@@ -268,6 +272,49 @@ func runCoreChecks(cfg *config.RuntimeConfig) []doctor.CheckResult {
 	return doctor.RunChecks(checks)
 }
 
+// runAnalyzerPluginChecks health-checks each configured analyzer plugin.
+func runAnalyzerPluginChecks(cfg *config.RuntimeConfig) []doctor.CheckResult {
+	var checks []doctor.Check
+	for _, pluginCfg := range cfg.Config.Analyzers.Plugins {
+		checks = append(checks, doctor.Check{
+			Name:     checkAnalyzerPlugin + ":" + pluginCfg.Name,
+			Category: "Analyzers",
+			Run: func() doctor.CheckResult {
+				return checkAnalyzerPluginResult(context.Background(), pluginCfg)
+			},
+		})
+	}
+
+	return doctor.RunChecks(checks)
+}
+
+// checkAnalyzerPluginResult health-checks a configured analyzer plugin. A
+// plugin that is configured but not reachable fails: packages it would have
+// judged go without its verdict.
+func checkAnalyzerPluginResult(ctx context.Context, pluginCfg config.AnalyzerPluginConfig) doctor.CheckResult {
+	p, err := plugin.New(pluginCfg)
+	if err != nil {
+		return doctor.CheckResult{
+			Status:  doctor.StatusFail,
+			Message: fmt.Sprintf("Invalid plugin config: %v", err),
+			Fix:     "Fix analyzers.plugins in config",
+		}
+	}
+
+	if err := p.Health(ctx); err != nil {
+		return doctor.CheckResult{
+			Status:  doctor.StatusFail,
+			Message: fmt.Sprintf("Plugin not healthy: %v", err),
+			Fix:     "Start the plugin or fix analyzers.plugins in config",
+		}
+	}
+
+	return doctor.CheckResult{
+		Status:  doctor.StatusPass,
+		Message: fmt.Sprintf("Plugin %s is healthy", pluginCfg.Name),
+	}
+}
+
 func checkSystemBinaryResult() doctor.CheckResult {
 	path, ok := shim.SystemShimBinary()
 	if !ok {
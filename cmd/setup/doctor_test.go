@@ -1,6 +1,7 @@
 package setup
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -156,3 +157,34 @@ func TestCheckEventLogDirResult(t *testing.T) {
 		assert.Equal(t, expectedFix, result.Fix)
 	})
 }
+
+func TestCheckAnalyzerPluginResult(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid config fails", func(t *testing.T) {
+		result := checkAnalyzerPluginResult(ctx, config.AnalyzerPluginConfig{Name: "allowlist"})
+		assert.Equal(t, doctor.StatusFail, result.Status)
+		assert.Contains(t, result.Message, "Invalid plugin config")
+	})
+
+	t.Run("unreachable plugin fails", func(t *testing.T) {
+		result := checkAnalyzerPluginResult(ctx, config.AnalyzerPluginConfig{
+			Name:   "allowlist",
+			Socket: filepath.Join(t.TempDir(), "missing.sock"),
+		})
+		assert.Equal(t, doctor.StatusFail, result.Status)
+		assert.Contains(t, result.Message, "Plugin not healthy")
+	})
+
+	t.Run("healthy plugin passes", func(t *testing.T) {
+		if _, err := os.Stat("/bin/sh"); err != nil {
+			t.Skip("no /bin/sh")
+		}
+		result := checkAnalyzerPluginResult(ctx, config.AnalyzerPluginConfig{
+			Name:    "allowlist",
+			Command: "/bin/sh",
+			Args:    []string{"-c", `cat >/dev/null; echo '{"action":"allow"}'`},
+		})
+		assert.Equal(t, doctor.StatusPass, result.Status)
+	})
+}
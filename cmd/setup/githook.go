@@ -0,0 +1,162 @@
+package setup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// gitHookMarker identifies the hooks PMG writes, so they can be replaced and
+// removed without touching anyone else's.
+const gitHookMarker = "# Installed by pmg setup git-hook."
+
+// gitHookScripts are the hooks `pmg setup git-hook` can install. A missing
+// pmg binary is reported and skipped rather than blocking every commit.
+var gitHookScripts = map[string]string{
+	// The first commit has no HEAD to diff against and is not reviewed.
+	"pre-commit": `#!/bin/sh
+` + gitHookMarker + `
+# Reviews the package versions the staged lockfile changes of this commit bring in.
+command -v pmg >/dev/null 2>&1 || { echo "pmg: not found in PATH, dependency review skipped" >&2; exit 0; }
+git rev-parse --verify --quiet HEAD >/dev/null || exit 0
+exec pmg review --base HEAD --staged
+`,
+
+	// git passes the refs being pushed on stdin. A new branch is diffed
+	// against the remote's default branch.
+	"pre-push": `#!/bin/sh
+` + gitHookMarker + `
+# Reviews the package versions the lockfile changes of the pushed commits bring in.
+command -v pmg >/dev/null 2>&1 || { echo "pmg: not found in PATH, dependency review skipped" >&2; exit 0; }
+while read -r local_ref local_sha remote_ref remote_sha; do
+	case $local_sha in *[!0]*) ;; *) continue ;; esac
+	base=$remote_sha
+	case $base in *[!0]*) ;; *) base= ;; esac
+	if [ -z "$base" ] || ! git cat-file -e "$base^{commit}" 2>/dev/null; then
+		base=$(git merge-base "$local_sha" "refs/remotes/$1/HEAD" 2>/dev/null) || continue
+	fi
+	pmg review --base "$base" --head "$local_sha" </dev/null || exit 1
+done
+`,
+}
+
+// NewGitHookCommand returns the `pmg setup git-hook` command.
+func NewGitHookCommand() *cobra.Command {
+	var (
+		hooks  []string
+		force  bool
+		remove bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "git-hook",
+		Short: "Run pmg review from the git hooks of the current repository",
+		Long: "Install git hooks that run \"pmg review\" on the lockfile changes of each\n" +
+			"commit (pre-commit) or push (pre-push), so a bad dependency bump is caught\n" +
+			"before it reaches CI. An existing hook that PMG did not write is kept unless\n" +
+			"--force is given.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if remove {
+				return runGitHookRemove(".", hooks, os.Stdout)
+			}
+			return runGitHookInstall(".", hooks, force, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&hooks, "hook", []string{"pre-commit"}, "Hooks to manage: pre-commit, pre-push")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace existing hooks that PMG did not write")
+	cmd.Flags().BoolVar(&remove, "remove", false, "Remove the hooks PMG wrote")
+	return cmd
+}
+
+func runGitHookInstall(dir string, hooks []string, force bool, out io.Writer) error {
+	hooksDir, err := gitHooksDir(dir, hooks)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", hooksDir, err)
+	}
+
+	for _, hook := range hooks {
+		path := filepath.Join(hooksDir, hook)
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err == nil && !strings.Contains(string(existing), gitHookMarker) && !force {
+			return fmt.Errorf("%s already exists and was not written by PMG; add pmg review to it or pass --force", path)
+		}
+
+		if err := os.WriteFile(path, []byte(gitHookScripts[hook]), 0o755); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		// WriteFile keeps the mode of a file it replaces.
+		if err := os.Chmod(path, 0o755); err != nil {
+			return fmt.Errorf("failed to make %s executable: %w", path, err)
+		}
+		if _, err := fmt.Fprintf(out, "Installed %s hook: %s\n", hook, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runGitHookRemove(dir string, hooks []string, out io.Writer) error {
+	hooksDir, err := gitHooksDir(dir, hooks)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		path := filepath.Join(hooksDir, hook)
+		existing, err := os.ReadFile(path)
+		if os.IsNotExist(err) || (err == nil && !strings.Contains(string(existing), gitHookMarker)) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		if _, err := fmt.Fprintf(out, "Removed %s hook: %s\n", hook, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitHooksDir validates hooks and returns the hooks directory of the
+// repository containing dir, honoring core.hooksPath.
+func gitHooksDir(dir string, hooks []string) (string, error) {
+	for _, hook := range hooks {
+		if _, ok := gitHookScripts[hook]; !ok {
+			supported := make([]string, 0, len(gitHookScripts))
+			for name := range gitHookScripts {
+				supported = append(supported, name)
+			}
+			sort.Strings(supported)
+			return "", fmt.Errorf("unsupported hook %q, expected one of: %s", hook, strings.Join(supported, ", "))
+		}
+	}
+
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not in a git repository: %w", dir, err)
+	}
+
+	hooksDir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(dir, hooksDir)
+	}
+	return hooksDir, nil
+}
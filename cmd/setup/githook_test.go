@@ -0,0 +1,79 @@
+package setup
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, exec.Command("git", "-C", dir, "init", "--quiet").Run())
+	return dir
+}
+
+func TestRunGitHookInstall(t *testing.T) {
+	dir := newGitRepo(t)
+	hooksDir := filepath.Join(dir, ".git", "hooks")
+
+	var out bytes.Buffer
+	require.NoError(t, runGitHookInstall(dir, []string{"pre-commit", "pre-push"}, false, &out))
+	assert.Contains(t, out.String(), "Installed pre-commit hook")
+
+	for _, hook := range []string{"pre-commit", "pre-push"} {
+		path := filepath.Join(hooksDir, hook)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, gitHookScripts[hook], string(data))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	}
+
+	// Reinstalling replaces PMG's own hooks.
+	require.NoError(t, runGitHookInstall(dir, []string{"pre-commit"}, false, &out))
+
+	out.Reset()
+	require.NoError(t, runGitHookRemove(dir, []string{"pre-commit", "pre-push"}, &out))
+	assert.Contains(t, out.String(), "Removed pre-push hook")
+	assert.NoFileExists(t, filepath.Join(hooksDir, "pre-commit"))
+	assert.NoFileExists(t, filepath.Join(hooksDir, "pre-push"))
+}
+
+func TestRunGitHookInstallKeepsForeignHook(t *testing.T) {
+	dir := newGitRepo(t)
+	path := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\nmake lint\n"), 0o644))
+
+	var out bytes.Buffer
+	err := runGitHookInstall(dir, []string{"pre-commit"}, false, &out)
+	assert.ErrorContains(t, err, "was not written by PMG")
+
+	require.NoError(t, runGitHookRemove(dir, []string{"pre-commit"}, &out))
+	assert.FileExists(t, path)
+
+	require.NoError(t, runGitHookInstall(dir, []string{"pre-commit"}, true, &out))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), gitHookMarker)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+}
+
+func TestRunGitHookInstallRejectsUnknownHook(t *testing.T) {
+	err := runGitHookInstall(t.TempDir(), []string{"post-merge"}, false, &bytes.Buffer{})
+	assert.ErrorContains(t, err, `unsupported hook "post-merge"`)
+}
@@ -0,0 +1,115 @@
+package setup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/npmsig"
+	"github.com/spf13/cobra"
+)
+
+// NewKeysCommand returns the `pmg setup keys` command tree.
+func NewKeysCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage the npm registry signing keys PMG verifies signatures with",
+		Long: "npm packuments carry ECDSA signatures made with the registry's signing keys.\n" +
+			"PMG ships the keys of registry.npmjs.org; when the registry rotates them, save\n" +
+			"https://registry.npmjs.org/-/npm/v1/keys to a file and install it with\n" +
+			"\"pmg setup keys update <file>\".",
+		RunE: func(cmd *cobra.Command, _ []string) error { return cmd.Help() },
+	}
+	cmd.AddCommand(newKeysListCommand())
+	cmd.AddCommand(newKeysUpdateCommand())
+	return cmd
+}
+
+func newKeysListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        "List the npm registry keys in use",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runKeysList(config.Get(), os.Stdout)
+		},
+	}
+}
+
+func newKeysUpdateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "update <file>",
+		Short:        "Validate an npm registry keyset and install it",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runKeysUpdate(config.Get(), args[0], os.Stdout)
+		},
+	}
+}
+
+func runKeysList(cfg *config.RuntimeConfig, out io.Writer) error {
+	path := cfg.NpmKeysPath()
+	source := path
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		source = "built-in"
+	}
+
+	keys, err := npmsig.Load(path)
+	if err != nil {
+		return fmt.Errorf("load npm keys: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Keyset: %s\n", source)
+	for _, key := range keys.Keys {
+		expires := "never"
+		if key.Expires != nil {
+			expires = key.Expires.UTC().Format("2006-01-02")
+		}
+		fmt.Fprintf(&b, "- %s (expires %s)\n", key.KeyID, expires)
+	}
+
+	_, err = io.WriteString(out, b.String())
+	return err
+}
+
+func runKeysUpdate(cfg *config.RuntimeConfig, path string, out io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read npm keys: %w", err)
+	}
+
+	keys, err := npmsig.Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid npm keys %s: %w", path, err)
+	}
+
+	target := cfg.NpmKeysPath()
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".npm-keys-*.tmp")
+	if err != nil {
+		return fmt.Errorf("write npm keys: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write npm keys: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write npm keys: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("install npm keys: %w", err)
+	}
+
+	_, err = fmt.Fprintf(out, "Installed %d npm registry key(s) to %s\n", len(keys.Keys), target)
+	return err
+}
@@ -0,0 +1,44 @@
+package setup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/npmsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunKeys(t *testing.T) {
+	t.Setenv("PMG_CONFIG_DIR", t.TempDir())
+	config.Reload()
+	cfg := config.Get()
+
+	var out bytes.Buffer
+	require.NoError(t, runKeysList(cfg, &out))
+	assert.Contains(t, out.String(), "Keyset: built-in")
+	assert.Contains(t, out.String(), npmsig.Embedded().Keys[0].KeyID)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"keys":[]}`), 0o644))
+	assert.Error(t, runKeysUpdate(cfg, invalid, &out))
+	assert.NoFileExists(t, cfg.NpmKeysPath())
+
+	valid := filepath.Join(t.TempDir(), "keys.json")
+	data, err := os.ReadFile("../../internal/npmsig/keys.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(valid, data, 0o644))
+
+	out.Reset()
+	require.NoError(t, runKeysUpdate(cfg, valid, &out))
+	assert.Contains(t, out.String(), "Installed 2 npm registry key(s)")
+	assert.FileExists(t, cfg.NpmKeysPath())
+
+	out.Reset()
+	require.NoError(t, runKeysList(cfg, &out))
+	assert.Contains(t, out.String(), "Keyset: "+cfg.NpmKeysPath())
+	assert.Contains(t, out.String(), "(expires never)")
+}
@@ -34,6 +34,8 @@ func NewSetupCommand() *cobra.Command {
 	setupCmd.AddCommand(NewDoctorCommand())
 	setupCmd.AddCommand(NewCertCommand())
 	setupCmd.AddCommand(NewCacheCommand())
+	setupCmd.AddCommand(NewKeysCommand())
+	setupCmd.AddCommand(NewGitHookCommand())
 
 	return setupCmd
 }
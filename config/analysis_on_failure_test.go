@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalysisOnFailurePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"empty defaults to allow", "", AnalysisOnFailureAllow},
+		{"allow", "allow", AnalysisOnFailureAllow},
+		{"confirm", "confirm", AnalysisOnFailureConfirm},
+		{"block", "block", AnalysisOnFailureBlock},
+		{"case and whitespace are normalized", "  BLOCK ", AnalysisOnFailureBlock},
+		{"unknown value fails closed", "deny", AnalysisOnFailureBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AnalysisConfig{OnFailure: tt.value}.OnFailurePolicy())
+		})
+	}
+}
+
+func TestAnalysisOnFailureFromConfigFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PMG_CONFIG_DIR", tmpDir)
+
+	err := os.WriteFile(filepath.Join(tmpDir, "config.yml"), []byte("analysis:\n  on_failure: confirm\n"), 0o644)
+	require.NoError(t, err)
+
+	initConfig()
+	config := Get()
+
+	assert.Equal(t, AnalysisOnFailureConfirm, config.Config.Analysis.OnFailurePolicy())
+}
+
+func TestAnalysisLocalFeedMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"empty defaults to off", "", AnalysisLocalFeedOff},
+		{"off", "off", AnalysisLocalFeedOff},
+		{"first", "first", AnalysisLocalFeedFirst},
+		{"only", " Only ", AnalysisLocalFeedOnly},
+		{"unknown value keeps the feed and Malysis", "prefer", AnalysisLocalFeedFirst},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AnalysisConfig{LocalFeed: tt.value}.LocalFeedMode())
+		})
+	}
+}
+
+func TestAnalyzersStrategyName(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"empty defaults to any_block", "", AnalyzersStrategyAnyBlock},
+		{"any_block", "any_block", AnalyzersStrategyAnyBlock},
+		{"majority", " Majority ", AnalyzersStrategyMajority},
+		{"first_definitive", "first_definitive", AnalyzersStrategyFirstDefinitive},
+		{"unknown value is the most cautious", "unanimous", AnalyzersStrategyAnyBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AnalyzersConfig{Strategy: tt.value}.StrategyName())
+		})
+	}
+}
+
+func TestInstallScriptsActions(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        InstallScriptsConfig
+		newScript  string
+		suspicious string
+	}{
+		{"empty uses defaults", InstallScriptsConfig{}, HeuristicActionConfirm, HeuristicActionBlock},
+		{"explicit values", InstallScriptsConfig{NewScript: " Block ", SuspiciousScript: "confirm"}, HeuristicActionBlock, HeuristicActionConfirm},
+		{"allow disables a check", InstallScriptsConfig{NewScript: "allow"}, HeuristicActionAllow, HeuristicActionBlock},
+		{"unknown value fails closed", InstallScriptsConfig{NewScript: "warn", SuspiciousScript: "ignore"}, HeuristicActionBlock, HeuristicActionBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.newScript, tt.cfg.NewScriptAction())
+			assert.Equal(t, tt.suspicious, tt.cfg.SuspiciousScriptAction())
+		})
+	}
+}
+
+func TestTyposquatActionName(t *testing.T) {
+	assert.Equal(t, HeuristicActionConfirm, TyposquatConfig{}.ActionName())
+	assert.Equal(t, HeuristicActionBlock, TyposquatConfig{Action: "Block"}.ActionName())
+	assert.Equal(t, HeuristicActionBlock, TyposquatConfig{Action: "warn"}.ActionName())
+}
+
+func TestLicensePolicyActions(t *testing.T) {
+	assert.Equal(t, HeuristicActionBlock, LicensePolicyConfig{}.ActionName())
+	assert.Equal(t, HeuristicActionAllow, LicensePolicyConfig{}.UnknownAction())
+	assert.Equal(t, HeuristicActionConfirm, LicensePolicyConfig{Action: " Confirm "}.ActionName())
+	assert.Equal(t, HeuristicActionBlock, LicensePolicyConfig{Unknown: "deny"}.UnknownAction())
+}
+
+func TestPackageAgeActionName(t *testing.T) {
+	assert.Equal(t, HeuristicActionConfirm, PackageAgeConfig{}.ActionName())
+	assert.Equal(t, HeuristicActionAllow, PackageAgeConfig{Action: "ALLOW"}.ActionName())
+	assert.Equal(t, HeuristicActionBlock, PackageAgeConfig{Action: "quarantine"}.ActionName())
+}
+
+func TestVulnerabilitySeverityLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     VulnerabilitiesConfig
+		block   string
+		confirm string
+	}{
+		{"empty uses defaults", VulnerabilitiesConfig{}, VulnerabilitySeverityCritical, VulnerabilitySeverityHigh},
+		{"explicit values", VulnerabilitiesConfig{BlockSeverity: " High ", ConfirmSeverity: "medium"}, VulnerabilitySeverityHigh, VulnerabilitySeverityMedium},
+		{"none disables a threshold", VulnerabilitiesConfig{BlockSeverity: "none"}, VulnerabilitySeverityNone, VulnerabilitySeverityHigh},
+		{"unknown value is the most cautious", VulnerabilitiesConfig{BlockSeverity: "severe", ConfirmSeverity: "moderate"}, VulnerabilitySeverityLow, VulnerabilitySeverityLow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.block, tt.cfg.BlockSeverityLevel())
+			assert.Equal(t, tt.confirm, tt.cfg.ConfirmSeverityLevel())
+		})
+	}
+}
+
+func TestVulnerabilityIgnoreActive(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+
+	assert.True(t, VulnerabilityIgnore{ID: "CVE-1"}.Active(now), "no expiry never expires")
+	assert.True(t, VulnerabilityIgnore{ID: "CVE-1", Expires: "2026-03-15"}.Active(now), "holds through the expiry day")
+	assert.False(t, VulnerabilityIgnore{ID: "CVE-1", Expires: "2026-03-14"}.Active(now))
+	assert.False(t, VulnerabilityIgnore{ID: "CVE-1", Expires: "next year"}.Active(now), "invalid expiry is expired")
+}
@@ -72,6 +72,18 @@ var configFlagSpecs = []flagSpec{
 			fs.StringArrayVar(&sandboxAllowRaw, name, nil, usage)
 		},
 	},
+	{
+		name: "sbom", usage: "Write an SBOM of the package versions fetched through the proxy to this file", managed: false,
+		bind: func(fs *pflag.FlagSet, name, usage string) {
+			fs.StringVar(&globalConfig.SBOMPath, name, globalConfig.SBOMPath, usage)
+		},
+	},
+	{
+		name: "sbom-format", usage: "Format of the --sbom file: cyclonedx or spdx", managed: false,
+		bind: func(fs *pflag.FlagSet, name, usage string) {
+			fs.StringVar(&globalConfig.SBOMFormat, name, globalConfig.SBOMFormat, usage)
+		},
+	},
 	{
 		name: "skip-dependency-cooldown", usage: "Skip dependency cooldown enforcement", managed: true,
 		bind: func(fs *pflag.FlagSet, name, usage string) {
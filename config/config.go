@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"os"
 	"os/user"
+	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	_ "embed"
 
 	"github.com/safedep/pmg/internal/fsutil"
+	"github.com/safedep/pmg/internal/pypiname"
 
 	"github.com/safedep/dry/log"
 	"github.com/safedep/dry/usefulerror"
@@ -109,11 +112,63 @@ type Config struct {
 
 	DependencyCooldown DependencyCooldownConfig `mapstructure:"dependency_cooldown"`
 
+	// MalwareStripping configures the removal of versions with a malicious
+	// verdict from npm and PyPI metadata, so resolvers pick a clean release.
+	MalwareStripping MalwareStrippingConfig `mapstructure:"malware_stripping"`
+
+	// PackageAge configures the new package control, which holds back
+	// packages whose first release is recent, whichever version is requested.
+	PackageAge PackageAgeConfig `mapstructure:"package_age"`
+
+	// ContentScan configures the artifact content scanner, which evaluates
+	// local rule packs against the files of every downloaded package.
+	ContentScan ContentScanConfig `mapstructure:"content_scan"`
+
+	// LockfileIntegrity configures the lockfile cross-check, which compares
+	// the artifacts downloaded by a lockfile install against the hashes the
+	// lockfile pins.
+	LockfileIntegrity LockfileIntegrityConfig `mapstructure:"lockfile_integrity"`
+
+	// RegistryIntegrity configures the registry cross-check, which compares
+	// downloaded artifacts against the hashes the registry's own metadata
+	// publishes for them.
+	RegistryIntegrity RegistryIntegrityConfig `mapstructure:"registry_integrity"`
+
+	// NpmSignatures configures verification of the ECDSA registry signatures
+	// npm packuments carry in dist.signatures.
+	NpmSignatures NpmSignaturesConfig `mapstructure:"npm_signatures"`
+
+	// Provenance requires verified Sigstore provenance attestations for
+	// selected npm and PyPI packages.
+	Provenance ProvenanceConfig `mapstructure:"provenance"`
+
+	// PrivateNamespaces declares package names that must only ever be
+	// served by internal registries, guarding against dependency confusion.
+	PrivateNamespaces []PrivateNamespace `mapstructure:"private_namespaces"`
+
 	// AnalysisCache configures the optional cross-run cache of malware-analysis
 	// verdicts, so repeat installs of an already-screened dependency graph skip
 	// the per-package analysis round-trip.
 	AnalysisCache AnalysisCacheConfig `mapstructure:"analysis_cache"`
 
+	// Analysis configures how the proxy behaves when malware analysis cannot
+	// produce a verdict for a package.
+	Analysis AnalysisConfig `mapstructure:"analysis"`
+
+	// Analyzers lists the analyzers that run together on every package and
+	// how their verdicts combine. When empty, analysis.local_feed picks the
+	// analyzers.
+	Analyzers AnalyzersConfig `mapstructure:"analyzers"`
+
+	// Vulnerabilities configures the known-vulnerability policy, checked
+	// against the OSV database imported with `pmg feed import
+	// --vulnerabilities`.
+	Vulnerabilities VulnerabilitiesConfig `mapstructure:"vulnerabilities"`
+
+	// LicensePolicy configures the license control, which checks the license
+	// a package declares before it is downloaded.
+	LicensePolicy LicensePolicyConfig `mapstructure:"license_policy"`
+
 	Cloud CloudConfig `mapstructure:"cloud"`
 
 	Proxy ProxyConfig `mapstructure:"proxy"`
@@ -150,6 +205,340 @@ type MalysisCacheConfig struct {
 	TTL time.Duration `mapstructure:"ttl"`
 }
 
+// Analysis failure policies. See AnalysisConfig.OnFailure.
+const (
+	AnalysisOnFailureAllow   = "allow"
+	AnalysisOnFailureConfirm = "confirm"
+	AnalysisOnFailureBlock   = "block"
+)
+
+// Local malware feed modes. See AnalysisConfig.LocalFeed.
+const (
+	AnalysisLocalFeedOff   = "off"
+	AnalysisLocalFeedFirst = "first"
+	AnalysisLocalFeedOnly  = "only"
+)
+
+// AnalysisConfig configures malware analysis behaviour in the proxy.
+type AnalysisConfig struct {
+	// OnFailure decides what happens to a package download when the analysis
+	// backend errors or its circuit breaker is open, so no verdict exists:
+	// allow (default, fail open), confirm (ask the user) or block (fail closed).
+	OnFailure string `mapstructure:"on_failure"`
+
+	// LocalFeed decides how the malicious-package feed imported with
+	// `pmg feed import` takes part in analysis: off (default), first (checked
+	// before Malysis, which still analyzes whatever the feed does not flag) or
+	// only (the feed alone, for hosts that cannot reach the analysis API).
+	LocalFeed string `mapstructure:"local_feed"`
+}
+
+// OnFailurePolicy returns the normalized failure policy. An empty value is the
+// default (allow); an unrecognized value fails closed to block, since a typo in
+// a security setting must not silently disable it.
+func (c AnalysisConfig) OnFailurePolicy() string {
+	policy := strings.ToLower(strings.TrimSpace(c.OnFailure))
+	switch policy {
+	case "":
+		return AnalysisOnFailureAllow
+	case AnalysisOnFailureAllow, AnalysisOnFailureConfirm, AnalysisOnFailureBlock:
+		return policy
+	default:
+		log.Warnf("Unknown analysis.on_failure value %q, treating as %q", c.OnFailure, AnalysisOnFailureBlock)
+		return AnalysisOnFailureBlock
+	}
+}
+
+// LocalFeedMode returns the normalized local feed mode. An empty value is the
+// default (off); an unrecognized value is treated as first, which consults the
+// feed without giving up Malysis.
+func (c AnalysisConfig) LocalFeedMode() string {
+	mode := strings.ToLower(strings.TrimSpace(c.LocalFeed))
+	switch mode {
+	case "":
+		return AnalysisLocalFeedOff
+	case AnalysisLocalFeedOff, AnalysisLocalFeedFirst, AnalysisLocalFeedOnly:
+		return mode
+	default:
+		log.Warnf("Unknown analysis.local_feed value %q, treating as %q", c.LocalFeed, AnalysisLocalFeedFirst)
+		return AnalysisLocalFeedFirst
+	}
+}
+
+// Analyzer names accepted in AnalyzersConfig.Enabled.
+const (
+	AnalyzerMalysis        = "malysis"
+	AnalyzerLocalFeed      = "local_feed"
+	AnalyzerInstallScripts = "install_scripts"
+	AnalyzerTyposquat      = "typosquat"
+)
+
+// Heuristic actions: what a heuristic analyzer does with a finding.
+const (
+	HeuristicActionAllow   = "allow"
+	HeuristicActionConfirm = "confirm"
+	HeuristicActionBlock   = "block"
+)
+
+// Verdict combination strategies. See AnalyzersConfig.Strategy.
+const (
+	AnalyzersStrategyAnyBlock        = "any_block"
+	AnalyzersStrategyMajority        = "majority"
+	AnalyzersStrategyFirstDefinitive = "first_definitive"
+)
+
+// AnalyzersConfig runs several analyzers concurrently on each package and
+// combines their verdicts.
+type AnalyzersConfig struct {
+	// Strategy combines the verdicts: any_block (default, the most severe
+	// verdict wins), majority (the verdict more than half of the analyzers
+	// agree on) or first_definitive (the first block or allow verdict in
+	// Enabled order).
+	Strategy string `mapstructure:"strategy"`
+
+	// Enabled names the analyzers to run, in priority order: malysis,
+	// local_feed, install_scripts, typosquat or the name of a plugin. Empty
+	// keeps the analyzer analysis.local_feed selects.
+	Enabled []string `mapstructure:"enabled"`
+
+	// InstallScripts configures the install_scripts analyzer.
+	InstallScripts InstallScriptsConfig `mapstructure:"install_scripts"`
+
+	// Typosquat configures the typosquat analyzer.
+	Typosquat TyposquatConfig `mapstructure:"typosquat"`
+
+	// Plugins are out-of-process analyzers. A plugin runs only when its name
+	// is listed in Enabled.
+	Plugins []AnalyzerPluginConfig `mapstructure:"plugins"`
+}
+
+// InstallScriptsConfig configures the npm install-script heuristics. Each
+// finding maps to a heuristic action: allow, confirm or block.
+type InstallScriptsConfig struct {
+	// NewScript applies to a version that adds a lifecycle script when the
+	// previous version had none. Defaults to confirm.
+	NewScript string `mapstructure:"new_script"`
+
+	// SuspiciousScript applies to a lifecycle script that downloads or runs
+	// code, such as curl, wget, node -e or an encoded blob. Defaults to
+	// block.
+	SuspiciousScript string `mapstructure:"suspicious_script"`
+}
+
+// NewScriptAction returns the normalized new_script action.
+func (c InstallScriptsConfig) NewScriptAction() string {
+	return heuristicAction("analyzers.install_scripts.new_script", c.NewScript, HeuristicActionConfirm)
+}
+
+// SuspiciousScriptAction returns the normalized suspicious_script action.
+func (c InstallScriptsConfig) SuspiciousScriptAction() string {
+	return heuristicAction("analyzers.install_scripts.suspicious_script", c.SuspiciousScript, HeuristicActionBlock)
+}
+
+// TyposquatConfig configures the typosquatting check of package names against
+// the embedded lists of popular npm and PyPI packages.
+type TyposquatConfig struct {
+	// Action applies to a name that resembles a popular package: allow,
+	// confirm or block. Defaults to confirm.
+	Action string `mapstructure:"action"`
+
+	// NpmList and PypiList are files with one package name per line, added
+	// to the embedded lists, such as the packages your organization uses.
+	NpmList  string `mapstructure:"npm_list"`
+	PypiList string `mapstructure:"pypi_list"`
+}
+
+// ActionName returns the normalized action.
+func (c TyposquatConfig) ActionName() string {
+	return heuristicAction("analyzers.typosquat.action", c.Action, HeuristicActionConfirm)
+}
+
+// heuristicAction normalizes the heuristic action configured at key. An empty
+// value is def; an unrecognized value is treated as block, so a typo never
+// silently disables a check.
+func heuristicAction(key, value, def string) string {
+	action := strings.ToLower(strings.TrimSpace(value))
+	switch action {
+	case "":
+		return def
+	case HeuristicActionAllow, HeuristicActionConfirm, HeuristicActionBlock:
+		return action
+	default:
+		log.Warnf("Unknown %s value %q, treating as %q", key, value, HeuristicActionBlock)
+		return HeuristicActionBlock
+	}
+}
+
+// AnalyzerPluginConfig configures an out-of-process analyzer. Exactly one of
+// Command and Socket is set.
+type AnalyzerPluginConfig struct {
+	Name string `mapstructure:"name"`
+
+	// Command is an executable run once per package, with Args. It reads the
+	// request from stdin and writes the verdict to stdout.
+	Command string   `mapstructure:"command"`
+	Args    []string `mapstructure:"args"`
+
+	// Socket is the path of a Unix socket a long-running plugin serves HTTP
+	// on.
+	Socket string `mapstructure:"socket"`
+
+	// Timeout bounds each request. Defaults to 5s.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// CacheTTL is how long a verdict is reused for the same package version.
+	// Defaults to 10m; a negative value disables the cache.
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+}
+
+// Plugin returns the plugin named name. Names are matched case-insensitively,
+// like the rest of analyzers.enabled.
+func (c AnalyzersConfig) Plugin(name string) (AnalyzerPluginConfig, bool) {
+	for _, p := range c.Plugins {
+		if strings.EqualFold(strings.TrimSpace(p.Name), strings.TrimSpace(name)) {
+			return p, true
+		}
+	}
+
+	return AnalyzerPluginConfig{}, false
+}
+
+// StrategyName returns the normalized strategy. An empty value is the default
+// (any_block); an unrecognized value is treated as any_block too, the most
+// cautious combination.
+func (c AnalyzersConfig) StrategyName() string {
+	strategy := strings.ToLower(strings.TrimSpace(c.Strategy))
+	switch strategy {
+	case "":
+		return AnalyzersStrategyAnyBlock
+	case AnalyzersStrategyAnyBlock, AnalyzersStrategyMajority, AnalyzersStrategyFirstDefinitive:
+		return strategy
+	default:
+		log.Warnf("Unknown analyzers.strategy value %q, treating as %q", c.Strategy, AnalyzersStrategyAnyBlock)
+		return AnalyzersStrategyAnyBlock
+	}
+}
+
+// Vulnerability severity levels, from least to most severe. See
+// VulnerabilitiesConfig.
+const (
+	VulnerabilitySeverityNone     = "none"
+	VulnerabilitySeverityLow      = "low"
+	VulnerabilitySeverityMedium   = "medium"
+	VulnerabilitySeverityHigh     = "high"
+	VulnerabilitySeverityCritical = "critical"
+)
+
+// VulnerabilitiesConfig blocks or asks about package versions affected by a
+// known vulnerability in the imported OSV database. Only the exact version
+// requested is checked.
+type VulnerabilitiesConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// BlockSeverity is the lowest severity that blocks a version: low,
+	// medium, high or critical (default), or none to never block.
+	BlockSeverity string `mapstructure:"block_severity"`
+
+	// ConfirmSeverity is the lowest severity that asks the user before the
+	// version is installed. Defaults to high; none never asks.
+	ConfirmSeverity string `mapstructure:"confirm_severity"`
+
+	// Ignore lists advisories that do not apply, such as a vulnerability in
+	// code the project does not use.
+	Ignore []VulnerabilityIgnore `mapstructure:"ignore"`
+}
+
+// VulnerabilityIgnore waives one advisory, matched by its ID or any of its
+// aliases, such as GHSA-xxxx-xxxx-xxxx or CVE-2024-1234.
+type VulnerabilityIgnore struct {
+	ID     string `mapstructure:"id"`
+	Reason string `mapstructure:"reason"`
+
+	// Expires is the date (YYYY-MM-DD) after which the advisory applies
+	// again. Empty never expires.
+	Expires string `mapstructure:"expires"`
+}
+
+// BlockSeverityLevel returns the normalized block_severity.
+func (c VulnerabilitiesConfig) BlockSeverityLevel() string {
+	return vulnerabilitySeverity("vulnerabilities.block_severity", c.BlockSeverity, VulnerabilitySeverityCritical)
+}
+
+// ConfirmSeverityLevel returns the normalized confirm_severity.
+func (c VulnerabilitiesConfig) ConfirmSeverityLevel() string {
+	return vulnerabilitySeverity("vulnerabilities.confirm_severity", c.ConfirmSeverity, VulnerabilitySeverityHigh)
+}
+
+// vulnerabilitySeverity normalizes the severity threshold configured at key.
+// An empty value is def; an unrecognized value is treated as low, the most
+// cautious threshold, so a typo never silently disables the policy.
+func vulnerabilitySeverity(key, value, def string) string {
+	severity := strings.ToLower(strings.TrimSpace(value))
+	switch severity {
+	case "":
+		return def
+	case VulnerabilitySeverityNone, VulnerabilitySeverityLow, VulnerabilitySeverityMedium,
+		VulnerabilitySeverityHigh, VulnerabilitySeverityCritical:
+		return severity
+	default:
+		log.Warnf("Unknown %s value %q, treating as %q", key, value, VulnerabilitySeverityLow)
+		return VulnerabilitySeverityLow
+	}
+}
+
+// Active reports whether the ignore entry applies at now. An expiry that does
+// not parse is treated as expired, so a typo never waives an advisory
+// forever.
+func (i VulnerabilityIgnore) Active(now time.Time) bool {
+	expires := strings.TrimSpace(i.Expires)
+	if expires == "" {
+		return true
+	}
+
+	date, err := time.ParseInLocation(time.DateOnly, expires, time.Local)
+	if err != nil {
+		log.Warnf("Invalid vulnerabilities.ignore expiry %q for %s, ignoring the entry", i.Expires, i.ID)
+		return false
+	}
+
+	// The entry holds through the whole expiry day.
+	return now.Before(date.AddDate(0, 0, 1))
+}
+
+// LicensePolicyConfig blocks or asks about packages whose declared license
+// the organization does not accept. The license is read from the npm
+// packument, the PyPI JSON API or the license files of a Go module zip.
+type LicensePolicyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Deny lists SPDX license identifiers, or expressions listing them, that
+	// are never accepted, such as AGPL-3.0. An identifier without -only or
+	// -or-later covers both, and a trailing * matches a prefix.
+	Deny []string `mapstructure:"deny"`
+
+	// Allow, when not empty, lists the only licenses accepted. Deny takes
+	// precedence.
+	Allow []string `mapstructure:"allow"`
+
+	// Action applies to a license the policy rejects: allow (log only),
+	// confirm or block. Defaults to block.
+	Action string `mapstructure:"action"`
+
+	// Unknown applies to a package that declares no license PMG can read:
+	// allow, confirm or block. Defaults to allow.
+	Unknown string `mapstructure:"unknown"`
+}
+
+// ActionName returns the normalized action for a rejected license.
+func (c LicensePolicyConfig) ActionName() string {
+	return heuristicAction("license_policy.action", c.Action, HeuristicActionBlock)
+}
+
+// UnknownAction returns the normalized action for an undeclared license.
+func (c LicensePolicyConfig) UnknownAction() string {
+	return heuristicAction("license_policy.unknown", c.Unknown, HeuristicActionAllow)
+}
+
 // CloudConfig configures audit event sync to SafeDep Cloud.
 type CloudConfig struct {
 	Enabled    bool                `mapstructure:"enabled"`
@@ -172,6 +561,25 @@ type ProxyConfig struct {
 	SkipCommands map[string][]string   `mapstructure:"skip_commands"`
 	Server       ProxyServerConfig     `mapstructure:"server"`
 	Registries   []ProxyRegistryConfig `mapstructure:"registries"`
+
+	// MaxInspectedArtifactMB is the largest artifact, in MiB, the proxy holds
+	// in memory to inspect or hash. A larger download is blocked by the
+	// checks that fail closed, and otherwise streamed to the package manager
+	// unchecked.
+	MaxInspectedArtifactMB int `mapstructure:"max_inspected_artifact_mb"`
+}
+
+// DefaultMaxInspectedArtifactMB is used when max_inspected_artifact_mb is not
+// positive.
+const DefaultMaxInspectedArtifactMB = 256
+
+// MaxInspectedArtifactBytes returns max_inspected_artifact_mb in bytes.
+func (c ProxyConfig) MaxInspectedArtifactBytes() int64 {
+	mb := c.MaxInspectedArtifactMB
+	if mb <= 0 {
+		mb = DefaultMaxInspectedArtifactMB
+	}
+	return int64(mb) << 20
 }
 
 // ProxyServerConfig configures the persistent proxy server (`pmg proxy start`).
@@ -225,6 +633,196 @@ type DependencyCooldownConfig struct {
 	Skip []TrustedPackage `mapstructure:"skip"`
 }
 
+// PackageAgeConfig blocks or asks about packages whose first release is
+// younger than MinDays. Unlike DependencyCooldownConfig, which judges each
+// version by its own publish date, it judges the package name as a whole:
+// brand-new names are where typosquats and dependency confusion payloads
+// live, and a new package has no older version to fall back to.
+type PackageAgeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	MinDays int  `mapstructure:"min_days"`
+
+	// Action applies to a package younger than MinDays: allow (log only),
+	// confirm or block. Defaults to confirm.
+	Action string `mapstructure:"action"`
+}
+
+// ActionName returns the normalized action for a package that is too new.
+func (c PackageAgeConfig) ActionName() string {
+	return heuristicAction("package_age.action", c.Action, HeuristicActionConfirm)
+}
+
+// ContentScanConfig enables scanning artifacts against the rule packs in
+// RulesDir. Scanning only runs when at least one pack is installed.
+type ContentScanConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// RulesDir is the directory of rule packs. Empty means the rules
+	// directory under the config dir.
+	RulesDir string `mapstructure:"rules_dir"`
+}
+
+// LockfileIntegrityConfig checks the artifacts of an install from a lockfile
+// (npm ci, pnpm install, poetry install, ...) against the lockfile. Mismatch
+// applies to an artifact whose hash differs from the one pinned, Unlisted to
+// one the lockfile does not list at all. Both take allow (log only), confirm
+// or block.
+type LockfileIntegrityConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Mismatch string `mapstructure:"mismatch"`
+	Unlisted string `mapstructure:"unlisted"`
+}
+
+// MismatchAction returns the normalized action for an artifact whose hash
+// differs from the lockfile. Defaults to block.
+func (c LockfileIntegrityConfig) MismatchAction() string {
+	return heuristicAction("lockfile_integrity.mismatch", c.Mismatch, HeuristicActionBlock)
+}
+
+// UnlistedAction returns the normalized action for an artifact the lockfile
+// does not list. Defaults to confirm.
+func (c LockfileIntegrityConfig) UnlistedAction() string {
+	return heuristicAction("lockfile_integrity.unlisted", c.Unlisted, HeuristicActionConfirm)
+}
+
+// MalwareStrippingConfig removes versions with a malicious verdict from the
+// npm packuments and PEP 691 Simple API pages the package manager resolves
+// against, the way dependency cooldown removes versions inside its window.
+// The dist-tag targets and the Versions most recently published versions of
+// each package are analyzed when its metadata is fetched; a version outside
+// that set is still blocked when its artifact is downloaded. It is off by
+// default, since every metadata request then waits for those analyses.
+type MalwareStrippingConfig struct {
+	Enabled  bool `mapstructure:"enabled"`
+	Versions int  `mapstructure:"versions"`
+}
+
+// RegistryIntegrityConfig checks every downloaded artifact against the hash
+// published for it in the metadata the package manager resolved it from: the
+// dist.integrity of an npm packument or the file hash of a PyPI Simple API
+// page. A mismatch is always blocked.
+type RegistryIntegrityConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// npm registry signature policies.
+const (
+	NpmSignaturePolicyOff     = "off"
+	NpmSignaturePolicyWarn    = "warn"
+	NpmSignaturePolicyRequire = "require"
+)
+
+// npmPublicRegistryHost is the registry Policy applies to.
+const npmPublicRegistryHost = "registry.npmjs.org"
+
+// NpmSignaturesConfig verifies the registry signature of every npm package
+// version PMG downloads against the registry's signing keys. Policy applies
+// to registry.npmjs.org; Registries sets the policy of other registry hosts,
+// which default to off since a custom registry signs with its own keys, if
+// at all. A policy is off, warn (log only) or require (block a version whose
+// signature is missing or does not verify).
+type NpmSignaturesConfig struct {
+	Policy     string                       `mapstructure:"policy"`
+	Registries []NpmSignatureRegistryConfig `mapstructure:"registries"`
+}
+
+// NpmSignatureRegistryConfig is the signature policy of one registry host.
+type NpmSignatureRegistryConfig struct {
+	Host   string `mapstructure:"host"`
+	Policy string `mapstructure:"policy"`
+}
+
+// PolicyFor returns the normalized signature policy for a registry host.
+func (c NpmSignaturesConfig) PolicyFor(host string) string {
+	host = strings.ToLower(host)
+	for _, registry := range c.Registries {
+		if strings.EqualFold(registry.Host, host) {
+			return npmSignaturePolicy("npm_signatures.registries["+registry.Host+"].policy", registry.Policy, NpmSignaturePolicyOff)
+		}
+	}
+
+	if host == npmPublicRegistryHost {
+		return npmSignaturePolicy("npm_signatures.policy", c.Policy, NpmSignaturePolicyWarn)
+	}
+	return NpmSignaturePolicyOff
+}
+
+// npmSignaturePolicy normalizes a signature policy value. Empty means def;
+// unknown values fail closed to require.
+func npmSignaturePolicy(key, value, def string) string {
+	policy := strings.ToLower(strings.TrimSpace(value))
+	switch policy {
+	case "":
+		return def
+	case NpmSignaturePolicyOff, NpmSignaturePolicyWarn, NpmSignaturePolicyRequire:
+		return policy
+	default:
+		log.Warnf("Unknown %s value %q, treating as %q", key, value, NpmSignaturePolicyRequire)
+		return NpmSignaturePolicyRequire
+	}
+}
+
+// Ecosystems a provenance requirement applies to.
+const (
+	ProvenanceEcosystemNpm  = "npm"
+	ProvenanceEcosystemPyPI = "pypi"
+)
+
+// ProvenanceConfig requires a Sigstore provenance attestation for the npm
+// and PyPI packages Packages selects: npm dist.attestations and PyPI PEP 740
+// attestations, verified offline against a trusted root. A version without
+// a valid attestation for its artifact, or built from a repository other
+// than the one expected, is blocked.
+type ProvenanceConfig struct {
+	Packages []ProvenancePackage `mapstructure:"packages"`
+
+	// TrustedRoot is the path of a Sigstore trusted_root.json to verify
+	// with instead of the one built into PMG.
+	TrustedRoot string `mapstructure:"trusted_root"`
+}
+
+// ProvenancePackage selects packages that must carry provenance.
+type ProvenancePackage struct {
+	// Ecosystem is npm or pypi.
+	Ecosystem string `mapstructure:"ecosystem"`
+
+	// Name is a package name or a path.Match pattern such as @acme/*.
+	// PyPI names are compared in their normalized form.
+	Name string `mapstructure:"name"`
+
+	// Repository, when set, is the source repository URL the attestation
+	// must name, or a path.Match pattern such as https://github.com/acme/*.
+	Repository string `mapstructure:"repository"`
+}
+
+// Match returns the first entry of Packages selecting a package.
+func (c ProvenanceConfig) Match(ecosystem, name string) (ProvenancePackage, bool) {
+	for _, pkg := range c.Packages {
+		if !strings.EqualFold(pkg.Ecosystem, ecosystem) {
+			continue
+		}
+
+		pattern, candidate := pkg.Name, name
+		if strings.EqualFold(ecosystem, ProvenanceEcosystemPyPI) {
+			pattern, candidate = pypiname.Normalize(pattern), pypiname.Normalize(candidate)
+		}
+		if ok, err := path.Match(pattern, candidate); ok && err == nil {
+			return pkg, true
+		}
+	}
+	return ProvenancePackage{}, false
+}
+
+// RepositoryMatches reports whether repository, as named by an attestation,
+// is the one expected. Any repository matches when none is expected.
+func (p ProvenancePackage) RepositoryMatches(repository string) bool {
+	if p.Repository == "" {
+		return true
+	}
+	ok, err := path.Match(strings.ToLower(p.Repository), strings.ToLower(repository))
+	return ok && err == nil
+}
+
 // legacyProfileAliases maps old default profile names, keyed by package
 // manager, to their per-PM leaf profiles. When npm-restrictive and
 // pypi-restrictive became pure bases with no environment allows (and
@@ -319,6 +917,14 @@ type RuntimeConfig struct {
 	// Not persisted to config.yml.
 	SandboxAllowOverrides []SandboxAllowOverride
 
+	// SBOMPath is where an SBOM of the package versions fetched through the
+	// proxy is written (--sbom). Empty disables it. Not persisted to config.yml.
+	SBOMPath string
+
+	// SBOMFormat is the format of the SBOM written to SBOMPath (--sbom-format):
+	// cyclonedx or spdx. Not persisted to config.yml.
+	SBOMFormat string
+
 	// Internal config values computed at runtime and must be accessed via. API
 	configDir                string
 	configFilePath           string // active config: globally managed file if present, else per-user
@@ -396,6 +1002,23 @@ func (r *RuntimeConfig) ConfigDir() string {
 	return r.configDir
 }
 
+// ContentRulesDir returns the directory content scan rule packs are loaded
+// from: content_scan.rules_dir when set, else the rules directory under the
+// config dir.
+func (r *RuntimeConfig) ContentRulesDir() string {
+	if r.Config.ContentScan.RulesDir != "" {
+		return r.Config.ContentScan.RulesDir
+	}
+	return filepath.Join(r.configDir, "rules")
+}
+
+// NpmKeysPath returns the path of the npm registry keyset installed by
+// `pmg setup keys update`. The keyset built into PMG is used while the file
+// does not exist.
+func (r *RuntimeConfig) NpmKeysPath() string {
+	return filepath.Join(r.configDir, "npm-keys.json")
+}
+
 // SandboxProfileDir returns the path to the user sandbox profile directory.
 func (r *RuntimeConfig) SandboxProfileDir() string {
 	return r.sandboxProfileDir
@@ -486,12 +1109,65 @@ func DefaultConfig() RuntimeConfig {
 				Enabled: true,
 				Days:    5,
 			},
+			MalwareStripping: MalwareStrippingConfig{
+				Enabled:  false,
+				Versions: 3,
+			},
+			PackageAge: PackageAgeConfig{
+				Enabled: false,
+				MinDays: 14,
+				Action:  HeuristicActionConfirm,
+			},
+			ContentScan: ContentScanConfig{
+				Enabled:  true,
+				RulesDir: "",
+			},
+			LockfileIntegrity: LockfileIntegrityConfig{
+				Enabled:  true,
+				Mismatch: HeuristicActionBlock,
+				Unlisted: HeuristicActionConfirm,
+			},
+			RegistryIntegrity: RegistryIntegrityConfig{
+				Enabled: true,
+			},
+			NpmSignatures: NpmSignaturesConfig{
+				Policy: NpmSignaturePolicyWarn,
+			},
 			AnalysisCache: AnalysisCacheConfig{
 				Malysis: MalysisCacheConfig{
 					Enabled: false,
 					TTL:     24 * time.Hour,
 				},
 			},
+			Analysis: AnalysisConfig{
+				OnFailure: AnalysisOnFailureAllow,
+				LocalFeed: AnalysisLocalFeedOff,
+			},
+			Analyzers: AnalyzersConfig{
+				Strategy: AnalyzersStrategyAnyBlock,
+				Enabled:  []string{},
+				InstallScripts: InstallScriptsConfig{
+					NewScript:        HeuristicActionConfirm,
+					SuspiciousScript: HeuristicActionBlock,
+				},
+				Typosquat: TyposquatConfig{
+					Action: HeuristicActionConfirm,
+				},
+				Plugins: []AnalyzerPluginConfig{},
+			},
+			Vulnerabilities: VulnerabilitiesConfig{
+				Enabled:         false,
+				BlockSeverity:   VulnerabilitySeverityCritical,
+				ConfirmSeverity: VulnerabilitySeverityHigh,
+				Ignore:          []VulnerabilityIgnore{},
+			},
+			LicensePolicy: LicensePolicyConfig{
+				Enabled: false,
+				Deny:    []string{},
+				Allow:   []string{},
+				Action:  HeuristicActionBlock,
+				Unknown: HeuristicActionAllow,
+			},
 			Cloud: CloudConfig{
 				Enabled: false,
 				AutoSync: CloudAutoSyncConfig{
@@ -506,6 +1182,7 @@ func DefaultConfig() RuntimeConfig {
 				Server: ProxyServerConfig{
 					ListenHost: "127.0.0.1",
 				},
+				MaxInspectedArtifactMB: DefaultMaxInspectedArtifactMB,
 			},
 		},
 		DryRun:               false,
@@ -619,9 +1296,10 @@ func initConfig() {
 var configLoadErr error
 
 // LoadError returns the error that made configuration loading fail closed,
-// or nil. Only an invalid proxy.registries entry sets this, since falling
-// back to defaults would silently drop the user's custom-registry
-// protections; every other load failure still warns and continues.
+// or nil. Only an invalid proxy.registries or private_namespaces entry sets
+// this, since falling back to defaults would silently drop the user's
+// custom-registry protections; every other load failure still warns and
+// continues.
 func LoadError() error {
 	return configLoadErr
 }
@@ -636,7 +1314,7 @@ func NewInvalidProxyRegistriesError(err error) error {
 	return usefulerror.NewUsefulError().
 		WithCode(errcodes.InvalidProxyRegistries).
 		WithHumanError(fmt.Sprintf("invalid proxy registries configuration: %v", detail)).
-		WithHelp("Fix the proxy.registries entries in your PMG configuration file, then retry.").
+		WithHelp("Fix the proxy.registries and private_namespaces entries in your PMG configuration file, then retry.").
 		Wrap(err)
 }
 
@@ -62,11 +62,41 @@ func TestTemplateMatchesDefaults(t *testing.T) {
 
 	assert.Equal(t, def.DependencyCooldown.Enabled, parsed.DependencyCooldown.Enabled, "dependency_cooldown.enabled mismatch")
 	assert.Equal(t, def.DependencyCooldown.Days, parsed.DependencyCooldown.Days, "dependency_cooldown.days mismatch")
+	assert.Equal(t, def.MalwareStripping, parsed.MalwareStripping, "malware_stripping mismatch")
+	assert.False(t, parsed.MalwareStripping.Enabled, "template malware_stripping must be disabled")
 	assert.Equal(t, def.AdvisoryMessage, parsed.AdvisoryMessage, "advisory_message mismatch")
 
+	assert.Equal(t, def.Analysis.OnFailure, parsed.Analysis.OnFailure, "analysis.on_failure mismatch")
+	assert.Equal(t, def.Analysis.LocalFeed, parsed.Analysis.LocalFeed, "analysis.local_feed mismatch")
+	assert.Equal(t, def.Analyzers.Strategy, parsed.Analyzers.Strategy, "analyzers.strategy mismatch")
+	assert.Empty(t, def.Analyzers.Enabled, "default analyzers.enabled must be empty")
+	assert.Empty(t, parsed.Analyzers.Enabled, "template analyzers.enabled must be empty")
+	assert.Empty(t, parsed.Analyzers.Plugins, "template analyzers.plugins must be empty")
+	assert.Equal(t, def.Analyzers.InstallScripts, parsed.Analyzers.InstallScripts, "analyzers.install_scripts mismatch")
+	assert.Equal(t, def.Analyzers.Typosquat, parsed.Analyzers.Typosquat, "analyzers.typosquat mismatch")
+	assert.Equal(t, def.Vulnerabilities.Enabled, parsed.Vulnerabilities.Enabled, "vulnerabilities.enabled mismatch")
+	assert.Equal(t, def.Vulnerabilities.BlockSeverity, parsed.Vulnerabilities.BlockSeverity, "vulnerabilities.block_severity mismatch")
+	assert.Equal(t, def.Vulnerabilities.ConfirmSeverity, parsed.Vulnerabilities.ConfirmSeverity, "vulnerabilities.confirm_severity mismatch")
+	assert.Empty(t, parsed.Vulnerabilities.Ignore, "template vulnerabilities.ignore must be empty")
+	assert.Equal(t, def.PackageAge, parsed.PackageAge, "package_age mismatch")
+	assert.Equal(t, def.ContentScan, parsed.ContentScan, "content_scan mismatch")
+	assert.Equal(t, def.LockfileIntegrity, parsed.LockfileIntegrity, "lockfile_integrity mismatch")
+	assert.Equal(t, def.RegistryIntegrity, parsed.RegistryIntegrity, "registry_integrity mismatch")
+	assert.Equal(t, def.NpmSignatures.Policy, parsed.NpmSignatures.Policy, "npm_signatures.policy mismatch")
+	assert.Empty(t, parsed.NpmSignatures.Registries, "template npm_signatures.registries must be empty")
+	assert.Empty(t, parsed.Provenance.Packages, "template provenance.packages must be empty")
+	assert.Equal(t, def.Provenance.TrustedRoot, parsed.Provenance.TrustedRoot, "provenance.trusted_root mismatch")
+	assert.Empty(t, parsed.PrivateNamespaces, "template private_namespaces must be empty")
+	assert.Equal(t, def.LicensePolicy.Enabled, parsed.LicensePolicy.Enabled, "license_policy.enabled mismatch")
+	assert.Equal(t, def.LicensePolicy.Action, parsed.LicensePolicy.Action, "license_policy.action mismatch")
+	assert.Equal(t, def.LicensePolicy.Unknown, parsed.LicensePolicy.Unknown, "license_policy.unknown mismatch")
+	assert.Empty(t, parsed.LicensePolicy.Deny, "template license_policy.deny must be empty")
+	assert.Empty(t, parsed.LicensePolicy.Allow, "template license_policy.allow must be empty")
+
 	assert.Equal(t, def.Cloud.Enabled, parsed.Cloud.Enabled, "cloud.enabled mismatch")
 	assert.Empty(t, def.Proxy.Registries, "default proxy.registries must be empty")
 	assert.Empty(t, parsed.Proxy.Registries, "template proxy.registries must be empty")
+	assert.Equal(t, def.Proxy.MaxInspectedArtifactMB, parsed.Proxy.MaxInspectedArtifactMB, "proxy.max_inspected_artifact_mb mismatch")
 }
 
 func TestTemplateHasCommentedRegistryExample(t *testing.T) {
@@ -26,6 +26,7 @@ func TestConfigHasDefaultValues(t *testing.T) {
 		assert.Equal(t, "/tmp/pmg-test/random-does-not-exist", config.configDir)
 		assert.Equal(t, "/tmp/pmg-test/random-does-not-exist/config.yml", config.configFilePath)
 		assert.Equal(t, false, config.Config.Proxy.InstallOnly)
+		assert.Equal(t, true, config.Config.RegistryIntegrity.Enabled)
 	})
 
 	t.Run("when no config directory is set", func(t *testing.T) {
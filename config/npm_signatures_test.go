@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNpmSignaturesPolicyFor(t *testing.T) {
+	cfg := NpmSignaturesConfig{
+		Registries: []NpmSignatureRegistryConfig{
+			{Host: "npm.corp.example", Policy: "require"},
+			{Host: "mirror.example", Policy: "bogus"},
+			{Host: "registry.npmjs.org", Policy: "off"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		cfg      NpmSignaturesConfig
+		host     string
+		expected string
+	}{
+		{"public registry defaults to warn", NpmSignaturesConfig{}, "registry.npmjs.org", NpmSignaturePolicyWarn},
+		{"public registry policy", NpmSignaturesConfig{Policy: " REQUIRE "}, "registry.npmjs.org", NpmSignaturePolicyRequire},
+		{"other registries default to off", NpmSignaturesConfig{Policy: "require"}, "npm.corp.example", NpmSignaturePolicyOff},
+		{"listed registry", cfg, "NPM.corp.example", NpmSignaturePolicyRequire},
+		{"listed entry overrides the public policy", cfg, "registry.npmjs.org", NpmSignaturePolicyOff},
+		{"unknown value fails closed", cfg, "mirror.example", NpmSignaturePolicyRequire},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.cfg.PolicyFor(tt.host))
+		})
+	}
+}
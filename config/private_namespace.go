@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/safedep/pmg/internal/pypiname"
+)
+
+// Ecosystems a private namespace can be declared for.
+const (
+	PrivateNamespaceEcosystemNpm  = "npm"
+	PrivateNamespaceEcosystemPyPI = "pypi"
+	PrivateNamespaceEcosystemGo   = "go"
+)
+
+// PrivateNamespace declares package names that belong to an internal
+// registry. A request for one of them to any other registry PMG intercepts
+// is refused, so a public package of the same name cannot be installed in
+// its place (dependency confusion).
+type PrivateNamespace struct {
+	// Ecosystem is npm, pypi or go.
+	Ecosystem string `mapstructure:"ecosystem"`
+
+	// Prefix selects the names: an npm scope such as @acme, a PyPI project
+	// name prefix such as acme-, or a Go module path prefix such as
+	// github.com/acme-corp. PyPI prefixes are compared in their normalized
+	// form, and a Go prefix matches whole path elements only.
+	Prefix string `mapstructure:"prefix"`
+
+	// Registries names the proxy.registries entries allowed to serve the
+	// names. Go modules are not served through proxy.registries: a Go
+	// namespace has no registries and only keeps the names off the public
+	// module proxy.
+	Registries []string `mapstructure:"registries"`
+}
+
+// Matches reports whether the namespace selects a package of ecosystem.
+func (n PrivateNamespace) Matches(ecosystem, name string) bool {
+	if !strings.EqualFold(n.Ecosystem, ecosystem) || n.Prefix == "" {
+		return false
+	}
+
+	switch strings.ToLower(ecosystem) {
+	case PrivateNamespaceEcosystemNpm:
+		scope := strings.ToLower(strings.TrimSuffix(n.Prefix, "/"))
+		return strings.HasPrefix(strings.ToLower(name), scope+"/")
+	case PrivateNamespaceEcosystemPyPI:
+		return strings.HasPrefix(pypiname.Normalize(name), pypiname.Normalize(n.Prefix))
+	case PrivateNamespaceEcosystemGo:
+		prefix := strings.TrimSuffix(n.Prefix, "/")
+		return name == prefix || strings.HasPrefix(name, prefix+"/")
+	}
+	return false
+}
+
+// AllowsRegistry reports whether the proxy.registries entry named registry
+// may serve the namespace's names.
+func (n PrivateNamespace) AllowsRegistry(registry string) bool {
+	return slices.Contains(n.Registries, registry)
+}
+
+// PrivateNamespaceFor returns the first of namespaces selecting a package.
+func PrivateNamespaceFor(namespaces []PrivateNamespace, ecosystem, name string) (PrivateNamespace, bool) {
+	for _, namespace := range namespaces {
+		if namespace.Matches(ecosystem, name) {
+			return namespace, true
+		}
+	}
+	return PrivateNamespace{}, false
+}
+
+// ValidatePrivateNamespaces checks that every npm and PyPI namespace names
+// at least one proxy.registries entry of its ecosystem, and that Go
+// namespaces name none.
+func ValidatePrivateNamespaces(namespaces []PrivateNamespace, registries []ProxyRegistryConfig) error {
+	ecosystems := make(map[string]string, len(registries))
+	for _, registry := range registries {
+		ecosystems[registry.Name] = registry.Ecosystem
+	}
+
+	for index, namespace := range namespaces {
+		if strings.TrimSpace(namespace.Prefix) == "" {
+			return fmt.Errorf("private_namespaces[%d].prefix is required", index)
+		}
+
+		switch strings.ToLower(namespace.Ecosystem) {
+		case PrivateNamespaceEcosystemNpm:
+			if !strings.HasPrefix(namespace.Prefix, "@") || strings.Contains(strings.TrimSuffix(namespace.Prefix, "/"), "/") {
+				return fmt.Errorf("private namespace %q must be an npm scope such as @acme", namespace.Prefix)
+			}
+		case PrivateNamespaceEcosystemPyPI:
+		case PrivateNamespaceEcosystemGo:
+			if len(namespace.Registries) > 0 {
+				return fmt.Errorf("private namespace %q: Go modules are not served through proxy.registries, so registries must be empty", namespace.Prefix)
+			}
+			continue
+		default:
+			return fmt.Errorf("private namespace %q has unsupported ecosystem %q", namespace.Prefix, namespace.Ecosystem)
+		}
+
+		if len(namespace.Registries) == 0 {
+			return fmt.Errorf("private namespace %q must name at least one proxy.registries entry", namespace.Prefix)
+		}
+		for _, name := range namespace.Registries {
+			ecosystem, ok := ecosystems[name]
+			if !ok {
+				return fmt.Errorf("private namespace %q names unknown proxy registry %q", namespace.Prefix, name)
+			}
+			if !strings.EqualFold(ecosystem, namespace.Ecosystem) {
+				return fmt.Errorf("private namespace %q is for %s but proxy registry %q is for %s",
+					namespace.Prefix, strings.ToLower(namespace.Ecosystem), name, ecosystem)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivateNamespaceMatches(t *testing.T) {
+	npm := PrivateNamespace{Ecosystem: "npm", Prefix: "@acme"}
+	assert.True(t, npm.Matches("npm", "@acme/widgets"))
+	assert.True(t, npm.Matches("npm", "@ACME/widgets"))
+	assert.False(t, npm.Matches("npm", "@acme-corp/widgets"))
+	assert.False(t, npm.Matches("npm", "acme"))
+	assert.False(t, npm.Matches("pypi", "@acme/widgets"))
+
+	pypi := PrivateNamespace{Ecosystem: "pypi", Prefix: "acme_"}
+	assert.True(t, pypi.Matches("pypi", "acme-widgets"))
+	assert.True(t, pypi.Matches("pypi", "Acme.Widgets"))
+	assert.False(t, pypi.Matches("pypi", "acmewidgets"))
+
+	golang := PrivateNamespace{Ecosystem: "go", Prefix: "github.com/acme-corp"}
+	assert.True(t, golang.Matches("go", "github.com/acme-corp"))
+	assert.True(t, golang.Matches("go", "github.com/acme-corp/widgets/v2"))
+	assert.False(t, golang.Matches("go", "github.com/acme-corporation/widgets"))
+
+	namespace, ok := PrivateNamespaceFor([]PrivateNamespace{pypi, npm}, "npm", "@acme/widgets")
+	assert.True(t, ok)
+	assert.Equal(t, npm, namespace)
+}
+
+func TestValidatePrivateNamespaces(t *testing.T) {
+	registries := []ProxyRegistryConfig{
+		{Name: "corp-npm", Ecosystem: "npm"},
+		{Name: "corp-pypi", Ecosystem: "pypi"},
+	}
+
+	tests := []struct {
+		name      string
+		namespace PrivateNamespace
+		wantErr   string
+	}{
+		{
+			name:      "npm scope",
+			namespace: PrivateNamespace{Ecosystem: "npm", Prefix: "@acme", Registries: []string{"corp-npm"}},
+		},
+		{
+			name:      "pypi prefix",
+			namespace: PrivateNamespace{Ecosystem: "pypi", Prefix: "acme-", Registries: []string{"corp-pypi"}},
+		},
+		{
+			name:      "go prefix",
+			namespace: PrivateNamespace{Ecosystem: "go", Prefix: "github.com/acme-corp"},
+		},
+		{
+			name:      "missing prefix",
+			namespace: PrivateNamespace{Ecosystem: "npm", Registries: []string{"corp-npm"}},
+			wantErr:   "private_namespaces[0].prefix is required",
+		},
+		{
+			name:      "npm prefix is not a scope",
+			namespace: PrivateNamespace{Ecosystem: "npm", Prefix: "acme", Registries: []string{"corp-npm"}},
+			wantErr:   "must be an npm scope",
+		},
+		{
+			name:      "unsupported ecosystem",
+			namespace: PrivateNamespace{Ecosystem: "maven", Prefix: "com.acme", Registries: []string{"corp-npm"}},
+			wantErr:   `unsupported ecosystem "maven"`,
+		},
+		{
+			name:      "no registries",
+			namespace: PrivateNamespace{Ecosystem: "npm", Prefix: "@acme"},
+			wantErr:   "must name at least one proxy.registries entry",
+		},
+		{
+			name:      "unknown registry",
+			namespace: PrivateNamespace{Ecosystem: "npm", Prefix: "@acme", Registries: []string{"corp"}},
+			wantErr:   `unknown proxy registry "corp"`,
+		},
+		{
+			name:      "registry of another ecosystem",
+			namespace: PrivateNamespace{Ecosystem: "npm", Prefix: "@acme", Registries: []string{"corp-pypi"}},
+			wantErr:   `proxy registry "corp-pypi" is for pypi`,
+		},
+		{
+			name:      "go with registries",
+			namespace: PrivateNamespace{Ecosystem: "go", Prefix: "github.com/acme-corp", Registries: []string{"corp-npm"}},
+			wantErr:   "registries must be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePrivateNamespaces([]PrivateNamespace{tt.namespace}, registries)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenanceMatch(t *testing.T) {
+	cfg := ProvenanceConfig{
+		Packages: []ProvenancePackage{
+			{Ecosystem: "npm", Name: "@acme/*", Repository: "https://github.com/acme/*"},
+			{Ecosystem: "pypi", Name: "Lite_LLM", Repository: "https://github.com/BerriAI/litellm"},
+			{Ecosystem: "npm", Name: "left-pad"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		ecosystem string
+		pkg       string
+		matched   bool
+		expected  string
+	}{
+		{"scope pattern", "npm", "@acme/widgets", true, "@acme/*"},
+		{"pattern does not cross scopes", "npm", "@acme-evil/widgets", false, ""},
+		{"exact name", "npm", "left-pad", true, "left-pad"},
+		{"normalized pypi name", "pypi", "lite.llm", true, "Lite_LLM"},
+		{"other ecosystem", "pypi", "left-pad", false, ""},
+		{"unlisted", "npm", "express", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, ok := cfg.Match(tt.ecosystem, tt.pkg)
+			assert.Equal(t, tt.matched, ok)
+			assert.Equal(t, tt.expected, pkg.Name)
+		})
+	}
+}
+
+func TestProvenanceRepositoryMatches(t *testing.T) {
+	pkg := ProvenancePackage{Repository: "https://github.com/acme/*"}
+	assert.True(t, pkg.RepositoryMatches("https://github.com/Acme/widgets"))
+	assert.False(t, pkg.RepositoryMatches("https://github.com/acme-forks/widgets"))
+	assert.False(t, pkg.RepositoryMatches(""))
+
+	assert.True(t, ProvenancePackage{}.RepositoryMatches("https://github.com/anyone/anything"))
+}
@@ -70,7 +70,9 @@ func ValidateProxyRegistries(registries []ProxyRegistryConfig) error {
 		}
 		names[name] = struct{}{}
 
-		if registry.Ecosystem != "npm" && registry.Ecosystem != "pypi" {
+		switch registry.Ecosystem {
+		case "npm", "pypi", "rubygems", "maven", "nuget", "composer":
+		default:
 			return fmt.Errorf("proxy registry %q has unsupported ecosystem %q", name, registry.Ecosystem)
 		}
 		if len(registry.Endpoints) == 0 {
@@ -38,6 +38,46 @@ func TestValidateProxyRegistries(t *testing.T) {
 				}},
 			}},
 		},
+		{
+			name: "valid nuget registry",
+			registries: []ProxyRegistryConfig{{
+				Name:      "company-nuget",
+				Ecosystem: "nuget",
+				Endpoints: []ProxyRegistryEndpointConfig{{
+					URL: "https://pkgs.dev.azure.com/acme/_packaging/feed/nuget/v3/",
+				}},
+			}},
+		},
+		{
+			name: "valid composer registry",
+			registries: []ProxyRegistryConfig{{
+				Name:      "company-composer",
+				Ecosystem: "composer",
+				Endpoints: []ProxyRegistryEndpointConfig{{
+					URL: "https://repo.packagist.com/acme/",
+				}},
+			}},
+		},
+		{
+			name: "valid maven registry",
+			registries: []ProxyRegistryConfig{{
+				Name:      "company-maven",
+				Ecosystem: "maven",
+				Endpoints: []ProxyRegistryEndpointConfig{{
+					URL: "https://nexus.example.test/repository/maven-public/",
+				}},
+			}},
+		},
+		{
+			name: "valid rubygems registry",
+			registries: []ProxyRegistryConfig{{
+				Name:      "company-gems",
+				Ecosystem: "rubygems",
+				Endpoints: []ProxyRegistryEndpointConfig{{
+					URL: "https://gem.fury.io/acme/",
+				}},
+			}},
+		},
 		{
 			name: "duplicate name",
 			registries: []ProxyRegistryConfig{
@@ -76,10 +116,10 @@ func TestValidateProxyRegistries(t *testing.T) {
 			name: "unsupported ecosystem",
 			registries: []ProxyRegistryConfig{{
 				Name:      "packages",
-				Ecosystem: "maven",
+				Ecosystem: "conda",
 				Endpoints: []ProxyRegistryEndpointConfig{{URL: "https://packages.example.test/"}},
 			}},
-			wantErr: `proxy registry "packages" has unsupported ecosystem "maven"`,
+			wantErr: `proxy registry "packages" has unsupported ecosystem "conda"`,
 		},
 		{
 			name: "missing ecosystem",
@@ -484,7 +524,7 @@ proxy:
     pypi: [list]
   registries:
     - name: company-npm
-      ecosystem: maven
+      ecosystem: conda
       endpoints:
         - url: https://packages.example.test/npm
 `,
@@ -529,7 +569,7 @@ paranoid: true
 proxy:
   registries:
     - name: packages
-      ecosystem: maven
+      ecosystem: conda
       endpoints:
         - url: https://packages.example.test/npm
 `), 0o644))
@@ -55,6 +55,9 @@ func loadViperConfig() error {
 	if err := ValidateProxyRegistries(merged.Proxy.Registries); err != nil {
 		return &ProxyRegistriesError{err: err}
 	}
+	if err := ValidatePrivateNamespaces(merged.PrivateNamespaces, merged.Proxy.Registries); err != nil {
+		return &ProxyRegistriesError{err: err}
+	}
 
 	globalConfig.Config = merged
 
@@ -37,6 +37,11 @@ const (
 	ProxyPolicyViolation   = "ProxyPolicyViolation"
 	InvalidProxyRegistries = "InvalidProxyRegistries"
 
+	// Scan error codes. ScanPolicyViolation is returned when a scan of
+	// lockfiles or installed packages finds versions the install-time policy
+	// would block, so CI jobs fail without running an install.
+	ScanPolicyViolation = "ScanPolicyViolation"
+
 	// Cloud error codes. CloudCredentialsNotFound is returned when a cloud
 	// operation needs SafeDep Cloud credentials but none are configured in the
 	// keychain or environment.
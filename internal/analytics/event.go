@@ -1,18 +1,27 @@
 package analytics
 
 const (
-	eventRun           = "pmg_command_run"
-	eventCommandNpm    = "pmg_command_npm"
-	eventCommandBun    = "pmg_command_bun"
-	eventCommandPnpm   = "pmg_command_pnpm"
-	eventCommandYarn   = "pmg_command_yarn"
-	eventCommandPip    = "pmg_command_pip"
-	eventCommandPip3   = "pmg_command_pip3"
-	eventCommandUv     = "pmg_command_uv"
-	eventCommandPoetry = "pmg_command_poetry"
-	eventCommandPipx   = "pmg_command_pipx"
-	eventCommandUvx    = "pmg_command_uvx"
-	eventCommandGo     = "pmg_command_go"
+	eventRun             = "pmg_command_run"
+	eventCommandNpm      = "pmg_command_npm"
+	eventCommandBun      = "pmg_command_bun"
+	eventCommandPnpm     = "pmg_command_pnpm"
+	eventCommandYarn     = "pmg_command_yarn"
+	eventCommandPip      = "pmg_command_pip"
+	eventCommandPip3     = "pmg_command_pip3"
+	eventCommandUv       = "pmg_command_uv"
+	eventCommandPoetry   = "pmg_command_poetry"
+	eventCommandPipx     = "pmg_command_pipx"
+	eventCommandUvx      = "pmg_command_uvx"
+	eventCommandGo       = "pmg_command_go"
+	eventCommandCargo    = "pmg_command_cargo"
+	eventCommandGem      = "pmg_command_gem"
+	eventCommandBundle   = "pmg_command_bundle"
+	eventCommandMvn      = "pmg_command_mvn"
+	eventCommandGradle   = "pmg_command_gradle"
+	eventCommandGradlew  = "pmg_command_gradlew"
+	eventCommandDotnet   = "pmg_command_dotnet"
+	eventCommandNuGet    = "pmg_command_nuget"
+	eventCommandComposer = "pmg_command_composer"
 
 	eventCommandNpx  = "pmg_command_npx"
 	eventCommandPnpx = "pmg_command_pnpx"
@@ -78,6 +87,42 @@ func TrackCommandGo() {
 	TrackEvent(eventCommandGo)
 }
 
+func TrackCommandCargo() {
+	TrackEvent(eventCommandCargo)
+}
+
+func TrackCommandGem() {
+	TrackEvent(eventCommandGem)
+}
+
+func TrackCommandBundle() {
+	TrackEvent(eventCommandBundle)
+}
+
+func TrackCommandMvn() {
+	TrackEvent(eventCommandMvn)
+}
+
+func TrackCommandGradle() {
+	TrackEvent(eventCommandGradle)
+}
+
+func TrackCommandGradlew() {
+	TrackEvent(eventCommandGradlew)
+}
+
+func TrackCommandDotnet() {
+	TrackEvent(eventCommandDotnet)
+}
+
+func TrackCommandNuGet() {
+	TrackEvent(eventCommandNuGet)
+}
+
+func TrackCommandComposer() {
+	TrackEvent(eventCommandComposer)
+}
+
 func TrackCommandGenerateEnvDocker() {
 	TrackEvent(eventPmgGenerateEnvDocker)
 }
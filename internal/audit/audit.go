@@ -3,6 +3,7 @@ package audit
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
@@ -79,7 +80,18 @@ func pkgEcosystem(pv *packagev1.PackageVersion) string {
 }
 
 // LogMalwareBlocked records that a package was blocked due to malware detection.
-func LogMalwareBlocked(pv *packagev1.PackageVersion, reason, analysisID, referenceURL string, isMalware, isVerified bool) {
+// decidedBy names the analyzer whose verdict decided, when several analyzers
+// were combined.
+func LogMalwareBlocked(pv *packagev1.PackageVersion, reason, analysisID, referenceURL, decidedBy string, isMalware, isVerified bool) {
+	details := map[string]any{
+		"reason":        reason,
+		"analysis_id":   analysisID,
+		"reference_url": referenceURL,
+	}
+	if decidedBy != "" {
+		details["analyzer"] = decidedBy
+	}
+
 	logEvent(AuditEvent{
 		Type:           EventTypeMalwareBlocked,
 		Message:        fmt.Sprintf("Blocked installation of malicious package: %s@%s", pkgName(pv), pkgVersion(pv)),
@@ -87,11 +99,54 @@ func LogMalwareBlocked(pv *packagev1.PackageVersion, reason, analysisID, referen
 		AnalysisID:     analysisID,
 		IsMalware:      isMalware,
 		IsVerified:     isVerified,
-		Details: map[string]any{
-			"reason":        reason,
-			"analysis_id":   analysisID,
-			"reference_url": referenceURL,
-		},
+		Details:        details,
+	})
+
+	if global != nil {
+		global.recordBlocked()
+	}
+}
+
+// LogMalwareStripped records that a malicious version was removed from the
+// package metadata served to the package manager. It is not a block: the
+// resolver falls back to another version, so the session is not counted as
+// blocked.
+func LogMalwareStripped(pv *packagev1.PackageVersion, reason, analysisID, referenceURL, decidedBy string) {
+	details := map[string]any{
+		"reason":        reason,
+		"analysis_id":   analysisID,
+		"reference_url": referenceURL,
+	}
+	if decidedBy != "" {
+		details["analyzer"] = decidedBy
+	}
+
+	logEvent(AuditEvent{
+		Type:           EventTypeMalwareStripped,
+		Message:        fmt.Sprintf("Withheld malicious version from package metadata: %s@%s", pkgName(pv), pkgVersion(pv)),
+		PackageVersion: pv,
+		AnalysisID:     analysisID,
+		IsMalware:      true,
+		Details:        details,
+	})
+}
+
+// LogVulnerabilityBlocked records that a package was blocked by the
+// vulnerability policy. severity is that of the most severe advisory.
+func LogVulnerabilityBlocked(pv *packagev1.PackageVersion, advisories []string, severity, decidedBy string) {
+	details := map[string]any{
+		"advisories": advisories,
+		"severity":   severity,
+	}
+	if decidedBy != "" {
+		details["analyzer"] = decidedBy
+	}
+
+	logEvent(AuditEvent{
+		Type:           EventTypeVulnerabilityBlocked,
+		Message:        fmt.Sprintf("Blocked installation of vulnerable package: %s@%s (%s)", pkgName(pv), pkgVersion(pv), strings.Join(advisories, ", ")),
+		PackageVersion: pv,
+		Details:        details,
 	})
 
 	if global != nil {
@@ -100,7 +155,12 @@ func LogMalwareBlocked(pv *packagev1.PackageVersion, reason, analysisID, referen
 }
 
 // LogMalwareConfirmed records that the user confirmed installation of a flagged package.
-func LogMalwareConfirmed(pv *packagev1.PackageVersion, analysisID string, isMalware, isVerified bool) {
+func LogMalwareConfirmed(pv *packagev1.PackageVersion, analysisID, decidedBy string, isMalware, isVerified bool) {
+	var details map[string]any
+	if decidedBy != "" {
+		details = map[string]any{"analyzer": decidedBy}
+	}
+
 	logEvent(AuditEvent{
 		Type:           EventTypeMalwareConfirmed,
 		Message:        fmt.Sprintf("User confirmed installation of flagged package: %s@%s", pkgName(pv), pkgVersion(pv)),
@@ -108,6 +168,7 @@ func LogMalwareConfirmed(pv *packagev1.PackageVersion, analysisID string, isMalw
 		AnalysisID:     analysisID,
 		IsMalware:      isMalware,
 		IsVerified:     isVerified,
+		Details:        details,
 	})
 
 	if global != nil {
@@ -229,6 +290,336 @@ func LogCooldownSkipped(pv *packagev1.PackageVersion) {
 	})
 }
 
+// Decisions recorded by LogAnalysisUnavailable, one per analysis.on_failure
+// outcome.
+const (
+	AnalysisUnavailableAllowed   = "allowed"
+	AnalysisUnavailableConfirmed = "confirmed"
+	AnalysisUnavailableBlocked   = "blocked"
+)
+
+// LogAnalysisUnavailable records a degraded decision: malware analysis produced
+// no verdict for the package, and the analysis.on_failure policy decided whether
+// it was installed.
+func LogAnalysisUnavailable(pv *packagev1.PackageVersion, policy, decision string, cause error) {
+	details := map[string]any{
+		"policy":   policy,
+		"decision": decision,
+	}
+	if cause != nil {
+		details["error"] = cause.Error()
+	}
+
+	logEvent(AuditEvent{
+		Type:           EventTypeAnalysisUnavailable,
+		Message:        fmt.Sprintf("Malware analysis unavailable for %s@%s, %s by on_failure policy %q", pkgName(pv), pkgVersion(pv), decision, policy),
+		PackageVersion: pv,
+		Reason:         policy,
+		Details:        details,
+		Error:          cause,
+	})
+
+	if global == nil {
+		return
+	}
+
+	// No install_allowed event follows a degraded decision, so this event
+	// carries the session accounting for the package on its own.
+	switch decision {
+	case AnalysisUnavailableBlocked:
+		global.recordBlocked()
+	case AnalysisUnavailableConfirmed:
+		global.recordConfirmed()
+		global.recordAllowed()
+	default:
+		global.recordAllowed()
+	}
+}
+
+// Decisions recorded by LogLicensePolicy, one per license_policy outcome.
+const (
+	LicensePolicyAllowed   = "allowed"
+	LicensePolicyConfirmed = "confirmed"
+	LicensePolicyBlocked   = "blocked"
+)
+
+// LogLicensePolicy records a package whose declared license the license
+// policy rejected or could not read. verdict says which ("denied", "not
+// allowed" or "undeclared"); license is the license that decided it.
+func LogLicensePolicy(pv *packagev1.PackageVersion, declared, license, verdict, decision string) {
+	details := map[string]any{
+		"verdict":  verdict,
+		"decision": decision,
+	}
+	if declared != "" {
+		details["declared"] = declared
+	}
+	if license != "" {
+		details["license"] = license
+	}
+
+	logEvent(AuditEvent{
+		Type:           EventTypeLicensePolicy,
+		Message:        fmt.Sprintf("License of %s@%s is %s, %s by license policy", pkgName(pv), pkgVersion(pv), verdict, decision),
+		PackageVersion: pv,
+		Reason:         verdict,
+		Details:        details,
+	})
+
+	if global == nil {
+		return
+	}
+
+	// An allowed or confirmed package goes on to malware analysis, whose
+	// own event records the install.
+	switch decision {
+	case LicensePolicyBlocked:
+		global.recordBlocked()
+	case LicensePolicyConfirmed:
+		global.recordConfirmed()
+	}
+}
+
+// Decisions recorded by LogPackageAge, one per package_age outcome.
+const (
+	PackageAgeAllowed   = "allowed"
+	PackageAgeConfirmed = "confirmed"
+	PackageAgeBlocked   = "blocked"
+)
+
+// LogPackageAge records a package whose first release is younger than the
+// package_age minimum.
+func LogPackageAge(pv *packagev1.PackageVersion, firstRelease time.Time, minDays, daysAgo int, decision string) {
+	logEvent(AuditEvent{
+		Type:           EventTypePackageAge,
+		Message:        fmt.Sprintf("Package %s first released %d days ago, %s by package age policy", pkgName(pv), daysAgo, decision),
+		PackageVersion: pv,
+		PublishDate:    firstRelease,
+		CooldownDays:   minDays,
+		DaysAgo:        daysAgo,
+		Details: map[string]any{
+			"decision":      decision,
+			"first_release": firstRelease.UTC().Format(time.RFC3339),
+			"min_days":      minDays,
+		},
+	})
+
+	if global == nil {
+		return
+	}
+
+	switch decision {
+	case PackageAgeBlocked:
+		global.recordBlocked()
+	case PackageAgeConfirmed:
+		global.recordConfirmed()
+	}
+}
+
+// Decisions recorded by LogContentRule, one per content scan outcome.
+const (
+	ContentRuleConfirmed = "confirmed"
+	ContentRuleBlocked   = "blocked"
+)
+
+// LogContentRule records a content scan rule matching a file of a downloaded
+// artifact.
+func LogContentRule(pv *packagev1.PackageVersion, pack, rule, file, decision string) {
+	logEvent(AuditEvent{
+		Type:           EventTypeContentRule,
+		Message:        fmt.Sprintf("Rule %s/%s matched %s in %s@%s, %s by content scan", pack, rule, file, pkgName(pv), pkgVersion(pv), decision),
+		PackageVersion: pv,
+		Reason:         rule,
+		Details: map[string]any{
+			"decision": decision,
+			"pack":     pack,
+			"rule":     rule,
+			"file":     file,
+		},
+	})
+
+	if global == nil {
+		return
+	}
+
+	switch decision {
+	case ContentRuleBlocked:
+		global.recordBlocked()
+	case ContentRuleConfirmed:
+		global.recordConfirmed()
+	}
+}
+
+// Decisions recorded by LogLockfileIntegrity, one per lockfile_integrity
+// outcome.
+const (
+	LockfileIntegrityAllowed   = "allowed"
+	LockfileIntegrityConfirmed = "confirmed"
+	LockfileIntegrityBlocked   = "blocked"
+)
+
+// LogLockfileIntegrity records a downloaded artifact that does not match the
+// project's lockfile. verdict is "mismatch" when its hash differs from the
+// pinned one, with expected and actual set, or "unlisted" when the lockfile
+// does not list it.
+func LogLockfileIntegrity(pv *packagev1.PackageVersion, lockfile, verdict, expected, actual, decision string) {
+	details := map[string]any{
+		"decision": decision,
+		"verdict":  verdict,
+		"lockfile": lockfile,
+	}
+	if expected != "" {
+		details["expected"] = expected
+		details["actual"] = actual
+	}
+
+	logEvent(AuditEvent{
+		Type:           EventTypeLockfileIntegrity,
+		Message:        fmt.Sprintf("Package %s@%s %s in lockfile %s, %s by lockfile integrity check", pkgName(pv), pkgVersion(pv), verdict, lockfile, decision),
+		PackageVersion: pv,
+		Reason:         verdict,
+		Details:        details,
+	})
+
+	if global == nil {
+		return
+	}
+
+	switch decision {
+	case LockfileIntegrityBlocked:
+		global.recordBlocked()
+	case LockfileIntegrityConfirmed:
+		global.recordConfirmed()
+	}
+}
+
+// LogRegistryIntegrity records a downloaded artifact blocked because its hash
+// differs from the one the registry published for it in its metadata.
+func LogRegistryIntegrity(pv *packagev1.PackageVersion, registry, expected, actual string) {
+	logEvent(AuditEvent{
+		Type:           EventTypeRegistryIntegrity,
+		Message:        fmt.Sprintf("Package %s@%s from %s does not match its registry metadata, blocked by registry integrity check", pkgName(pv), pkgVersion(pv), registry),
+		PackageVersion: pv,
+		Reason:         "mismatch",
+		Details: map[string]any{
+			"decision": "blocked",
+			"registry": registry,
+			"expected": expected,
+			"actual":   actual,
+		},
+	})
+
+	if global != nil {
+		global.recordBlocked()
+	}
+}
+
+// Decisions recorded by LogNpmSignature, one per npm_signatures policy that
+// acts on a failed verification.
+const (
+	NpmSignatureWarned  = "warned"
+	NpmSignatureBlocked = "blocked"
+)
+
+// LogNpmSignature records an npm package version whose registry signature is
+// missing or does not verify. reason is the verification failure.
+func LogNpmSignature(pv *packagev1.PackageVersion, registry, reason, decision string) {
+	logEvent(AuditEvent{
+		Type:           EventTypeNpmSignature,
+		Message:        fmt.Sprintf("Package %s@%s from %s: %s, %s by npm signature policy", pkgName(pv), pkgVersion(pv), registry, reason, decision),
+		PackageVersion: pv,
+		Reason:         reason,
+		Details: map[string]any{
+			"decision": decision,
+			"registry": registry,
+		},
+	})
+
+	if global != nil && decision == NpmSignatureBlocked {
+		global.recordBlocked()
+	}
+}
+
+// LogProvenance records a package version blocked because it lacks a valid
+// provenance attestation. reason is why the attestation was not accepted;
+// repository is the source repository it names, if it verified, and
+// expected the one the provenance policy requires.
+func LogProvenance(pv *packagev1.PackageVersion, reason, repository, expected string) {
+	logEvent(AuditEvent{
+		Type:           EventTypeProvenance,
+		Message:        fmt.Sprintf("Package %s@%s: %s, blocked by provenance policy", pkgName(pv), pkgVersion(pv), reason),
+		PackageVersion: pv,
+		Reason:         reason,
+		Details: map[string]any{
+			"decision":            "blocked",
+			"repository":          repository,
+			"expected_repository": expected,
+		},
+	})
+
+	if global != nil {
+		global.recordBlocked()
+	}
+}
+
+// Decisions of the private namespace policy. An artifact download is
+// blocked; a metadata request is refused so the resolver falls back to the
+// next index, which is not an install block.
+const (
+	DependencyConfusionBlocked = "blocked"
+	DependencyConfusionRefused = "refused"
+)
+
+// LogDependencyConfusion records a request for a package of a private
+// namespace to a registry that may not serve it. pv carries no version for
+// a metadata request.
+func LogDependencyConfusion(pv *packagev1.PackageVersion, namespace, registry, decision string) {
+	ref := pkgName(pv)
+	if pkgVersion(pv) != "" {
+		ref += "@" + pkgVersion(pv)
+	}
+
+	logEvent(AuditEvent{
+		Type:           EventTypeDependencyConfusion,
+		Message:        fmt.Sprintf("Package %s requested from %s: private namespace %s, %s by private namespace policy", ref, registry, namespace, decision),
+		PackageVersion: pv,
+		Reason:         fmt.Sprintf("%s is a private namespace", namespace),
+		Details: map[string]any{
+			"decision":  decision,
+			"namespace": namespace,
+			"registry":  registry,
+		},
+	})
+
+	if global != nil && decision == DependencyConfusionBlocked {
+		global.recordBlocked()
+	}
+}
+
+// LogHeuristicBlocked records a package blocked by a local heuristic, such
+// as the install-script or typosquat analyzer, rather than by a malware
+// verdict. reason is the heuristic's finding.
+func LogHeuristicBlocked(pv *packagev1.PackageVersion, heuristic, reason, decidedBy string) {
+	details := map[string]any{
+		"heuristic": heuristic,
+	}
+	if decidedBy != "" {
+		details["analyzer"] = decidedBy
+	}
+
+	logEvent(AuditEvent{
+		Type:           EventTypeHeuristicBlocked,
+		Message:        fmt.Sprintf("Blocked installation of suspicious package: %s@%s (%s)", pkgName(pv), pkgVersion(pv), heuristic),
+		PackageVersion: pv,
+		Reason:         reason,
+		Details:        details,
+	})
+
+	if global != nil {
+		global.recordBlocked()
+	}
+}
+
 // LogSandboxOverride records that runtime sandbox policy overrides were applied.
 func LogSandboxOverride(sandboxProfile string, overrides []map[string]string) {
 	logEvent(AuditEvent{
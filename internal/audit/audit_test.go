@@ -132,13 +132,14 @@ func TestPublicAPIDispatchesToSinks(t *testing.T) {
 	defer resetGlobal()
 
 	pv := testPackageVersion("evil", "1.0.0", "npm")
-	LogMalwareBlocked(pv, "malware", "analysis-1", "https://ref", true, false)
+	LogMalwareBlocked(pv, "malware", "analysis-1", "https://ref", "local-malware-feed", true, false)
 
 	events := s.getEvents()
 	require.Len(t, events, 1)
 	assert.Equal(t, EventTypeMalwareBlocked, events[0].Type)
 	assert.Equal(t, pv, events[0].PackageVersion)
 	assert.Equal(t, "malware", events[0].Details["reason"])
+	assert.Equal(t, "local-malware-feed", events[0].Details["analyzer"])
 	assert.Equal(t, "analysis-1", events[0].AnalysisID)
 	assert.Equal(t, true, events[0].IsMalware)
 }
@@ -147,8 +148,8 @@ func TestPublicAPISilentWhenNotInitialized(t *testing.T) {
 	resetGlobal()
 
 	// None of these should panic
-	LogMalwareBlocked(nil, "reason", "", "", false, false)
-	LogMalwareConfirmed(nil, "", false, false)
+	LogMalwareBlocked(nil, "reason", "", "", "", false, false)
+	LogMalwareConfirmed(nil, "", "", false, false)
 	LogInstallAllowed(nil, 5)
 	LogInstallTrustedAllowed(nil)
 	LogInstallInsecureBypass(nil)
@@ -194,7 +195,7 @@ func TestLogMalwareBlockedIncrementsSession(t *testing.T) {
 	defer resetGlobal()
 
 	a.startSession("npm", nil)
-	LogMalwareBlocked(testPackageVersion("evil", "1.0", "npm"), "bad", "", "", true, false)
+	LogMalwareBlocked(testPackageVersion("evil", "1.0", "npm"), "bad", "", "", "", true, false)
 
 	sess := a.getSession()
 	require.NotNil(t, sess)
@@ -209,7 +210,7 @@ func TestLogMalwareConfirmedIncrementsSession(t *testing.T) {
 	defer resetGlobal()
 
 	a.startSession("npm", nil)
-	LogMalwareConfirmed(testPackageVersion("pkg", "1.0", "npm"), "a-1", true, false)
+	LogMalwareConfirmed(testPackageVersion("pkg", "1.0", "npm"), "a-1", "", true, false)
 
 	sess := a.getSession()
 	require.NotNil(t, sess)
@@ -247,6 +248,288 @@ func TestLogCooldownSkippedEmitsEventWithReason(t *testing.T) {
 	assert.Equal(t, "dependency_cooldown.skip", events[0].Details["reason"])
 }
 
+func TestLogAnalysisUnavailableRecordsDecision(t *testing.T) {
+	tests := []struct {
+		decision          string
+		expectedAllowed   uint32
+		expectedBlocked   uint32
+		expectedConfirmed uint32
+	}{
+		{AnalysisUnavailableAllowed, 1, 0, 0},
+		{AnalysisUnavailableConfirmed, 1, 0, 1},
+		{AnalysisUnavailableBlocked, 0, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.decision, func(t *testing.T) {
+			s := &mockSink{}
+			a := newAuditor(s)
+			setGlobal(a)
+			defer resetGlobal()
+
+			a.startSession("npm", nil)
+			LogAnalysisUnavailable(testPackageVersion("pkg", "1.0", "npm"), "block", tt.decision, assert.AnError)
+
+			events := s.getEvents()
+			require.Len(t, events, 1)
+			assert.Equal(t, EventTypeAnalysisUnavailable, events[0].Type)
+			assert.Equal(t, "block", events[0].Details["policy"])
+			assert.Equal(t, tt.decision, events[0].Details["decision"])
+			assert.Equal(t, assert.AnError.Error(), events[0].Details["error"])
+
+			sess := a.getSession()
+			require.NotNil(t, sess)
+			assert.Equal(t, tt.expectedAllowed, sess.allowedCount)
+			assert.Equal(t, tt.expectedBlocked, sess.blockedCount)
+			assert.Equal(t, tt.expectedConfirmed, sess.confirmedCount)
+			assert.Equal(t, uint32(1), sess.totalAnalyzed)
+		})
+	}
+}
+
+func TestLogVulnerabilityBlocked(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogVulnerabilityBlocked(testPackageVersion("lodash", "4.17.10", "npm"), []string{"GHSA-1", "GHSA-2"}, "critical", "vulnerabilities")
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeVulnerabilityBlocked, events[0].Type)
+	assert.Equal(t, []string{"GHSA-1", "GHSA-2"}, events[0].Details["advisories"])
+	assert.Equal(t, "critical", events[0].Details["severity"])
+	assert.Contains(t, events[0].Message, "GHSA-1, GHSA-2")
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogLicensePolicy(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogLicensePolicy(testPackageVersion("agpl-lib", "1.0.0", "npm"), "AGPL-3.0-only", "AGPL-3.0-only", "denied", LicensePolicyBlocked)
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeLicensePolicy, events[0].Type)
+	assert.Equal(t, "AGPL-3.0-only", events[0].Details["license"])
+	assert.Equal(t, LicensePolicyBlocked, events[0].Details["decision"])
+	assert.Equal(t, "denied", events[0].Reason)
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogPackageAge(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	firstRelease := time.Now().Add(-48 * time.Hour)
+	LogPackageAge(testPackageVersion("brand-new", "0.0.1", "npm"), firstRelease, 14, 2, PackageAgeConfirmed)
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypePackageAge, events[0].Type)
+	assert.Equal(t, PackageAgeConfirmed, events[0].Details["decision"])
+	assert.Equal(t, 14, events[0].Details["min_days"])
+	assert.Equal(t, firstRelease, events[0].PublishDate)
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.confirmedCount)
+}
+
+func TestLogContentRule(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogContentRule(testPackageVersion("left-pad", "1.3.1", "npm"), "campaign", "token_stealer", "lib/bundle.js", ContentRuleBlocked)
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeContentRule, events[0].Type)
+	assert.Equal(t, "token_stealer", events[0].Reason)
+	assert.Equal(t, "lib/bundle.js", events[0].Details["file"])
+	assert.Equal(t, ContentRuleBlocked, events[0].Details["decision"])
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogLockfileIntegrity(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogLockfileIntegrity(testPackageVersion("left-pad", "1.3.0", "npm"), "package-lock.json", "mismatch", "sha512-AAAA", "sha512-BBBB", LockfileIntegrityBlocked)
+	LogLockfileIntegrity(testPackageVersion("extra", "1.0.0", "npm"), "package-lock.json", "unlisted", "", "", LockfileIntegrityConfirmed)
+
+	events := s.getEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, EventTypeLockfileIntegrity, events[0].Type)
+	assert.Equal(t, "mismatch", events[0].Reason)
+	assert.Equal(t, "sha512-AAAA", events[0].Details["expected"])
+	assert.Equal(t, "sha512-BBBB", events[0].Details["actual"])
+	assert.NotContains(t, events[1].Details, "expected")
+	assert.Equal(t, LockfileIntegrityConfirmed, events[1].Details["decision"])
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogRegistryIntegrity(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogRegistryIntegrity(testPackageVersion("left-pad", "1.3.0", "npm"), "npm.corp.example", "sha512-AAAA", "sha512-BBBB")
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeRegistryIntegrity, events[0].Type)
+	assert.Equal(t, "npm.corp.example", events[0].Details["registry"])
+	assert.Equal(t, "sha512-AAAA", events[0].Details["expected"])
+	assert.Equal(t, "sha512-BBBB", events[0].Details["actual"])
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogProvenance(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("pip", nil)
+	LogProvenance(testPackageVersion("litellm", "1.82.8", "pypi"), "built from https://github.com/evil/litellm",
+		"https://github.com/evil/litellm", "https://github.com/BerriAI/litellm")
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeProvenance, events[0].Type)
+	assert.Equal(t, "built from https://github.com/evil/litellm", events[0].Reason)
+	assert.Equal(t, "https://github.com/evil/litellm", events[0].Details["repository"])
+	assert.Equal(t, "https://github.com/BerriAI/litellm", events[0].Details["expected_repository"])
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogDependencyConfusion(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogDependencyConfusion(testPackageVersion("@acme/widgets", "", "npm"), "@acme", "registry.npmjs.org", DependencyConfusionRefused)
+	LogDependencyConfusion(testPackageVersion("@acme/widgets", "1.0.0", "npm"), "@acme", "registry.npmjs.org", DependencyConfusionBlocked)
+
+	events := s.getEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, EventTypeDependencyConfusion, events[0].Type)
+	assert.Equal(t, "Package @acme/widgets requested from registry.npmjs.org: private namespace @acme, refused by private namespace policy", events[0].Message)
+	assert.Equal(t, "@acme", events[1].Details["namespace"])
+	assert.Equal(t, "registry.npmjs.org", events[1].Details["registry"])
+	assert.Equal(t, DependencyConfusionBlocked, events[1].Details["decision"])
+
+	// Only the artifact download counts as a block.
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogHeuristicBlocked(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogHeuristicBlocked(testPackageVersion("lodahs", "1.0.0", "npm"), "typosquat",
+		"lodahs is one character away from the popular package lodash. Did you mean lodash?", "typosquat")
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeHeuristicBlocked, events[0].Type)
+	assert.Equal(t, "Blocked installation of suspicious package: lodahs@1.0.0 (typosquat)", events[0].Message)
+	assert.Equal(t, "typosquat", events[0].Details["heuristic"])
+	assert.False(t, events[0].IsMalware)
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
+func TestLogMalwareStripped(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogMalwareStripped(testPackageVersion("left-pad", "1.3.1", "npm"), "credential stealer", "an-1", "https://example.com/an-1", "")
+
+	events := s.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeMalwareStripped, events[0].Type)
+	assert.Equal(t, "an-1", events[0].AnalysisID)
+	assert.True(t, events[0].IsMalware)
+	assert.Equal(t, "credential stealer", events[0].Details["reason"])
+	assert.NotContains(t, events[0].Details, "analyzer")
+
+	// The install fell back to a clean version; nothing was blocked.
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(0), sess.blockedCount)
+}
+
+func TestLogNpmSignature(t *testing.T) {
+	s := &mockSink{}
+	a := newAuditor(s)
+	setGlobal(a)
+	defer resetGlobal()
+
+	a.startSession("npm", nil)
+	LogNpmSignature(testPackageVersion("left-pad", "1.3.0", "npm"), "registry.npmjs.org", "invalid registry signature", NpmSignatureWarned)
+	LogNpmSignature(testPackageVersion("left-pad", "1.3.1", "npm"), "registry.npmjs.org", "no registry signature", NpmSignatureBlocked)
+
+	events := s.getEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, EventTypeNpmSignature, events[0].Type)
+	assert.Equal(t, "invalid registry signature", events[0].Reason)
+	assert.Equal(t, "registry.npmjs.org", events[0].Details["registry"])
+	assert.Equal(t, NpmSignatureBlocked, events[1].Details["decision"])
+
+	sess := a.getSession()
+	require.NotNil(t, sess)
+	assert.Equal(t, uint32(1), sess.blockedCount)
+}
+
 func TestLogSessionCompleteDispatchesEvent(t *testing.T) {
 	s := &mockSink{}
 	a := newAuditor(s)
@@ -12,6 +12,13 @@ func (s *cloudSink) translateToPmgEvents(event AuditEvent) []*controltowerv1.Pmg
 	switch event.Type {
 	case EventTypeMalwareBlocked:
 		return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+	case EventTypeVulnerabilityBlocked:
+		return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+	case EventTypeHeuristicBlocked:
+		return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+	case EventTypeMalwareStripped:
+		// The malicious version never reached the package manager.
+		return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
 	case EventTypeMalwareConfirmed:
 		return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_CONFIRMED)}
 	case EventTypeCooldownSkipped:
@@ -25,6 +32,67 @@ func (s *cloudSink) translateToPmgEvents(event AuditEvent) []*controltowerv1.Pmg
 		return nil
 	case EventTypeDependencyCooldown:
 		return []*controltowerv1.PmgEvent{newCooldownBlockedEvent(event)}
+	case EventTypeAnalysisUnavailable:
+		// Only decisions that deviate from a plain install are package
+		// decisions; an unverified allow is reported like install_allowed.
+		switch event.Details["decision"] {
+		case AnalysisUnavailableBlocked:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+		case AnalysisUnavailableConfirmed:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_CONFIRMED)}
+		default:
+			return nil
+		}
+	case EventTypeLicensePolicy:
+		switch event.Details["decision"] {
+		case LicensePolicyBlocked:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+		case LicensePolicyConfirmed:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_CONFIRMED)}
+		default:
+			return nil
+		}
+	case EventTypePackageAge:
+		switch event.Details["decision"] {
+		case PackageAgeBlocked:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+		case PackageAgeConfirmed:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_CONFIRMED)}
+		default:
+			return nil
+		}
+	case EventTypeContentRule:
+		switch event.Details["decision"] {
+		case ContentRuleBlocked:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+		case ContentRuleConfirmed:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_CONFIRMED)}
+		default:
+			return nil
+		}
+	case EventTypeLockfileIntegrity:
+		switch event.Details["decision"] {
+		case LockfileIntegrityBlocked:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+		case LockfileIntegrityConfirmed:
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_CONFIRMED)}
+		default:
+			return nil
+		}
+	case EventTypeRegistryIntegrity:
+		return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+	case EventTypeNpmSignature:
+		if event.Details["decision"] == NpmSignatureBlocked {
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+		}
+		return nil
+	case EventTypeProvenance:
+		return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+	case EventTypeDependencyConfusion:
+		if event.Details["decision"] == DependencyConfusionBlocked {
+			return []*controltowerv1.PmgEvent{newPackageDecisionEvent(event, controltowerv1.PmgPackageAction_PMG_PACKAGE_ACTION_BLOCKED)}
+		}
+		return nil
 	case EventTypeProxyHostObserved:
 		return []*controltowerv1.PmgEvent{newHostObservationEvent(event)}
 	case EventTypeSandboxOverride:
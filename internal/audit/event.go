@@ -47,6 +47,7 @@ type EventType string
 const (
 	EventTypeMalwareBlocked        EventType = "malware_blocked"
 	EventTypeMalwareConfirmed      EventType = "malware_confirmed"
+	EventTypeMalwareStripped       EventType = "malware_stripped"
 	EventTypeInstallAllowed        EventType = "install_allowed"
 	EventTypeInstallTrustedAllowed EventType = "install_trusted_allowed"
 	EventTypeInstallStarted        EventType = "install_started"
@@ -55,6 +56,17 @@ const (
 	EventTypeProxyHostObserved     EventType = "proxy_host_observed"
 	EventTypeDependencyCooldown    EventType = "dependency_cooldown"
 	EventTypeCooldownSkipped       EventType = "dependency_cooldown_skipped"
+	EventTypeAnalysisUnavailable   EventType = "analysis_unavailable"
+	EventTypeVulnerabilityBlocked  EventType = "vulnerability_blocked"
+	EventTypeLicensePolicy         EventType = "license_policy"
+	EventTypePackageAge            EventType = "package_age"
+	EventTypeContentRule           EventType = "content_rule"
+	EventTypeLockfileIntegrity     EventType = "lockfile_integrity"
+	EventTypeRegistryIntegrity     EventType = "registry_integrity"
+	EventTypeNpmSignature          EventType = "npm_signature"
+	EventTypeProvenance            EventType = "provenance"
+	EventTypeDependencyConfusion   EventType = "dependency_confusion"
+	EventTypeHeuristicBlocked      EventType = "heuristic_blocked"
 	EventTypeSandboxOverride       EventType = "sandbox_override"
 	EventTypeError                 EventType = "error"
 	EventTypeSessionComplete       EventType = "session_complete"
@@ -0,0 +1,161 @@
+package contentscan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// maxFileSize bounds a single scanned file. Larger files are skipped:
+	// rules target scripts and manifests, not bundled binaries.
+	maxFileSize = 16 << 20
+
+	// maxArchiveSize bounds the decompressed content scanned in one archive,
+	// so a decompression bomb cannot exhaust memory.
+	maxArchiveSize = 512 << 20
+)
+
+// ErrArchiveTooLarge is returned when an archive decompresses to more than
+// the scanner reads. The files before the limit were scanned.
+var ErrArchiveTooLarge = errors.New("archive exceeds the content scan size limit")
+
+// Format is the archive format of an artifact.
+type Format int
+
+const (
+	FormatUnknown Format = iota
+
+	// FormatTarGz is a gzipped tarball: npm tarballs and PyPI sdists. Their
+	// files sit under a single top-level directory, which is stripped.
+	FormatTarGz
+
+	// FormatZip is a zip archive: PyPI wheels and Go module zips.
+	FormatZip
+)
+
+// FormatOf returns the archive format of an artifact from its file name.
+func FormatOf(name string) Format {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".tar.gz"):
+		return FormatTarGz
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".whl"):
+		return FormatZip
+	default:
+		return FormatUnknown
+	}
+}
+
+// walkArchive calls fn with the path and content of every regular file in
+// the archive. root is a prefix stripped from zip entry names, such as the
+// module@version/ directory of a Go module zip.
+func walkArchive(format Format, data []byte, root string, fn func(name string, content []byte) error) error {
+	switch format {
+	case FormatTarGz:
+		return walkTarGz(data, fn)
+	case FormatZip:
+		return walkZip(data, root, fn)
+	default:
+		return errors.New("unsupported archive format")
+	}
+}
+
+func walkTarGz(data []byte, fn func(name string, content []byte) error) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	var total int64
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg || header.Size > maxFileSize {
+			continue
+		}
+
+		total += header.Size
+		if total > maxArchiveSize {
+			return ErrArchiveTooLarge
+		}
+
+		content, err := io.ReadAll(io.LimitReader(reader, maxFileSize))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+
+		name := cleanName(header.Name)
+		if _, rest, ok := strings.Cut(name, "/"); ok {
+			name = rest
+		}
+		if err := fn(name, content); err != nil {
+			return err
+		}
+	}
+}
+
+func walkZip(data []byte, root string, fn func(name string, content []byte) error) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive: %w", err)
+	}
+
+	var total int64
+	for _, file := range archive.File {
+		if !file.Mode().IsRegular() || file.UncompressedSize64 > maxFileSize {
+			continue
+		}
+
+		content, err := readZipEntry(file)
+		if err != nil {
+			return err
+		}
+
+		// The declared size can lie, so the bound is on what was read.
+		total += int64(len(content))
+		if total > maxArchiveSize {
+			return ErrArchiveTooLarge
+		}
+
+		name := strings.TrimPrefix(cleanName(file.Name), root)
+		if err := fn(name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readZipEntry(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	return content, nil
+}
+
+// cleanName normalizes an archive entry name to a relative slash path.
+func cleanName(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(name, "/")
+}
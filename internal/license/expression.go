@@ -0,0 +1,214 @@
+// Package license reads the licenses packages declare and evaluates them
+// against the license policy. Declared licenses are SPDX license
+// expressions, such as "MIT OR Apache-2.0"; a package without one can often
+// still be identified from its license text.
+package license
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expression is a parsed SPDX license expression. A leaf names one license,
+// optionally with an exception; an inner node combines its operands with AND
+// or OR.
+type Expression struct {
+	// Op is OperatorAnd or OperatorOr for an inner node, empty for a leaf.
+	Op       string
+	Operands []*Expression
+
+	// License is the license identifier of a leaf, such as MIT or
+	// GPL-2.0-or-later.
+	License string
+
+	// Exception is the WITH exception of a leaf, such as
+	// Classpath-exception-2.0.
+	Exception string
+}
+
+const (
+	OperatorAnd = "AND"
+	OperatorOr  = "OR"
+)
+
+// Parse parses an SPDX license expression. Operators are matched
+// case-insensitively, since registries carry both "MIT OR ISC" and
+// "MIT or ISC". AND binds tighter than OR.
+func Parse(s string) (*Expression, error) {
+	p := &parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in license expression %q", p.tokens[p.pos], s)
+	}
+	return expr, nil
+}
+
+// Licenses returns the license identifiers of the expression, in order.
+func (e *Expression) Licenses() []string {
+	if e.Op == "" {
+		return []string{e.License}
+	}
+
+	var licenses []string
+	for _, operand := range e.Operands {
+		licenses = append(licenses, operand.Licenses()...)
+	}
+	return licenses
+}
+
+func (e *Expression) String() string {
+	if e.Op == "" {
+		if e.Exception != "" {
+			return e.License + " WITH " + e.Exception
+		}
+		return e.License
+	}
+
+	parts := make([]string, 0, len(e.Operands))
+	for _, operand := range e.Operands {
+		s := operand.String()
+		// An OR inside an AND needs its parentheses back.
+		if operand.Op == OperatorOr && e.Op == OperatorAnd {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+// Join combines expressions with op. It returns nil for no expressions and
+// the expression itself for one.
+func Join(op string, exprs ...*Expression) *Expression {
+	switch len(exprs) {
+	case 0:
+		return nil
+	case 1:
+		return exprs[0]
+	default:
+		return &Expression{Op: op, Operands: exprs}
+	}
+}
+
+func tokenize(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *parser) parseOr() (*Expression, error) {
+	return p.parseBinary(OperatorOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*Expression, error) {
+	return p.parseBinary(OperatorAnd, p.parseWith)
+}
+
+func (p *parser) parseBinary(op string, operand func() (*Expression, error)) (*Expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []*Expression{first}
+	for strings.EqualFold(p.peek(), op) {
+		p.next()
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+
+	return Join(op, operands...), nil
+}
+
+func (p *parser) parseWith() (*Expression, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.next()
+		if expr.Op != "" {
+			return nil, fmt.Errorf("WITH applies to a single license")
+		}
+		exception := p.next()
+		if !isIdentifier(exception) {
+			return nil, fmt.Errorf("missing exception after WITH")
+		}
+		expr.Exception = exception
+	}
+
+	return expr, nil
+}
+
+func (p *parser) parsePrimary() (*Expression, error) {
+	token := p.next()
+	switch {
+	case token == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return expr, nil
+	case isIdentifier(token):
+		return &Expression{License: token}, nil
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of license expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q in license expression", token)
+	}
+}
+
+// isIdentifier reports whether token can be a license or exception
+// identifier: letters, digits, '.', '-' and ':' (for DocumentRef), with an
+// optional trailing '+'. Operators are not identifiers.
+func isIdentifier(token string) bool {
+	if token == "" || isOperator(token) {
+		return false
+	}
+
+	for i, r := range token {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '-', r == ':':
+		case r == '+' && i == len(token)-1:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isOperator(token string) bool {
+	return strings.EqualFold(token, OperatorAnd) || strings.EqualFold(token, OperatorOr) ||
+		strings.EqualFold(token, "WITH")
+}
@@ -0,0 +1,209 @@
+package license
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		out      string
+		licenses []string
+	}{
+		{"MIT", "MIT", []string{"MIT"}},
+		{"MIT OR Apache-2.0", "MIT OR Apache-2.0", []string{"MIT", "Apache-2.0"}},
+		{"mit or (isc and bsd-2-clause)", "mit OR isc AND bsd-2-clause", []string{"mit", "isc", "bsd-2-clause"}},
+		{"(MIT OR ISC) AND GPL-2.0+", "(MIT OR ISC) AND GPL-2.0+", []string{"MIT", "ISC", "GPL-2.0+"}},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only"}},
+		{"LicenseRef-Proprietary", "LicenseRef-Proprietary", []string{"LicenseRef-Proprietary"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			expr, err := Parse(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, expr.String())
+			assert.Equal(t, tt.licenses, expr.Licenses())
+		})
+	}
+
+	for _, invalid := range []string{"", "MIT OR", "(MIT", "MIT ISC", "MIT License", "(MIT OR ISC) WITH foo", "SEE LICENSE IN LICENSE"} {
+		_, err := Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		deny     []string
+		allow    []string
+		declared string
+		verdict  Verdict
+		license  string
+	}{
+		{"bare id denies -only", []string{"AGPL-3.0"}, nil, "AGPL-3.0-only", VerdictDenied, "AGPL-3.0-only"},
+		{"bare id denies plus", []string{"AGPL-3.0"}, nil, "AGPL-3.0+", VerdictDenied, "AGPL-3.0+"},
+		{"case-insensitive", []string{"agpl-3.0-or-later"}, nil, "AGPL-3.0+", VerdictDenied, "AGPL-3.0+"},
+		{"-only entry is exact", []string{"GPL-3.0-only"}, nil, "GPL-3.0-or-later", VerdictAllowed, ""},
+		{"family is not a prefix", []string{"GPL-2.0"}, nil, "LGPL-2.0-only", VerdictAllowed, ""},
+		{"wildcard", []string{"AGPL-*"}, nil, "AGPL-1.0", VerdictDenied, "AGPL-1.0"},
+		{"expression entry", []string{"AGPL-3.0-only OR SSPL-1.0"}, nil, "SSPL-1.0", VerdictDenied, "SSPL-1.0"},
+		{"OR takes the acceptable alternative", []string{"GPL-3.0"}, nil, "GPL-3.0-only OR MIT", VerdictAllowed, ""},
+		{"AND applies every license", []string{"GPL-3.0"}, nil, "MIT AND GPL-3.0-only", VerdictDenied, "GPL-3.0-only"},
+		{"allow list", nil, []string{"MIT", "Apache-2.0"}, "ISC", VerdictNotAllowed, "ISC"},
+		{"allow list alternative", nil, []string{"MIT", "Apache-2.0"}, "ISC OR Apache-2.0", VerdictAllowed, ""},
+		{"deny wins over allow", []string{"MIT"}, []string{"MIT"}, "MIT", VerdictDenied, "MIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.declared)
+			require.NoError(t, err)
+
+			decision := NewPolicy(tt.deny, tt.allow).Evaluate(expr)
+			assert.Equal(t, tt.verdict, decision.Verdict)
+			assert.Equal(t, tt.license, decision.License)
+		})
+	}
+
+	assert.Equal(t, VerdictUndeclared, NewPolicy([]string{"MIT"}, nil).Evaluate(nil).Verdict)
+}
+
+const gplText = `GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007`
+
+const lgplText = `GNU LESSER GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007
+
+  This version of the GNU Lesser General Public License incorporates
+the terms and conditions of version 3 of the GNU General Public
+License`
+
+const mitText = `Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software")`
+
+func TestDetect(t *testing.T) {
+	assert.Equal(t, "GPL-3.0-only", Detect(gplText))
+	assert.Equal(t, "LGPL-3.0-only", Detect(lgplText))
+	assert.Equal(t, "MIT", Detect(mitText))
+	assert.Equal(t, "BSD-3-Clause", Detect("Redistribution and use in source and binary forms ... Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products"))
+	assert.Equal(t, "BSD-2-Clause", Detect("Redistribution and use in source and binary forms, with or without modification"))
+	assert.Equal(t, "", Detect("All rights reserved."))
+}
+
+func TestParseDeclared(t *testing.T) {
+	tests := map[string]string{
+		"MIT":                  "MIT",
+		"MIT License":          "MIT",
+		"Apache 2.0":           "Apache-2.0",
+		"(MIT OR Apache-2.0)":  "MIT OR Apache-2.0",
+		"UNLICENSED":           "UNLICENSED",
+		mitText:                "MIT",
+		"SEE LICENSE IN EULA":  "",
+		"Proprietary software": "",
+	}
+
+	for declared, want := range tests {
+		expr := ParseDeclared(declared)
+		if want == "" {
+			assert.Nil(t, expr, declared)
+			continue
+		}
+		require.NotNil(t, expr, declared)
+		assert.Equal(t, want, expr.String())
+	}
+}
+
+func TestFromNpmPackument(t *testing.T) {
+	licenses, err := FromNpmPackument([]byte(`{
+		"versions": {
+			"1.0.0": {"license": "MIT"},
+			"2.0.0": {"license": {"type": "GPL-3.0", "url": "https://example.com"}},
+			"3.0.0": {"licenses": [{"type": "MIT"}, {"type": "Apache-2.0"}]},
+			"4.0.0": {"license": "SEE LICENSE IN LICENSE.md"},
+			"5.0.0": {}
+		}
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "MIT", licenses["1.0.0"].String())
+	assert.Equal(t, "GPL-3.0", licenses["2.0.0"].String())
+	assert.Equal(t, "MIT OR Apache-2.0", licenses["3.0.0"].String())
+	assert.NotContains(t, licenses, "4.0.0")
+	assert.NotContains(t, licenses, "5.0.0")
+}
+
+func TestFromNpmManifest(t *testing.T) {
+	expr, err := FromNpmManifest([]byte(`{"name": "lib", "version": "1.0.0", "licenses": [{"type": "MIT"}, {"type": "Apache-2.0"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "MIT OR Apache-2.0", expr.String())
+
+	expr, err = FromNpmManifest([]byte(`{"name": "lib", "version": "2.0.0"}`))
+	require.NoError(t, err)
+	assert.Nil(t, expr)
+
+	_, err = FromNpmManifest([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestFromPyPIRelease(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"license expression", `{"info": {"license_expression": "BSD-3-Clause", "license": "BSD"}}`, "BSD-3-Clause"},
+		{"classifiers", `{"info": {"license": "", "classifiers": [
+			"Programming Language :: Python",
+			"License :: OSI Approved :: GNU Affero General Public License v3",
+			"License :: OSI Approved :: MIT License"]}}`, "AGPL-3.0-only OR MIT"},
+		{"license field", `{"info": {"license": "Apache Software License", "classifiers": ["License :: OSI Approved :: BSD License"]}}`, "Apache-2.0"},
+		{"nothing declared", `{"info": {"license": null}}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := FromPyPIRelease([]byte(tt.body))
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, expr)
+				return
+			}
+			require.NotNil(t, expr)
+			assert.Equal(t, tt.want, expr.String())
+		})
+	}
+}
+
+func TestFromGoModuleZip(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"example.com/lib@v1.2.0/LICENSE":            mitText,
+		"example.com/lib@v1.2.0/COPYING.LESSER":     lgplText,
+		"example.com/lib@v1.2.0/main.go":            "package lib",
+		"example.com/lib@v1.2.0/vendor/x/LICENSE":   gplText,
+		"example.com/lib@v1.2.0/LICENSE-THIRDPARTY": "All rights reserved.",
+	} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	expr, err := FromGoModuleZip(buf.Bytes(), "example.com/lib", "v1.2.0")
+	require.NoError(t, err)
+	require.NotNil(t, expr)
+	assert.ElementsMatch(t, []string{"MIT", "LGPL-3.0-only"}, expr.Licenses())
+	assert.Equal(t, OperatorAnd, expr.Op)
+
+	_, err = FromGoModuleZip([]byte("not a zip"), "example.com/lib", "v1.2.0")
+	assert.Error(t, err)
+}
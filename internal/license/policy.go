@@ -0,0 +1,187 @@
+package license
+
+import (
+	"strings"
+
+	"github.com/safedep/dry/log"
+)
+
+// Verdict is the outcome of evaluating a declared license against a Policy.
+type Verdict int
+
+const (
+	// VerdictAllowed is a license the policy accepts.
+	VerdictAllowed Verdict = iota
+
+	// VerdictDenied is a license on the deny list, with no alternative the
+	// policy accepts.
+	VerdictDenied
+
+	// VerdictNotAllowed is a license missing from a non-empty allow list,
+	// with no alternative the policy accepts.
+	VerdictNotAllowed
+
+	// VerdictUndeclared is a package that declares no license PMG can read.
+	VerdictUndeclared
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case VerdictAllowed:
+		return "allowed"
+	case VerdictDenied:
+		return "denied"
+	case VerdictNotAllowed:
+		return "not allowed"
+	default:
+		return "undeclared"
+	}
+}
+
+// Decision is the result of Policy.Evaluate.
+type Decision struct {
+	Verdict Verdict
+
+	// Expression is the declared license expression, normalized.
+	Expression string
+
+	// License is the license that decided a denied or not-allowed verdict.
+	License string
+}
+
+// Policy is the deny and allow lists of license_policy. Entries are SPDX
+// license identifiers or expressions listing them. An identifier without a
+// -only or -or-later suffix covers both, so AGPL-3.0 matches AGPL-3.0-only,
+// AGPL-3.0-or-later and AGPL-3.0+. A trailing * matches a prefix, as in
+// AGPL-*. Matching is case-insensitive.
+type Policy struct {
+	deny  []pattern
+	allow []pattern
+}
+
+func NewPolicy(deny, allow []string) *Policy {
+	return &Policy{deny: patterns(deny), allow: patterns(allow)}
+}
+
+// Evaluate decides a declared license expression. A package may be used
+// under any alternative of an OR, so it is rejected only when every
+// alternative is; all licenses joined by AND apply at once. The deny list
+// takes precedence over the allow list.
+func (p *Policy) Evaluate(declared *Expression) Decision {
+	if declared == nil {
+		return Decision{Verdict: VerdictUndeclared}
+	}
+
+	decision := p.evaluate(declared)
+	decision.Expression = declared.String()
+	return decision
+}
+
+func (p *Policy) evaluate(e *Expression) Decision {
+	switch e.Op {
+	case "":
+		switch {
+		case matchAny(p.deny, e.License):
+			return Decision{Verdict: VerdictDenied, License: e.License}
+		case len(p.allow) > 0 && !matchAny(p.allow, e.License):
+			return Decision{Verdict: VerdictNotAllowed, License: e.License}
+		default:
+			return Decision{Verdict: VerdictAllowed}
+		}
+
+	case OperatorOr:
+		var first Decision
+		for i, operand := range e.Operands {
+			decision := p.evaluate(operand)
+			if decision.Verdict == VerdictAllowed {
+				return decision
+			}
+			if i == 0 {
+				first = decision
+			}
+		}
+		return first
+
+	default:
+		for _, operand := range e.Operands {
+			if decision := p.evaluate(operand); decision.Verdict != VerdictAllowed {
+				return decision
+			}
+		}
+		return Decision{Verdict: VerdictAllowed}
+	}
+}
+
+// pattern is one normalized policy entry.
+type pattern struct {
+	id     string
+	prefix bool
+}
+
+func patterns(entries []string) []pattern {
+	var result []pattern
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		ids := []string{entry}
+		if strings.ContainsAny(entry, " ()") {
+			expr, err := Parse(entry)
+			if err != nil {
+				log.Warnf("Invalid license_policy entry %q: %v", entry, err)
+				continue
+			}
+			ids = expr.Licenses()
+		}
+
+		for _, id := range ids {
+			id = strings.ToLower(id)
+			if prefix, ok := strings.CutSuffix(id, "*"); ok {
+				result = append(result, pattern{id: prefix, prefix: true})
+				continue
+			}
+			result = append(result, pattern{id: canonicalSuffix(id)})
+		}
+	}
+	return result
+}
+
+func matchAny(patterns []pattern, license string) bool {
+	license = canonicalSuffix(strings.ToLower(license))
+	for _, p := range patterns {
+		if p.matches(license) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pattern) matches(license string) bool {
+	if p.prefix {
+		return strings.HasPrefix(license, p.id)
+	}
+	if p.id == license {
+		return true
+	}
+	// A bare identifier covers its -only and -or-later variants.
+	return !hasVersionSuffix(p.id) && p.id == family(license)
+}
+
+// canonicalSuffix rewrites the deprecated "+" suffix as -or-later.
+func canonicalSuffix(id string) string {
+	if base, ok := strings.CutSuffix(id, "+"); ok {
+		return base + "-or-later"
+	}
+	return id
+}
+
+func hasVersionSuffix(id string) bool {
+	return strings.HasSuffix(id, "-only") || strings.HasSuffix(id, "-or-later")
+}
+
+func family(id string) string {
+	id = strings.TrimSuffix(id, "-only")
+	return strings.TrimSuffix(id, "-or-later")
+}
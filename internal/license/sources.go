@@ -0,0 +1,219 @@
+package license
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// npmManifest is the part of an npm version manifest that declares its
+// license.
+type npmManifest struct {
+	License  json.RawMessage   `json:"license"`
+	Licenses []json.RawMessage `json:"licenses"`
+}
+
+// FromNpmPackument returns the declared license of every version in an npm
+// packument, keyed by version. Versions without a license the registry
+// declares are left out. Besides the SPDX "license" string, old packages
+// carry {"type": ...} objects and a "licenses" array, read as alternatives.
+func FromNpmPackument(packument []byte) (map[string]*Expression, error) {
+	var doc struct {
+		Versions map[string]npmManifest `json:"versions"`
+	}
+	if err := json.Unmarshal(packument, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal npm packument: %w", err)
+	}
+
+	licenses := make(map[string]*Expression, len(doc.Versions))
+	for version, manifest := range doc.Versions {
+		if expr := manifest.expression(); expr != nil {
+			licenses[version] = expr
+		}
+	}
+
+	return licenses, nil
+}
+
+// FromNpmManifest returns the declared license of an npm version manifest,
+// the document a registry serves at /<name>/<version>, read as
+// FromNpmPackument reads each version. It returns nil when the manifest
+// declares none.
+func FromNpmManifest(body []byte) (*Expression, error) {
+	var manifest npmManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal npm version manifest: %w", err)
+	}
+	return manifest.expression(), nil
+}
+
+func (m npmManifest) expression() *Expression {
+	if expr := npmLicense(m.License); expr != nil {
+		return expr
+	}
+
+	var alternatives []*Expression
+	for _, raw := range m.Licenses {
+		if expr := npmLicense(raw); expr != nil {
+			alternatives = append(alternatives, expr)
+		}
+	}
+	return Join(OperatorOr, alternatives...)
+}
+
+func npmLicense(raw json.RawMessage) *Expression {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var declared string
+	if err := json.Unmarshal(raw, &declared); err != nil {
+		var object struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil
+		}
+		declared = object.Type
+	}
+
+	return ParseDeclared(declared)
+}
+
+// pypiClassifiers maps the license trove classifiers of PyPI, without their
+// "License :: " or "License :: OSI Approved :: " prefix, to SPDX
+// identifiers. Classifiers that name no single license, such as
+// "BSD License", are left out.
+var pypiClassifiers = map[string]string{
+	"MIT License":                          "MIT",
+	"Apache Software License":              "Apache-2.0",
+	"ISC License (ISCL)":                   "ISC",
+	"GNU Affero General Public License v3": "AGPL-3.0-only",
+	"GNU Affero General Public License v3 or later (AGPLv3+)":    "AGPL-3.0-or-later",
+	"GNU General Public License v2 (GPLv2)":                      "GPL-2.0-only",
+	"GNU General Public License v2 or later (GPLv2+)":            "GPL-2.0-or-later",
+	"GNU General Public License v3 (GPLv3)":                      "GPL-3.0-only",
+	"GNU General Public License v3 or later (GPLv3+)":            "GPL-3.0-or-later",
+	"GNU Lesser General Public License v2 (LGPLv2)":              "LGPL-2.0-only",
+	"GNU Lesser General Public License v2 or later (LGPLv2+)":    "LGPL-2.0-or-later",
+	"GNU Lesser General Public License v3 (LGPLv3)":              "LGPL-3.0-only",
+	"GNU Lesser General Public License v3 or later (LGPLv3+)":    "LGPL-3.0-or-later",
+	"Mozilla Public License 2.0 (MPL 2.0)":                       "MPL-2.0",
+	"Eclipse Public License 2.0 (EPL-2.0)":                       "EPL-2.0",
+	"European Union Public Licence 1.2 (EUPL 1.2)":               "EUPL-1.2",
+	"Boost Software License 1.0 (BSL-1.0)":                       "BSL-1.0",
+	"Python Software Foundation License":                         "PSF-2.0",
+	"The Unlicense (Unlicense)":                                  "Unlicense",
+	"zlib/libpng License":                                        "Zlib",
+	"Server Side Public License (SSPL)":                          "SSPL-1.0",
+	"GNU Free Documentation License (FDL)":                       "GFDL-1.3-only",
+	"Universal Permissive License (UPL)":                         "UPL-1.0",
+	"Historical Permission Notice and Disclaimer (HPND)":         "HPND",
+	"Academic Free License (AFL)":                                "AFL-3.0",
+	"Common Development and Distribution License 1.0 (CDDL-1.0)": "CDDL-1.0",
+}
+
+// FromPyPIRelease returns the declared license of a release from its PyPI
+// JSON API document (/pypi/<name>/<version>/json): the PEP 639
+// license_expression when the release has one, else its license
+// classifiers as alternatives, else the free-form license field. It
+// returns nil when none of them names a license.
+func FromPyPIRelease(body []byte) (*Expression, error) {
+	var doc struct {
+		Info struct {
+			License           string   `json:"license"`
+			LicenseExpression string   `json:"license_expression"`
+			Classifiers       []string `json:"classifiers"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal PyPI release: %w", err)
+	}
+
+	if expr, err := Parse(doc.Info.LicenseExpression); err == nil {
+		return expr, nil
+	}
+
+	var alternatives []*Expression
+	for _, classifier := range doc.Info.Classifiers {
+		name, ok := strings.CutPrefix(classifier, "License :: ")
+		if !ok {
+			continue
+		}
+		name = strings.TrimPrefix(name, "OSI Approved :: ")
+		if id, ok := pypiClassifiers[name]; ok {
+			alternatives = append(alternatives, &Expression{License: id})
+		}
+	}
+	if expr := Join(OperatorOr, alternatives...); expr != nil {
+		return expr, nil
+	}
+
+	return ParseDeclared(doc.Info.License), nil
+}
+
+// maxLicenseFileSize bounds how much of one license file is read.
+const maxLicenseFileSize = 256 << 10
+
+// FromGoModuleZip detects the license of a Go module from the license files
+// at the root of its module zip, as served by a module proxy. Every license
+// found applies, so several are joined with AND. It returns nil when the
+// module has no license file PMG recognizes.
+func FromGoModuleZip(data []byte, module, version string) (*Expression, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open module zip: %w", err)
+	}
+
+	root := module + "@" + version + "/"
+	var found []*Expression
+	seen := map[string]bool{}
+	for _, file := range archive.File {
+		name, ok := strings.CutPrefix(file.Name, root)
+		if !ok || strings.Contains(name, "/") || !isLicenseFile(name) {
+			continue
+		}
+
+		text, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		if id := Detect(text); id != "" && !seen[id] {
+			seen[id] = true
+			found = append(found, &Expression{License: id})
+		}
+	}
+
+	return Join(OperatorAnd, found...), nil
+}
+
+// isLicenseFile reports whether a file name is a conventional license file
+// name, such as LICENSE, LICENSE.md, LICENSE-MIT or COPYING.
+func isLicenseFile(name string) bool {
+	base := strings.ToUpper(name)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"} {
+		if base == prefix || strings.HasPrefix(base, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func readZipFile(file *zip.File) (string, error) {
+	r, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = r.Close() }()
+
+	text, err := io.ReadAll(io.LimitReader(r, maxLicenseFileSize))
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
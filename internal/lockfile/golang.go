@@ -0,0 +1,28 @@
+package lockfile
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// parseGoSum reads go.sum. Each module version is listed with the hash of
+// its go.mod file, and with the hash of its source tree when the build
+// needed it; only the latter is a module go downloads. The h1: hashes are
+// of the extracted tree, not of an artifact, so no hashes are recorded.
+func parseGoSum(path string, data []byte) (*Lockfile, error) {
+	lock := newLockfile(path, EcosystemGo)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		lock.add(fields[0], fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
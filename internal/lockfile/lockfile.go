@@ -0,0 +1,323 @@
+// Package lockfile reads the packages and artifact hashes a project's
+// lockfile pins, so downloads can be checked against them and pinned
+// versions scanned without installing.
+package lockfile
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/safedep/pmg/internal/pypiname"
+)
+
+// Ecosystems of the packages a lockfile lists, as used by Lockfile.Ecosystem.
+const (
+	EcosystemNpm  = "npm"
+	EcosystemPyPI = "pypi"
+	EcosystemGo   = "go"
+)
+
+// managerLockfiles lists the lockfiles each package manager installs from,
+// in order of precedence.
+var managerLockfiles = map[string][]string{
+	"npm":    {"npm-shrinkwrap.json", "package-lock.json"},
+	"pnpm":   {"pnpm-lock.yaml"},
+	"yarn":   {"yarn.lock"},
+	"poetry": {"poetry.lock"},
+	"uv":     {"uv.lock"},
+}
+
+// Hash is an expected digest of a package artifact.
+type Hash struct {
+	// Algorithm is sha1, sha256, sha384 or sha512.
+	Algorithm string
+	Digest    []byte
+}
+
+// String returns the hash in Subresource Integrity form, as npm lockfiles
+// write it.
+func (h Hash) String() string {
+	return h.Algorithm + "-" + base64.StdEncoding.EncodeToString(h.Digest)
+}
+
+// Package is a package version pinned by a lockfile.
+type Package struct {
+	Name    string
+	Version string
+
+	// Hashes are the digests of the package's artifacts. A package fetched
+	// from a git repository or a local path has none.
+	Hashes []Hash
+}
+
+// Lockfile holds the packages a lockfile pins.
+type Lockfile struct {
+	// Path is the file the lockfile was read from.
+	Path string
+
+	// Ecosystem is EcosystemNpm, EcosystemPyPI or EcosystemGo.
+	Ecosystem string
+
+	packages map[string]*Package
+}
+
+func newLockfile(path, ecosystem string) *Lockfile {
+	return &Lockfile{Path: path, Ecosystem: ecosystem, packages: map[string]*Package{}}
+}
+
+// Load reads the lockfile the package manager installs from in dir. It
+// returns nil when the manager has no lockfile format supported here or dir
+// has none.
+func Load(dir, manager string) (*Lockfile, error) {
+	for _, name := range managerLockfiles[manager] {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		lock, err := Parse(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return lock, nil
+	}
+	return nil, nil
+}
+
+// Parse reads a lockfile, picking its format by the file name of path.
+func Parse(path string, data []byte) (*Lockfile, error) {
+	switch filepath.Base(path) {
+	case "package-lock.json", "npm-shrinkwrap.json":
+		return parsePackageLock(path, data)
+	case "pnpm-lock.yaml":
+		return parsePnpmLock(path, data)
+	case "yarn.lock":
+		return parseYarnLock(path, data)
+	case "poetry.lock":
+		return parsePoetryLock(path, data)
+	case "uv.lock":
+		return parseUvLock(path, data)
+	case "bun.lock":
+		return parseBunLock(path, data)
+	case "Pipfile.lock":
+		return parsePipfileLock(path, data)
+	case "go.sum":
+		return parseGoSum(path, data)
+	}
+
+	if isRequirementsFile(filepath.Base(path)) {
+		return parseRequirements(path, data)
+	}
+	return nil, fmt.Errorf("unsupported lockfile %s", filepath.Base(path))
+}
+
+// scanOnlyLockfiles are parsed by Parse but are not the lockfile of a
+// package manager PMG verifies installs against.
+var scanOnlyLockfiles = []string{"bun.lock", "Pipfile.lock", "go.sum"}
+
+// IsLockfile reports whether name is the file name of a supported lockfile
+// or pip requirements file.
+func IsLockfile(name string) bool {
+	for _, names := range managerLockfiles {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	for _, n := range scanOnlyLockfiles {
+		if n == name {
+			return true
+		}
+	}
+	return isRequirementsFile(name)
+}
+
+// isRequirementsFile reports whether name is a pip requirements file, such
+// as requirements.txt or requirements-dev.txt.
+func isRequirementsFile(name string) bool {
+	matched, _ := filepath.Match("requirements*.txt", name)
+	return matched
+}
+
+// Len returns the number of package versions in the lockfile.
+func (l *Lockfile) Len() int {
+	return len(l.packages)
+}
+
+// Contains reports whether the lockfile pins a package version.
+func (l *Lockfile) Contains(name, version string) bool {
+	_, ok := l.packages[l.key(name, version)]
+	return ok
+}
+
+// Hashes returns the digests the lockfile pins for a package version.
+func (l *Lockfile) Hashes(name, version string) []Hash {
+	if pkg, ok := l.packages[l.key(name, version)]; ok {
+		return pkg.Hashes
+	}
+	return nil
+}
+
+// Packages returns the package versions in the lockfile, sorted by name and
+// version.
+func (l *Lockfile) Packages() []Package {
+	result := make([]Package, 0, len(l.packages))
+	for _, pkg := range l.packages {
+		result = append(result, *pkg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
+// add records a package version, merging its hashes with those of an
+// earlier entry of the same version.
+func (l *Lockfile) add(name, version string, hashes ...Hash) {
+	if name == "" || version == "" {
+		return
+	}
+
+	key := l.key(name, version)
+	pkg, ok := l.packages[key]
+	if !ok {
+		if l.Ecosystem == EcosystemPyPI {
+			name = pypiname.Normalize(name)
+		}
+		pkg = &Package{Name: name, Version: version}
+		l.packages[key] = pkg
+	}
+
+	for _, h := range hashes {
+		if !containsHash(pkg.Hashes, h) {
+			pkg.Hashes = append(pkg.Hashes, h)
+		}
+	}
+}
+
+func (l *Lockfile) key(name, version string) string {
+	if l.Ecosystem == EcosystemPyPI {
+		return pypiname.Normalize(name) + "@" + strings.ToLower(version)
+	}
+	return name + "@" + version
+}
+
+func containsHash(hashes []Hash, h Hash) bool {
+	for _, existing := range hashes {
+		if existing.Algorithm == h.Algorithm && bytes.Equal(existing.Digest, h.Digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// algorithmStrength orders the supported algorithms, weakest first.
+var algorithmStrength = map[string]int{"sha1": 1, "sha256": 2, "sha384": 3, "sha512": 4}
+
+func newHasher(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	case "sha384":
+		return sha512.New384()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
+
+// Verify checks an artifact against the hashes pinned for it. Like
+// Subresource Integrity, only the strongest algorithm among hashes is
+// checked, and the artifact matches when it has any of the digests of that
+// algorithm. It returns whether the artifact matched, and the artifact's
+// digest in that algorithm.
+func Verify(hashes []Hash, artifact []byte) (bool, Hash) {
+	strongest := ""
+	for _, h := range hashes {
+		if algorithmStrength[h.Algorithm] > algorithmStrength[strongest] {
+			strongest = h.Algorithm
+		}
+	}
+	if strongest == "" {
+		return true, Hash{}
+	}
+
+	hasher := newHasher(strongest)
+	hasher.Write(artifact)
+	actual := Hash{Algorithm: strongest, Digest: hasher.Sum(nil)}
+
+	for _, h := range hashes {
+		if h.Algorithm == strongest && bytes.Equal(h.Digest, actual.Digest) {
+			return true, actual
+		}
+	}
+	return false, actual
+}
+
+// ParseSRI parses a Subresource Integrity value, such as the integrity field
+// of a package-lock.json entry. Tokens with an unsupported algorithm or an
+// invalid digest are skipped.
+func ParseSRI(value string) []Hash {
+	var hashes []Hash
+	for _, token := range strings.Fields(value) {
+		algorithm, digest, ok := strings.Cut(token, "-")
+		if !ok || newHasher(algorithm) == nil {
+			continue
+		}
+		// Options follow a "?" and are not part of the digest.
+		digest, _, _ = strings.Cut(digest, "?")
+
+		raw, err := base64.StdEncoding.DecodeString(digest)
+		if err != nil || len(raw) != newHasher(algorithm).Size() {
+			continue
+		}
+		hashes = append(hashes, Hash{Algorithm: algorithm, Digest: raw})
+	}
+	return hashes
+}
+
+// ParseHexHash parses an "algorithm:hexdigest" value, such as the hashes of
+// Python lockfiles. ok is false for an unsupported algorithm or an invalid
+// digest.
+func ParseHexHash(value string) (Hash, bool) {
+	algorithm, digest, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return Hash{}, false
+	}
+	return hexHash(algorithm, digest)
+}
+
+func hexHash(algorithm, digest string) (Hash, bool) {
+	algorithm = strings.ToLower(algorithm)
+	hasher := newHasher(algorithm)
+	if hasher == nil {
+		return Hash{}, false
+	}
+
+	raw, err := hex.DecodeString(digest)
+	if err != nil || len(raw) != hasher.Size() {
+		return Hash{}, false
+	}
+	return Hash{Algorithm: algorithm, Digest: raw}, true
+}
@@ -0,0 +1,368 @@
+package lockfile
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	tarball   = []byte("tarball contents")
+	sha512SRI = func() string {
+		sum := sha512.Sum512(tarball)
+		return "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+	}()
+	sha1Hex = func() string {
+		sum := sha1.Sum(tarball)
+		return hex.EncodeToString(sum[:])
+	}()
+	sha256Hex = func() string {
+		sum := sha256.Sum256(tarball)
+		return hex.EncodeToString(sum[:])
+	}()
+)
+
+func TestParsePackageLock(t *testing.T) {
+	data := `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "version": "1.0.0"},
+    "node_modules/left-pad": {"version": "1.3.0", "integrity": "` + sha512SRI + `"},
+    "node_modules/a/node_modules/@scope/b": {"version": "2.0.0", "integrity": "sha1-AAAA"},
+    "node_modules/alias": {"name": "real-pkg", "version": "3.0.0"},
+    "node_modules/ws-a": {"resolved": "packages/ws-a", "link": true},
+    "packages/ws-a": {"version": "0.1.0"}
+  }
+}`
+
+	lock, err := Parse("/p/package-lock.json", []byte(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, EcosystemNpm, lock.Ecosystem)
+	assert.Equal(t, 3, lock.Len())
+	assert.True(t, lock.Contains("left-pad", "1.3.0"))
+	assert.True(t, lock.Contains("@scope/b", "2.0.0"))
+	assert.True(t, lock.Contains("real-pkg", "3.0.0"))
+	assert.False(t, lock.Contains("alias", "3.0.0"))
+	assert.False(t, lock.Contains("ws-a", "0.1.0"))
+
+	require.Len(t, lock.Hashes("left-pad", "1.3.0"), 1)
+	assert.Equal(t, sha512SRI, lock.Hashes("left-pad", "1.3.0")[0].String())
+	// An invalid digest is dropped.
+	assert.Empty(t, lock.Hashes("@scope/b", "2.0.0"))
+}
+
+func TestParsePackageLockV1(t *testing.T) {
+	data := `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "left-pad": {"version": "1.3.0", "integrity": "` + sha512SRI + `",
+      "dependencies": {"nested": {"version": "0.0.1"}}},
+    "alias": {"version": "npm:real-pkg@3.0.0"}
+  }
+}`
+
+	lock, err := Parse("npm-shrinkwrap.json", []byte(data))
+	require.NoError(t, err)
+	assert.True(t, lock.Contains("left-pad", "1.3.0"))
+	assert.True(t, lock.Contains("nested", "0.0.1"))
+	assert.True(t, lock.Contains("real-pkg", "3.0.0"))
+	assert.Len(t, lock.Hashes("left-pad", "1.3.0"), 1)
+}
+
+func TestParsePnpmLock(t *testing.T) {
+	data := `
+lockfileVersion: '9.0'
+packages:
+  left-pad@1.3.0:
+    resolution: {integrity: ` + sha512SRI + `}
+  '@scope/b@2.0.0':
+    resolution: {integrity: ` + sha512SRI + `}
+  /old/4.0.0_react@18.0.0:
+    resolution: {integrity: ` + sha512SRI + `}
+  /v6@5.0.0(react@18.0.0):
+    resolution: {integrity: ` + sha512SRI + `}
+  git-dep@https://codeload.github.com/x/y/tar.gz/abc:
+    name: git-dep
+    version: 0.0.1
+    resolution: {tarball: https://codeload.github.com/x/y/tar.gz/abc}
+`
+
+	lock, err := Parse("pnpm-lock.yaml", []byte(data))
+	require.NoError(t, err)
+	assert.True(t, lock.Contains("left-pad", "1.3.0"))
+	assert.True(t, lock.Contains("@scope/b", "2.0.0"))
+	assert.True(t, lock.Contains("old", "4.0.0"))
+	assert.True(t, lock.Contains("v6", "5.0.0"))
+	assert.True(t, lock.Contains("git-dep", "0.0.1"))
+	assert.Empty(t, lock.Hashes("git-dep", "0.0.1"))
+	assert.Len(t, lock.Hashes("v6", "5.0.0"), 1)
+}
+
+func TestParseYarnLockClassic(t *testing.T) {
+	data := `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@scope/b@^2.0.0", "@scope/b@^2.0.1":
+  version "2.0.1"
+  resolved "https://registry.yarnpkg.com/@scope/b/-/b-2.0.1.tgz#` + sha1Hex + `"
+  integrity ` + sha512SRI + `
+  dependencies:
+    left-pad "^1.3.0"
+
+left-pad@^1.3.0:
+  version "1.3.0"
+  resolved "https://registry.yarnpkg.com/left-pad/-/left-pad-1.3.0.tgz"
+`
+
+	lock, err := Parse("yarn.lock", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, 2, lock.Len())
+	assert.True(t, lock.Contains("left-pad", "1.3.0"))
+	assert.Len(t, lock.Hashes("@scope/b", "2.0.1"), 2)
+
+	ok, actual := Verify(lock.Hashes("@scope/b", "2.0.1"), tarball)
+	assert.True(t, ok)
+	assert.Equal(t, "sha512", actual.Algorithm)
+}
+
+func TestParseYarnLockBerry(t *testing.T) {
+	data := `__metadata:
+  version: 8
+  cacheKey: 10c0
+
+"@scope/b@npm:^2.0.0":
+  version: 2.0.1
+  resolution: "@scope/b@npm:2.0.1"
+  checksum: 10c0/0000
+  languageName: node
+  linkType: hard
+
+"alias@npm:real-pkg@^3.0.0":
+  version: 3.0.0
+  resolution: "real-pkg@npm:3.0.0"
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+`
+
+	lock, err := Parse("yarn.lock", []byte(data))
+	require.NoError(t, err)
+	assert.True(t, lock.Contains("@scope/b", "2.0.1"))
+	assert.Empty(t, lock.Hashes("@scope/b", "2.0.1"))
+	assert.True(t, lock.Contains("alias", "3.0.0"))
+	assert.False(t, lock.Contains("app", "0.0.0-use.local"))
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	data := `
+[[package]]
+name = "Requests"
+version = "2.31.0"
+files = [
+    {file = "requests-2.31.0-py3-none-any.whl", hash = "sha256:` + sha256Hex + `"},
+    {file = "requests-2.31.0.tar.gz", hash = "md5:0000"},
+]
+
+[[package]]
+name = "typing_extensions"
+version = "4.0.0"
+
+[metadata]
+lock-version = "1.1"
+
+[metadata.files]
+typing-extensions = [
+    {file = "typing_extensions-4.0.0.tar.gz", hash = "sha256:` + sha256Hex + `"},
+]
+`
+
+	lock, err := Parse("poetry.lock", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, EcosystemPyPI, lock.Ecosystem)
+	assert.True(t, lock.Contains("requests", "2.31.0"))
+	assert.True(t, lock.Contains("Typing.Extensions", "4.0.0"))
+	assert.Len(t, lock.Hashes("requests", "2.31.0"), 1)
+	assert.Len(t, lock.Hashes("typing-extensions", "4.0.0"), 1)
+
+	assert.Equal(t, []Package{
+		{Name: "requests", Version: "2.31.0", Hashes: lock.Hashes("requests", "2.31.0")},
+		{Name: "typing-extensions", Version: "4.0.0", Hashes: lock.Hashes("typing-extensions", "4.0.0")},
+	}, lock.Packages())
+}
+
+func TestParseUvLock(t *testing.T) {
+	data := `
+version = 1
+
+[[package]]
+name = "app"
+version = "0.1.0"
+source = { editable = "." }
+
+[[package]]
+name = "requests"
+version = "2.31.0"
+source = { registry = "https://pypi.org/simple" }
+sdist = { url = "https://files.pythonhosted.org/requests-2.31.0.tar.gz", hash = "sha256:` + sha256Hex + `", size = 10 }
+wheels = [
+    { url = "https://files.pythonhosted.org/requests-2.31.0-py3-none-any.whl", hash = "sha256:` + sha256Hex[:62] + `00", size = 10 },
+]
+`
+
+	lock, err := Parse("uv.lock", []byte(data))
+	require.NoError(t, err)
+	assert.True(t, lock.Contains("requests", "2.31.0"))
+	assert.Len(t, lock.Hashes("requests", "2.31.0"), 2)
+
+	ok, _ := Verify(lock.Hashes("requests", "2.31.0"), tarball)
+	assert.True(t, ok)
+	ok, actual := Verify(lock.Hashes("requests", "2.31.0"), []byte("tampered"))
+	assert.False(t, ok)
+	assert.Equal(t, "sha256", actual.Algorithm)
+}
+
+func TestVerify(t *testing.T) {
+	sha1Hash, ok := hexHash("sha1", sha1Hex)
+	require.True(t, ok)
+
+	// Only the strongest algorithm counts.
+	ok, _ = Verify(append(ParseSRI("sha512-"+base64.StdEncoding.EncodeToString(make([]byte, 64))), sha1Hash), tarball)
+	assert.False(t, ok)
+
+	ok, _ = Verify([]Hash{sha1Hash}, tarball)
+	assert.True(t, ok)
+
+	ok, actual := Verify(nil, tarball)
+	assert.True(t, ok)
+	assert.Empty(t, actual.Algorithm)
+}
+
+func TestParseSRI(t *testing.T) {
+	hashes := ParseSRI(sha512SRI + "?opt sha384-invalid md5-AAAA")
+	require.Len(t, hashes, 1)
+	assert.Equal(t, "sha512", hashes[0].Algorithm)
+
+	_, ok := ParseHexHash("sha256:zz")
+	assert.False(t, ok)
+}
+
+func TestParseBunLock(t *testing.T) {
+	data := `{
+  "lockfileVersion": 1,
+  "workspaces": {
+    "": {"name": "app", "dependencies": {"left-pad": "^1.3.0"},},
+  },
+  "packages": {
+    "left-pad": ["left-pad@1.3.0", "", {}, "` + sha512SRI + `"],
+    "@scope/b": ["@scope/b@2.0.0", "", {"dependencies": {"left-pad": "^1.0.0"}}, "` + sha512SRI + `",],
+    "ws-a": ["ws-a@workspace:packages/ws-a"],
+    "git-dep": ["git-dep@github:x/y#abc", {}, "x-y-abc"],
+  },
+}`
+
+	lock, err := Parse("bun.lock", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, EcosystemNpm, lock.Ecosystem)
+	assert.Equal(t, 2, lock.Len())
+	assert.True(t, lock.Contains("left-pad", "1.3.0"))
+	assert.True(t, lock.Contains("@scope/b", "2.0.0"))
+	assert.Len(t, lock.Hashes("@scope/b", "2.0.0"), 1)
+}
+
+func TestParseRequirements(t *testing.T) {
+	data := `# pinned for reproducible builds
+--index-url https://pypi.org/simple
+Requests[socks]==2.31.0 \
+    --hash=sha256:` + sha256Hex + `
+urllib3===2.0.7 ; python_version >= "3.8"  # transitive
+flask>=2.0
+-e git+https://github.com/x/y.git#egg=y
+django==4.2.*
+`
+
+	lock, err := Parse("/p/requirements-dev.txt", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, EcosystemPyPI, lock.Ecosystem)
+	assert.Equal(t, 2, lock.Len())
+	assert.True(t, lock.Contains("requests", "2.31.0"))
+	assert.True(t, lock.Contains("urllib3", "2.0.7"))
+	require.Len(t, lock.Hashes("requests", "2.31.0"), 1)
+	assert.Equal(t, "sha256", lock.Hashes("requests", "2.31.0")[0].Algorithm)
+}
+
+func TestParsePipfileLock(t *testing.T) {
+	data := `{
+  "_meta": {"hash": {"sha256": "abc"}},
+  "default": {
+    "requests": {"hashes": ["sha256:` + sha256Hex + `"], "version": "==2.31.0"},
+    "local": {"editable": true, "path": "."}
+  },
+  "develop": {
+    "pytest": {"version": "==8.0.0"}
+  }
+}`
+
+	lock, err := Parse("Pipfile.lock", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, 2, lock.Len())
+	assert.True(t, lock.Contains("requests", "2.31.0"))
+	assert.True(t, lock.Contains("pytest", "8.0.0"))
+	assert.Len(t, lock.Hashes("requests", "2.31.0"), 1)
+}
+
+func TestParseGoSum(t *testing.T) {
+	data := `github.com/pkg/errors v0.9.1 h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4=
+github.com/pkg/errors v0.9.1/go.mod h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=
+golang.org/x/text v0.3.0/go.mod h1:NqM8EUOU14njkJ3fqMW+pc6Ldnwhi/IjpwHt7yyuwOQ=
+`
+
+	lock, err := Parse("go.sum", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, EcosystemGo, lock.Ecosystem)
+	assert.Equal(t, 1, lock.Len())
+	assert.True(t, lock.Contains("github.com/pkg/errors", "v0.9.1"))
+	assert.Empty(t, lock.Hashes("github.com/pkg/errors", "v0.9.1"))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Load(dir, "npm")
+	require.NoError(t, err)
+	assert.Nil(t, lock)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package-lock.json"), []byte(`{"packages": {"node_modules/a": {"version": "1.0.0"}}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "npm-shrinkwrap.json"), []byte(`{"packages": {"node_modules/b": {"version": "1.0.0"}}}`), 0o644))
+
+	lock, err = Load(dir, "npm")
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+	assert.Equal(t, filepath.Join(dir, "npm-shrinkwrap.json"), lock.Path)
+	assert.True(t, lock.Contains("b", "1.0.0"))
+
+	lock, err = Load(dir, "pip")
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "uv.lock"), []byte("not = [toml"), 0o644))
+	_, err = Load(dir, "uv")
+	assert.Error(t, err)
+
+	assert.True(t, IsLockfile("poetry.lock"))
+	assert.True(t, IsLockfile("go.sum"))
+	assert.True(t, IsLockfile("requirements-dev.txt"))
+	assert.False(t, IsLockfile("package.json"))
+	assert.False(t, IsLockfile("constraints.txt"))
+}
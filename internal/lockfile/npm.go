@@ -0,0 +1,299 @@
+package lockfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type packageLockEntry struct {
+	Name         string                      `json:"name"`
+	Version      string                      `json:"version"`
+	Integrity    string                      `json:"integrity"`
+	Link         bool                        `json:"link"`
+	Dependencies map[string]packageLockEntry `json:"dependencies"`
+}
+
+// parsePackageLock reads package-lock.json and npm-shrinkwrap.json. The
+// packages map of lockfile versions 2 and 3 is preferred; version 1 only has
+// the nested dependencies tree.
+func parsePackageLock(path string, data []byte) (*Lockfile, error) {
+	var doc struct {
+		Packages     map[string]packageLockEntry `json:"packages"`
+		Dependencies map[string]packageLockEntry `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	lock := newLockfile(path, EcosystemNpm)
+	if len(doc.Packages) > 0 {
+		for location, entry := range doc.Packages {
+			// Keys outside node_modules are the root project and the
+			// sources of workspaces; they are not downloaded.
+			idx := strings.LastIndex(location, "node_modules/")
+			if idx < 0 || entry.Link {
+				continue
+			}
+
+			name := entry.Name
+			if name == "" {
+				name = location[idx+len("node_modules/"):]
+			}
+			lock.add(name, entry.Version, ParseSRI(entry.Integrity)...)
+		}
+		return lock, nil
+	}
+
+	var walk func(deps map[string]packageLockEntry)
+	walk = func(deps map[string]packageLockEntry) {
+		for name, entry := range deps {
+			version := entry.Version
+			// An aliased dependency records the real package as
+			// "npm:name@version".
+			if alias, ok := strings.CutPrefix(version, "npm:"); ok {
+				if at := strings.LastIndex(alias, "@"); at > 0 {
+					name, version = alias[:at], alias[at+1:]
+				}
+			}
+			lock.add(name, version, ParseSRI(entry.Integrity)...)
+			walk(entry.Dependencies)
+		}
+	}
+	walk(doc.Dependencies)
+	return lock, nil
+}
+
+// parsePnpmLock reads pnpm-lock.yaml. Package keys are "/name/1.0.0" in
+// lockfile version 5, "/name@1.0.0" in version 6, and "name@1.0.0" from
+// version 9, any of them with a peer dependency suffix.
+func parsePnpmLock(path string, data []byte) (*Lockfile, error) {
+	var doc struct {
+		Packages map[string]struct {
+			Name       string `yaml:"name"`
+			Version    string `yaml:"version"`
+			Resolution struct {
+				Integrity string `yaml:"integrity"`
+			} `yaml:"resolution"`
+		} `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	lock := newLockfile(path, EcosystemNpm)
+	for key, entry := range doc.Packages {
+		name, version := parsePnpmKey(key)
+		// Packages from a tarball URL or git are keyed by their source,
+		// with the name and version recorded alongside.
+		if entry.Name != "" {
+			name = entry.Name
+		}
+		if entry.Version != "" {
+			version = entry.Version
+		}
+		lock.add(name, version, ParseSRI(entry.Resolution.Integrity)...)
+	}
+	return lock, nil
+}
+
+func parsePnpmKey(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	if idx := strings.Index(key, "("); idx >= 0 {
+		key = key[:idx]
+	}
+
+	// The name ends at the first "@" or "/" past its scope.
+	nameStart := 0
+	if strings.HasPrefix(key, "@") {
+		nameStart = strings.Index(key, "/") + 1
+		if nameStart == 0 {
+			return "", ""
+		}
+	}
+	sep := strings.IndexAny(key[nameStart:], "/@")
+	if sep < 0 {
+		return "", ""
+	}
+	sep += nameStart
+
+	version := key[sep+1:]
+	if key[sep] == '/' {
+		version, _, _ = strings.Cut(version, "_")
+	}
+	return key[:sep], version
+}
+
+// parseYarnLock reads yarn.lock, both the classic format and the YAML based
+// format of Yarn 2 and later. Classic entries carry the tarball integrity
+// and the sha1 in the resolved URL's fragment. The checksum of later Yarn
+// versions hashes Yarn's own archive of the package, not the tarball the
+// registry serves, so those entries only record the version.
+func parseYarnLock(path string, data []byte) (*Lockfile, error) {
+	lock := newLockfile(path, EcosystemNpm)
+
+	var (
+		name    string
+		version string
+		hashes  []Hash
+	)
+	flush := func() {
+		lock.add(name, version, hashes...)
+		name, version, hashes = "", "", nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") {
+			flush()
+			name = yarnEntryName(strings.TrimSuffix(trimmed, ":"))
+			continue
+		}
+
+		// Only the fields of the entry itself are read, not those of its
+		// nested dependencies maps.
+		if strings.HasPrefix(line, "    ") || name == "" {
+			continue
+		}
+
+		field, value, _ := strings.Cut(trimmed, " ")
+		field = strings.TrimSuffix(field, ":")
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch field {
+		case "version":
+			version = value
+		case "integrity":
+			hashes = append(hashes, ParseSRI(value)...)
+		case "resolved":
+			if _, fragment, ok := strings.Cut(value, "#"); ok {
+				if h, ok := hexHash("sha1", fragment); ok {
+					hashes = append(hashes, h)
+				}
+			}
+		case "resolution":
+			// Workspaces and linked packages are not downloaded.
+			if strings.Contains(value, "@workspace:") || strings.Contains(value, "@link:") ||
+				strings.Contains(value, "@portal:") || strings.Contains(value, "@file:") {
+				name = ""
+			}
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read yarn.lock: %w", err)
+	}
+	return lock, nil
+}
+
+// yarnEntryName returns the package name of a yarn.lock entry header, a
+// comma separated list of "name@range" descriptors.
+func yarnEntryName(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	first = strings.Trim(strings.TrimSpace(first), `"`)
+	if first == "__metadata" {
+		return ""
+	}
+
+	at := strings.LastIndex(first, "@")
+	if at <= 0 {
+		return ""
+	}
+	name := first[:at]
+
+	// Yarn 2 descriptors name the protocol: "name@npm:^1.0.0". A descriptor
+	// whose range holds an "@" has the name before it.
+	if idx := strings.Index(name[1:], "@"); idx >= 0 {
+		name = name[:idx+1]
+	}
+	return name
+}
+
+// parseBunLock reads bun.lock, the text lockfile of Bun 1.2 and later. It is
+// JSON with trailing commas. Each packages entry is an array of the
+// resolved "name@version", the registry URL, the package's metadata and its
+// integrity; workspace, git, file and link packages resolve to a version
+// with a protocol such as "workspace:" and are skipped.
+func parseBunLock(path string, data []byte) (*Lockfile, error) {
+	var doc struct {
+		Packages map[string][]json.RawMessage `json:"packages"`
+	}
+	if err := json.Unmarshal(stripTrailingCommas(data), &doc); err != nil {
+		return nil, err
+	}
+
+	lock := newLockfile(path, EcosystemNpm)
+	for _, entry := range doc.Packages {
+		if len(entry) == 0 {
+			continue
+		}
+
+		var resolved string
+		if err := json.Unmarshal(entry[0], &resolved); err != nil {
+			continue
+		}
+		at := strings.LastIndex(resolved, "@")
+		if at <= 0 || strings.Contains(resolved[at+1:], ":") {
+			continue
+		}
+
+		var hashes []Hash
+		if len(entry) > 3 {
+			var integrity string
+			if json.Unmarshal(entry[3], &integrity) == nil {
+				hashes = ParseSRI(integrity)
+			}
+		}
+		lock.add(resolved[:at], resolved[at+1:], hashes...)
+	}
+	return lock, nil
+}
+
+// stripTrailingCommas drops the commas that directly precede a closing
+// bracket or brace outside of strings, turning bun.lock into JSON.
+func stripTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString, escaped := false, false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			out = append(out, c)
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		} else if c == ',' {
+			next := i + 1
+			for next < len(data) && bytes.IndexByte([]byte(" \t\r\n"), data[next]) >= 0 {
+				next++
+			}
+			if next < len(data) && (data[next] == '}' || data[next] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
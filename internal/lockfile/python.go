@@ -0,0 +1,184 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/safedep/pmg/internal/pypiname"
+)
+
+type pythonLockFile struct {
+	Hash string `toml:"hash"`
+}
+
+// parsePoetryLock reads poetry.lock. Poetry 1.2 and later list each
+// package's files with it; older lockfiles keep them in [metadata.files],
+// keyed by package name.
+func parsePoetryLock(path string, data []byte) (*Lockfile, error) {
+	var doc struct {
+		Package []struct {
+			Name    string           `toml:"name"`
+			Version string           `toml:"version"`
+			Files   []pythonLockFile `toml:"files"`
+		} `toml:"package"`
+		Metadata struct {
+			Files map[string][]pythonLockFile `toml:"files"`
+		} `toml:"metadata"`
+	}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	legacyFiles := make(map[string][]pythonLockFile, len(doc.Metadata.Files))
+	for name, files := range doc.Metadata.Files {
+		legacyFiles[pypiname.Normalize(name)] = files
+	}
+
+	lock := newLockfile(path, EcosystemPyPI)
+	for _, pkg := range doc.Package {
+		files := pkg.Files
+		if len(files) == 0 {
+			files = legacyFiles[pypiname.Normalize(pkg.Name)]
+		}
+		lock.add(pkg.Name, pkg.Version, pythonLockHashes(files)...)
+	}
+	return lock, nil
+}
+
+// parseUvLock reads uv.lock, whose packages list the hashes of their sdist
+// and wheels.
+func parseUvLock(path string, data []byte) (*Lockfile, error) {
+	var doc struct {
+		Package []struct {
+			Name    string           `toml:"name"`
+			Version string           `toml:"version"`
+			Sdist   *pythonLockFile  `toml:"sdist"`
+			Wheels  []pythonLockFile `toml:"wheels"`
+		} `toml:"package"`
+	}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	lock := newLockfile(path, EcosystemPyPI)
+	for _, pkg := range doc.Package {
+		files := pkg.Wheels
+		if pkg.Sdist != nil {
+			files = append(files, *pkg.Sdist)
+		}
+		lock.add(pkg.Name, pkg.Version, pythonLockHashes(files)...)
+	}
+	return lock, nil
+}
+
+func pythonLockHashes(files []pythonLockFile) []Hash {
+	var hashes []Hash
+	for _, file := range files {
+		if h, ok := ParseHexHash(file.Hash); ok {
+			hashes = append(hashes, h)
+		}
+	}
+	return hashes
+}
+
+// parsePipfileLock reads Pipfile.lock. Packages of the default and develop
+// groups pin their version as "==1.0.0"; editable, git and path packages
+// have no version and are skipped.
+func parsePipfileLock(path string, data []byte) (*Lockfile, error) {
+	type pipfileEntry struct {
+		Version string   `json:"version"`
+		Hashes  []string `json:"hashes"`
+	}
+	var doc struct {
+		Default map[string]pipfileEntry `json:"default"`
+		Develop map[string]pipfileEntry `json:"develop"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	lock := newLockfile(path, EcosystemPyPI)
+	for _, group := range []map[string]pipfileEntry{doc.Default, doc.Develop} {
+		for name, entry := range group {
+			version, ok := strings.CutPrefix(entry.Version, "==")
+			if !ok {
+				continue
+			}
+
+			var hashes []Hash
+			for _, value := range entry.Hashes {
+				if h, ok := ParseHexHash(value); ok {
+					hashes = append(hashes, h)
+				}
+			}
+			lock.add(name, strings.TrimPrefix(version, "="), hashes...)
+		}
+	}
+	return lock, nil
+}
+
+var (
+	requirementHashOption = regexp.MustCompile(`--hash[=\s]\s*([A-Za-z0-9]+):([0-9A-Fa-f]+)`)
+	requirementComment    = regexp.MustCompile(`(^|\s)#.*$`)
+	requirementPin        = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*===?\s*([^\s,;*]+)$`)
+)
+
+// parseRequirements reads a pip requirements file. Only requirements pinned
+// to an exact version with == or === are listed, with the hashes of their
+// --hash options; ranges, URLs, paths and pip options are skipped.
+func parseRequirements(path string, data []byte) (*Lockfile, error) {
+	lock := newLockfile(path, EcosystemPyPI)
+
+	for _, line := range requirementLines(string(data)) {
+		if strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		var hashes []Hash
+		for _, match := range requirementHashOption.FindAllStringSubmatch(line, -1) {
+			if h, ok := hexHash(match[1], match[2]); ok {
+				hashes = append(hashes, h)
+			}
+		}
+
+		spec := requirementHashOption.ReplaceAllString(line, "")
+		spec, _, _ = strings.Cut(spec, ";")
+		match := requirementPin.FindStringSubmatch(strings.TrimSpace(spec))
+		if match == nil {
+			continue
+		}
+		lock.add(match[1], match[2], hashes...)
+	}
+	return lock, nil
+}
+
+// requirementLines returns the logical lines of a requirements file, with
+// backslash continuations joined and comments removed.
+func requirementLines(data string) []string {
+	var lines []string
+	var current strings.Builder
+
+	for _, raw := range strings.Split(data, "\n") {
+		// A comment starts at a # at the beginning of a line or after
+		// whitespace; a # inside a URL fragment is kept.
+		raw = requirementComment.ReplaceAllString(strings.TrimRight(raw, "\r"), "")
+
+		if continued, ok := strings.CutSuffix(strings.TrimRight(raw, " \t"), "\\"); ok {
+			current.WriteString(continued)
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(raw)
+
+		if line := strings.TrimSpace(current.String()); line != "" {
+			lines = append(lines, line)
+		}
+		current.Reset()
+	}
+	if line := strings.TrimSpace(current.String()); line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
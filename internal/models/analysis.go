@@ -0,0 +1,9 @@
+package models
+
+// UnverifiedPackage records a package version that malware analysis could not
+// produce a verdict for, because the analysis backend was unavailable. The
+// analysis.on_failure policy decided whether it was installed or blocked.
+type UnverifiedPackage struct {
+	Name    string
+	Version string
+}
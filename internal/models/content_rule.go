@@ -0,0 +1,11 @@
+package models
+
+// ContentRuleBlock records a package blocked because a content scan rule
+// matched a file of its artifact.
+type ContentRuleBlock struct {
+	Name    string
+	Version string
+	Pack    string
+	Rule    string
+	File    string
+}
@@ -0,0 +1,12 @@
+package models
+
+// DependencyConfusionBlock records a package version of a private namespace
+// blocked because a registry that may not serve the namespace was asked for
+// it. Registry is the registry that was asked.
+type DependencyConfusionBlock struct {
+	Ecosystem string
+	Name      string
+	Version   string
+	Namespace string
+	Registry  string
+}
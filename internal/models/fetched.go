@@ -0,0 +1,52 @@
+package models
+
+// Verdicts a FetchedPackage was let through with.
+const (
+	// FetchVerdictClean is a version the analyzer allowed.
+	FetchVerdictClean = "clean"
+
+	// FetchVerdictConfirmed is a suspicious version the user confirmed.
+	FetchVerdictConfirmed = "confirmed"
+
+	// FetchVerdictTrusted is a version on trusted_packages; it is not
+	// analyzed.
+	FetchVerdictTrusted = "trusted"
+
+	// FetchVerdictUnverified is a version allowed without a malware verdict
+	// because analysis was unavailable.
+	FetchVerdictUnverified = "unverified"
+
+	// FetchVerdictInsecure is a version fetched in insecure installation
+	// mode, which skips every check.
+	FetchVerdictInsecure = "insecure"
+)
+
+// FetchedPackage records a package version whose artifacts the proxy
+// delivered to the package manager. It is kept in the persistent proxy's
+// state file, hence the JSON tags.
+type FetchedPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+
+	// Registry is the name of the registry endpoint that served the
+	// artifacts, or the host for a download no endpoint matched.
+	Registry string `json:"registry"`
+
+	// Verdict is one of the FetchVerdict constants, or empty when the
+	// decision was reused from an earlier request.
+	Verdict string `json:"verdict,omitempty"`
+
+	Artifacts []FetchedArtifact `json:"artifacts"`
+}
+
+// FetchedArtifact is one file of a FetchedPackage, such as a wheel or a
+// tarball, as delivered.
+type FetchedArtifact struct {
+	// URL is the download URL without its query string or credentials.
+	URL string `json:"url"`
+
+	// SHA256 is the hex digest of the bytes delivered. It is empty when the
+	// registry served the file content-encoded.
+	SHA256 string `json:"sha256,omitempty"`
+}
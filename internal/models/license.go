@@ -0,0 +1,10 @@
+package models
+
+// LicenseBlock records a package blocked by the license policy.
+type LicenseBlock struct {
+	Name     string
+	Version  string
+	License  string
+	Declared string
+	Verdict  string
+}
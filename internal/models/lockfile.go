@@ -0,0 +1,22 @@
+package models
+
+// Verdicts of the lockfile integrity check.
+const (
+	// LockfileVerdictMismatch is an artifact whose hash differs from the one
+	// its lockfile entry pins.
+	LockfileVerdictMismatch = "mismatch"
+
+	// LockfileVerdictUnlisted is an artifact the lockfile does not list.
+	LockfileVerdictUnlisted = "unlisted"
+)
+
+// LockfileBlock records a package blocked because its artifact does not
+// match the project's lockfile. Expected and Actual are set for a mismatch.
+type LockfileBlock struct {
+	Name     string
+	Version  string
+	Lockfile string
+	Verdict  string
+	Expected string
+	Actual   string
+}
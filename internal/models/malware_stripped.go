@@ -0,0 +1,11 @@
+package models
+
+// MalwareStripped records a version with a malicious verdict removed from the
+// package metadata served to the package manager. It is not a block: the
+// resolver falls back to another version.
+type MalwareStripped struct {
+	Ecosystem string
+	Name      string
+	Version   string
+	Summary   string
+}
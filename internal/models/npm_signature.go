@@ -0,0 +1,11 @@
+package models
+
+// NpmSignatureBlock records an npm package version blocked because its
+// registry signature is missing or does not verify. Reason is the
+// verification failure.
+type NpmSignatureBlock struct {
+	Name     string
+	Version  string
+	Registry string
+	Reason   string
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PackageAgeBlock records a package blocked because its first release is
+// younger than the package_age minimum.
+type PackageAgeBlock struct {
+	Name         string
+	Version      string
+	FirstRelease time.Time
+	DaysAgo      int
+	MinDays      int
+}
@@ -0,0 +1,13 @@
+package models
+
+// ProvenanceBlock records a package version blocked because it lacks a
+// valid provenance attestation for its artifact. Repository is the source
+// repository a verified attestation names, if any, and Reason why the
+// attestation was not accepted.
+type ProvenanceBlock struct {
+	Ecosystem  string
+	Name       string
+	Version    string
+	Repository string
+	Reason     string
+}
@@ -0,0 +1,11 @@
+package models
+
+// RegistryIntegrityBlock records a package blocked because the artifact a
+// registry served does not match the hash published in its metadata.
+type RegistryIntegrityBlock struct {
+	Name     string
+	Version  string
+	Registry string
+	Expected string
+	Actual   string
+}
@@ -0,0 +1,164 @@
+// Package npmsig verifies the ECDSA registry signatures npm packuments carry
+// in dist.signatures against the registry's published signing keys.
+package npmsig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// embeddedKeys is the keyset of registry.npmjs.org at build time, in the
+// format of https://registry.npmjs.org/-/npm/v1/keys.
+//
+//go:embed keys.json
+var embeddedKeys []byte
+
+// Verification failures. A version can be unsigned, signed only by keys the
+// keyset does not know, signed only by keys that had expired when it was
+// published, or carry a signature that does not verify.
+var (
+	ErrUnsigned         = errors.New("no registry signature")
+	ErrUnknownKey       = errors.New("signed by an unknown key")
+	ErrExpiredKey       = errors.New("signed by a key that expired before the version was published")
+	ErrInvalidSignature = errors.New("invalid registry signature")
+)
+
+// Key is a registry signing key.
+type Key struct {
+	KeyID   string     `json:"keyid"`
+	KeyType string     `json:"keytype"`
+	Scheme  string     `json:"scheme"`
+	Key     string     `json:"key"`
+	Expires *time.Time `json:"expires"`
+
+	public *ecdsa.PublicKey
+}
+
+// Signature is an entry of a version's dist.signatures.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Keyset is the set of keys signatures are verified with.
+type Keyset struct {
+	Keys []Key `json:"keys"`
+}
+
+// Embedded returns the keyset built into PMG.
+func Embedded() *Keyset {
+	keys, err := Parse(embeddedKeys)
+	if err != nil {
+		panic(fmt.Sprintf("npmsig: invalid embedded keyset: %v", err))
+	}
+	return keys
+}
+
+// Load reads the keyset at path, falling back to the embedded keyset when
+// the file does not exist.
+func Load(path string) (*Keyset, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Embedded(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse reads a keyset in the format of the registry's keys endpoint. Every
+// key must be an ECDSA P-256 public key.
+func Parse(data []byte) (*Keyset, error) {
+	var keys Keyset
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid keyset: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, errors.New("keyset has no keys")
+	}
+
+	for i := range keys.Keys {
+		key := &keys.Keys[i]
+		if key.KeyID == "" {
+			return nil, fmt.Errorf("key %d has no keyid", i)
+		}
+
+		der, err := base64.StdEncoding.DecodeString(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.KeyID, err)
+		}
+		parsed, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.KeyID, err)
+		}
+		public, ok := parsed.(*ecdsa.PublicKey)
+		if !ok || public.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %s is not an ECDSA P-256 key", key.KeyID)
+		}
+		key.public = public
+	}
+	return &keys, nil
+}
+
+// Verify checks that one of signatures is a valid signature of
+// "name@version:integrity" by a key of the keyset that had not expired when
+// the version was published. The registry keeps the signatures made before a
+// rotation, so an expired key still verifies older versions. A zero
+// published time is unknown and the expiry is not checked, as npm does for
+// versions whose packument carries no time.
+func (k *Keyset) Verify(name, version, integrity string, published time.Time, signatures []Signature) error {
+	if len(signatures) == 0 {
+		return ErrUnsigned
+	}
+
+	digest := sha256.Sum256([]byte(name + "@" + version + ":" + integrity))
+	known, expired := false, false
+	for _, signature := range signatures {
+		key := k.key(signature.KeyID)
+		if key == nil {
+			continue
+		}
+		known = true
+
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+		if !ecdsa.VerifyASN1(key.public, digest[:], sig) {
+			continue
+		}
+		if key.Expires != nil && !published.IsZero() && !published.Before(*key.Expires) {
+			expired = true
+			continue
+		}
+		return nil
+	}
+
+	switch {
+	case !known:
+		return ErrUnknownKey
+	case expired:
+		return ErrExpiredKey
+	default:
+		return ErrInvalidSignature
+	}
+}
+
+func (k *Keyset) key(id string) *Key {
+	for i := range k.Keys {
+		if k.Keys[i].KeyID == id {
+			return &k.Keys[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package npmsig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKeyset(t *testing.T) (*Keyset, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	require.NoError(t, err)
+
+	keys, err := Parse([]byte(`{"keys":[{"keyid":"SHA256:test","keytype":"ecdsa-sha2-nistp256",` +
+		`"scheme":"ecdsa-sha2-nistp256","expires":null,"key":"` + base64.StdEncoding.EncodeToString(der) + `"}]}`))
+	require.NoError(t, err)
+	return keys, private
+}
+
+func sign(t *testing.T, private *ecdsa.PrivateKey, message string) string {
+	t.Helper()
+
+	digest := sha256.Sum256([]byte(message))
+	sig, err := ecdsa.SignASN1(rand.Reader, private, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestEmbedded(t *testing.T) {
+	keys := Embedded()
+	require.NotEmpty(t, keys.Keys)
+	for _, key := range keys.Keys {
+		assert.NotNil(t, key.public, key.KeyID)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	keys, private := testKeyset(t)
+	sig := sign(t, private, "left-pad@1.3.0:sha512-AAAA")
+
+	assert.NoError(t, keys.Verify("left-pad", "1.3.0", "sha512-AAAA", time.Time{},
+		[]Signature{{KeyID: "SHA256:other", Sig: sig}, {KeyID: "SHA256:test", Sig: sig}}))
+
+	assert.ErrorIs(t, keys.Verify("left-pad", "1.3.0", "sha512-BBBB", time.Time{}, []Signature{{KeyID: "SHA256:test", Sig: sig}}), ErrInvalidSignature)
+	assert.ErrorIs(t, keys.Verify("left-pad", "1.3.1", "sha512-AAAA", time.Time{}, []Signature{{KeyID: "SHA256:test", Sig: sig}}), ErrInvalidSignature)
+	assert.ErrorIs(t, keys.Verify("left-pad", "1.3.0", "sha512-AAAA", time.Time{}, []Signature{{KeyID: "SHA256:test", Sig: "not base64"}}), ErrInvalidSignature)
+	assert.ErrorIs(t, keys.Verify("left-pad", "1.3.0", "sha512-AAAA", time.Time{}, []Signature{{KeyID: "SHA256:other", Sig: sig}}), ErrUnknownKey)
+	assert.ErrorIs(t, keys.Verify("left-pad", "1.3.0", "sha512-AAAA", time.Time{}, nil), ErrUnsigned)
+}
+
+func TestVerify_ExpiredKey(t *testing.T) {
+	keys, private := testKeyset(t)
+	expires := time.Date(2025, 1, 29, 0, 0, 0, 0, time.UTC)
+	keys.Keys[0].Expires = &expires
+	signatures := []Signature{{KeyID: "SHA256:test", Sig: sign(t, private, "left-pad@1.3.0:sha512-AAAA")}}
+
+	assert.NoError(t, keys.Verify("left-pad", "1.3.0", "sha512-AAAA", expires.Add(-time.Hour), signatures))
+	assert.ErrorIs(t, keys.Verify("left-pad", "1.3.0", "sha512-AAAA", expires, signatures), ErrExpiredKey)
+	// An unknown publish time leaves the expiry unchecked.
+	assert.NoError(t, keys.Verify("left-pad", "1.3.0", "sha512-AAAA", time.Time{}, signatures))
+
+	// A bad signature is invalid whether or not its key expired.
+	assert.ErrorIs(t, keys.Verify("left-pad", "1.3.0", "sha512-BBBB", expires, signatures), ErrInvalidSignature)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"not json":   `keys`,
+		"no keys":    `{"keys":[]}`,
+		"no keyid":   `{"keys":[{"key":"MFkw"}]}`,
+		"bad base64": `{"keys":[{"keyid":"k","key":"!!"}]}`,
+		"not a key":  `{"keys":[{"keyid":"k","key":"AAAA"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	keys, err := Load(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	assert.Equal(t, len(Embedded().Keys), len(keys.Keys))
+
+	path := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(path, embeddedKeys[:10], 0o644))
+	_, err = Load(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,432 @@
+package provenance
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedded(t *testing.T) {
+	root := Embedded()
+	assert.NotEmpty(t, root.authorities)
+	assert.NotEmpty(t, root.logs)
+}
+
+func TestVerifyBundle_PublicGood(t *testing.T) {
+	data, err := os.ReadFile("testdata/sigstore-2.0.0.sigstore.json")
+	require.NoError(t, err)
+
+	result, err := Embedded().VerifyBundle(data)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/sigstore/sigstore-js", result.SourceRepository)
+	assert.Equal(t, "https://slsa.dev/provenance/v1", result.PredicateType)
+	require.Len(t, result.subjects, 1)
+	assert.Equal(t, "pkg:npm/sigstore@2.0.0", result.subjects[0].Name)
+
+	doc := []byte(`{"attestations":[{"predicateType":"https://github.com/npm/attestation/tree/main/specs/publish/v0.1","bundle":{}},` +
+		`{"predicateType":"https://slsa.dev/provenance/v1","bundle":` + string(data) + `}]}`)
+	result, err = Embedded().VerifyNpm(doc)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/sigstore/sigstore-js", result.SourceRepository)
+
+	_, err = Embedded().VerifyNpm([]byte(`{"attestations":[]}`))
+	assert.ErrorIs(t, err, ErrNoProvenance)
+
+	// A statement other than the one signed does not verify.
+	var b map[string]any
+	require.NoError(t, json.Unmarshal(data, &b))
+	b["dsseEnvelope"].(map[string]any)["payload"] = base64.StdEncoding.EncodeToString([]byte(`{"subject":[]}`))
+	tampered, err := json.Marshal(b)
+	require.NoError(t, err)
+	_, err = Embedded().VerifyBundle(tampered)
+	assert.Error(t, err)
+}
+
+func TestVerifyBundle_PublicGoodTransparencyLog(t *testing.T) {
+	data, err := os.ReadFile("testdata/sigstore-2.0.0.sigstore.json")
+	require.NoError(t, err)
+
+	// edit returns the bundle with its transparency log entry changed.
+	edit := func(change func(entry map[string]any)) []byte {
+		var b map[string]any
+		require.NoError(t, json.Unmarshal(data, &b))
+		change(b["verificationMaterial"].(map[string]any)["tlogEntries"].([]any)[0].(map[string]any))
+		edited, err := json.Marshal(b)
+		require.NoError(t, err)
+		return edited
+	}
+
+	// Either the inclusion proof or the inclusion promise is enough.
+	_, err = Embedded().VerifyBundle(edit(func(entry map[string]any) { delete(entry, "inclusionPromise") }))
+	assert.NoError(t, err)
+	_, err = Embedded().VerifyBundle(edit(func(entry map[string]any) { delete(entry, "inclusionProof") }))
+	assert.NoError(t, err)
+	_, err = Embedded().VerifyBundle(edit(func(entry map[string]any) {
+		delete(entry, "inclusionPromise")
+		delete(entry, "inclusionProof")
+	}))
+	assert.Error(t, err)
+
+	// A proof that does not lead to the checkpoint's root does not verify,
+	// even with a valid promise.
+	_, err = Embedded().VerifyBundle(edit(func(entry map[string]any) {
+		proof := entry["inclusionProof"].(map[string]any)
+		hashes := proof["hashes"].([]any)
+		hashes[0], hashes[1] = hashes[1], hashes[0]
+	}))
+	assert.Error(t, err)
+	_, err = Embedded().VerifyBundle(edit(func(entry map[string]any) {
+		delete(entry, "inclusionPromise")
+		proof := entry["inclusionProof"].(map[string]any)
+		proof["checkpoint"] = map[string]any{"envelope": "rekor.sigstore.dev - 2605736670972794746\n1\nAAAA\n\n"}
+	}))
+	assert.Error(t, err)
+
+	// The certificate's timestamp must come from a trusted CT log.
+	var root map[string]any
+	require.NoError(t, json.Unmarshal(embeddedTrustedRoot, &root))
+	root["ctlogs"] = root["ctlogs"].([]any)[:1]
+	rootData, err := json.Marshal(root)
+	require.NoError(t, err)
+	withoutLog, err := Parse(rootData)
+	require.NoError(t, err)
+	_, err = withoutLog.VerifyBundle(data)
+	assert.Error(t, err)
+}
+
+// testSigstore is a certificate authority, transparency log and CT log for
+// signing test attestations. Its log entries carry an inclusion proof, and an
+// inclusion promise unless proofOnly is set.
+type testSigstore struct {
+	t         *testing.T
+	caKey     *ecdsa.PrivateKey
+	ca        *x509.Certificate
+	logKey    *ecdsa.PrivateKey
+	logID     []byte
+	ctKey     *ecdsa.PrivateKey
+	ctID      []byte
+	proofOnly bool
+}
+
+func newTestSigstore(t *testing.T) *testSigstore {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	logDER, err := x509.MarshalPKIXPublicKey(&logKey.PublicKey)
+	require.NoError(t, err)
+	logID := sha256.Sum256(logDER)
+
+	ctKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ctDER, err := x509.MarshalPKIXPublicKey(&ctKey.PublicKey)
+	require.NoError(t, err)
+	ctID := sha256.Sum256(ctDER)
+
+	return &testSigstore{t: t, caKey: caKey, ca: ca, logKey: logKey, logID: logID[:], ctKey: ctKey, ctID: ctID[:]}
+}
+
+func (s *testSigstore) trustRoot() *TrustRoot {
+	logDER, err := x509.MarshalPKIXPublicKey(&s.logKey.PublicKey)
+	require.NoError(s.t, err)
+	ctDER, err := x509.MarshalPKIXPublicKey(&s.ctKey.PublicKey)
+	require.NoError(s.t, err)
+
+	doc := map[string]any{
+		"tlogs": []any{map[string]any{
+			"publicKey": map[string]any{
+				"rawBytes": base64.StdEncoding.EncodeToString(logDER),
+				"validFor": map[string]any{"start": time.Now().Add(-time.Hour)},
+			},
+			"logId": map[string]any{"keyId": base64.StdEncoding.EncodeToString(s.logID)},
+		}},
+		"ctlogs": []any{map[string]any{
+			"publicKey": map[string]any{
+				"rawBytes": base64.StdEncoding.EncodeToString(ctDER),
+				"validFor": map[string]any{"start": time.Now().Add(-time.Hour)},
+			},
+			"logId": map[string]any{"keyId": base64.StdEncoding.EncodeToString(s.ctID)},
+		}},
+		"certificateAuthorities": []any{map[string]any{
+			"certChain": map[string]any{"certificates": []any{map[string]any{"rawBytes": base64.StdEncoding.EncodeToString(s.ca.Raw)}}},
+			"validFor":  map[string]any{"start": time.Now().Add(-time.Hour)},
+		}},
+	}
+	data, err := json.Marshal(doc)
+	require.NoError(s.t, err)
+	root, err := Parse(data)
+	require.NoError(s.t, err)
+	return root
+}
+
+// pep740 returns a PyPI provenance object attesting artifact as filename,
+// signed in a workflow of repository.
+func (s *testSigstore) pep740(filename string, artifact []byte, repository string) []byte {
+	t := s.t
+	digest := sha256.Sum256(artifact)
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"subject":       []any{map[string]any{"name": filename, "digest": map[string]string{"sha256": hex.EncodeToString(digest[:])}}},
+		"predicateType": "https://docs.pypi.org/attestations/publish/v1",
+	})
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	repositoryExt, err := asn1.MarshalWithParams(repository, "utf8")
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{{Id: oidSourceRepositoryURI, Value: repositoryExt}},
+	}
+	cert := s.certificate(template, &key.PublicKey)
+
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digestOf(pae(inTotoPayloadType, statement)))
+	require.NoError(t, err)
+
+	statementHash := sha256.Sum256(statement)
+	body, err := json.Marshal(map[string]any{
+		"apiVersion": "0.0.1",
+		"kind":       "dsse",
+		"spec": map[string]any{
+			"payloadHash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(statementHash[:])},
+			"signatures": []any{map[string]string{
+				"signature": base64.StdEncoding.EncodeToString(sig),
+				"verifier":  base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	canonicalizedBody := base64.StdEncoding.EncodeToString(body)
+	integratedTime := time.Now().Unix()
+	message, err := json.Marshal(map[string]any{
+		"body":           canonicalizedBody,
+		"integratedTime": integratedTime,
+		"logID":          hex.EncodeToString(s.logID),
+		"logIndex":       7,
+	})
+	require.NoError(t, err)
+	set, err := ecdsa.SignASN1(rand.Reader, s.logKey, digestOf(message))
+	require.NoError(t, err)
+
+	entry := map[string]any{
+		"logIndex":          "7",
+		"logId":             map[string]string{"keyId": base64.StdEncoding.EncodeToString(s.logID)},
+		"integratedTime":    strconv.FormatInt(integratedTime, 10),
+		"inclusionPromise":  map[string]string{"signedEntryTimestamp": base64.StdEncoding.EncodeToString(set)},
+		"inclusionProof":    s.inclusionProof(body),
+		"canonicalizedBody": canonicalizedBody,
+	}
+	if s.proofOnly {
+		delete(entry, "inclusionPromise")
+	}
+
+	doc, err := json.Marshal(map[string]any{
+		"version": 1,
+		"attestation_bundles": []any{map[string]any{
+			"publisher": map[string]any{"kind": "GitHub", "repository": "acme/widgets"},
+			"attestations": []any{map[string]any{
+				"version": 1,
+				"verification_material": map[string]any{
+					"certificate":          base64.StdEncoding.EncodeToString(cert),
+					"transparency_entries": []any{entry},
+				},
+				"envelope": map[string]any{
+					"statement": base64.StdEncoding.EncodeToString(statement),
+					"signature": base64.StdEncoding.EncodeToString(sig),
+				},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	return doc
+}
+
+// certificate issues a certificate for key with an SCT from the CT log
+// embedded, made over the certificate without it.
+func (s *testSigstore) certificate(template *x509.Certificate, key *ecdsa.PublicKey) []byte {
+	t := s.t
+	precert, err := x509.CreateCertificate(rand.Reader, template, s.ca, key, s.caKey)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(precert)
+	require.NoError(t, err)
+
+	timestamp := uint64(time.Now().UnixMilli())
+	issuerKeyHash := sha256.Sum256(s.ca.RawSubjectPublicKeyInfo)
+	tbs := parsed.RawTBSCertificate
+	signed := []byte{0, 0}
+	signed = binary.BigEndian.AppendUint64(signed, timestamp)
+	signed = binary.BigEndian.AppendUint16(signed, 1)
+	signed = append(signed, issuerKeyHash[:]...)
+	signed = append(signed, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+	signed = append(signed, tbs...)
+	signed = binary.BigEndian.AppendUint16(signed, 0)
+	sig, err := ecdsa.SignASN1(rand.Reader, s.ctKey, digestOf(signed))
+	require.NoError(t, err)
+
+	sct := append([]byte{0}, s.ctID...)
+	sct = binary.BigEndian.AppendUint64(sct, timestamp)
+	sct = binary.BigEndian.AppendUint16(sct, 0)
+	sct = append(sct, 4, 3)
+	sct = binary.BigEndian.AppendUint16(sct, uint16(len(sig)))
+	sct = append(sct, sig...)
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(sct)+2))
+	list = binary.BigEndian.AppendUint16(list, uint16(len(sct)))
+	list = append(list, sct...)
+	value, err := asn1.Marshal(list)
+	require.NoError(t, err)
+
+	template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: oidSCTList, Value: value})
+	cert, err := x509.CreateCertificate(rand.Reader, template, s.ca, key, s.caKey)
+	require.NoError(t, err)
+	return cert
+}
+
+// inclusionProof returns an inclusion proof of entry as the third of five
+// entries in the log, with a checkpoint the log signed.
+func (s *testSigstore) inclusionProof(entry []byte) map[string]any {
+	leaves := [][]byte{[]byte("a"), []byte("b"), entry, []byte("d"), []byte("e")}
+	root := merkleTreeHash(leaves)
+
+	text := "test.log - 1\n5\n" + base64.StdEncoding.EncodeToString(root) + "\n"
+	sig, err := ecdsa.SignASN1(rand.Reader, s.logKey, digestOf([]byte(text)))
+	require.NoError(s.t, err)
+	checkpoint := text + "\n— test.log " + base64.StdEncoding.EncodeToString(append(append([]byte{}, s.logID[:4]...), sig...)) + "\n"
+
+	hashes := []string{}
+	for _, h := range merkleAuditPath(2, leaves) {
+		hashes = append(hashes, base64.StdEncoding.EncodeToString(h))
+	}
+	return map[string]any{
+		"logIndex":   "2",
+		"rootHash":   base64.StdEncoding.EncodeToString(root),
+		"treeSize":   "5",
+		"hashes":     hashes,
+		"checkpoint": map[string]string{"envelope": checkpoint},
+	}
+}
+
+// merkleTreeHash is the RFC 6962 Merkle tree hash of leaves.
+func merkleTreeHash(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return merkleHash(0x00, leaves[0])
+	}
+	k := merkleSplit(len(leaves))
+	return merkleHash(0x01, merkleTreeHash(leaves[:k]), merkleTreeHash(leaves[k:]))
+}
+
+// merkleAuditPath is the RFC 6962 audit path of the leaf at index.
+func merkleAuditPath(index int, leaves [][]byte) [][]byte {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := merkleSplit(len(leaves))
+	if index < k {
+		return append(merkleAuditPath(index, leaves[:k]), merkleTreeHash(leaves[k:]))
+	}
+	return append(merkleAuditPath(index-k, leaves[k:]), merkleTreeHash(leaves[:k]))
+}
+
+// merkleSplit is the largest power of two smaller than n.
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+func digestOf(message []byte) []byte {
+	sum := sha256.Sum256(message)
+	return sum[:]
+}
+
+func TestVerifyPyPI(t *testing.T) {
+	sigstore := newTestSigstore(t)
+	artifact := []byte("wheel bytes")
+	doc := sigstore.pep740("widgets-1.0-py3-none-any.whl", artifact, "https://github.com/acme/widgets")
+
+	result, err := sigstore.trustRoot().VerifyPyPI(doc)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/widgets", result.SourceRepository)
+	assert.True(t, result.Covers(artifact, "widgets-1.0-py3-none-any.whl"))
+	assert.False(t, result.Covers([]byte("other bytes"), "widgets-1.0-py3-none-any.whl"))
+	assert.False(t, result.Covers(artifact, "widgets-1.0.tar.gz"))
+
+	// Another trust root does not know the signing authority or the log.
+	_, err = newTestSigstore(t).trustRoot().VerifyPyPI(doc)
+	assert.Error(t, err)
+
+	_, err = sigstore.trustRoot().VerifyPyPI([]byte(`{"version":1,"attestation_bundles":[]}`))
+	assert.ErrorIs(t, err, ErrNoProvenance)
+}
+
+func TestVerifyPyPI_InclusionProofOnly(t *testing.T) {
+	sigstore := newTestSigstore(t)
+	sigstore.proofOnly = true
+	artifact := []byte("wheel bytes")
+	doc := sigstore.pep740("widgets-1.0-py3-none-any.whl", artifact, "https://github.com/acme/widgets")
+
+	result, err := sigstore.trustRoot().VerifyPyPI(doc)
+	require.NoError(t, err)
+	assert.True(t, result.Covers(artifact, "widgets-1.0-py3-none-any.whl"))
+
+	// A checkpoint signed by another key does not verify.
+	forger := *sigstore
+	forger.logKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	var provenance map[string]any
+	require.NoError(t, json.Unmarshal(doc, &provenance))
+	attestation := provenance["attestation_bundles"].([]any)[0].(map[string]any)["attestations"].([]any)[0].(map[string]any)
+	entry := attestation["verification_material"].(map[string]any)["transparency_entries"].([]any)[0].(map[string]any)
+	body, err := base64.StdEncoding.DecodeString(entry["canonicalizedBody"].(string))
+	require.NoError(t, err)
+	entry["inclusionProof"] = forger.inclusionProof(body)
+	forged, err := json.Marshal(provenance)
+	require.NoError(t, err)
+	_, err = sigstore.trustRoot().VerifyPyPI(forged)
+	assert.Error(t, err)
+}
+
+func TestNpmSubjectNames(t *testing.T) {
+	assert.Equal(t, []string{"pkg:npm/left-pad@1.3.0"}, NpmSubjectNames("left-pad", "1.3.0"))
+	assert.Equal(t, []string{"pkg:npm/@acme/widgets@1.0.0", "pkg:npm/%40acme/widgets@1.0.0"}, NpmSubjectNames("@acme/widgets", "1.0.0"))
+}
@@ -0,0 +1,158 @@
+package provenance
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// oidSCTList is the X.509 extension embedding the signed certificate
+// timestamps CT logs issued for a precertificate.
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// signedCertificateTimestamp is an RFC 6962 v1 SCT.
+type signedCertificateTimestamp struct {
+	logID      []byte
+	timestamp  uint64
+	extensions []byte
+	signature  []byte
+}
+
+// verifySCT checks that leaf embeds a signed certificate timestamp from a
+// trusted CT log, so the certificate authority published the certificate.
+// A trusted root without CT logs checks none.
+func (r *TrustRoot) verifySCT(leaf, issuer *x509.Certificate) error {
+	if len(r.ctLogs) == 0 {
+		return nil
+	}
+
+	var list []byte
+	for _, ext := range leaf.Extensions {
+		if ext.Id.Equal(oidSCTList) {
+			if _, err := asn1.Unmarshal(ext.Value, &list); err != nil {
+				return fmt.Errorf("invalid signed certificate timestamps: %w", err)
+			}
+		}
+	}
+	if list == nil {
+		return errors.New("signing certificate has no signed certificate timestamp")
+	}
+
+	scts, err := parseSCTList(list)
+	if err != nil {
+		return fmt.Errorf("invalid signed certificate timestamps: %w", err)
+	}
+	tbs, err := precertificateTBS(leaf.RawTBSCertificate)
+	if err != nil {
+		return fmt.Errorf("invalid signing certificate: %w", err)
+	}
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+
+	for _, sct := range scts {
+		log, ok := r.ctLogs[string(sct.logID)]
+		if !ok || !log.contains(time.UnixMilli(int64(sct.timestamp))) {
+			continue
+		}
+
+		// The digitally-signed precert_entry of RFC 6962 section 3.2.
+		signed := []byte{0, 0}
+		signed = binary.BigEndian.AppendUint64(signed, sct.timestamp)
+		signed = binary.BigEndian.AppendUint16(signed, 1)
+		signed = append(signed, issuerKeyHash[:]...)
+		signed = append(signed, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+		signed = append(signed, tbs...)
+		signed = binary.BigEndian.AppendUint16(signed, uint16(len(sct.extensions)))
+		signed = append(signed, sct.extensions...)
+
+		if ecdsa.VerifyASN1(log.key, digest(log.key, signed), sct.signature) {
+			return nil
+		}
+	}
+	return errors.New("signing certificate has no signed certificate timestamp from a trusted CT log")
+}
+
+// parseSCTList reads a TLS-encoded SignedCertificateTimestampList.
+func parseSCTList(data []byte) ([]signedCertificateTimestamp, error) {
+	list, rest, err := readVector(data, 2)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("malformed list")
+	}
+
+	var scts []signedCertificateTimestamp
+	for len(list) > 0 {
+		var raw []byte
+		if raw, list, err = readVector(list, 2); err != nil {
+			return nil, err
+		}
+		// Version v1, a 32 byte log ID and a 64-bit timestamp.
+		if len(raw) < 41 || raw[0] != 0 {
+			continue
+		}
+		sct := signedCertificateTimestamp{logID: raw[1:33], timestamp: binary.BigEndian.Uint64(raw[33:41])}
+		if sct.extensions, raw, err = readVector(raw[41:], 2); err != nil || len(raw) < 2 {
+			return nil, errors.New("malformed timestamp")
+		}
+		// The hash and signature algorithms precede the signature.
+		if sct.signature, raw, err = readVector(raw[2:], 2); err != nil || len(raw) != 0 {
+			return nil, errors.New("malformed timestamp")
+		}
+		scts = append(scts, sct)
+	}
+	return scts, nil
+}
+
+// readVector reads a TLS vector with a length prefix of size bytes.
+func readVector(data []byte, size int) (vector, rest []byte, err error) {
+	if len(data) < size {
+		return nil, nil, errors.New("truncated vector")
+	}
+	n := 0
+	for _, b := range data[:size] {
+		n = n<<8 | int(b)
+	}
+	if len(data) < size+n {
+		return nil, nil, errors.New("truncated vector")
+	}
+	return data[size : size+n], data[size+n:], nil
+}
+
+// tbsCertificate is the ASN.1 TBSCertificate of RFC 5280.
+type tbsCertificate struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm asn1.RawValue
+	Issuer             asn1.RawValue
+	Validity           asn1.RawValue
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	IssuerUniqueID     asn1.BitString   `asn1:"optional,tag:1"`
+	SubjectUniqueID    asn1.BitString   `asn1:"optional,tag:2"`
+	Extensions         []pkix.Extension `asn1:"omitempty,optional,explicit,tag:3"`
+}
+
+// precertificateTBS returns the TBSCertificate a CT log signed: the
+// certificate's own without the SCT list extension.
+func precertificateTBS(raw []byte) ([]byte, error) {
+	var tbs tbsCertificate
+	if _, err := asn1.Unmarshal(raw, &tbs); err != nil {
+		return nil, err
+	}
+
+	extensions := tbs.Extensions[:0]
+	for _, ext := range tbs.Extensions {
+		if !ext.Id.Equal(oidSCTList) {
+			extensions = append(extensions, ext)
+		}
+	}
+	tbs.Extensions = extensions
+	tbs.Raw = nil
+	return asn1.Marshal(tbs)
+}
@@ -0,0 +1,127 @@
+package provenance
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// verifyInclusionProof checks that the inclusion proof of entry leads from
+// its body to a root hash, and that log signed a checkpoint for that root.
+func verifyInclusionProof(entry tlogEntry, log transparencyLog, logID []byte) error {
+	proof := entry.InclusionProof
+
+	leaf, err := base64.StdEncoding.DecodeString(entry.CanonicalizedBody)
+	if err != nil {
+		return fmt.Errorf("invalid transparency log entry body: %w", err)
+	}
+	rootHash, err := base64.StdEncoding.DecodeString(proof.RootHash)
+	if err != nil {
+		return fmt.Errorf("invalid inclusion proof root hash: %w", err)
+	}
+	hashes := make([][]byte, 0, len(proof.Hashes))
+	for _, h := range proof.Hashes {
+		decoded, err := base64.StdEncoding.DecodeString(h)
+		if err != nil {
+			return fmt.Errorf("invalid inclusion proof hash: %w", err)
+		}
+		hashes = append(hashes, decoded)
+	}
+
+	if err := verifyInclusion(leaf, int64(proof.LogIndex), int64(proof.TreeSize), hashes, rootHash); err != nil {
+		return err
+	}
+	return verifyCheckpoint(proof.Checkpoint.Envelope, log, logID, int64(proof.TreeSize), rootHash)
+}
+
+// verifyInclusion checks an RFC 9162 inclusion proof: that hashing leaf, the
+// entry at index in a tree of treeSize entries, with the proof hashes gives
+// rootHash.
+func verifyInclusion(leaf []byte, index, treeSize int64, proof [][]byte, rootHash []byte) error {
+	if index < 0 || index >= treeSize {
+		return errors.New("inclusion proof index is outside the tree")
+	}
+
+	hash := merkleHash(0x00, leaf)
+	fn, sn := index, treeSize-1
+	for _, p := range proof {
+		if sn == 0 {
+			return errors.New("inclusion proof is too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			hash = merkleHash(0x01, p, hash)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			hash = merkleHash(0x01, hash, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 || !bytes.Equal(hash, rootHash) {
+		return errors.New("transparency log inclusion proof does not verify")
+	}
+	return nil
+}
+
+// merkleHash hashes the parts of a Merkle tree leaf (prefix 0x00) or node
+// (prefix 0x01).
+func merkleHash(prefix byte, parts ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{prefix})
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// verifyCheckpoint checks that a checkpoint, a signed note whose text is
+// the log origin, the tree size and the root hash, commits to a tree of
+// treeSize entries with rootHash and is signed by log. Signature lines carry
+// a four byte key hint, the start of the log ID, before the signature.
+func verifyCheckpoint(envelope string, log transparencyLog, logID []byte, treeSize int64, rootHash []byte) error {
+	text, signatures, ok := strings.Cut(envelope, "\n\n")
+	if !ok {
+		return errors.New("invalid transparency log checkpoint")
+	}
+	text += "\n"
+
+	lines := strings.Split(text, "\n")
+	if len(lines) < 4 {
+		return errors.New("invalid transparency log checkpoint")
+	}
+	size, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transparency log checkpoint size: %w", err)
+	}
+	root, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil {
+		return fmt.Errorf("invalid transparency log checkpoint root hash: %w", err)
+	}
+	if size != treeSize || !bytes.Equal(root, rootHash) {
+		return errors.New("transparency log checkpoint is for another tree")
+	}
+
+	for _, line := range strings.Split(signatures, "\n") {
+		line, ok := strings.CutPrefix(line, "— ")
+		if !ok {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(line[strings.LastIndex(line, " ")+1:])
+		if err != nil || len(sig) <= 4 || len(logID) < 4 || !bytes.Equal(sig[:4], logID[:4]) {
+			continue
+		}
+		if ecdsa.VerifyASN1(log.key, digest(log.key, []byte(text)), sig[4:]) {
+			return nil
+		}
+	}
+	return errors.New("transparency log checkpoint is not signed by the log")
+}
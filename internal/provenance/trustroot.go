@@ -0,0 +1,196 @@
+// Package provenance verifies Sigstore provenance attestations offline: npm
+// dist.attestations bundles and PyPI PEP 740 attestations are checked
+// against a trusted root of Fulcio certificate authorities and Rekor
+// transparency log keys, without contacting Sigstore.
+package provenance
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// embeddedTrustedRoot is the Sigstore public-good trusted root at build
+// time, in the format of the trusted_root.json TUF target.
+//
+//go:embed trusted_root.json
+var embeddedTrustedRoot []byte
+
+// TrustRoot holds the certificate authorities that issue signing
+// certificates, the transparency logs that timestamp signatures, and the CT
+// logs the authorities publish certificates to.
+type TrustRoot struct {
+	authorities []certificateAuthority
+	logs        map[string]transparencyLog
+	ctLogs      map[string]transparencyLog
+}
+
+type certificateAuthority struct {
+	root          *x509.Certificate
+	intermediates []*x509.Certificate
+	validity
+}
+
+type transparencyLog struct {
+	key *ecdsa.PublicKey
+	validity
+}
+
+// validity is the period a key or authority may be used for. A zero end is
+// open.
+type validity struct {
+	start time.Time
+	end   time.Time
+}
+
+func (v validity) contains(t time.Time) bool {
+	if t.Before(v.start) {
+		return false
+	}
+	return v.end.IsZero() || !t.After(v.end)
+}
+
+// trustedRootDocument is the JSON form of a Sigstore TrustedRoot.
+type trustedRootDocument struct {
+	Tlogs                  []logJSON `json:"tlogs"`
+	Ctlogs                 []logJSON `json:"ctlogs"`
+	CertificateAuthorities []struct {
+		CertChain struct {
+			Certificates []struct {
+				RawBytes string `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"certChain"`
+		ValidFor validForJSON `json:"validFor"`
+	} `json:"certificateAuthorities"`
+}
+
+type logJSON struct {
+	PublicKey struct {
+		RawBytes   string       `json:"rawBytes"`
+		KeyDetails string       `json:"keyDetails"`
+		ValidFor   validForJSON `json:"validFor"`
+	} `json:"publicKey"`
+	LogID struct {
+		KeyID string `json:"keyId"`
+	} `json:"logId"`
+}
+
+type validForJSON struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+func (v validForJSON) validity() validity {
+	out := validity{start: v.Start}
+	if v.End != nil {
+		out.end = *v.End
+	}
+	return out
+}
+
+// Embedded returns the trusted root built into PMG.
+func Embedded() *TrustRoot {
+	root, err := Parse(embeddedTrustedRoot)
+	if err != nil {
+		panic(fmt.Sprintf("provenance: invalid embedded trusted root: %v", err))
+	}
+	return root
+}
+
+// Load reads the trusted root at path, or returns the embedded one when path
+// is empty.
+func Load(path string) (*TrustRoot, error) {
+	if path == "" {
+		return Embedded(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse reads a Sigstore trusted root. Transparency log keys must be ECDSA
+// keys; timestamp authorities and CT logs are not used.
+func Parse(data []byte) (*TrustRoot, error) {
+	var doc trustedRootDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid trusted root: %w", err)
+	}
+
+	logs, err := parseLogs(doc.Tlogs)
+	if err != nil {
+		return nil, fmt.Errorf("transparency log %w", err)
+	}
+	ctLogs, err := parseLogs(doc.Ctlogs)
+	if err != nil {
+		return nil, fmt.Errorf("CT log %w", err)
+	}
+
+	root := &TrustRoot{logs: logs, ctLogs: ctLogs}
+	for i, ca := range doc.CertificateAuthorities {
+		certs := ca.CertChain.Certificates
+		if len(certs) == 0 {
+			return nil, fmt.Errorf("certificate authority %d has no certificates", i)
+		}
+
+		chain := make([]*x509.Certificate, 0, len(certs))
+		for _, c := range certs {
+			der, err := base64.StdEncoding.DecodeString(c.RawBytes)
+			if err != nil {
+				return nil, fmt.Errorf("certificate authority %d: %w", i, err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("certificate authority %d: %w", i, err)
+			}
+			chain = append(chain, cert)
+		}
+
+		// The chain lists the issuing certificate first and the root last.
+		root.authorities = append(root.authorities, certificateAuthority{
+			root:          chain[len(chain)-1],
+			intermediates: chain[:len(chain)-1],
+			validity:      ca.ValidFor.validity(),
+		})
+	}
+
+	if len(root.authorities) == 0 || len(root.logs) == 0 {
+		return nil, errors.New("trusted root needs a certificate authority and a transparency log")
+	}
+	return root, nil
+}
+
+// parseLogs reads the ECDSA keys of logs, keyed by log ID. Rekor v2 logs
+// sign with ed25519 and a different entry format, and are skipped.
+func parseLogs(entries []logJSON) (map[string]transparencyLog, error) {
+	logs := map[string]transparencyLog{}
+	for _, entry := range entries {
+		der, err := base64.StdEncoding.DecodeString(entry.PublicKey.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.LogID.KeyID, err)
+		}
+		parsed, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.LogID.KeyID, err)
+		}
+		key, ok := parsed.(*ecdsa.PublicKey)
+		if !ok {
+			continue
+		}
+
+		logID, err := base64.StdEncoding.DecodeString(entry.LogID.KeyID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.LogID.KeyID, err)
+		}
+		logs[string(logID)] = transparencyLog{key: key, validity: entry.PublicKey.ValidFor.validity()}
+	}
+	return logs, nil
+}
@@ -0,0 +1,513 @@
+package provenance
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoProvenance is returned when a package version carries no provenance
+// attestation.
+var ErrNoProvenance = errors.New("no provenance attestation")
+
+// inTotoPayloadType is the DSSE payload type of in-toto statements.
+const inTotoPayloadType = "application/vnd.in-toto+json"
+
+// slsaProvenancePrefix prefixes the predicate types of SLSA provenance.
+const slsaProvenancePrefix = "https://slsa.dev/provenance/"
+
+// oidSourceRepositoryURI is the Fulcio certificate extension naming the
+// repository the signing workflow ran in.
+var oidSourceRepositoryURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+
+// Result is what a verified attestation states about the artifacts it
+// covers.
+type Result struct {
+	// SourceRepository is the repository URL of the workflow that signed
+	// the attestation, as certified by Fulcio.
+	SourceRepository string
+
+	// PredicateType is the in-toto predicate type of the statement.
+	PredicateType string
+
+	subjects []subject
+}
+
+type subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Covers reports whether the attestation names artifact, under one of
+// names, with a digest that matches its bytes.
+func (r *Result) Covers(artifact []byte, names ...string) bool {
+	for _, s := range r.subjects {
+		named := false
+		for _, name := range names {
+			if s.Name == name {
+				named = true
+				break
+			}
+		}
+		if !named {
+			continue
+		}
+
+		for algorithm, digest := range s.Digest {
+			var h hash.Hash
+			switch algorithm {
+			case "sha256":
+				h = sha256.New()
+			case "sha512":
+				h = sha512.New()
+			default:
+				continue
+			}
+			h.Write(artifact)
+			if strings.EqualFold(hex.EncodeToString(h.Sum(nil)), digest) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NpmSubjectNames returns the subject names npm provenance uses for a
+// package version: its purl, with the scope either percent-encoded or not.
+func NpmSubjectNames(name, version string) []string {
+	names := []string{"pkg:npm/" + name + "@" + version}
+	if strings.HasPrefix(name, "@") {
+		names = append(names, "pkg:npm/%40"+name[1:]+"@"+version)
+	}
+	return names
+}
+
+// VerifyNpm verifies the SLSA provenance in an npm attestations document,
+// as served at /-/npm/v1/attestations/<name>@<version>.
+func (r *TrustRoot) VerifyNpm(doc []byte) (*Result, error) {
+	var attestations struct {
+		Attestations []struct {
+			PredicateType string          `json:"predicateType"`
+			Bundle        json.RawMessage `json:"bundle"`
+		} `json:"attestations"`
+	}
+	if err := json.Unmarshal(doc, &attestations); err != nil {
+		return nil, fmt.Errorf("invalid npm attestations: %w", err)
+	}
+
+	for _, a := range attestations.Attestations {
+		if strings.HasPrefix(a.PredicateType, slsaProvenancePrefix) {
+			return r.VerifyBundle(a.Bundle)
+		}
+	}
+	return nil, ErrNoProvenance
+}
+
+// VerifyPyPI verifies the PEP 740 attestations in a PyPI provenance object,
+// as served by the Integrity API, and returns the first that verifies.
+func (r *TrustRoot) VerifyPyPI(doc []byte) (*Result, error) {
+	var provenance struct {
+		AttestationBundles []struct {
+			Attestations []pep740Attestation `json:"attestations"`
+		} `json:"attestation_bundles"`
+	}
+	if err := json.Unmarshal(doc, &provenance); err != nil {
+		return nil, fmt.Errorf("invalid PyPI provenance: %w", err)
+	}
+
+	err := ErrNoProvenance
+	for _, b := range provenance.AttestationBundles {
+		for _, a := range b.Attestations {
+			var result *Result
+			result, err = r.verifyPEP740(a)
+			if err == nil {
+				return result, nil
+			}
+		}
+	}
+	return nil, err
+}
+
+// bundle is the JSON form of a Sigstore bundle, versions 0.1 to 0.3.
+type bundle struct {
+	VerificationMaterial struct {
+		Certificate          *rawBytes `json:"certificate"`
+		X509CertificateChain *struct {
+			Certificates []rawBytes `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		TlogEntries []tlogEntry `json:"tlogEntries"`
+	} `json:"verificationMaterial"`
+	DSSEEnvelope *struct {
+		Payload     string `json:"payload"`
+		PayloadType string `json:"payloadType"`
+		Signatures  []struct {
+			Sig string `json:"sig"`
+		} `json:"signatures"`
+	} `json:"dsseEnvelope"`
+}
+
+type rawBytes struct {
+	RawBytes string `json:"rawBytes"`
+}
+
+// pep740Attestation is a PEP 740 attestation object.
+type pep740Attestation struct {
+	VerificationMaterial struct {
+		Certificate         string      `json:"certificate"`
+		TransparencyEntries []tlogEntry `json:"transparency_entries"`
+	} `json:"verification_material"`
+	Envelope struct {
+		Statement string `json:"statement"`
+		Signature string `json:"signature"`
+	} `json:"envelope"`
+}
+
+// tlogEntry is a Rekor transparency log entry with its inclusion promise,
+// its inclusion proof, or both.
+type tlogEntry struct {
+	LogIndex int64String `json:"logIndex"`
+	LogID    struct {
+		KeyID string `json:"keyId"`
+	} `json:"logId"`
+	IntegratedTime   int64String `json:"integratedTime"`
+	InclusionPromise *struct {
+		SignedEntryTimestamp string `json:"signedEntryTimestamp"`
+	} `json:"inclusionPromise"`
+	InclusionProof    *inclusionProof `json:"inclusionProof"`
+	CanonicalizedBody string          `json:"canonicalizedBody"`
+}
+
+// inclusionProof proves an entry is in the log's Merkle tree, under a root
+// hash the log signed in checkpoint. LogIndex is the index in that tree.
+type inclusionProof struct {
+	LogIndex   int64String `json:"logIndex"`
+	RootHash   string      `json:"rootHash"`
+	TreeSize   int64String `json:"treeSize"`
+	Hashes     []string    `json:"hashes"`
+	Checkpoint struct {
+		Envelope string `json:"envelope"`
+	} `json:"checkpoint"`
+}
+
+// int64String reads a protobuf JSON int64, which is a string or a number.
+type int64String int64
+
+func (i *int64String) UnmarshalJSON(data []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = int64String(n)
+	return nil
+}
+
+// VerifyBundle verifies a Sigstore bundle holding a DSSE-signed in-toto
+// statement.
+func (r *TrustRoot) VerifyBundle(data []byte) (*Result, error) {
+	var b bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid Sigstore bundle: %w", err)
+	}
+	if b.DSSEEnvelope == nil || len(b.DSSEEnvelope.Signatures) == 0 {
+		return nil, errors.New("bundle has no signed statement")
+	}
+
+	var certificate string
+	switch {
+	case b.VerificationMaterial.Certificate != nil:
+		certificate = b.VerificationMaterial.Certificate.RawBytes
+	case b.VerificationMaterial.X509CertificateChain != nil && len(b.VerificationMaterial.X509CertificateChain.Certificates) > 0:
+		certificate = b.VerificationMaterial.X509CertificateChain.Certificates[0].RawBytes
+	default:
+		return nil, errors.New("bundle has no signing certificate")
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(b.DSSEEnvelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statement: %w", err)
+	}
+	signatures := make([]string, 0, len(b.DSSEEnvelope.Signatures))
+	for _, s := range b.DSSEEnvelope.Signatures {
+		signatures = append(signatures, s.Sig)
+	}
+	return r.verify(certificate, b.VerificationMaterial.TlogEntries, b.DSSEEnvelope.PayloadType, payload, signatures)
+}
+
+func (r *TrustRoot) verifyPEP740(a pep740Attestation) (*Result, error) {
+	statement, err := base64.StdEncoding.DecodeString(a.Envelope.Statement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statement: %w", err)
+	}
+	return r.verify(a.VerificationMaterial.Certificate, a.VerificationMaterial.TransparencyEntries, inTotoPayloadType, statement, []string{a.Envelope.Signature})
+}
+
+// verify checks the DSSE signatures over payload: the signing certificate
+// must chain to a trusted certificate authority at the time a trusted
+// transparency log recorded the signature, embed a timestamp from a trusted
+// CT log, and one of the signatures must verify with its key.
+func (r *TrustRoot) verify(certificate string, entries []tlogEntry, payloadType string, payload []byte, signatures []string) (*Result, error) {
+	der, err := base64.StdEncoding.DecodeString(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %w", err)
+	}
+
+	signedAt, err := r.verifyTlog(entries, leaf, payload)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := r.verifyCertificate(leaf, signedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.verifySCT(leaf, issuer); err != nil {
+		return nil, err
+	}
+
+	key, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("signing certificate does not hold an ECDSA key")
+	}
+	verified := false
+	for _, signature := range signatures {
+		sig, err := base64.StdEncoding.DecodeString(signature)
+		if err == nil && ecdsa.VerifyASN1(key, digest(key, pae(payloadType, payload)), sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("attestation signature does not verify")
+	}
+
+	if payloadType != inTotoPayloadType {
+		return nil, fmt.Errorf("unexpected payload type %q", payloadType)
+	}
+	var statement struct {
+		Subject       []subject `json:"subject"`
+		PredicateType string    `json:"predicateType"`
+	}
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("invalid statement: %w", err)
+	}
+
+	return &Result{
+		SourceRepository: sourceRepository(leaf),
+		PredicateType:    statement.PredicateType,
+		subjects:         statement.Subject,
+	}, nil
+}
+
+// verifyTlog finds an entry of a trusted log whose body records this signing
+// certificate and payload, and that the log proved it included: by an
+// inclusion proof under a checkpoint it signed, by a signed inclusion
+// promise, or both, in which case both must verify. It returns the time the
+// signature was made: the integrated time the promise signs, or, for an
+// entry with only a proof, whose integrated time nothing signs, the time the
+// certificate was issued.
+func (r *TrustRoot) verifyTlog(entries []tlogEntry, leaf *x509.Certificate, payload []byte) (time.Time, error) {
+	payloadHash := sha256.Sum256(payload)
+	err := errors.New("attestation has no transparency log entry")
+
+	for _, entry := range entries {
+		logID, decodeErr := base64.StdEncoding.DecodeString(entry.LogID.KeyID)
+		if decodeErr != nil {
+			err = fmt.Errorf("invalid transparency log id: %w", decodeErr)
+			continue
+		}
+		log, ok := r.logs[string(logID)]
+		if !ok {
+			err = errors.New("attestation was recorded by an unknown transparency log")
+			continue
+		}
+		if entry.InclusionPromise == nil && entry.InclusionProof == nil {
+			err = errors.New("transparency log entry has no inclusion proof or promise")
+			continue
+		}
+
+		signedAt := leaf.NotBefore
+		if entry.InclusionPromise != nil {
+			signedAt = time.Unix(int64(entry.IntegratedTime), 0)
+		}
+		if !log.contains(signedAt) {
+			err = errors.New("transparency log key is not valid at the entry's time")
+			continue
+		}
+
+		if entry.InclusionProof != nil {
+			if proofErr := verifyInclusionProof(entry, log, logID); proofErr != nil {
+				err = proofErr
+				continue
+			}
+		}
+
+		if entry.InclusionPromise != nil {
+			// The promise signs the canonical JSON of the entry; the struct
+			// keeps its keys in sorted order.
+			message, _ := json.Marshal(struct {
+				Body           string `json:"body"`
+				IntegratedTime int64  `json:"integratedTime"`
+				LogID          string `json:"logID"`
+				LogIndex       int64  `json:"logIndex"`
+			}{entry.CanonicalizedBody, int64(entry.IntegratedTime), hex.EncodeToString(logID), int64(entry.LogIndex)})
+
+			set, decodeErr := base64.StdEncoding.DecodeString(entry.InclusionPromise.SignedEntryTimestamp)
+			if decodeErr != nil || !ecdsa.VerifyASN1(log.key, digest(log.key, message), set) {
+				err = errors.New("transparency log inclusion promise does not verify")
+				continue
+			}
+		}
+
+		if !entryRecords(entry.CanonicalizedBody, leaf.Raw, hex.EncodeToString(payloadHash[:])) {
+			err = errors.New("transparency log entry is for another signature")
+			continue
+		}
+		return signedAt, nil
+	}
+	return time.Time{}, err
+}
+
+// entryRecords reports whether an intoto or dsse Rekor entry body records
+// the payload hash and signing certificate of an attestation.
+func entryRecords(canonicalizedBody string, certificate []byte, payloadHash string) bool {
+	data, err := base64.StdEncoding.DecodeString(canonicalizedBody)
+	if err != nil {
+		return false
+	}
+
+	type hashValue struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"value"`
+	}
+	var body struct {
+		Spec struct {
+			// intoto v0.0.2
+			Content struct {
+				PayloadHash hashValue `json:"payloadHash"`
+				Envelope    struct {
+					Signatures []struct {
+						PublicKey string `json:"publicKey"`
+					} `json:"signatures"`
+				} `json:"envelope"`
+			} `json:"content"`
+
+			// dsse v0.0.1
+			PayloadHash hashValue `json:"payloadHash"`
+			Signatures  []struct {
+				Verifier string `json:"verifier"`
+			} `json:"signatures"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return false
+	}
+
+	recorded := body.Spec.PayloadHash
+	verifiers := make([]string, 0, 1)
+	for _, s := range body.Spec.Signatures {
+		verifiers = append(verifiers, s.Verifier)
+	}
+	if recorded.Value == "" {
+		recorded = body.Spec.Content.PayloadHash
+		for _, s := range body.Spec.Content.Envelope.Signatures {
+			verifiers = append(verifiers, s.PublicKey)
+		}
+	}
+	if recorded.Algorithm != "sha256" || !strings.EqualFold(recorded.Value, payloadHash) {
+		return false
+	}
+
+	for _, verifier := range verifiers {
+		encoded, err := base64.StdEncoding.DecodeString(verifier)
+		if err != nil {
+			continue
+		}
+		if block, _ := pem.Decode(encoded); block != nil && bytes.Equal(block.Bytes, certificate) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyCertificate checks that leaf chains to a certificate authority that
+// was valid at signedAt, and was itself valid then. It returns the
+// certificate that issued leaf.
+func (r *TrustRoot) verifyCertificate(leaf *x509.Certificate, signedAt time.Time) (*x509.Certificate, error) {
+	for _, ca := range r.authorities {
+		if !ca.contains(signedAt) {
+			continue
+		}
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.root)
+		intermediates := x509.NewCertPool()
+		for _, c := range ca.intermediates {
+			intermediates.AddCert(c)
+		}
+
+		chains, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   signedAt,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		})
+		if err == nil && len(chains[0]) > 1 {
+			return chains[0][1], nil
+		}
+	}
+	return nil, errors.New("attestation signing certificate is not issued by a trusted authority")
+}
+
+// sourceRepository returns the Source Repository URI extension of a Fulcio
+// certificate, or "" when it has none.
+func sourceRepository(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSourceRepositoryURI) {
+			continue
+		}
+		var uri string
+		if _, err := asn1.UnmarshalWithParams(ext.Value, &uri, "utf8"); err != nil {
+			return ""
+		}
+		return uri
+	}
+	return ""
+}
+
+// pae is the DSSE pre-authentication encoding signatures are made over.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// digest hashes message for an ECDSA key, with the hash its curve pairs
+// with.
+func digest(key *ecdsa.PublicKey, message []byte) []byte {
+	h := crypto.SHA256
+	switch key.Curve {
+	case elliptic.P384():
+		h = crypto.SHA384
+	case elliptic.P521():
+		h = crypto.SHA512
+	}
+	hasher := h.New()
+	hasher.Write(message)
+	return hasher.Sum(nil)
+}
@@ -63,8 +63,10 @@ type ProxyDaemonConfig struct {
 
 // Run starts the persistent proxy server in the foreground and blocks until it
 // receives SIGINT/SIGTERM. It writes the state file on startup, auto-blocks
-// suspicious packages, and records the final blocked count on shutdown.
-func Run(ctx context.Context, cfg *config.RuntimeConfig, statePath, host string, port int) error {
+// suspicious packages, and records the final blocked count on shutdown. With
+// recordSBOM it also records every package version it delivers, for
+// `pmg proxy stop --sbom`.
+func Run(ctx context.Context, cfg *config.RuntimeConfig, statePath, host string, port int, recordSBOM bool) error {
 	// The daemon runs intercepted traffic for every ecosystem, so an
 	// unloadable proxy.registries entry must abort here rather than fall
 	// back to defaults. Non-install commands (pmg config, proxy stop, ...)
@@ -97,7 +99,7 @@ func Run(ctx context.Context, cfg *config.RuntimeConfig, statePath, host string,
 		}
 	}()
 
-	malysisAnalyzer, err := flows.BuildMalysisAnalyzer(ctx, cfg, localDB)
+	packageAnalyzer, err := flows.BuildAnalyzer(ctx, cfg, localDB)
 	if err != nil {
 		return fmt.Errorf("create analyzer: %w", err)
 	}
@@ -108,7 +110,7 @@ func Run(ctx context.Context, cfg *config.RuntimeConfig, statePath, host string,
 	go autoBlockConfirmations(confirmationChan)
 
 	interceptorList, err := buildInterceptors(
-		malysisAnalyzer, cache, statsCollector, confirmationChan, cfg.Config.Proxy.Registries,
+		packageAnalyzer, cache, statsCollector, confirmationChan, cfg.Config.Proxy.Registries, recordSBOM,
 	)
 	if err != nil {
 		return err
@@ -134,6 +136,7 @@ func Run(ctx context.Context, cfg *config.RuntimeConfig, statePath, host string,
 		PID:        os.Getpid(),
 		Addr:       server.Address(),
 		CACertPath: caCertPath,
+		RecordSBOM: recordSBOM,
 	}
 	if err := writeState(statePath, state); err != nil {
 		stopCtx, cancel := context.WithTimeout(context.Background(), serverStopTimeout)
@@ -171,6 +174,10 @@ func Run(ctx context.Context, cfg *config.RuntimeConfig, statePath, host string,
 	// keeps `stop --fail-on-violation` correct in those cases.
 	stats := statsCollector.GetStats()
 	state.BlockedCount = stats.BlockedCount
+	state.Fetched = statsCollector.GetFetchedPackages()
+	if stats.UnverifiedCount > 0 {
+		log.Warnf("Malware analysis was unavailable for %d package(s) served without a verdict", stats.UnverifiedCount)
+	}
 	if werr := writeState(statePath, state); werr != nil {
 		log.Warnf("failed to write final proxy state: %v", werr)
 	}
@@ -196,18 +203,21 @@ func Run(ctx context.Context, cfg *config.RuntimeConfig, statePath, host string,
 }
 
 func buildInterceptors(
-	malysisAnalyzer analyzer.PackageVersionAnalyzer,
+	packageAnalyzer analyzer.PackageVersionAnalyzer,
 	cache interceptors.AnalysisCache,
 	statsCollector *interceptors.AnalysisStatsCollector,
 	confirmationChan chan *interceptors.ConfirmationRequest,
 	registries []config.ProxyRegistryConfig,
+	recordFetched bool,
 ) ([]pmgproxy.Interceptor, error) {
 	factory, err := interceptors.NewInterceptorFactory(
-		malysisAnalyzer,
+		packageAnalyzer,
 		cache,
 		statsCollector,
 		confirmationChan,
-		interceptors.InterceptorContext{},
+		// Recording holds every artifact in memory while it is hashed, so it
+		// is only done when the proxy was started with --record-sbom.
+		interceptors.InterceptorContext{RecordFetched: recordFetched},
 		registries,
 	)
 	if err != nil {
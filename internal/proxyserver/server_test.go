@@ -21,7 +21,7 @@ func TestBuildInterceptorsWiresCustomRegistries(t *testing.T) {
 		{Name: "company-pypi", Ecosystem: "pypi", Endpoints: []config.ProxyRegistryEndpointConfig{{URL: "https://python.test/simple"}}},
 	}
 
-	got, err := buildInterceptors(nil, nil, nil, nil, registries)
+	got, err := buildInterceptors(nil, nil, nil, nil, registries, false)
 	require.NoError(t, err)
 	require.Len(t, got, len(interceptors.SupportedEcosystems())+1)
 
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+
+	"github.com/safedep/pmg/internal/models"
 )
 
 const stateFileName = "proxy-state.json"
@@ -23,6 +25,14 @@ type State struct {
 	// suppressed without --debug), so the state file is how the result reaches
 	// the stop process. nil when cloud sync is disabled.
 	CloudSync *CloudSyncResult `json:"cloud_sync,omitempty"`
+
+	// RecordSBOM is set when the daemon was started with --record-sbom and
+	// records the package versions it delivers.
+	RecordSBOM bool `json:"record_sbom,omitempty"`
+
+	// Fetched lists the package versions delivered during the daemon's
+	// lifetime, for `pmg proxy stop --sbom`. Written at shutdown.
+	Fetched []models.FetchedPackage `json:"fetched,omitempty"`
 }
 
 // CloudSyncResult is the outcome of the daemon's shutdown flush to SafeDep Cloud.
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/safedep/pmg/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -13,7 +14,10 @@ func TestWriteAndReadState(t *testing.T) {
 	dir := t.TempDir()
 	path := stateFilePath(dir)
 
-	s := State{PID: 12345, Addr: "127.0.0.1:9999", CACertPath: "/tmp/ca.pem"}
+	s := State{PID: 12345, Addr: "127.0.0.1:9999", CACertPath: "/tmp/ca.pem", Fetched: []models.FetchedPackage{{
+		Ecosystem: "ECOSYSTEM_NPM", Name: "left-pad", Version: "1.3.0", Registry: "registry.npmjs.org",
+		Artifacts: []models.FetchedArtifact{{URL: "https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz", SHA256: "aa"}},
+	}}}
 	require.NoError(t, writeState(path, s))
 
 	got, err := readState(path)
@@ -21,6 +25,7 @@ func TestWriteAndReadState(t *testing.T) {
 	assert.Equal(t, s.PID, got.PID)
 	assert.Equal(t, s.Addr, got.Addr)
 	assert.Equal(t, s.CACertPath, got.CACertPath)
+	assert.Equal(t, s.Fetched, got.Fetched)
 }
 
 func TestWriteStateCreatesMissingDir(t *testing.T) {
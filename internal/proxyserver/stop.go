@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/internal/models"
 )
 
 const (
@@ -30,6 +31,11 @@ type StopResult struct {
 	// CloudSync is the daemon's shutdown flush outcome (nil when cloud sync is
 	// disabled), surfaced so the caller can report it.
 	CloudSync *CloudSyncResult
+	// RecordSBOM is false when the proxy was not started with --record-sbom,
+	// so Fetched is empty whatever was installed.
+	RecordSBOM bool
+	// Fetched lists the package versions the proxy delivered, for an SBOM.
+	Fetched []models.FetchedPackage
 }
 
 // Stop signals the running proxy to terminate, waits for it to exit, and
@@ -86,5 +92,7 @@ func Stop(statePath string) (StopResult, error) {
 		BlockedCount:  final.BlockedCount,
 		StateVerified: readErr == nil,
 		CloudSync:     final.CloudSync,
+		RecordSBOM:    final.RecordSBOM,
+		Fetched:       final.Fetched,
 	}, nil
 }
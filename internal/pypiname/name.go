@@ -0,0 +1,16 @@
+// Package pypiname normalizes PyPI project names, so that names spelled with
+// different case or separators compare equal.
+package pypiname
+
+import (
+	"regexp"
+	"strings"
+)
+
+var separators = regexp.MustCompile(`[-_.]+`)
+
+// Normalize returns the PEP 503 normalized form of a PyPI project name:
+// lowercase, with every run of -, _ and . replaced by a single -.
+func Normalize(name string) string {
+	return separators.ReplaceAllString(strings.ToLower(name), "-")
+}
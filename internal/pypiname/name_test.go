@@ -0,0 +1,22 @@
+package pypiname
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"requests":          "requests",
+		"Django":            "django",
+		"zope.interface":    "zope-interface",
+		"typing_extensions": "typing-extensions",
+		"Foo.-_Bar":         "foo-bar",
+		"acme-":             "acme-",
+	}
+
+	for name, expected := range tests {
+		assert.Equal(t, expected, Normalize(name), name)
+	}
+}
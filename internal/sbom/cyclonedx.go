@@ -0,0 +1,112 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/safedep/pmg/internal/models"
+)
+
+// The subset of the CycloneDX 1.5 JSON schema PMG writes.
+type cdxBOM struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string   `json:"timestamp"`
+	Tools     cdxTools `json:"tools"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type               string        `json:"type"`
+	BOMRef             string        `json:"bom-ref,omitempty"`
+	Publisher          string        `json:"publisher,omitempty"`
+	Name               string        `json:"name"`
+	Version            string        `json:"version,omitempty"`
+	PURL               string        `json:"purl,omitempty"`
+	Hashes             []cdxHash     `json:"hashes,omitempty"`
+	ExternalReferences []cdxExtRef   `json:"externalReferences,omitempty"`
+	Properties         []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxExtRef struct {
+	Type   string    `json:"type"`
+	URL    string    `json:"url"`
+	Hashes []cdxHash `json:"hashes,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func writeCycloneDX(out io.Writer, packages []models.FetchedPackage, created time.Time) error {
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid.NewString(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: created.UTC().Format(time.RFC3339),
+			Tools: cdxTools{Components: []cdxComponent{{
+				Type:      "application",
+				Publisher: "SafeDep",
+				Name:      "pmg",
+				Version:   toolVersion(),
+			}}},
+		},
+		Components: make([]cdxComponent, 0, len(packages)),
+	}
+
+	for _, pkg := range packages {
+		component := cdxComponent{
+			Type:    "library",
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			PURL:    purl(pkg),
+		}
+		component.BOMRef = component.PURL
+		if component.BOMRef == "" {
+			component.BOMRef = pkg.Ecosystem + ":" + pkg.Name + "@" + pkg.Version
+		}
+
+		for _, artifact := range pkg.Artifacts {
+			reference := cdxExtRef{Type: "distribution", URL: artifact.URL}
+			if artifact.SHA256 != "" {
+				reference.Hashes = []cdxHash{{Alg: "SHA-256", Content: artifact.SHA256}}
+			}
+			component.ExternalReferences = append(component.ExternalReferences, reference)
+		}
+		// A component hash is only unambiguous for a single artifact; a
+		// wheel and an sdist of the same version differ.
+		if len(pkg.Artifacts) == 1 && pkg.Artifacts[0].SHA256 != "" {
+			component.Hashes = []cdxHash{{Alg: "SHA-256", Content: pkg.Artifacts[0].SHA256}}
+		}
+
+		component.Properties = append(component.Properties, cdxProperty{Name: "pmg:registry", Value: pkg.Registry})
+		if pkg.Verdict != "" {
+			component.Properties = append(component.Properties, cdxProperty{Name: "pmg:verdict", Value: pkg.Verdict})
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bom)
+}
@@ -0,0 +1,128 @@
+// Package sbom writes the package versions fetched through the PMG proxy as a
+// CycloneDX 1.5 or SPDX 2.3 JSON document. Because the proxy hashes the bytes
+// it delivered, the document describes what was installed rather than what a
+// lockfile claims.
+package sbom
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/internal/version"
+)
+
+// Format is an SBOM document format.
+type Format string
+
+const (
+	FormatCycloneDX Format = "cyclonedx"
+	FormatSPDX      Format = "spdx"
+)
+
+// ParseFormat parses the --sbom-format value. Empty selects CycloneDX.
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatCycloneDX:
+		return FormatCycloneDX, nil
+	case FormatSPDX:
+		return FormatSPDX, nil
+	default:
+		msg := fmt.Sprintf("unsupported SBOM format %q, expected cyclonedx or spdx", value)
+		return "", usefulerror.NewUsefulError().
+			WithCode(errcodes.InvalidArgument).
+			WithHumanError(msg).
+			WithMsg(msg).
+			WithHelp("Pass --sbom-format cyclonedx or --sbom-format spdx")
+	}
+}
+
+// Write writes packages to out as a document in format, stamped with created.
+func Write(out io.Writer, format Format, packages []models.FetchedPackage, created time.Time) error {
+	switch format {
+	case FormatCycloneDX:
+		return writeCycloneDX(out, packages, created)
+	case FormatSPDX:
+		return writeSPDX(out, packages, created)
+	default:
+		return fmt.Errorf("unsupported SBOM format %q", format)
+	}
+}
+
+// WriteFile writes packages to path as a document in format. The file is
+// replaced, not appended to.
+func WriteFile(path string, format Format, packages []models.FetchedPackage) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	if err := Write(file, format, packages, time.Now()); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// toolVersion is the PMG version recorded as the document's creator.
+func toolVersion() string {
+	if version.Version == "" {
+		return "dev"
+	}
+	return version.Version
+}
+
+// purlTypes maps ecosystems to package URL types.
+var purlTypes = map[packagev1.Ecosystem]string{
+	packagev1.Ecosystem_ECOSYSTEM_NPM:       "npm",
+	packagev1.Ecosystem_ECOSYSTEM_PYPI:      "pypi",
+	packagev1.Ecosystem_ECOSYSTEM_GO:        "golang",
+	packagev1.Ecosystem_ECOSYSTEM_CARGO:     "cargo",
+	packagev1.Ecosystem_ECOSYSTEM_MAVEN:     "maven",
+	packagev1.Ecosystem_ECOSYSTEM_NUGET:     "nuget",
+	packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS:  "gem",
+	packagev1.Ecosystem_ECOSYSTEM_PACKAGIST: "composer",
+}
+
+// purl returns the package URL of pkg, or "" for an ecosystem without a
+// package URL type.
+func purl(pkg models.FetchedPackage) string {
+	ecosystem := packagev1.Ecosystem(packagev1.Ecosystem_value[pkg.Ecosystem])
+	purlType, ok := purlTypes[ecosystem]
+	if !ok {
+		return ""
+	}
+
+	name := pkg.Name
+	switch ecosystem {
+	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	case packagev1.Ecosystem_ECOSYSTEM_MAVEN:
+		// groupId:artifactId
+		name = strings.Replace(name, ":", "/", 1)
+	case packagev1.Ecosystem_ECOSYSTEM_NUGET:
+		name = strings.ToLower(name)
+	}
+
+	segments := strings.Split(name, "/")
+	for index, segment := range segments {
+		segments[index] = escapePurlSegment(segment)
+	}
+	return "pkg:" + purlType + "/" + strings.Join(segments, "/") + "@" + escapePurlSegment(pkg.Version)
+}
+
+// escapePurlSegment percent-encodes a package URL segment. An npm scope is
+// written %40scope, as the spec requires.
+func escapePurlSegment(segment string) string {
+	return strings.ReplaceAll(url.PathEscape(segment), "@", "%40")
+}
@@ -0,0 +1,136 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/safedep/dry/usefulerror"
+	"github.com/safedep/pmg/errcodes"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fetchedPackages = []models.FetchedPackage{
+	{
+		Ecosystem: "ECOSYSTEM_NPM",
+		Name:      "@types/node",
+		Version:   "20.11.0",
+		Registry:  "registry.npmjs.org",
+		Verdict:   models.FetchVerdictClean,
+		Artifacts: []models.FetchedArtifact{{
+			URL:    "https://registry.npmjs.org/@types/node/-/node-20.11.0.tgz",
+			SHA256: "0f2c",
+		}},
+	},
+	{
+		Ecosystem: "ECOSYSTEM_PYPI",
+		Name:      "Typing_Extensions",
+		Version:   "4.9.0",
+		Registry:  "pypi.org",
+		Artifacts: []models.FetchedArtifact{
+			{URL: "https://files.pythonhosted.org/typing_extensions-4.9.0-py3-none-any.whl", SHA256: "aa11"},
+			{URL: "https://files.pythonhosted.org/typing_extensions-4.9.0.tar.gz"},
+		},
+	},
+}
+
+var created = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCycloneDX, format)
+
+	format, err = ParseFormat("SPDX")
+	require.NoError(t, err)
+	assert.Equal(t, FormatSPDX, format)
+
+	_, err = ParseFormat("swid")
+	usefulErr, ok := usefulerror.AsUsefulError(err)
+	require.True(t, ok)
+	assert.Equal(t, errcodes.InvalidArgument, usefulErr.Code())
+}
+
+func TestPurl(t *testing.T) {
+	tests := []struct {
+		ecosystem, name, version, want string
+	}{
+		{"ECOSYSTEM_NPM", "@types/node", "20.11.0", "pkg:npm/%40types/node@20.11.0"},
+		{"ECOSYSTEM_PYPI", "Typing_Extensions", "4.9.0", "pkg:pypi/typing-extensions@4.9.0"},
+		{"ECOSYSTEM_GO", "github.com/pkg/errors", "v0.9.1", "pkg:golang/github.com/pkg/errors@v0.9.1"},
+		{"ECOSYSTEM_MAVEN", "org.slf4j:slf4j-api", "2.0.9", "pkg:maven/org.slf4j/slf4j-api@2.0.9"},
+		{"ECOSYSTEM_NUGET", "Newtonsoft.Json", "13.0.3", "pkg:nuget/newtonsoft.json@13.0.3"},
+		{"ECOSYSTEM_PACKAGIST", "monolog/monolog", "3.5.0", "pkg:composer/monolog/monolog@3.5.0"},
+		{"ECOSYSTEM_UNSPECIFIED", "x", "1", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, purl(models.FetchedPackage{
+				Ecosystem: test.ecosystem, Name: test.name, Version: test.version,
+			}))
+		})
+	}
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, FormatCycloneDX, fetchedPackages, created))
+
+	var bom cdxBOM
+	require.NoError(t, json.Unmarshal(out.Bytes(), &bom))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "1.5", bom.SpecVersion)
+	assert.Regexp(t, `^urn:uuid:[0-9a-f-]{36}$`, bom.SerialNumber)
+	assert.Equal(t, "2026-03-01T12:00:00Z", bom.Metadata.Timestamp)
+	require.Len(t, bom.Components, 2)
+
+	npm := bom.Components[0]
+	assert.Equal(t, "pkg:npm/%40types/node@20.11.0", npm.PURL)
+	assert.Equal(t, npm.PURL, npm.BOMRef)
+	assert.Equal(t, []cdxHash{{Alg: "SHA-256", Content: "0f2c"}}, npm.Hashes)
+	assert.Contains(t, npm.Properties, cdxProperty{Name: "pmg:verdict", Value: "clean"})
+
+	pypi := bom.Components[1]
+	assert.Empty(t, pypi.Hashes)
+	require.Len(t, pypi.ExternalReferences, 2)
+	assert.Equal(t, "distribution", pypi.ExternalReferences[0].Type)
+	assert.Equal(t, []cdxHash{{Alg: "SHA-256", Content: "aa11"}}, pypi.ExternalReferences[0].Hashes)
+	assert.Empty(t, pypi.ExternalReferences[1].Hashes)
+	assert.Equal(t, []cdxProperty{{Name: "pmg:registry", Value: "pypi.org"}}, pypi.Properties)
+}
+
+func TestWriteSPDX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sbom.spdx.json")
+	require.NoError(t, WriteFile(path, FormatSPDX, fetchedPackages))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var document spdxDocument
+	require.NoError(t, json.Unmarshal(data, &document))
+	assert.Equal(t, "SPDX-2.3", document.SPDXVersion)
+	assert.Contains(t, document.DocumentNamespace, spdxNamespacePrefix)
+	require.Len(t, document.Packages, 2)
+	require.Len(t, document.Relationships, 2)
+
+	npm := document.Packages[0]
+	assert.Equal(t, "SPDXRef-Package-1", npm.SPDXID)
+	assert.Equal(t, fetchedPackages[0].Artifacts[0].URL, npm.DownloadLocation)
+	assert.Equal(t, []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: "0f2c"}}, npm.Checksums)
+	assert.Equal(t, "pkg:npm/%40types/node@20.11.0", npm.ExternalRefs[0].ReferenceLocator)
+	assert.Contains(t, npm.Comment, "verdict: clean")
+
+	pypi := document.Packages[1]
+	assert.Equal(t, "NOASSERTION", pypi.DownloadLocation)
+	assert.Empty(t, pypi.Checksums)
+	assert.Contains(t, pypi.Comment, "typing_extensions-4.9.0.tar.gz")
+	assert.Equal(t, spdxRelationship{
+		SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Package-2",
+	}, document.Relationships[1])
+}
@@ -0,0 +1,135 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/safedep/pmg/internal/models"
+)
+
+// spdxNamespacePrefix prefixes the unique documentNamespace of each document.
+const spdxNamespacePrefix = "https://safedep.io/spdx/pmg/"
+
+// The subset of the SPDX 2.3 JSON schema PMG writes.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string         `json:"name"`
+	SPDXID                string         `json:"SPDXID"`
+	VersionInfo           string         `json:"versionInfo"`
+	DownloadLocation      string         `json:"downloadLocation"`
+	FilesAnalyzed         bool           `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string         `json:"primaryPackagePurpose"`
+	Checksums             []spdxChecksum `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExtRef   `json:"externalRefs,omitempty"`
+	Comment               string         `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExtRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func writeSPDX(out io.Writer, packages []models.FetchedPackage, created time.Time) error {
+	document := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "pmg-fetched-packages",
+		DocumentNamespace: spdxNamespacePrefix + uuid.NewString(),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: pmg-" + toolVersion()},
+		},
+		Packages:      make([]spdxPackage, 0, len(packages)),
+		Relationships: make([]spdxRelationship, 0, len(packages)),
+	}
+
+	for index, pkg := range packages {
+		spdxPkg := spdxPackage{
+			Name:                  pkg.Name,
+			SPDXID:                fmt.Sprintf("SPDXRef-Package-%d", index+1),
+			VersionInfo:           pkg.Version,
+			DownloadLocation:      "NOASSERTION",
+			PrimaryPackagePurpose: "LIBRARY",
+			Comment:               spdxComment(pkg),
+		}
+
+		// SPDX has one download location and checksum set per package, so
+		// they are only filled in for a single artifact; the comment lists
+		// every artifact.
+		if len(pkg.Artifacts) == 1 {
+			spdxPkg.DownloadLocation = pkg.Artifacts[0].URL
+			if pkg.Artifacts[0].SHA256 != "" {
+				spdxPkg.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: pkg.Artifacts[0].SHA256}}
+			}
+		}
+		if locator := purl(pkg); locator != "" {
+			spdxPkg.ExternalRefs = []spdxExtRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  locator,
+			}}
+		}
+
+		document.Packages = append(document.Packages, spdxPkg)
+		document.Relationships = append(document.Relationships, spdxRelationship{
+			SPDXElementID:      document.SPDXID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: spdxPkg.SPDXID,
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// spdxComment records what SPDX has no field for: the registry, the verdict
+// and each artifact delivered with its digest.
+func spdxComment(pkg models.FetchedPackage) string {
+	var comment strings.Builder
+	fmt.Fprintf(&comment, "Fetched through PMG from %s", pkg.Registry)
+	if pkg.Verdict != "" {
+		fmt.Fprintf(&comment, ", verdict: %s", pkg.Verdict)
+	}
+	comment.WriteString(".")
+
+	for _, artifact := range pkg.Artifacts {
+		fmt.Fprintf(&comment, "\n%s", artifact.URL)
+		if artifact.SHA256 != "" {
+			fmt.Fprintf(&comment, " sha256:%s", artifact.SHA256)
+		}
+	}
+	return comment.String()
+}
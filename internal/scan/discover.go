@@ -0,0 +1,111 @@
+package scan
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/internal/lockfile"
+)
+
+// skippedDirs are not searched for lockfiles: they hold installed or vendored
+// dependencies, whose own lockfiles are not what the project installs from.
+var skippedDirs = map[string]bool{
+	"node_modules":   true,
+	"vendor":         true,
+	".git":           true,
+	".venv":          true,
+	"venv":           true,
+	"__pypackages__": true,
+	".tox":           true,
+}
+
+// Discover returns the lockfiles under root, sorted. When root is a file it
+// is returned as is, so a lockfile can be named directly.
+func Discover(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && (skippedDirs[entry.Name()] || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() && lockfile.IsLockfile(entry.Name()) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// LoadLockfiles reads the package versions pinned by the lockfiles at paths.
+// A lockfile that cannot be read or parsed is an error: a gate must not pass
+// on a file it did not check.
+func LoadLockfiles(paths []string) ([]Package, error) {
+	var packages []Package
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		lock, err := lockfile.Parse(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		packages = append(packages, FromLockfile(lock)...)
+	}
+	return packages, nil
+}
+
+// FromLockfile returns the package versions a lockfile pins, with the
+// lockfile as their source.
+func FromLockfile(lock *lockfile.Lockfile) []Package {
+	ecosystem := LockfileEcosystem(lock.Ecosystem)
+
+	var packages []Package
+	for _, pkg := range lock.Packages() {
+		packages = append(packages, Package{
+			Ecosystem: ecosystem,
+			Name:      pkg.Name,
+			Version:   pkg.Version,
+			Sources:   []string{lock.Path},
+		})
+	}
+	return packages
+}
+
+// LockfileEcosystem maps a lockfile.Lockfile ecosystem to the analyzer's.
+func LockfileEcosystem(ecosystem string) packagev1.Ecosystem {
+	switch ecosystem {
+	case lockfile.EcosystemNpm:
+		return packagev1.Ecosystem_ECOSYSTEM_NPM
+	case lockfile.EcosystemPyPI:
+		return packagev1.Ecosystem_ECOSYSTEM_PYPI
+	case lockfile.EcosystemGo:
+		return packagev1.Ecosystem_ECOSYSTEM_GO
+	}
+	return packagev1.Ecosystem_ECOSYSTEM_UNSPECIFIED
+}
@@ -0,0 +1,215 @@
+package scan
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/internal/pypiname"
+	gomodule "golang.org/x/mod/module"
+)
+
+// GoModuleCacheSource is the source of the versions found in the Go module
+// cache.
+const GoModuleCacheSource = "Go module cache"
+
+// InstalledPackages walks root for installed npm and Python packages: the
+// package.json of every package under a node_modules directory, including
+// nested and pnpm-style layouts, and the METADATA of every *.dist-info
+// directory under a site-packages or dist-packages directory. Symbolic links
+// are not followed, so a linked package is counted where it is stored. A
+// directory that cannot be read is skipped with a warning.
+func InstalledPackages(root string) ([]Package, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+
+	var packages []Package
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			log.Warnf("Skipping %s: %v", path, err)
+			return nil
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if strings.HasSuffix(entry.Name(), ".dist-info") && isSitePackages(filepath.Dir(path)) {
+				if pkg, ok := readDistInfo(path); ok {
+					packages = append(packages, pkg)
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.Name() == "package.json" && isNodeModulesPackage(filepath.Dir(path)) {
+			if pkg, ok := readPackageJSON(path); ok {
+				packages = append(packages, pkg)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// isNodeModulesPackage reports whether dir is a package installed in a
+// node_modules directory: node_modules/name or node_modules/@scope/name.
+func isNodeModulesPackage(dir string) bool {
+	parent := filepath.Dir(dir)
+	if filepath.Base(parent) == "node_modules" {
+		return !strings.HasPrefix(filepath.Base(dir), ".")
+	}
+	return strings.HasPrefix(filepath.Base(parent), "@") && filepath.Base(filepath.Dir(parent)) == "node_modules"
+}
+
+func isSitePackages(dir string) bool {
+	name := filepath.Base(dir)
+	return name == "site-packages" || name == "dist-packages"
+}
+
+func readPackageJSON(path string) (Package, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Warnf("Skipping %s: %v", path, err)
+		return Package{}, false
+	}
+
+	var manifest struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Name == "" || manifest.Version == "" {
+		return Package{}, false
+	}
+
+	return Package{
+		Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM,
+		Name:      manifest.Name,
+		Version:   manifest.Version,
+		Sources:   []string{filepath.Dir(path)},
+	}, true
+}
+
+// readDistInfo reads the Name and Version headers of an installed Python
+// distribution's METADATA file.
+func readDistInfo(dir string) (Package, bool) {
+	file, err := os.Open(filepath.Join(dir, "METADATA"))
+	if err != nil {
+		log.Warnf("Skipping %s: %v", dir, err)
+		return Package{}, false
+	}
+	defer func() { _ = file.Close() }()
+
+	var name, version string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// The headers end at the first blank line; the description follows.
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, "Name:"); ok {
+			name = strings.TrimSpace(value)
+		} else if value, ok := strings.CutPrefix(line, "Version:"); ok {
+			version = strings.TrimSpace(value)
+		}
+	}
+	if name == "" || version == "" {
+		return Package{}, false
+	}
+
+	return Package{
+		Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PYPI,
+		Name:      pypiname.Normalize(name),
+		Version:   version,
+		Sources:   []string{dir},
+	}, true
+}
+
+// GoModuleCacheDir returns the Go module cache go uses: GOMODCACHE, or
+// pkg/mod under the first GOPATH entry, or under ~/go.
+func GoModuleCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// GoModuleCachePackages lists the module versions whose source go has
+// downloaded into the module cache at dir, from the zips under
+// cache/download. Versions for which go only fetched go.mod are not
+// listed. A missing cache has no versions.
+func GoModuleCachePackages(dir string) ([]Package, error) {
+	downloads := filepath.Join(dir, "cache", "download")
+	if _, err := os.Stat(downloads); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var packages []Package
+	err := filepath.WalkDir(downloads, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == downloads {
+				return err
+			}
+			log.Warnf("Skipping %s: %v", path, err)
+			return nil
+		}
+
+		if entry.IsDir() {
+			if path == filepath.Join(downloads, "sumdb") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		escapedVersion, ok := strings.CutSuffix(entry.Name(), ".zip")
+		if !ok || filepath.Base(filepath.Dir(path)) != "@v" {
+			return nil
+		}
+
+		escapedPath, err := filepath.Rel(downloads, filepath.Dir(filepath.Dir(path)))
+		if err != nil {
+			return nil
+		}
+		module, err := gomodule.UnescapePath(filepath.ToSlash(escapedPath))
+		if err != nil {
+			return nil
+		}
+		version, err := gomodule.UnescapeVersion(escapedVersion)
+		if err != nil {
+			return nil
+		}
+
+		packages = append(packages, Package{
+			Ecosystem: packagev1.Ecosystem_ECOSYSTEM_GO,
+			Name:      module,
+			Version:   version,
+			Sources:   []string{GoModuleCacheSource},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
@@ -0,0 +1,76 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestInstalledPackages(t *testing.T) {
+	dir := t.TempDir()
+	nodeModules := filepath.Join(dir, "web", "node_modules")
+
+	writeTestFile(t, filepath.Join(dir, "web", "package.json"), `{"name": "web", "version": "1.0.0"}`)
+	writeTestFile(t, filepath.Join(nodeModules, "left-pad", "package.json"), `{"name": "left-pad", "version": "1.3.0"}`)
+	writeTestFile(t, filepath.Join(nodeModules, "left-pad", "lib", "package.json"), `{"name": "inner", "version": "0.0.1"}`)
+	writeTestFile(t, filepath.Join(nodeModules, "@scope", "b", "package.json"), `{"name": "@scope/b", "version": "2.0.0"}`)
+	writeTestFile(t, filepath.Join(nodeModules, "a", "node_modules", "c", "package.json"), `{"name": "c", "version": "3.0.0"}`)
+	writeTestFile(t, filepath.Join(nodeModules, ".pnpm", "d@4.0.0", "node_modules", "d", "package.json"), `{"name": "d", "version": "4.0.0"}`)
+
+	sitePackages := filepath.Join(dir, ".venv", "lib", "python3.12", "site-packages")
+	writeTestFile(t, filepath.Join(sitePackages, "Requests-2.31.0.dist-info", "METADATA"),
+		"Metadata-Version: 2.1\nName: Requests\nVersion: 2.31.0\n\nName: not-a-header\n")
+	writeTestFile(t, filepath.Join(sitePackages, "broken-1.0.dist-info", "RECORD"), "")
+
+	packages, err := InstalledPackages(dir)
+	require.NoError(t, err)
+
+	found := map[string]Package{}
+	for _, pkg := range packages {
+		found[pkg.Name+"@"+pkg.Version] = pkg
+	}
+	assert.Len(t, found, 5)
+	for _, key := range []string{"left-pad@1.3.0", "@scope/b@2.0.0", "c@3.0.0", "d@4.0.0", "requests@2.31.0"} {
+		assert.Contains(t, found, key)
+	}
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_PYPI, found["requests@2.31.0"].Ecosystem)
+	assert.Equal(t, []string{filepath.Join(nodeModules, "left-pad")}, found["left-pad@1.3.0"].Sources)
+
+	_, err = InstalledPackages(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestGoModuleCachePackages(t *testing.T) {
+	dir := t.TempDir()
+	downloads := filepath.Join(dir, "cache", "download")
+	writeTestFile(t, filepath.Join(downloads, "github.com", "!burnt!sushi", "toml", "@v", "v1.3.2.zip"), "")
+	writeTestFile(t, filepath.Join(downloads, "github.com", "!burnt!sushi", "toml", "@v", "v1.3.2.mod"), "")
+	writeTestFile(t, filepath.Join(downloads, "golang.org", "x", "text", "@v", "v0.3.0.mod"), "")
+	writeTestFile(t, filepath.Join(downloads, "sumdb", "sum.golang.org", "lookup", "x.zip"), "")
+
+	packages, err := GoModuleCachePackages(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []Package{{
+		Ecosystem: packagev1.Ecosystem_ECOSYSTEM_GO,
+		Name:      "github.com/BurntSushi/toml",
+		Version:   "v1.3.2",
+		Sources:   []string{GoModuleCacheSource},
+	}}, packages)
+
+	packages, err = GoModuleCachePackages(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, packages)
+
+	t.Setenv("GOMODCACHE", dir)
+	assert.Equal(t, dir, GoModuleCacheDir())
+}
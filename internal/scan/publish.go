@@ -0,0 +1,192 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	gomodule "golang.org/x/mod/module"
+	"golang.org/x/sync/singleflight"
+)
+
+// The public registries publish times are read from. Packages are looked up
+// where the proxy's cooldown would see them for a default install.
+var (
+	npmRegistryBaseURL = "https://registry.npmjs.org"
+	pypiJSONAPIBaseURL = "https://pypi.org/pypi"
+	goProxyBaseURL     = "https://proxy.golang.org"
+)
+
+// maxPublishHistorySize bounds a fetched npm packument or PyPI release.
+const maxPublishHistorySize = 32 << 20
+
+// registryPublishTimes looks up publish times on the public registries. An
+// npm packument lists every version's publish time, so it is fetched once
+// per package.
+type registryPublishTimes struct {
+	client   *http.Client
+	inflight singleflight.Group
+
+	mu       sync.Mutex
+	npmTimes map[string]map[string]time.Time
+}
+
+func newRegistryPublishTimes() *registryPublishTimes {
+	return &registryPublishTimes{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		npmTimes: map[string]map[string]time.Time{},
+	}
+}
+
+// PublishTime returns when a package version was published. It returns the
+// zero time for an ecosystem without publish times, or a version the
+// registry does not date.
+func (r *registryPublishTimes) PublishTime(ctx context.Context, ecosystem packagev1.Ecosystem, name, version string) (time.Time, error) {
+	switch ecosystem {
+	case packagev1.Ecosystem_ECOSYSTEM_NPM:
+		return r.npmPublishTime(ctx, name, version)
+	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
+		return r.pypiPublishTime(ctx, name, version)
+	case packagev1.Ecosystem_ECOSYSTEM_GO:
+		return r.goPublishTime(ctx, name, version)
+	}
+	return time.Time{}, nil
+}
+
+func (r *registryPublishTimes) npmPublishTime(ctx context.Context, name, version string) (time.Time, error) {
+	timesAny, err, _ := r.inflight.Do(name, func() (interface{}, error) {
+		r.mu.Lock()
+		times, ok := r.npmTimes[name]
+		r.mu.Unlock()
+		if ok {
+			return times, nil
+		}
+
+		endpoint := fmt.Sprintf("%s/%s", strings.TrimSuffix(npmRegistryBaseURL, "/"), url.PathEscape(name))
+		body, err := r.fetch(ctx, endpoint, "application/json")
+		if err != nil {
+			return nil, err
+		}
+		times, err = parseNpmPublishTimes(body)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		r.npmTimes[name] = times
+		r.mu.Unlock()
+		return times, nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return timesAny.(map[string]time.Time)[version], nil
+}
+
+func parseNpmPublishTimes(packument []byte) (map[string]time.Time, error) {
+	var doc struct {
+		Time map[string]string `json:"time"`
+	}
+	if err := json.Unmarshal(packument, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse packument: %w", err)
+	}
+
+	times := make(map[string]time.Time, len(doc.Time))
+	for version, value := range doc.Time {
+		if version == "created" || version == "modified" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			times[version] = t
+		}
+	}
+	return times, nil
+}
+
+// pypiPublishTime returns the earliest upload time of a release's files.
+func (r *registryPublishTimes) pypiPublishTime(ctx context.Context, name, version string) (time.Time, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/json", strings.TrimSuffix(pypiJSONAPIBaseURL, "/"),
+		url.PathEscape(name), url.PathEscape(version))
+	body, err := r.fetch(ctx, endpoint, "application/json")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var doc struct {
+		URLs []struct {
+			UploadTime time.Time `json:"upload_time_iso_8601"`
+		} `json:"urls"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse release %s: %w", version, err)
+	}
+
+	var first time.Time
+	for _, file := range doc.URLs {
+		if first.IsZero() || (!file.UploadTime.IsZero() && file.UploadTime.Before(first)) {
+			first = file.UploadTime
+		}
+	}
+	return first, nil
+}
+
+func (r *registryPublishTimes) goPublishTime(ctx context.Context, module, version string) (time.Time, error) {
+	escapedPath, err := gomodule.EscapePath(module)
+	if err != nil {
+		return time.Time{}, err
+	}
+	escapedVersion, err := gomodule.EscapeVersion(version)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/@v/%s.info", strings.TrimSuffix(goProxyBaseURL, "/"), escapedPath, escapedVersion)
+	body, err := r.fetch(ctx, endpoint, "")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var info struct {
+		Time time.Time `json:"Time"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse %s.info: %w", version, err)
+	}
+	return info.Time, nil
+}
+
+func (r *registryPublishTimes) fetch(ctx context.Context, endpoint, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPublishHistorySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxPublishHistorySize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", endpoint, maxPublishHistorySize)
+	}
+	return body, nil
+}
@@ -0,0 +1,257 @@
+package scan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/internal/lockfile"
+)
+
+// Change is a package version a lockfile diff brings in: a package the base
+// did not pin, or a new version of one it did.
+type Change struct {
+	Package
+
+	// Previous are the versions of the package the base pinned. It is empty
+	// for a package the diff adds.
+	Previous []string
+}
+
+// Review is the finding for a changed package version.
+type Review struct {
+	Finding
+
+	// Previous are the versions the change replaces, as in Change.
+	Previous []string
+}
+
+// Diff returns the package versions head pins and base does not, sorted by
+// name and version. base is nil for a lockfile the diff adds. Versions that
+// only disappear are not listed: removing a package installs nothing.
+func Diff(base, head *lockfile.Lockfile) []Change {
+	previous := map[string][]string{}
+	if base != nil {
+		for _, pkg := range base.Packages() {
+			previous[pkg.Name] = append(previous[pkg.Name], pkg.Version)
+		}
+	}
+
+	ecosystem := LockfileEcosystem(head.Ecosystem)
+	var changes []Change
+	for _, pkg := range head.Packages() {
+		if base != nil && base.Contains(pkg.Name, pkg.Version) {
+			continue
+		}
+		changes = append(changes, Change{
+			Package: Package{
+				Ecosystem: ecosystem,
+				Name:      pkg.Name,
+				Version:   pkg.Version,
+				Sources:   []string{head.Path},
+			},
+			Previous: previous[pkg.Name],
+		})
+	}
+	return changes
+}
+
+// Reviews pairs each finding with the versions its changes replace.
+func Reviews(changes []Change, findings []Finding) []Review {
+	previous := map[string][]string{}
+	for _, change := range changes {
+		key := packageKey(change.Package)
+		for _, version := range change.Previous {
+			if !slices.Contains(previous[key], version) {
+				previous[key] = append(previous[key], version)
+			}
+		}
+	}
+
+	reviews := make([]Review, 0, len(findings))
+	for _, finding := range findings {
+		versions := previous[packageKey(finding.Package)]
+		sort.Strings(versions)
+		reviews = append(reviews, Review{Finding: finding, Previous: versions})
+	}
+	return reviews
+}
+
+// indexRevision is the revision readRevision and changedLockfiles read the
+// git index, the staged content, at.
+const indexRevision = ":"
+
+// GitDiff returns the package versions that the lockfiles changed between
+// the git ref base and head bring in, and the lockfiles that changed, as
+// paths relative to the repository root. dir is any directory of the
+// repository. An empty head compares against the working tree, including
+// untracked lockfiles, or against the index when staged is set, as
+// `git diff --cached` does. Lockfiles are skipped in the directories Discover
+// skips.
+func GitDiff(dir, base, head string, staged bool) ([]Change, []string, error) {
+	if staged && head != "" {
+		return nil, nil, errors.New("a head ref and the staged changes cannot both be reviewed")
+	}
+
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, nil, err
+	}
+	root = strings.TrimSpace(root)
+
+	refs := []string{base}
+	if head != "" {
+		refs = append(refs, head)
+	}
+	for _, ref := range refs {
+		if _, err := git(root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return nil, nil, fmt.Errorf("unknown git revision %q", ref)
+		}
+	}
+
+	if staged {
+		head = indexRevision
+	}
+
+	paths, err := changedLockfiles(root, base, head)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	var reviewed []string
+	for _, path := range paths {
+		data, ok, err := readRevision(root, head, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			// The diff deletes the lockfile.
+			continue
+		}
+
+		headLock, err := lockfile.Parse(path, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		var baseLock *lockfile.Lockfile
+		if data, ok, err := readRevision(root, base, path); err != nil {
+			return nil, nil, err
+		} else if ok {
+			// Reviewing every version is the safe reading of a base that
+			// cannot be parsed, for example one written by an older
+			// package manager.
+			if baseLock, err = lockfile.Parse(path, data); err != nil {
+				log.Warnf("Reviewing every version in %s: failed to parse it at %s: %v", path, base, err)
+				baseLock = nil
+			}
+		}
+
+		changes = append(changes, Diff(baseLock, headLock)...)
+		reviewed = append(reviewed, path)
+	}
+	return changes, reviewed, nil
+}
+
+// changedLockfiles returns the lockfiles that differ between base and head,
+// sorted.
+func changedLockfiles(root, base, head string) ([]string, error) {
+	args := []string{"diff", "--name-only", "--no-renames", "-z"}
+	switch head {
+	case "":
+		args = append(args, base)
+	case indexRevision:
+		args = append(args, "--cached", base)
+	default:
+		args = append(args, base, head)
+	}
+	out, err := git(root, args...)
+	if err != nil {
+		return nil, err
+	}
+	names := strings.Split(out, "\x00")
+
+	if head == "" {
+		untracked, err := git(root, "ls-files", "--others", "--exclude-standard", "-z")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, strings.Split(untracked, "\x00")...)
+	}
+
+	var paths []string
+	for _, name := range names {
+		if name == "" || !lockfile.IsLockfile(filepath.Base(name)) || skippedPath(name) {
+			continue
+		}
+		if !slices.Contains(paths, name) {
+			paths = append(paths, name)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// skippedPath reports whether a repository path is in a directory Discover
+// does not search.
+func skippedPath(path string) bool {
+	dirs := strings.Split(path, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if skippedDirs[dir] || strings.HasPrefix(dir, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// readRevision reads path at the git ref rev, from the index when rev is
+// indexRevision, or from the working tree when rev is empty. ok is false
+// when the file does not exist there.
+func readRevision(root, rev, path string) (data []byte, ok bool, err error) {
+	if rev == "" {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return data, true, nil
+	}
+
+	object := rev + ":" + path
+	if rev == indexRevision {
+		object = ":" + path
+	}
+	if _, err := git(root, "cat-file", "-e", object); err != nil {
+		return nil, false, nil
+	}
+	out, err := git(root, "cat-file", "blob", object)
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(out), true, nil
+}
+
+// git runs a git command in dir and returns its standard output.
+func git(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,124 @@
+package scan
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/internal/lockfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	baseRequirements = "requests==2.31.0\nurllib3==2.0.7\nidna==3.4\n"
+	headRequirements = "requests==2.32.3\nurllib3==2.0.7\ncertifi==2024.2.2\n"
+)
+
+func TestDiff(t *testing.T) {
+	base, err := lockfile.Parse("requirements.txt", []byte(baseRequirements))
+	require.NoError(t, err)
+	head, err := lockfile.Parse("requirements.txt", []byte(headRequirements))
+	require.NoError(t, err)
+
+	changes := Diff(base, head)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "certifi", changes[0].Name)
+	assert.Empty(t, changes[0].Previous)
+	assert.Equal(t, "requests", changes[1].Name)
+	assert.Equal(t, "2.32.3", changes[1].Version)
+	assert.Equal(t, []string{"2.31.0"}, changes[1].Previous)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_PYPI, changes[1].Ecosystem)
+	assert.Equal(t, []string{"requirements.txt"}, changes[1].Sources)
+
+	assert.Len(t, Diff(nil, head), 3)
+}
+
+func TestReviews(t *testing.T) {
+	changes := []Change{
+		{Package: npmPackage("left-pad", "1.3.0"), Previous: []string{"1.2.0"}},
+		{Package: npmPackage("left-pad", "1.3.0"), Previous: []string{"1.1.0", "1.2.0"}},
+	}
+	findings := []Finding{{Package: npmPackage("left-pad", "1.3.0"), Verdict: VerdictClean}}
+
+	reviews := Reviews(changes, findings)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, []string{"1.1.0", "1.2.0"}, reviews[0].Previous)
+}
+
+func TestGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	run := func(args ...string) {
+		t.Helper()
+		_, err := git(dir, append([]string{"-c", "user.name=pmg", "-c", "user.email=pmg@example.com"}, args...)...)
+		require.NoError(t, err)
+	}
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	run("init", "--quiet")
+	write("api/requirements.txt", baseRequirements)
+	write("web/package-lock.json", `{"packages": {"node_modules/left-pad": {"version": "1.3.0"}}}`)
+	write("README.md", "")
+	run("add", "-A")
+	run("commit", "--quiet", "-m", "base")
+
+	write("api/requirements.txt", headRequirements)
+	require.NoError(t, os.Remove(filepath.Join(dir, "web/package-lock.json")))
+	write("tools/go.sum", "github.com/pkg/errors v0.9.1 h1:abc=\n")
+	write("node_modules/dep/package-lock.json", `{"packages": {"node_modules/x": {"version": "1.0.0"}}}`)
+	write("README.md", "changed")
+
+	changes, paths, err := GitDiff(filepath.Join(dir, "api"), "HEAD", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api/requirements.txt", "tools/go.sum"}, paths)
+	require.Len(t, changes, 3)
+	assert.Equal(t, "requests", changes[1].Name)
+	assert.Equal(t, []string{"2.31.0"}, changes[1].Previous)
+	assert.Equal(t, "github.com/pkg/errors", changes[2].Name)
+	assert.Empty(t, changes[2].Previous)
+
+	// Only what is staged is reviewed with staged.
+	run("add", "api/requirements.txt")
+	changes, paths, err = GitDiff(dir, "HEAD", "", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api/requirements.txt"}, paths)
+	assert.Len(t, changes, 2)
+
+	// The staged copy is read, not the working tree's.
+	write("api/requirements.txt", baseRequirements)
+	changes, _, err = GitDiff(dir, "HEAD", "", true)
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+	write("api/requirements.txt", headRequirements)
+
+	_, _, err = GitDiff(dir, "HEAD", "HEAD", true)
+	assert.Error(t, err)
+
+	run("add", "-A")
+	run("commit", "--quiet", "-m", "head")
+
+	changes, paths, err = GitDiff(dir, "HEAD~1", "HEAD", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api/requirements.txt", "tools/go.sum"}, paths)
+	assert.Len(t, changes, 3)
+
+	changes, _, err = GitDiff(dir, "HEAD", "HEAD", false)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, _, err = GitDiff(dir, "no-such-ref", "", false)
+	assert.ErrorContains(t, err, "no-such-ref")
+}
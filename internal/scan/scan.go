@@ -0,0 +1,319 @@
+// Package scan checks package versions that are already pinned or installed
+// against the policy PMG enforces at install time: trusted packages, malware
+// and vulnerability analysis, and the dependency cooldown. It backs the
+// commands that review dependencies without running a package manager.
+package scan
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultConcurrency is the number of package versions analyzed at once.
+const DefaultConcurrency = 8
+
+// analysisTimeout bounds the analysis of one package version.
+const analysisTimeout = 30 * time.Second
+
+// Package is a package version to check, with the files it was found in.
+type Package struct {
+	Ecosystem packagev1.Ecosystem
+	Name      string
+	Version   string
+
+	// Sources are the lockfiles or install locations listing the version.
+	Sources []string
+}
+
+// Verdict is the outcome of checking a package version.
+type Verdict string
+
+const (
+	// VerdictClean is a version that passed every check.
+	VerdictClean Verdict = "clean"
+
+	// VerdictTrusted is a version on trusted_packages; it is not analyzed.
+	VerdictTrusted Verdict = "trusted"
+
+	// VerdictMalicious is a version the analyzer blocks as malware.
+	VerdictMalicious Verdict = "malicious"
+
+	// VerdictSuspicious is a version the analyzer would ask to confirm.
+	// Nobody can confirm it without an install, so it is a violation.
+	VerdictSuspicious Verdict = "suspicious"
+
+	// VerdictVulnerable is a version the vulnerability policy blocks.
+	VerdictVulnerable Verdict = "vulnerable"
+
+	// VerdictCooldown is a version published within the cooldown window.
+	VerdictCooldown Verdict = "cooldown"
+
+	// VerdictUnverified is a version the analyzer gave no verdict for.
+	// analysis.on_failure decides whether it is a violation.
+	VerdictUnverified Verdict = "unverified"
+)
+
+// Finding is the result of checking one package version.
+type Finding struct {
+	Package
+
+	Verdict Verdict
+
+	// Violation is true when the install-time policy would not have let the
+	// version in.
+	Violation bool
+
+	// Summary explains the verdict: the analyzer summary, the blocking
+	// vulnerabilities, or why analysis failed.
+	Summary      string
+	ReferenceURL string
+
+	// PublishedAt is when the version was published, when the cooldown
+	// check looked it up. DaysLeft is the rest of the cooldown window.
+	PublishedAt time.Time
+	DaysLeft    int
+}
+
+// PublishTimeFunc returns when a package version was published.
+type PublishTimeFunc func(ctx context.Context, ecosystem packagev1.Ecosystem, name, version string) (time.Time, error)
+
+// ScannerConfig configures a Scanner.
+type ScannerConfig struct {
+	// Concurrency is the number of versions analyzed at once. Zero means
+	// DefaultConcurrency.
+	Concurrency int
+
+	// SkipCooldown leaves out the dependency cooldown check, for versions
+	// that are already installed rather than about to be.
+	SkipCooldown bool
+}
+
+// Scanner checks package versions with an analyzer.
+type Scanner struct {
+	analyzer    analyzer.PackageVersionAnalyzer
+	config      ScannerConfig
+	publishTime PublishTimeFunc
+}
+
+// NewScanner returns a scanner that looks up publish times on the public
+// registries for the cooldown.
+func NewScanner(a analyzer.PackageVersionAnalyzer, cfg ScannerConfig) *Scanner {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	return &Scanner{
+		analyzer:    a,
+		config:      cfg,
+		publishTime: newRegistryPublishTimes().PublishTime,
+	}
+}
+
+// Scan checks every package version and returns a finding for each, sorted
+// by ecosystem, name and version. Versions listed more than once are checked
+// once, with their sources merged. Scan stops early only when ctx is
+// cancelled.
+func (s *Scanner) Scan(ctx context.Context, packages []Package) ([]Finding, error) {
+	packages = Merge(packages)
+	findings := make([]Finding, len(packages))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(s.config.Concurrency)
+	for i, pkg := range packages {
+		group.Go(func() error {
+			if err := groupCtx.Err(); err != nil {
+				return err
+			}
+			findings[i] = s.check(groupCtx, pkg)
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+func (s *Scanner) check(ctx context.Context, pkg Package) Finding {
+	finding := Finding{Package: pkg, Verdict: VerdictClean}
+
+	if config.IsTrustedPackageRef(pkg.Ecosystem, pkg.Name, pkg.Version) {
+		finding.Verdict = VerdictTrusted
+		return finding
+	}
+
+	s.analyze(ctx, &finding)
+	if finding.Verdict == VerdictClean && !s.config.SkipCooldown {
+		s.checkCooldown(ctx, &finding)
+	}
+	return finding
+}
+
+// analyze applies the analyzer's verdict to finding the way the proxy does
+// at install time. A version missing from the analysis database is clean.
+func (s *Scanner) analyze(ctx context.Context, finding *Finding) {
+	analysisCtx, cancel := context.WithTimeout(ctx, analysisTimeout)
+	defer cancel()
+
+	result, err := s.analyzer.Analyze(analysisCtx, &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: finding.Ecosystem, Name: finding.Name},
+		Version: finding.Version,
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			return
+		}
+
+		log.Warnf("Failed to analyze %s/%s@%s: %v", finding.Ecosystem.String(), finding.Name, finding.Version, err)
+		finding.Verdict = VerdictUnverified
+		finding.Summary = err.Error()
+		finding.Violation = config.Get().Config.Analysis.OnFailurePolicy() != config.AnalysisOnFailureAllow
+		return
+	}
+	if result == nil {
+		return
+	}
+
+	finding.Summary = result.Summary
+	finding.ReferenceURL = result.ReferenceURL
+
+	switch result.Action {
+	case analyzer.ActionBlock:
+		finding.Verdict = VerdictMalicious
+		if len(result.Vulnerabilities) > 0 && !result.IsMalware {
+			finding.Verdict = VerdictVulnerable
+			finding.Summary = vulnerabilitySummary(result.Vulnerabilities)
+		}
+		finding.Violation = true
+	case analyzer.ActionConfirm:
+		finding.Verdict = VerdictSuspicious
+		finding.Violation = true
+	}
+}
+
+// checkCooldown flags a version published within dependency_cooldown.days.
+// Versions on dependency_cooldown.skip, and those of a private namespace,
+// whose names must not be sent to public registries, are not looked up. A
+// publish time that cannot be read fails open, as it does in the proxy.
+func (s *Scanner) checkCooldown(ctx context.Context, finding *Finding) {
+	cooldown := config.Get().Config.DependencyCooldown
+	if !cooldown.Enabled || cooldown.Days <= 0 || s.publishTime == nil {
+		return
+	}
+	if config.CooldownSkip(finding.Ecosystem, finding.Name).ExemptsVersion(finding.Version) {
+		return
+	}
+	if _, private := config.PrivateNamespaceFor(config.Get().Config.PrivateNamespaces,
+		policyEcosystem(finding.Ecosystem), finding.Name); private {
+		return
+	}
+
+	published, err := s.publishTime(ctx, finding.Ecosystem, finding.Name, finding.Version)
+	if err != nil {
+		log.Warnf("Cooldown: failed to read the publish time of %s/%s@%s: %v",
+			finding.Ecosystem.String(), finding.Name, finding.Version, err)
+		return
+	}
+	if published.IsZero() {
+		return
+	}
+
+	finding.PublishedAt = published
+	age := time.Since(published)
+	if age < 0 {
+		age = 0
+	}
+	daysAgo := int(age.Hours() / 24)
+	if daysAgo < cooldown.Days {
+		finding.Verdict = VerdictCooldown
+		finding.DaysLeft = cooldown.Days - daysAgo
+		finding.Violation = true
+	}
+}
+
+// Violations returns the number of findings that violate policy.
+func Violations(findings []Finding) int {
+	count := 0
+	for _, finding := range findings {
+		if finding.Violation {
+			count++
+		}
+	}
+	return count
+}
+
+func vulnerabilitySummary(vulnerabilities []analyzer.Vulnerability) string {
+	ids := make([]string, 0, len(vulnerabilities))
+	for _, v := range vulnerabilities {
+		id := v.ID
+		if v.Severity != "" {
+			id += " (" + strings.ToLower(v.Severity) + ")"
+		}
+		ids = append(ids, id)
+	}
+	return strings.Join(ids, ", ")
+}
+
+// policyEcosystem returns the ecosystem name private_namespaces uses.
+func policyEcosystem(ecosystem packagev1.Ecosystem) string {
+	switch ecosystem {
+	case packagev1.Ecosystem_ECOSYSTEM_NPM:
+		return config.PrivateNamespaceEcosystemNpm
+	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
+		return config.PrivateNamespaceEcosystemPyPI
+	case packagev1.Ecosystem_ECOSYSTEM_GO:
+		return config.PrivateNamespaceEcosystemGo
+	}
+	return ""
+}
+
+// Merge returns packages with duplicate versions combined and their sources
+// merged, sorted by ecosystem, name and version.
+func Merge(packages []Package) []Package {
+	byKey := make(map[string]*Package, len(packages))
+	for _, pkg := range packages {
+		key := packageKey(pkg)
+		existing, ok := byKey[key]
+		if !ok {
+			merged := pkg
+			merged.Sources = nil
+			existing = &merged
+			byKey[key] = existing
+		}
+		for _, source := range pkg.Sources {
+			if !slices.Contains(existing.Sources, source) {
+				existing.Sources = append(existing.Sources, source)
+			}
+		}
+	}
+
+	result := make([]Package, 0, len(byKey))
+	for _, pkg := range byKey {
+		sort.Strings(pkg.Sources)
+		result = append(result, *pkg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Ecosystem != result[j].Ecosystem {
+			return result[i].Ecosystem < result[j].Ecosystem
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
+func packageKey(pkg Package) string {
+	return pkg.Ecosystem.String() + ":" + pkg.Name + "@" + pkg.Version
+}
@@ -0,0 +1,237 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubAnalyzer returns a fixed result or error per package name.
+type stubAnalyzer struct {
+	results map[string]*analyzer.PackageVersionAnalysisResult
+	errs    map[string]error
+}
+
+func (a *stubAnalyzer) Name() string { return "stub" }
+
+func (a *stubAnalyzer) Analyze(_ context.Context, pv *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	name := pv.GetPackage().GetName()
+	if err, ok := a.errs[name]; ok {
+		return nil, err
+	}
+	if result, ok := a.results[name]; ok {
+		return result, nil
+	}
+	return &analyzer.PackageVersionAnalysisResult{PackageVersion: pv, Action: analyzer.ActionAllow}, nil
+}
+
+func setScanConfig(t *testing.T, mutate func(cfg *config.Config)) {
+	t.Helper()
+	orig := config.Get().Config
+	t.Cleanup(func() { config.Get().Config = orig })
+	mutate(&config.Get().Config)
+	require.NoError(t, config.PreprocessPackageRefs(&config.Get().Config))
+}
+
+func npmPackage(name, version string) Package {
+	return Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM, Name: name, Version: version, Sources: []string{"package-lock.json"}}
+}
+
+func TestScannerVerdicts(t *testing.T) {
+	setScanConfig(t, func(cfg *config.Config) {
+		cfg.TrustedPackages = []config.TrustedPackage{{Purl: "pkg:npm/trusted"}}
+		cfg.DependencyCooldown = config.DependencyCooldownConfig{
+			Enabled: true,
+			Days:    5,
+			Skip:    []config.TrustedPackage{{Purl: "pkg:npm/fast-track"}},
+		}
+		cfg.Analysis.OnFailure = config.AnalysisOnFailureBlock
+	})
+
+	stub := &stubAnalyzer{
+		results: map[string]*analyzer.PackageVersionAnalysisResult{
+			"evil":       {Action: analyzer.ActionBlock, IsMalware: true, Summary: "credential stealer"},
+			"odd":        {Action: analyzer.ActionConfirm, Summary: "obfuscated install script"},
+			"vulnerable": {Action: analyzer.ActionBlock, Vulnerabilities: []analyzer.Vulnerability{{ID: "GHSA-1", Severity: "HIGH"}}},
+		},
+		errs: map[string]error{
+			"unlisted": status.Error(codes.NotFound, "not found"),
+			"broken":   errors.New("analysis backend unavailable"),
+		},
+	}
+
+	scanner := NewScanner(stub, ScannerConfig{Concurrency: 2})
+	scanner.publishTime = func(_ context.Context, _ packagev1.Ecosystem, name, _ string) (time.Time, error) {
+		if name == "fresh" || name == "fast-track" {
+			return time.Now().Add(-48 * time.Hour), nil
+		}
+		return time.Now().Add(-30 * 24 * time.Hour), nil
+	}
+
+	findings, err := scanner.Scan(context.Background(), []Package{
+		npmPackage("trusted", "1.0.0"),
+		npmPackage("evil", "1.0.0"),
+		npmPackage("odd", "1.0.0"),
+		npmPackage("vulnerable", "1.0.0"),
+		npmPackage("unlisted", "1.0.0"),
+		npmPackage("broken", "1.0.0"),
+		npmPackage("fresh", "1.0.0"),
+		npmPackage("fast-track", "1.0.0"),
+		npmPackage("settled", "1.0.0"),
+	})
+	require.NoError(t, err)
+
+	verdicts := map[string]Finding{}
+	for _, finding := range findings {
+		verdicts[finding.Name] = finding
+	}
+
+	assert.Equal(t, VerdictTrusted, verdicts["trusted"].Verdict)
+	assert.Equal(t, VerdictMalicious, verdicts["evil"].Verdict)
+	assert.Equal(t, "credential stealer", verdicts["evil"].Summary)
+	assert.Equal(t, VerdictSuspicious, verdicts["odd"].Verdict)
+	assert.Equal(t, VerdictVulnerable, verdicts["vulnerable"].Verdict)
+	assert.Equal(t, "GHSA-1 (high)", verdicts["vulnerable"].Summary)
+	assert.Equal(t, VerdictClean, verdicts["unlisted"].Verdict)
+	assert.Equal(t, VerdictUnverified, verdicts["broken"].Verdict)
+	assert.True(t, verdicts["broken"].Violation)
+	assert.Equal(t, VerdictCooldown, verdicts["fresh"].Verdict)
+	assert.Equal(t, 3, verdicts["fresh"].DaysLeft)
+	assert.Equal(t, VerdictClean, verdicts["fast-track"].Verdict)
+	assert.Equal(t, VerdictClean, verdicts["settled"].Verdict)
+	assert.False(t, verdicts["settled"].PublishedAt.IsZero())
+
+	for _, name := range []string{"trusted", "unlisted", "fast-track", "settled"} {
+		assert.False(t, verdicts[name].Violation, name)
+	}
+	for _, name := range []string{"evil", "odd", "vulnerable", "fresh"} {
+		assert.True(t, verdicts[name].Violation, name)
+	}
+}
+
+func TestScannerAnalysisFailureAllowed(t *testing.T) {
+	setScanConfig(t, func(cfg *config.Config) {
+		cfg.Analysis.OnFailure = config.AnalysisOnFailureAllow
+		cfg.DependencyCooldown.Enabled = false
+	})
+
+	scanner := NewScanner(&stubAnalyzer{errs: map[string]error{"broken": errors.New("timeout")}}, ScannerConfig{})
+	findings, err := scanner.Scan(context.Background(), []Package{npmPackage("broken", "1.0.0")})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, VerdictUnverified, findings[0].Verdict)
+	assert.False(t, findings[0].Violation)
+}
+
+func TestMerge(t *testing.T) {
+	merged := Merge([]Package{
+		{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PYPI, Name: "requests", Version: "2.31.0", Sources: []string{"b/uv.lock"}},
+		npmPackage("left-pad", "1.3.0"),
+		{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PYPI, Name: "requests", Version: "2.31.0", Sources: []string{"a/requirements.txt"}},
+	})
+
+	require.Len(t, merged, 2)
+	assert.Equal(t, "left-pad", merged[0].Name)
+	assert.Equal(t, []string{"a/requirements.txt", "b/uv.lock"}, merged[1].Sources)
+}
+
+func TestDiscoverAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	write("package-lock.json", `{"packages": {"node_modules/left-pad": {"version": "1.3.0"}}}`)
+	write("services/api/requirements-dev.txt", "requests==2.31.0\n")
+	write("tools/go.sum", "github.com/pkg/errors v0.9.1 h1:abc=\n")
+	write("node_modules/dep/package-lock.json", `{}`)
+	write(".cache/yarn.lock", ``)
+	write("README.md", "")
+
+	paths, err := Discover(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "package-lock.json"),
+		filepath.Join(dir, "services/api/requirements-dev.txt"),
+		filepath.Join(dir, "tools/go.sum"),
+	}, paths)
+
+	packages, err := LoadLockfiles(paths)
+	require.NoError(t, err)
+	require.Len(t, packages, 3)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_NPM, packages[0].Ecosystem)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_PYPI, packages[1].Ecosystem)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_GO, packages[2].Ecosystem)
+	assert.Equal(t, []string{paths[2]}, packages[2].Sources)
+
+	single, err := Discover(paths[1])
+	require.NoError(t, err)
+	assert.Equal(t, []string{paths[1]}, single)
+
+	write("broken/poetry.lock", "not = [toml")
+	_, err = LoadLockfiles([]string{filepath.Join(dir, "broken/poetry.lock")})
+	assert.Error(t, err)
+}
+
+func TestRegistryPublishTimes(t *testing.T) {
+	npmRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/npm/@scope%2Fwidget":
+			npmRequests++
+			_, _ = w.Write([]byte(`{"time": {"created": "2020-01-01T00:00:00.000Z", "1.0.0": "2024-01-02T03:04:05.000Z"}}`))
+		case "/pypi/requests/2.31.0/json":
+			_, _ = w.Write([]byte(`{"urls": [
+				{"upload_time_iso_8601": "2023-05-22T15:12:44.175Z"},
+				{"upload_time_iso_8601": "2023-05-22T15:12:42.313Z"}
+			]}`))
+		case "/go/github.com/!burnt!sushi/toml/@v/v1.3.2.info":
+			_, _ = w.Write([]byte(`{"Version": "v1.3.2", "Time": "2023-06-08T06:08:58Z"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	origNpm, origPyPI, origGo := npmRegistryBaseURL, pypiJSONAPIBaseURL, goProxyBaseURL
+	t.Cleanup(func() { npmRegistryBaseURL, pypiJSONAPIBaseURL, goProxyBaseURL = origNpm, origPyPI, origGo })
+	npmRegistryBaseURL, pypiJSONAPIBaseURL, goProxyBaseURL = server.URL+"/npm", server.URL+"/pypi", server.URL+"/go"
+
+	r := newRegistryPublishTimes()
+	ctx := context.Background()
+
+	published, err := r.PublishTime(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "@scope/widget", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), published.UTC())
+
+	published, err = r.PublishTime(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "@scope/widget", "2.0.0")
+	require.NoError(t, err)
+	assert.True(t, published.IsZero())
+	assert.Equal(t, 1, npmRequests)
+
+	published, err = r.PublishTime(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, "requests", "2.31.0")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 5, 22, 15, 12, 42, 313000000, time.UTC), published.UTC())
+
+	published, err = r.PublishTime(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, "github.com/BurntSushi/toml", "v1.3.2")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 6, 8, 6, 8, 58, 0, time.UTC), published.UTC())
+
+	_, err = r.PublishTime(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, "missing", "1.0")
+	assert.Error(t, err)
+}
@@ -47,6 +47,35 @@ func (p ProxyPresenter) BlockMessage(reason proxy.BlockReason, blockCtx *proxy.B
 			message += "\n\nReference: " + blockCtx.MalwareReferenceURL
 		}
 
+	case proxy.BlockReasonHeuristic:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nReason: %s\nFlagged by: %s heuristic",
+			HeuristicBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
+			blockCtx.MalwareSummary, blockCtx.Heuristic)
+		message += "\n\nCheck the package before installing it, or trust it under trusted_packages."
+
+	case proxy.BlockReasonVulnerable:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nKnown vulnerabilities:",
+			VulnerableBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion)
+		for _, v := range blockCtx.Vulnerabilities {
+			message += fmt.Sprintf("\n  - %s (%s)", v.ID, v.Severity)
+			if v.Summary != "" {
+				message += ": " + v.Summary
+			}
+		}
+		if len(blockCtx.Vulnerabilities) > 0 && blockCtx.Vulnerabilities[0].ReferenceURL != "" {
+			message += "\n\nReference: " + blockCtx.Vulnerabilities[0].ReferenceURL
+		}
+		message += "\n\nInstall a fixed version, or waive an advisory under vulnerabilities.ignore."
+
+	case proxy.BlockReasonLicense:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\n%s",
+			LicenseBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
+			licenseBlockLine(blockCtx.License, blockCtx.LicenseVerdict))
+		if blockCtx.LicenseDeclared != "" && blockCtx.LicenseDeclared != blockCtx.License {
+			message += "\nDeclared: " + blockCtx.LicenseDeclared
+		}
+		message += "\n\nChoose a package under an accepted license, or trust it under trusted_packages."
+
 	case proxy.BlockReasonConfirmationFailed:
 		// Operational failure rather than a policy decision; the advisory
 		// message is intentionally not appended.
@@ -58,6 +87,54 @@ func (p ProxyPresenter) BlockMessage(reason proxy.BlockReason, blockCtx *proxy.B
 			ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
 			blockCtx.CooldownDaysAgo, blockCtx.CooldownDays, blockCtx.CooldownDaysLeft)
 
+	case proxy.BlockReasonPackageAge:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nFirst released %d day(s) ago; package_age requires %d day(s) (%d remaining).\n\nCheck the package name for typos, or trust it under trusted_packages.",
+			NewPackageBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
+			blockCtx.CooldownDaysAgo, blockCtx.CooldownDays, blockCtx.CooldownDaysLeft)
+
+	case proxy.BlockReasonContentRule:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nRule: %s/%s\nFile: %s",
+			ContentRuleBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
+			blockCtx.RulePack, blockCtx.RuleName, blockCtx.RuleFile)
+		if blockCtx.RuleDescription != "" {
+			message += "\nReason: " + blockCtx.RuleDescription
+		}
+
+	case proxy.BlockReasonLockfileMismatch:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nThe downloaded artifact does not match %s.\nExpected: %s\nActual:   %s",
+			LockfileBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
+			blockCtx.Lockfile, blockCtx.ExpectedHash, blockCtx.ActualHash)
+
+	case proxy.BlockReasonLockfileUnlisted:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nThe package is not listed in %s. Update the lockfile if the change is intended.",
+			LockfileBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion, blockCtx.Lockfile)
+
+	case proxy.BlockReasonRegistryIntegrity:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nThe artifact served by %s does not match the hash published in its registry metadata.\nExpected: %s\nActual:   %s",
+			RegistryIntegrityBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
+			blockCtx.Registry, blockCtx.ExpectedHash, blockCtx.ActualHash)
+
+	case proxy.BlockReasonNpmSignature:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nThe registry signature of this version from %s could not be verified: %s.",
+			NpmSignatureBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion,
+			blockCtx.Registry, blockCtx.SignatureError)
+
+	case proxy.BlockReasonProvenance:
+		message = fmt.Sprintf("%s: %s/%s@%s\n\nThis package requires a verified provenance attestation: %s.",
+			ProvenanceBlockedHeadline, ecosystem, blockCtx.PackageName, blockCtx.PackageVersion, blockCtx.ProvenanceError)
+
+	case proxy.BlockReasonDependencyConfusion:
+		ref := fmt.Sprintf("%s/%s", ecosystem, blockCtx.PackageName)
+		if blockCtx.PackageVersion != "" {
+			ref += "@" + blockCtx.PackageVersion
+		}
+		message = fmt.Sprintf("%s: %s\n\n%s is a private namespace that %s may not serve. Install it from the registry configured for the namespace.",
+			DependencyConfusionBlockedHeadline, ref, blockCtx.PrivateNamespace, blockCtx.Registry)
+
+	case proxy.BlockReasonAnalysisUnavailable:
+		message = fmt.Sprintf("Package blocked: malware analysis unavailable for %s/%s@%s\n\nPMG could not obtain a verdict for this package, and the analysis.on_failure policy does not allow installing unchecked packages.",
+			ecosystem, blockCtx.PackageName, blockCtx.PackageVersion)
+
 	default:
 		return ""
 	}
@@ -76,6 +76,18 @@ func TestProxyPresenterBlockMessage(t *testing.T) {
 			advisory: "Contact #security-help",
 			expected: "Malicious package blocked: npm/evil@1.0.0\n\nReason: Contains known malware\n\nContact #security-help",
 		},
+		{
+			name:   "typosquat names the suspected target",
+			reason: proxy.BlockReasonUserDeclined,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:       packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:     "lodahs",
+				PackageVersion:  "1.0.0",
+				MalwareSummary:  "lodahs is one character away from the popular package lodash. Did you mean lodash?",
+				SuspectedTarget: "lodash",
+			},
+			expected: "Installation blocked by user: npm/lodahs@1.0.0\n\nReason: lodahs is one character away from the popular package lodash. Did you mean lodash?",
+		},
 		{
 			name:     "confirmation failed carries no advisory",
 			reason:   proxy.BlockReasonConfirmationFailed,
@@ -97,6 +109,179 @@ func TestProxyPresenterBlockMessage(t *testing.T) {
 			advisory: "Request an exemption at go/pmg-exceptions",
 			expected: "Package blocked by dependency cooldown: go/example.com/fresh@v1.1.0\n\nPublished 2 day(s) ago; cooldown window is 7 day(s) (5 remaining).\n\nRequest an exemption at go/pmg-exceptions",
 		},
+		{
+			name:   "analysis unavailable",
+			reason: proxy.BlockReasonAnalysisUnavailable,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_PYPI,
+				PackageName:    "requests",
+				PackageVersion: "2.32.0",
+			},
+			advisory: "Contact #security-help",
+			expected: "Package blocked: malware analysis unavailable for pypi/requests@2.32.0\n\nPMG could not obtain a verdict for this package, and the analysis.on_failure policy does not allow installing unchecked packages.\n\nContact #security-help",
+		},
+		{
+			name:   "heuristic",
+			reason: proxy.BlockReasonHeuristic,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:    "left-pad",
+				PackageVersion: "1.3.1",
+				MalwareSummary: "1.3.1 adds postinstall, 1.3.0 had no install scripts",
+				Heuristic:      "install-script",
+			},
+			expected: "Suspicious package blocked: npm/left-pad@1.3.1\n\nReason: 1.3.1 adds postinstall, 1.3.0 had no install scripts\nFlagged by: install-script heuristic\n\nCheck the package before installing it, or trust it under trusted_packages.",
+		},
+		{
+			name:   "vulnerable",
+			reason: proxy.BlockReasonVulnerable,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:    "lodash",
+				PackageVersion: "4.17.10",
+				Vulnerabilities: []proxy.BlockedVulnerability{
+					{ID: "GHSA-jf85-cpcp-j695", Severity: "critical", Summary: "Prototype Pollution in lodash", ReferenceURL: "https://osv.dev/vulnerability/GHSA-jf85-cpcp-j695"},
+					{ID: "GHSA-4xc9-xhrj-v574", Severity: "high"},
+				},
+			},
+			advisory: "Contact #security-help",
+			expected: "Vulnerable package blocked: npm/lodash@4.17.10\n\nKnown vulnerabilities:\n  - GHSA-jf85-cpcp-j695 (critical): Prototype Pollution in lodash\n  - GHSA-4xc9-xhrj-v574 (high)\n\nReference: https://osv.dev/vulnerability/GHSA-jf85-cpcp-j695\n\nInstall a fixed version, or waive an advisory under vulnerabilities.ignore.\n\nContact #security-help",
+		},
+		{
+			name:   "license denied",
+			reason: proxy.BlockReasonLicense,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:       packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:     "left-pad",
+				PackageVersion:  "1.3.0",
+				License:         "AGPL-3.0-only",
+				LicenseDeclared: "AGPL-3.0-only OR SSPL-1.0",
+				LicenseVerdict:  "denied",
+			},
+			advisory: "Contact #security-help",
+			expected: "License policy blocked: npm/left-pad@1.3.0\n\nLicense: AGPL-3.0-only (denied by license_policy)\nDeclared: AGPL-3.0-only OR SSPL-1.0\n\nChoose a package under an accepted license, or trust it under trusted_packages.\n\nContact #security-help",
+		},
+		{
+			name:   "license undeclared",
+			reason: proxy.BlockReasonLicense,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_PYPI,
+				PackageName:    "internal-tool",
+				PackageVersion: "0.1.0",
+				LicenseVerdict: "undeclared",
+			},
+			expected: "License policy blocked: pypi/internal-tool@0.1.0\n\nLicense: no declared license\n\nChoose a package under an accepted license, or trust it under trusted_packages.",
+		},
+		{
+			name:   "new package",
+			reason: proxy.BlockReasonPackageAge,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:        packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:      "expresss",
+				PackageVersion:   "0.0.1",
+				CooldownDays:     14,
+				CooldownDaysAgo:  2,
+				CooldownDaysLeft: 12,
+			},
+			expected: "New package blocked: npm/expresss@0.0.1\n\nFirst released 2 day(s) ago; package_age requires 14 day(s) (12 remaining).\n\nCheck the package name for typos, or trust it under trusted_packages.",
+		},
+		{
+			name:   "content rule",
+			reason: proxy.BlockReasonContentRule,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:       packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:     "evil",
+				PackageVersion:  "1.0.0",
+				RulePack:        "campaign",
+				RuleName:        "token_stealer",
+				RuleDescription: "Posts npm tokens to a webhook",
+				RuleFile:        "lib/worker.js",
+			},
+			expected: "Content rule blocked: npm/evil@1.0.0\n\nRule: campaign/token_stealer\nFile: lib/worker.js\nReason: Posts npm tokens to a webhook",
+		},
+		{
+			name:   "lockfile mismatch",
+			reason: proxy.BlockReasonLockfileMismatch,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:    "left-pad",
+				PackageVersion: "1.3.0",
+				Lockfile:       "package-lock.json",
+				ExpectedHash:   "sha512-AAAA",
+				ActualHash:     "sha512-BBBB",
+			},
+			expected: "Lockfile integrity blocked: npm/left-pad@1.3.0\n\nThe downloaded artifact does not match package-lock.json.\nExpected: sha512-AAAA\nActual:   sha512-BBBB",
+		},
+		{
+			name:   "lockfile unlisted",
+			reason: proxy.BlockReasonLockfileUnlisted,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_PYPI,
+				PackageName:    "requests",
+				PackageVersion: "2.31.0",
+				Lockfile:       "poetry.lock",
+			},
+			expected: "Lockfile integrity blocked: pypi/requests@2.31.0\n\nThe package is not listed in poetry.lock. Update the lockfile if the change is intended.",
+		},
+		{
+			name:   "registry integrity",
+			reason: proxy.BlockReasonRegistryIntegrity,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:    "left-pad",
+				PackageVersion: "1.3.0",
+				Registry:       "npm.corp.example",
+				ExpectedHash:   "sha512-AAAA",
+				ActualHash:     "sha512-BBBB",
+			},
+			expected: "Registry integrity blocked: npm/left-pad@1.3.0\n\nThe artifact served by npm.corp.example does not match the hash published in its registry metadata.\nExpected: sha512-AAAA\nActual:   sha512-BBBB",
+		},
+		{
+			name:   "npm signature",
+			reason: proxy.BlockReasonNpmSignature,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:    "left-pad",
+				PackageVersion: "1.3.0",
+				Registry:       "registry.npmjs.org",
+				SignatureError: "invalid registry signature",
+			},
+			expected: "Registry signature blocked: npm/left-pad@1.3.0\n\nThe registry signature of this version from registry.npmjs.org could not be verified: invalid registry signature.",
+		},
+		{
+			name:   "provenance",
+			reason: proxy.BlockReasonProvenance,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:       packagev1.Ecosystem_ECOSYSTEM_PYPI,
+				PackageName:     "litellm",
+				PackageVersion:  "1.82.8",
+				ProvenanceError: "no provenance attestation",
+			},
+			expected: "Provenance blocked: pypi/litellm@1.82.8\n\nThis package requires a verified provenance attestation: no provenance attestation.",
+		},
+		{
+			name:   "dependency confusion",
+			reason: proxy.BlockReasonDependencyConfusion,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:        packagev1.Ecosystem_ECOSYSTEM_NPM,
+				PackageName:      "@acme/widgets",
+				PackageVersion:   "1.0.0",
+				Registry:         "registry.npmjs.org",
+				PrivateNamespace: "@acme",
+			},
+			expected: "Dependency confusion blocked: npm/@acme/widgets@1.0.0\n\n@acme is a private namespace that registry.npmjs.org may not serve. Install it from the registry configured for the namespace.",
+		},
+		{
+			name:   "dependency confusion metadata",
+			reason: proxy.BlockReasonDependencyConfusion,
+			blockCtx: &proxy.BlockContext{
+				Ecosystem:        packagev1.Ecosystem_ECOSYSTEM_PYPI,
+				PackageName:      "acme-widgets",
+				Registry:         "pypi.org",
+				PrivateNamespace: "acme-",
+			},
+			expected: "Dependency confusion blocked: pypi/acme-widgets\n\nacme- is a private namespace that pypi.org may not serve. Install it from the registry configured for the namespace.",
+		},
 		{
 			name:     "nil context",
 			reason:   proxy.BlockReasonMalware,
@@ -77,6 +77,43 @@ type ReportData struct {
 	BlockedPackages   []*analyzer.PackageVersionAnalysisResult
 	ConfirmedPackages []*analyzer.PackageVersionAnalysisResult
 
+	// Packages blocked by the vulnerability policy (proxy mode only).
+	// Included in BlockedCount, not in BlockedPackages.
+	VulnerableBlockedPackages []*analyzer.PackageVersionAnalysisResult
+
+	// Packages blocked by the license policy (proxy mode only). Included in
+	// BlockedCount, not in BlockedPackages.
+	LicenseBlockedPackages []models.LicenseBlock
+
+	// Packages blocked because their first release is too recent (proxy
+	// mode only). Included in BlockedCount.
+	PackageAgeBlockedPackages []models.PackageAgeBlock
+
+	// Packages blocked by a content scan rule (proxy mode only). Included in
+	// BlockedCount.
+	ContentRuleBlockedPackages []models.ContentRuleBlock
+
+	// Packages blocked because their artifact does not match the project's
+	// lockfile (proxy mode only). Included in BlockedCount.
+	LockfileBlockedPackages []models.LockfileBlock
+
+	// Packages blocked because their artifact does not match the hash the
+	// registry published for it (proxy mode only). Included in BlockedCount.
+	RegistryIntegrityBlockedPackages []models.RegistryIntegrityBlock
+
+	// npm packages blocked because their registry signature is missing or
+	// does not verify (proxy mode only). Included in BlockedCount.
+	NpmSignatureBlockedPackages []models.NpmSignatureBlock
+
+	// Packages blocked because they lack a valid provenance attestation
+	// (proxy mode only). Included in BlockedCount.
+	ProvenanceBlockedPackages []models.ProvenanceBlock
+
+	// Packages of a private namespace blocked because a registry that may
+	// not serve them was asked for them (proxy mode only). Included in
+	// BlockedCount.
+	DependencyConfusionBlockedPackages []models.DependencyConfusionBlock
+
 	// Packages blocked by the dependency cooldown policy (proxy mode only)
 	CooldownBlockedPackages []models.CooldownBlock
 
@@ -86,6 +123,19 @@ type ReportData struct {
 	// Rendered as a hint when the install fails.
 	CooldownWithheldPackages []models.CooldownWithheld
 
+	// Malicious versions stripped from registry metadata so the resolver fell
+	// back to a clean release (proxy mode only). Not blocks.
+	MalwareStrippedVersions []models.MalwareStripped
+
+	// Packages installed without a malware verdict because analysis was
+	// unavailable, as allowed by the analysis.on_failure policy (proxy mode
+	// only).
+	UnverifiedPackages []models.UnverifiedPackage
+
+	// Packages blocked because analysis was unavailable (proxy mode only).
+	// Included in BlockedCount.
+	AnalysisUnavailableBlockedPackages []models.UnverifiedPackage
+
 	// AdvisoryMessage is the optional org-configured message appended to block
 	// output regardless of which control blocked. Set from advisory_message.
 	AdvisoryMessage string
@@ -117,7 +167,8 @@ func (r *ReportData) Finalize() {
 
 // HasIssues returns true if any packages were blocked or required confirmation
 func (r *ReportData) HasIssues() bool {
-	return r.BlockedCount > 0 || r.ConfirmedCount > 0 || len(r.CooldownBlockedPackages) > 0
+	return r.BlockedCount > 0 || r.ConfirmedCount > 0 || len(r.CooldownBlockedPackages) > 0 ||
+		len(r.UnverifiedPackages) > 0
 }
 
 // WasSuccessful returns true if execution completed without blocks or errors
@@ -148,6 +199,46 @@ func Report(data *ReportData) {
 // non-zero exit code, which any failure would also produce.
 const MalwareBlockedHeadline = "Malicious package blocked"
 
+// HeuristicBlockedHeadline is the headline printed when a local heuristic,
+// such as the install-script or typosquat analyzer, blocks a package.
+const HeuristicBlockedHeadline = "Suspicious package blocked"
+
+// VulnerableBlockedHeadline is the headline printed when the vulnerability
+// policy blocks a package.
+const VulnerableBlockedHeadline = "Vulnerable package blocked"
+
+// LicenseBlockedHeadline is the headline printed when the license policy
+// blocks a package.
+const LicenseBlockedHeadline = "License policy blocked"
+
+// NewPackageBlockedHeadline is the headline printed when the package age
+// policy blocks a package.
+const NewPackageBlockedHeadline = "New package blocked"
+
+// ContentRuleBlockedHeadline is the headline printed when a content scan rule
+// blocks a package.
+const ContentRuleBlockedHeadline = "Content rule blocked"
+
+// LockfileBlockedHeadline is the headline printed when the lockfile
+// integrity check blocks a package.
+const LockfileBlockedHeadline = "Lockfile integrity blocked"
+
+// RegistryIntegrityBlockedHeadline is the headline printed when the registry
+// integrity check blocks a package.
+const RegistryIntegrityBlockedHeadline = "Registry integrity blocked"
+
+// NpmSignatureBlockedHeadline is the headline printed when the npm signature
+// policy blocks a package.
+const NpmSignatureBlockedHeadline = "Registry signature blocked"
+
+// ProvenanceBlockedHeadline is the headline printed when the provenance
+// policy blocks a package.
+const ProvenanceBlockedHeadline = "Provenance blocked"
+
+// DependencyConfusionBlockedHeadline is the headline printed when the
+// private namespace policy blocks a package.
+const DependencyConfusionBlockedHeadline = "Dependency confusion blocked"
+
 func printMalwareBlockSection(data *ReportData) {
 	if len(data.BlockedPackages) == 0 {
 		return
@@ -159,6 +250,233 @@ func printMalwareBlockSection(data *ReportData) {
 	fmt.Println()
 }
 
+// printVulnerableBlockSection lists packages blocked by the vulnerability
+// policy, apart from malware: a vulnerable version is usually fixed by an
+// upgrade, not by removing the dependency.
+func printVulnerableBlockSection(data *ReportData) {
+	if len(data.VulnerableBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.VulnerableBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("Known vulnerabilities — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.VulnerableBlockedPackages {
+		printVulnerablePackage(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+// printVulnerablePackage prints a blocked package and its advisories, most
+// severe first.
+func printVulnerablePackage(pkg *analyzer.PackageVersionAnalysisResult, indent string) {
+	if pkg == nil || pkg.PackageVersion == nil {
+		return
+	}
+
+	fmt.Printf("%s- %s@%s\n", indent, pkg.PackageVersion.GetPackage().GetName(), pkg.PackageVersion.GetVersion())
+	for _, v := range pkg.Vulnerabilities {
+		line := fmt.Sprintf("%s (%s)", v.ID, v.Severity)
+		if v.Summary != "" {
+			line += ": " + v.Summary
+		}
+		fmt.Printf("%s    %s\n", indent, Colors.Dim(line))
+	}
+}
+
+// printLicenseBlockSection lists packages blocked by the license policy.
+func printLicenseBlockSection(data *ReportData) {
+	if len(data.LicenseBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.LicenseBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("License policy — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.LicenseBlockedPackages {
+		printLicenseBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printLicenseBlock(pkg models.LicenseBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	fmt.Printf("%s    %s\n", indent, Colors.Dim(licenseBlockLine(pkg.License, pkg.Verdict)))
+}
+
+// licenseBlockLine explains why the license policy rejected a license.
+func licenseBlockLine(license, verdict string) string {
+	switch verdict {
+	case "denied":
+		return fmt.Sprintf("License: %s (denied by license_policy)", license)
+	case "not allowed":
+		return fmt.Sprintf("License: %s (not on the license_policy allow list)", license)
+	default:
+		return "License: no declared license"
+	}
+}
+
+// printPackageAgeBlockSection lists packages blocked because their first
+// release is too recent.
+func printPackageAgeBlockSection(data *ReportData) {
+	if len(data.PackageAgeBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.PackageAgeBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("New packages — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.PackageAgeBlockedPackages {
+		printPackageAgeBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printPackageAgeBlock(pkg models.PackageAgeBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	fmt.Printf("%s    %s\n", indent, Colors.Dim(fmt.Sprintf("First released %s ago (%s) — package_age requires %s",
+		pluralizeDays(pkg.DaysAgo), pkg.FirstRelease.Format("2006-01-02"), pluralizeDays(pkg.MinDays))))
+}
+
+// printContentRuleBlockSection lists packages blocked by a content scan rule.
+func printContentRuleBlockSection(data *ReportData) {
+	if len(data.ContentRuleBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.ContentRuleBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("Content rules — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.ContentRuleBlockedPackages {
+		printContentRuleBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printContentRuleBlock(pkg models.ContentRuleBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	fmt.Printf("%s    %s\n", indent, Colors.Dim(fmt.Sprintf("Rule %s/%s matched %s", pkg.Pack, pkg.Rule, pkg.File)))
+}
+
+// printLockfileBlockSection lists packages blocked because their artifact
+// does not match the project's lockfile.
+func printLockfileBlockSection(data *ReportData) {
+	if len(data.LockfileBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.LockfileBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("Lockfile integrity — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.LockfileBlockedPackages {
+		printLockfileBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printLockfileBlock(pkg models.LockfileBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	detail := fmt.Sprintf("Not listed in %s", pkg.Lockfile)
+	if pkg.Verdict == models.LockfileVerdictMismatch {
+		detail = fmt.Sprintf("Hash %s does not match %s in %s", pkg.Actual, pkg.Expected, pkg.Lockfile)
+	}
+	fmt.Printf("%s    %s\n", indent, Colors.Dim(detail))
+}
+
+// printRegistryIntegrityBlockSection lists packages blocked because their
+// artifact does not match the registry's metadata.
+func printRegistryIntegrityBlockSection(data *ReportData) {
+	if len(data.RegistryIntegrityBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.RegistryIntegrityBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("Registry integrity — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.RegistryIntegrityBlockedPackages {
+		printRegistryIntegrityBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printRegistryIntegrityBlock(pkg models.RegistryIntegrityBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	fmt.Printf("%s    %s\n", indent,
+		Colors.Dim(fmt.Sprintf("Hash %s does not match %s published by %s", pkg.Actual, pkg.Expected, pkg.Registry)))
+}
+
+// printNpmSignatureBlockSection lists npm packages blocked because their
+// registry signature is missing or does not verify.
+func printNpmSignatureBlockSection(data *ReportData) {
+	if len(data.NpmSignatureBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.NpmSignatureBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("Registry signature — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.NpmSignatureBlockedPackages {
+		printNpmSignatureBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printNpmSignatureBlock(pkg models.NpmSignatureBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	fmt.Printf("%s    %s\n", indent, Colors.Dim(fmt.Sprintf("%s from %s", pkg.Reason, pkg.Registry)))
+}
+
+// printProvenanceBlockSection lists packages blocked because they lack a
+// valid provenance attestation.
+func printProvenanceBlockSection(data *ReportData) {
+	if len(data.ProvenanceBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.ProvenanceBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("Provenance — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.ProvenanceBlockedPackages {
+		printProvenanceBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printProvenanceBlock(pkg models.ProvenanceBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	fmt.Printf("%s    %s\n", indent, Colors.Dim(pkg.Reason))
+}
+
+// printDependencyConfusionBlockSection lists packages of a private namespace
+// blocked because a registry that may not serve them was asked for them.
+func printDependencyConfusionBlockSection(data *ReportData) {
+	if len(data.DependencyConfusionBlockedPackages) == 0 {
+		return
+	}
+
+	fmt.Println()
+	n := len(data.DependencyConfusionBlockedPackages)
+	fmt.Printf("%s %s\n", Colors.Red("✗"),
+		Colors.Red(fmt.Sprintf("Dependency confusion — %s blocked", pluralizePackages(n))))
+	for _, pkg := range data.DependencyConfusionBlockedPackages {
+		printDependencyConfusionBlock(pkg, "  ")
+	}
+	fmt.Println()
+}
+
+func printDependencyConfusionBlock(pkg models.DependencyConfusionBlock, indent string) {
+	fmt.Printf("%s- %s@%s\n", indent, pkg.Name, pkg.Version)
+	fmt.Printf("%s    %s\n", indent, Colors.Dim(fmt.Sprintf("private namespace %s requested from %s", pkg.Namespace, pkg.Registry)))
+}
+
 // reportSilent shows output only when the install was blocked: silent mode
 // hides PMG except for errors and malicious package detection. Cooldown-only
 // blocks stay hidden, matching the documented silent contract.
@@ -182,6 +500,7 @@ func reportNormal(data *ReportData) {
 		// Withheld versions are the one PMG-side fact that can explain a
 		// resolution failure, so surface them here.
 		printCooldownWithheldHint(data.CooldownWithheldPackages)
+		printMalwareStrippedSummary(data.MalwareStrippedVersions)
 		return
 	}
 
@@ -200,7 +519,7 @@ func reportNormal(data *ReportData) {
 		return
 	}
 
-	if data.TotalAnalyzed == 0 {
+	if data.TotalAnalyzed == 0 && len(data.UnverifiedPackages) == 0 {
 		// No packages analyzed (e.g., npm install with no new packages)
 		return
 	}
@@ -212,6 +531,24 @@ func reportNormal(data *ReportData) {
 	case OutcomeBlocked:
 		printMalwareBlockSection(data)
 
+		printVulnerableBlockSection(data)
+
+		printLicenseBlockSection(data)
+
+		printPackageAgeBlockSection(data)
+
+		printContentRuleBlockSection(data)
+
+		printLockfileBlockSection(data)
+
+		printRegistryIntegrityBlockSection(data)
+
+		printNpmSignatureBlockSection(data)
+
+		printProvenanceBlockSection(data)
+
+		printDependencyConfusionBlockSection(data)
+
 		if len(data.CooldownBlockedPackages) > 0 {
 			fmt.Println()
 			n := len(data.CooldownBlockedPackages)
@@ -222,12 +559,27 @@ func reportNormal(data *ReportData) {
 			fmt.Println()
 		}
 
+		if len(data.AnalysisUnavailableBlockedPackages) > 0 {
+			fmt.Println()
+			n := len(data.AnalysisUnavailableBlockedPackages)
+			fmt.Printf("%s %s\n",
+				Colors.Red("✗"),
+				Colors.Red(fmt.Sprintf("Malware analysis unavailable — %s blocked", pluralizePackages(n))))
+			printUnverifiedPackagesList(data.AnalysisUnavailableBlockedPackages)
+			fmt.Println()
+		}
+
 		if data.AdvisoryMessage != "" {
 			printAdvisoryMessage(data.AdvisoryMessage)
 			fmt.Println()
 		}
 
-		onlyCooldown := len(data.BlockedPackages) == 0 && len(data.CooldownBlockedPackages) > 0
+		onlyCooldown := len(data.BlockedPackages) == 0 && len(data.VulnerableBlockedPackages) == 0 &&
+			len(data.LicenseBlockedPackages) == 0 && len(data.PackageAgeBlockedPackages) == 0 &&
+			len(data.ContentRuleBlockedPackages) == 0 && len(data.LockfileBlockedPackages) == 0 &&
+			len(data.RegistryIntegrityBlockedPackages) == 0 && len(data.NpmSignatureBlockedPackages) == 0 &&
+			len(data.ProvenanceBlockedPackages) == 0 && len(data.DependencyConfusionBlockedPackages) == 0 &&
+			len(data.CooldownBlockedPackages) > 0
 		if onlyCooldown {
 			icon = Colors.Yellow("⊘")
 			message = fmt.Sprintf("PMG: %s analyzed, %s blocked by cooldown",
@@ -243,7 +595,11 @@ func reportNormal(data *ReportData) {
 			data.TotalAnalyzed)
 	default:
 		// Success case
-		if data.HasIssues() {
+		if len(data.UnverifiedPackages) > 0 && data.ConfirmedCount == 0 {
+			// The unverified line below carries the warning.
+			icon = Colors.Yellow("!")
+			message = fmt.Sprintf("PMG: %d packages analyzed", data.TotalAnalyzed)
+		} else if data.HasIssues() {
 			icon = Colors.Yellow("!")
 			message = fmt.Sprintf("PMG: %d packages analyzed (%d confirmed)",
 				data.TotalAnalyzed, data.ConfirmedCount)
@@ -254,6 +610,44 @@ func reportNormal(data *ReportData) {
 	}
 
 	fmt.Printf("%s %s\n", icon, Colors.Dim(message))
+	printUnverifiedSummary(data.UnverifiedPackages)
+	printMalwareStrippedSummary(data.MalwareStrippedVersions)
+}
+
+// printUnverifiedSummary renders the report line counting packages installed
+// without a malware verdict. It is a security-relevant degradation, so it is
+// shown whenever it happened, not only in verbose mode.
+func printUnverifiedSummary(packages []models.UnverifiedPackage) {
+	if len(packages) == 0 {
+		return
+	}
+
+	fmt.Printf("%s %s\n", Colors.Yellow("⚠"),
+		Colors.Yellow(fmt.Sprintf("Malware analysis unavailable — %s installed without a verdict", pluralizePackages(len(packages)))))
+}
+
+// malwareStrippedSummaryMaxVersions bounds how many stripped versions the
+// normal report lists before "and N more".
+const malwareStrippedSummaryMaxVersions = 5
+
+// printMalwareStrippedSummary renders the report lines listing malicious
+// versions withheld from registry metadata. The install avoided them, but a
+// dependency resolving to an older release than expected needs explaining.
+func printMalwareStrippedSummary(versions []models.MalwareStripped) {
+	if len(versions) == 0 {
+		return
+	}
+
+	fmt.Printf("%s %s\n", Colors.Yellow("⊘"),
+		Colors.Yellow(fmt.Sprintf("Malicious versions withheld — %s skipped during resolution", pluralizeVersions(len(versions)))))
+
+	shown := versions[:min(len(versions), malwareStrippedSummaryMaxVersions)]
+	for _, v := range shown {
+		fmt.Printf("    %s\n", Colors.Dim(fmt.Sprintf("%s@%s", v.Name, v.Version)))
+	}
+	if hidden := len(versions) - len(shown); hidden > 0 {
+		fmt.Printf("    %s\n", Colors.Dim(fmt.Sprintf("and %d more...", hidden)))
+	}
 }
 
 // reportVerbose shows detailed debugging information
@@ -285,6 +679,12 @@ func reportVerbose(data *ReportData) {
 		data.ConfirmedCount,
 		data.BlockedCount)
 
+	if len(data.UnverifiedPackages) > 0 {
+		fmt.Printf("  %s %s\n",
+			Colors.Bold("Unverified:"),
+			Colors.Yellow(fmt.Sprintf("%s installed without a verdict (analysis unavailable)", pluralizePackages(len(data.UnverifiedPackages)))))
+	}
+
 	// Configuration section
 	fmt.Println()
 	fmt.Printf("  %s %s | %s flow | paranoid: %s\n",
@@ -312,6 +712,78 @@ func reportVerbose(data *ReportData) {
 		}
 	}
 
+	if len(data.VulnerableBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked for known vulnerabilities:"))
+		for _, pkg := range data.VulnerableBlockedPackages {
+			printVulnerablePackage(pkg, "    ")
+		}
+	}
+
+	if len(data.LicenseBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked by license policy:"))
+		for _, pkg := range data.LicenseBlockedPackages {
+			printLicenseBlock(pkg, "    ")
+		}
+	}
+
+	if len(data.PackageAgeBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked as new packages:"))
+		for _, pkg := range data.PackageAgeBlockedPackages {
+			printPackageAgeBlock(pkg, "    ")
+		}
+	}
+
+	if len(data.ContentRuleBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked by content rules:"))
+		for _, pkg := range data.ContentRuleBlockedPackages {
+			printContentRuleBlock(pkg, "    ")
+		}
+	}
+
+	if len(data.LockfileBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked by lockfile integrity:"))
+		for _, pkg := range data.LockfileBlockedPackages {
+			printLockfileBlock(pkg, "    ")
+		}
+	}
+
+	if len(data.RegistryIntegrityBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked by registry integrity:"))
+		for _, pkg := range data.RegistryIntegrityBlockedPackages {
+			printRegistryIntegrityBlock(pkg, "    ")
+		}
+	}
+
+	if len(data.NpmSignatureBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked by registry signature:"))
+		for _, pkg := range data.NpmSignatureBlockedPackages {
+			printNpmSignatureBlock(pkg, "    ")
+		}
+	}
+
+	if len(data.ProvenanceBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked by provenance policy:"))
+		for _, pkg := range data.ProvenanceBlockedPackages {
+			printProvenanceBlock(pkg, "    ")
+		}
+	}
+
+	if len(data.DependencyConfusionBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked by private namespace policy:"))
+		for _, pkg := range data.DependencyConfusionBlockedPackages {
+			printDependencyConfusionBlock(pkg, "    ")
+		}
+	}
+
 	if len(data.ConfirmedPackages) > 0 {
 		fmt.Println()
 		fmt.Println(Colors.Yellow("  User-confirmed packages:"))
@@ -340,6 +812,22 @@ func reportVerbose(data *ReportData) {
 		}
 	}
 
+	if len(data.AnalysisUnavailableBlockedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Red("  Blocked because malware analysis was unavailable:"))
+		for _, pkg := range data.AnalysisUnavailableBlockedPackages {
+			fmt.Printf("    - %s@%s\n", pkg.Name, pkg.Version)
+		}
+	}
+
+	if len(data.UnverifiedPackages) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Yellow("  Installed without a verdict (analysis unavailable):"))
+		for _, pkg := range data.UnverifiedPackages {
+			fmt.Printf("    - %s@%s\n", pkg.Name, pkg.Version)
+		}
+	}
+
 	if len(data.CooldownWithheldPackages) > 0 {
 		fmt.Println()
 		fmt.Println(Colors.Yellow("  Versions withheld by dependency cooldown:"))
@@ -349,6 +837,17 @@ func reportVerbose(data *ReportData) {
 		}
 	}
 
+	if len(data.MalwareStrippedVersions) > 0 {
+		fmt.Println()
+		fmt.Println(Colors.Yellow("  Malicious versions withheld from registry metadata:"))
+		for _, v := range data.MalwareStrippedVersions {
+			fmt.Printf("    %s %s\n", Colors.Yellow("⊘"), Colors.Yellow(fmt.Sprintf("%s@%s", v.Name, v.Version)))
+			if v.Summary != "" {
+				fmt.Printf("      %s\n", Colors.Dim(termWidthFormatTextIndent(v.Summary, 76, "      ")))
+			}
+		}
+	}
+
 	if data.Outcome == OutcomeBlocked && data.AdvisoryMessage != "" {
 		fmt.Println()
 		printAdvisoryMessage(data.AdvisoryMessage)
@@ -451,9 +950,39 @@ func printOutcomeLine(data *ReportData) {
 	case OutcomeBlocked:
 		hasMalware := len(data.BlockedPackages) > 0
 		hasCooldown := len(data.CooldownBlockedPackages) > 0
+		hasUnavailable := len(data.AnalysisUnavailableBlockedPackages) > 0
+		hasVulnerable := len(data.VulnerableBlockedPackages) > 0
+		hasLicense := len(data.LicenseBlockedPackages) > 0
+		hasNewPackage := len(data.PackageAgeBlockedPackages) > 0
+		hasContentRule := len(data.ContentRuleBlockedPackages) > 0
+		hasLockfile := len(data.LockfileBlockedPackages) > 0
+		hasRegistryIntegrity := len(data.RegistryIntegrityBlockedPackages) > 0
+		hasSignature := len(data.NpmSignatureBlockedPackages) > 0
+		hasProvenance := len(data.ProvenanceBlockedPackages) > 0
+		hasConfusion := len(data.DependencyConfusionBlockedPackages) > 0
 		switch {
 		case hasMalware && hasCooldown:
 			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — malicious package detected + cooldown policy"))
+		case hasVulnerable && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — known vulnerabilities"))
+		case hasLicense && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — license policy"))
+		case hasNewPackage && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — new package policy"))
+		case hasContentRule && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — content rule matched"))
+		case hasLockfile && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — artifact does not match lockfile"))
+		case hasRegistryIntegrity && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — artifact does not match registry metadata"))
+		case hasSignature && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — registry signature not verified"))
+		case hasProvenance && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — provenance not verified"))
+		case hasConfusion && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — private package requested from another registry"))
+		case hasUnavailable && !hasMalware && !hasCooldown:
+			fmt.Printf("  %s %s\n", Colors.Red("✗"), Colors.Red("Installation blocked — malware analysis unavailable"))
 		case hasCooldown:
 			fmt.Printf("  %s %s\n", Colors.Yellow("⊘"), Colors.Yellow("Installation blocked — dependency cooldown policy"))
 		default:
@@ -479,6 +1008,10 @@ func printPackageDetail(pkg *analyzer.PackageVersionAnalysisResult) {
 	version := pkg.PackageVersion.GetVersion()
 	fmt.Printf("    - %s@%s\n", name, version)
 
+	if pkg.DecidedBy != "" {
+		fmt.Printf("      %s\n", Colors.Dim("Flagged by: "+pkg.DecidedBy))
+	}
+
 	if pkg.ReferenceURL != "" {
 		fmt.Printf("      %s\n", Colors.Dim(pkg.ReferenceURL))
 	}
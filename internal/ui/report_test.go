@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 	"github.com/safedep/pmg/analyzer"
@@ -128,6 +129,280 @@ func TestReportVerboseAdvisoryMessage(t *testing.T) {
 	assert.Contains(t, out, "ℹ Contact #security-help")
 }
 
+func TestReportNormalUnverifiedSummary(t *testing.T) {
+	withVerbosity(t, VerbosityLevelNormal)
+
+	data := NewReportData()
+	data.TotalAnalyzed = 2
+	data.UnverifiedPackages = []models.UnverifiedPackage{
+		{Name: "left-pad", Version: "1.3.0"},
+		{Name: "lodash", Version: "4.17.21"},
+	}
+
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "PMG: 2 packages analyzed")
+	assert.Contains(t, out, "Malware analysis unavailable — 2 packages installed without a verdict")
+	assert.NotContains(t, out, "confirmed")
+}
+
+func TestReportNormalMalwareStrippedSummary(t *testing.T) {
+	withVerbosity(t, VerbosityLevelNormal)
+
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.AllowedCount = 1
+	for _, v := range []string{"1.0.1", "1.0.2", "1.0.3", "1.0.4", "1.0.5", "1.0.6"} {
+		data.MalwareStrippedVersions = append(data.MalwareStrippedVersions,
+			models.MalwareStripped{Ecosystem: "ECOSYSTEM_NPM", Name: "nx", Version: v, Summary: "credential stealer"})
+	}
+
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "PMG: 1 packages analyzed")
+	assert.Contains(t, out, "Malicious versions withheld — 6 versions skipped during resolution")
+	assert.Contains(t, out, "nx@1.0.5")
+	assert.NotContains(t, out, "nx@1.0.6")
+	assert.Contains(t, out, "and 1 more...")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Malicious versions withheld from registry metadata:")
+	assert.Contains(t, out, "nx@1.0.6")
+	assert.Contains(t, out, "credential stealer")
+}
+
+func TestReportNormalAnalysisUnavailableBlocked(t *testing.T) {
+	withVerbosity(t, VerbosityLevelNormal)
+
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.AnalysisUnavailableBlockedPackages = []models.UnverifiedPackage{{Name: "requests", Version: "2.32.0"}}
+
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Malware analysis unavailable — 1 package blocked")
+	assert.Contains(t, out, "requests@2.32.0")
+	assert.NotContains(t, out, "Malicious package blocked")
+}
+
+func TestReportVerboseAnalysisUnavailable(t *testing.T) {
+	withVerbosity(t, VerbosityLevelVerbose)
+
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.AnalysisUnavailableBlockedPackages = []models.UnverifiedPackage{{Name: "requests", Version: "2.32.0"}}
+	data.UnverifiedPackages = []models.UnverifiedPackage{{Name: "urllib3", Version: "2.2.0"}}
+
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — malware analysis unavailable")
+	assert.Contains(t, out, "Blocked because malware analysis was unavailable:")
+	assert.Contains(t, out, "Installed without a verdict (analysis unavailable):")
+	assert.Contains(t, out, "urllib3@2.2.0")
+}
+
+func TestReportVulnerableBlockedApartFromMalware(t *testing.T) {
+	vulnerable := &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM, Name: "lodash"},
+			Version: "4.17.10",
+		},
+		Vulnerabilities: []analyzer.Vulnerability{{ID: "GHSA-1", Severity: "critical", Summary: "Prototype Pollution"}},
+	}
+
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.VulnerableBlockedPackages = []*analyzer.PackageVersionAnalysisResult{vulnerable}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Known vulnerabilities — 1 package blocked")
+	assert.Contains(t, out, "lodash@4.17.10")
+	assert.Contains(t, out, "GHSA-1 (critical): Prototype Pollution")
+	assert.NotContains(t, out, MalwareBlockedHeadline)
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — known vulnerabilities")
+	assert.Contains(t, out, "Blocked for known vulnerabilities:")
+	assert.NotContains(t, out, "Blocked packages:")
+}
+
+func TestReportLicenseBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 2
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.LicenseBlockedPackages = []models.LicenseBlock{
+		{Name: "left-pad", Version: "1.3.0", License: "AGPL-3.0-only", Declared: "AGPL-3.0-only", Verdict: "denied"},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "License policy — 1 package blocked")
+	assert.Contains(t, out, "left-pad@1.3.0")
+	assert.Contains(t, out, "License: AGPL-3.0-only (denied by license_policy)")
+	assert.NotContains(t, out, MalwareBlockedHeadline)
+	assert.NotContains(t, out, "blocked by cooldown")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — license policy")
+	assert.Contains(t, out, "Blocked by license policy:")
+	assert.NotContains(t, out, "Blocked packages:")
+}
+
+func TestReportPackageAgeBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.PackageAgeBlockedPackages = []models.PackageAgeBlock{
+		{Name: "expresss", Version: "0.0.1", FirstRelease: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), DaysAgo: 2, MinDays: 14},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "New packages — 1 package blocked")
+	assert.Contains(t, out, "expresss@0.0.1")
+	assert.Contains(t, out, "First released 2 days ago (2026-10-14) — package_age requires 14 days")
+	assert.NotContains(t, out, "blocked by cooldown")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — new package policy")
+	assert.Contains(t, out, "Blocked as new packages:")
+}
+
+func TestReportContentRuleBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.ContentRuleBlockedPackages = []models.ContentRuleBlock{
+		{Name: "evil", Version: "1.0.0", Pack: "campaign", Rule: "token_stealer", File: "lib/worker.js"},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Content rules — 1 package blocked")
+	assert.Contains(t, out, "evil@1.0.0")
+	assert.Contains(t, out, "Rule campaign/token_stealer matched lib/worker.js")
+	assert.NotContains(t, out, "blocked by cooldown")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — content rule matched")
+	assert.Contains(t, out, "Blocked by content rules:")
+}
+
+func TestReportLockfileBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 2
+	data.BlockedCount = 2
+	data.Outcome = OutcomeBlocked
+	data.LockfileBlockedPackages = []models.LockfileBlock{
+		{Name: "left-pad", Version: "1.3.0", Lockfile: "package-lock.json", Verdict: models.LockfileVerdictMismatch, Expected: "sha512-AAAA", Actual: "sha512-BBBB"},
+		{Name: "extra", Version: "1.0.0", Lockfile: "package-lock.json", Verdict: models.LockfileVerdictUnlisted},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Lockfile integrity — 2 packages blocked")
+	assert.Contains(t, out, "Hash sha512-BBBB does not match sha512-AAAA in package-lock.json")
+	assert.Contains(t, out, "Not listed in package-lock.json")
+	assert.NotContains(t, out, "blocked by cooldown")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — artifact does not match lockfile")
+	assert.Contains(t, out, "Blocked by lockfile integrity:")
+}
+
+func TestReportRegistryIntegrityBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.RegistryIntegrityBlockedPackages = []models.RegistryIntegrityBlock{
+		{Name: "left-pad", Version: "1.3.0", Registry: "npm.corp.example", Expected: "sha512-AAAA", Actual: "sha512-BBBB"},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Registry integrity — 1 package blocked")
+	assert.Contains(t, out, "Hash sha512-BBBB does not match sha512-AAAA published by npm.corp.example")
+	assert.NotContains(t, out, "blocked by cooldown")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — artifact does not match registry metadata")
+	assert.Contains(t, out, "Blocked by registry integrity:")
+}
+
+func TestReportNpmSignatureBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.NpmSignatureBlockedPackages = []models.NpmSignatureBlock{
+		{Name: "left-pad", Version: "1.3.0", Registry: "registry.npmjs.org", Reason: "no registry signature"},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Registry signature — 1 package blocked")
+	assert.Contains(t, out, "no registry signature from registry.npmjs.org")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — registry signature not verified")
+	assert.Contains(t, out, "Blocked by registry signature:")
+}
+
+func TestReportProvenanceBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.ProvenanceBlockedPackages = []models.ProvenanceBlock{
+		{Ecosystem: "ECOSYSTEM_PYPI", Name: "litellm", Version: "1.82.8", Reason: "no provenance attestation"},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Provenance — 1 package blocked")
+	assert.Contains(t, out, "no provenance attestation")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — provenance not verified")
+	assert.Contains(t, out, "Blocked by provenance policy:")
+}
+
+func TestReportDependencyConfusionBlocked(t *testing.T) {
+	data := NewReportData()
+	data.TotalAnalyzed = 1
+	data.BlockedCount = 1
+	data.Outcome = OutcomeBlocked
+	data.DependencyConfusionBlockedPackages = []models.DependencyConfusionBlock{
+		{Ecosystem: "ECOSYSTEM_NPM", Name: "@acme/widgets", Version: "1.0.0", Namespace: "@acme", Registry: "registry.npmjs.org"},
+	}
+
+	withVerbosity(t, VerbosityLevelNormal)
+	out := captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Dependency confusion — 1 package blocked")
+	assert.Contains(t, out, "private namespace @acme requested from registry.npmjs.org")
+
+	withVerbosity(t, VerbosityLevelVerbose)
+	out = captureStdout(t, func() { Report(data) })
+	assert.Contains(t, out, "Installation blocked — private package requested from another registry")
+	assert.Contains(t, out, "Blocked by private namespace policy:")
+}
+
 func withheldData(outcome ExecutionOutcome) *ReportData {
 	data := NewReportData()
 	data.Outcome = outcome
@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/safedep/pmg/internal/scan"
+)
+
+// scanDetailWidth bounds the details column of a scan report.
+const scanDetailWidth = 80
+
+// ScanReport renders the findings of a dependency scan of sources files, or
+// install locations. Violations and unverified versions are always listed;
+// clean and trusted versions only in verbose mode. Silent mode prints
+// nothing when there is nothing to list.
+func ScanReport(out io.Writer, findings []scan.Finding, sources int) error {
+	verbose := verbosityLevel == VerbosityLevelVerbose
+	violations, unverified := 0, 0
+	rows := [][]string{{"VERDICT", "PACKAGE", "DETAILS", "SOURCE"}}
+	for _, finding := range findings {
+		if finding.Violation {
+			violations++
+		} else if finding.Verdict == scan.VerdictUnverified {
+			unverified++
+		}
+
+		if !finding.Violation && finding.Verdict != scan.VerdictUnverified && !verbose {
+			continue
+		}
+		rows = append(rows, []string{
+			scanVerdictLabel(finding),
+			fmt.Sprintf("%s/%s@%s", ecosystemLabel(finding.Ecosystem), finding.Name, finding.Version),
+			Truncate(scanFindingDetail(finding), scanDetailWidth),
+			scanFindingSource(finding.Sources),
+		})
+	}
+
+	if verbosityLevel == VerbosityLevelSilent && len(rows) == 1 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(out, "Scanned %d package version(s) from %d source(s)\n", len(findings), sources); err != nil {
+		return err
+	}
+
+	if len(rows) > 1 {
+		if _, err := fmt.Fprintln(out); err != nil {
+			return err
+		}
+		if err := RenderTable(out, rows, nil); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(out); err != nil {
+		return err
+	}
+
+	var line string
+	switch {
+	case violations > 0:
+		line = Colors.Red("✗ %d package version(s) violate policy", violations)
+	case len(findings) == 0:
+		line = Colors.Yellow("No package versions found")
+	default:
+		line = Colors.Green("✓ No policy violations")
+	}
+	if unverified > 0 {
+		line += Colors.Yellow(" (%d not analyzed, allowed by analysis.on_failure)", unverified)
+	}
+	_, err := fmt.Fprintln(out, line)
+	return err
+}
+
+// ReviewReport renders the review of the package versions a change to
+// lockfiles brings in. Every reviewed version is listed with the versions it
+// replaces; silent mode lists only violations and unverified versions.
+func ReviewReport(out io.Writer, reviews []scan.Review, lockfiles int) error {
+	silent := verbosityLevel == VerbosityLevelSilent
+	violations, unverified := 0, 0
+	rows := [][]string{{"VERDICT", "PACKAGE", "CHANGE", "DETAILS", "SOURCE"}}
+	for _, review := range reviews {
+		if review.Violation {
+			violations++
+		} else if review.Verdict == scan.VerdictUnverified {
+			unverified++
+		}
+
+		if silent && !review.Violation && review.Verdict != scan.VerdictUnverified {
+			continue
+		}
+		change := "added"
+		if len(review.Previous) > 0 {
+			change = strings.Join(review.Previous, ", ") + " → " + review.Version
+		}
+		rows = append(rows, []string{
+			scanVerdictLabel(review.Finding),
+			fmt.Sprintf("%s/%s@%s", ecosystemLabel(review.Ecosystem), review.Name, review.Version),
+			change,
+			Truncate(scanFindingDetail(review.Finding), scanDetailWidth),
+			scanFindingSource(review.Sources),
+		})
+	}
+
+	if silent && len(rows) == 1 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(out, "Reviewed %d changed package version(s) in %d lockfile(s)\n", len(reviews), lockfiles); err != nil {
+		return err
+	}
+
+	if len(rows) > 1 {
+		if _, err := fmt.Fprintln(out); err != nil {
+			return err
+		}
+		if err := RenderTable(out, rows, nil); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(out); err != nil {
+		return err
+	}
+
+	var line string
+	switch {
+	case violations > 0:
+		line = Colors.Red("✗ %d changed package version(s) violate policy", violations)
+	case len(reviews) == 0:
+		line = Colors.Green("✓ No package versions added or changed")
+	default:
+		line = Colors.Green("✓ No policy violations")
+	}
+	if unverified > 0 {
+		line += Colors.Yellow(" (%d not analyzed, allowed by analysis.on_failure)", unverified)
+	}
+	_, err := fmt.Fprintln(out, line)
+	return err
+}
+
+func scanVerdictLabel(finding scan.Finding) string {
+	label := string(finding.Verdict)
+	switch {
+	case finding.Violation:
+		return Colors.Red(label)
+	case finding.Verdict == scan.VerdictUnverified:
+		return Colors.Yellow(label)
+	case finding.Verdict == scan.VerdictClean:
+		return Colors.Green(label)
+	}
+	return Colors.Dim(label)
+}
+
+func scanFindingDetail(finding scan.Finding) string {
+	var parts []string
+	if finding.Summary != "" {
+		parts = append(parts, strings.Join(strings.Fields(finding.Summary), " "))
+	}
+	if finding.ReferenceURL != "" {
+		parts = append(parts, finding.ReferenceURL)
+	}
+	if !finding.PublishedAt.IsZero() {
+		published := fmt.Sprintf("published %d day(s) ago", int(time.Since(finding.PublishedAt).Hours()/24))
+		if finding.Verdict == scan.VerdictCooldown {
+			published += fmt.Sprintf(", %d day(s) left in cooldown", finding.DaysLeft)
+		}
+		parts = append(parts, published)
+	}
+	return strings.Join(parts, " — ")
+}
+
+func scanFindingSource(sources []string) string {
+	switch len(sources) {
+	case 0:
+		return ""
+	case 1:
+		return sources[0]
+	}
+	return fmt.Sprintf("%s (+%d)", sources[0], len(sources)-1)
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanReport(t *testing.T) {
+	findings := []scan.Finding{
+		{
+			Package: scan.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM, Name: "evil", Version: "1.0.0",
+				Sources: []string{"package-lock.json", "web/package-lock.json"}},
+			Verdict:   scan.VerdictMalicious,
+			Violation: true,
+			Summary:   "credential stealer",
+		},
+		{
+			Package:     scan.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PYPI, Name: "fresh", Version: "2.0", Sources: []string{"uv.lock"}},
+			Verdict:     scan.VerdictCooldown,
+			Violation:   true,
+			PublishedAt: time.Now().Add(-49 * time.Hour),
+			DaysLeft:    3,
+		},
+		{
+			Package: scan.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_GO, Name: "github.com/pkg/errors", Version: "v0.9.1", Sources: []string{"go.sum"}},
+			Verdict: scan.VerdictClean,
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, ScanReport(&out, findings, 3))
+
+	report := out.String()
+	assert.Contains(t, report, "Scanned 3 package version(s) from 3 source(s)")
+	assert.Contains(t, report, "npm/evil@1.0.0")
+	assert.Contains(t, report, "package-lock.json (+1)")
+	assert.Contains(t, report, "published 2 day(s) ago, 3 day(s) left in cooldown")
+	assert.Contains(t, report, "2 package version(s) violate policy")
+	assert.NotContains(t, report, "github.com/pkg/errors")
+
+	withVerbosity(t, VerbosityLevelSilent)
+	out.Reset()
+	require.NoError(t, ScanReport(&out, findings[2:], 1))
+	assert.Empty(t, out.String())
+}
+
+func TestReviewReport(t *testing.T) {
+	reviews := []scan.Review{
+		{
+			Finding: scan.Finding{
+				Package:     scan.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM, Name: "left-pad", Version: "1.3.0", Sources: []string{"package-lock.json"}},
+				Verdict:     scan.VerdictCooldown,
+				Violation:   true,
+				PublishedAt: time.Now().Add(-25 * time.Hour),
+				DaysLeft:    4,
+			},
+			Previous: []string{"1.2.0"},
+		},
+		{
+			Finding: scan.Finding{
+				Package:     scan.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PYPI, Name: "certifi", Version: "2024.2.2", Sources: []string{"requirements.txt"}},
+				Verdict:     scan.VerdictClean,
+				PublishedAt: time.Now().Add(-40 * 24 * time.Hour),
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, ReviewReport(&out, reviews, 2))
+
+	report := out.String()
+	assert.Contains(t, report, "Reviewed 2 changed package version(s) in 2 lockfile(s)")
+	assert.Contains(t, report, "1.2.0 → 1.3.0")
+	assert.Contains(t, report, "published 1 day(s) ago, 4 day(s) left in cooldown")
+	assert.Contains(t, report, "pypi/certifi@2024.2.2")
+	assert.Contains(t, report, "added")
+	assert.Contains(t, report, "published 40 day(s) ago")
+	assert.Contains(t, report, "1 changed package version(s) violate policy")
+
+	withVerbosity(t, VerbosityLevelSilent)
+	out.Reset()
+	require.NoError(t, ReviewReport(&out, reviews[1:], 1))
+	assert.Empty(t, out.String())
+}
@@ -195,8 +195,16 @@ func printMaliciousPackagesList(malwarePackages []*analyzer.PackageVersionAnalys
 			Colors.Red(fmt.Sprintf("%s@%s", mp.PackageVersion.GetPackage().GetName(),
 				mp.PackageVersion.GetVersion())))
 
+		// The typosquat summary already names the suspected target, so it is
+		// only repeated when the summary is not shown.
 		if verbosityLevel == VerbosityLevelVerbose {
 			fmt.Printf("    %s\n", Colors.Dim(termWidthFormatTextIndent(mp.Summary, 76, "    ")))
+		} else if mp.SuspectedTarget != "" {
+			fmt.Printf("    %s\n", Colors.Yellow(fmt.Sprintf("Did you mean: %s", mp.SuspectedTarget)))
+		}
+
+		if mp.DecidedBy != "" {
+			fmt.Printf("    %s\n", Colors.Dim(fmt.Sprintf("Flagged by: %s", mp.DecidedBy)))
 		}
 
 		if mp.ReferenceURL != "" {
@@ -228,6 +236,14 @@ func printCooldownPackagesList(packages []models.CooldownBlock) {
 	}
 }
 
+func printUnverifiedPackagesList(packages []models.UnverifiedPackage) {
+	for _, pkg := range packages {
+		fmt.Println()
+		fmt.Printf("  %s %s\n", Colors.Red("-"), Colors.Red(fmt.Sprintf("%s@%s", pkg.Name, pkg.Version)))
+	}
+	fmt.Printf("    %s\n", Colors.Dim("PMG could not obtain a malware verdict; blocked by the analysis.on_failure policy"))
+}
+
 // printAdvisoryMessage renders the org-configured advisory_message as an info
 // note attached to the block output. Callers are responsible for surrounding
 // blank lines. No-op when the message is empty.
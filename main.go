@@ -10,15 +10,25 @@ import (
 
 	"github.com/safedep/dry/log"
 	"github.com/safedep/dry/usefulerror"
+	auditCmd "github.com/safedep/pmg/cmd/audit"
+	cargoCmd "github.com/safedep/pmg/cmd/cargo"
 	"github.com/safedep/pmg/cmd/cloud"
+	composerCmd "github.com/safedep/pmg/cmd/composer"
 	configCmd "github.com/safedep/pmg/cmd/config"
 	"github.com/safedep/pmg/cmd/executors"
+	feedCmd "github.com/safedep/pmg/cmd/feed"
 	golangCmd "github.com/safedep/pmg/cmd/golang"
 	landlockCmd "github.com/safedep/pmg/cmd/landlock"
+	"github.com/safedep/pmg/cmd/maven"
 	"github.com/safedep/pmg/cmd/npm"
+	"github.com/safedep/pmg/cmd/nuget"
 	proxyCmd "github.com/safedep/pmg/cmd/proxy"
 	"github.com/safedep/pmg/cmd/pypi"
+	reviewCmd "github.com/safedep/pmg/cmd/review"
+	"github.com/safedep/pmg/cmd/rubygems"
+	rulesCmd "github.com/safedep/pmg/cmd/rules"
 	sandboxCmd "github.com/safedep/pmg/cmd/sandbox"
+	scanCmd "github.com/safedep/pmg/cmd/scan"
 	"github.com/safedep/pmg/cmd/setup"
 	"github.com/safedep/pmg/cmd/version"
 	"github.com/safedep/pmg/config"
@@ -167,12 +177,26 @@ func main() {
 	cmd.AddCommand(executors.NewPipxCommand())
 	cmd.AddCommand(executors.NewUvxCommand())
 	cmd.AddCommand(golangCmd.NewGoCommand())
+	cmd.AddCommand(cargoCmd.NewCargoCommand())
+	cmd.AddCommand(rubygems.NewGemCommand())
+	cmd.AddCommand(rubygems.NewBundleCommand())
+	cmd.AddCommand(maven.NewMvnCommand())
+	cmd.AddCommand(maven.NewGradleCommand())
+	cmd.AddCommand(maven.NewGradlewCommand())
+	cmd.AddCommand(nuget.NewDotnetCommand())
+	cmd.AddCommand(nuget.NewNuGetCommand())
+	cmd.AddCommand(composerCmd.NewComposerCommand())
 	cmd.AddCommand(proxyCmd.NewProxyCommand())
 	cmd.AddCommand(version.NewVersionCommand())
 	cmd.AddCommand(setup.NewSetupCommand())
 	cmd.AddCommand(setup.NewRemoveCommand())
 	cmd.AddCommand(sandboxCmd.NewCommand())
 	cmd.AddCommand(cloud.NewCloudCommand())
+	cmd.AddCommand(feedCmd.NewFeedCommand())
+	cmd.AddCommand(rulesCmd.NewRulesCommand())
+	cmd.AddCommand(scanCmd.NewScanCommand())
+	cmd.AddCommand(auditCmd.NewAuditCommand())
+	cmd.AddCommand(reviewCmd.NewReviewCommand())
 	cmd.AddCommand(configCmd.NewConfigCommand())
 
 	if subcmd := landlockCmd.NewLandlockSandboxExecCommand(); subcmd != nil {
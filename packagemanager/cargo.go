@@ -0,0 +1,223 @@
+package packagemanager
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/spf13/pflag"
+)
+
+type CargoPackageManagerConfig struct {
+	CommandName string
+
+	// InstallCommands accept crate[@version] args on the command line, used to
+	// extract pinned versions for cooldown reporting.
+	InstallCommands []string
+
+	// ManifestInstallCommands resolve and download the dependencies declared
+	// in Cargo.toml / Cargo.lock.
+	ManifestInstallCommands []string
+
+	// NonDownloadCommands never resolve the dependency graph and therefore
+	// never fetch crates. Anything not listed runs with the proxy.
+	NonDownloadCommands []string
+}
+
+func DefaultCargoPackageManagerConfig() CargoPackageManagerConfig {
+	return CargoPackageManagerConfig{
+		CommandName:     "cargo",
+		InstallCommands: []string{"add", "install"},
+		ManifestInstallCommands: []string{
+			"build", "b", "check", "c", "fetch", "run", "r", "test", "t",
+			"bench", "doc", "d", "update", "generate-lockfile", "vendor",
+		},
+		NonDownloadCommands: []string{
+			"version", "help", "new", "init", "search", "login", "logout",
+			"clean", "uninstall", "fmt", "locate-project",
+		},
+	}
+}
+
+type cargoPackageManager struct {
+	Config CargoPackageManagerConfig
+}
+
+func NewCargoPackageManager(config CargoPackageManagerConfig) (*cargoPackageManager, error) {
+	return &cargoPackageManager{Config: config}, nil
+}
+
+var _ PackageManager = &cargoPackageManager{}
+
+func (c *cargoPackageManager) Name() string {
+	return c.Config.CommandName
+}
+
+func (c *cargoPackageManager) Ecosystem() packagev1.Ecosystem {
+	return packagev1.Ecosystem_ECOSYSTEM_CARGO
+}
+
+func (c *cargoPackageManager) ParseCommand(args []string) (*ParsedCommand, error) {
+	if len(args) > 0 && args[0] == c.Config.CommandName {
+		args = args[1:]
+	}
+
+	parsed := &ParsedCommand{Command: Command{Exe: c.Config.CommandName, Args: args}}
+
+	subcmd, rest := cargoSubcommand(args)
+	if subcmd == "" {
+		return parsed, nil
+	}
+
+	if slices.Contains(c.Config.NonDownloadCommands, subcmd) {
+		parsed.IsKnownNonDownloadCommand = true
+		return parsed, nil
+	}
+
+	if slices.Contains(c.Config.ManifestInstallCommands, subcmd) {
+		parsed.IsManifestInstall = true
+		return parsed, nil
+	}
+
+	if slices.Contains(c.Config.InstallCommands, subcmd) {
+		parsed.InstallTargets = cargoInstallTargets(subcmd, rest)
+
+		// `cargo add` without crate names (e.g. --path) and `cargo install`
+		// from a local path or git still resolve registry dependencies.
+		if len(parsed.InstallTargets) == 0 {
+			parsed.IsManifestInstall = true
+		}
+	}
+
+	return parsed, nil
+}
+
+var _ ProxyRoutingProvider = &cargoPackageManager{}
+
+// ProxyRouting forces the sparse index protocol for crates.io. The legacy git
+// index is fetched from github.com, where PMG cannot read publish times, so
+// cooldown would silently stop applying; crate downloads go to
+// static.crates.io under either protocol.
+func (c *cargoPackageManager) ProxyRouting(_ context.Context) (*ProxyRouting, error) {
+	return &ProxyRouting{
+		ExtraEnv: []string{"CARGO_REGISTRIES_CRATES_IO_PROTOCOL=sparse"},
+	}, nil
+}
+
+// cargoGlobalValueFlags are global cargo flags that take a separate value
+// argument, which must not be mistaken for the subcommand.
+var cargoGlobalValueFlags = []string{"--config", "--color", "-C", "-Z"}
+
+// cargoSubcommand returns the cargo subcommand and its arguments, skipping
+// global flags and a leading rustup toolchain override (cargo +nightly build).
+func cargoSubcommand(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if slices.Contains(cargoGlobalValueFlags, arg) {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+") {
+			continue
+		}
+		return arg, args[i+1:]
+	}
+	return "", nil
+}
+
+// cargoExactVersionPattern matches a complete semver version, the only form
+// that pins a single crate version.
+var cargoExactVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]+)?$`)
+
+// cargoInstallTargets extracts registry crate targets from `cargo add` and
+// `cargo install` args. Local path and git sources are skipped since they are
+// not downloaded from crates.io. A bare version is exact for cargo install but
+// a caret requirement for cargo add, where only `=x.y.z` pins a version.
+func cargoInstallTargets(subcmd string, args []string) []*PackageInstallTarget {
+	var versionFlag, pathFlag, gitFlag string
+
+	flagSet := pflag.NewFlagSet(subcmd, pflag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.ParseErrorsAllowlist.UnknownFlags = true
+
+	// Value flags are declared so UnknownFlags mode does not treat their
+	// value as a crate name.
+	flagSet.StringVar(&pathFlag, "path", "", "")
+	flagSet.StringVar(&gitFlag, "git", "", "")
+	flagSet.StringArrayP("features", "F", nil, "")
+	flagSet.StringArrayP("package", "p", nil, "")
+	for _, name := range []string{
+		"branch", "tag", "rev", "rename", "target", "registry", "index",
+		"manifest-path", "lockfile-path", "root", "target-dir", "profile",
+		"bin", "example", "config", "color",
+	} {
+		flagSet.StringArray(name, nil, "")
+	}
+	flagSet.StringArrayP("jobs", "j", nil, "")
+	flagSet.StringArrayP("unstable", "Z", nil, "")
+
+	// Boolean flags are declared so UnknownFlags mode does not swallow the
+	// crate name that follows them.
+	for _, name := range []string{
+		"dev", "build", "optional", "no-optional", "public", "no-public",
+		"default-features", "no-default-features", "all-features", "dry-run",
+		"offline", "frozen", "locked", "debug", "no-track", "list", "bins",
+		"examples", "ignore-rust-version", "keep-going", "timings",
+	} {
+		flagSet.Bool(name, false, "")
+	}
+	flagSet.BoolP("quiet", "q", false, "")
+	flagSet.CountP("verbose", "v", "")
+	flagSet.BoolP("force", "f", false, "")
+
+	if subcmd == "install" {
+		flagSet.StringVar(&versionFlag, "version", "", "")
+		flagSet.StringVar(&versionFlag, "vers", "", "")
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil
+	}
+
+	if pathFlag != "" || gitFlag != "" {
+		return nil
+	}
+
+	var targets []*PackageInstallTarget
+	for _, arg := range flagSet.Args() {
+		name, version, _ := strings.Cut(arg, "@")
+		if name == "" {
+			continue
+		}
+
+		if version == "" {
+			version = versionFlag
+		}
+
+		explicit := false
+		switch {
+		case subcmd == "install":
+			version = strings.TrimPrefix(version, "=")
+			explicit = cargoExactVersionPattern.MatchString(version)
+		case strings.HasPrefix(version, "="):
+			version = strings.TrimSpace(strings.TrimPrefix(version, "="))
+			explicit = cargoExactVersionPattern.MatchString(version)
+		}
+
+		targets = append(targets, &PackageInstallTarget{
+			PackageVersion: &packagev1.PackageVersion{
+				Package: &packagev1.Package{
+					Ecosystem: packagev1.Ecosystem_ECOSYSTEM_CARGO,
+					Name:      name,
+				},
+				Version: version,
+			},
+			IsExplicitVersion: explicit,
+		})
+	}
+
+	return targets
+}
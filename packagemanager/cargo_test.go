@@ -0,0 +1,145 @@
+package packagemanager
+
+import (
+	"context"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCargoPackageManagerParseCommand(t *testing.T) {
+	type target struct {
+		name     string
+		version  string
+		explicit bool
+	}
+
+	cases := []struct {
+		name            string
+		args            []string
+		nonDownload     bool
+		manifestInstall bool
+		targets         []target
+	}{
+		{
+			name:        "cargo version is non-download",
+			args:        []string{"cargo", "version"},
+			nonDownload: true,
+		},
+		{
+			name:        "cargo new is non-download",
+			args:        []string{"cargo", "new", "hello"},
+			nonDownload: true,
+		},
+		{
+			name:            "cargo build is manifest install",
+			args:            []string{"cargo", "build", "--release"},
+			manifestInstall: true,
+		},
+		{
+			name:            "cargo fetch is manifest install",
+			args:            []string{"cargo", "fetch"},
+			manifestInstall: true,
+		},
+		{
+			name:            "toolchain override is skipped",
+			args:            []string{"cargo", "+nightly", "build"},
+			manifestInstall: true,
+		},
+		{
+			name:            "global value flag is skipped",
+			args:            []string{"cargo", "--color", "always", "check"},
+			manifestInstall: true,
+		},
+		{
+			name:    "cargo add without version",
+			args:    []string{"cargo", "add", "serde"},
+			targets: []target{{name: "serde"}},
+		},
+		{
+			name:    "cargo add bare version is a requirement, not a pin",
+			args:    []string{"cargo", "add", "serde@1.0.200"},
+			targets: []target{{name: "serde", version: "1.0.200"}},
+		},
+		{
+			name:    "cargo add exact requirement is explicit",
+			args:    []string{"cargo", "add", "serde@=1.0.200"},
+			targets: []target{{name: "serde", version: "1.0.200", explicit: true}},
+		},
+		{
+			name: "cargo add with features and dev flag",
+			args: []string{"cargo", "add", "--dev", "tokio", "-F", "full", "serde_json"},
+			targets: []target{
+				{name: "tokio"},
+				{name: "serde_json"},
+			},
+		},
+		{
+			name:            "cargo add from local path resolves manifest",
+			args:            []string{"cargo", "add", "--path", "../mylib"},
+			manifestInstall: true,
+		},
+		{
+			name:    "cargo install with version suffix is explicit",
+			args:    []string{"cargo", "install", "ripgrep@14.1.0"},
+			targets: []target{{name: "ripgrep", version: "14.1.0", explicit: true}},
+		},
+		{
+			name:    "cargo install with --version flag is explicit",
+			args:    []string{"cargo", "install", "--locked", "ripgrep", "--version", "14.1.0"},
+			targets: []target{{name: "ripgrep", version: "14.1.0", explicit: true}},
+		},
+		{
+			name:    "cargo install with partial version is not explicit",
+			args:    []string{"cargo", "install", "ripgrep@14"},
+			targets: []target{{name: "ripgrep", version: "14"}},
+		},
+		{
+			name:            "cargo install from git resolves manifest",
+			args:            []string{"cargo", "install", "--git", "https://github.com/x/y"},
+			manifestInstall: true,
+		},
+		{
+			name: "no subcommand",
+			args: []string{"cargo"},
+		},
+	}
+
+	pm, err := NewCargoPackageManager(DefaultCargoPackageManagerConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, "cargo", pm.Name())
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_CARGO, pm.Ecosystem())
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := pm.ParseCommand(tc.args)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.nonDownload, parsed.IsKnownNonDownloadCommand)
+			assert.Equal(t, tc.manifestInstall, parsed.IsManifestInstall)
+
+			require.Len(t, parsed.InstallTargets, len(tc.targets))
+			for i, want := range tc.targets {
+				got := parsed.InstallTargets[i]
+				assert.Equal(t, want.name, got.PackageVersion.GetPackage().GetName())
+				assert.Equal(t, want.version, got.PackageVersion.GetVersion())
+				assert.Equal(t, want.explicit, got.IsExplicitVersion)
+				assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_CARGO, got.PackageVersion.GetPackage().GetEcosystem())
+			}
+		})
+	}
+}
+
+func TestCargoProxyRoutingForcesSparseProtocol(t *testing.T) {
+	pm, err := NewCargoPackageManager(DefaultCargoPackageManagerConfig())
+	require.NoError(t, err)
+
+	routing, err := pm.ProxyRouting(context.Background())
+	require.NoError(t, err)
+
+	assert.Contains(t, routing.ExtraEnv, "CARGO_REGISTRIES_CRATES_IO_PROTOCOL=sparse")
+	assert.Empty(t, routing.MITMHosts)
+}
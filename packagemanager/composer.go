@@ -0,0 +1,301 @@
+package packagemanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/spf13/pflag"
+)
+
+type ComposerPackageManagerConfig struct {
+	CommandName string
+
+	// InstallCommands accept package names on the command line.
+	InstallCommands []string
+
+	// ManifestInstallCommands install the packages composer.json /
+	// composer.lock declare.
+	ManifestInstallCommands []string
+
+	// NonDownloadCommands never download packages. Anything not listed
+	// runs with the proxy.
+	NonDownloadCommands []string
+}
+
+func DefaultComposerPackageManagerConfig() ComposerPackageManagerConfig {
+	return ComposerPackageManagerConfig{
+		CommandName:             "composer",
+		InstallCommands:         []string{"require", "create-project"},
+		ManifestInstallCommands: []string{"install", "i", "update", "u", "upgrade", "reinstall"},
+		NonDownloadCommands: []string{
+			"remove", "rm", "uninstall", "dump-autoload", "dumpautoload", "validate",
+			"list", "help", "about", "licenses", "config", "diagnose", "status",
+			"run-script", "run", "exec", "init", "check-platform-reqs", "clear-cache",
+			"clearcache", "cc", "fund", "suggests", "depends", "why", "prohibits", "why-not",
+		},
+	}
+}
+
+type composerPackageManager struct {
+	Config ComposerPackageManagerConfig
+
+	// workingDir is the project directory of the parsed command, from
+	// -d/--working-dir.
+	workingDir string
+}
+
+func NewComposerPackageManager(config ComposerPackageManagerConfig) (*composerPackageManager, error) {
+	if config.CommandName != "composer" {
+		return nil, fmt.Errorf("unsupported package manager: %s", config.CommandName)
+	}
+
+	return &composerPackageManager{Config: config}, nil
+}
+
+var _ PackageManager = &composerPackageManager{}
+
+func (c *composerPackageManager) Name() string {
+	return c.Config.CommandName
+}
+
+func (c *composerPackageManager) Ecosystem() packagev1.Ecosystem {
+	return packagev1.Ecosystem_ECOSYSTEM_PACKAGIST
+}
+
+func (c *composerPackageManager) ParseCommand(args []string) (*ParsedCommand, error) {
+	if len(args) > 0 && args[0] == c.Config.CommandName {
+		args = args[1:]
+	}
+
+	parsed := &ParsedCommand{Command: Command{Exe: c.Config.CommandName, Args: args}}
+
+	flagSet := newComposerFlagSet()
+	if err := flagSet.Parse(args); err != nil {
+		return parsed, nil
+	}
+
+	c.workingDir, _ = flagSet.GetString("working-dir")
+
+	positional := flagSet.Args()
+	if len(positional) == 0 {
+		// A bare `composer` lists the available commands.
+		parsed.IsKnownNonDownloadCommand = true
+		return parsed, nil
+	}
+
+	subcmd, rest := positional[0], positional[1:]
+	switch {
+	case slices.Contains(c.Config.NonDownloadCommands, subcmd):
+		parsed.IsKnownNonDownloadCommand = true
+	case slices.Contains(c.Config.ManifestInstallCommands, subcmd):
+		parsed.IsManifestInstall = true
+	case slices.Contains(c.Config.InstallCommands, subcmd):
+		if subcmd == "create-project" {
+			// create-project <package> [directory] [version]
+			parsed.InstallTargets = composerCreateProjectTargets(rest)
+		} else {
+			parsed.InstallTargets = composerRequireTargets(rest)
+		}
+
+		// `composer require` without names prompts for packages and then
+		// resolves the whole manifest.
+		if len(parsed.InstallTargets) == 0 {
+			parsed.IsManifestInstall = true
+		}
+	}
+
+	return parsed, nil
+}
+
+// newComposerFlagSet declares composer's global options and the boolean
+// options of the install commands, so UnknownFlags mode does not swallow the
+// package name that follows them.
+func newComposerFlagSet() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("composer", pflag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.ParseErrorsAllowlist.UnknownFlags = true
+
+	flagSet.StringP("working-dir", "d", "", "")
+	for _, name := range []string{"prefer-install", "ignore-platform-req", "audit-format", "apcu-autoloader-prefix", "stability", "repository", "add-repository"} {
+		flagSet.StringArray(name, nil, "")
+	}
+
+	for _, name := range []string{
+		"dev", "dry-run", "prefer-source", "prefer-dist", "no-progress", "no-update",
+		"no-install", "no-audit", "update-no-dev", "ignore-platform-reqs",
+		"prefer-stable", "prefer-lowest", "sort-packages", "apcu-autoloader", "no-scripts",
+		"no-plugins", "no-cache", "no-dev", "fixed", "minimal-changes", "no-secure-http",
+		"keep-vcs", "remove-vcs", "ansi", "no-ansi", "profile", "lock", "interactive",
+	} {
+		flagSet.Bool(name, false, "")
+	}
+	flagSet.BoolP("no-interaction", "n", false, "")
+	flagSet.BoolP("quiet", "q", false, "")
+	flagSet.BoolP("verbose", "v", false, "")
+	flagSet.BoolP("help", "h", false, "")
+	flagSet.BoolP("version", "V", false, "")
+	flagSet.BoolP("update-with-dependencies", "w", false, "")
+	flagSet.BoolP("update-with-all-dependencies", "W", false, "")
+	flagSet.BoolP("optimize-autoloader", "o", false, "")
+	flagSet.BoolP("classmap-authoritative", "a", false, "")
+	flagSet.Bool("with-dependencies", false, "")
+	flagSet.Bool("with-all-dependencies", false, "")
+
+	return flagSet
+}
+
+// composerExactVersionPattern matches a single version, as opposed to a
+// constraint such as "^1.2", "~6.4" or ">=2.0 <3.0".
+var composerExactVersionPattern = regexp.MustCompile(`^v?\d+(?:\.\d+){0,3}(?:-[0-9A-Za-z.]+)?$`)
+
+// composerConstraintPattern matches the start of a version constraint given
+// as its own argument.
+var composerConstraintPattern = regexp.MustCompile(`^(?:v?\d|[\^~<>=!*]|dev-)`)
+
+// composerRequireTargets extracts packages from `composer require` args.
+// Constraints come from `vendor/package:constraint`, `vendor/package=constraint`
+// or a separate argument following the package name.
+func composerRequireTargets(args []string) []*PackageInstallTarget {
+	var targets []*PackageInstallTarget
+	for _, arg := range args {
+		name, constraint, found := strings.Cut(arg, ":")
+		if !found {
+			name, constraint, _ = strings.Cut(arg, "=")
+		}
+
+		if !strings.Contains(name, "/") {
+			// `composer require vendor/package 1.2.3`: a bare constraint
+			// applies to the package before it. Platform packages such as
+			// php or ext-json are not downloaded.
+			if !found && composerConstraintPattern.MatchString(arg) &&
+				len(targets) > 0 && targets[len(targets)-1].PackageVersion.GetVersion() == "" {
+				last := targets[len(targets)-1]
+				targets[len(targets)-1] = composerInstallTarget(last.PackageVersion.GetPackage().GetName(), arg)
+			}
+			continue
+		}
+
+		targets = append(targets, composerInstallTarget(name, constraint))
+	}
+
+	return targets
+}
+
+func composerCreateProjectTargets(args []string) []*PackageInstallTarget {
+	if len(args) == 0 || !strings.Contains(args[0], "/") {
+		return nil
+	}
+
+	name, constraint, _ := strings.Cut(args[0], ":")
+	if constraint == "" && len(args) >= 3 {
+		constraint = args[2]
+	}
+
+	return []*PackageInstallTarget{composerInstallTarget(name, constraint)}
+}
+
+func composerInstallTarget(name, constraint string) *PackageInstallTarget {
+	constraint = strings.TrimSpace(constraint)
+	return &PackageInstallTarget{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{
+				Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PACKAGIST,
+				Name:      strings.ToLower(name),
+			},
+			Version: constraint,
+		},
+		IsExplicitVersion: composerExactVersionPattern.MatchString(constraint),
+	}
+}
+
+var _ ProxyRoutingProvider = &composerPackageManager{}
+
+// ProxyRouting hands the proxy the dist URLs locked in composer.lock.
+// Packagist dists are served by GitHub, GitLab or Bitbucket archive URLs
+// that do not name the package, and `composer install` from a lock file
+// downloads them without fetching any metadata the proxy could learn the
+// URLs from.
+func (c *composerPackageManager) ProxyRouting(_ context.Context) (*ProxyRouting, error) {
+	routing := &ProxyRouting{}
+
+	lockPath := composerLockPath(c.workingDir)
+	artifacts, err := readComposerLockDists(lockPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to read %s; locked dists are analyzed only when their metadata is fetched: %v", lockPath, err)
+		}
+		return routing, nil
+	}
+
+	routing.Artifacts = artifacts
+	return routing, nil
+}
+
+// composerLockPath returns the lock file composer uses: the COMPOSER
+// manifest name with a .lock extension, in the working directory.
+func composerLockPath(workingDir string) string {
+	manifest := os.Getenv("COMPOSER")
+	if manifest == "" {
+		manifest = "composer.json"
+	}
+
+	lockFile := strings.TrimSuffix(manifest, filepath.Ext(manifest)) + ".lock"
+	if filepath.IsAbs(lockFile) {
+		return lockFile
+	}
+
+	return filepath.Join(workingDir, lockFile)
+}
+
+type composerLockFile struct {
+	Packages    []composerLockPackage `json:"packages"`
+	PackagesDev []composerLockPackage `json:"packages-dev"`
+}
+
+type composerLockPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Dist    struct {
+		URL string `json:"url"`
+	} `json:"dist"`
+}
+
+// readComposerLockDists maps each locked dist URL to the package version it
+// delivers.
+func readComposerLockDists(lockPath string) (map[string]*packagev1.PackageVersion, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock composerLockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse composer lock file: %w", err)
+	}
+
+	artifacts := make(map[string]*packagev1.PackageVersion)
+	for _, pkg := range append(lock.Packages, lock.PackagesDev...) {
+		if pkg.Name == "" || pkg.Version == "" || pkg.Dist.URL == "" {
+			continue
+		}
+
+		artifacts[pkg.Dist.URL] = &packagev1.PackageVersion{
+			Package: &packagev1.Package{
+				Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PACKAGIST,
+				Name:      strings.ToLower(pkg.Name),
+			},
+			Version: pkg.Version,
+		}
+	}
+
+	return artifacts, nil
+}
@@ -0,0 +1,156 @@
+package packagemanager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposerPackageManagerParseCommand(t *testing.T) {
+	type target struct {
+		name     string
+		version  string
+		explicit bool
+	}
+
+	cases := []struct {
+		name            string
+		args            []string
+		nonDownload     bool
+		manifestInstall bool
+		targets         []target
+	}{
+		{
+			name:    "require without constraint",
+			args:    []string{"composer", "require", "monolog/monolog"},
+			targets: []target{{name: "monolog/monolog"}},
+		},
+		{
+			name: "require with colon and equals constraints",
+			args: []string{"composer", "require", "Symfony/Console:^6.4", "guzzlehttp/guzzle=7.8.1"},
+			targets: []target{
+				{name: "symfony/console", version: "^6.4"},
+				{name: "guzzlehttp/guzzle", version: "7.8.1", explicit: true},
+			},
+		},
+		{
+			name:    "require with separate constraint after boolean flags",
+			args:    []string{"composer", "require", "--dev", "-W", "phpunit/phpunit", "v10.5.2"},
+			targets: []target{{name: "phpunit/phpunit", version: "v10.5.2", explicit: true}},
+		},
+		{
+			name:    "require skips platform packages",
+			args:    []string{"composer", "require", "php:^8.1", "ext-json", "laravel/framework"},
+			targets: []target{{name: "laravel/framework"}},
+		},
+		{
+			name:            "require without packages resolves the manifest",
+			args:            []string{"composer", "require", "-n"},
+			manifestInstall: true,
+		},
+		{
+			name:    "create-project with version argument",
+			args:    []string{"composer", "create-project", "laravel/laravel", "app", "11.0.3"},
+			targets: []target{{name: "laravel/laravel", version: "11.0.3", explicit: true}},
+		},
+		{
+			name:            "install is manifest install",
+			args:            []string{"composer", "-d", "app", "install", "--no-dev"},
+			manifestInstall: true,
+		},
+		{
+			name:            "update is manifest install",
+			args:            []string{"composer", "update", "symfony/*"},
+			manifestInstall: true,
+		},
+		{
+			name:        "dump-autoload is non-download",
+			args:        []string{"composer", "dump-autoload", "-o"},
+			nonDownload: true,
+		},
+		{
+			name:        "bare composer is non-download",
+			args:        []string{"composer"},
+			nonDownload: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pm, err := NewComposerPackageManager(DefaultComposerPackageManagerConfig())
+			require.NoError(t, err)
+			assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, pm.Ecosystem())
+
+			parsed, err := pm.ParseCommand(tc.args)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.nonDownload, parsed.IsKnownNonDownloadCommand)
+			assert.Equal(t, tc.manifestInstall, parsed.IsManifestInstall)
+
+			require.Len(t, parsed.InstallTargets, len(tc.targets))
+			for i, want := range tc.targets {
+				got := parsed.InstallTargets[i]
+				assert.Equal(t, want.name, got.PackageVersion.GetPackage().GetName())
+				assert.Equal(t, want.version, got.PackageVersion.GetVersion())
+				assert.Equal(t, want.explicit, got.IsExplicitVersion)
+				assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, got.PackageVersion.GetPackage().GetEcosystem())
+			}
+		})
+	}
+}
+
+func TestNewComposerPackageManagerRejectsUnknownCommand(t *testing.T) {
+	_, err := NewComposerPackageManager(ComposerPackageManagerConfig{CommandName: "php"})
+	assert.Error(t, err)
+}
+
+func TestComposerPackageManagerProxyRouting(t *testing.T) {
+	t.Setenv("COMPOSER", "")
+
+	dir := t.TempDir()
+	lock := `{
+  "packages": [
+    {"name": "Symfony/Console", "version": "v6.4.1",
+     "dist": {"type": "zip", "url": "https://api.github.com/repos/symfony/console/zipball/abc"}}
+  ],
+  "packages-dev": [
+    {"name": "phpunit/phpunit", "version": "10.5.2",
+     "dist": {"type": "zip", "url": "https://api.github.com/repos/sebastianbergmann/phpunit/zipball/def"}},
+    {"name": "acme/path-repo", "version": "dev-main", "dist": {"type": "path", "url": ""}}
+  ]
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "composer.lock"), []byte(lock), 0o644))
+
+	pm, err := NewComposerPackageManager(DefaultComposerPackageManagerConfig())
+	require.NoError(t, err)
+
+	_, err = pm.ParseCommand([]string{"composer", "install", "--working-dir", dir})
+	require.NoError(t, err)
+
+	routing, err := pm.ProxyRouting(context.Background())
+	require.NoError(t, err)
+	require.Len(t, routing.Artifacts, 2)
+
+	console := routing.Artifacts["https://api.github.com/repos/symfony/console/zipball/abc"]
+	require.NotNil(t, console)
+	assert.Equal(t, "symfony/console", console.GetPackage().GetName())
+	assert.Equal(t, "v6.4.1", console.GetVersion())
+
+	// Without a lock file there is nothing to seed and no error.
+	_, err = pm.ParseCommand([]string{"composer", "install", "-d", t.TempDir()})
+	require.NoError(t, err)
+
+	routing, err = pm.ProxyRouting(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, routing.Artifacts)
+}
+
+func TestComposerLockPathHonoursComposerEnv(t *testing.T) {
+	t.Setenv("COMPOSER", "composer-ci.json")
+	assert.Equal(t, filepath.Join("app", "composer-ci.lock"), composerLockPath("app"))
+}
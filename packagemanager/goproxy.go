@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"strings"
 
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 	"github.com/safedep/dry/log"
 )
 
@@ -17,9 +18,13 @@ import (
 // must intercept dynamically to the upstream base URL packages are served
 // under (scheme + host + optional path prefix) — Go's module proxy is
 // user-configurable via GOPROXY, unlike npm/PyPI's fixed registry hosts.
+// Artifacts maps artifact URLs the run is known to download to the package
+// version each delivers, for registries whose artifact URLs do not name the
+// package (Composer dists served from GitHub archives).
 type ProxyRouting struct {
 	ExtraEnv  []string
 	MITMHosts map[string]string
+	Artifacts map[string]*packagev1.PackageVersion
 }
 
 // ProxyRoutingProvider is implemented by package managers that need
@@ -0,0 +1,167 @@
+package packagemanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+)
+
+type MavenPackageManagerConfig struct {
+	// CommandName is the name PMG reports and matches skip_commands against.
+	CommandName string
+
+	// Executable is the binary that runs, e.g. ./gradlew for the Gradle
+	// wrapper. Defaults to CommandName.
+	Executable string
+
+	// NonDownloadFlags only print information and never resolve
+	// dependencies. Any other invocation runs a build, which resolves the
+	// dependencies and plugins declared in pom.xml / build.gradle.
+	NonDownloadFlags []string
+
+	// DisableDaemon turns the Gradle daemon off for proxied runs. A daemon
+	// outlives the run and would keep the proxy settings of a proxy that no
+	// longer exists.
+	DisableDaemon bool
+}
+
+func DefaultMavenPackageManagerConfig() MavenPackageManagerConfig {
+	return MavenPackageManagerConfig{
+		CommandName:      "mvn",
+		NonDownloadFlags: []string{"-v", "--version", "-h", "--help"},
+	}
+}
+
+func DefaultGradlePackageManagerConfig() MavenPackageManagerConfig {
+	return MavenPackageManagerConfig{
+		CommandName:      "gradle",
+		NonDownloadFlags: []string{"-v", "--version", "-h", "-?", "--help", "--stop", "--status"},
+		DisableDaemon:    true,
+	}
+}
+
+func DefaultGradleWrapperPackageManagerConfig() MavenPackageManagerConfig {
+	config := DefaultGradlePackageManagerConfig()
+	config.CommandName = "gradlew"
+	config.Executable = "./gradlew"
+	return config
+}
+
+type mavenPackageManager struct {
+	Config MavenPackageManagerConfig
+}
+
+func NewMavenPackageManager(config MavenPackageManagerConfig) (*mavenPackageManager, error) {
+	if config.CommandName == "" {
+		return nil, fmt.Errorf("maven package manager command name is required")
+	}
+
+	if config.Executable == "" {
+		config.Executable = config.CommandName
+	}
+
+	return &mavenPackageManager{Config: config}, nil
+}
+
+var _ PackageManager = &mavenPackageManager{}
+
+func (m *mavenPackageManager) Name() string {
+	return m.Config.CommandName
+}
+
+func (m *mavenPackageManager) Ecosystem() packagev1.Ecosystem {
+	return packagev1.Ecosystem_ECOSYSTEM_MAVEN
+}
+
+func (m *mavenPackageManager) ParseCommand(args []string) (*ParsedCommand, error) {
+	if len(args) > 0 && (args[0] == m.Config.CommandName || args[0] == m.Config.Executable) {
+		args = args[1:]
+	}
+
+	parsed := &ParsedCommand{Command: Command{Exe: m.Config.Executable, Args: args}}
+
+	if slices.ContainsFunc(args, func(arg string) bool {
+		return slices.Contains(m.Config.NonDownloadFlags, arg)
+	}) {
+		parsed.IsKnownNonDownloadCommand = true
+		return parsed, nil
+	}
+
+	if m.Config.CommandName == "mvn" {
+		parsed.InstallTargets = mavenDependencyGetTargets(args)
+	}
+
+	// Builds resolve whatever the project declares, and `mvn` with no goals
+	// still downloads the plugins it needs to report the missing goal.
+	if len(parsed.InstallTargets) == 0 {
+		parsed.IsManifestInstall = true
+	}
+
+	return parsed, nil
+}
+
+// mavenDependencyGetTargets extracts the artifact of
+// `mvn dependency:get -Dartifact=groupId:artifactId:version[:packaging[:classifier]]`,
+// the only Maven invocation that names a package on the command line.
+func mavenDependencyGetTargets(args []string) []*PackageInstallTarget {
+	isGet := slices.ContainsFunc(args, func(arg string) bool {
+		return arg == "dependency:get" ||
+			strings.Contains(arg, "maven-dependency-plugin") && strings.HasSuffix(arg, ":get")
+	})
+	if !isGet {
+		return nil
+	}
+
+	for i, arg := range args {
+		value, ok := strings.CutPrefix(arg, "-Dartifact=")
+		if !ok && arg == "-D" && i+1 < len(args) {
+			value, ok = strings.CutPrefix(args[i+1], "artifact=")
+		}
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(value, ":")
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil
+		}
+
+		version := ""
+		if len(parts) >= 3 {
+			version = parts[2]
+		}
+
+		return []*PackageInstallTarget{{
+			PackageVersion: &packagev1.PackageVersion{
+				Package: &packagev1.Package{
+					Ecosystem: packagev1.Ecosystem_ECOSYSTEM_MAVEN,
+					Name:      parts[0] + ":" + parts[1],
+				},
+				Version: version,
+			},
+			// Maven version ranges are bracketed; anything else is a pin.
+			IsExplicitVersion: version != "" && !strings.ContainsAny(version, "[]()"),
+		}}
+	}
+
+	return nil
+}
+
+var _ ProxyRoutingProvider = &mavenPackageManager{}
+
+// ProxyRouting turns the Gradle daemon off for the run. The proxy and
+// truststore themselves are JVM system properties injected through
+// JAVA_TOOL_OPTIONS by EnvVarForJavaProxy.
+func (m *mavenPackageManager) ProxyRouting(_ context.Context) (*ProxyRouting, error) {
+	routing := &ProxyRouting{}
+	if m.Config.DisableDaemon {
+		routing.ExtraEnv = append(routing.ExtraEnv,
+			"GRADLE_OPTS="+appendJVMOptions(os.Getenv("GRADLE_OPTS"), "-Dorg.gradle.daemon=false"))
+	}
+
+	return routing, nil
+}
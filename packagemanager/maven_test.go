@@ -0,0 +1,121 @@
+package packagemanager
+
+import (
+	"context"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMavenPackageManagerParseCommand(t *testing.T) {
+	type target struct {
+		name     string
+		version  string
+		explicit bool
+	}
+
+	cases := []struct {
+		name            string
+		config          MavenPackageManagerConfig
+		args            []string
+		exe             string
+		nonDownload     bool
+		manifestInstall bool
+		targets         []target
+	}{
+		{
+			name:            "mvn build is manifest install",
+			config:          DefaultMavenPackageManagerConfig(),
+			args:            []string{"mvn", "-B", "clean", "package"},
+			exe:             "mvn",
+			manifestInstall: true,
+		},
+		{
+			name:        "mvn version is non-download",
+			config:      DefaultMavenPackageManagerConfig(),
+			args:        []string{"mvn", "--version"},
+			exe:         "mvn",
+			nonDownload: true,
+		},
+		{
+			name:    "mvn dependency:get with version",
+			config:  DefaultMavenPackageManagerConfig(),
+			args:    []string{"mvn", "dependency:get", "-Dartifact=org.slf4j:slf4j-api:2.0.9"},
+			exe:     "mvn",
+			targets: []target{{name: "org.slf4j:slf4j-api", version: "2.0.9", explicit: true}},
+		},
+		{
+			name:    "mvn dependency:get with version range",
+			config:  DefaultMavenPackageManagerConfig(),
+			args:    []string{"mvn", "org.apache.maven.plugins:maven-dependency-plugin:3.6.1:get", "-Dartifact=com.google.guava:guava:[32,)"},
+			exe:     "mvn",
+			targets: []target{{name: "com.google.guava:guava", version: "[32,)"}},
+		},
+		{
+			name:            "gradle build is manifest install",
+			config:          DefaultGradlePackageManagerConfig(),
+			args:            []string{"gradle", "build"},
+			exe:             "gradle",
+			manifestInstall: true,
+		},
+		{
+			name:        "gradle --stop is non-download",
+			config:      DefaultGradlePackageManagerConfig(),
+			args:        []string{"gradle", "--stop"},
+			exe:         "gradle",
+			nonDownload: true,
+		},
+		{
+			name:            "gradle wrapper runs ./gradlew",
+			config:          DefaultGradleWrapperPackageManagerConfig(),
+			args:            []string{"./gradlew", "dependencies"},
+			exe:             "./gradlew",
+			manifestInstall: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pm, err := NewMavenPackageManager(tc.config)
+			require.NoError(t, err)
+			assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_MAVEN, pm.Ecosystem())
+
+			parsed, err := pm.ParseCommand(tc.args)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.exe, parsed.Command.Exe)
+			assert.Equal(t, tc.args[1:], parsed.Command.Args)
+			assert.Equal(t, tc.nonDownload, parsed.IsKnownNonDownloadCommand)
+			assert.Equal(t, tc.manifestInstall, parsed.IsManifestInstall)
+
+			require.Len(t, parsed.InstallTargets, len(tc.targets))
+			for i, want := range tc.targets {
+				got := parsed.InstallTargets[i]
+				assert.Equal(t, want.name, got.PackageVersion.GetPackage().GetName())
+				assert.Equal(t, want.version, got.PackageVersion.GetVersion())
+				assert.Equal(t, want.explicit, got.IsExplicitVersion)
+			}
+		})
+	}
+}
+
+func TestMavenPackageManagerProxyRouting(t *testing.T) {
+	t.Setenv("GRADLE_OPTS", "-Xmx1g")
+
+	gradle, err := NewMavenPackageManager(DefaultGradlePackageManagerConfig())
+	require.NoError(t, err)
+
+	routing, err := gradle.ProxyRouting(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GRADLE_OPTS=-Xmx1g -Dorg.gradle.daemon=false"}, routing.ExtraEnv,
+		"a daemon outlives the run and would keep a stale proxy configuration")
+
+	mvn, err := NewMavenPackageManager(DefaultMavenPackageManagerConfig())
+	require.NoError(t, err)
+
+	routing, err = mvn.ProxyRouting(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, routing.ExtraEnv)
+}
@@ -0,0 +1,242 @@
+package packagemanager
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/spf13/pflag"
+)
+
+type NuGetPackageManagerConfig struct {
+	CommandName string
+
+	// ManifestInstallCommands restore the packages a project, solution or
+	// packages.config declares.
+	ManifestInstallCommands []string
+
+	// NonDownloadCommands never download packages. Anything not listed
+	// runs with the proxy.
+	NonDownloadCommands []string
+}
+
+func DefaultDotnetPackageManagerConfig() NuGetPackageManagerConfig {
+	return NuGetPackageManagerConfig{
+		CommandName: "dotnet",
+		// Building, running, testing and packing restore implicitly.
+		ManifestInstallCommands: []string{"restore", "build", "run", "test", "publish", "pack"},
+		NonDownloadCommands: []string{
+			"clean", "sln", "list", "remove", "format", "help", "nuget",
+			"dev-certs", "sdk", "--info", "--version", "--list-sdks", "--list-runtimes",
+			"-h", "--help", "-?",
+		},
+	}
+}
+
+func DefaultNuGetPackageManagerConfig() NuGetPackageManagerConfig {
+	return NuGetPackageManagerConfig{
+		CommandName:             "nuget",
+		ManifestInstallCommands: []string{"restore", "update"},
+		NonDownloadCommands: []string{
+			"help", "?", "locals", "sources", "config", "setapikey", "spec", "pack",
+			"push", "delete", "add", "init", "verify", "sign", "trusted-signers", "client-certs",
+		},
+	}
+}
+
+type nugetPackageManager struct {
+	Config NuGetPackageManagerConfig
+}
+
+func NewNuGetPackageManager(config NuGetPackageManagerConfig) (*nugetPackageManager, error) {
+	switch config.CommandName {
+	case "dotnet", "nuget":
+	default:
+		return nil, fmt.Errorf("unsupported package manager: %s", config.CommandName)
+	}
+
+	return &nugetPackageManager{Config: config}, nil
+}
+
+var _ PackageManager = &nugetPackageManager{}
+
+func (n *nugetPackageManager) Name() string {
+	return n.Config.CommandName
+}
+
+func (n *nugetPackageManager) Ecosystem() packagev1.Ecosystem {
+	return packagev1.Ecosystem_ECOSYSTEM_NUGET
+}
+
+func (n *nugetPackageManager) ParseCommand(args []string) (*ParsedCommand, error) {
+	if len(args) > 0 && args[0] == n.Config.CommandName {
+		args = args[1:]
+	}
+
+	parsed := &ParsedCommand{Command: Command{Exe: n.Config.CommandName, Args: args}}
+	if len(args) == 0 {
+		parsed.IsKnownNonDownloadCommand = true
+		return parsed, nil
+	}
+
+	// nuget.exe commands and options are case-insensitive.
+	subcmd, rest := args[0], args[1:]
+	if n.Config.CommandName == "nuget" {
+		subcmd = strings.ToLower(subcmd)
+	}
+
+	switch {
+	case slices.Contains(n.Config.NonDownloadCommands, subcmd):
+		parsed.IsKnownNonDownloadCommand = true
+	case slices.Contains(n.Config.ManifestInstallCommands, subcmd):
+		if slices.Contains(rest, "--no-restore") {
+			parsed.IsKnownNonDownloadCommand = true
+		} else {
+			parsed.IsManifestInstall = true
+		}
+	case n.Config.CommandName == "nuget" && subcmd == "install":
+		parsed.InstallTargets, parsed.IsManifestInstall = nugetInstallTargets(rest)
+	case n.Config.CommandName == "dotnet":
+		n.parseDotnetCommand(parsed, subcmd, rest)
+	}
+
+	return parsed, nil
+}
+
+// parseDotnetCommand handles the dotnet commands that name packages:
+//
+//	dotnet add [<PROJECT>] package <ID>
+//	dotnet package add <ID>             (.NET 10)
+//	dotnet tool install|update <ID>
+//	dotnet tool restore
+func (n *nugetPackageManager) parseDotnetCommand(parsed *ParsedCommand, subcmd string, rest []string) {
+	switch subcmd {
+	case "add":
+		// `dotnet add reference` edits project references only.
+		i := slices.Index(rest, "package")
+		if i < 0 {
+			parsed.IsKnownNonDownloadCommand = true
+			return
+		}
+		parsed.InstallTargets = dotnetInstallTargets(rest[i+1:], true)
+	case "package":
+		if len(rest) > 0 && rest[0] == "add" {
+			parsed.InstallTargets = dotnetInstallTargets(rest[1:], true)
+		}
+	case "tool":
+		if len(rest) == 0 {
+			return
+		}
+		switch rest[0] {
+		case "install", "update":
+			parsed.InstallTargets = dotnetInstallTargets(rest[1:], false)
+		case "restore":
+			parsed.IsManifestInstall = true
+		case "list", "uninstall", "search", "run":
+			parsed.IsKnownNonDownloadCommand = true
+		}
+	}
+}
+
+// dotnetInstallTargets extracts the package named after `dotnet add package`
+// or `dotnet tool install`, with its --version. -v is --version for
+// `dotnet add package` but --verbosity for `dotnet tool`.
+func dotnetInstallTargets(args []string, versionShorthand bool) []*PackageInstallTarget {
+	var version string
+
+	flagSet := pflag.NewFlagSet("dotnet", pflag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.ParseErrorsAllowlist.UnknownFlags = true
+	if versionShorthand {
+		flagSet.StringVarP(&version, "version", "v", "", "")
+		flagSet.StringArray("verbosity", nil, "")
+	} else {
+		flagSet.StringVar(&version, "version", "", "")
+		flagSet.StringArrayP("verbosity", "v", nil, "")
+	}
+	flagSet.StringArrayP("framework", "f", nil, "")
+	flagSet.StringArrayP("source", "s", nil, "")
+	flagSet.StringArrayP("arch", "a", nil, "")
+	for _, name := range []string{"package-directory", "project", "add-source", "configfile", "tool-path", "tool-manifest"} {
+		flagSet.StringArray(name, nil, "")
+	}
+
+	// Boolean flags are declared so UnknownFlags mode does not swallow the
+	// package id that follows them.
+	for _, name := range []string{
+		"prerelease", "interactive", "local", "create-manifest-if-needed",
+		"allow-downgrade", "allow-roll-forward", "disable-parallel",
+		"ignore-failed-sources", "no-cache", "no-http-cache",
+	} {
+		flagSet.Bool(name, false, "")
+	}
+	flagSet.BoolP("no-restore", "n", false, "")
+	flagSet.BoolP("global", "g", false, "")
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil
+	}
+
+	// Both commands take exactly one id; dotnet rejects anything after it.
+	ids := flagSet.Args()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return []*PackageInstallTarget{nugetInstallTarget(ids[0], version)}
+}
+
+// nugetInstallValueOptions are the nuget.exe install options that take a
+// value. nuget.exe options are single-dash and case-insensitive, which pflag
+// does not support, so install args are scanned by hand.
+var nugetInstallValueOptions = []string{
+	"version", "source", "outputdirectory", "o", "configfile", "fallbacksource",
+	"framework", "solutiondirectory", "dependencyversion", "packagesavemode",
+	"verbosity", "msbuildversion", "msbuildpath",
+}
+
+// nugetInstallTargets extracts the package of `nuget install <id>`. Installing
+// from a packages.config file is a manifest install.
+func nugetInstallTargets(args []string) ([]*PackageInstallTarget, bool) {
+	var id, version string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			name := strings.ToLower(strings.TrimLeft(arg, "-"))
+			if slices.Contains(nugetInstallValueOptions, name) && i+1 < len(args) {
+				if name == "version" {
+					version = args[i+1]
+				}
+				i++
+			}
+			continue
+		}
+
+		if id == "" {
+			id = arg
+		}
+	}
+
+	if id == "" || strings.HasSuffix(strings.ToLower(id), ".config") {
+		return nil, true
+	}
+
+	return []*PackageInstallTarget{nugetInstallTarget(id, version)}, false
+}
+
+func nugetInstallTarget(id, version string) *PackageInstallTarget {
+	return &PackageInstallTarget{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{
+				Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NUGET,
+				Name:      id,
+			},
+			Version: version,
+		},
+		// Version ranges are bracketed and floating versions use '*';
+		// anything else is a pin.
+		IsExplicitVersion: version != "" && !strings.ContainsAny(version, "[]()*,"),
+	}
+}
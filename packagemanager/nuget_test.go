@@ -0,0 +1,139 @@
+package packagemanager
+
+import (
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNuGetPackageManagerParseCommand(t *testing.T) {
+	type target struct {
+		name     string
+		version  string
+		explicit bool
+	}
+
+	cases := []struct {
+		name            string
+		config          NuGetPackageManagerConfig
+		args            []string
+		nonDownload     bool
+		manifestInstall bool
+		targets         []target
+	}{
+		{
+			name:    "dotnet add package without version",
+			config:  DefaultDotnetPackageManagerConfig(),
+			args:    []string{"dotnet", "add", "package", "Newtonsoft.Json"},
+			targets: []target{{name: "Newtonsoft.Json"}},
+		},
+		{
+			name:    "dotnet add package to project with exact version",
+			config:  DefaultDotnetPackageManagerConfig(),
+			args:    []string{"dotnet", "add", "src/App.csproj", "package", "Serilog", "-v", "3.1.1", "--prerelease"},
+			targets: []target{{name: "Serilog", version: "3.1.1", explicit: true}},
+		},
+		{
+			name:    "dotnet add package with floating version is not explicit",
+			config:  DefaultDotnetPackageManagerConfig(),
+			args:    []string{"dotnet", "add", "package", "Serilog", "--version", "3.*"},
+			targets: []target{{name: "Serilog", version: "3.*"}},
+		},
+		{
+			name:    "dotnet package add",
+			config:  DefaultDotnetPackageManagerConfig(),
+			args:    []string{"dotnet", "package", "add", "Polly", "--version", "8.2.0"},
+			targets: []target{{name: "Polly", version: "8.2.0", explicit: true}},
+		},
+		{
+			name:    "dotnet tool install treats -v as verbosity",
+			config:  DefaultDotnetPackageManagerConfig(),
+			args:    []string{"dotnet", "tool", "install", "-g", "-v", "q", "dotnet-ef"},
+			targets: []target{{name: "dotnet-ef"}},
+		},
+		{
+			name:        "dotnet add reference is non-download",
+			config:      DefaultDotnetPackageManagerConfig(),
+			args:        []string{"dotnet", "add", "reference", "../Lib/Lib.csproj"},
+			nonDownload: true,
+		},
+		{
+			name:            "dotnet restore is manifest install",
+			config:          DefaultDotnetPackageManagerConfig(),
+			args:            []string{"dotnet", "restore", "App.sln"},
+			manifestInstall: true,
+		},
+		{
+			name:            "dotnet build restores implicitly",
+			config:          DefaultDotnetPackageManagerConfig(),
+			args:            []string{"dotnet", "build", "-c", "Release"},
+			manifestInstall: true,
+		},
+		{
+			name:        "dotnet build without restore is non-download",
+			config:      DefaultDotnetPackageManagerConfig(),
+			args:        []string{"dotnet", "build", "--no-restore"},
+			nonDownload: true,
+		},
+		{
+			name:        "dotnet --info is non-download",
+			config:      DefaultDotnetPackageManagerConfig(),
+			args:        []string{"dotnet", "--info"},
+			nonDownload: true,
+		},
+		{
+			name:    "nuget install with version",
+			config:  DefaultNuGetPackageManagerConfig(),
+			args:    []string{"nuget", "install", "NUnit", "-Version", "3.14.0", "-OutputDirectory", "packages"},
+			targets: []target{{name: "NUnit", version: "3.14.0", explicit: true}},
+		},
+		{
+			name:            "nuget install packages.config is manifest install",
+			config:          DefaultNuGetPackageManagerConfig(),
+			args:            []string{"nuget", "Install", "packages.config", "-Source", "https://api.nuget.org/v3/index.json"},
+			manifestInstall: true,
+		},
+		{
+			name:            "nuget restore is manifest install",
+			config:          DefaultNuGetPackageManagerConfig(),
+			args:            []string{"nuget", "restore", "App.sln"},
+			manifestInstall: true,
+		},
+		{
+			name:        "nuget locals is non-download",
+			config:      DefaultNuGetPackageManagerConfig(),
+			args:        []string{"nuget", "locals", "all", "-clear"},
+			nonDownload: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pm, err := NewNuGetPackageManager(tc.config)
+			require.NoError(t, err)
+			assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_NUGET, pm.Ecosystem())
+
+			parsed, err := pm.ParseCommand(tc.args)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.nonDownload, parsed.IsKnownNonDownloadCommand)
+			assert.Equal(t, tc.manifestInstall, parsed.IsManifestInstall)
+
+			require.Len(t, parsed.InstallTargets, len(tc.targets))
+			for i, want := range tc.targets {
+				got := parsed.InstallTargets[i]
+				assert.Equal(t, want.name, got.PackageVersion.GetPackage().GetName())
+				assert.Equal(t, want.version, got.PackageVersion.GetVersion())
+				assert.Equal(t, want.explicit, got.IsExplicitVersion)
+				assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_NUGET, got.PackageVersion.GetPackage().GetEcosystem())
+			}
+		})
+	}
+}
+
+func TestNewNuGetPackageManagerRejectsUnknownCommand(t *testing.T) {
+	_, err := NewNuGetPackageManager(NuGetPackageManagerConfig{CommandName: "msbuild"})
+	assert.Error(t, err)
+}
@@ -1,6 +1,11 @@
 package packagemanager
 
-import "fmt"
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
 
 // proxyNoProxyList is the NO_PROXY value for proxied package-manager runs.
 //
@@ -14,8 +19,9 @@ const proxyNoProxyList = "localhost,127.0.0.1,::1"
 // EnvVarForProxy returns the environment variables (KEY=VALUE lines) that route
 // the supported package managers through the proxy at proxyAddr and make them
 // trust its MITM CA at certPath. It encodes per-package-manager quirks: yarn
-// Berry ignores HTTP_PROXY and needs YARN_* (#319); pip/requests and Node each
-// read their own CA-bundle var.
+// Berry ignores HTTP_PROXY and needs YARN_* (#319); pip/requests, Node and
+// cargo each read their own CA-bundle var, and CARGO_HTTP_PROXY overrides an
+// http.proxy set in the user's .cargo/config.toml.
 //
 // The cert-path variables are always emitted, never skipped based on OS
 // trust-store status. Whether a tool trusts the OS store varies by tool,
@@ -40,10 +46,64 @@ func EnvVarForProxy(proxyAddr, certPath string) []string {
 		fmt.Sprintf("PIP_PROXY=%s", proxyURL),
 		fmt.Sprintf("YARN_HTTP_PROXY=%s", proxyURL),
 		fmt.Sprintf("YARN_HTTPS_PROXY=%s", proxyURL),
+		fmt.Sprintf("CARGO_HTTP_PROXY=%s", proxyURL),
 		fmt.Sprintf("NODE_EXTRA_CA_CERTS=%s", certPath),
 		fmt.Sprintf("SSL_CERT_FILE=%s", certPath),
 		fmt.Sprintf("REQUESTS_CA_BUNDLE=%s", certPath),
 		fmt.Sprintf("PIP_CERT=%s", certPath),
 		fmt.Sprintf("YARN_HTTPS_CA_FILE_PATH=%s", certPath),
+		fmt.Sprintf("CARGO_HTTP_CAINFO=%s", certPath),
 	}
 }
+
+// EnvVarForJavaProxy returns the JAVA_TOOL_OPTIONS entry that routes a JVM
+// package manager (Maven, Gradle) through the proxy at proxyAddr. The JVM
+// ignores HTTPS_PROXY and SSL_CERT_FILE, so the proxy is set with the
+// standard networking system properties and the MITM CA is trusted through a
+// JKS truststore at trustStorePath. Maven's resolver ignores the proxy
+// properties unless told to use system properties.
+//
+// Options already in the user's JAVA_TOOL_OPTIONS are kept; PMG's come last
+// so they win over any proxy or truststore the user configured.
+func EnvVarForJavaProxy(proxyAddr, trustStorePath, trustStorePassword string) []string {
+	host, port, err := net.SplitHostPort(proxyAddr)
+	if err != nil {
+		host, port = proxyAddr, ""
+	}
+
+	options := []string{
+		"-Dhttp.proxyHost=" + host,
+		"-Dhttp.proxyPort=" + port,
+		"-Dhttps.proxyHost=" + host,
+		"-Dhttps.proxyPort=" + port,
+		"-Daether.connector.http.useSystemProperties=true",
+		"-Djavax.net.ssl.trustStore=" + quoteJVMOption(trustStorePath),
+		"-Djavax.net.ssl.trustStorePassword=" + trustStorePassword,
+		"-Djavax.net.ssl.trustStoreType=JKS",
+	}
+
+	return []string{
+		"JAVA_TOOL_OPTIONS=" + appendJVMOptions(os.Getenv("JAVA_TOOL_OPTIONS"), options...),
+	}
+}
+
+// appendJVMOptions appends options to an existing JVM options string such as
+// JAVA_TOOL_OPTIONS or GRADLE_OPTS.
+func appendJVMOptions(existing string, options ...string) string {
+	parts := make([]string, 0, len(options)+1)
+	if existing = strings.TrimSpace(existing); existing != "" {
+		parts = append(parts, existing)
+	}
+
+	return strings.Join(append(parts, options...), " ")
+}
+
+// quoteJVMOption quotes a value containing whitespace. The JVM splits
+// JAVA_TOOL_OPTIONS on whitespace but honors double quotes.
+func quoteJVMOption(value string) string {
+	if !strings.ContainsAny(value, " \t") {
+		return value
+	}
+
+	return `"` + value + `"`
+}
@@ -60,8 +60,40 @@ func TestEnvVarForProxyAlwaysEmitsCertVars(t *testing.T) {
 	assert.Equal(t, "http://127.0.0.1:9000", env["HTTP_PROXY"])
 	for _, key := range []string{
 		"NODE_EXTRA_CA_CERTS", "SSL_CERT_FILE", "REQUESTS_CA_BUNDLE",
-		"PIP_CERT", "YARN_HTTPS_CA_FILE_PATH",
+		"PIP_CERT", "YARN_HTTPS_CA_FILE_PATH", "CARGO_HTTP_CAINFO",
 	} {
 		assert.Equal(t, "/tmp/ca.pem", env[key], "%s must point at the CA bundle", key)
 	}
 }
+
+// TestEnvVarForProxyConfiguresCargo proves cargo is routed through the proxy
+// even when the user's .cargo/config.toml sets http.proxy, and that it trusts
+// the MITM CA through its own CA-bundle setting.
+func TestEnvVarForProxyConfiguresCargo(t *testing.T) {
+	env := envToMap(EnvVarForProxy("127.0.0.1:9000", "/tmp/ca.pem"))
+
+	assert.Equal(t, "http://127.0.0.1:9000", env["CARGO_HTTP_PROXY"])
+	assert.Equal(t, "/tmp/ca.pem", env["CARGO_HTTP_CAINFO"])
+}
+
+// TestEnvVarForJavaProxy proves Java build tools get the proxy and the MITM CA
+// through JVM system properties, since the JVM ignores HTTPS_PROXY and
+// SSL_CERT_FILE, and that options the user already set are kept.
+func TestEnvVarForJavaProxy(t *testing.T) {
+	t.Setenv("JAVA_TOOL_OPTIONS", "-Xmx2g")
+
+	env := envToMap(EnvVarForJavaProxy("127.0.0.1:9000", "/tmp/pmg dir/proxy-ca.jks", "changeit"))
+	options := env["JAVA_TOOL_OPTIONS"]
+
+	assert.True(t, strings.HasPrefix(options, "-Xmx2g "), "existing JAVA_TOOL_OPTIONS must be preserved")
+	for _, option := range []string{
+		"-Dhttps.proxyHost=127.0.0.1",
+		"-Dhttps.proxyPort=9000",
+		"-Dhttp.proxyHost=127.0.0.1",
+		"-Daether.connector.http.useSystemProperties=true",
+		`-Djavax.net.ssl.trustStore="/tmp/pmg dir/proxy-ca.jks"`,
+		"-Djavax.net.ssl.trustStorePassword=changeit",
+	} {
+		assert.Contains(t, options, option)
+	}
+}
@@ -236,12 +236,15 @@ func (u *uvCommandParser) ParseCommand(args []string) (*ParsedCommand, error) {
 		}, nil
 	}
 
+	// The uv pip interface installs from requirements.txt style files, never
+	// from the project's uv.lock, so it is not a manifest install.
+	pipInterface := args[0] == "pip"
+
 	// Handles pip sync command (installs from requirements.txt style files)
-	if len(args) >= 3 && args[0] == "pip" && args[1] == "sync" {
+	if len(args) >= 3 && pipInterface && args[1] == "sync" {
 		return &ParsedCommand{
-			Command:           command,
-			InstallTargets:    nil,
-			IsManifestInstall: true,
+			Command:        command,
+			InstallTargets: nil,
 		}, nil
 	}
 
@@ -284,7 +287,7 @@ func (u *uvCommandParser) ParseCommand(args []string) (*ParsedCommand, error) {
 	packages := flagSet.Args()
 
 	// Determine if this is a manifest install
-	isManifestInstall := len(manifestFiles) > 0
+	isManifestInstall := len(manifestFiles) > 0 && !pipInterface
 
 	var installTargets []*PackageInstallTarget
 	for _, pkg := range packages {
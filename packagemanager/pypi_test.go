@@ -699,17 +699,25 @@ func TestUvParseCommand(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:             "uv pip install from manifest file",
+			name:             "uv pip install from requirements file is not a uv.lock install",
 			args:             []string{"pip", "install", "-r", "requirements.txt"},
-			expectedManifest: true,
+			expectedManifest: false,
 			expectedTargets:  0,
 			expectedPackages: []string{},
 			wantErr:          false,
 		},
 		{
-			name:             "uv pip install from multiple manifest files",
+			name:             "uv pip install from multiple requirements files",
 			args:             []string{"pip", "install", "-r", "requirements.txt", "-r", "dev-requirements.txt"},
-			expectedManifest: true,
+			expectedManifest: false,
+			expectedTargets:  0,
+			expectedPackages: []string{},
+			wantErr:          false,
+		},
+		{
+			name:             "uv pip sync from requirements file",
+			args:             []string{"pip", "sync", "requirements.txt"},
+			expectedManifest: false,
 			expectedTargets:  0,
 			expectedPackages: []string{},
 			wantErr:          false,
@@ -0,0 +1,255 @@
+package packagemanager
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/spf13/pflag"
+)
+
+type RubyGemsPackageManagerConfig struct {
+	CommandName string
+
+	// InstallCommands accept gem names on the command line.
+	InstallCommands []string
+
+	// ManifestInstallCommands resolve and download the gems declared in the
+	// Gemfile / Gemfile.lock.
+	ManifestInstallCommands []string
+
+	// NonDownloadCommands never contact the gem source. Anything not listed
+	// runs with the proxy.
+	NonDownloadCommands []string
+}
+
+func DefaultGemPackageManagerConfig() RubyGemsPackageManagerConfig {
+	return RubyGemsPackageManagerConfig{
+		CommandName:     "gem",
+		InstallCommands: []string{"install", "i", "update"},
+		NonDownloadCommands: []string{
+			// Removal and local maintenance
+			"uninstall", "cleanup", "pristine", "build",
+			// Inspection of installed gems
+			"list", "contents", "which", "environment", "env", "help", "check",
+		},
+	}
+}
+
+func DefaultBundlePackageManagerConfig() RubyGemsPackageManagerConfig {
+	return RubyGemsPackageManagerConfig{
+		CommandName:             "bundle",
+		InstallCommands:         []string{"add"},
+		ManifestInstallCommands: []string{"install", "update", "lock", "cache", "package", "binstubs"},
+		NonDownloadCommands: []string{
+			"remove", "clean", "check", "list", "show", "info", "config",
+			"help", "version", "init", "platform", "doctor",
+		},
+	}
+}
+
+type rubyGemsPackageManager struct {
+	Config RubyGemsPackageManagerConfig
+}
+
+func NewRubyGemsPackageManager(config RubyGemsPackageManagerConfig) (*rubyGemsPackageManager, error) {
+	switch config.CommandName {
+	case "gem", "bundle":
+	default:
+		return nil, fmt.Errorf("unsupported package manager: %s", config.CommandName)
+	}
+
+	return &rubyGemsPackageManager{Config: config}, nil
+}
+
+var _ PackageManager = &rubyGemsPackageManager{}
+
+func (r *rubyGemsPackageManager) Name() string {
+	return r.Config.CommandName
+}
+
+func (r *rubyGemsPackageManager) Ecosystem() packagev1.Ecosystem {
+	return packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS
+}
+
+func (r *rubyGemsPackageManager) ParseCommand(args []string) (*ParsedCommand, error) {
+	if len(args) > 0 && args[0] == r.Config.CommandName {
+		args = args[1:]
+	}
+
+	parsed := &ParsedCommand{Command: Command{Exe: r.Config.CommandName, Args: args}}
+
+	subcmd, rest := rubyGemsSubcommand(args)
+	if subcmd == "" {
+		// A bare `bundle` is `bundle install`.
+		parsed.IsManifestInstall = r.Config.CommandName == "bundle"
+		return parsed, nil
+	}
+
+	switch {
+	case slices.Contains(r.Config.NonDownloadCommands, subcmd):
+		parsed.IsKnownNonDownloadCommand = true
+	case slices.Contains(r.Config.ManifestInstallCommands, subcmd):
+		parsed.IsManifestInstall = true
+	case slices.Contains(r.Config.InstallCommands, subcmd):
+		if r.Config.CommandName == "bundle" {
+			parsed.InstallTargets = bundleAddTargets(rest)
+		} else {
+			parsed.InstallTargets = gemInstallTargets(rest)
+		}
+
+		// `gem update` with no names updates every installed gem, and
+		// `gem install -g Gemfile` installs from a manifest.
+		if len(parsed.InstallTargets) == 0 {
+			parsed.IsManifestInstall = true
+		}
+	}
+
+	return parsed, nil
+}
+
+func rubyGemsSubcommand(args []string) (string, []string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		return arg, args[i+1:]
+	}
+	return "", nil
+}
+
+// rubyGemsExactVersionPattern matches a plain gem version, as opposed to a
+// requirement such as "~> 7.1" or ">= 1.0".
+var rubyGemsExactVersionPattern = regexp.MustCompile(`^\d+(?:\.[0-9A-Za-z]+)*$`)
+
+// rubyGemsExactVersion returns the version pinned by a requirement string:
+// a plain version or "= x.y.z". Anything else is not a pin.
+func rubyGemsExactVersion(requirement string) (string, bool) {
+	v := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(requirement), "="))
+	return v, rubyGemsExactVersionPattern.MatchString(v)
+}
+
+func newRubyGemsFlagSet(name string) *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.ParseErrorsAllowlist.UnknownFlags = true
+	return flagSet
+}
+
+// gemInstallTargets extracts gem targets from `gem install` args. Versions
+// come from `name:version` or -v/--version; a requirement like "~> 7.1" is
+// kept as the version but is not explicit.
+func gemInstallTargets(args []string) []*PackageInstallTarget {
+	var versionFlag, gemfile string
+
+	flagSet := newRubyGemsFlagSet("gem")
+	flagSet.StringVarP(&versionFlag, "version", "v", "", "")
+	flagSet.StringVarP(&gemfile, "file", "g", "", "")
+	flagSet.StringArrayP("source", "s", nil, "")
+	flagSet.StringArrayP("install-dir", "i", nil, "")
+	flagSet.StringArrayP("bindir", "n", nil, "")
+	flagSet.StringArray("platform", nil, "")
+	flagSet.StringArray("document", nil, "")
+
+	// Boolean flags are declared so UnknownFlags mode does not swallow the
+	// gem name that follows them.
+	for _, name := range []string{
+		"user-install", "no-user-install", "conservative",
+		"pre", "prerelease", "minimal-deps", "development", "development-all",
+		"ignore-dependencies", "explain", "lock", "no-lock", "default",
+		"both", "clear-sources", "env-shebang", "format-executable",
+		"no-suggestions", "post-install-message", "wrappers", "system",
+	} {
+		flagSet.Bool(name, false, "")
+	}
+	flagSet.BoolP("no-document", "N", false, "")
+	flagSet.BoolP("force", "f", false, "")
+	flagSet.BoolP("local", "l", false, "")
+	flagSet.BoolP("remote", "r", false, "")
+	flagSet.BoolP("quiet", "q", false, "")
+	flagSet.BoolP("verbose", "V", false, "")
+
+	if err := flagSet.Parse(args); err != nil || gemfile != "" {
+		return nil
+	}
+
+	var targets []*PackageInstallTarget
+	for _, arg := range flagSet.Args() {
+		// A local .gem file is not downloaded from the gem source.
+		if strings.HasSuffix(arg, ".gem") {
+			continue
+		}
+
+		name, version, _ := strings.Cut(arg, ":")
+		if name == "" {
+			continue
+		}
+		if version == "" {
+			version = versionFlag
+		}
+
+		exact, explicit := rubyGemsExactVersion(version)
+		if explicit {
+			version = exact
+		}
+
+		targets = append(targets, rubyGemsInstallTarget(name, version, explicit))
+	}
+
+	return targets
+}
+
+// bundleAddTargets extracts gem targets from `bundle add` args. Gems from a
+// git source or local path are skipped since they are not downloaded from
+// the gem source.
+func bundleAddTargets(args []string) []*PackageInstallTarget {
+	var versionFlag, gitFlag, githubFlag, pathFlag string
+
+	flagSet := newRubyGemsFlagSet("bundle")
+	flagSet.StringVarP(&versionFlag, "version", "v", "", "")
+	flagSet.StringVar(&gitFlag, "git", "", "")
+	flagSet.StringVar(&githubFlag, "github", "", "")
+	flagSet.StringVar(&pathFlag, "path", "", "")
+	flagSet.StringArrayP("group", "g", nil, "")
+	flagSet.StringArrayP("source", "s", nil, "")
+	flagSet.StringArrayP("require", "r", nil, "")
+	for _, name := range []string{"branch", "ref", "glob"} {
+		flagSet.StringArray(name, nil, "")
+	}
+	for _, name := range []string{"skip-install", "strict", "optimistic", "quiet"} {
+		flagSet.Bool(name, false, "")
+	}
+
+	if err := flagSet.Parse(args); err != nil || gitFlag != "" || githubFlag != "" || pathFlag != "" {
+		return nil
+	}
+
+	var targets []*PackageInstallTarget
+	for _, name := range flagSet.Args() {
+		version := versionFlag
+		exact, explicit := rubyGemsExactVersion(version)
+		if explicit {
+			version = exact
+		}
+
+		targets = append(targets, rubyGemsInstallTarget(name, version, explicit))
+	}
+
+	return targets
+}
+
+func rubyGemsInstallTarget(name, version string, explicit bool) *PackageInstallTarget {
+	return &PackageInstallTarget{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{
+				Ecosystem: packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS,
+				Name:      name,
+			},
+			Version: version,
+		},
+		IsExplicitVersion: explicit,
+	}
+}
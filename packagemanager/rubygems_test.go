@@ -0,0 +1,139 @@
+package packagemanager
+
+import (
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRubyGemsPackageManagerParseCommand(t *testing.T) {
+	type target struct {
+		name     string
+		version  string
+		explicit bool
+	}
+
+	cases := []struct {
+		name            string
+		config          RubyGemsPackageManagerConfig
+		args            []string
+		nonDownload     bool
+		manifestInstall bool
+		targets         []target
+	}{
+		{
+			name:    "gem install without version",
+			config:  DefaultGemPackageManagerConfig(),
+			args:    []string{"gem", "install", "rails"},
+			targets: []target{{name: "rails"}},
+		},
+		{
+			name:    "gem install with -v exact version",
+			config:  DefaultGemPackageManagerConfig(),
+			args:    []string{"gem", "install", "rails", "-v", "7.1.3"},
+			targets: []target{{name: "rails", version: "7.1.3", explicit: true}},
+		},
+		{
+			name:    "gem install with name:version",
+			config:  DefaultGemPackageManagerConfig(),
+			args:    []string{"gem", "install", "--no-document", "nokogiri:1.16.0"},
+			targets: []target{{name: "nokogiri", version: "1.16.0", explicit: true}},
+		},
+		{
+			name:    "gem install with requirement is not explicit",
+			config:  DefaultGemPackageManagerConfig(),
+			args:    []string{"gem", "install", "rails", "--version", "~> 7.1"},
+			targets: []target{{name: "rails", version: "~> 7.1"}},
+		},
+		{
+			name:    "gem install skips local gem file",
+			config:  DefaultGemPackageManagerConfig(),
+			args:    []string{"gem", "install", "./pkg/mygem-1.0.0.gem", "rake"},
+			targets: []target{{name: "rake"}},
+		},
+		{
+			name:            "gem install from Gemfile is manifest install",
+			config:          DefaultGemPackageManagerConfig(),
+			args:            []string{"gem", "install", "-g", "Gemfile"},
+			manifestInstall: true,
+		},
+		{
+			name:            "gem update without names is manifest install",
+			config:          DefaultGemPackageManagerConfig(),
+			args:            []string{"gem", "update"},
+			manifestInstall: true,
+		},
+		{
+			name:        "gem list is non-download",
+			config:      DefaultGemPackageManagerConfig(),
+			args:        []string{"gem", "list"},
+			nonDownload: true,
+		},
+		{
+			name:            "bare bundle is manifest install",
+			config:          DefaultBundlePackageManagerConfig(),
+			args:            []string{"bundle"},
+			manifestInstall: true,
+		},
+		{
+			name:            "bundle install is manifest install",
+			config:          DefaultBundlePackageManagerConfig(),
+			args:            []string{"bundle", "install", "--jobs", "4"},
+			manifestInstall: true,
+		},
+		{
+			name:    "bundle add with exact version",
+			config:  DefaultBundlePackageManagerConfig(),
+			args:    []string{"bundle", "add", "sidekiq", "--version", "7.2.0"},
+			targets: []target{{name: "sidekiq", version: "7.2.0", explicit: true}},
+		},
+		{
+			name:    "bundle add with group",
+			config:  DefaultBundlePackageManagerConfig(),
+			args:    []string{"bundle", "add", "--group", "test", "rspec"},
+			targets: []target{{name: "rspec"}},
+		},
+		{
+			name:            "bundle add from git resolves manifest",
+			config:          DefaultBundlePackageManagerConfig(),
+			args:            []string{"bundle", "add", "mygem", "--git", "https://github.com/x/mygem"},
+			manifestInstall: true,
+		},
+		{
+			name:        "bundle check is non-download",
+			config:      DefaultBundlePackageManagerConfig(),
+			args:        []string{"bundle", "check"},
+			nonDownload: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pm, err := NewRubyGemsPackageManager(tc.config)
+			require.NoError(t, err)
+			assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, pm.Ecosystem())
+
+			parsed, err := pm.ParseCommand(tc.args)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.nonDownload, parsed.IsKnownNonDownloadCommand)
+			assert.Equal(t, tc.manifestInstall, parsed.IsManifestInstall)
+
+			require.Len(t, parsed.InstallTargets, len(tc.targets))
+			for i, want := range tc.targets {
+				got := parsed.InstallTargets[i]
+				assert.Equal(t, want.name, got.PackageVersion.GetPackage().GetName())
+				assert.Equal(t, want.version, got.PackageVersion.GetVersion())
+				assert.Equal(t, want.explicit, got.IsExplicitVersion)
+				assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, got.PackageVersion.GetPackage().GetEcosystem())
+			}
+		})
+	}
+}
+
+func TestNewRubyGemsPackageManagerRejectsUnknownCommand(t *testing.T) {
+	_, err := NewRubyGemsPackageManager(RubyGemsPackageManagerConfig{CommandName: "rake"})
+	assert.Error(t, err)
+}
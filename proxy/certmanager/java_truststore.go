@@ -0,0 +1,112 @@
+package certmanager
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	javaTrustStoreFileName = "proxy-ca.jks"
+
+	// JavaTrustStorePassword protects the integrity check of the generated
+	// truststore, not its contents: a truststore only holds public
+	// certificates. "changeit" is the JDK's own default.
+	JavaTrustStorePassword = "changeit"
+
+	jksMagic            = 0xFEEDFEED
+	jksVersion          = 2
+	jksTrustedCertEntry = 2
+
+	// jksDigestWhitener is mixed into the JKS integrity digest by the JDK.
+	jksDigestWhitener = "Mighty Aphrodite"
+)
+
+// EphemeralJavaTrustStorePath returns a per-process temp path for the Java
+// truststore, alongside EphemeralProxyCABundlePath.
+func EphemeralJavaTrustStorePath() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("pmg-%d-%s", os.Getpid(), javaTrustStoreFileName))
+}
+
+// WriteJavaTrustStore converts the PEM bundle at bundlePath (PMG CA plus
+// system roots, see MergeWithSystemCA) into a JKS truststore at outputPath.
+// Java ignores SSL_CERT_FILE and only reads keystore files.
+func WriteJavaTrustStore(bundlePath, outputPath, password string) error {
+	bundlePEM, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	store, err := EncodeJavaTrustStore(bundlePEM, password, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, store, 0o600); err != nil {
+		return fmt.Errorf("failed to write Java truststore: %w", err)
+	}
+
+	return nil
+}
+
+// EncodeJavaTrustStore encodes every certificate in bundlePEM as a trusted
+// certificate entry of a JKS keystore. Blocks that are not parseable
+// certificates are skipped; an empty result is an error.
+func EncodeJavaTrustStore(bundlePEM []byte, password string, created time.Time) ([]byte, error) {
+	var certs [][]byte
+	for rest := bundlePEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			continue
+		}
+		certs = append(certs, block.Bytes)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in CA bundle")
+	}
+
+	var buf bytes.Buffer
+	writeUint32 := func(v uint32) { _ = binary.Write(&buf, binary.BigEndian, v) }
+	writeUTF := func(s string) {
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(s)))
+		buf.WriteString(s)
+	}
+
+	writeUint32(jksMagic)
+	writeUint32(jksVersion)
+	writeUint32(uint32(len(certs)))
+
+	for i, der := range certs {
+		writeUint32(jksTrustedCertEntry)
+		writeUTF(fmt.Sprintf("pmg-%d", i))
+		_ = binary.Write(&buf, binary.BigEndian, uint64(created.UnixMilli()))
+		writeUTF("X.509")
+		writeUint32(uint32(len(der)))
+		buf.Write(der)
+	}
+
+	digest := sha1.New()
+	for _, c := range utf16.Encode([]rune(password)) {
+		digest.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	digest.Write([]byte(jksDigestWhitener))
+	digest.Write(buf.Bytes())
+	buf.Write(digest.Sum(nil))
+
+	return buf.Bytes(), nil
+}
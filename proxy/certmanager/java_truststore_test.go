@@ -0,0 +1,104 @@
+package certmanager
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readJKSTrustedCerts decodes the trusted certificate entries of a JKS
+// keystore and verifies its integrity digest.
+func readJKSTrustedCerts(t *testing.T, store []byte, password string) [][]byte {
+	t.Helper()
+	require.Greater(t, len(store), sha1.Size)
+
+	body, digest := store[:len(store)-sha1.Size], store[len(store)-sha1.Size:]
+	h := sha1.New()
+	for _, r := range password {
+		h.Write([]byte{0, byte(r)})
+	}
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(body)
+	require.Equal(t, h.Sum(nil), digest, "integrity digest must match")
+
+	r := bytes.NewReader(body)
+	readUint32 := func() uint32 {
+		var v uint32
+		require.NoError(t, binary.Read(r, binary.BigEndian, &v))
+		return v
+	}
+	readUTF := func() string {
+		var n uint16
+		require.NoError(t, binary.Read(r, binary.BigEndian, &n))
+		b := make([]byte, n)
+		_, err := r.Read(b)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, uint32(0xFEEDFEED), readUint32())
+	assert.Equal(t, uint32(2), readUint32())
+	count := readUint32()
+
+	var certs [][]byte
+	for range count {
+		assert.Equal(t, uint32(2), readUint32(), "entry must be a trusted certificate")
+		readUTF()
+		var created uint64
+		require.NoError(t, binary.Read(r, binary.BigEndian, &created))
+		assert.Equal(t, "X.509", readUTF())
+		der := make([]byte, readUint32())
+		_, err := r.Read(der)
+		require.NoError(t, err)
+		certs = append(certs, der)
+	}
+	assert.Zero(t, r.Len())
+
+	return certs
+}
+
+func TestEncodeJavaTrustStore(t *testing.T) {
+	first, err := GenerateCA(DefaultCertManagerConfig())
+	require.NoError(t, err)
+	second, err := GenerateCA(DefaultCertManagerConfig())
+	require.NoError(t, err)
+
+	bundle := append(append([]byte{}, first.Certificate...), []byte("-----BEGIN GARBAGE-----\nAAAA\n-----END GARBAGE-----\n")...)
+	bundle = append(bundle, second.Certificate...)
+
+	store, err := EncodeJavaTrustStore(bundle, JavaTrustStorePassword, time.Now())
+	require.NoError(t, err)
+
+	certs := readJKSTrustedCerts(t, store, JavaTrustStorePassword)
+	require.Len(t, certs, 2)
+	assert.Equal(t, first.X509Cert.Raw, certs[0])
+	assert.Equal(t, second.X509Cert.Raw, certs[1])
+}
+
+func TestEncodeJavaTrustStoreRejectsEmptyBundle(t *testing.T) {
+	_, err := EncodeJavaTrustStore([]byte("not a certificate"), JavaTrustStorePassword, time.Now())
+	assert.Error(t, err)
+}
+
+func TestWriteJavaTrustStore(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := GenerateCA(DefaultCertManagerConfig())
+	require.NoError(t, err)
+
+	bundlePath := filepath.Join(dir, "bundle.pem")
+	require.NoError(t, os.WriteFile(bundlePath, ca.Certificate, 0o600))
+
+	storePath := filepath.Join(dir, "store.jks")
+	require.NoError(t, WriteJavaTrustStore(bundlePath, storePath, JavaTrustStorePassword))
+
+	store, err := os.ReadFile(storePath)
+	require.NoError(t, err)
+	assert.Len(t, readJKSTrustedCerts(t, store, JavaTrustStorePassword), 1)
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"time"
@@ -53,6 +54,18 @@ const (
 	BlockReasonUserDeclined
 	BlockReasonConfirmationFailed
 	BlockReasonDependencyCooldown
+	BlockReasonAnalysisUnavailable
+	BlockReasonVulnerable
+	BlockReasonLicense
+	BlockReasonPackageAge
+	BlockReasonContentRule
+	BlockReasonLockfileMismatch
+	BlockReasonLockfileUnlisted
+	BlockReasonRegistryIntegrity
+	BlockReasonNpmSignature
+	BlockReasonProvenance
+	BlockReasonDependencyConfusion
+	BlockReasonHeuristic
 )
 
 // BlockContext carries the structured facts of a block decision so a
@@ -63,16 +76,71 @@ type BlockContext struct {
 	PackageName    string
 	PackageVersion string
 
-	// For BlockReasonMalware and BlockReasonUserDeclined
+	// For BlockReasonMalware, BlockReasonHeuristic and BlockReasonUserDeclined
 	MalwareSummary      string
 	MalwareReferenceURL string
-
-	// For BlockReasonDependencyCooldown
+	SuspectedTarget     string
+
+	// For BlockReasonHeuristic: the heuristic that flagged the package
+	Heuristic string
+
+	// For BlockReasonVulnerable: the advisories, most severe first
+	Vulnerabilities []BlockedVulnerability
+
+	// For BlockReasonLicense: the license that decided the block, the
+	// declared license expression and the verdict ("denied", "not allowed"
+	// or "undeclared")
+	License         string
+	LicenseDeclared string
+	LicenseVerdict  string
+
+	// For BlockReasonContentRule: the rule pack and rule that matched, and
+	// the file inside the artifact it matched
+	RulePack        string
+	RuleName        string
+	RuleDescription string
+	RuleFile        string
+
+	// For BlockReasonLockfileMismatch and BlockReasonLockfileUnlisted: the
+	// lockfile checked, and for a mismatch the hash it pins and the hash of
+	// the downloaded artifact
+	Lockfile     string
+	ExpectedHash string
+	ActualHash   string
+
+	// For BlockReasonRegistryIntegrity: the registry host that served the
+	// artifact. ExpectedHash is the hash its metadata published.
+	// BlockReasonNpmSignature and BlockReasonDependencyConfusion use
+	// Registry too.
+	Registry string
+
+	// For BlockReasonDependencyConfusion: the private namespace the package
+	// belongs to. PackageVersion is empty for a metadata request.
+	PrivateNamespace string
+
+	// For BlockReasonNpmSignature: why the registry signature did not verify
+	SignatureError string
+
+	// For BlockReasonProvenance: why the provenance attestation was not
+	// accepted
+	ProvenanceError string
+
+	// For BlockReasonDependencyCooldown. BlockReasonPackageAge uses them for
+	// the package's first release and the package_age minimum.
 	CooldownDays     int
 	CooldownDaysAgo  int
 	CooldownDaysLeft int
 }
 
+// BlockedVulnerability is an advisory that caused a BlockReasonVulnerable
+// block.
+type BlockedVulnerability struct {
+	ID           string
+	Severity     string
+	Summary      string
+	ReferenceURL string
+}
+
 // InterceptorResponse defines how the proxy should handle the request
 type InterceptorResponse struct {
 	// Action to take
@@ -95,12 +163,40 @@ type InterceptorResponse struct {
 
 	// For Action = ModifyResponse: response modification function
 	ResponseModifier ResponseModifierFunc
+
+	// MaxResponseSize bounds the body ResponseModifier is run on. A larger
+	// response is not read into memory: OversizeResponse decides whether it
+	// is blocked or streamed to the client unmodified. Zero means no limit.
+	MaxResponseSize int64
+
+	// OversizeResponse is called instead of ResponseModifier for a response
+	// over MaxResponseSize. A nil func, or a nil block, streams it.
+	OversizeResponse OversizeResponseFunc
 }
 
+// OversizeResponseFunc decides on a response too large to modify, from its
+// status code and headers. Returning a *ResponseBlock blocks it; nil lets it
+// through unmodified.
+type OversizeResponseFunc func(statusCode int, headers http.Header) *ResponseBlock
+
 // ResponseModifierFunc modifies HTTP response
-// It receives the status code, headers, and body, and returns modified versions
+// It receives the status code, headers, and body, and returns modified versions.
+// Returning a *ResponseBlock error blocks the response; any other error
+// forwards the original response.
 type ResponseModifierFunc func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error)
 
+// ResponseBlock is returned by a ResponseModifierFunc to replace the upstream
+// response with a block, rendered the same way as an ActionBlock decision.
+type ResponseBlock struct {
+	Code    int
+	Reason  BlockReason
+	Context *BlockContext
+}
+
+func (e *ResponseBlock) Error() string {
+	return fmt.Sprintf("response blocked by interceptor (reason %d)", e.Reason)
+}
+
 // Interceptor processes HTTP/HTTPS requests and can modify or block them
 type Interceptor interface {
 	// Name returns the interceptor name for logging
@@ -0,0 +1,124 @@
+package interceptors
+
+import (
+	"fmt"
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+// artifactInspector checks the bytes of a downloaded artifact.
+type artifactInspector struct {
+	// check returns the block to answer the download with, or nil to let it
+	// through.
+	check func(artifact []byte) *proxy.ResponseBlock
+
+	// unreadable, when set, returns the block for a response whose bytes are
+	// not the published artifact, such as a content-encoded or partial one,
+	// or that is too large to read. Inspectors without it let such a response
+	// through unchecked.
+	unreadable func(reason string) *proxy.ResponseBlock
+}
+
+// requestWholeArtifact asks upstream for the complete, unencoded artifact,
+// so that what is inspected or hashed is the published file.
+func requestWholeArtifact(headers http.Header) {
+	if headers == nil {
+		return
+	}
+	headers.Set("Accept-Encoding", "identity")
+	headers.Del("Range")
+	headers.Del("If-Range")
+}
+
+// inspectArtifact turns an allowed artifact download into one whose response
+// runs through inspectors, in order, before it reaches the package manager.
+// The first block wins. Other decisions, trusted packages, insecure mode,
+// and downloads no inspector applies to (nil inspectors) pass through
+// unchanged. An artifact over proxy.max_inspected_artifact_mb is unreadable,
+// like an encoded or partial one.
+func (b *baseRegistryInterceptor) inspectArtifact(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	resp *proxy.InterceptorResponse,
+	inspectors ...*artifactInspector,
+) *proxy.InterceptorResponse {
+	if resp == nil || resp.Action != proxy.ActionAllow {
+		return resp
+	}
+
+	active := make([]*artifactInspector, 0, len(inspectors))
+	for _, inspector := range inspectors {
+		if inspector != nil {
+			active = append(active, inspector)
+		}
+	}
+	if len(active) == 0 {
+		return resp
+	}
+
+	if config.Get().InsecureInstallation || config.IsTrustedPackageRef(ecosystem, packageName, packageVersion) {
+		return resp
+	}
+
+	requestWholeArtifact(ctx.Headers)
+
+	// unreadable returns the first block of an inspector that fails closed on
+	// a response it cannot read; with none, the response goes through
+	// unchecked.
+	unreadable := func(reason string) *proxy.ResponseBlock {
+		for _, inspector := range active {
+			if inspector.unreadable == nil {
+				continue
+			}
+			if block := inspector.unreadable(reason); block != nil {
+				return block
+			}
+		}
+		log.Warnf("[%s] Skipping inspection of %s/%s@%s, response is %s",
+			ctx.RequestID, ecosystem.String(), packageName, packageVersion, reason)
+		return nil
+	}
+
+	maxSize := config.Get().Config.Proxy.MaxInspectedArtifactBytes()
+	return &proxy.InterceptorResponse{
+		Action:          proxy.ActionModifyResponse,
+		MaxResponseSize: maxSize,
+		OversizeResponse: func(statusCode int, _ http.Header) *proxy.ResponseBlock {
+			if statusCode != http.StatusOK && statusCode != http.StatusPartialContent {
+				return nil
+			}
+			return unreadable(fmt.Sprintf("over the %d MB inspection limit", maxSize>>20))
+		},
+		ResponseModifier: func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+			var reason string
+			switch encoding := headers.Get("Content-Encoding"); {
+			case statusCode == http.StatusPartialContent:
+				reason = "a partial response"
+			case statusCode != http.StatusOK:
+				return statusCode, headers, body, nil
+			case encoding != "" && encoding != "identity":
+				reason = encoding + " encoded"
+			}
+
+			if reason != "" {
+				if block := unreadable(reason); block != nil {
+					return 0, nil, nil, block
+				}
+				return statusCode, headers, body, nil
+			}
+
+			for _, inspector := range active {
+				if block := inspector.check(body); block != nil {
+					return 0, nil, nil, block
+				}
+			}
+			return statusCode, headers, body, nil
+		},
+	}
+}
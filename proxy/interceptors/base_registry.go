@@ -11,6 +11,7 @@ import (
 	"github.com/safedep/pmg/analyzer"
 	"github.com/safedep/pmg/config"
 	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/models"
 	"github.com/safedep/pmg/proxy"
 	gobreaker "github.com/sony/gobreaker/v2"
 	"golang.org/x/sync/singleflight"
@@ -89,12 +90,14 @@ func (b *baseRegistryInterceptor) fastAllow(
 	if config.Get().InsecureInstallation {
 		log.Debugf("[%s] Skipping insecure installation", ctx.RequestID)
 		audit.LogInstallInsecureBypass(pkgVersion)
+		setFetchVerdict(ctx, models.FetchVerdictInsecure)
 		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, true
 	}
 
 	if config.IsTrustedPackageRef(ecosystem, name, version) {
 		log.Debugf("[%s] Skipping trusted package: %s/%s@%s", ctx.RequestID, ecosystem.String(), name, version)
 		audit.LogInstallTrustedAllowed(pkgVersion)
+		setFetchVerdict(ctx, models.FetchVerdictTrusted)
 		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, true
 	}
 
@@ -186,9 +189,16 @@ func (b *baseRegistryInterceptor) handleAnalysisResult(
 ) (*proxy.InterceptorResponse, error) {
 	switch result.Action {
 	case analyzer.ActionBlock:
+		if isVulnerabilityVerdict(result) {
+			return b.blockVulnerable(ctx, ecosystem, packageName, packageVersion, result), nil
+		}
+		if isHeuristicVerdict(result) {
+			return b.blockHeuristic(ctx, ecosystem, packageName, packageVersion, result), nil
+		}
+
 		log.Warnf("[%s] Blocking malicious package %s@%s", ctx.RequestID, packageName, packageVersion)
 
-		audit.LogMalwareBlocked(result.PackageVersion, result.Summary, result.AnalysisID, result.ReferenceURL, result.IsMalware, result.IsVerified)
+		audit.LogMalwareBlocked(result.PackageVersion, result.Summary, result.AnalysisID, result.ReferenceURL, result.DecidedBy, result.IsMalware, result.IsVerified)
 
 		if b.statsCollector != nil {
 			b.statsCollector.RecordBlocked(result)
@@ -204,6 +214,7 @@ func (b *baseRegistryInterceptor) handleAnalysisResult(
 				PackageVersion:      packageVersion,
 				MalwareSummary:      result.Summary,
 				MalwareReferenceURL: result.ReferenceURL,
+				SuspectedTarget:     result.SuspectedTarget,
 			},
 		}, nil
 
@@ -233,7 +244,14 @@ func (b *baseRegistryInterceptor) handleAnalysisResult(
 		if !confirmed {
 			log.Infof("[%s] User declined installation of suspicious package %s/%s@%s", ctx.RequestID, ecosystem.String(), packageName, packageVersion)
 
-			audit.LogMalwareBlocked(result.PackageVersion, result.Summary, result.AnalysisID, result.ReferenceURL, result.IsMalware, result.IsVerified)
+			switch {
+			case isVulnerabilityVerdict(result):
+				audit.LogVulnerabilityBlocked(result.PackageVersion, advisoryIDs(result), result.Vulnerabilities[0].Severity, result.DecidedBy)
+			case isHeuristicVerdict(result):
+				audit.LogHeuristicBlocked(result.PackageVersion, result.Heuristic, result.Summary, result.DecidedBy)
+			default:
+				audit.LogMalwareBlocked(result.PackageVersion, result.Summary, result.AnalysisID, result.ReferenceURL, result.DecidedBy, result.IsMalware, result.IsVerified)
+			}
 
 			if b.statsCollector != nil {
 				b.statsCollector.RecordUserCancelled(result)
@@ -249,16 +267,18 @@ func (b *baseRegistryInterceptor) handleAnalysisResult(
 					PackageVersion:      packageVersion,
 					MalwareSummary:      result.Summary,
 					MalwareReferenceURL: result.ReferenceURL,
+					SuspectedTarget:     result.SuspectedTarget,
 				},
 			}, nil
 		}
 
-		audit.LogMalwareConfirmed(result.PackageVersion, result.AnalysisID, result.IsMalware, result.IsVerified)
+		audit.LogMalwareConfirmed(result.PackageVersion, result.AnalysisID, result.DecidedBy, result.IsMalware, result.IsVerified)
 		audit.LogInstallAllowed(result.PackageVersion, 1)
 
 		if b.statsCollector != nil {
 			b.statsCollector.RecordConfirmed(result)
 		}
+		setFetchVerdict(ctx, models.FetchVerdictConfirmed)
 
 		log.Infof("[%s] User confirmed installation of suspicious package %s/%s@%s", ctx.RequestID, ecosystem.String(), packageName, packageVersion)
 		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
@@ -269,6 +289,7 @@ func (b *baseRegistryInterceptor) handleAnalysisResult(
 		if b.statsCollector != nil {
 			b.statsCollector.RecordAllowed(result)
 		}
+		setFetchVerdict(ctx, models.FetchVerdictClean)
 
 		// A flagged package allowed only because of a tenant-specific exclusion is
 		// a security-relevant trust decision; surface it so it is never silent.
@@ -286,12 +307,206 @@ func (b *baseRegistryInterceptor) handleAnalysisResult(
 		if b.statsCollector != nil {
 			b.statsCollector.RecordAllowed(result)
 		}
+		setFetchVerdict(ctx, models.FetchVerdictClean)
 
 		log.Warnf("[%s] Unknown analysis action %d for package %s/%s@%s, allowing by default", ctx.RequestID, result.Action, ecosystem.String(), packageName, packageVersion)
 		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
 	}
 }
 
+// isVulnerabilityVerdict reports whether a verdict comes from the
+// vulnerability policy alone. A malware verdict takes precedence.
+func isVulnerabilityVerdict(result *analyzer.PackageVersionAnalysisResult) bool {
+	return len(result.Vulnerabilities) > 0 && !result.IsMalware
+}
+
+// isHeuristicVerdict reports whether a verdict comes from a local heuristic
+// alone. A malware verdict takes precedence.
+func isHeuristicVerdict(result *analyzer.PackageVersionAnalysisResult) bool {
+	return result.Heuristic != "" && !result.IsMalware
+}
+
+// advisoryIDs returns the IDs of the advisories behind a vulnerability
+// verdict.
+func advisoryIDs(result *analyzer.PackageVersionAnalysisResult) []string {
+	ids := make([]string, 0, len(result.Vulnerabilities))
+	for _, v := range result.Vulnerabilities {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+// blockHeuristic records and returns a block by a local heuristic. It is
+// audited apart from malware blocks: the package is suspicious, not known
+// to be malicious.
+func (b *baseRegistryInterceptor) blockHeuristic(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	result *analyzer.PackageVersionAnalysisResult,
+) *proxy.InterceptorResponse {
+	log.Warnf("[%s] Blocking suspicious package %s@%s (%s heuristic): %s", ctx.RequestID, packageName, packageVersion, result.Heuristic, result.Summary)
+
+	audit.LogHeuristicBlocked(result.PackageVersion, result.Heuristic, result.Summary, result.DecidedBy)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordBlocked(result)
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:      proxy.ActionBlock,
+		BlockCode:   http.StatusForbidden,
+		BlockReason: proxy.BlockReasonHeuristic,
+		BlockContext: &proxy.BlockContext{
+			Ecosystem:       ecosystem,
+			PackageName:     packageName,
+			PackageVersion:  packageVersion,
+			MalwareSummary:  result.Summary,
+			SuspectedTarget: result.SuspectedTarget,
+			Heuristic:       result.Heuristic,
+		},
+	}
+}
+
+// blockVulnerable records and returns a block by the vulnerability policy.
+func (b *baseRegistryInterceptor) blockVulnerable(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	result *analyzer.PackageVersionAnalysisResult,
+) *proxy.InterceptorResponse {
+	log.Warnf("[%s] Blocking vulnerable package %s@%s: %s", ctx.RequestID, packageName, packageVersion, result.Summary)
+
+	vulnerabilities := make([]proxy.BlockedVulnerability, 0, len(result.Vulnerabilities))
+	for _, v := range result.Vulnerabilities {
+		vulnerabilities = append(vulnerabilities, proxy.BlockedVulnerability{
+			ID:           v.ID,
+			Severity:     v.Severity,
+			Summary:      v.Summary,
+			ReferenceURL: v.ReferenceURL,
+		})
+	}
+
+	audit.LogVulnerabilityBlocked(result.PackageVersion, advisoryIDs(result), result.Vulnerabilities[0].Severity, result.DecidedBy)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordVulnerableBlocked(result)
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:      proxy.ActionBlock,
+		BlockCode:   http.StatusForbidden,
+		BlockReason: proxy.BlockReasonVulnerable,
+		BlockContext: &proxy.BlockContext{
+			Ecosystem:       ecosystem,
+			PackageName:     packageName,
+			PackageVersion:  packageVersion,
+			Vulnerabilities: vulnerabilities,
+		},
+	}
+}
+
+// analysisUnavailableSummary is shown in the confirmation prompt for a package
+// that could not be analyzed under analysis.on_failure: confirm.
+const analysisUnavailableSummary = "Malware analysis is unavailable; this package has not been checked"
+
+// handleAnalysisFailure applies the analysis.on_failure policy when
+// analyzePackage produced no verdict, either because the analyzer failed or
+// because its circuit breaker is open. Every degraded decision is audited.
+func (b *baseRegistryInterceptor) handleAnalysisFailure(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	analysisErr error,
+) *proxy.InterceptorResponse {
+	pkgVersion := &packagev1.PackageVersion{
+		Package: &packagev1.Package{
+			Ecosystem: ecosystem,
+			Name:      packageName,
+		},
+		Version: packageVersion,
+	}
+
+	policy := config.Get().Config.Analysis.OnFailurePolicy()
+
+	// The decision is recorded with the stats like an analyzed package's, so
+	// the summary counts it; RecordUnverified tracks the missing verdict.
+	decision := &analyzer.PackageVersionAnalysisResult{PackageVersion: pkgVersion, Action: analyzer.ActionAllow}
+	switch policy {
+	case config.AnalysisOnFailureBlock:
+		log.Warnf("[%s] Blocking package %s/%s@%s, malware analysis unavailable: %v",
+			ctx.RequestID, ecosystem.String(), packageName, packageVersion, analysisErr)
+		return b.blockAnalysisUnavailable(pkgVersion, policy, analysisErr)
+
+	case config.AnalysisOnFailureConfirm:
+		log.Warnf("[%s] Malware analysis unavailable for %s/%s@%s, requesting user confirmation: %v",
+			ctx.RequestID, ecosystem.String(), packageName, packageVersion, analysisErr)
+
+		decision = &analyzer.PackageVersionAnalysisResult{
+			PackageVersion: pkgVersion,
+			Action:         analyzer.ActionConfirm,
+			Summary:        analysisUnavailableSummary,
+		}
+		confirmed, err := b.requestUserConfirmation(ctx, decision)
+		if err != nil {
+			log.Errorf("[%s] Failed to get user confirmation: %v", ctx.RequestID, err)
+			return b.blockAnalysisUnavailable(pkgVersion, policy, analysisErr)
+		}
+
+		if !confirmed {
+			log.Infof("[%s] User declined installation of unanalyzed package %s/%s@%s", ctx.RequestID, ecosystem.String(), packageName, packageVersion)
+			return b.blockAnalysisUnavailable(pkgVersion, policy, analysisErr)
+		}
+
+		log.Infof("[%s] User confirmed installation of unanalyzed package %s/%s@%s", ctx.RequestID, ecosystem.String(), packageName, packageVersion)
+		audit.LogAnalysisUnavailable(pkgVersion, policy, audit.AnalysisUnavailableConfirmed, analysisErr)
+
+	default:
+		log.Errorf("[%s] Failed to analyze package %s@%s, allowing without a verdict: %v", ctx.RequestID, packageName, packageVersion, analysisErr)
+		audit.LogAnalysisUnavailable(pkgVersion, policy, audit.AnalysisUnavailableAllowed, analysisErr)
+	}
+
+	if b.statsCollector != nil {
+		if decision.Action == analyzer.ActionConfirm {
+			b.statsCollector.RecordConfirmed(decision)
+		} else {
+			b.statsCollector.RecordAllowed(decision)
+		}
+		b.statsCollector.RecordUnverified(packageName, packageVersion)
+	}
+	setFetchVerdict(ctx, models.FetchVerdictUnverified)
+
+	return &proxy.InterceptorResponse{Action: proxy.ActionAllow}
+}
+
+// blockAnalysisUnavailable records and returns a block for a package that has
+// no verdict because analysis was unavailable.
+func (b *baseRegistryInterceptor) blockAnalysisUnavailable(
+	pkgVersion *packagev1.PackageVersion,
+	policy string,
+	analysisErr error,
+) *proxy.InterceptorResponse {
+	audit.LogAnalysisUnavailable(pkgVersion, policy, audit.AnalysisUnavailableBlocked, analysisErr)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordAnalysisUnavailableBlocked(pkgVersion.GetPackage().GetName(), pkgVersion.GetVersion())
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:      proxy.ActionBlock,
+		BlockCode:   http.StatusForbidden,
+		BlockReason: proxy.BlockReasonAnalysisUnavailable,
+		BlockContext: &proxy.BlockContext{
+			Ecosystem:      pkgVersion.GetPackage().GetEcosystem(),
+			PackageName:    pkgVersion.GetPackage().GetName(),
+			PackageVersion: pkgVersion.GetVersion(),
+		},
+	}
+}
+
 // requestUserConfirmation sends a confirmation request and blocks waiting for user response
 func (b *baseRegistryInterceptor) requestUserConfirmation(
 	ctx *proxy.RequestContext,
@@ -314,3 +529,54 @@ func (b *baseRegistryInterceptor) requestUserConfirmation(
 		return false, fmt.Errorf("timeout waiting for user confirmation")
 	}
 }
+
+// policyDecision is how applyPolicyAction settled a policy finding.
+type policyDecision int
+
+const (
+	policyAllowed policyDecision = iota
+	policyConfirmed
+	policyBlocked
+)
+
+// applyPolicyAction carries out a policy's allow, confirm or block action for
+// a package version it flagged, described by summary. allow only logs the
+// finding and confirm asks the user first; a declined or failed confirmation
+// and any other action block. policy names the policy in the log. Callers
+// audit and record the decision.
+func (b *baseRegistryInterceptor) applyPolicyAction(
+	ctx *proxy.RequestContext,
+	policy string,
+	pkgVersion *packagev1.PackageVersion,
+	action string,
+	summary string,
+) policyDecision {
+	ecosystem := pkgVersion.GetPackage().GetEcosystem().String()
+	packageName := pkgVersion.GetPackage().GetName()
+	packageVersion := pkgVersion.GetVersion()
+
+	switch action {
+	case config.HeuristicActionAllow:
+		log.Warnf("[%s] %s: allowing %s/%s@%s: %s", ctx.RequestID, policy, ecosystem, packageName, packageVersion, summary)
+		return policyAllowed
+
+	case config.HeuristicActionConfirm:
+		log.Warnf("[%s] %s: %s/%s@%s: %s, requesting user confirmation", ctx.RequestID, policy, ecosystem, packageName, packageVersion, summary)
+
+		confirmed, err := b.requestUserConfirmation(ctx, &analyzer.PackageVersionAnalysisResult{
+			PackageVersion: pkgVersion,
+			Action:         analyzer.ActionConfirm,
+			Summary:        summary,
+		})
+		if err != nil {
+			log.Errorf("[%s] Failed to get user confirmation: %v", ctx.RequestID, err)
+		}
+		if err == nil && confirmed {
+			log.Infof("[%s] User confirmed installation of %s/%s@%s", ctx.RequestID, ecosystem, packageName, packageVersion)
+			return policyConfirmed
+		}
+	}
+
+	log.Warnf("[%s] Blocking %s/%s@%s: %s", ctx.RequestID, ecosystem, packageName, packageVersion, summary)
+	return policyBlocked
+}
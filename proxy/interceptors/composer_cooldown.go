@@ -0,0 +1,179 @@
+package interceptors
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+// composerDistIndex maps dist archive URLs to the package version they
+// deliver. Packagist dists are GitHub, GitLab or Bitbucket archive URLs
+// such as https://api.github.com/repos/symfony/console/zipball/<sha> that
+// do not name the package, so the index is seeded from composer.lock and
+// filled from the p2 metadata composer fetches before downloading.
+type composerDistIndex struct {
+	mu    sync.RWMutex
+	byURL map[string]*packagev1.PackageVersion
+}
+
+func newComposerDistIndex(known map[string]*packagev1.PackageVersion) *composerDistIndex {
+	index := &composerDistIndex{byURL: make(map[string]*packagev1.PackageVersion, len(known))}
+	for rawURL, pv := range known {
+		if key := composerDistKey(rawURL); key != "" {
+			index.byURL[key] = pv
+		}
+	}
+
+	return index
+}
+
+// Record remembers the dist URL of every version in metadata.
+func (d *composerDistIndex) Record(name string, versions []composerVersion) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, version := range versions {
+		key := composerDistKey(version.DistURL())
+		if key == "" || version.Version() == "" {
+			continue
+		}
+
+		d.byURL[key] = &packagev1.PackageVersion{
+			Package: &packagev1.Package{
+				Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PACKAGIST,
+				Name:      name,
+			},
+			Version: version.Version(),
+		}
+	}
+}
+
+// Lookup returns the package version served at u, if known.
+func (d *composerDistIndex) Lookup(u *url.URL) (*packagev1.PackageVersion, bool) {
+	if u == nil {
+		return nil, false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	pv, ok := d.byURL[composerDistKey(u.String())]
+	return pv, ok
+}
+
+// composerDistKey normalizes a dist URL for lookup: the host is lowercased
+// and a default port dropped, as intercepted requests can carry one. The
+// query is kept: GitLab archive URLs carry the commit in ?sha=.
+func composerDistKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port == "443" && u.Scheme == "https" || port == "80" && u.Scheme == "http" {
+		u.Host = u.Hostname()
+	}
+	return u.String()
+}
+
+// composerCooldownHandler handles p2 metadata for Composer packages. Every
+// response is read so the dist index learns the dist URLs of the package;
+// with dependency cooldown enabled, versions released within the window are
+// dropped so Composer's solver picks the latest eligible version.
+type composerCooldownHandler struct {
+	statsCollector *AnalysisStatsCollector
+	dists          *composerDistIndex
+}
+
+func newComposerCooldownHandler(statsCollector *AnalysisStatsCollector, dists *composerDistIndex) *composerCooldownHandler {
+	return &composerCooldownHandler{statsCollector: statsCollector, dists: dists}
+}
+
+// HandleMetadataRequest registers a response modifier for the p2 metadata of
+// name. cooldownDays of zero records dists only.
+func (h *composerCooldownHandler) HandleMetadataRequest(ctx *proxy.RequestContext, name string, cooldownDays int, pinnedVersion string) (*proxy.InterceptorResponse, error) {
+	skip := pmgconfig.CooldownSkip(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name)
+	applyCooldown := cooldownDays > 0 && !skip.SkipAll
+
+	log.Debugf("[%s] Registering p2 metadata modifier for %s (cooldown: %t)", ctx.RequestID, name, applyCooldown)
+
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode != http.StatusOK {
+			return statusCode, headers, body, nil
+		}
+
+		metadata, err := parseComposerMetadata(body, name)
+		if err != nil {
+			log.Debugf("[%s] Failed to parse p2 metadata for %s: %v", ctx.RequestID, name, err)
+			return statusCode, headers, body, nil
+		}
+
+		h.dists.Record(name, metadata.Versions)
+
+		if !applyCooldown {
+			return statusCode, headers, body, nil
+		}
+
+		dates := make(map[string]time.Time, len(metadata.Versions))
+		for _, version := range metadata.Versions {
+			if publishTime, ok := version.Time(); ok {
+				dates[version.Version()] = publishTime
+			}
+		}
+
+		exempt := cooldownExemptVersions(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name, skip, dates, cooldownDays)
+		auditCooldownSkips(ctx.RequestID, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name, exempt)
+
+		kept := make([]composerVersion, 0, len(metadata.Versions))
+		var stripped []string
+		for _, version := range metadata.Versions {
+			publishTime, ok := dates[version.Version()]
+			if !ok || exempt.all[version.Version()] {
+				kept = append(kept, version)
+				continue
+			}
+			if within, _, _ := cooldownIsWithinWindow(publishTime, cooldownDays); within {
+				stripped = append(stripped, version.Version())
+				continue
+			}
+			kept = append(kept, version)
+		}
+
+		if len(stripped) == 0 {
+			return statusCode, headers, body, nil
+		}
+
+		metadata.Versions = kept
+		strippedBody, err := metadata.Encode()
+		if err != nil {
+			return statusCode, headers, body, nil
+		}
+
+		log.Infof("[%s] Cooldown: stripped %d version(s) from %s p2 metadata (%d days, %d eligible remain)",
+			ctx.RequestID, len(stripped), name, cooldownDays, len(kept))
+
+		recordCooldownStats(h.statsCollector, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name, pinnedVersion, dates, stripped, len(kept), cooldownDays)
+
+		headers.Del("ETag")
+		headers.Del("Last-Modified")
+		headers.Set("Cache-Control", "no-store")
+
+		return statusCode, headers, strippedBody, nil
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package interceptors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// composerTestMetadata returns minified p2 metadata for acme/lib with one
+// version per entry of ages, newest first as Packagist lists them.
+func composerTestMetadata(versions []string, ages []time.Duration) []byte {
+	entries := ""
+	for index, version := range versions {
+		if index > 0 {
+			entries += ","
+		}
+		published := time.Now().Add(-ages[index]).UTC().Format(time.RFC3339)
+		dist := "https://api.github.com/repos/acme/lib/zipball/" + version
+		if index == 0 {
+			entries += fmt.Sprintf(`{"name":"acme/lib","version":%q,"time":%q,"dist":{"type":"zip","url":%q}}`, version, published, dist)
+		} else {
+			entries += fmt.Sprintf(`{"version":%q,"time":%q,"dist":{"type":"zip","url":%q}}`, version, published, dist)
+		}
+	}
+
+	return []byte(`{"packages":{"acme/lib":[` + entries + `]},"minified":"composer/2.0"}`)
+}
+
+func TestComposerCooldown_StripsRecentVersions(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	day := 24 * time.Hour
+	body := composerTestMetadata([]string{"v3.0.0", "v2.1.0", "v2.0.0"}, []time.Duration{1 * day, 2 * day, 90 * day})
+
+	collector := NewAnalysisStatsCollector()
+	dists := newComposerDistIndex(nil)
+	handler := newComposerCooldownHandler(collector, dists)
+
+	ctx := makeTestRequestContext("https://repo.packagist.org/p2/acme/lib.json")
+	ctx.Headers.Set("If-None-Match", `"etag"`)
+	resp, err := handler.HandleMetadataRequest(ctx, "acme/lib", 5, "")
+	require.NoError(t, err)
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+	assert.Empty(t, ctx.Headers.Get("If-None-Match"))
+
+	headers := http.Header{"Etag": []string{`"etag"`}}
+	status, headers, modified, err := resp.ResponseModifier(http.StatusOK, headers, body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, headers.Get("ETag"))
+	assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+
+	metadata, err := parseComposerMetadata(modified, "acme/lib")
+	require.NoError(t, err)
+	require.Len(t, metadata.Versions, 1)
+	assert.Equal(t, "v2.0.0", metadata.Versions[0].Version())
+	assert.Equal(t, `"acme/lib"`, string(metadata.Versions[0]["name"]), "the new first version is listed in full")
+	assert.NotEmpty(t, collector.GetCooldownWithheld())
+
+	// Dists of stripped versions are still known, so a lock file that pins
+	// one is analyzed rather than passed through.
+	u, _ := url.Parse("https://api.github.com/repos/acme/lib/zipball/v3.0.0")
+	pv, ok := dists.Lookup(u)
+	require.True(t, ok)
+	assert.Equal(t, "v3.0.0", pv.GetVersion())
+}
+
+func TestComposerCooldown_RecordsDistsWithoutCooldown(t *testing.T) {
+	body := composerTestMetadata([]string{"v1.0.0"}, []time.Duration{time.Hour})
+
+	dists := newComposerDistIndex(nil)
+	handler := newComposerCooldownHandler(NewAnalysisStatsCollector(), dists)
+
+	resp, err := handler.HandleMetadataRequest(makeTestRequestContext("https://repo.packagist.org/p2/acme/lib.json"), "acme/lib", 0, "")
+	require.NoError(t, err)
+
+	_, _, modified, err := resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+	assert.Equal(t, body, modified)
+
+	u, _ := url.Parse("https://API.github.com:443/repos/acme/lib/zipball/v1.0.0")
+	pv, ok := dists.Lookup(u)
+	require.True(t, ok)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, pv.GetPackage().GetEcosystem())
+	assert.Equal(t, "acme/lib", pv.GetPackage().GetName())
+}
+
+func TestComposerCooldown_UnparseableBodyPassesThrough(t *testing.T) {
+	handler := newComposerCooldownHandler(NewAnalysisStatsCollector(), newComposerDistIndex(nil))
+
+	resp, err := handler.HandleMetadataRequest(makeTestRequestContext("https://repo.packagist.org/p2/acme/lib.json"), "acme/lib", 5, "")
+	require.NoError(t, err)
+
+	_, _, modified, err := resp.ResponseModifier(http.StatusOK, http.Header{}, []byte("<html>"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("<html>"), modified)
+}
+
+func TestComposerDistIndex_SeededFromLockFile(t *testing.T) {
+	dists := newComposerDistIndex(map[string]*packagev1.PackageVersion{
+		"https://gitlab.com/api/v4/projects/acme%2Flib/repository/archive.zip?sha=abc": {
+			Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, Name: "acme/lib"},
+			Version: "1.0.0",
+		},
+	})
+
+	u, _ := url.Parse("https://gitlab.com/api/v4/projects/acme%2Flib/repository/archive.zip?sha=abc")
+	_, ok := dists.Lookup(u)
+	assert.True(t, ok)
+
+	u, _ = url.Parse("https://gitlab.com/api/v4/projects/acme%2Flib/repository/archive.zip?sha=def")
+	_, ok = dists.Lookup(u)
+	assert.False(t, ok, "the commit in the query is part of the key")
+}
@@ -0,0 +1,156 @@
+package interceptors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// composerMinifiedFormat is the "minified" marker of Composer 2 metadata.
+const composerMinifiedFormat = "composer/2.0"
+
+// composerUnset marks a key removed relative to the previous version in
+// minified metadata.
+var composerUnset = json.RawMessage(`"__unset"`)
+
+// composerVersion is one version entry of p2 metadata, keyed by field.
+type composerVersion map[string]json.RawMessage
+
+func (v composerVersion) stringField(key string) string {
+	var s string
+	_ = json.Unmarshal(v[key], &s)
+	return s
+}
+
+// Version returns the version as Packagist lists it, e.g. "v6.4.1".
+func (v composerVersion) Version() string { return v.stringField("version") }
+
+// Time returns the release time, when the entry carries one.
+func (v composerVersion) Time() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, v.stringField("time"))
+	return t, err == nil
+}
+
+// DistURL returns the dist archive URL, when the entry has a dist.
+func (v composerVersion) DistURL() string {
+	var dist struct {
+		URL string `json:"url"`
+	}
+	_ = json.Unmarshal(v["dist"], &dist)
+	return dist.URL
+}
+
+// composerMetadata is a p2/{vendor}/{package}.json document:
+//
+//	{"packages": {"vendor/package": [{...}, {...}]}, "minified": "composer/2.0"}
+//
+// Minified documents list the first version in full and every following
+// version as a diff against the one before it. Versions holds the expanded
+// entries of the requested package; the document is re-minified on encode.
+type composerMetadata struct {
+	document map[string]json.RawMessage
+	packages map[string][]composerVersion
+	name     string
+	minified bool
+
+	Versions []composerVersion
+}
+
+func parseComposerMetadata(body []byte, name string) (*composerMetadata, error) {
+	m := &composerMetadata{name: name}
+	if err := json.Unmarshal(body, &m.document); err != nil {
+		return nil, fmt.Errorf("failed to parse Composer metadata: %w", err)
+	}
+
+	if err := json.Unmarshal(m.document["packages"], &m.packages); err != nil {
+		return nil, fmt.Errorf("failed to parse Composer metadata packages: %w", err)
+	}
+
+	versions, ok := m.packages[name]
+	if !ok {
+		return nil, fmt.Errorf("Composer metadata does not list %s", name)
+	}
+
+	var format string
+	_ = json.Unmarshal(m.document["minified"], &format)
+	m.minified = format == composerMinifiedFormat
+
+	m.Versions = versions
+	if m.minified {
+		m.Versions = expandComposerVersions(versions)
+	}
+
+	return m, nil
+}
+
+// Encode returns the document with Versions in place of the original list,
+// minified again if it was minified.
+func (m *composerMetadata) Encode() ([]byte, error) {
+	versions := m.Versions
+	if m.minified {
+		versions = minifyComposerVersions(versions)
+	}
+
+	m.packages[m.name] = versions
+	packages, err := json.Marshal(m.packages)
+	if err != nil {
+		return nil, err
+	}
+
+	m.document["packages"] = packages
+	return json.Marshal(m.document)
+}
+
+// expandComposerVersions reverses Composer's MetadataMinifier::minify.
+func expandComposerVersions(versions []composerVersion) []composerVersion {
+	expanded := make([]composerVersion, 0, len(versions))
+	var current composerVersion
+	for _, version := range versions {
+		if current == nil {
+			current = maps.Clone(version)
+		} else {
+			for key, value := range version {
+				if bytes.Equal(value, composerUnset) {
+					delete(current, key)
+				} else {
+					current[key] = value
+				}
+			}
+		}
+		expanded = append(expanded, maps.Clone(current))
+	}
+
+	return expanded
+}
+
+// minifyComposerVersions mirrors Composer's MetadataMinifier::minify.
+func minifyComposerVersions(versions []composerVersion) []composerVersion {
+	minified := make([]composerVersion, 0, len(versions))
+	var last composerVersion
+	for _, version := range versions {
+		if last == nil {
+			last = maps.Clone(version)
+			minified = append(minified, maps.Clone(version))
+			continue
+		}
+
+		diff := composerVersion{}
+		for key, value := range version {
+			if previous, ok := last[key]; !ok || !bytes.Equal(previous, value) {
+				diff[key] = value
+				last[key] = value
+			}
+		}
+		for key := range last {
+			if _, ok := version[key]; !ok {
+				diff[key] = composerUnset
+				delete(last, key)
+			}
+		}
+		minified = append(minified, diff)
+	}
+
+	return minified
+}
@@ -0,0 +1,84 @@
+package interceptors
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// composerMinifiedFixture is p2 metadata as repo.packagist.org serves it:
+// v2.0.0 drops the "suggest" key of v2.1.0 and changes its time and dist.
+const composerMinifiedFixture = `{
+  "packages": {
+    "acme/lib": [
+      {"name": "acme/lib", "version": "v2.1.0", "time": "2024-03-01T00:00:00+00:00",
+       "dist": {"type": "zip", "url": "https://api.github.com/repos/acme/lib/zipball/bbb"},
+       "suggest": {"ext-intl": "faster"}},
+      {"version": "v2.0.0", "time": "2024-01-01T00:00:00+00:00",
+       "dist": {"type": "zip", "url": "https://api.github.com/repos/acme/lib/zipball/aaa"},
+       "suggest": "__unset"}
+    ]
+  },
+  "minified": "composer/2.0"
+}`
+
+func TestComposerMetadata_ExpandsMinifiedVersions(t *testing.T) {
+	metadata, err := parseComposerMetadata([]byte(composerMinifiedFixture), "acme/lib")
+	require.NoError(t, err)
+	require.Len(t, metadata.Versions, 2)
+
+	older := metadata.Versions[1]
+	assert.Equal(t, "v2.0.0", older.Version())
+	assert.Equal(t, "https://api.github.com/repos/acme/lib/zipball/aaa", older.DistURL())
+	assert.Equal(t, json.RawMessage(`"acme/lib"`), older["name"], "unchanged keys are inherited")
+	assert.NotContains(t, older, "suggest", "__unset removes the key")
+
+	published, ok := older.Time()
+	require.True(t, ok)
+	assert.Equal(t, 2024, published.Year())
+}
+
+func TestComposerMetadata_EncodeRoundTrips(t *testing.T) {
+	metadata, err := parseComposerMetadata([]byte(composerMinifiedFixture), "acme/lib")
+	require.NoError(t, err)
+
+	body, err := metadata.Encode()
+	require.NoError(t, err)
+
+	reparsed, err := parseComposerMetadata(body, "acme/lib")
+	require.NoError(t, err)
+	assert.Equal(t, metadata.Versions, reparsed.Versions)
+
+	var document struct {
+		Packages map[string][]map[string]json.RawMessage `json:"packages"`
+		Minified string                                  `json:"minified"`
+	}
+	require.NoError(t, json.Unmarshal(body, &document))
+	assert.Equal(t, composerMinifiedFormat, document.Minified)
+	assert.NotContains(t, document.Packages["acme/lib"][1], "name", "re-minified versions only carry changed keys")
+}
+
+func TestComposerMetadata_DroppingFirstVersionKeepsInheritedKeys(t *testing.T) {
+	metadata, err := parseComposerMetadata([]byte(composerMinifiedFixture), "acme/lib")
+	require.NoError(t, err)
+
+	metadata.Versions = metadata.Versions[1:]
+	body, err := metadata.Encode()
+	require.NoError(t, err)
+
+	reparsed, err := parseComposerMetadata(body, "acme/lib")
+	require.NoError(t, err)
+	require.Len(t, reparsed.Versions, 1)
+	assert.Equal(t, "v2.0.0", reparsed.Versions[0].Version())
+	assert.Equal(t, json.RawMessage(`"acme/lib"`), reparsed.Versions[0]["name"])
+}
+
+func TestComposerMetadata_Errors(t *testing.T) {
+	_, err := parseComposerMetadata([]byte(`not json`), "acme/lib")
+	assert.Error(t, err)
+
+	_, err = parseComposerMetadata([]byte(`{"packages": {"other/lib": []}}`), "acme/lib")
+	assert.Error(t, err)
+}
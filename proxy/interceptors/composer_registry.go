@@ -0,0 +1,161 @@
+package interceptors
+
+import (
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+var composerRegistryEndpoints = []registryEndpoint{
+	// repo.packagist.org serves p2 metadata; packagist.org receives install
+	// notifications.
+	builtInRegistryEndpoint("packagist.org", true, composerParser{}),
+}
+
+// composerDistEndpoints are the hosts Packagist dists are downloaded from.
+// They are not registries and stay out of the catalog, so custom registries
+// of other ecosystems can still be configured on gitlab.com and the like;
+// only requests for dists the interceptor knows are analyzed.
+var composerDistEndpoints = []registryEndpoint{
+	builtInRegistryEndpoint("api.github.com", true, composerParser{}),
+	builtInRegistryEndpoint("gitlab.com", true, composerParser{}),
+	builtInRegistryEndpoint("bitbucket.org", true, composerParser{}),
+}
+
+// ComposerRegistryInterceptor intercepts Composer repository requests. p2
+// metadata carries dependency cooldown and tells the interceptor which
+// package version each dist URL delivers; dist downloads are analyzed for
+// malware before they are served.
+type ComposerRegistryInterceptor struct {
+	baseRegistryInterceptor
+	cooldownHandler *composerCooldownHandler
+	dists           *composerDistIndex
+	registries      registrySet
+	distHosts       registrySet
+}
+
+var _ proxy.Interceptor = (*ComposerRegistryInterceptor)(nil)
+var _ proxy.MITMDecider = (*ComposerRegistryInterceptor)(nil)
+
+func newComposerRegistryInterceptor(
+	analyzer analyzer.PackageVersionAnalyzer,
+	cache AnalysisCache,
+	statsCollector *AnalysisStatsCollector,
+	confirmationChan chan *ConfirmationRequest,
+	execContext InterceptorContext,
+	registries registrySet,
+) *ComposerRegistryInterceptor {
+	dists := newComposerDistIndex(execContext.KnownArtifacts)
+
+	return &ComposerRegistryInterceptor{
+		baseRegistryInterceptor: baseRegistryInterceptor{
+			analyzer:         analyzer,
+			cache:            cache,
+			statsCollector:   statsCollector,
+			confirmationChan: confirmationChan,
+			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-packagist"),
+			execContext:      execContext,
+		},
+		cooldownHandler: newComposerCooldownHandler(statsCollector, dists),
+		dists:           dists,
+		registries:      registries,
+		distHosts:       registrySet{entries: append([]registryEndpoint(nil), composerDistEndpoints...)},
+	}
+}
+
+func (i *ComposerRegistryInterceptor) Name() string {
+	return "composer-registry-interceptor"
+}
+
+func (i *ComposerRegistryInterceptor) ShouldMITM(ctx *proxy.RequestContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return registryHostSupportsAnalysis(i.registries, ctx.Hostname, ctx.Port) ||
+		registryHostSupportsAnalysis(i.distHosts, ctx.Hostname, ctx.Port)
+}
+
+func (i *ComposerRegistryInterceptor) ShouldIntercept(ctx *proxy.RequestContext) bool {
+	return registryRequestMatch(i.registries, ctx) != nil || registryRequestMatch(i.distHosts, ctx) != nil
+}
+
+// HandleRequest processes the request and returns response action.
+// We take a fail-open approach here, allowing requests that we can't parse the
+// package information from the URL.
+func (i *ComposerRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy.InterceptorResponse, error) {
+	log.Debugf("[%s] Handling Composer registry request: %s", ctx.RequestID, ctx.URL.Path)
+
+	if ctx.Method != http.MethodGet && ctx.Method != http.MethodHead {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	// Dists are identified by URL, whichever host serves them.
+	if pv, ok := i.dists.Lookup(registryAbsoluteRequestURL(ctx)); ok {
+		name, version := pv.GetPackage().GetName(), pv.GetVersion()
+		resp, err := i.handleArtifact(ctx, name, version)
+		if err != nil {
+			return resp, err
+		}
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, "", name, version, resp), nil
+	}
+
+	match := registryRequestMatch(i.registries, ctx)
+	if match == nil {
+		log.Debugf("[%s] Allowing unknown Composer dist: %s", ctx.RequestID, ctx.URL.String())
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	endpoint := match.Endpoint
+	if !endpoint.Analyze {
+		log.Debugf("[%s] Skipping analysis for %s registry (not supported for analysis): %s",
+			ctx.RequestID, endpoint.Host, ctx.URL.String())
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	pkgInfo, err := endpoint.Parser.ParseURL(match.RelativePath)
+	if err != nil {
+		logRegistryParseFailure(ctx, endpoint, "Composer", err)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	info, ok := pkgInfo.(*composerPackageInfo)
+	if !ok || !info.isMetadata {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	return i.handleMetadataRequest(ctx, info)
+}
+
+// handleMetadataRequest reads p2 metadata for dist URLs and, for tagged
+// releases, applies dependency cooldown. Dev branches have no release to
+// wait for.
+func (i *ComposerRegistryInterceptor) handleMetadataRequest(ctx *proxy.RequestContext, info *composerPackageInfo) (*proxy.InterceptorResponse, error) {
+	name := info.GetName()
+
+	cooldownDays := 0
+	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
+	if depCooldownConfig.Enabled && !info.isDev && !pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name) {
+		cooldownDays = depCooldownConfig.Days
+	}
+
+	return i.cooldownHandler.HandleMetadataRequest(ctx, name, cooldownDays, i.execContext.PinnedVersions[name])
+}
+
+// handleArtifact runs the trust, analysis, and verdict pipeline for a dist
+// download.
+func (i *ComposerRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, name, version string) (*proxy.InterceptorResponse, error) {
+	if resp, ok := i.fastAllow(ctx, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name, version); ok {
+		return resp, nil
+	}
+
+	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name, version)
+	if err != nil {
+		return i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name, version, err), nil
+	}
+
+	return i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, name, version, result)
+}
@@ -0,0 +1,115 @@
+package interceptors
+
+import (
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestComposerInterceptor(a analyzer.PackageVersionAnalyzer, execContext InterceptorContext) *ComposerRegistryInterceptor {
+	return newComposerRegistryInterceptor(a, NewInMemoryAnalysisCache(), NewAnalysisStatsCollector(),
+		make(chan *ConfirmationRequest, 1), execContext,
+		newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST))
+}
+
+func TestComposerRegistryInterceptor_ShouldIntercept(t *testing.T) {
+	interceptor := newTestComposerInterceptor(nil, InterceptorContext{})
+
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"https://repo.packagist.org/p2/symfony/console.json", true},
+		{"https://api.github.com/repos/symfony/console/zipball/abc", true},
+		{"https://gitlab.com/api/v4/projects/1/repository/archive.zip?sha=abc", true},
+		{"https://registry.npmjs.org/symfony", false},
+	}
+
+	for _, tc := range cases {
+		ctx := registryRequest(t, tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldIntercept(ctx), tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldMITM(ctx), tc.url)
+	}
+}
+
+func TestComposerRegistryInterceptor_DistHostsStayOutOfCatalog(t *testing.T) {
+	assert.Nil(t, newBuiltInRegistryCatalog().builtInForHostname("gitlab.com"),
+		"custom registries of other ecosystems may live on dist hosts")
+	assert.NotNil(t, newBuiltInRegistryCatalog().builtInForHostname("repo.packagist.org"))
+}
+
+func TestComposerRegistryInterceptor_BlocksMaliciousLockedDist(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	distURL := "https://api.github.com/repos/evil/lib/zipball/abc"
+	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, Name: "evil/lib"},
+			Version: "1.0.0",
+		},
+		Action:  analyzer.ActionBlock,
+		Summary: "Contains known malware",
+	}}
+	interceptor := newTestComposerInterceptor(mock, InterceptorContext{
+		KnownArtifacts: map[string]*packagev1.PackageVersion{
+			distURL: {
+				Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, Name: "evil/lib"},
+				Version: "1.0.0",
+			},
+		},
+	})
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext(distURL))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, mock.callCount)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+	require.NotNil(t, resp.BlockContext)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_PACKAGIST, resp.BlockContext.Ecosystem)
+	assert.Equal(t, "evil/lib", resp.BlockContext.PackageName)
+}
+
+func TestComposerRegistryInterceptor_UnknownDistPassesThrough(t *testing.T) {
+	mock := &mockAnalyzer{}
+	interceptor := newTestComposerInterceptor(mock, InterceptorContext{})
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://api.github.com/repos/acme/lib/zipball/abc"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+	assert.Equal(t, 0, mock.callCount)
+}
+
+func TestComposerRegistryInterceptor_MetadataIsAlwaysRead(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+	interceptor := newTestComposerInterceptor(&mockAnalyzer{}, InterceptorContext{})
+
+	for _, enabled := range []bool{false, true} {
+		setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: enabled, Days: 5})
+
+		resp, err := interceptor.HandleRequest(makeTestRequestContext("https://repo.packagist.org/p2/acme/lib.json"))
+		require.NoError(t, err)
+		assert.Equal(t, proxy.ActionModifyResponse, resp.Action, "dist URLs are learned from metadata")
+
+		resp, err = interceptor.HandleRequest(makeTestRequestContext("https://repo.packagist.org/packages.json"))
+		require.NoError(t, err)
+		assert.Equal(t, proxy.ActionAllow, resp.Action)
+	}
+}
+
+func TestInterceptorFactoryCreatesComposerInterceptor(t *testing.T) {
+	assert.True(t, IsSupported(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST))
+
+	factory, err := NewInterceptorFactory(nil, nil, nil, nil, InterceptorContext{}, nil)
+	require.NoError(t, err)
+
+	got, err := factory.CreateInterceptor(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST)
+	require.NoError(t, err)
+	assert.Equal(t, "composer-registry-interceptor", got.Name())
+}
@@ -0,0 +1,69 @@
+package interceptors
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// composerPackageNamePattern matches a Composer package name, vendor/package,
+// as Packagist validates it.
+var composerPackageNamePattern = regexp.MustCompile(`^[a-z0-9]([_.-]?[a-z0-9]+)*/[a-z0-9](([_.]|-{1,2})?[a-z0-9]+)*$`)
+
+// composerPackageInfo is parsed package information from a Composer
+// repository URL.
+type composerPackageInfo struct {
+	name string
+
+	// isMetadata marks p2/{vendor}/{package}.json; isDev marks the ~dev.json
+	// variant, which lists branches rather than releases.
+	isMetadata bool
+	isDev      bool
+}
+
+var _ packageInfo = (*composerPackageInfo)(nil)
+
+func (c *composerPackageInfo) GetName() string { return c.name }
+
+func (c *composerPackageInfo) GetVersion() string { return "" }
+
+// IsFileDownload is always false: dist URLs do not name the package and are
+// identified from metadata and composer.lock instead, see composerDistIndex.
+func (c *composerPackageInfo) IsFileDownload() bool { return false }
+
+// composerParser parses Composer v2 repository metadata URLs, relative to
+// the repository base path:
+//
+//	/p2/{vendor}/{package}.json      -> tagged releases (minified)
+//	/p2/{vendor}/{package}~dev.json  -> dev branches (minified)
+//	/packages.json                   -> repository root
+//
+// Everything else, including Composer 1 provider files, passes through.
+type composerParser struct{}
+
+var _ registryURLParser = composerParser{}
+
+func (p composerParser) ParseURL(urlPath string) (packageInfo, error) {
+	urlPath = strings.Trim(urlPath, "/")
+	if urlPath == "" {
+		return nil, fmt.Errorf("empty Composer URL path")
+	}
+
+	segments := strings.Split(urlPath, "/")
+	if len(segments) != 3 || segments[0] != "p2" {
+		return &composerPackageInfo{}, nil
+	}
+
+	file, ok := strings.CutSuffix(segments[2], ".json")
+	if !ok {
+		return &composerPackageInfo{}, nil
+	}
+
+	file, isDev := strings.CutSuffix(file, "~dev")
+	name := segments[1] + "/" + file
+	if !composerPackageNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid Composer package name in URL: %q", name)
+	}
+
+	return &composerPackageInfo{name: name, isMetadata: true, isDev: isDev}, nil
+}
@@ -0,0 +1,45 @@
+package interceptors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposerParser(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		pkg        string
+		isMetadata bool
+		isDev      bool
+		wantErr    bool
+	}{
+		{name: "tagged releases", path: "/p2/symfony/console.json", pkg: "symfony/console", isMetadata: true},
+		{name: "dev branches", path: "/p2/symfony/console~dev.json", pkg: "symfony/console", isMetadata: true, isDev: true},
+		{name: "name with dots and dashes", path: "/p2/league/flysystem-aws-s3-v3.json", pkg: "league/flysystem-aws-s3-v3", isMetadata: true},
+		{name: "repository root", path: "/packages.json"},
+		{name: "composer 1 provider", path: "/p/provider-2024$abc.json"},
+		{name: "non-json p2 file", path: "/p2/symfony/console.zip"},
+		{name: "uppercase name", path: "/p2/Symfony/Console.json", wantErr: true},
+		{name: "empty path", path: "/", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := composerParser{}.ParseURL(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.pkg, info.GetName())
+			assert.Empty(t, info.GetVersion())
+			assert.False(t, info.IsFileDownload())
+			assert.Equal(t, tc.isMetadata, info.(*composerPackageInfo).isMetadata)
+			assert.Equal(t, tc.isDev, info.(*composerPackageInfo).isDev)
+		})
+	}
+}
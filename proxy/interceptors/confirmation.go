@@ -51,7 +51,11 @@ func HandleConfirmationRequests(confirmationChan chan *ConfirmationRequest,
 			}()
 
 			packageName := req.PackageVersion.GetPackage().GetName()
-			log.Debugf("Processing confirmation request for package %s", packageName)
+			if target := req.AnalysisResult.SuspectedTarget; target != "" {
+				log.Debugf("Processing confirmation request for package %s (suspected typosquat of %s)", packageName, target)
+			} else {
+				log.Debugf("Processing confirmation request for package %s", packageName)
+			}
 
 			// Hook to allow the caller to customize the confirmation process.
 			// Hook failures are non-fatal and will not break the confirmation process.
@@ -0,0 +1,176 @@
+package interceptors
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/contentscan"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+)
+
+// contentRules holds the rule packs shared by every registry interceptor.
+var contentRules = &contentRuleLoader{}
+
+// contentRuleLoader loads the content scan rule packs. The rules directory
+// is checked on every artifact download and reloaded when its files change,
+// so a pack dropped in while a proxy runs applies to the next download.
+type contentRuleLoader struct {
+	mu          sync.Mutex
+	dir         string
+	fingerprint string
+	scanner     *contentscan.Scanner
+}
+
+// Scanner returns a scanner for the rule packs in dir.
+func (l *contentRuleLoader) Scanner(dir string) *contentscan.Scanner {
+	fingerprint := contentRulesFingerprint(dir)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.scanner != nil && l.dir == dir && l.fingerprint == fingerprint {
+		return l.scanner
+	}
+
+	packs, err := contentscan.LoadDir(dir)
+	if err != nil {
+		log.Warnf("Content scan: some rule packs in %s were not loaded: %v", dir, err)
+	}
+	if len(packs) > 0 {
+		log.Infof("Content scan: loaded %d rule pack(s) from %s", len(packs), dir)
+	}
+
+	l.dir = dir
+	l.fingerprint = fingerprint
+	l.scanner = contentscan.NewScanner(packs)
+	return l.scanner
+}
+
+// contentRulesFingerprint summarizes the names, sizes and modification times
+// of the files in dir, to tell when the rule packs need reloading.
+func contentRulesFingerprint(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// contentScanInspector returns the inspector that scans an artifact against
+// the content scan rule packs, or nil when scanning is disabled, the
+// artifact format is not known, or no rule pack is installed. root is
+// stripped from zip entry names (see contentscan.FormatZip).
+func (b *baseRegistryInterceptor) contentScanInspector(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	format contentscan.Format,
+	root string,
+) *artifactInspector {
+	cfg := config.Get()
+	if !cfg.Config.ContentScan.Enabled || format == contentscan.FormatUnknown {
+		return nil
+	}
+
+	scanner := contentRules.Scanner(cfg.ContentRulesDir())
+	if scanner.Empty() {
+		return nil
+	}
+
+	return &artifactInspector{check: func(artifact []byte) *proxy.ResponseBlock {
+		return b.applyContentRules(ctx, ecosystem, packageName, packageVersion, scanner, format, root, artifact)
+	}}
+}
+
+// applyContentRules scans an artifact and returns the block for its first
+// matching rule, or nil when the download may continue. Block rules take
+// precedence over confirm rules. An artifact that cannot be scanned in full
+// fails open for the files that were not read.
+func (b *baseRegistryInterceptor) applyContentRules(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	scanner *contentscan.Scanner,
+	format contentscan.Format,
+	root string,
+	artifact []byte,
+) *proxy.ResponseBlock {
+	label := strings.ToLower(strings.TrimPrefix(ecosystem.String(), "ECOSYSTEM_"))
+	matches, err := scanner.ScanArchive(label, format, artifact, root)
+	if err != nil {
+		log.Warnf("[%s] Content scan of %s/%s@%s incomplete: %v",
+			ctx.RequestID, ecosystem.String(), packageName, packageVersion, err)
+	}
+	if len(matches) == 0 {
+		log.Debugf("[%s] Content scan: no rule matched %s/%s@%s", ctx.RequestID, ecosystem.String(), packageName, packageVersion)
+		return nil
+	}
+
+	match := matches[0]
+	for _, m := range matches {
+		if m.Action == contentscan.ActionBlock {
+			match = m
+			break
+		}
+	}
+
+	pkgVersion := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: ecosystem, Name: packageName},
+		Version: packageVersion,
+	}
+	summary := fmt.Sprintf("Content rule %s/%s matched %s", match.Pack, match.Rule, match.File)
+	if match.Description != "" {
+		summary += ": " + match.Description
+	}
+
+	// Content rule actions are confirm or block, spelled as policy actions.
+	if b.applyPolicyAction(ctx, "Content scan", pkgVersion, match.Action, summary) == policyConfirmed {
+		audit.LogContentRule(pkgVersion, match.Pack, match.Rule, match.File, audit.ContentRuleConfirmed)
+		return nil
+	}
+
+	audit.LogContentRule(pkgVersion, match.Pack, match.Rule, match.File, audit.ContentRuleBlocked)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordContentRuleBlocked(models.ContentRuleBlock{
+			Name:    packageName,
+			Version: packageVersion,
+			Pack:    match.Pack,
+			Rule:    match.Rule,
+			File:    match.File,
+		})
+	}
+
+	return &proxy.ResponseBlock{
+		Code:   http.StatusForbidden,
+		Reason: proxy.BlockReasonContentRule,
+		Context: &proxy.BlockContext{
+			Ecosystem:       ecosystem,
+			PackageName:     packageName,
+			PackageVersion:  packageVersion,
+			RulePack:        match.Pack,
+			RuleName:        match.Rule,
+			RuleDescription: match.Description,
+			RuleFile:        match.File,
+		},
+	}
+}
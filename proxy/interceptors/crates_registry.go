@@ -0,0 +1,136 @@
+package interceptors
+
+import (
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+var cratesRegistryEndpoints = []registryEndpoint{
+	builtInRegistryEndpoint("index.crates.io", true, cratesIndexParser{}),
+	builtInRegistryEndpoint("static.crates.io", true, cratesStaticParser{}),
+}
+
+// CratesRegistryInterceptor intercepts crates.io sparse index and crate
+// download requests. Index files carry dependency cooldown; .crate downloads
+// from static.crates.io are analyzed for malware.
+type CratesRegistryInterceptor struct {
+	baseRegistryInterceptor
+	cooldownHandler *cratesCooldownHandler
+	registries      registrySet
+}
+
+var _ proxy.Interceptor = (*CratesRegistryInterceptor)(nil)
+var _ proxy.MITMDecider = (*CratesRegistryInterceptor)(nil)
+
+func newCratesRegistryInterceptor(
+	analyzer analyzer.PackageVersionAnalyzer,
+	cache AnalysisCache,
+	statsCollector *AnalysisStatsCollector,
+	confirmationChan chan *ConfirmationRequest,
+	execContext InterceptorContext,
+	registries registrySet,
+) *CratesRegistryInterceptor {
+	return &CratesRegistryInterceptor{
+		baseRegistryInterceptor: baseRegistryInterceptor{
+			analyzer:         analyzer,
+			cache:            cache,
+			statsCollector:   statsCollector,
+			confirmationChan: confirmationChan,
+			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-cargo"),
+			execContext:      execContext,
+		},
+		cooldownHandler: newCratesCooldownHandler(statsCollector),
+		registries:      registries,
+	}
+}
+
+func (i *CratesRegistryInterceptor) Name() string {
+	return "crates-registry-interceptor"
+}
+
+func (i *CratesRegistryInterceptor) ShouldMITM(ctx *proxy.RequestContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return registryHostSupportsAnalysis(i.registries, ctx.Hostname, ctx.Port)
+}
+
+func (i *CratesRegistryInterceptor) ShouldIntercept(ctx *proxy.RequestContext) bool {
+	return registryRequestMatch(i.registries, ctx) != nil
+}
+
+// HandleRequest processes the request and returns response action.
+// We take a fail-open approach here, allowing requests that we can't parse the
+// crate information from the URL.
+func (i *CratesRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy.InterceptorResponse, error) {
+	log.Debugf("[%s] Handling crates.io registry request: %s", ctx.RequestID, ctx.URL.Path)
+
+	match := registryRequestMatch(i.registries, ctx)
+	if match == nil {
+		log.Warnf("[%s] No registry config found for hostname: %s", ctx.RequestID, ctx.Hostname)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if ctx.Method != http.MethodGet && ctx.Method != http.MethodHead {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	endpoint := match.Endpoint
+	if !endpoint.Analyze {
+		log.Debugf("[%s] Skipping analysis for %s registry (not supported for analysis): %s",
+			ctx.RequestID, endpoint.Host, ctx.URL.String())
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	pkgInfo, err := endpoint.Parser.ParseURL(match.RelativePath)
+	if err != nil {
+		logRegistryParseFailure(ctx, endpoint, "crates.io", err)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if packageInfoHasCompleteIdentity(pkgInfo) {
+		name, version := pkgInfo.GetName(), pkgInfo.GetVersion()
+		resp, err := i.handleArtifact(ctx, name, version)
+		if err != nil {
+			return resp, err
+		}
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_CARGO, endpoint.Name, name, version, resp), nil
+	}
+
+	if !pkgInfo.IsFileDownload() && pkgInfo.GetName() != "" {
+		return i.handleMetadataRequest(ctx, pkgInfo.GetName())
+	}
+
+	return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+}
+
+// handleMetadataRequest applies dependency cooldown to a sparse index file.
+func (i *CratesRegistryInterceptor) handleMetadataRequest(ctx *proxy.RequestContext, crateName string) (*proxy.InterceptorResponse, error) {
+	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
+	if !depCooldownConfig.Enabled || pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_CARGO, crateName) {
+		log.Debugf("[%s] Skipping analysis for index request: %s", ctx.RequestID, crateName)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	return i.cooldownHandler.HandleMetadataRequest(ctx, crateName, depCooldownConfig.Days, i.execContext.PinnedVersions[crateName])
+}
+
+// handleArtifact runs the trust, analysis, and verdict pipeline for a .crate
+// download.
+func (i *CratesRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, name, version string) (*proxy.InterceptorResponse, error) {
+	if resp, ok := i.fastAllow(ctx, packagev1.Ecosystem_ECOSYSTEM_CARGO, name, version); ok {
+		return resp, nil
+	}
+
+	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_CARGO, name, version)
+	if err != nil {
+		return i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_CARGO, name, version, err), nil
+	}
+
+	return i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_CARGO, name, version, result)
+}
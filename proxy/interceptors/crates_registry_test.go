@@ -0,0 +1,108 @@
+package interceptors
+
+import (
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCratesInterceptor(a analyzer.PackageVersionAnalyzer) *CratesRegistryInterceptor {
+	return newCratesRegistryInterceptor(a, NewInMemoryAnalysisCache(), NewAnalysisStatsCollector(),
+		make(chan *ConfirmationRequest, 1), InterceptorContext{},
+		newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_CARGO))
+}
+
+func TestCratesRegistryInterceptor_ShouldIntercept(t *testing.T) {
+	interceptor := newTestCratesInterceptor(nil)
+
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"https://index.crates.io/se/rd/serde", true},
+		{"https://static.crates.io/crates/serde/serde-1.0.200.crate", true},
+		{"https://crates.io/api/v1/crates/serde", false},
+		{"https://registry.npmjs.org/serde", false},
+	}
+
+	for _, tc := range cases {
+		ctx := registryRequest(t, tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldIntercept(ctx), tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldMITM(ctx), tc.url)
+	}
+}
+
+func TestCratesRegistryInterceptor_BlocksMaliciousCrate(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_CARGO, Name: "evil-crate"},
+			Version: "0.1.0",
+		},
+		Action:  analyzer.ActionBlock,
+		Summary: "Contains known malware",
+	}}
+	interceptor := newTestCratesInterceptor(mock)
+
+	ctx := makeTestRequestContext("https://static.crates.io/crates/evil-crate/evil-crate-0.1.0.crate")
+	resp, err := interceptor.HandleRequest(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, mock.callCount)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+	assert.Equal(t, proxy.BlockReasonMalware, resp.BlockReason)
+	require.NotNil(t, resp.BlockContext)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_CARGO, resp.BlockContext.Ecosystem)
+	assert.Equal(t, "evil-crate", resp.BlockContext.PackageName)
+	assert.Equal(t, "0.1.0", resp.BlockContext.PackageVersion)
+}
+
+func TestCratesRegistryInterceptor_IndexRequestAppliesCooldown(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	for _, enabled := range []bool{true, false} {
+		setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: enabled, Days: 5})
+
+		mock := &mockAnalyzer{}
+		interceptor := newTestCratesInterceptor(mock)
+
+		resp, err := interceptor.HandleRequest(makeTestRequestContext("https://index.crates.io/se/rd/serde"))
+		require.NoError(t, err)
+		assert.Equal(t, 0, mock.callCount, "index requests are never analyzed")
+
+		if enabled {
+			assert.Equal(t, proxy.ActionModifyResponse, resp.Action)
+		} else {
+			assert.Equal(t, proxy.ActionAllow, resp.Action)
+		}
+	}
+}
+
+func TestCratesRegistryInterceptor_UnparseableDownloadAllowed(t *testing.T) {
+	mock := &mockAnalyzer{}
+	interceptor := newTestCratesInterceptor(mock)
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://static.crates.io/rss/crates/serde.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+	assert.Equal(t, 0, mock.callCount)
+}
+
+func TestInterceptorFactoryCreatesCratesInterceptor(t *testing.T) {
+	assert.True(t, IsSupported(packagev1.Ecosystem_ECOSYSTEM_CARGO))
+
+	factory, err := NewInterceptorFactory(nil, nil, nil, nil, InterceptorContext{}, nil)
+	require.NoError(t, err)
+
+	got, err := factory.CreateInterceptor(packagev1.Ecosystem_ECOSYSTEM_CARGO)
+	require.NoError(t, err)
+	assert.Equal(t, "crates-registry-interceptor", got.Name())
+}
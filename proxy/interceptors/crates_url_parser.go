@@ -0,0 +1,142 @@
+package interceptors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cratesPackageInfo is parsed crate information from a crates.io sparse index
+// or crate download URL.
+type cratesPackageInfo struct {
+	name       string
+	version    string
+	isDownload bool
+}
+
+var _ packageInfo = (*cratesPackageInfo)(nil)
+
+func (c *cratesPackageInfo) GetName() string { return c.name }
+
+func (c *cratesPackageInfo) GetVersion() string { return c.version }
+
+func (c *cratesPackageInfo) IsFileDownload() bool { return c.isDownload }
+
+// cratesIndexParser parses sparse index URLs from index.crates.io
+// (https://doc.rust-lang.org/cargo/reference/registry-index.html#index-files):
+//
+//	/config.json           -> registry configuration
+//	/1/{name}              -> one-character names
+//	/2/{name}              -> two-character names
+//	/3/{c}/{name}          -> three-character names, c is the first character
+//	/{ab}/{cd}/{name}      -> longer names, ab and cd are characters 1-2 and 3-4
+//
+// Index files list every published version of a crate, one JSON object per
+// line. Prefix directories are lowercase; a path whose prefix does not match
+// the name is rejected.
+type cratesIndexParser struct{}
+
+var _ registryURLParser = cratesIndexParser{}
+
+func (p cratesIndexParser) ParseURL(urlPath string) (packageInfo, error) {
+	urlPath = strings.Trim(urlPath, "/")
+	if urlPath == "" {
+		return nil, fmt.Errorf("empty crates index URL path")
+	}
+
+	if urlPath == "config.json" {
+		return &cratesPackageInfo{}, nil
+	}
+
+	segments := strings.Split(urlPath, "/")
+	name := segments[len(segments)-1]
+	if !isValidCrateName(name) {
+		return nil, fmt.Errorf("invalid crate name in index URL: %q", name)
+	}
+
+	prefix := strings.Join(segments[:len(segments)-1], "/")
+	if prefix != cratesIndexPrefix(name) {
+		return nil, fmt.Errorf("crates index path %q does not match crate %q", urlPath, name)
+	}
+
+	return &cratesPackageInfo{name: name}, nil
+}
+
+// cratesIndexPrefix returns the sparse index directory for a crate name.
+func cratesIndexPrefix(name string) string {
+	lower := strings.ToLower(name)
+	switch len(lower) {
+	case 1:
+		return "1"
+	case 2:
+		return "2"
+	case 3:
+		return "3/" + lower[:1]
+	default:
+		return lower[:2] + "/" + lower[2:4]
+	}
+}
+
+// cratesStaticParser parses crate download URLs from static.crates.io, in
+// either form:
+//
+//	/crates/{name}/{version}/download       -> the index's dl URL, which cargo requests
+//	/crates/{name}/{name}-{version}.crate   -> the file it is stored as
+//
+// Crate names may contain hyphens, so the version of a file name is whatever
+// follows the "{name}-" prefix rather than the last hyphen.
+type cratesStaticParser struct{}
+
+var _ registryURLParser = cratesStaticParser{}
+
+func (p cratesStaticParser) ParseURL(urlPath string) (packageInfo, error) {
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+	if len(segments) == 4 && segments[0] == "crates" && segments[3] == "download" {
+		name, version := segments[1], segments[2]
+		if !isValidCrateName(name) {
+			return nil, fmt.Errorf("invalid crate name in download URL: %q", name)
+		}
+		if version == "" {
+			return nil, fmt.Errorf("crates download URL has no version: %q", urlPath)
+		}
+		return &cratesPackageInfo{name: name, version: version, isDownload: true}, nil
+	}
+
+	if len(segments) != 3 || segments[0] != "crates" {
+		return nil, fmt.Errorf("crates download URL must be /crates/{name}/{version}/download or /crates/{name}/{name}-{version}.crate: %q", urlPath)
+	}
+
+	name, filename := segments[1], segments[2]
+	if !isValidCrateName(name) {
+		return nil, fmt.Errorf("invalid crate name in download URL: %q", name)
+	}
+
+	stem, ok := strings.CutSuffix(filename, ".crate")
+	if !ok {
+		return nil, fmt.Errorf("crates download URL is not a .crate file: %q", filename)
+	}
+
+	version, ok := strings.CutPrefix(stem, name+"-")
+	if !ok || version == "" {
+		return nil, fmt.Errorf("crate file %q does not match crate %q", filename, name)
+	}
+
+	return &cratesPackageInfo{name: name, version: version, isDownload: true}, nil
+}
+
+// isValidCrateName reports whether name uses only the characters crates.io
+// accepts: ASCII alphanumerics, '-' and '_'.
+func isValidCrateName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,80 @@
+package interceptors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCratesIndexParser(t *testing.T) {
+	cases := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "config.json", path: "/config.json", want: ""},
+		{name: "one-character name", path: "/1/a", want: "a"},
+		{name: "two-character name", path: "/2/cc", want: "cc"},
+		{name: "three-character name", path: "/3/l/log", want: "log"},
+		{name: "long name", path: "/se/rd/serde", want: "serde"},
+		{name: "name with hyphen and underscore", path: "/se/rd/serde_json-x", want: "serde_json-x"},
+		{name: "prefix mismatch", path: "/ab/cd/serde", wantErr: true},
+		{name: "wrong length bucket", path: "/1/ab", wantErr: true},
+		{name: "invalid characters", path: "/se/rd/se.rde", wantErr: true},
+		{name: "empty path", path: "/", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := cratesIndexParser{}.ParseURL(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, info.GetName())
+			assert.Empty(t, info.GetVersion())
+			assert.False(t, info.IsFileDownload())
+		})
+	}
+}
+
+func TestCratesStaticParser(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		wantName    string
+		wantVersion string
+		wantErr     bool
+	}{
+		{name: "simple crate", path: "/crates/serde/serde-1.0.200.crate", wantName: "serde", wantVersion: "1.0.200"},
+		{name: "hyphenated name", path: "/crates/tokio-util/tokio-util-0.7.11.crate", wantName: "tokio-util", wantVersion: "0.7.11"},
+		{name: "prerelease version", path: "/crates/foo/foo-1.0.0-rc.1.crate", wantName: "foo", wantVersion: "1.0.0-rc.1"},
+		{name: "dl download", path: "/crates/serde/1.0.200/download", wantName: "serde", wantVersion: "1.0.200"},
+		{name: "dl download prerelease", path: "/crates/tokio-util/0.8.0-alpha.1/download", wantName: "tokio-util", wantVersion: "0.8.0-alpha.1"},
+		{name: "dl download without version", path: "/crates/serde//download", wantErr: true},
+		{name: "dl download invalid name", path: "/crates/ser.de/1.0.0/download", wantErr: true},
+		{name: "name mismatch", path: "/crates/serde/other-1.0.0.crate", wantErr: true},
+		{name: "not a crate file", path: "/crates/serde/serde-1.0.0.tar.gz", wantErr: true},
+		{name: "missing crates prefix", path: "/serde/serde-1.0.0.crate", wantErr: true},
+		{name: "missing version", path: "/crates/serde/serde-.crate", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := cratesStaticParser{}.ParseURL(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantName, info.GetName())
+			assert.Equal(t, tc.wantVersion, info.GetVersion())
+			assert.True(t, info.IsFileDownload())
+		})
+	}
+}
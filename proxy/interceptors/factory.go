@@ -6,6 +6,7 @@ import (
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 	"github.com/safedep/pmg/analyzer"
 	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/lockfile"
 	"github.com/safedep/pmg/proxy"
 )
 
@@ -20,6 +21,20 @@ type InterceptorContext struct {
 	// prefix). Go registry routing is derived dynamically from GOPROXY and
 	// remains separate from the npm/PyPI registry catalog.
 	GoProxyBaseURLs map[string]string
+
+	// KnownArtifacts maps artifact URLs to the package version each one
+	// delivers, for downloads whose URL does not name the package, such as
+	// Composer dists locked in composer.lock.
+	KnownArtifacts map[string]*packagev1.PackageVersion
+
+	// Lockfile is the project lockfile a manifest install runs from, or nil.
+	// Artifact downloads are checked against it (lockfile_integrity).
+	Lockfile *lockfile.Lockfile
+
+	// RecordFetched records every artifact download delivered to the
+	// package manager, with the SHA-256 of its bytes, in the stats collector
+	// for an SBOM. Hashing holds each artifact in memory.
+	RecordFetched bool
 }
 
 // InterceptorFactory creates ecosystem-specific interceptors for the proxy
@@ -88,6 +103,56 @@ func (f *InterceptorFactory) CreateInterceptor(ecosystem packagev1.Ecosystem) (p
 			f.execContext,
 		), nil
 
+	case packagev1.Ecosystem_ECOSYSTEM_CARGO:
+		return newCratesRegistryInterceptor(
+			f.analyzer,
+			f.cache,
+			f.statsCollector,
+			f.confirmationChan,
+			f.execContext,
+			f.registries.registrySet(packagev1.Ecosystem_ECOSYSTEM_CARGO),
+		), nil
+
+	case packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS:
+		return newRubyGemsRegistryInterceptor(
+			f.analyzer,
+			f.cache,
+			f.statsCollector,
+			f.confirmationChan,
+			f.execContext,
+			f.registries.registrySet(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS),
+		), nil
+
+	case packagev1.Ecosystem_ECOSYSTEM_MAVEN:
+		return newMavenRegistryInterceptor(
+			f.analyzer,
+			f.cache,
+			f.statsCollector,
+			f.confirmationChan,
+			f.execContext,
+			f.registries.registrySet(packagev1.Ecosystem_ECOSYSTEM_MAVEN),
+		), nil
+
+	case packagev1.Ecosystem_ECOSYSTEM_NUGET:
+		return newNuGetRegistryInterceptor(
+			f.analyzer,
+			f.cache,
+			f.statsCollector,
+			f.confirmationChan,
+			f.execContext,
+			f.registries.registrySet(packagev1.Ecosystem_ECOSYSTEM_NUGET),
+		), nil
+
+	case packagev1.Ecosystem_ECOSYSTEM_PACKAGIST:
+		return newComposerRegistryInterceptor(
+			f.analyzer,
+			f.cache,
+			f.statsCollector,
+			f.confirmationChan,
+			f.execContext,
+			f.registries.registrySet(packagev1.Ecosystem_ECOSYSTEM_PACKAGIST),
+		), nil
+
 	default:
 		return nil, fmt.Errorf("proxy-based interception not yet supported for ecosystem: %s", ecosystem.String())
 	}
@@ -111,6 +176,11 @@ func SupportedEcosystems() []packagev1.Ecosystem {
 		packagev1.Ecosystem_ECOSYSTEM_NPM,
 		packagev1.Ecosystem_ECOSYSTEM_PYPI,
 		packagev1.Ecosystem_ECOSYSTEM_GO,
+		packagev1.Ecosystem_ECOSYSTEM_CARGO,
+		packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS,
+		packagev1.Ecosystem_ECOSYSTEM_MAVEN,
+		packagev1.Ecosystem_ECOSYSTEM_NUGET,
+		packagev1.Ecosystem_ECOSYSTEM_PACKAGIST,
 	}
 }
 
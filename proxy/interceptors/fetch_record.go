@@ -0,0 +1,124 @@
+package interceptors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+)
+
+// fetchVerdictKey is the RequestContext.Data key under which the artifact
+// pipeline leaves the verdict a download was allowed with, for recordFetch.
+const fetchVerdictKey = "fetch_verdict"
+
+func setFetchVerdict(ctx *proxy.RequestContext, verdict string) {
+	if ctx != nil && ctx.Data != nil {
+		ctx.Data[fetchVerdictKey] = verdict
+	}
+}
+
+// recordFetch turns an allowed artifact download into one whose delivered
+// bytes are hashed and recorded with the stats collector, when
+// InterceptorContext.RecordFetched is set. It runs after any response
+// modifier resp already carries, so what is hashed is what the package
+// manager receives. Blocks and downloads that fail upstream are not
+// recorded. An artifact over proxy.max_inspected_artifact_mb is recorded
+// without a digest.
+func (b *baseRegistryInterceptor) recordFetch(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	registry string,
+	packageName string,
+	packageVersion string,
+	resp *proxy.InterceptorResponse,
+) *proxy.InterceptorResponse {
+	if !b.execContext.RecordFetched || b.statsCollector == nil || resp == nil || ctx.Method != http.MethodGet {
+		return resp
+	}
+
+	var next proxy.ResponseModifierFunc
+	var nextOversize proxy.OversizeResponseFunc
+	switch resp.Action {
+	case proxy.ActionAllow:
+	case proxy.ActionModifyResponse:
+		next = resp.ResponseModifier
+		nextOversize = resp.OversizeResponse
+	default:
+		return resp
+	}
+
+	verdict, _ := ctx.Data[fetchVerdictKey].(string)
+	artifactURL := registryAbsoluteRequestURL(ctx)
+	if artifactURL == nil {
+		return resp
+	}
+	artifactURL.User = nil
+	artifactURL.RawQuery = ""
+	artifactURL.Fragment = ""
+	if port := artifactURL.Port(); (artifactURL.Scheme == "https" && port == "443") || (artifactURL.Scheme == "http" && port == "80") {
+		artifactURL.Host = artifactURL.Hostname()
+	}
+	if registry == "" {
+		registry = ctx.Hostname
+	}
+
+	requestWholeArtifact(ctx.Headers)
+
+	record := func(artifact models.FetchedArtifact) {
+		b.statsCollector.RecordFetched(models.FetchedPackage{
+			Ecosystem: ecosystem.String(),
+			Name:      packageName,
+			Version:   packageVersion,
+			Registry:  registry,
+			Verdict:   verdict,
+			Artifacts: []models.FetchedArtifact{artifact},
+		})
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:          proxy.ActionModifyResponse,
+		MaxResponseSize: config.Get().Config.Proxy.MaxInspectedArtifactBytes(),
+		OversizeResponse: func(statusCode int, headers http.Header) *proxy.ResponseBlock {
+			if nextOversize != nil {
+				if block := nextOversize(statusCode, headers); block != nil {
+					return block
+				}
+			}
+			if statusCode == http.StatusOK {
+				log.Debugf("[%s] Not hashing %s/%s@%s, response is over the inspection limit",
+					ctx.RequestID, ecosystem.String(), packageName, packageVersion)
+				record(models.FetchedArtifact{URL: artifactURL.String()})
+			}
+			return nil
+		},
+		ResponseModifier: func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+			if next != nil {
+				var err error
+				statusCode, headers, body, err = next(statusCode, headers, body)
+				if err != nil {
+					return statusCode, headers, body, err
+				}
+			}
+			if statusCode != http.StatusOK {
+				return statusCode, headers, body, nil
+			}
+
+			artifact := models.FetchedArtifact{URL: artifactURL.String()}
+			if encoding := headers.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+				log.Debugf("[%s] Not hashing %s/%s@%s, response is %s encoded",
+					ctx.RequestID, ecosystem.String(), packageName, packageVersion, encoding)
+			} else {
+				sum := sha256.Sum256(body)
+				artifact.SHA256 = hex.EncodeToString(sum[:])
+			}
+
+			record(artifact)
+			return statusCode, headers, body, nil
+		},
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 	"github.com/safedep/dry/log"
 	"github.com/safedep/pmg/analyzer"
 	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/contentscan"
+	"github.com/safedep/pmg/internal/license"
 	"github.com/safedep/pmg/proxy"
 )
 
@@ -26,9 +29,11 @@ const goToolchainModule = "golang.org/toolchain"
 // checksum-database traffic is tunneled, not MITM'd.
 type GoRegistryInterceptor struct {
 	baseRegistryInterceptor
-	domains         goRegistryConfigMap
-	baseURLs        map[string]string
-	cooldownHandler *goCooldownHandler
+	domains           goRegistryConfigMap
+	baseURLs          map[string]string
+	cooldownHandler   *goCooldownHandler
+	licenseHandler    *licenseHandler
+	packageAgeHandler *packageAgeHandler
 
 	// zipVerdicts memoizes the final response per module zip. go re-requests
 	// a failed zip (once more during go get's load phase), and without this
@@ -72,10 +77,12 @@ func NewGoRegistryInterceptor(
 			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-go"),
 			execContext:      execContext,
 		},
-		domains:         domains,
-		baseURLs:        baseURLs,
-		cooldownHandler: newGoCooldownHandler(statsCollector),
-		zipVerdicts:     map[string]*proxy.InterceptorResponse{},
+		domains:           domains,
+		baseURLs:          baseURLs,
+		cooldownHandler:   newGoCooldownHandler(statsCollector),
+		licenseHandler:    newLicenseHandler(),
+		packageAgeHandler: newPackageAgeHandler(),
+		zipVerdicts:       map[string]*proxy.InterceptorResponse{},
 	}
 }
 
@@ -141,6 +148,10 @@ func (i *GoRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy
 	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
 
 	if !info.IsFileDownload() {
+		if resp, refused := i.checkGoPrivateNamespace(ctx, config, info); refused {
+			return resp, nil
+		}
+
 		if info.requestType == goRequestInfo && info.version != "" && depCooldownConfig.Enabled {
 			return i.cooldownHandler.HandleInfoRequest(ctx, info.name, info.version)
 		}
@@ -154,9 +165,14 @@ func (i *GoRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy
 	i.zipVerdictsMu.Lock()
 	memo := i.zipVerdicts[key]
 	i.zipVerdictsMu.Unlock()
+	// The content scan reads each response, so it wraps the memoized
+	// decision rather than being memoized with it.
+	root := info.name + "@" + info.version + "/"
 	if memo != nil {
 		log.Debugf("[%s] Reusing verdict for repeated zip request: %s", ctx.RequestID, key)
-		return memo, nil
+		resp := i.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, memo,
+			i.contentScanInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, contentscan.FormatZip, root))
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, config.Host, info.name, info.version, resp), nil
 	}
 
 	resp, memoize, err := i.handleZipDownload(ctx, config, info, depCooldownConfig)
@@ -170,19 +186,27 @@ func (i *GoRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy
 		i.zipVerdictsMu.Unlock()
 	}
 
-	return resp, nil
+	resp = i.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, resp,
+		i.contentScanInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, contentscan.FormatZip, root))
+	return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, config.Host, info.name, info.version, resp), nil
 }
 
 // handleZipDownload runs the security controls for a module source download:
-// dependency cooldown, then trusted/insecure fast-allow, then malware
-// analysis. memoize is false only when the outcome is a fail-open allow after
-// an analyzer error, so a retried request gets another chance to be analyzed.
+// the private namespace policy, dependency cooldown, then trusted/insecure
+// fast-allow, then the package age and license policies, then malware
+// analysis. memoize is false only when the outcome is an allow without a
+// verdict after an analyzer error, so a retried request gets another chance
+// to be analyzed.
 func (i *GoRegistryInterceptor) handleZipDownload(
 	ctx *proxy.RequestContext,
 	config *goRegistryConfig,
 	info *goModuleInfo,
 	depCooldownConfig pmgconfig.DependencyCooldownConfig,
 ) (*proxy.InterceptorResponse, bool, error) {
+	if resp, refused := i.checkGoPrivateNamespace(ctx, config, info); refused {
+		return resp, true, nil
+	}
+
 	if depCooldownConfig.Enabled {
 		if resp, handled := i.cooldownHandler.CheckZipDownload(ctx, i.baseURLs[config.Host], info.name, info.version, depCooldownConfig.Days); handled {
 			return resp, true, nil
@@ -193,12 +217,39 @@ func (i *GoRegistryInterceptor) handleZipDownload(
 		return resp, true, nil
 	}
 
+	if resp, blocked := i.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, func() (time.Time, error) {
+		return i.packageAgeHandler.GoFirstRelease(i.baseURLs[config.Host], info.name, info.version)
+	}); blocked {
+		return resp, true, nil
+	}
+
+	if resp, blocked := i.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, func() (*license.Expression, error) {
+		return i.licenseHandler.GoLicense(i.baseURLs[config.Host], info.name, info.version)
+	}); blocked {
+		return resp, true, nil
+	}
+
 	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version)
 	if err != nil {
-		log.Errorf("[%s] Failed to analyze package %s@%s: %v", ctx.RequestID, info.name, info.version, err)
-		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, false, nil
+		resp := i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, err)
+		return resp, resp.Action != proxy.ActionAllow, nil
 	}
 
 	resp, err := i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, info.name, info.version, result)
 	return resp, err == nil, err
 }
+
+// checkGoPrivateNamespace refuses a request for a module of a private
+// namespace to the public Go module proxy. Refused metadata is a 404, on
+// which go tries the next GOPROXY entry.
+func (i *GoRegistryInterceptor) checkGoPrivateNamespace(
+	ctx *proxy.RequestContext,
+	config *goRegistryConfig,
+	info *goModuleInfo,
+) (*proxy.InterceptorResponse, bool) {
+	if config.Host != publicGoProxyHost {
+		return nil, false
+	}
+	return i.checkPrivateNamespace(ctx, packagev1.Ecosystem_ECOSYSTEM_GO, pmgconfig.PrivateNamespaceEcosystemGo,
+		config.Host, info.name, info.version, info.IsFileDownload())
+}
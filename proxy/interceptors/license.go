@@ -0,0 +1,267 @@
+package interceptors
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/license"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	gomodule "golang.org/x/mod/module"
+)
+
+// pypiJSONAPIBaseURL is where the license of a package from the public PyPI
+// index is read. pip resolves through the Simple API, which carries no
+// license, so the JSON API document is fetched on the side.
+var pypiJSONAPIBaseURL = "https://pypi.org/pypi"
+
+// upstreamFetchClient fetches documents the package manager does not request
+// itself, such as license sources and release histories. Like
+// goInfoFetchClient, it goes straight upstream rather than back through
+// PMG's own proxy.
+var upstreamFetchClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchUpstream GETs endpoint and returns its body, failing on any status
+// but 200 OK or a body larger than maxSize.
+func fetchUpstream(endpoint, accept string, maxSize int) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := upstreamFetchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", endpoint, maxSize)
+	}
+	return body, nil
+}
+
+// maxLicenseSourceSize bounds a fetched license source. Go module zips are
+// the largest; a module too large to fetch has an undeclared license.
+const maxLicenseSourceSize = 64 << 20
+
+// licenseHandler resolves the declared license of package versions for the
+// license policy. npm licenses come from the packuments the interceptor
+// already fetches where it can; other licenses are fetched once per
+// version.
+type licenseHandler struct {
+	mu      sync.Mutex
+	npm     map[string]map[string]*license.Expression
+	fetched map[string]*license.Expression
+}
+
+var _ analyzer.NpmPackumentObserver = (*licenseHandler)(nil)
+
+func newLicenseHandler() *licenseHandler {
+	return &licenseHandler{
+		npm:     map[string]map[string]*license.Expression{},
+		fetched: map[string]*license.Expression{},
+	}
+}
+
+// ObserveNpmPackument records the declared license of every version in a
+// packument.
+func (h *licenseHandler) ObserveNpmPackument(name string, packument []byte) {
+	licenses, err := license.FromNpmPackument(packument)
+	if err != nil {
+		log.Warnf("License: failed to read licenses of %s: %v", name, err)
+		return
+	}
+
+	h.mu.Lock()
+	h.npm[name] = licenses
+	h.mu.Unlock()
+}
+
+// NpmLicense returns the declared license of an npm package version. A
+// version whose packument was not seen, such as one npm resolved from its
+// own cache, or that the packument declares no license for, is read from its
+// version document on baseURL, the registry the artifact is downloaded from.
+func (h *licenseHandler) NpmLicense(baseURL, name, version string) (*license.Expression, error) {
+	h.mu.Lock()
+	expr := h.npm[name][version]
+	h.mu.Unlock()
+	if expr != nil || baseURL == "" {
+		return expr, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(name), url.PathEscape(version))
+	return h.fetch("npm:"+name+"@"+version, endpoint, license.FromNpmManifest)
+}
+
+// PyPILicense fetches the declared license of a release from the PyPI JSON
+// API.
+func (h *licenseHandler) PyPILicense(name, version string) (*license.Expression, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/json", strings.TrimSuffix(pypiJSONAPIBaseURL, "/"),
+		url.PathEscape(name), url.PathEscape(version))
+
+	return h.fetch("pypi:"+name+"@"+version, endpoint, license.FromPyPIRelease)
+}
+
+// GoLicense fetches the zip of a module version from baseURL, the module
+// proxy go downloads it from, and detects the license from its license
+// files.
+func (h *licenseHandler) GoLicense(baseURL, module, version string) (*license.Expression, error) {
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	escapedPath, err := gomodule.EscapePath(module)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := gomodule.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/@v/%s.zip", strings.TrimSuffix(baseURL, "/"), escapedPath, escapedVersion)
+	return h.fetch("go:"+goModuleVersionKey(module, version), endpoint, func(body []byte) (*license.Expression, error) {
+		return license.FromGoModuleZip(body, module, version)
+	})
+}
+
+// fetch reads the license at endpoint once per key. A failed fetch is not
+// remembered, so a retried download tries again.
+func (h *licenseHandler) fetch(key, endpoint string, read func([]byte) (*license.Expression, error)) (*license.Expression, error) {
+	h.mu.Lock()
+	expr, ok := h.fetched[key]
+	h.mu.Unlock()
+	if ok {
+		return expr, nil
+	}
+
+	body, err := fetchUpstream(endpoint, "", maxLicenseSourceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err = read(body)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	h.fetched[key] = expr
+	h.mu.Unlock()
+	return expr, nil
+}
+
+// checkLicense applies license_policy to an artifact download. resolve is
+// only called when the policy is enabled; a license it fails to read is
+// undeclared. It returns (response, true) when the policy blocked the
+// download; otherwise the download goes on to malware analysis.
+func (b *baseRegistryInterceptor) checkLicense(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	resolve func() (*license.Expression, error),
+) (*proxy.InterceptorResponse, bool) {
+	cfg := config.Get().Config.LicensePolicy
+	if !cfg.Enabled {
+		return nil, false
+	}
+
+	declared, err := resolve()
+	if err != nil {
+		log.Warnf("[%s] License: failed to read the license of %s/%s@%s: %v",
+			ctx.RequestID, ecosystem.String(), packageName, packageVersion, err)
+		declared = nil
+	}
+
+	decision := license.NewPolicy(cfg.Deny, cfg.Allow).Evaluate(declared)
+	action := cfg.ActionName()
+	switch decision.Verdict {
+	case license.VerdictAllowed:
+		log.Debugf("[%s] License: %s@%s is %s", ctx.RequestID, packageName, packageVersion, decision.Expression)
+		return nil, false
+	case license.VerdictUndeclared:
+		action = cfg.UnknownAction()
+	}
+
+	pkgVersion := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: ecosystem, Name: packageName},
+		Version: packageVersion,
+	}
+	summary := licenseSummary(decision)
+	logPolicy := func(outcome string) {
+		audit.LogLicensePolicy(pkgVersion, decision.Expression, decision.License, decision.Verdict.String(), outcome)
+	}
+
+	switch b.applyPolicyAction(ctx, "License", pkgVersion, action, summary) {
+	case policyAllowed:
+		if decision.Verdict != license.VerdictUndeclared {
+			logPolicy(audit.LicensePolicyAllowed)
+		}
+		return nil, false
+	case policyConfirmed:
+		logPolicy(audit.LicensePolicyConfirmed)
+		return nil, false
+	}
+
+	logPolicy(audit.LicensePolicyBlocked)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordLicenseBlocked(models.LicenseBlock{
+			Name:     packageName,
+			Version:  packageVersion,
+			License:  decision.License,
+			Declared: decision.Expression,
+			Verdict:  decision.Verdict.String(),
+		})
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:      proxy.ActionBlock,
+		BlockCode:   http.StatusForbidden,
+		BlockReason: proxy.BlockReasonLicense,
+		BlockContext: &proxy.BlockContext{
+			Ecosystem:       ecosystem,
+			PackageName:     packageName,
+			PackageVersion:  packageVersion,
+			License:         decision.License,
+			LicenseDeclared: decision.Expression,
+			LicenseVerdict:  decision.Verdict.String(),
+		},
+	}, true
+}
+
+// licenseSummary describes a rejected license for the confirmation prompt
+// and the log.
+func licenseSummary(decision license.Decision) string {
+	switch decision.Verdict {
+	case license.VerdictDenied:
+		return fmt.Sprintf("License %s is denied by license_policy", decision.License)
+	case license.VerdictNotAllowed:
+		return fmt.Sprintf("License %s is not on the license_policy allow list", decision.License)
+	default:
+		return "No declared license could be read"
+	}
+}
@@ -0,0 +1,228 @@
+package interceptors
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/license"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setLicensePolicyConfig(t *testing.T, cfg pmgconfig.LicensePolicyConfig) {
+	t.Helper()
+	orig := pmgconfig.Get().Config.LicensePolicy
+	t.Cleanup(func() { pmgconfig.Get().Config.LicensePolicy = orig })
+	pmgconfig.Get().Config.LicensePolicy = cfg
+}
+
+func staticLicense(declared string) func() (*license.Expression, error) {
+	return func() (*license.Expression, error) {
+		if declared == "" {
+			return nil, nil
+		}
+		return license.Parse(declared)
+	}
+}
+
+func TestCheckLicense_BlocksDeniedLicense(t *testing.T) {
+	setLicensePolicyConfig(t, pmgconfig.LicensePolicyConfig{
+		Enabled: true,
+		Deny:    []string{"AGPL-3.0"},
+		Action:  pmgconfig.HeuristicActionBlock,
+		Unknown: pmgconfig.HeuristicActionAllow,
+	})
+
+	base := &baseRegistryInterceptor{statsCollector: NewAnalysisStatsCollector()}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/copyleft/-/copyleft-1.0.0.tgz")
+
+	response, blocked := base.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "copyleft", "1.0.0",
+		staticLicense("AGPL-3.0-only OR SSPL-1.0"))
+	require.True(t, blocked)
+	assert.Equal(t, proxy.ActionBlock, response.Action)
+	assert.Equal(t, http.StatusForbidden, response.BlockCode)
+	assert.Equal(t, proxy.BlockReasonLicense, response.BlockReason)
+	require.NotNil(t, response.BlockContext)
+	assert.Equal(t, "AGPL-3.0-only", response.BlockContext.License)
+	assert.Equal(t, "AGPL-3.0-only OR SSPL-1.0", response.BlockContext.LicenseDeclared)
+	assert.Equal(t, "denied", response.BlockContext.LicenseVerdict)
+
+	_, blocked = base.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "permissive", "1.0.0", staticLicense("MIT"))
+	assert.False(t, blocked)
+
+	// Undeclared licenses follow license_policy.unknown, allow here.
+	_, blocked = base.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "mystery", "1.0.0", staticLicense(""))
+	assert.False(t, blocked)
+
+	stats := base.statsCollector.GetStats()
+	assert.Equal(t, 1, stats.BlockedCount)
+	assert.Equal(t, 1, stats.LicenseBlockedCount)
+	assert.Equal(t, []models.LicenseBlock{{
+		Name:     "copyleft",
+		Version:  "1.0.0",
+		License:  "AGPL-3.0-only",
+		Declared: "AGPL-3.0-only OR SSPL-1.0",
+		Verdict:  "denied",
+	}}, base.statsCollector.GetLicenseBlocks())
+}
+
+func TestCheckLicense_Confirm(t *testing.T) {
+	setLicensePolicyConfig(t, pmgconfig.LicensePolicyConfig{
+		Enabled: true,
+		Allow:   []string{"MIT", "Apache-2.0"},
+		Action:  pmgconfig.HeuristicActionConfirm,
+		Unknown: pmgconfig.HeuristicActionBlock,
+	})
+
+	for _, confirms := range []bool{true, false} {
+		confirmationChan := make(chan *ConfirmationRequest, 1)
+		base := &baseRegistryInterceptor{confirmationChan: confirmationChan}
+		ctx := makeTestRequestContext("https://registry.npmjs.org/isc-pkg/-/isc-pkg-1.0.0.tgz")
+
+		go func() {
+			req := <-confirmationChan
+			req.ResponseChan <- confirms
+			close(req.ResponseChan)
+		}()
+
+		response, blocked := base.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "isc-pkg", "1.0.0", staticLicense("ISC"))
+		assert.Equal(t, !confirms, blocked)
+		if !confirms {
+			assert.Equal(t, proxy.BlockReasonLicense, response.BlockReason)
+			assert.Equal(t, "not allowed", response.BlockContext.LicenseVerdict)
+		}
+	}
+
+	// license_policy.unknown applies to undeclared licenses without asking.
+	base := &baseRegistryInterceptor{}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/mystery/-/mystery-1.0.0.tgz")
+	response, blocked := base.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "mystery", "1.0.0", staticLicense(""))
+	require.True(t, blocked)
+	assert.Equal(t, "undeclared", response.BlockContext.LicenseVerdict)
+}
+
+func TestCheckLicense_DisabledDoesNotResolve(t *testing.T) {
+	setLicensePolicyConfig(t, pmgconfig.LicensePolicyConfig{Enabled: false, Deny: []string{"MIT"}})
+
+	base := &baseRegistryInterceptor{}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/lib/-/lib-1.0.0.tgz")
+	_, blocked := base.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "lib", "1.0.0", func() (*license.Expression, error) {
+		t.Fatal("resolve called while license_policy is disabled")
+		return nil, nil
+	})
+	assert.False(t, blocked)
+}
+
+func TestLicenseHandler_Npm(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.EscapedPath() != "/@scope%2Fother/1.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "@scope/other", "version": "1.0.0", "license": "MIT"}`))
+	}))
+	defer server.Close()
+
+	h := newLicenseHandler()
+	h.ObserveNpmPackument("lib", []byte(`{"versions": {"1.0.0": {"license": "GPL-3.0+"}}}`))
+
+	expr, err := h.NpmLicense(server.URL, "lib", "1.0.0")
+	require.NoError(t, err)
+	require.NotNil(t, expr)
+	assert.Equal(t, "GPL-3.0+", expr.String())
+	assert.Zero(t, hits.Load())
+
+	// A packument npm never requested is read from the version document,
+	// once.
+	for range 2 {
+		expr, err = h.NpmLicense(server.URL, "@scope/other", "1.0.0")
+		require.NoError(t, err)
+		require.NotNil(t, expr)
+		assert.Equal(t, "MIT", expr.String())
+	}
+	assert.Equal(t, int32(1), hits.Load())
+
+	_, err = h.NpmLicense(server.URL, "missing", "1.0.0")
+	assert.Error(t, err)
+
+	expr, err = h.NpmLicense("", "missing", "1.0.0")
+	require.NoError(t, err)
+	assert.Nil(t, expr)
+}
+
+func TestNpmRegistryBaseURL(t *testing.T) {
+	registries := newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_NPM)
+
+	ctx := makeTestRequestContext("https://registry.npmjs.org/lib/-/lib-1.0.0.tgz")
+	assert.Equal(t, "https://registry.npmjs.org", npmRegistryBaseURL(ctx, registries))
+
+	ctx = makeTestRequestContext("https://example.com/lib/-/lib-1.0.0.tgz")
+	assert.Empty(t, npmRegistryBaseURL(ctx, registries))
+}
+
+func TestLicenseHandler_PyPI(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/pypi/requests/2.32.0/json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"info": {"license_expression": "Apache-2.0"}}`))
+	}))
+	defer server.Close()
+
+	orig := pypiJSONAPIBaseURL
+	pypiJSONAPIBaseURL = server.URL + "/pypi"
+	t.Cleanup(func() { pypiJSONAPIBaseURL = orig })
+
+	h := newLicenseHandler()
+	for range 2 {
+		expr, err := h.PyPILicense("requests", "2.32.0")
+		require.NoError(t, err)
+		require.NotNil(t, expr)
+		assert.Equal(t, "Apache-2.0", expr.String())
+	}
+	assert.Equal(t, int32(1), hits.Load(), "license is fetched once per version")
+
+	_, err := h.PyPILicense("missing", "1.0.0")
+	assert.Error(t, err)
+}
+
+func TestLicenseHandler_Go(t *testing.T) {
+	var zipped bytes.Buffer
+	w := zip.NewWriter(&zipped)
+	f, err := w.Create("github.com/Example/lib@v1.0.0/LICENSE")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3, 19 November 2007"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/!example/lib/@v/v1.0.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(zipped.Bytes())
+	}))
+	defer server.Close()
+
+	h := newLicenseHandler()
+	expr, err := h.GoLicense(server.URL, "github.com/Example/lib", "v1.0.0")
+	require.NoError(t, err)
+	require.NotNil(t, expr)
+	assert.Equal(t, "AGPL-3.0-only", expr.String())
+
+	_, err = h.GoLicense(server.URL, "github.com/Example/lib", "v2.0.0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package interceptors
+
+import (
+	"fmt"
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/lockfile"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+)
+
+// projectLockfile returns the lockfile the install runs from when it lists
+// packages of ecosystem and the lockfile integrity check is enabled.
+func (b *baseRegistryInterceptor) projectLockfile(ecosystem packagev1.Ecosystem) *lockfile.Lockfile {
+	lock := b.execContext.Lockfile
+	if lock == nil || !config.Get().Config.LockfileIntegrity.Enabled {
+		return nil
+	}
+
+	switch {
+	case ecosystem == packagev1.Ecosystem_ECOSYSTEM_NPM && lock.Ecosystem == lockfile.EcosystemNpm,
+		ecosystem == packagev1.Ecosystem_ECOSYSTEM_PYPI && lock.Ecosystem == lockfile.EcosystemPyPI:
+		return lock
+	}
+	return nil
+}
+
+// checkLockfile applies lockfile_integrity.unlisted to an artifact download
+// of a lockfile install. It returns (response, true) when the policy blocked
+// the download.
+func (b *baseRegistryInterceptor) checkLockfile(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+) (*proxy.InterceptorResponse, bool) {
+	lock := b.projectLockfile(ecosystem)
+	if lock == nil || lock.Contains(packageName, packageVersion) {
+		return nil, false
+	}
+
+	action := config.Get().Config.LockfileIntegrity.UnlistedAction()
+	if !b.applyLockfilePolicy(ctx, ecosystem, packageName, packageVersion, lock.Path, models.LockfileVerdictUnlisted, "", "", action) {
+		return nil, false
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:      proxy.ActionBlock,
+		BlockCode:   http.StatusForbidden,
+		BlockReason: proxy.BlockReasonLockfileUnlisted,
+		BlockContext: &proxy.BlockContext{
+			Ecosystem:      ecosystem,
+			PackageName:    packageName,
+			PackageVersion: packageVersion,
+			Lockfile:       lock.Path,
+		},
+	}, true
+}
+
+// lockfileInspector returns the inspector that compares an artifact's hash
+// with the one its lockfile entry pins, or nil when there is no lockfile or
+// the entry pins no hash. A response it cannot hash is a mismatch.
+func (b *baseRegistryInterceptor) lockfileInspector(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+) *artifactInspector {
+	lock := b.projectLockfile(ecosystem)
+	if lock == nil {
+		return nil
+	}
+
+	hashes := lock.Hashes(packageName, packageVersion)
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	mismatch := func(expected, actual string) *proxy.ResponseBlock {
+		action := config.Get().Config.LockfileIntegrity.MismatchAction()
+		if !b.applyLockfilePolicy(ctx, ecosystem, packageName, packageVersion, lock.Path,
+			models.LockfileVerdictMismatch, expected, actual, action) {
+			return nil
+		}
+
+		return &proxy.ResponseBlock{
+			Code:   http.StatusForbidden,
+			Reason: proxy.BlockReasonLockfileMismatch,
+			Context: &proxy.BlockContext{
+				Ecosystem:      ecosystem,
+				PackageName:    packageName,
+				PackageVersion: packageVersion,
+				Lockfile:       lock.Path,
+				ExpectedHash:   expected,
+				ActualHash:     actual,
+			},
+		}
+	}
+
+	return &artifactInspector{
+		check: func(artifact []byte) *proxy.ResponseBlock {
+			ok, actual := lockfile.Verify(hashes, artifact)
+			if ok {
+				log.Debugf("[%s] Lockfile integrity: %s/%s@%s matches %s", ctx.RequestID, ecosystem.String(), packageName, packageVersion, lock.Path)
+				return nil
+			}
+
+			// The expected hash shown is one of the algorithm Verify checked.
+			expected := hashes[0]
+			for _, h := range hashes {
+				if h.Algorithm == actual.Algorithm {
+					expected = h
+					break
+				}
+			}
+			return mismatch(expected.String(), actual.String())
+		},
+		unreadable: func(reason string) *proxy.ResponseBlock {
+			return mismatch(hashes[0].String(), "unknown, the response is "+reason)
+		},
+	}
+}
+
+// applyLockfilePolicy carries out action for an artifact that does not match
+// the lockfile, asking the user first for confirm. It returns whether the
+// download is blocked.
+func (b *baseRegistryInterceptor) applyLockfilePolicy(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	lockPath string,
+	verdict string,
+	expected string,
+	actual string,
+	action string,
+) bool {
+	pkgVersion := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: ecosystem, Name: packageName},
+		Version: packageVersion,
+	}
+
+	summary := fmt.Sprintf("Package is not listed in %s", lockPath)
+	if verdict == models.LockfileVerdictMismatch {
+		summary = fmt.Sprintf("Artifact hash %s does not match %s pinned in %s", actual, expected, lockPath)
+	}
+	logPolicy := func(decision string) {
+		audit.LogLockfileIntegrity(pkgVersion, lockPath, verdict, expected, actual, decision)
+	}
+
+	switch b.applyPolicyAction(ctx, "Lockfile integrity", pkgVersion, action, summary) {
+	case policyAllowed:
+		logPolicy(audit.LockfileIntegrityAllowed)
+		return false
+	case policyConfirmed:
+		logPolicy(audit.LockfileIntegrityConfirmed)
+		return false
+	}
+
+	logPolicy(audit.LockfileIntegrityBlocked)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordLockfileBlocked(models.LockfileBlock{
+			Name:     packageName,
+			Version:  packageVersion,
+			Lockfile: lockPath,
+			Verdict:  verdict,
+			Expected: expected,
+			Actual:   actual,
+		})
+	}
+	return true
+}
@@ -0,0 +1,257 @@
+package interceptors
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/lockfile"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lockedTarball = []byte("left-pad tarball")
+
+func testPackageLock(t *testing.T) *lockfile.Lockfile {
+	t.Helper()
+
+	sum := sha512.Sum512(lockedTarball)
+	data := `{"lockfileVersion": 3, "packages": {
+  "node_modules/left-pad": {"version": "1.3.0", "integrity": "sha512-` + base64.StdEncoding.EncodeToString(sum[:]) + `"},
+  "node_modules/git-dep": {"version": "0.0.1"}
+}}`
+	lock, err := lockfile.Parse("package-lock.json", []byte(data))
+	require.NoError(t, err)
+	return lock
+}
+
+func setLockfileIntegrityConfig(t *testing.T, cfg pmgconfig.LockfileIntegrityConfig) {
+	t.Helper()
+
+	orig := pmgconfig.Get().Config.LockfileIntegrity
+	t.Cleanup(func() { pmgconfig.Get().Config.LockfileIntegrity = orig })
+	pmgconfig.Get().Config.LockfileIntegrity = cfg
+}
+
+func TestCheckLockfile_Unlisted(t *testing.T) {
+	setLockfileIntegrityConfig(t, pmgconfig.LockfileIntegrityConfig{Enabled: true, Unlisted: "block"})
+
+	base := &baseRegistryInterceptor{
+		statsCollector: NewAnalysisStatsCollector(),
+		execContext:    InterceptorContext{Lockfile: testPackageLock(t)},
+	}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/extra/-/extra-1.0.0.tgz")
+
+	_, blocked := base.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0")
+	assert.False(t, blocked)
+
+	resp, blocked := base.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "extra", "1.0.0")
+	require.True(t, blocked)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, proxy.BlockReasonLockfileUnlisted, resp.BlockReason)
+	assert.Equal(t, "package-lock.json", resp.BlockContext.Lockfile)
+
+	stats := base.statsCollector.GetStats()
+	assert.Equal(t, 1, stats.TotalAnalyzed)
+	assert.Equal(t, 1, stats.LockfileBlockedCount)
+	assert.Equal(t, []models.LockfileBlock{{
+		Name:     "extra",
+		Version:  "1.0.0",
+		Lockfile: "package-lock.json",
+		Verdict:  models.LockfileVerdictUnlisted,
+	}}, base.statsCollector.GetLockfileBlocks())
+
+	// The lockfile only speaks for its own ecosystem.
+	_, blocked = base.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, "extra", "1.0.0")
+	assert.False(t, blocked)
+
+	pmgconfig.Get().Config.LockfileIntegrity.Unlisted = "allow"
+	_, blocked = base.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "extra", "1.0.0")
+	assert.False(t, blocked)
+
+	pmgconfig.Get().Config.LockfileIntegrity = pmgconfig.LockfileIntegrityConfig{Enabled: false, Unlisted: "block"}
+	_, blocked = base.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "extra", "1.0.0")
+	assert.False(t, blocked)
+}
+
+func TestCheckLockfile_UnlistedConfirm(t *testing.T) {
+	setLockfileIntegrityConfig(t, pmgconfig.LockfileIntegrityConfig{Enabled: true})
+
+	for _, confirms := range []bool{true, false} {
+		confirmationChan := make(chan *ConfirmationRequest, 1)
+		base := &baseRegistryInterceptor{
+			confirmationChan: confirmationChan,
+			execContext:      InterceptorContext{Lockfile: testPackageLock(t)},
+		}
+		ctx := makeTestRequestContext("https://registry.npmjs.org/extra/-/extra-1.0.0.tgz")
+
+		go func() {
+			req := <-confirmationChan
+			req.ResponseChan <- confirms
+			close(req.ResponseChan)
+		}()
+
+		_, blocked := base.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "extra", "1.0.0")
+		assert.Equal(t, !confirms, blocked)
+	}
+}
+
+func TestLockfileInspector_Mismatch(t *testing.T) {
+	setLockfileIntegrityConfig(t, pmgconfig.LockfileIntegrityConfig{Enabled: true})
+
+	base := &baseRegistryInterceptor{
+		statsCollector: NewAnalysisStatsCollector(),
+		execContext:    InterceptorContext{Lockfile: testPackageLock(t)},
+	}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz")
+
+	// An entry without a hash has nothing to compare.
+	assert.Nil(t, base.lockfileInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "git-dep", "0.0.1"))
+
+	response := base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		base.lockfileInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0"))
+	require.Equal(t, proxy.ActionModifyResponse, response.Action)
+
+	status, _, body, err := response.ResponseModifier(http.StatusOK, http.Header{}, lockedTarball)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, lockedTarball, body)
+
+	_, _, _, err = response.ResponseModifier(http.StatusOK, http.Header{}, []byte("tampered"))
+	var block *proxy.ResponseBlock
+	require.True(t, errors.As(err, &block))
+	assert.Equal(t, proxy.BlockReasonLockfileMismatch, block.Reason)
+	assert.Equal(t, "package-lock.json", block.Context.Lockfile)
+	assert.Contains(t, block.Context.ExpectedHash, "sha512-")
+	assert.Contains(t, block.Context.ActualHash, "sha512-")
+	assert.NotEqual(t, block.Context.ExpectedHash, block.Context.ActualHash)
+
+	stats := base.statsCollector.GetStats()
+	assert.Equal(t, 0, stats.TotalAnalyzed)
+	assert.Equal(t, 1, stats.BlockedCount)
+	assert.Equal(t, 1, stats.LockfileBlockedCount)
+
+	// mismatch: allow only logs.
+	pmgconfig.Get().Config.LockfileIntegrity.Mismatch = "allow"
+	_, _, _, err = response.ResponseModifier(http.StatusOK, http.Header{}, []byte("tampered"))
+	assert.NoError(t, err)
+}
+
+func TestInspectArtifact_NoInspectors(t *testing.T) {
+	base := &baseRegistryInterceptor{}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz")
+	allow := &proxy.InterceptorResponse{Action: proxy.ActionAllow}
+
+	assert.Same(t, allow, base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0", allow))
+	assert.Same(t, allow, base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0", allow,
+		base.lockfileInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0")))
+}
+
+func TestInspectArtifact_UnreadableResponse(t *testing.T) {
+	setLockfileIntegrityConfig(t, pmgconfig.LockfileIntegrityConfig{Enabled: true})
+	setRegistryIntegrityConfig(t, pmgconfig.RegistryIntegrityConfig{Enabled: true})
+
+	base := &baseRegistryInterceptor{
+		statsCollector: NewAnalysisStatsCollector(),
+		execContext:    InterceptorContext{Lockfile: testPackageLock(t)},
+	}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz")
+	ctx.Headers.Set("Accept-Encoding", "gzip")
+	ctx.Headers.Set("Range", "bytes=100-")
+
+	lenient := base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		&artifactInspector{check: func([]byte) *proxy.ResponseBlock { return nil }})
+	require.Equal(t, proxy.ActionModifyResponse, lenient.Action)
+
+	// The whole, unencoded artifact is requested.
+	assert.Equal(t, "identity", ctx.Headers.Get("Accept-Encoding"))
+	assert.Empty(t, ctx.Headers.Get("Range"))
+
+	encoded := http.Header{}
+	encoded.Set("Content-Encoding", "gzip")
+
+	// An inspector without an unreadable hook lets what it cannot read
+	// through.
+	_, _, body, err := lenient.ResponseModifier(http.StatusOK, encoded, []byte("gzipped"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("gzipped"), body)
+
+	// The lockfile check fails closed.
+	locked := base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		base.lockfileInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0"))
+	_, _, _, err = locked.ResponseModifier(http.StatusOK, encoded, []byte("gzipped"))
+	var block *proxy.ResponseBlock
+	require.True(t, errors.As(err, &block))
+	assert.Equal(t, proxy.BlockReasonLockfileMismatch, block.Reason)
+	assert.Contains(t, block.Context.ActualHash, "gzip encoded")
+
+	// So does the registry integrity check.
+	handler := newRegistryIntegrityHandler()
+	sum := sha1.Sum(lockedTarball)
+	require.NoError(t, handler.ObserveNpmPackument("left-pad", []byte(`{"versions":{"1.3.0":{"dist":{"shasum":"`+
+		hex.EncodeToString(sum[:])+`"}}}}`)))
+	published := base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		base.registryIntegrityInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0",
+			handler.NpmHashes("left-pad", "1.3.0")))
+
+	_, _, _, err = published.ResponseModifier(http.StatusOK, encoded, []byte("gzipped"))
+	require.True(t, errors.As(err, &block))
+	assert.Equal(t, proxy.BlockReasonRegistryIntegrity, block.Reason)
+	assert.Contains(t, block.Context.ActualHash, "gzip encoded")
+
+	_, _, _, err = published.ResponseModifier(http.StatusPartialContent, http.Header{}, lockedTarball[:4])
+	require.True(t, errors.As(err, &block))
+	assert.Contains(t, block.Context.ActualHash, "partial response")
+
+	// Other failed downloads pass through.
+	status, _, _, err := published.ResponseModifier(http.StatusNotFound, http.Header{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _, _, err = published.ResponseModifier(http.StatusOK, http.Header{}, lockedTarball)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestInspectArtifact_OversizeResponse(t *testing.T) {
+	setLockfileIntegrityConfig(t, pmgconfig.LockfileIntegrityConfig{Enabled: true})
+
+	base := &baseRegistryInterceptor{
+		statsCollector: NewAnalysisStatsCollector(),
+		execContext:    InterceptorContext{Lockfile: testPackageLock(t)},
+	}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz")
+
+	response := base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		base.lockfileInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0"))
+	require.NotNil(t, response.OversizeResponse)
+	assert.Equal(t, pmgconfig.Get().Config.Proxy.MaxInspectedArtifactBytes(), response.MaxResponseSize)
+
+	// A tarball padded past the limit cannot be hashed, so it is blocked.
+	block := response.OversizeResponse(http.StatusOK, http.Header{})
+	require.NotNil(t, block)
+	assert.Equal(t, proxy.BlockReasonLockfileMismatch, block.Reason)
+	assert.Contains(t, block.Context.ActualHash, "inspection limit")
+	assert.Equal(t, 1, base.statsCollector.GetStats().LockfileBlockedCount)
+
+	assert.Nil(t, response.OversizeResponse(http.StatusNotFound, http.Header{}))
+
+	// Only inspectors that fail closed block it.
+	lenient := base.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "left-pad", "1.3.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		&artifactInspector{check: func([]byte) *proxy.ResponseBlock { return nil }})
+	assert.Nil(t, lenient.OversizeResponse(http.StatusOK, http.Header{}))
+}
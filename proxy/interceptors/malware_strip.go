@@ -0,0 +1,327 @@
+package interceptors
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/Masterminds/semver"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	"golang.org/x/sync/errgroup"
+)
+
+// malwareStripConcurrency bounds the analyses run for one metadata response.
+const malwareStripConcurrency = 8
+
+// malwareStripFormat is how versions are read from and removed from one kind
+// of metadata document.
+type malwareStripFormat struct {
+	// prepare adjusts the metadata request so the response can be rewritten.
+	// It returns false when the client could not parse such a response.
+	prepare func(ctx *proxy.RequestContext) bool
+
+	// candidates returns the versions to analyze, given how many of the most
+	// recently published to include, and the number of versions the
+	// document lists.
+	candidates func(body []byte, newest int) ([]string, int, error)
+
+	// strip removes versions from the document. It returns false when the
+	// document could not be rewritten.
+	strip func(body []byte, remove map[string]bool) ([]byte, bool)
+}
+
+// npmMalwareStripFormat rewrites npm packuments. The full packument is
+// requested for its publish times.
+var npmMalwareStripFormat = malwareStripFormat{
+	prepare: func(ctx *proxy.RequestContext) bool {
+		ctx.Headers.Set("Accept", "application/json")
+		return true
+	},
+	candidates: npmMalwareStripCandidates,
+	strip: func(body []byte, remove map[string]bool) ([]byte, bool) {
+		return stripNpmVersions("Malware stripping", body, remove, nil)
+	},
+}
+
+// pypiMalwareStripFormat rewrites PEP 691 Simple API pages. Clients that do
+// not accept PEP 691 JSON are served the registry's page unchanged.
+var pypiMalwareStripFormat = malwareStripFormat{
+	prepare: func(ctx *proxy.RequestContext) bool {
+		if !strings.Contains(ctx.Headers.Get("Accept"), pypiSimpleAPIContentType) {
+			return false
+		}
+		ctx.Headers.Set("Accept", pypiSimpleAPIContentType)
+		return true
+	},
+	candidates: pypiMalwareStripCandidates,
+	strip: func(body []byte, remove map[string]bool) ([]byte, bool) {
+		return stripPEP691Files("Malware stripping", body, remove)
+	},
+}
+
+// stripMalwareVersions wraps resp so that, after resp's own response modifier
+// ran, candidate versions with a malicious verdict are removed from the
+// metadata. The resolver then falls back to a clean version instead of
+// failing at download. Verdicts come from analyzePackage, so the download of
+// the version finally resolved reuses them.
+//
+// pinnedVersion, the version requested on the command line, is never
+// stripped: its download is blocked with the full malware report. Neither is
+// a version stripping would leave the package without. Analysis failures
+// leave versions in place; the download runs the analysis failure policy.
+// Responses other than allow or modify are returned unchanged.
+func (b *baseRegistryInterceptor) stripMalwareVersions(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	pinnedVersion string,
+	format malwareStripFormat,
+	resp *proxy.InterceptorResponse,
+) *proxy.InterceptorResponse {
+	if resp.Action != proxy.ActionAllow && resp.Action != proxy.ActionModifyResponse {
+		return resp
+	}
+	if config.Get().InsecureInstallation {
+		return resp
+	}
+	if !format.prepare(ctx) {
+		log.Debugf("[%s] Malware stripping: client cannot parse rewritten metadata for %s", ctx.RequestID, packageName)
+		return resp
+	}
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+
+	newest := config.Get().Config.MalwareStripping.Versions
+	next := resp.ResponseModifier
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if next != nil {
+			var err error
+			statusCode, headers, body, err = next(statusCode, headers, body)
+			if err != nil {
+				return statusCode, headers, body, err
+			}
+		}
+		if statusCode != http.StatusOK {
+			return statusCode, headers, body, nil
+		}
+
+		candidates, total, err := format.candidates(body, newest)
+		if err != nil {
+			log.Warnf("[%s] Malware stripping: failed to read the versions of %s: %v", ctx.RequestID, packageName, err)
+			return statusCode, headers, body, nil
+		}
+
+		malicious := b.maliciousVersions(ctx, ecosystem, packageName, pinnedVersion, candidates)
+		if len(malicious) == 0 {
+			return statusCode, headers, body, nil
+		}
+		if len(malicious) >= total {
+			log.Infof("[%s] Malware stripping: every version of %s is malicious, leaving metadata unchanged", ctx.RequestID, packageName)
+			return statusCode, headers, body, nil
+		}
+
+		remove := make(map[string]bool, len(malicious))
+		for version := range malicious {
+			remove[version] = true
+		}
+		stripped, ok := format.strip(body, remove)
+		if !ok {
+			return statusCode, headers, body, nil
+		}
+
+		versions := slices.Sorted(maps.Keys(malicious))
+		log.Infof("[%s] Malware stripping: stripped %d malicious version(s) of %s from metadata: %s",
+			ctx.RequestID, len(versions), packageName, strings.Join(versions, ", "))
+
+		for _, version := range versions {
+			result := malicious[version]
+			audit.LogMalwareStripped(result.PackageVersion, result.Summary, result.AnalysisID, result.ReferenceURL, result.DecidedBy)
+			if b.statsCollector != nil {
+				b.statsCollector.RecordMalwareStripped(models.MalwareStripped{
+					Ecosystem: ecosystem.String(),
+					Name:      packageName,
+					Version:   version,
+					Summary:   result.Summary,
+				})
+			}
+		}
+
+		// Verdicts change as packages are analyzed; the package manager must
+		// not cache the rewritten metadata.
+		headers.Set("Cache-Control", "no-store")
+		return statusCode, headers, stripped, nil
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}
+}
+
+// maliciousVersions analyzes candidates and returns the verdicts of those
+// with a malicious block verdict. A block by the vulnerability policy alone
+// is not stripped: it is reported at download.
+func (b *baseRegistryInterceptor) maliciousVersions(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	pinnedVersion string,
+	candidates []string,
+) map[string]*analyzer.PackageVersionAnalysisResult {
+	var mu sync.Mutex
+	malicious := make(map[string]*analyzer.PackageVersionAnalysisResult)
+
+	var g errgroup.Group
+	g.SetLimit(malwareStripConcurrency)
+	for _, version := range candidates {
+		if version == pinnedVersion || config.IsTrustedPackageRef(ecosystem, packageName, version) {
+			continue
+		}
+
+		g.Go(func() error {
+			result, err := b.analyzePackage(ctx, ecosystem, packageName, version)
+			if err != nil {
+				log.Debugf("[%s] Malware stripping: leaving %s@%s in metadata: %v", ctx.RequestID, packageName, version, err)
+				return nil
+			}
+			if result.Action == analyzer.ActionBlock && !isVulnerabilityVerdict(result) {
+				mu.Lock()
+				malicious[version] = result
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return malicious
+}
+
+// npmMalwareStripCandidates returns the dist-tag targets and the newest most
+// recently published versions of a packument. Versions are ordered by semver
+// when the packument has no publish times.
+func npmMalwareStripCandidates(body []byte, newest int) ([]string, int, error) {
+	var packument struct {
+		DistTags map[string]string          `json:"dist-tags"`
+		Versions map[string]json.RawMessage `json:"versions"`
+		Time     map[string]string          `json:"time"`
+	}
+	if err := json.Unmarshal(body, &packument); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal npm metadata: %w", err)
+	}
+
+	published := make(map[string]time.Time, len(packument.Versions))
+	for version := range packument.Versions {
+		if t, err := time.Parse(time.RFC3339, packument.Time[version]); err == nil {
+			published[version] = t
+		}
+	}
+
+	versions := slices.Collect(maps.Keys(packument.Versions))
+	if len(published) > 0 {
+		sort.Slice(versions, func(i, j int) bool {
+			return published[versions[i]].After(published[versions[j]])
+		})
+	} else {
+		sortVersionsDescending(versions)
+	}
+
+	candidates := make([]string, 0, len(packument.DistTags)+newest)
+	seen := make(map[string]bool)
+	add := func(version string) {
+		if _, ok := packument.Versions[version]; ok && !seen[version] {
+			seen[version] = true
+			candidates = append(candidates, version)
+		}
+	}
+	for _, tag := range slices.Sorted(maps.Keys(packument.DistTags)) {
+		add(packument.DistTags[tag])
+	}
+	for _, version := range versions[:min(max(newest, 0), len(versions))] {
+		add(version)
+	}
+
+	return candidates, len(packument.Versions), nil
+}
+
+// pypiMalwareStripCandidates returns the newest most recently uploaded
+// versions of a PEP 691 page. A version's upload time is that of its
+// earliest file. Unless every version has one, the later a version is first
+// listed, the newer it is taken to be.
+func pypiMalwareStripCandidates(body []byte, newest int) ([]string, int, error) {
+	var page struct {
+		Files []struct {
+			Filename   string `json:"filename"`
+			UploadTime string `json:"upload-time"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal PEP 691 response: %w", err)
+	}
+
+	var versions []string
+	listed := make(map[string]bool)
+	uploaded := make(map[string]time.Time)
+	for _, f := range page.Files {
+		info, err := parseFilename(f.Filename)
+		if err != nil || info.GetVersion() == "" {
+			continue
+		}
+		version := info.GetVersion()
+
+		if !listed[version] {
+			listed[version] = true
+			versions = append(versions, version)
+		}
+		if t, err := parsePEP691UploadTime(f.UploadTime); err == nil {
+			if existing, ok := uploaded[version]; !ok || t.Before(existing) {
+				uploaded[version] = t
+			}
+		}
+	}
+
+	slices.Reverse(versions)
+	if len(uploaded) == len(versions) {
+		sort.SliceStable(versions, func(i, j int) bool {
+			return uploaded[versions[i]].After(uploaded[versions[j]])
+		})
+	}
+
+	return versions[:min(max(newest, 0), len(versions))], len(versions), nil
+}
+
+// sortVersionsDescending sorts semver versions newest first. Versions that do
+// not parse sort last, in reverse lexical order.
+func sortVersionsDescending(versions []string) {
+	parsed := make(map[string]*semver.Version, len(versions))
+	for _, v := range versions {
+		if sv, err := semver.NewVersion(v); err == nil {
+			parsed[v] = sv
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		vi, vj := parsed[versions[i]], parsed[versions[j]]
+		switch {
+		case vi != nil && vj != nil:
+			return vi.GreaterThan(vj)
+		case vi != nil:
+			return true
+		case vj != nil:
+			return false
+		default:
+			return versions[i] > versions[j]
+		}
+	})
+}
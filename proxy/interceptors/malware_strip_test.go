@@ -0,0 +1,175 @@
+package interceptors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setMalwareStrippingConfig(t *testing.T, cfg config.MalwareStrippingConfig) {
+	t.Helper()
+	orig := config.Get().Config.MalwareStripping
+	t.Cleanup(func() { config.Get().Config.MalwareStripping = orig })
+	config.Get().Config.MalwareStripping = cfg
+}
+
+// versionAnalyzer blocks the listed versions as malware and allows any
+// other. It is safe for concurrent use.
+type versionAnalyzer struct {
+	malicious map[string]bool
+}
+
+func (a *versionAnalyzer) Name() string { return "versions" }
+
+func (a *versionAnalyzer) Analyze(_ context.Context, pv *packagev1.PackageVersion) (*analyzer.PackageVersionAnalysisResult, error) {
+	if a.malicious[pv.GetVersion()] {
+		return &analyzer.PackageVersionAnalysisResult{
+			PackageVersion: pv,
+			Action:         analyzer.ActionBlock,
+			IsMalware:      true,
+			Summary:        "credential stealer",
+		}, nil
+	}
+	return &analyzer.PackageVersionAnalysisResult{PackageVersion: pv, Action: analyzer.ActionAllow}, nil
+}
+
+const testMalwareStripPackument = `{
+	"name": "widget",
+	"dist-tags": {"latest": "1.2.0", "next": "2.0.0-beta.1"},
+	"versions": {"1.0.0": {}, "1.1.0": {}, "1.2.0": {}, "2.0.0-beta.1": {}},
+	"time": {
+		"created": "2024-01-01T00:00:00.000Z",
+		"1.0.0": "2024-01-01T00:00:00.000Z",
+		"1.1.0": "2024-02-01T00:00:00.000Z",
+		"1.2.0": "2024-03-01T00:00:00.000Z",
+		"2.0.0-beta.1": "2024-04-01T00:00:00.000Z"
+	}
+}`
+
+func TestNpmMalwareStripCandidates(t *testing.T) {
+	candidates, total, err := npmMalwareStripCandidates([]byte(testMalwareStripPackument), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []string{"1.2.0", "2.0.0-beta.1", "1.1.0"}, candidates)
+
+	// Without publish times the newest versions are the highest.
+	candidates, _, err = npmMalwareStripCandidates([]byte(`{"versions": {"1.0.0": {}, "1.10.0": {}, "1.9.0": {}}}`), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.10.0", "1.9.0"}, candidates)
+}
+
+func TestStripMalwareVersions_Npm(t *testing.T) {
+	setMalwareStrippingConfig(t, config.MalwareStrippingConfig{Enabled: true, Versions: 3})
+
+	interceptor := newTestBaseInterceptor(&versionAnalyzer{malicious: map[string]bool{"1.2.0": true}})
+	ctx := makeTestRequestContext("https://registry.npmjs.org/widget")
+	ctx.Headers.Set("Accept", "application/vnd.npm.install-v1+json")
+
+	resp := interceptor.stripMalwareVersions(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "widget", "",
+		npmMalwareStripFormat, &proxy.InterceptorResponse{Action: proxy.ActionAllow})
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+	assert.Equal(t, "application/json", ctx.Headers.Get("Accept"))
+
+	_, headers, body, err := resp.ResponseModifier(http.StatusOK, http.Header{}, []byte(testMalwareStripPackument))
+	require.NoError(t, err)
+	assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+
+	var packument struct {
+		DistTags map[string]string          `json:"dist-tags"`
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	require.NoError(t, json.Unmarshal(body, &packument))
+	assert.NotContains(t, packument.Versions, "1.2.0")
+	assert.Equal(t, "1.1.0", packument.DistTags["latest"])
+	assert.Equal(t, "2.0.0-beta.1", packument.DistTags["next"])
+
+	assert.Equal(t, []models.MalwareStripped{{
+		Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM.String(),
+		Name:      "widget",
+		Version:   "1.2.0",
+		Summary:   "credential stealer",
+	}}, interceptor.statsCollector.GetMalwareStripped())
+	assert.Zero(t, interceptor.statsCollector.GetStats().BlockedCount)
+}
+
+func TestStripMalwareVersions_NpmLeavesMetadata(t *testing.T) {
+	setMalwareStrippingConfig(t, config.MalwareStrippingConfig{Enabled: true, Versions: 10})
+
+	tests := []struct {
+		name      string
+		malicious map[string]bool
+		pinned    string
+	}{
+		{
+			name:      "pinned version is blocked at download instead",
+			malicious: map[string]bool{"1.2.0": true},
+			pinned:    "1.2.0",
+		},
+		{
+			name:      "no version would remain",
+			malicious: map[string]bool{"1.0.0": true, "1.1.0": true, "1.2.0": true, "2.0.0-beta.1": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := newTestBaseInterceptor(&versionAnalyzer{malicious: tt.malicious})
+			resp := interceptor.stripMalwareVersions(makeTestRequestContext("https://registry.npmjs.org/widget"),
+				packagev1.Ecosystem_ECOSYSTEM_NPM, "widget", tt.pinned, npmMalwareStripFormat,
+				&proxy.InterceptorResponse{Action: proxy.ActionAllow})
+
+			_, headers, body, err := resp.ResponseModifier(http.StatusOK, http.Header{}, []byte(testMalwareStripPackument))
+			require.NoError(t, err)
+			assert.Equal(t, testMalwareStripPackument, string(body))
+			assert.Empty(t, headers.Get("Cache-Control"))
+			assert.Empty(t, interceptor.statsCollector.GetMalwareStripped())
+		})
+	}
+}
+
+func TestStripMalwareVersions_PyPI(t *testing.T) {
+	setMalwareStrippingConfig(t, config.MalwareStrippingConfig{Enabled: true, Versions: 2})
+
+	page := `{"meta": {"api-version": "1.1"}, "name": "widget", "files": [
+		{"filename": "widget-1.0.tar.gz", "upload-time": "2024-01-01T00:00:00.000000Z"},
+		{"filename": "widget-1.1.tar.gz", "upload-time": "2024-02-01T00:00:00.000000Z"},
+		{"filename": "widget-1.1-py3-none-any.whl", "upload-time": "2024-02-01T00:00:01.000000Z"},
+		{"filename": "widget-1.2.tar.gz", "upload-time": "2024-03-01T00:00:00.000000Z"}
+	]}`
+
+	candidates, total, err := pypiMalwareStripCandidates([]byte(page), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"1.2", "1.1"}, candidates)
+
+	interceptor := newTestBaseInterceptor(&versionAnalyzer{malicious: map[string]bool{"1.1": true}})
+
+	// pip older than 22.3 cannot parse a rewritten PEP 691 page.
+	ctx := makeTestRequestContext("https://pypi.org/simple/widget/")
+	ctx.Headers.Set("Accept", "text/html")
+	resp := interceptor.stripMalwareVersions(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, "widget", "",
+		pypiMalwareStripFormat, &proxy.InterceptorResponse{Action: proxy.ActionAllow})
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+
+	ctx.Headers.Set("Accept", pypiSimpleAPIContentType+", text/html;q=0.01")
+	resp = interceptor.stripMalwareVersions(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, "widget", "",
+		pypiMalwareStripFormat, &proxy.InterceptorResponse{Action: proxy.ActionAllow})
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+	assert.Equal(t, pypiSimpleAPIContentType, ctx.Headers.Get("Accept"))
+
+	_, _, body, err := resp.ResponseModifier(http.StatusOK, http.Header{}, []byte(page))
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "widget-1.1")
+	assert.Contains(t, string(body), "widget-1.0.tar.gz")
+	assert.Contains(t, string(body), "widget-1.2.tar.gz")
+	require.Len(t, interceptor.statsCollector.GetMalwareStripped(), 1)
+}
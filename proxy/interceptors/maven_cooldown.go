@@ -0,0 +1,371 @@
+package interceptors
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+// mavenCooldownMaxLookups caps the publish-time lookups per metadata file.
+// Lookups walk from the newest version and stop at the first one outside the
+// window, so this only matters for artifacts with a burst of recent releases.
+const mavenCooldownMaxLookups = 20
+
+// Rewritten metadata is only needed until Maven fetches its checksum
+// sidecars, which it does right after the metadata file. Entries expire after
+// mavenCooldownRewriteTTL and at most mavenCooldownMaxRewrites are kept, so a
+// long-running proxy does not accumulate every metadata file it rewrote.
+const (
+	mavenCooldownRewriteTTL  = 10 * time.Minute
+	mavenCooldownMaxRewrites = 1024
+)
+
+var (
+	mavenVersionsBlockPattern = regexp.MustCompile(`(?s)<versions>.*?</versions>`)
+	mavenVersionPattern       = regexp.MustCompile(`[ \t]*<version>\s*([^<\s]+)\s*</version>[ \t]*(?:\r?\n)?`)
+	mavenLatestPattern        = regexp.MustCompile(`<latest>\s*([^<\s]*)\s*</latest>`)
+	mavenReleasePattern       = regexp.MustCompile(`<release>\s*([^<\s]*)\s*</release>`)
+)
+
+// mavenPublishTimeFetchClient looks up publish times straight from the
+// upstream repository rather than back through PMG's own proxy.
+var mavenPublishTimeFetchClient = &http.Client{Timeout: 10 * time.Second}
+
+// mavenCooldownHandler handles dependency cooldown for Maven artifacts. It
+// strips recently-published versions from the <versions> list of
+// maven-metadata.xml so version ranges, LATEST/RELEASE and Gradle dynamic
+// versions resolve to an eligible version. maven-metadata.xml carries no
+// per-version timestamps; a version's publish time is the Last-Modified of
+// its POM. Rewritten metadata is remembered so the checksum sidecars Maven
+// fetches next can be served to match it.
+type mavenCooldownHandler struct {
+	statsCollector *AnalysisStatsCollector
+	client         *http.Client
+
+	mu       sync.Mutex
+	rewrites map[string]mavenMetadataRewrite
+}
+
+// mavenMetadataRewrite is a rewritten maven-metadata.xml and when it was
+// served.
+type mavenMetadataRewrite struct {
+	body     []byte
+	storedAt time.Time
+}
+
+func newMavenCooldownHandler(statsCollector *AnalysisStatsCollector) *mavenCooldownHandler {
+	return &mavenCooldownHandler{
+		statsCollector: statsCollector,
+		client:         mavenPublishTimeFetchClient,
+		rewrites:       map[string]mavenMetadataRewrite{},
+	}
+}
+
+// HandleMetadataRequest registers a response modifier that strips versions
+// within the cooldown window from maven-metadata.xml.
+func (h *mavenCooldownHandler) HandleMetadataRequest(ctx *proxy.RequestContext, info *mavenPackageInfo, cooldownDays int, pinnedVersion string) (*proxy.InterceptorResponse, error) {
+	name := info.GetName()
+	skip := pmgconfig.CooldownSkip(packagev1.Ecosystem_ECOSYSTEM_MAVEN, name)
+	if skip.SkipAll {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	log.Debugf("[%s] Cooldown: registering maven-metadata.xml modifier for %s", ctx.RequestID, name)
+
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+	metadataURL := registryURLWithoutQuery(registryAbsoluteRequestURL(ctx))
+	authorization := ctx.Headers.Get("Authorization")
+
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode != http.StatusOK {
+			return statusCode, headers, body, nil
+		}
+
+		versions := parseMavenMetadataVersions(body)
+		if len(versions) == 0 {
+			return statusCode, headers, body, nil
+		}
+
+		dates := h.fetchPublishTimes(ctx, metadataURL, info.artifactID, authorization, versions, cooldownDays)
+
+		exempt := cooldownExemptVersions(packagev1.Ecosystem_ECOSYSTEM_MAVEN, name, skip, dates, cooldownDays)
+		auditCooldownSkips(ctx.RequestID, packagev1.Ecosystem_ECOSYSTEM_MAVEN, name, exempt)
+		strippedBody, stripped, remaining := stripMavenMetadataVersions(body, dates, cooldownDays, exempt.all)
+		if len(stripped) == 0 {
+			return statusCode, headers, body, nil
+		}
+
+		log.Infof("[%s] Cooldown: stripped %d version(s) from %s maven-metadata.xml (%d days, %d eligible remain)",
+			ctx.RequestID, len(stripped), name, cooldownDays, remaining)
+
+		recordCooldownStats(h.statsCollector, packagev1.Ecosystem_ECOSYSTEM_MAVEN, name, pinnedVersion, dates, stripped, remaining, cooldownDays)
+
+		h.storeRewrite(metadataURL, strippedBody, time.Now())
+
+		// Keep the rewritten file out of the local repository's
+		// update-policy cache and any intermediate cache.
+		headers.Del("ETag")
+		headers.Del("Last-Modified")
+		headers.Set("Cache-Control", "no-store")
+
+		return statusCode, headers, strippedBody, nil
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}, nil
+}
+
+// HandleChecksumRequest serves the checksum of rewritten maven-metadata.xml
+// in place of the upstream one, which would no longer match. Checksums of
+// metadata that was not rewritten pass through.
+func (h *mavenCooldownHandler) HandleChecksumRequest(ctx *proxy.RequestContext, checksumExtension string) (*proxy.InterceptorResponse, error) {
+	newHash := mavenChecksumHash(checksumExtension)
+	if newHash == nil {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	metadataURL := strings.TrimSuffix(registryURLWithoutQuery(registryAbsoluteRequestURL(ctx)), checksumExtension)
+
+	rewritten, ok := h.lookupRewrite(metadataURL, time.Now())
+	if !ok {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode != http.StatusOK {
+			return statusCode, headers, body, nil
+		}
+
+		hasher := newHash()
+		hasher.Write(rewritten)
+
+		headers.Del("ETag")
+		headers.Del("Last-Modified")
+		headers.Set("Cache-Control", "no-store")
+
+		return statusCode, headers, []byte(hex.EncodeToString(hasher.Sum(nil))), nil
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}, nil
+}
+
+// storeRewrite remembers the rewritten metadata served for metadataURL,
+// dropping expired entries and, past mavenCooldownMaxRewrites, the oldest one.
+func (h *mavenCooldownHandler) storeRewrite(metadataURL string, body []byte, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, rewrite := range h.rewrites {
+		if now.Sub(rewrite.storedAt) > mavenCooldownRewriteTTL {
+			delete(h.rewrites, key)
+		}
+	}
+
+	if _, ok := h.rewrites[metadataURL]; !ok && len(h.rewrites) >= mavenCooldownMaxRewrites {
+		oldest := ""
+		for key, rewrite := range h.rewrites {
+			if oldest == "" || rewrite.storedAt.Before(h.rewrites[oldest].storedAt) {
+				oldest = key
+			}
+		}
+		delete(h.rewrites, oldest)
+	}
+
+	h.rewrites[metadataURL] = mavenMetadataRewrite{body: body, storedAt: now}
+}
+
+// lookupRewrite returns the rewritten metadata served for metadataURL unless
+// it has expired.
+func (h *mavenCooldownHandler) lookupRewrite(metadataURL string, now time.Time) ([]byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rewrite, ok := h.rewrites[metadataURL]
+	if !ok {
+		return nil, false
+	}
+	if now.Sub(rewrite.storedAt) > mavenCooldownRewriteTTL {
+		delete(h.rewrites, metadataURL)
+		return nil, false
+	}
+	return rewrite.body, true
+}
+
+// fetchPublishTimes looks up publish times from the newest version
+// backwards, stopping at the first version outside the cooldown window.
+// Versions not looked up are absent from the result and stay eligible.
+func (h *mavenCooldownHandler) fetchPublishTimes(ctx *proxy.RequestContext, metadataURL, artifactID, authorization string, versions []string, cooldownDays int) map[string]time.Time {
+	dates := make(map[string]time.Time)
+	for i, lookups := len(versions)-1, 0; i >= 0 && lookups < mavenCooldownMaxLookups; i, lookups = i-1, lookups+1 {
+		version := versions[i]
+		publishTime, ok := h.fetchPublishTime(ctx, metadataURL, artifactID, version, authorization)
+		if !ok {
+			break
+		}
+
+		dates[version] = publishTime
+		if within, _, _ := cooldownIsWithinWindow(publishTime, cooldownDays); !within {
+			break
+		}
+	}
+
+	return dates
+}
+
+// fetchPublishTime returns the Last-Modified time of a version's POM, which
+// sits next to maven-metadata.xml at {version}/{artifactId}-{version}.pom.
+func (h *mavenCooldownHandler) fetchPublishTime(ctx *proxy.RequestContext, metadataURL, artifactID, version, authorization string) (time.Time, bool) {
+	pomURL, err := url.Parse(metadataURL)
+	if err != nil {
+		return time.Time{}, false
+	}
+	pomURL.Path = path.Join(path.Dir(pomURL.Path), version, artifactID+"-"+version+".pom")
+	pomURL.RawPath = ""
+
+	req, err := http.NewRequest(http.MethodHead, pomURL.String(), nil)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		log.Warnf("[%s] Cooldown: failed to fetch %s: %v", ctx.RequestID, pomURL, err)
+		return time.Time{}, false
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Debugf("[%s] Cooldown: fetching %s returned HTTP %d", ctx.RequestID, pomURL, resp.StatusCode)
+		return time.Time{}, false
+	}
+
+	publishTime, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		log.Debugf("[%s] Cooldown: no Last-Modified for %s", ctx.RequestID, pomURL)
+		return time.Time{}, false
+	}
+
+	return publishTime, true
+}
+
+// parseMavenMetadataVersions returns the <versions> list in document order.
+func parseMavenMetadataVersions(body []byte) []string {
+	block := mavenVersionsBlockPattern.Find(body)
+	if block == nil {
+		return nil
+	}
+
+	var versions []string
+	for _, match := range mavenVersionPattern.FindAllSubmatch(block, -1) {
+		versions = append(versions, string(match[1]))
+	}
+
+	return versions
+}
+
+// stripMavenMetadataVersions removes versions within the cooldown window
+// from <versions> and repoints <latest> and <release> at the newest
+// remaining version. Returns the modified body, the stripped versions, and
+// the count of versions remaining.
+func stripMavenMetadataVersions(body []byte, dates map[string]time.Time, cooldownDays int, exemptVersions map[string]bool) ([]byte, []string, int) {
+	tooNew := make(map[string]bool)
+	for version, publishDate := range dates {
+		if exemptVersions[version] {
+			continue
+		}
+		if within, _, _ := cooldownIsWithinWindow(publishDate, cooldownDays); within {
+			tooNew[version] = true
+		}
+	}
+
+	versions := parseMavenMetadataVersions(body)
+	var kept []string
+	for _, version := range versions {
+		if !tooNew[version] {
+			kept = append(kept, version)
+		}
+	}
+
+	if len(kept) == len(versions) {
+		return body, nil, len(kept)
+	}
+
+	stripped := mavenVersionsBlockPattern.ReplaceAllFunc(body, func(block []byte) []byte {
+		return mavenVersionPattern.ReplaceAllFunc(block, func(entry []byte) []byte {
+			if tooNew[string(mavenVersionPattern.FindSubmatch(entry)[1])] {
+				return nil
+			}
+			return entry
+		})
+	})
+
+	latest, release := "", ""
+	for _, version := range kept {
+		latest = version
+		if !strings.HasSuffix(version, "-SNAPSHOT") {
+			release = version
+		}
+	}
+
+	stripped = repointMavenMetadataElement(stripped, mavenLatestPattern, "latest", latest, tooNew)
+	stripped = repointMavenMetadataElement(stripped, mavenReleasePattern, "release", release, tooNew)
+
+	return stripped, slices.Collect(maps.Keys(tooNew)), len(kept)
+}
+
+// repointMavenMetadataElement replaces the value of <element> when it names
+// a stripped version.
+func repointMavenMetadataElement(body []byte, pattern *regexp.Regexp, element, replacement string, stripped map[string]bool) []byte {
+	if replacement == "" {
+		return body
+	}
+
+	return pattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		if !stripped[string(pattern.FindSubmatch(match)[1])] {
+			return match
+		}
+		return []byte("<" + element + ">" + replacement + "</" + element + ">")
+	})
+}
+
+func mavenChecksumHash(checksumExtension string) func() hash.Hash {
+	switch checksumExtension {
+	case ".sha1":
+		return sha1.New
+	case ".md5":
+		return md5.New
+	case ".sha256":
+		return sha256.New
+	case ".sha512":
+		return sha512.New
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,151 @@
+package interceptors
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMavenMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.acme</groupId>
+  <artifactId>lib</artifactId>
+  <versioning>
+    <latest>2.0.0</latest>
+    <release>2.0.0</release>
+    <versions>
+      <version>0.9.0</version>
+      <version>1.0.0</version>
+      <version>2.0.0</version>
+    </versions>
+    <lastUpdated>20240101120000</lastUpdated>
+  </versioning>
+</metadata>
+`
+
+// newTestMavenRepository serves POM Last-Modified times for com.acme:lib and
+// counts the lookups.
+func newTestMavenRepository(t *testing.T, published map[string]time.Time, lookups *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*lookups++
+		for version, publishedAt := range published {
+			if r.URL.Path == "/com/acme/lib/"+version+"/lib-"+version+".pom" {
+				w.Header().Set("Last-Modified", publishedAt.UTC().Format(http.TimeFormat))
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMavenCooldown_StripsRecentVersions(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	lookups := 0
+	server := newTestMavenRepository(t, map[string]time.Time{
+		"0.9.0": now.Add(-90 * day),
+		"1.0.0": now.Add(-30 * day),
+		"2.0.0": now.Add(-1 * day),
+	}, &lookups)
+
+	collector := NewAnalysisStatsCollector()
+	handler := newMavenCooldownHandler(collector)
+	ctx := makeTestRequestContext(server.URL + "/com/acme/lib/maven-metadata.xml")
+	info := &mavenPackageInfo{groupID: "com.acme", artifactID: "lib", isMetadata: true}
+
+	resp, err := handler.HandleMetadataRequest(ctx, info, 5, "")
+	require.NoError(t, err)
+	require.NotNil(t, resp.ResponseModifier)
+
+	_, headers, body, err := resp.ResponseModifier(http.StatusOK, http.Header{}, []byte(testMavenMetadata))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"0.9.0", "1.0.0"}, parseMavenMetadataVersions(body))
+	assert.Contains(t, string(body), "<latest>1.0.0</latest>")
+	assert.Contains(t, string(body), "<release>1.0.0</release>")
+	assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+	assert.Equal(t, 2, lookups, "lookups stop at the first version outside the window")
+	require.Len(t, collector.GetCooldownWithheld(), 1)
+
+	// The metadata checksum fetched next must match the rewritten file.
+	checksumCtx := makeTestRequestContext(server.URL + "/com/acme/lib/maven-metadata.xml.sha1")
+	checksumResp, err := handler.HandleChecksumRequest(checksumCtx, ".sha1")
+	require.NoError(t, err)
+	require.NotNil(t, checksumResp.ResponseModifier)
+
+	_, _, checksum, err := checksumResp.ResponseModifier(http.StatusOK, http.Header{}, []byte("upstream"))
+	require.NoError(t, err)
+	sum := sha1.Sum(body)
+	assert.Equal(t, hex.EncodeToString(sum[:]), string(checksum))
+}
+
+func TestMavenCooldown_LookupFailureKeepsVersions(t *testing.T) {
+	lookups := 0
+	server := newTestMavenRepository(t, nil, &lookups)
+
+	handler := newMavenCooldownHandler(NewAnalysisStatsCollector())
+	ctx := makeTestRequestContext(server.URL + "/com/acme/lib/maven-metadata.xml")
+	resp, err := handler.HandleMetadataRequest(ctx, &mavenPackageInfo{groupID: "com.acme", artifactID: "lib", isMetadata: true}, 5, "")
+	require.NoError(t, err)
+
+	_, headers, body, err := resp.ResponseModifier(http.StatusOK, http.Header{}, []byte(testMavenMetadata))
+	require.NoError(t, err)
+	assert.Equal(t, testMavenMetadata, string(body))
+	assert.Empty(t, headers.Get("Cache-Control"))
+}
+
+func TestMavenCooldown_ChecksumOfUnmodifiedMetadataPassesThrough(t *testing.T) {
+	handler := newMavenCooldownHandler(NewAnalysisStatsCollector())
+	resp, err := handler.HandleChecksumRequest(makeTestRequestContext("https://repo1.maven.org/maven2/com/acme/lib/maven-metadata.xml.sha1"), ".sha1")
+	require.NoError(t, err)
+	assert.Nil(t, resp.ResponseModifier)
+}
+
+func TestMavenCooldown_RewritesAreBounded(t *testing.T) {
+	handler := newMavenCooldownHandler(NewAnalysisStatsCollector())
+	start := time.Now()
+
+	handler.storeRewrite("https://repo/a/maven-metadata.xml", []byte("a"), start)
+	body, ok := handler.lookupRewrite("https://repo/a/maven-metadata.xml", start.Add(time.Minute))
+	require.True(t, ok)
+	assert.Equal(t, []byte("a"), body)
+
+	_, ok = handler.lookupRewrite("https://repo/a/maven-metadata.xml", start.Add(mavenCooldownRewriteTTL+time.Second))
+	assert.False(t, ok, "expired rewrites are dropped")
+	assert.Empty(t, handler.rewrites)
+
+	for i := 0; i <= mavenCooldownMaxRewrites; i++ {
+		handler.storeRewrite(fmt.Sprintf("https://repo/%d/maven-metadata.xml", i), []byte("x"), start.Add(time.Duration(i)*time.Millisecond))
+	}
+	assert.Len(t, handler.rewrites, mavenCooldownMaxRewrites)
+	_, ok = handler.lookupRewrite("https://repo/0/maven-metadata.xml", start)
+	assert.False(t, ok, "the oldest rewrite is evicted first")
+}
+
+func TestStripMavenMetadataVersions_PinnedVersionInCooldown(t *testing.T) {
+	now := time.Now()
+	dates := map[string]time.Time{
+		"2.0.0": now.Add(-1 * 24 * time.Hour),
+	}
+
+	body, stripped, remaining := stripMavenMetadataVersions([]byte(testMavenMetadata), dates, 5, map[string]bool{"2.0.0": true})
+	assert.Empty(t, stripped, "exempt versions are kept")
+	assert.Equal(t, 3, remaining)
+	assert.Equal(t, testMavenMetadata, string(body))
+
+	body, stripped, remaining = stripMavenMetadataVersions([]byte(testMavenMetadata), dates, 5, nil)
+	assert.Equal(t, []string{"2.0.0"}, stripped)
+	assert.Equal(t, 2, remaining)
+	assert.False(t, strings.Contains(string(body), "<version>2.0.0</version>"))
+}
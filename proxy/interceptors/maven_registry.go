@@ -0,0 +1,142 @@
+package interceptors
+
+import (
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+var mavenRegistryEndpoints = []registryEndpoint{
+	builtInRegistryEndpoint("repo1.maven.org", true, mavenParser{basePath: "maven2"}),
+	builtInRegistryEndpoint("repo.maven.apache.org", true, mavenParser{basePath: "maven2"}),
+}
+
+// MavenRegistryInterceptor intercepts Maven repository requests from Maven
+// and Gradle. Artifacts (.pom, .jar, ...) are analyzed for malware by their
+// groupId:artifactId coordinate and version; maven-metadata.xml carries
+// dependency cooldown.
+type MavenRegistryInterceptor struct {
+	baseRegistryInterceptor
+	cooldownHandler *mavenCooldownHandler
+	registries      registrySet
+}
+
+var _ proxy.Interceptor = (*MavenRegistryInterceptor)(nil)
+var _ proxy.MITMDecider = (*MavenRegistryInterceptor)(nil)
+
+func newMavenRegistryInterceptor(
+	analyzer analyzer.PackageVersionAnalyzer,
+	cache AnalysisCache,
+	statsCollector *AnalysisStatsCollector,
+	confirmationChan chan *ConfirmationRequest,
+	execContext InterceptorContext,
+	registries registrySet,
+) *MavenRegistryInterceptor {
+	return &MavenRegistryInterceptor{
+		baseRegistryInterceptor: baseRegistryInterceptor{
+			analyzer:         analyzer,
+			cache:            cache,
+			statsCollector:   statsCollector,
+			confirmationChan: confirmationChan,
+			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-maven"),
+			execContext:      execContext,
+		},
+		cooldownHandler: newMavenCooldownHandler(statsCollector),
+		registries:      registries,
+	}
+}
+
+func (i *MavenRegistryInterceptor) Name() string {
+	return "maven-registry-interceptor"
+}
+
+func (i *MavenRegistryInterceptor) ShouldMITM(ctx *proxy.RequestContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return registryHostSupportsAnalysis(i.registries, ctx.Hostname, ctx.Port)
+}
+
+func (i *MavenRegistryInterceptor) ShouldIntercept(ctx *proxy.RequestContext) bool {
+	return registryRequestMatch(i.registries, ctx) != nil
+}
+
+// HandleRequest processes the request and returns response action.
+// We take a fail-open approach here, allowing requests that we can't parse the
+// artifact coordinate from the URL.
+func (i *MavenRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy.InterceptorResponse, error) {
+	log.Debugf("[%s] Handling Maven registry request: %s", ctx.RequestID, ctx.URL.Path)
+
+	match := registryRequestMatch(i.registries, ctx)
+	if match == nil {
+		log.Warnf("[%s] No registry config found for hostname: %s", ctx.RequestID, ctx.Hostname)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if ctx.Method != http.MethodGet && ctx.Method != http.MethodHead {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	endpoint := match.Endpoint
+	if !endpoint.Analyze {
+		log.Debugf("[%s] Skipping analysis for %s registry (not supported for analysis): %s",
+			ctx.RequestID, endpoint.Host, ctx.URL.String())
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	pkgInfo, err := endpoint.Parser.ParseURL(match.RelativePath)
+	if err != nil {
+		logRegistryParseFailure(ctx, endpoint, "Maven", err)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if packageInfoHasCompleteIdentity(pkgInfo) {
+		name, version := pkgInfo.GetName(), pkgInfo.GetVersion()
+		resp, err := i.handleArtifact(ctx, name, version)
+		if err != nil {
+			return resp, err
+		}
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_MAVEN, endpoint.Name, name, version, resp), nil
+	}
+
+	if info, ok := pkgInfo.(*mavenPackageInfo); ok && info.isMetadata {
+		return i.handleMetadataRequest(ctx, info)
+	}
+
+	return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+}
+
+// handleMetadataRequest applies dependency cooldown to maven-metadata.xml and
+// keeps its checksum sidecars consistent with the rewritten file.
+func (i *MavenRegistryInterceptor) handleMetadataRequest(ctx *proxy.RequestContext, info *mavenPackageInfo) (*proxy.InterceptorResponse, error) {
+	if info.checksumExtension != "" {
+		return i.cooldownHandler.HandleChecksumRequest(ctx, info.checksumExtension)
+	}
+
+	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
+	if !depCooldownConfig.Enabled || pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_MAVEN, info.GetName()) {
+		log.Debugf("[%s] Skipping analysis for metadata request: %s", ctx.RequestID, info.GetName())
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	return i.cooldownHandler.HandleMetadataRequest(ctx, info, depCooldownConfig.Days, i.execContext.PinnedVersions[info.GetName()])
+}
+
+// handleArtifact runs the trust, analysis, and verdict pipeline for an
+// artifact download.
+func (i *MavenRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, name, version string) (*proxy.InterceptorResponse, error) {
+	if resp, ok := i.fastAllow(ctx, packagev1.Ecosystem_ECOSYSTEM_MAVEN, name, version); ok {
+		return resp, nil
+	}
+
+	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_MAVEN, name, version)
+	if err != nil {
+		return i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_MAVEN, name, version, err), nil
+	}
+
+	return i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_MAVEN, name, version, result)
+}
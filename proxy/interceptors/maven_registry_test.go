@@ -0,0 +1,109 @@
+package interceptors
+
+import (
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMavenInterceptor(a analyzer.PackageVersionAnalyzer, registries registrySet) *MavenRegistryInterceptor {
+	return newMavenRegistryInterceptor(a, NewInMemoryAnalysisCache(), NewAnalysisStatsCollector(),
+		make(chan *ConfirmationRequest, 1), InterceptorContext{}, registries)
+}
+
+func TestMavenRegistryInterceptor_ShouldIntercept(t *testing.T) {
+	interceptor := newTestMavenInterceptor(nil, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_MAVEN))
+
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"https://repo1.maven.org/maven2/org/slf4j/slf4j-api/2.0.9/slf4j-api-2.0.9.jar", true},
+		{"https://repo.maven.apache.org/maven2/org/slf4j/slf4j-api/maven-metadata.xml", true},
+		{"https://registry.npmjs.org/slf4j", false},
+	}
+
+	for _, tc := range cases {
+		ctx := registryRequest(t, tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldIntercept(ctx), tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldMITM(ctx), tc.url)
+	}
+}
+
+func TestMavenRegistryInterceptor_BlocksMaliciousArtifact(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_MAVEN, Name: "com.evil:payload"},
+			Version: "1.0.0",
+		},
+		Action:  analyzer.ActionBlock,
+		Summary: "Contains known malware",
+	}}
+	interceptor := newTestMavenInterceptor(mock, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_MAVEN))
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://repo1.maven.org/maven2/com/evil/payload/1.0.0/payload-1.0.0.pom"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, mock.callCount)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+	require.NotNil(t, resp.BlockContext)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_MAVEN, resp.BlockContext.Ecosystem)
+	assert.Equal(t, "com.evil:payload", resp.BlockContext.PackageName)
+	assert.Equal(t, "1.0.0", resp.BlockContext.PackageVersion)
+}
+
+func TestMavenRegistryInterceptor_MetadataAppliesCooldown(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	for _, enabled := range []bool{true, false} {
+		setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: enabled, Days: 5})
+
+		mock := &mockAnalyzer{}
+		interceptor := newTestMavenInterceptor(mock, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_MAVEN))
+
+		resp, err := interceptor.HandleRequest(makeTestRequestContext("https://repo1.maven.org/maven2/org/slf4j/slf4j-api/maven-metadata.xml"))
+		require.NoError(t, err)
+		assert.Equal(t, 0, mock.callCount, "metadata requests are never analyzed")
+		if enabled {
+			assert.Equal(t, proxy.ActionModifyResponse, resp.Action)
+		} else {
+			assert.Equal(t, proxy.ActionAllow, resp.Action)
+		}
+	}
+}
+
+func TestMavenRegistryInterceptor_CustomRegistry(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{Action: analyzer.ActionAllow}}
+	registries := newTestCustomRegistrySetFor(t, packagev1.Ecosystem_ECOSYSTEM_MAVEN, "https://nexus.example.test/repository/maven-public/")
+	interceptor := newTestMavenInterceptor(mock, registries)
+
+	url := "https://nexus.example.test/repository/maven-public/com/acme/internal/1.2.0/internal-1.2.0.jar"
+	assert.True(t, interceptor.ShouldIntercept(registryRequest(t, url)))
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext(url))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+	assert.Equal(t, 1, mock.callCount)
+}
+
+func TestInterceptorFactoryCreatesMavenInterceptor(t *testing.T) {
+	assert.True(t, IsSupported(packagev1.Ecosystem_ECOSYSTEM_MAVEN))
+
+	factory, err := NewInterceptorFactory(nil, nil, nil, nil, InterceptorContext{}, nil)
+	require.NoError(t, err)
+
+	got, err := factory.CreateInterceptor(packagev1.Ecosystem_ECOSYSTEM_MAVEN)
+	require.NoError(t, err)
+	assert.Equal(t, "maven-registry-interceptor", got.Name())
+}
@@ -0,0 +1,173 @@
+package interceptors
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const mavenMetadataFileName = "maven-metadata.xml"
+
+// mavenArtifactExtensions are the artifact files analyzed before they are
+// served. The POM is fetched first, so a malicious artifact is blocked before
+// its jar is requested.
+var mavenArtifactExtensions = []string{".jar", ".pom", ".war", ".aar"}
+
+// mavenChecksumExtensions are the checksum and signature sidecars Maven
+// fetches next to every file.
+var mavenChecksumExtensions = []string{".sha1", ".md5", ".sha256", ".sha512", ".asc"}
+
+// mavenPackageInfo is parsed artifact information from a Maven repository
+// URL. The package name is the groupId:artifactId coordinate.
+type mavenPackageInfo struct {
+	groupID    string
+	artifactID string
+	version    string
+	isDownload bool
+
+	// isMetadata marks maven-metadata.xml, or one of its sidecars when
+	// checksumExtension is set.
+	isMetadata        bool
+	checksumExtension string
+}
+
+var _ packageInfo = (*mavenPackageInfo)(nil)
+
+func (m *mavenPackageInfo) GetName() string {
+	if m.groupID == "" || m.artifactID == "" {
+		return ""
+	}
+	return m.groupID + ":" + m.artifactID
+}
+
+func (m *mavenPackageInfo) GetVersion() string { return m.version }
+
+func (m *mavenPackageInfo) IsFileDownload() bool { return m.isDownload }
+
+// mavenParser parses Maven 2 repository layout URLs, relative to basePath:
+//
+//	/{group/path}/{artifactId}/{version}/{artifactId}-{version}[-{classifier}].{ext}
+//	/{group/path}/{artifactId}/maven-metadata.xml
+//
+// groupId dots map to path segments. Snapshot versions pass through.
+// Checksum sidecars (.sha1, .md5, ...) of artifacts are passed through; those
+// of maven-metadata.xml are tracked so cooldown can keep them consistent with
+// the rewritten metadata.
+type mavenParser struct {
+	// basePath is the repository root below the host for built-in
+	// endpoints, e.g. "maven2" on Maven Central. Custom endpoints carry
+	// their base path on the endpoint instead.
+	basePath string
+}
+
+var _ registryURLParser = mavenParser{}
+
+func (p mavenParser) ParseURL(urlPath string) (packageInfo, error) {
+	urlPath = strings.Trim(urlPath, "/")
+	if p.basePath != "" {
+		rest, ok := strings.CutPrefix(urlPath, p.basePath+"/")
+		if !ok {
+			return nil, fmt.Errorf("maven URL path %q is outside repository root %q", urlPath, p.basePath)
+		}
+		urlPath = rest
+	}
+
+	segments := strings.Split(urlPath, "/")
+	filename := segments[len(segments)-1]
+
+	checksumExtension := ""
+	for _, ext := range mavenChecksumExtensions {
+		if stem, ok := strings.CutSuffix(filename, ext); ok {
+			filename, checksumExtension = stem, ext
+			break
+		}
+	}
+
+	if filename == mavenMetadataFileName {
+		return parseMavenMetadataPath(segments, checksumExtension)
+	}
+
+	if checksumExtension != "" {
+		return &mavenPackageInfo{}, nil
+	}
+
+	if len(segments) < 4 {
+		return nil, fmt.Errorf("maven artifact URL must be /{group}/{artifact}/{version}/{file}: %q", urlPath)
+	}
+
+	groupID := strings.Join(segments[:len(segments)-3], ".")
+	artifactID, version := segments[len(segments)-3], segments[len(segments)-2]
+	if !isValidMavenCoordinate(groupID) || !isValidMavenCoordinate(artifactID) || !isValidMavenCoordinate(version) {
+		return nil, fmt.Errorf("invalid maven coordinate in URL: %q", urlPath)
+	}
+
+	ext := path.Ext(filename)
+	if ext == "" || !strings.HasPrefix(filename, artifactID+"-") {
+		return nil, fmt.Errorf("maven file %q does not belong to artifact %q", filename, artifactID)
+	}
+
+	// Snapshots are never published to public repositories; they are
+	// in-house builds and are passed through without analysis.
+	if strings.HasSuffix(version, "-SNAPSHOT") {
+		return &mavenPackageInfo{}, nil
+	}
+
+	if !strings.HasPrefix(filename, artifactID+"-"+version) {
+		return nil, fmt.Errorf("maven file %q does not match version %q", filename, version)
+	}
+
+	info := &mavenPackageInfo{groupID: groupID, artifactID: artifactID, version: version}
+	for _, artifactExt := range mavenArtifactExtensions {
+		if ext == artifactExt {
+			info.isDownload = true
+			break
+		}
+	}
+
+	return info, nil
+}
+
+// parseMavenMetadataPath parses {group/path}/{artifactId}/maven-metadata.xml.
+// Snapshot version metadata ({artifactId}/{version}-SNAPSHOT/maven-metadata.xml)
+// lists builds rather than versions and is passed through.
+func parseMavenMetadataPath(segments []string, checksumExtension string) (packageInfo, error) {
+	if len(segments) < 3 {
+		return nil, fmt.Errorf("maven metadata URL has no artifact: %q", strings.Join(segments, "/"))
+	}
+
+	artifactID := segments[len(segments)-2]
+	if strings.HasSuffix(artifactID, "-SNAPSHOT") {
+		return &mavenPackageInfo{}, nil
+	}
+
+	groupID := strings.Join(segments[:len(segments)-2], ".")
+	if !isValidMavenCoordinate(groupID) || !isValidMavenCoordinate(artifactID) {
+		return nil, fmt.Errorf("invalid maven coordinate in metadata URL: %q", strings.Join(segments, "/"))
+	}
+
+	return &mavenPackageInfo{
+		groupID:           groupID,
+		artifactID:        artifactID,
+		isMetadata:        true,
+		checksumExtension: checksumExtension,
+	}, nil
+}
+
+// isValidMavenCoordinate reports whether s is a plausible groupId,
+// artifactId or version: non-empty and free of characters Maven rejects.
+func isValidMavenCoordinate(s string) bool {
+	if s == "" || s == "." || s == ".." {
+		return false
+	}
+
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '-', r == '_', r == '.', r == '+':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
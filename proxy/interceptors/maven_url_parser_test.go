@@ -0,0 +1,95 @@
+package interceptors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMavenParser(t *testing.T) {
+	cases := []struct {
+		name       string
+		parser     mavenParser
+		path       string
+		pkg        string
+		version    string
+		isDownload bool
+		isMetadata bool
+		checksum   string
+		wantErr    bool
+	}{
+		{
+			name:       "central jar",
+			parser:     mavenParser{basePath: "maven2"},
+			path:       "/maven2/org/apache/commons/commons-lang3/3.14.0/commons-lang3-3.14.0.jar",
+			pkg:        "org.apache.commons:commons-lang3",
+			version:    "3.14.0",
+			isDownload: true,
+		},
+		{
+			name:       "central pom",
+			parser:     mavenParser{basePath: "maven2"},
+			path:       "/maven2/com/google/guava/guava/33.0.0-jre/guava-33.0.0-jre.pom",
+			pkg:        "com.google.guava:guava",
+			version:    "33.0.0-jre",
+			isDownload: true,
+		},
+		{
+			name:       "classifier jar",
+			parser:     mavenParser{},
+			path:       "/io/netty/netty-transport-native-epoll/4.1.100.Final/netty-transport-native-epoll-4.1.100.Final-linux-x86_64.jar",
+			pkg:        "io.netty:netty-transport-native-epoll",
+			version:    "4.1.100.Final",
+			isDownload: true,
+		},
+		{
+			name:    "gradle module metadata is not analyzed",
+			parser:  mavenParser{},
+			path:    "/com/squareup/okio/okio/3.6.0/okio-3.6.0.module",
+			pkg:     "com.squareup.okio:okio",
+			version: "3.6.0",
+		},
+		{
+			name:       "artifact metadata",
+			parser:     mavenParser{basePath: "maven2"},
+			path:       "/maven2/org/slf4j/slf4j-api/maven-metadata.xml",
+			pkg:        "org.slf4j:slf4j-api",
+			isMetadata: true,
+		},
+		{
+			name:       "artifact metadata checksum",
+			parser:     mavenParser{},
+			path:       "/org/slf4j/slf4j-api/maven-metadata.xml.sha1",
+			pkg:        "org.slf4j:slf4j-api",
+			isMetadata: true,
+			checksum:   ".sha1",
+		},
+		{name: "artifact checksum", parser: mavenParser{}, path: "/org/slf4j/slf4j-api/2.0.9/slf4j-api-2.0.9.jar.sha1"},
+		{name: "snapshot artifact", parser: mavenParser{}, path: "/com/acme/lib/1.0-SNAPSHOT/lib-1.0-20240101.120000-1.jar"},
+		{name: "snapshot metadata", parser: mavenParser{}, path: "/com/acme/lib/1.0-SNAPSHOT/maven-metadata.xml"},
+		{name: "outside repository root", parser: mavenParser{basePath: "maven2"}, path: "/org/slf4j/slf4j-api/maven-metadata.xml", wantErr: true},
+		{name: "file of another artifact", parser: mavenParser{}, path: "/org/slf4j/slf4j-api/2.0.9/evil-2.0.9.jar", wantErr: true},
+		{name: "file of another version", parser: mavenParser{}, path: "/org/slf4j/slf4j-api/2.0.9/slf4j-api-2.1.0.jar", wantErr: true},
+		{name: "too short", parser: mavenParser{}, path: "/slf4j-api/2.0.9/slf4j-api-2.0.9.jar", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := tc.parser.ParseURL(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.pkg, info.GetName())
+			assert.Equal(t, tc.version, info.GetVersion())
+			assert.Equal(t, tc.isDownload, info.IsFileDownload())
+
+			mavenInfo := info.(*mavenPackageInfo)
+			assert.Equal(t, tc.isMetadata, mavenInfo.isMetadata)
+			assert.Equal(t, tc.checksum, mavenInfo.checksumExtension)
+		})
+	}
+}
@@ -145,15 +145,29 @@ func (h *npmCooldownHandler) stripCooldownVersions(body []byte, dates map[string
 		return body, nil, remaining
 	}
 
+	result, ok := stripNpmVersions("Cooldown", body, tooNew, slices.Collect(maps.Keys(dates)))
+	if !ok {
+		return body, nil, remaining
+	}
+
+	return result, slices.Collect(maps.Keys(tooNew)), remaining
+}
+
+// stripNpmVersions removes the versions in remove from an npm packument: their
+// entries in "versions" and "time", and the dist-tags pointing at them.
+// known lists the versions a repaired latest tag may point to; nil means the
+// versions that survive in "versions". control names the caller in log lines.
+// It returns false when the packument could not be rewritten.
+func stripNpmVersions(control string, body []byte, remove map[string]bool, known []string) ([]byte, bool) {
 	var metadata map[string]json.RawMessage
 	if err := json.Unmarshal(body, &metadata); err != nil {
-		log.Warnf("Cooldown: failed to unmarshal metadata body: %v", err)
-		return body, nil, remaining
+		log.Warnf("%s: failed to unmarshal metadata body: %v", control, err)
+		return body, false
 	}
 
 	// survivingVersions holds the version keys still present in the "versions" object
 	// after stripping. It is nil if the field is missing or unparseable, in which case
-	// dist-tag repair falls back to the publish-date set. When non-nil it bounds the
+	// dist-tag repair falls back to the known set. When non-nil it bounds the
 	// repair candidates so a repaired dist-tag never points to a version absent from
 	// the packument (e.g. an unpublished version whose "time" entry lingers).
 	var survivingVersions map[string]bool
@@ -161,9 +175,9 @@ func (h *npmCooldownHandler) stripCooldownVersions(body []byte, dates map[string
 	if raw, ok := metadata["versions"]; ok {
 		var versions map[string]json.RawMessage
 		if err := json.Unmarshal(raw, &versions); err != nil {
-			log.Warnf("Cooldown: failed to unmarshal versions field: %v", err)
+			log.Warnf("%s: failed to unmarshal versions field: %v", control, err)
 		} else {
-			for v := range tooNew {
+			for v := range remove {
 				delete(versions, v)
 			}
 			survivingVersions = make(map[string]bool, len(versions))
@@ -171,23 +185,27 @@ func (h *npmCooldownHandler) stripCooldownVersions(body []byte, dates map[string
 				survivingVersions[v] = true
 			}
 			if updated, err := json.Marshal(versions); err != nil {
-				log.Warnf("Cooldown: failed to marshal updated versions: %v", err)
+				log.Warnf("%s: failed to marshal updated versions: %v", control, err)
 			} else {
 				metadata["versions"] = updated
 			}
 		}
 	}
 
+	if known == nil {
+		known = slices.Collect(maps.Keys(survivingVersions))
+	}
+
 	if raw, ok := metadata["time"]; ok {
 		var timeMap map[string]string
 		if err := json.Unmarshal(raw, &timeMap); err != nil {
-			log.Warnf("Cooldown: failed to unmarshal time field: %v", err)
+			log.Warnf("%s: failed to unmarshal time field: %v", control, err)
 		} else {
-			for v := range tooNew {
+			for v := range remove {
 				delete(timeMap, v)
 			}
 			if updated, err := json.Marshal(timeMap); err != nil {
-				log.Warnf("Cooldown: failed to marshal updated time: %v", err)
+				log.Warnf("%s: failed to marshal updated time: %v", control, err)
 			} else {
 				metadata["time"] = updated
 			}
@@ -197,17 +215,17 @@ func (h *npmCooldownHandler) stripCooldownVersions(body []byte, dates map[string
 	if raw, ok := metadata["dist-tags"]; ok {
 		var distTags map[string]string
 		if err := json.Unmarshal(raw, &distTags); err != nil {
-			log.Warnf("Cooldown: failed to unmarshal dist-tags field: %v", err)
+			log.Warnf("%s: failed to unmarshal dist-tags field: %v", control, err)
 		} else {
 			changed := false
 
 			// Repair the latest tag only when it points at a stripped version.
 			// The eligible-version scan and semver parsing are deferred to this
 			// branch so an unaffected latest tag costs nothing.
-			if latest, ok := distTags["latest"]; ok && tooNew[latest] {
-				eligible := make([]string, 0, len(dates))
-				for v := range dates {
-					if tooNew[v] {
+			if latest, ok := distTags["latest"]; ok && remove[latest] {
+				eligible := make([]string, 0, len(known))
+				for _, v := range known {
+					if remove[v] {
 						continue
 					}
 					if survivingVersions != nil && !survivingVersions[v] {
@@ -221,22 +239,22 @@ func (h *npmCooldownHandler) stripCooldownVersions(body []byte, dates map[string
 					// `npm install <pkg>` resolves a real release — never a
 					// more-recently-published prerelease or platform-specific
 					// build (see #275).
-					log.Infof("Cooldown: repaired dist-tag latest %s -> %s for stripped version", latest, latestStable)
+					log.Infof("%s: repaired dist-tag latest %s -> %s for stripped version", control, latest, latestStable)
 					distTags["latest"] = latestStable
 				} else {
 					// No stable version survives — drop the tag so npm fails
 					// cleanly instead of mis-resolving.
-					log.Infof("Cooldown: removed dist-tag latest (was %s, no eligible stable version remains)", latest)
+					log.Infof("%s: removed dist-tag latest (was %s, no eligible stable version remains)", control, latest)
 					delete(distTags, "latest")
 				}
 				changed = true
 			}
 
 			// Drop any non-latest tag (beta, next, platform tags) whose target was
-			// stripped: an explicit `pkg@<tag>` request for a version in cooldown
+			// stripped: an explicit `pkg@<tag>` request for a stripped version
 			// should fail cleanly, not resolve to an unrelated version.
 			for tag, version := range distTags {
-				if tag != "latest" && tooNew[version] {
+				if tag != "latest" && remove[version] {
 					delete(distTags, tag)
 					changed = true
 				}
@@ -244,7 +262,7 @@ func (h *npmCooldownHandler) stripCooldownVersions(body []byte, dates map[string
 
 			if changed {
 				if updated, err := json.Marshal(distTags); err != nil {
-					log.Warnf("Cooldown: failed to marshal updated dist-tags: %v", err)
+					log.Warnf("%s: failed to marshal updated dist-tags: %v", control, err)
 				} else {
 					metadata["dist-tags"] = updated
 				}
@@ -254,9 +272,9 @@ func (h *npmCooldownHandler) stripCooldownVersions(body []byte, dates map[string
 
 	result, err := json.Marshal(metadata)
 	if err != nil {
-		log.Warnf("Cooldown: failed to marshal final metadata: %v", err)
-		return body, nil, remaining
+		log.Warnf("%s: failed to marshal final metadata: %v", control, err)
+		return body, false
 	}
 
-	return result, slices.Collect(maps.Keys(tooNew)), remaining
+	return result, true
 }
@@ -664,6 +664,8 @@ func TestNpmCooldown_InterceptorDelegation_CooldownEnabled(t *testing.T) {
 
 func TestNpmCooldown_InterceptorDelegation_CooldownDisabled(t *testing.T) {
 	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false, Days: 5})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
+	setNpmSignaturesConfig(t, config.NpmSignaturesConfig{Policy: config.NpmSignaturePolicyOff})
 
 	interceptor := newTestDefaultNpmInterceptor(t)
 
@@ -0,0 +1,51 @@
+package interceptors
+
+import (
+	"net/http"
+
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/proxy"
+)
+
+// observeNpmPackument makes the metadata response for packageName a full
+// packument and hands it to observers before resp's own response modifier
+// runs. Observers see the packument as the registry served it, before
+// cooldown strips any version. Responses other than allow or modify are
+// returned unchanged.
+func observeNpmPackument(
+	ctx *proxy.RequestContext,
+	packageName string,
+	observers []analyzer.NpmPackumentObserver,
+	resp *proxy.InterceptorResponse,
+) *proxy.InterceptorResponse {
+	if resp.Action != proxy.ActionAllow && resp.Action != proxy.ActionModifyResponse {
+		return resp
+	}
+
+	log.Debugf("[%s] Registering packument observer for %s", ctx.RequestID, packageName)
+
+	// Abbreviated metadata (Accept: application/vnd.npm.install-v1+json)
+	// omits the scripts of each version.
+	ctx.Headers.Set("Accept", "application/json")
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+
+	next := resp.ResponseModifier
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode == http.StatusOK {
+			for _, observer := range observers {
+				observer.ObserveNpmPackument(packageName, body)
+			}
+		}
+
+		if next == nil {
+			return statusCode, headers, body, nil
+		}
+		return next(statusCode, headers, body)
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}
+}
@@ -0,0 +1,94 @@
+package interceptors
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// observingAnalyzer records the packuments the interceptor hands it.
+type observingAnalyzer struct {
+	mockAnalyzer
+	observed map[string][]byte
+}
+
+func (o *observingAnalyzer) ObserveNpmPackument(name string, packument []byte) {
+	if o.observed == nil {
+		o.observed = map[string][]byte{}
+	}
+	o.observed[name] = packument
+}
+
+func newTestNpmObservingInterceptor(t *testing.T, a *observingAnalyzer) *NpmRegistryInterceptor {
+	t.Helper()
+	return newNpmRegistryInterceptor(a, NewInMemoryAnalysisCache(), NewAnalysisStatsCollector(), make(chan *ConfirmationRequest, 1), InterceptorContext{},
+		newTestCustomRegistrySetFor(t, packagev1.Ecosystem_ECOSYSTEM_NPM, "https://packages.test/npm"))
+}
+
+func TestNpmRegistryInterceptor_ObserverGetsFullPackumentWithoutCooldown(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+
+	a := &observingAnalyzer{}
+	interceptor := newTestNpmObservingInterceptor(t, a)
+
+	ctx := makeTestRequestContext("https://packages.test/npm/demo")
+	ctx.Headers.Set("Accept", "application/vnd.npm.install-v1+json")
+	ctx.Headers.Set("If-None-Match", `"etag-value"`)
+
+	resp, err := interceptor.HandleRequest(ctx)
+	require.NoError(t, err)
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+	assert.Equal(t, "application/json", ctx.Headers.Get("Accept"))
+	assert.Empty(t, ctx.Headers.Get("If-None-Match"))
+
+	body := []byte(`{"name":"demo","versions":{"1.0.0":{}}}`)
+	status, _, out, err := resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, body, out)
+	assert.Equal(t, body, a.observed["demo"])
+	assert.Zero(t, a.callCount)
+}
+
+func TestNpmRegistryInterceptor_ObserverSkipsErrorResponses(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+
+	a := &observingAnalyzer{}
+	interceptor := newTestNpmObservingInterceptor(t, a)
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://packages.test/npm/demo"))
+	require.NoError(t, err)
+
+	_, _, _, err = resp.ResponseModifier(http.StatusNotFound, http.Header{}, []byte(`{"error":"Not found"}`))
+	require.NoError(t, err)
+	assert.Empty(t, a.observed)
+}
+
+func TestNpmRegistryInterceptor_ObserverRunsBeforeCooldown(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: true, Days: 5})
+
+	a := &observingAnalyzer{}
+	interceptor := newTestNpmObservingInterceptor(t, a)
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://packages.test/npm/demo"))
+	require.NoError(t, err)
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	body := []byte(fmt.Sprintf(`{"name":"demo","dist-tags":{"latest":"1.0.0"},`+
+		`"versions":{"1.0.0":{},"2.0.0":{}},"time":{"1.0.0":"2020-01-01T00:00:00Z","2.0.0":%q}}`, now))
+
+	_, _, out, err := resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+
+	// The observer sees the version cooldown strips from the client's copy.
+	assert.Equal(t, body, a.observed["demo"])
+	assert.NotContains(t, string(out), `"2.0.0":{}`)
+}
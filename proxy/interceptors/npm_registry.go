@@ -2,11 +2,14 @@ package interceptors
 
 import (
 	"net/http"
+	"time"
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 	"github.com/safedep/dry/log"
 	"github.com/safedep/pmg/analyzer"
 	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/contentscan"
+	"github.com/safedep/pmg/internal/license"
 	"github.com/safedep/pmg/proxy"
 )
 
@@ -21,8 +24,12 @@ var npmRegistryEndpoints = []registryEndpoint{
 // It embeds baseRegistryInterceptor to reuse ecosystem agnostic functionality
 type NpmRegistryInterceptor struct {
 	baseRegistryInterceptor
-	cooldownHandler *npmCooldownHandler
-	registries      registrySet
+	cooldownHandler   *npmCooldownHandler
+	licenseHandler    *licenseHandler
+	packageAgeHandler *packageAgeHandler
+	integrityHandler  *registryIntegrityHandler
+	signatureHandler  *npmSignatureHandler
+	registries        registrySet
 }
 
 var _ proxy.Interceptor = (*NpmRegistryInterceptor)(nil)
@@ -45,8 +52,12 @@ func newNpmRegistryInterceptor(
 			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-npm"),
 			execContext:      execContext,
 		},
-		cooldownHandler: newNpmCooldownHandler(statsCollector),
-		registries:      registries,
+		cooldownHandler:   newNpmCooldownHandler(statsCollector),
+		licenseHandler:    newLicenseHandler(),
+		packageAgeHandler: newPackageAgeHandler(),
+		integrityHandler:  newRegistryIntegrityHandler(),
+		signatureHandler:  newNpmSignatureHandler(),
+		registries:        registries,
 	}
 }
 
@@ -98,8 +109,25 @@ func (i *NpmRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*prox
 	// Parse URL using registry-specific strategy
 	pkgInfo, parseErr := endpoint.Parser.ParseURL(match.RelativePath)
 
+	if parseErr == nil {
+		if resp, refused := i.checkPrivateNamespace(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, pmgconfig.PrivateNamespaceEcosystemNpm,
+			endpoint.Name, pkgInfo.GetName(), pkgInfo.GetVersion(), packageInfoHasCompleteIdentity(pkgInfo)); refused {
+			return resp, nil
+		}
+	}
+
 	if parseErr == nil && packageInfoHasCompleteIdentity(pkgInfo) {
-		return i.handleArtifact(ctx, pkgInfo.GetName(), pkgInfo.GetVersion())
+		resp, err := i.handleArtifact(ctx, pkgInfo.GetName(), pkgInfo.GetVersion())
+		if err != nil {
+			return resp, err
+		}
+		name, version := pkgInfo.GetName(), pkgInfo.GetVersion()
+		resp = i.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version, resp,
+			i.registryIntegrityInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version, i.integrityHandler.NpmHashes(name, version)),
+			i.lockfileInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version),
+			i.npmProvenanceInspector(ctx, name, version),
+			i.contentScanInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version, contentscan.FormatTarGz, ""))
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, endpoint.Name, name, version, resp), nil
 	}
 
 	if parseErr != nil {
@@ -118,33 +146,106 @@ func (i *NpmRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*prox
 	return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
 }
 
-// handleMetadataRequest applies dependency cooldown to a metadata request.
+// handleMetadataRequest applies dependency cooldown to a metadata request and
+// hands the packument to the analyzers that observe npm packuments, and to
+// the license, package age, registry integrity and signature checks when
+// they are enabled.
 func (i *NpmRegistryInterceptor) handleMetadataRequest(
 	ctx *proxy.RequestContext,
 	pkgInfo packageInfo,
 ) (*proxy.InterceptorResponse, error) {
-	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
-	if !depCooldownConfig.Enabled ||
-		pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_NPM, pkgInfo.GetName()) {
+	if pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_NPM, pkgInfo.GetName()) {
 		log.Debugf("[%s] Skipping analysis for metadata request: %s", ctx.RequestID, pkgInfo.GetName())
 		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
 	}
 
-	return i.cooldownHandler.HandleMetadataRequest(ctx, pkgInfo.GetName(), depCooldownConfig.Days, i.execContext.PinnedVersions[pkgInfo.GetName()])
+	resp := &proxy.InterceptorResponse{Action: proxy.ActionAllow}
+	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
+	if depCooldownConfig.Enabled {
+		var err error
+		resp, err = i.cooldownHandler.HandleMetadataRequest(ctx, pkgInfo.GetName(), depCooldownConfig.Days, i.execContext.PinnedVersions[pkgInfo.GetName()])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pmgconfig.Get().Config.MalwareStripping.Enabled {
+		resp = i.stripMalwareVersions(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, pkgInfo.GetName(),
+			i.execContext.PinnedVersions[pkgInfo.GetName()], npmMalwareStripFormat, resp)
+	}
+
+	observers := analyzer.NpmPackumentObservers(i.analyzer)
+	if pmgconfig.Get().Config.LicensePolicy.Enabled {
+		observers = append(observers, i.licenseHandler)
+	}
+	if pmgconfig.Get().Config.PackageAge.Enabled {
+		observers = append(observers, i.packageAgeHandler)
+	}
+	if len(observers) > 0 {
+		resp = observeNpmPackument(ctx, pkgInfo.GetName(), observers, resp)
+	}
+
+	if pmgconfig.Get().Config.RegistryIntegrity.Enabled {
+		name := pkgInfo.GetName()
+		resp = observeRegistryMetadata(ctx, name, "Registry integrity", func(body []byte, _ string) error {
+			return i.integrityHandler.ObserveNpmPackument(name, body)
+		}, resp)
+	}
+
+	if pmgconfig.Get().Config.NpmSignatures.PolicyFor(ctx.Hostname) != pmgconfig.NpmSignaturePolicyOff {
+		name := pkgInfo.GetName()
+		resp = observeRegistryMetadata(ctx, name, "npm signatures", func(body []byte, _ string) error {
+			return i.signatureHandler.ObserveNpmPackument(name, body)
+		}, resp)
+	}
+
+	return resp, nil
 }
 
-// handleArtifact runs the trust, analysis, and verdict pipeline for an
-// artifact download identified by canonical URL parsing.
+// handleArtifact runs the trust, lockfile, signature, package age, license,
+// analysis, and verdict pipeline for an artifact download identified by
+// canonical URL parsing.
 func (i *NpmRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, name, version string) (*proxy.InterceptorResponse, error) {
 	if resp, ok := i.fastAllow(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version); ok {
 		return resp, nil
 	}
 
+	if resp, blocked := i.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version); blocked {
+		return resp, nil
+	}
+
+	if resp, blocked := i.checkNpmSignature(ctx, name, version); blocked {
+		return resp, nil
+	}
+
+	if resp, blocked := i.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version, func() (time.Time, error) {
+		return i.packageAgeHandler.NpmFirstRelease(npmRegistryBaseURL(ctx, i.registries), name)
+	}); blocked {
+		return resp, nil
+	}
+
+	if resp, blocked := i.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version, func() (*license.Expression, error) {
+		return i.licenseHandler.NpmLicense(npmRegistryBaseURL(ctx, i.registries), name, version)
+	}); blocked {
+		return resp, nil
+	}
+
 	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version)
 	if err != nil {
-		log.Errorf("[%s] Failed to analyze package %s@%s: %v", ctx.RequestID, name, version, err)
-		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+		return i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version, err), nil
 	}
 
 	return i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, name, version, result)
 }
+
+// npmRegistryBaseURL returns the root of the registry an npm request went to,
+// under which its packuments and version documents are served, or "" when
+// the request matches no registry.
+func npmRegistryBaseURL(ctx *proxy.RequestContext, registries registrySet) string {
+	match := registryRequestMatch(registries, ctx)
+	u := registryAbsoluteRequestURL(ctx)
+	if match == nil || u == nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + match.Endpoint.BasePath
+}
@@ -130,9 +130,10 @@ func TestNpmRegistryInterceptor_Custom_OpaqueArtifactIsAllowedWithoutAnalysis(t
 
 func TestNpmRegistryInterceptor_Custom_MetadataResponseIsNotModifiedWithoutCooldown(t *testing.T) {
 	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
 
-	// With cooldown disabled a metadata response must pass through
-	// untouched: no response modifier, no header mutation.
+	// With cooldown and registry integrity disabled a metadata response
+	// must pass through untouched: no response modifier, no header mutation.
 	mock := &mockAnalyzer{}
 	interceptor := newTestNpmCustomInterceptor(t, mock, "https://packages.test/npm")
 
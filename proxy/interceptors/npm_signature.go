@@ -0,0 +1,203 @@
+package interceptors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/internal/npmsig"
+	"github.com/safedep/pmg/proxy"
+)
+
+// npmSignedDist is what a registry signs for an npm package version: its
+// integrity, and the signatures over it. published is when the version was
+// published, zero when its document carries no time.
+type npmSignedDist struct {
+	integrity  string
+	signatures []npmsig.Signature
+	published  time.Time
+}
+
+// npmVersionDist is the dist of an npm version document, or of a version in
+// a packument.
+type npmVersionDist struct {
+	Dist struct {
+		Integrity  string             `json:"integrity"`
+		Signatures []npmsig.Signature `json:"signatures"`
+	} `json:"dist"`
+}
+
+// maxNpmVersionDocumentSize bounds a version document fetched to verify a
+// version whose packument was not seen.
+const maxNpmVersionDocumentSize = 16 << 20
+
+// npmSignatureHandler remembers the dist.integrity and dist.signatures of the
+// npm packuments PMG proxies and verifies them against the registry keyset
+// when a version is downloaded.
+type npmSignatureHandler struct {
+	mu    sync.Mutex
+	dists map[string]npmSignedDist
+
+	keysOnce sync.Once
+	keys     *npmsig.Keyset
+}
+
+func newNpmSignatureHandler() *npmSignatureHandler {
+	return &npmSignatureHandler{dists: map[string]npmSignedDist{}}
+}
+
+// ObserveNpmPackument records the signed dist of every version in a full or
+// abbreviated packument. Only full packuments carry publish times.
+func (h *npmSignatureHandler) ObserveNpmPackument(name string, packument []byte) error {
+	var metadata struct {
+		Versions map[string]npmVersionDist `json:"versions"`
+		Time     map[string]string         `json:"time"`
+	}
+	if err := json.Unmarshal(packument, &metadata); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for version, v := range metadata.Versions {
+		published, _ := time.Parse(time.RFC3339, metadata.Time[version])
+		h.dists[name+"@"+version] = npmSignedDist{
+			integrity:  v.Dist.Integrity,
+			signatures: v.Dist.Signatures,
+			published:  published,
+		}
+	}
+	return nil
+}
+
+// Verify checks the registry signature of a package version. A version
+// whose packument was not observed has its version document fetched from
+// baseURL, the registry the artifact is downloaded from; seen is false when
+// baseURL is empty, so there is nothing to verify.
+func (h *npmSignatureHandler) Verify(baseURL, name, version string) (seen bool, err error) {
+	h.mu.Lock()
+	dist, seen := h.dists[name+"@"+version]
+	h.mu.Unlock()
+	if !seen {
+		if baseURL == "" {
+			return false, nil
+		}
+		if dist, err = h.fetchVersion(baseURL, name, version); err != nil {
+			return true, fmt.Errorf("failed to fetch the registry signature: %w", err)
+		}
+	}
+
+	return true, h.keyset().Verify(name, version, dist.integrity, dist.published, dist.signatures)
+}
+
+// fetchVersion fetches the signed dist of a version from its version
+// document and remembers it.
+func (h *npmSignatureHandler) fetchVersion(baseURL, name, version string) (npmSignedDist, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(name), url.PathEscape(version))
+	body, err := fetchUpstream(endpoint, "application/json", maxNpmVersionDocumentSize)
+	if err != nil {
+		return npmSignedDist{}, err
+	}
+
+	var document npmVersionDist
+	if err := json.Unmarshal(body, &document); err != nil {
+		return npmSignedDist{}, err
+	}
+
+	dist := npmSignedDist{integrity: document.Dist.Integrity, signatures: document.Dist.Signatures}
+	h.mu.Lock()
+	h.dists[name+"@"+version] = dist
+	h.mu.Unlock()
+	return dist, nil
+}
+
+// keyset loads the keyset installed by `pmg setup keys update`, falling back
+// to the built-in one when there is none or it cannot be read.
+func (h *npmSignatureHandler) keyset() *npmsig.Keyset {
+	h.keysOnce.Do(func() {
+		path := config.Get().NpmKeysPath()
+		keys, err := npmsig.Load(path)
+		if err != nil {
+			log.Warnf("npm signatures: failed to load %s, using the built-in keyset: %v", path, err)
+			keys = npmsig.Embedded()
+		}
+		h.keys = keys
+	})
+	return h.keys
+}
+
+// checkNpmSignature applies npm_signatures to an artifact download. A
+// version whose packument was not seen in this session, such as one npm
+// resolved from its lockfile, is only checked under require, which fetches
+// its version document from the registry. It returns (response, true) when
+// the policy blocked the download.
+func (i *NpmRegistryInterceptor) checkNpmSignature(
+	ctx *proxy.RequestContext,
+	packageName string,
+	packageVersion string,
+) (*proxy.InterceptorResponse, bool) {
+	policy := config.Get().Config.NpmSignatures.PolicyFor(ctx.Hostname)
+	if policy == config.NpmSignaturePolicyOff {
+		return nil, false
+	}
+
+	var baseURL string
+	if policy == config.NpmSignaturePolicyRequire {
+		baseURL = npmRegistryBaseURL(ctx, i.registries)
+	}
+
+	seen, err := i.signatureHandler.Verify(baseURL, packageName, packageVersion)
+	if !seen {
+		log.Debugf("[%s] npm signatures: packument of %s@%s not seen, skipping verification", ctx.RequestID, packageName, packageVersion)
+		return nil, false
+	}
+	if err == nil {
+		log.Debugf("[%s] npm signatures: %s@%s verified", ctx.RequestID, packageName, packageVersion)
+		return nil, false
+	}
+
+	pkgVersion := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM, Name: packageName},
+		Version: packageVersion,
+	}
+
+	if policy == config.NpmSignaturePolicyWarn {
+		log.Warnf("[%s] npm signatures: %s@%s from %s: %v", ctx.RequestID, packageName, packageVersion, ctx.Hostname, err)
+		audit.LogNpmSignature(pkgVersion, ctx.Hostname, err.Error(), audit.NpmSignatureWarned)
+		return nil, false
+	}
+
+	log.Warnf("[%s] Blocking %s@%s from %s: %v", ctx.RequestID, packageName, packageVersion, ctx.Hostname, err)
+	audit.LogNpmSignature(pkgVersion, ctx.Hostname, err.Error(), audit.NpmSignatureBlocked)
+
+	if i.statsCollector != nil {
+		i.statsCollector.RecordNpmSignatureBlocked(models.NpmSignatureBlock{
+			Name:     packageName,
+			Version:  packageVersion,
+			Registry: ctx.Hostname,
+			Reason:   err.Error(),
+		})
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:      proxy.ActionBlock,
+		BlockCode:   http.StatusForbidden,
+		BlockReason: proxy.BlockReasonNpmSignature,
+		BlockContext: &proxy.BlockContext{
+			Ecosystem:      packagev1.Ecosystem_ECOSYSTEM_NPM,
+			PackageName:    packageName,
+			PackageVersion: packageVersion,
+			Registry:       ctx.Hostname,
+			SignatureError: err.Error(),
+		},
+	}, true
+}
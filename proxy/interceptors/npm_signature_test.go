@@ -0,0 +1,189 @@
+package interceptors
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/internal/npmsig"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setNpmSignaturesConfig(t *testing.T, cfg config.NpmSignaturesConfig) {
+	t.Helper()
+
+	orig := config.Get().Config.NpmSignatures
+	t.Cleanup(func() { config.Get().Config.NpmSignatures = orig })
+	config.Get().Config.NpmSignatures = cfg
+}
+
+// useTestNpmKeyset makes handler verify with a freshly generated key and
+// returns a function signing "name@version:integrity" with it.
+func useTestNpmKeyset(t *testing.T, handler *npmSignatureHandler) func(message string) string {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	require.NoError(t, err)
+
+	keys, err := npmsig.Parse([]byte(`{"keys":[{"keyid":"SHA256:test","expires":null,"key":"` +
+		base64.StdEncoding.EncodeToString(der) + `"}]}`))
+	require.NoError(t, err)
+	handler.keysOnce.Do(func() { handler.keys = keys })
+
+	return func(message string) string {
+		digest := sha256.Sum256([]byte(message))
+		sig, err := ecdsa.SignASN1(rand.Reader, private, digest[:])
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+}
+
+func TestCheckNpmSignature(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setNpmSignaturesConfig(t, config.NpmSignaturesConfig{Policy: config.NpmSignaturePolicyRequire})
+
+	interceptor := newTestDefaultNpmInterceptor(t)
+	sign := useTestNpmKeyset(t, interceptor.signatureHandler)
+
+	ctx := makeTestRequestContext("https://registry.npmjs.org/demo")
+	resp, err := interceptor.HandleRequest(ctx)
+	require.NoError(t, err)
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+
+	body := []byte(`{"name":"demo","versions":{` +
+		`"1.0.0":{"dist":{"integrity":"sha512-AAAA","signatures":[{"keyid":"SHA256:test","sig":"` + sign("demo@1.0.0:sha512-AAAA") + `"}]}},` +
+		`"1.0.1":{"dist":{"integrity":"sha512-BBBB","signatures":[{"keyid":"SHA256:test","sig":"` + sign("demo@1.0.1:sha512-AAAA") + `"}]}},` +
+		`"1.0.2":{"dist":{"integrity":"sha512-CCCC"}}}}`)
+	_, _, _, err = resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+
+	artifactCtx := makeTestRequestContext("https://registry.npmjs.org/demo/-/demo-1.0.0.tgz")
+	_, blocked := interceptor.checkNpmSignature(artifactCtx, "demo", "1.0.0")
+	assert.False(t, blocked)
+
+	blockResp, blocked := interceptor.checkNpmSignature(artifactCtx, "demo", "1.0.1")
+	require.True(t, blocked)
+	assert.Equal(t, proxy.ActionBlock, blockResp.Action)
+	assert.Equal(t, proxy.BlockReasonNpmSignature, blockResp.BlockReason)
+	assert.Equal(t, "registry.npmjs.org", blockResp.BlockContext.Registry)
+	assert.Equal(t, npmsig.ErrInvalidSignature.Error(), blockResp.BlockContext.SignatureError)
+
+	_, blocked = interceptor.checkNpmSignature(artifactCtx, "demo", "1.0.2")
+	require.True(t, blocked)
+
+	stats := interceptor.statsCollector.GetStats()
+	assert.Equal(t, 2, stats.BlockedCount)
+	assert.Equal(t, 2, stats.NpmSignatureBlockedCount)
+	assert.Equal(t, models.NpmSignatureBlock{
+		Name:     "demo",
+		Version:  "1.0.2",
+		Registry: "registry.npmjs.org",
+		Reason:   npmsig.ErrUnsigned.Error(),
+	}, interceptor.statsCollector.GetNpmSignatureBlocks()[1])
+
+	// warn only logs, and does not verify a version whose packument was not
+	// seen.
+	config.Get().Config.NpmSignatures.Policy = config.NpmSignaturePolicyWarn
+	_, blocked = interceptor.checkNpmSignature(artifactCtx, "demo", "1.0.1")
+	assert.False(t, blocked)
+	_, blocked = interceptor.checkNpmSignature(artifactCtx, "demo", "2.0.0")
+	assert.False(t, blocked)
+}
+
+func TestNpmSignatureHandler_FetchesUnseenVersion(t *testing.T) {
+	handler := newNpmSignatureHandler()
+	sign := useTestNpmKeyset(t, handler)
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.EscapedPath() != "/@scope%2Fdemo/1.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"@scope/demo","version":"1.0.0","dist":{"integrity":"sha512-AAAA",` +
+			`"signatures":[{"keyid":"SHA256:test","sig":"` + sign("@scope/demo@1.0.0:sha512-AAAA") + `"}]}}`))
+	}))
+	defer server.Close()
+
+	// Without a registry to fetch from there is nothing to verify.
+	seen, err := handler.Verify("", "@scope/demo", "1.0.0")
+	assert.False(t, seen)
+	assert.NoError(t, err)
+
+	for range 2 {
+		seen, err = handler.Verify(server.URL, "@scope/demo", "1.0.0")
+		assert.True(t, seen)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), hits.Load())
+
+	// A version the registry does not serve cannot be verified.
+	seen, err = handler.Verify(server.URL, "@scope/demo", "2.0.0")
+	assert.True(t, seen)
+	assert.Error(t, err)
+}
+
+func TestNpmSignatureHandler_ExpiredKey(t *testing.T) {
+	handler := newNpmSignatureHandler()
+	sign := useTestNpmKeyset(t, handler)
+	expires := time.Date(2025, 1, 29, 0, 0, 0, 0, time.UTC)
+	handler.keys.Keys[0].Expires = &expires
+
+	require.NoError(t, handler.ObserveNpmPackument("demo", []byte(`{"versions":{`+
+		`"1.0.0":{"dist":{"integrity":"sha512-AAAA","signatures":[{"keyid":"SHA256:test","sig":"`+sign("demo@1.0.0:sha512-AAAA")+`"}]}},`+
+		`"2.0.0":{"dist":{"integrity":"sha512-BBBB","signatures":[{"keyid":"SHA256:test","sig":"`+sign("demo@2.0.0:sha512-BBBB")+`"}]}}},`+
+		`"time":{"1.0.0":"2024-06-01T00:00:00.000Z","2.0.0":"2025-06-01T00:00:00.000Z"}}`)))
+
+	_, err := handler.Verify("", "demo", "1.0.0")
+	assert.NoError(t, err)
+	_, err = handler.Verify("", "demo", "2.0.0")
+	assert.ErrorIs(t, err, npmsig.ErrExpiredKey)
+
+	// A version document carries no publish time, so an old version signed
+	// only by the expired key still verifies when its packument was not seen.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"old","version":"0.1.0","dist":{"integrity":"sha512-CCCC",` +
+			`"signatures":[{"keyid":"SHA256:test","sig":"` + sign("old@0.1.0:sha512-CCCC") + `"}]}}`))
+	}))
+	defer server.Close()
+
+	seen, err := handler.Verify(server.URL, "old", "0.1.0")
+	assert.True(t, seen)
+	assert.NoError(t, err)
+}
+
+func TestCheckNpmSignature_OffForOtherRegistries(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
+	setNpmSignaturesConfig(t, config.NpmSignaturesConfig{Policy: config.NpmSignaturePolicyRequire})
+
+	interceptor := newTestNpmCustomInterceptor(t, &mockAnalyzer{}, "https://packages.test/npm")
+	require.NoError(t, interceptor.signatureHandler.ObserveNpmPackument("demo", []byte(`{"versions":{"1.0.0":{"dist":{}}}}`)))
+
+	ctx := makeTestRequestContext("https://packages.test/npm/demo")
+	resp, err := interceptor.HandleRequest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+
+	_, blocked := interceptor.checkNpmSignature(makeTestRequestContext("https://packages.test/npm/demo/-/demo-1.0.0.tgz"), "demo", "1.0.0")
+	assert.False(t, blocked)
+
+	config.Get().Config.NpmSignatures.Registries = []config.NpmSignatureRegistryConfig{{Host: "packages.test", Policy: "require"}}
+	_, blocked = interceptor.checkNpmSignature(makeTestRequestContext("https://packages.test/npm/demo/-/demo-1.0.0.tgz"), "demo", "1.0.0")
+	assert.True(t, blocked)
+}
@@ -0,0 +1,324 @@
+package interceptors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+// nugetCooldownMaxPageFetches caps the registration pages fetched per
+// package. Pages are walked from the newest and the walk stops at the first
+// page that reaches outside the window, so this only matters for packages
+// with a burst of recent releases.
+const nugetCooldownMaxPageFetches = 10
+
+// nugetOrgFeedResources are the api.nuget.org resources cooldown uses, so
+// the built-in feed needs no service index lookup.
+var nugetOrgFeedResources = &nugetFeedResources{
+	packageBaseAddress:   "https://api.nuget.org/v3-flatcontainer/",
+	registrationsBaseURL: "https://api.nuget.org/v3/registration5-gz-semver2/",
+}
+
+// nugetRegistrationsResourceTypes are the registration resource types in
+// order of preference. Only the SemVer 2.0.0 hives list every version.
+var nugetRegistrationsResourceTypes = []string{
+	"RegistrationsBaseUrl/3.6.0",
+	"RegistrationsBaseUrl/3.4.0",
+	"RegistrationsBaseUrl",
+}
+
+// nugetMetadataFetchClient fetches service indexes and registration pages
+// straight from the upstream feed rather than back through PMG's own proxy.
+var nugetMetadataFetchClient = &http.Client{Timeout: 10 * time.Second}
+
+// nugetFeedResources are the service index resources of a NuGet v3 feed.
+type nugetFeedResources struct {
+	// packageBaseAddress is the flat container root (PackageBaseAddress/3.0.0).
+	packageBaseAddress string
+
+	// registrationsBaseURL is the registration hive cooldown reads publish
+	// times from.
+	registrationsBaseURL string
+}
+
+// isFlatContainerIndex reports whether requestURL is the flat container
+// version list of package id.
+func (r *nugetFeedResources) isFlatContainerIndex(requestURL *url.URL, id string) bool {
+	if r == nil || r.packageBaseAddress == "" || requestURL == nil {
+		return false
+	}
+
+	want := strings.TrimSuffix(r.packageBaseAddress, "/") + "/" + id + "/index.json"
+	return strings.EqualFold(registryURLWithoutQuery(requestURL), want)
+}
+
+// nugetCooldownHandler handles dependency cooldown for NuGet packages. It
+// strips recently-published versions from the flat container index.json so
+// NuGet's resolver picks the latest eligible version. The flat container
+// carries no timestamps; publish times come from the feed's registration
+// pages. Service indexes of custom feeds are looked up once per run.
+type nugetCooldownHandler struct {
+	statsCollector *AnalysisStatsCollector
+	client         *http.Client
+
+	mu    sync.Mutex
+	feeds map[string]*nugetFeedResources
+}
+
+func newNuGetCooldownHandler(statsCollector *AnalysisStatsCollector) *nugetCooldownHandler {
+	return &nugetCooldownHandler{
+		statsCollector: statsCollector,
+		client:         nugetMetadataFetchClient,
+		feeds:          map[string]*nugetFeedResources{},
+	}
+}
+
+// nugetServiceIndex is the subset of a v3 service index cooldown needs.
+type nugetServiceIndex struct {
+	Resources []struct {
+		ID   string `json:"@id"`
+		Type string `json:"@type"`
+	} `json:"resources"`
+}
+
+// nugetRegistrationEntry is a registration index, a page or a leaf; all
+// three share this shape. A page's Items is absent when it is not inlined in
+// the index. Only the fields cooldown needs are decoded.
+type nugetRegistrationEntry struct {
+	ID           string                   `json:"@id"`
+	Items        []nugetRegistrationEntry `json:"items"`
+	CatalogEntry *nugetCatalogEntry       `json:"catalogEntry"`
+}
+
+type nugetCatalogEntry struct {
+	Version   string    `json:"version"`
+	Published time.Time `json:"published"`
+}
+
+// nugetFlatContainerIndex is the flat container index.json document.
+type nugetFlatContainerIndex struct {
+	Versions []string `json:"versions"`
+}
+
+// FeedResources returns the resources of the feed whose service index is at
+// serviceIndexURL. Lookup failures are remembered as a feed without
+// resources, so a feed that cannot be resolved is not retried on every
+// request and gets malware analysis without cooldown.
+func (h *nugetCooldownHandler) FeedResources(ctx *proxy.RequestContext, serviceIndexURL string) *nugetFeedResources {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if resources, ok := h.feeds[serviceIndexURL]; ok {
+		return resources
+	}
+
+	resources, err := h.fetchFeedResources(serviceIndexURL, ctx.Headers.Get("Authorization"))
+	if err != nil {
+		log.Warnf("[%s] Cooldown: failed to resolve NuGet service index %s; cooldown not enforced: %v",
+			ctx.RequestID, serviceIndexURL, err)
+		resources = &nugetFeedResources{}
+	}
+
+	h.feeds[serviceIndexURL] = resources
+	return resources
+}
+
+func (h *nugetCooldownHandler) fetchFeedResources(serviceIndexURL, authorization string) (*nugetFeedResources, error) {
+	var index nugetServiceIndex
+	if err := h.getJSON(serviceIndexURL, authorization, &index); err != nil {
+		return nil, err
+	}
+
+	resources := &nugetFeedResources{}
+	byType := make(map[string]string, len(index.Resources))
+	for _, resource := range index.Resources {
+		if _, ok := byType[resource.Type]; !ok {
+			byType[resource.Type] = resource.ID
+		}
+	}
+
+	resources.packageBaseAddress = byType["PackageBaseAddress/3.0.0"]
+	for _, resourceType := range nugetRegistrationsResourceTypes {
+		if id := byType[resourceType]; id != "" {
+			resources.registrationsBaseURL = id
+			break
+		}
+	}
+
+	if resources.packageBaseAddress == "" {
+		return nil, fmt.Errorf("service index has no PackageBaseAddress/3.0.0 resource")
+	}
+
+	return resources, nil
+}
+
+// HandleMetadataRequest registers a response modifier that strips versions
+// within the cooldown window from a flat container index.json.
+func (h *nugetCooldownHandler) HandleMetadataRequest(ctx *proxy.RequestContext, resources *nugetFeedResources, id string, cooldownDays int, pinnedVersion string) (*proxy.InterceptorResponse, error) {
+	skip := pmgconfig.CooldownSkip(packagev1.Ecosystem_ECOSYSTEM_NUGET, id)
+	if skip.SkipAll {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	log.Debugf("[%s] Cooldown: registering flat container modifier for %s", ctx.RequestID, id)
+
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+	authorization := ctx.Headers.Get("Authorization")
+
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode != http.StatusOK {
+			return statusCode, headers, body, nil
+		}
+
+		var index nugetFlatContainerIndex
+		if err := json.Unmarshal(body, &index); err != nil || len(index.Versions) == 0 {
+			return statusCode, headers, body, nil
+		}
+
+		dates, err := h.fetchPublishTimes(resources.registrationsBaseURL, id, authorization, cooldownDays)
+		if err != nil {
+			log.Warnf("[%s] Cooldown: no publish times for %s; cooldown not enforced: %v", ctx.RequestID, id, err)
+			return statusCode, headers, body, nil
+		}
+
+		exempt := cooldownExemptVersions(packagev1.Ecosystem_ECOSYSTEM_NUGET, id, skip, dates, cooldownDays)
+		auditCooldownSkips(ctx.RequestID, packagev1.Ecosystem_ECOSYSTEM_NUGET, id, exempt)
+
+		kept := make([]string, 0, len(index.Versions))
+		var stripped []string
+		for _, version := range index.Versions {
+			publishTime, ok := dates[version]
+			if !ok || exempt.all[version] {
+				kept = append(kept, version)
+				continue
+			}
+			if within, _, _ := cooldownIsWithinWindow(publishTime, cooldownDays); within {
+				stripped = append(stripped, version)
+				continue
+			}
+			kept = append(kept, version)
+		}
+
+		if len(stripped) == 0 {
+			return statusCode, headers, body, nil
+		}
+
+		strippedBody, err := json.Marshal(nugetFlatContainerIndex{Versions: kept})
+		if err != nil {
+			return statusCode, headers, body, nil
+		}
+
+		log.Infof("[%s] Cooldown: stripped %d version(s) from %s flat container index (%d days, %d eligible remain)",
+			ctx.RequestID, len(stripped), id, cooldownDays, len(kept))
+
+		recordCooldownStats(h.statsCollector, packagev1.Ecosystem_ECOSYSTEM_NUGET, id, pinnedVersion, dates, stripped, len(kept), cooldownDays)
+
+		headers.Del("ETag")
+		headers.Del("Last-Modified")
+		headers.Set("Cache-Control", "no-store")
+
+		return statusCode, headers, strippedBody, nil
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}, nil
+}
+
+// fetchPublishTimes returns the publish time of each version of a package,
+// keyed by the lowercase normalized version the flat container lists.
+// Registration pages are walked from the newest; the walk stops at the
+// first page holding a version outside the cooldown window.
+func (h *nugetCooldownHandler) fetchPublishTimes(registrationsBaseURL, id, authorization string, cooldownDays int) (map[string]time.Time, error) {
+	if registrationsBaseURL == "" {
+		return nil, fmt.Errorf("feed has no registration resource")
+	}
+
+	indexURL := strings.TrimSuffix(registrationsBaseURL, "/") + "/" + url.PathEscape(id) + "/index.json"
+
+	var index nugetRegistrationEntry
+	if err := h.getJSON(indexURL, authorization, &index); err != nil {
+		return nil, err
+	}
+
+	dates := make(map[string]time.Time)
+	fetches := 0
+	for i := len(index.Items) - 1; i >= 0; i-- {
+		page := index.Items[i]
+		leaves := page.Items
+		if leaves == nil {
+			if fetches == nugetCooldownMaxPageFetches {
+				break
+			}
+			fetches++
+
+			var fetched nugetRegistrationEntry
+			if err := h.getJSON(page.ID, authorization, &fetched); err != nil {
+				return nil, err
+			}
+			leaves = fetched.Items
+		}
+
+		reachedOutsideWindow := false
+		for _, leaf := range leaves {
+			if leaf.CatalogEntry == nil || leaf.CatalogEntry.Version == "" || leaf.CatalogEntry.Published.IsZero() {
+				continue
+			}
+
+			dates[normalizeNuGetVersion(leaf.CatalogEntry.Version)] = leaf.CatalogEntry.Published
+			if within, _, _ := cooldownIsWithinWindow(leaf.CatalogEntry.Published, cooldownDays); !within {
+				reachedOutsideWindow = true
+			}
+		}
+
+		if reachedOutsideWindow {
+			break
+		}
+	}
+
+	return dates, nil
+}
+
+func (h *nugetCooldownHandler) getJSON(rawURL, authorization string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s returned HTTP %d", rawURL, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", rawURL, err)
+	}
+
+	return nil
+}
+
+// normalizeNuGetVersion returns a version as the flat container lists it:
+// lowercase, without SemVer build metadata.
+func normalizeNuGetVersion(version string) string {
+	version, _, _ = strings.Cut(version, "+")
+	return strings.ToLower(version)
+}
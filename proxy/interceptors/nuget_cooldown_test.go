@@ -0,0 +1,129 @@
+package interceptors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNuGetFeed serves a service index and a registration hive for
+// contoso.lib whose versions were published at the given times. Each version
+// gets its own registration page; pages are inlined unless inline is false.
+func newTestNuGetFeed(t *testing.T, versions []string, published map[string]time.Time, inline bool) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	leaf := func(version string) map[string]any {
+		return map[string]any{"catalogEntry": map[string]any{
+			"version":   version,
+			"published": published[version].UTC().Format(time.RFC3339),
+		}}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/index.json", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"resources": []map[string]string{
+			{"@id": server.URL + "/v3/flat2/", "@type": "PackageBaseAddress/3.0.0"},
+			{"@id": server.URL + "/v3/registrations-semver1/", "@type": "RegistrationsBaseUrl"},
+			{"@id": server.URL + "/v3/registrations/", "@type": "RegistrationsBaseUrl/3.6.0"},
+		}})
+	})
+	mux.HandleFunc("/v3/registrations/contoso.lib/index.json", func(w http.ResponseWriter, r *http.Request) {
+		var pages []map[string]any
+		for _, version := range versions {
+			page := map[string]any{"@id": server.URL + "/v3/registrations/contoso.lib/page/" + version + ".json"}
+			if inline {
+				page["items"] = []map[string]any{leaf(version)}
+			}
+			pages = append(pages, page)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": pages})
+	})
+	for _, version := range versions {
+		mux.HandleFunc("/v3/registrations/contoso.lib/page/"+version+".json", func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"items": []map[string]any{leaf(version)}})
+		})
+	}
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNuGetCooldown_StripsRecentVersions(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	published := map[string]time.Time{
+		"1.0.0":        now.Add(-90 * day),
+		"2.0.0-beta.1": now.Add(-30 * day),
+		"2.0.0":        now.Add(-1 * day),
+	}
+
+	for _, inline := range []bool{true, false} {
+		server := newTestNuGetFeed(t, []string{"1.0.0", "2.0.0-Beta.1", "2.0.0"}, map[string]time.Time{
+			"1.0.0":        published["1.0.0"],
+			"2.0.0-Beta.1": published["2.0.0-beta.1"],
+			"2.0.0":        published["2.0.0"],
+		}, inline)
+
+		collector := NewAnalysisStatsCollector()
+		handler := newNuGetCooldownHandler(collector)
+		ctx := makeTestRequestContext(server.URL + "/v3/flat2/contoso.lib/index.json")
+
+		resources := handler.FeedResources(ctx, server.URL+"/v3/index.json")
+		require.True(t, resources.isFlatContainerIndex(ctx.URL, "contoso.lib"))
+		assert.Equal(t, server.URL+"/v3/registrations/", resources.registrationsBaseURL, "SemVer 2.0.0 hive is preferred")
+
+		resp, err := handler.HandleMetadataRequest(ctx, resources, "contoso.lib", 5, "")
+		require.NoError(t, err)
+		require.NotNil(t, resp.ResponseModifier)
+
+		body := []byte(`{"versions":["1.0.0","2.0.0-beta.1","2.0.0"]}`)
+		_, headers, modified, err := resp.ResponseModifier(http.StatusOK, http.Header{"Etag": {`"abc"`}}, body)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{"versions":["1.0.0","2.0.0-beta.1"]}`, string(modified))
+		assert.Empty(t, headers.Get("ETag"))
+		assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+		require.Len(t, collector.GetCooldownWithheld(), 1)
+	}
+}
+
+func TestNuGetCooldown_RegistrationFailureKeepsVersions(t *testing.T) {
+	server := newTestNuGetFeed(t, nil, nil, true)
+
+	handler := newNuGetCooldownHandler(NewAnalysisStatsCollector())
+	ctx := makeTestRequestContext(server.URL + "/v3/flat2/other.lib/index.json")
+	resources := handler.FeedResources(ctx, server.URL+"/v3/index.json")
+
+	resp, err := handler.HandleMetadataRequest(ctx, resources, "other.lib", 5, "")
+	require.NoError(t, err)
+
+	body := []byte(`{"versions":["1.0.0"]}`)
+	_, _, modified, err := resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+	assert.Equal(t, body, modified)
+}
+
+func TestNuGetCooldown_UnresolvableFeedIsNotRetried(t *testing.T) {
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	handler := newNuGetCooldownHandler(NewAnalysisStatsCollector())
+	ctx := makeTestRequestContext(server.URL + "/flat2/contoso.lib/index.json")
+
+	for range 2 {
+		resources := handler.FeedResources(ctx, server.URL+"/index.json")
+		assert.False(t, resources.isFlatContainerIndex(ctx.URL, "contoso.lib"))
+	}
+	assert.Equal(t, 1, lookups)
+}
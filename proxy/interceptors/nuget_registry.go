@@ -0,0 +1,168 @@
+package interceptors
+
+import (
+	"net/http"
+	"strings"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+var nugetRegistryEndpoints = []registryEndpoint{
+	// Serves the service index, flat container and registration hives.
+	builtInRegistryEndpoint("api.nuget.org", true, nugetParser{}),
+}
+
+// NuGetRegistryInterceptor intercepts NuGet v3 feed requests from dotnet and
+// nuget. .nupkg downloads are analyzed for malware; the flat container
+// version list carries dependency cooldown.
+type NuGetRegistryInterceptor struct {
+	baseRegistryInterceptor
+	cooldownHandler *nugetCooldownHandler
+	registries      registrySet
+}
+
+var _ proxy.Interceptor = (*NuGetRegistryInterceptor)(nil)
+var _ proxy.MITMDecider = (*NuGetRegistryInterceptor)(nil)
+
+func newNuGetRegistryInterceptor(
+	analyzer analyzer.PackageVersionAnalyzer,
+	cache AnalysisCache,
+	statsCollector *AnalysisStatsCollector,
+	confirmationChan chan *ConfirmationRequest,
+	execContext InterceptorContext,
+	registries registrySet,
+) *NuGetRegistryInterceptor {
+	// Re-key pinned versions to the lowercase form the flat container uses,
+	// so lookups by URL-parsed package id match.
+	normalizedPinned := make(map[string]string, len(execContext.PinnedVersions))
+	for id, version := range execContext.PinnedVersions {
+		normalizedPinned[strings.ToLower(id)] = normalizeNuGetVersion(version)
+	}
+	execContext.PinnedVersions = normalizedPinned
+
+	return &NuGetRegistryInterceptor{
+		baseRegistryInterceptor: baseRegistryInterceptor{
+			analyzer:         analyzer,
+			cache:            cache,
+			statsCollector:   statsCollector,
+			confirmationChan: confirmationChan,
+			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-nuget"),
+			execContext:      execContext,
+		},
+		cooldownHandler: newNuGetCooldownHandler(statsCollector),
+		registries:      registries,
+	}
+}
+
+func (i *NuGetRegistryInterceptor) Name() string {
+	return "nuget-registry-interceptor"
+}
+
+func (i *NuGetRegistryInterceptor) ShouldMITM(ctx *proxy.RequestContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return registryHostSupportsAnalysis(i.registries, ctx.Hostname, ctx.Port)
+}
+
+func (i *NuGetRegistryInterceptor) ShouldIntercept(ctx *proxy.RequestContext) bool {
+	return registryRequestMatch(i.registries, ctx) != nil
+}
+
+// HandleRequest processes the request and returns response action.
+// We take a fail-open approach here, allowing requests that we can't parse the
+// package information from the URL.
+func (i *NuGetRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy.InterceptorResponse, error) {
+	log.Debugf("[%s] Handling NuGet registry request: %s", ctx.RequestID, ctx.URL.Path)
+
+	match := registryRequestMatch(i.registries, ctx)
+	if match == nil {
+		log.Warnf("[%s] No registry config found for hostname: %s", ctx.RequestID, ctx.Hostname)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if ctx.Method != http.MethodGet && ctx.Method != http.MethodHead {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	endpoint := match.Endpoint
+	if !endpoint.Analyze {
+		log.Debugf("[%s] Skipping analysis for %s registry (not supported for analysis): %s",
+			ctx.RequestID, endpoint.Host, ctx.URL.String())
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	pkgInfo, err := endpoint.Parser.ParseURL(match.RelativePath)
+	if err != nil {
+		logRegistryParseFailure(ctx, endpoint, "NuGet", err)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if packageInfoHasCompleteIdentity(pkgInfo) {
+		name, version := pkgInfo.GetName(), pkgInfo.GetVersion()
+		resp, err := i.handleArtifact(ctx, name, version)
+		if err != nil {
+			return resp, err
+		}
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_NUGET, endpoint.Name, name, version, resp), nil
+	}
+
+	if info, ok := pkgInfo.(*nugetPackageInfo); ok && info.isIndex {
+		return i.handleIndexRequest(ctx, endpoint, info.GetName())
+	}
+
+	return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+}
+
+// handleIndexRequest applies dependency cooldown to a flat container
+// index.json. Registration indexes share its shape and pass through.
+func (i *NuGetRegistryInterceptor) handleIndexRequest(ctx *proxy.RequestContext, endpoint *registryEndpoint, id string) (*proxy.InterceptorResponse, error) {
+	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
+	if !depCooldownConfig.Enabled || pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_NUGET, id) {
+		log.Debugf("[%s] Skipping analysis for index request: %s", ctx.RequestID, id)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	resources := i.feedResources(ctx, endpoint)
+	if !resources.isFlatContainerIndex(registryAbsoluteRequestURL(ctx), id) {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	return i.cooldownHandler.HandleMetadataRequest(ctx, resources, id,
+		depCooldownConfig.Days, i.execContext.PinnedVersions[id])
+}
+
+// feedResources returns the service index resources of the feed endpoint
+// serves. Custom feeds serve their service index at {base path}/index.json.
+func (i *NuGetRegistryInterceptor) feedResources(ctx *proxy.RequestContext, endpoint *registryEndpoint) *nugetFeedResources {
+	if endpoint.Source == registrySourceBuiltIn {
+		return nugetOrgFeedResources
+	}
+
+	requestURL := registryAbsoluteRequestURL(ctx)
+	if requestURL == nil {
+		return nil
+	}
+
+	serviceIndexURL := requestURL.Scheme + "://" + requestURL.Host + strings.TrimSuffix(endpoint.BasePath, "/") + "/index.json"
+	return i.cooldownHandler.FeedResources(ctx, serviceIndexURL)
+}
+
+// handleArtifact runs the trust, analysis, and verdict pipeline for a .nupkg
+// download.
+func (i *NuGetRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, id, version string) (*proxy.InterceptorResponse, error) {
+	if resp, ok := i.fastAllow(ctx, packagev1.Ecosystem_ECOSYSTEM_NUGET, id, version); ok {
+		return resp, nil
+	}
+
+	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_NUGET, id, version)
+	if err != nil {
+		return i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_NUGET, id, version, err), nil
+	}
+
+	return i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_NUGET, id, version, result)
+}
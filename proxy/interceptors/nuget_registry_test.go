@@ -0,0 +1,102 @@
+package interceptors
+
+import (
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNuGetInterceptor(a analyzer.PackageVersionAnalyzer, registries registrySet, execContext InterceptorContext) *NuGetRegistryInterceptor {
+	return newNuGetRegistryInterceptor(a, NewInMemoryAnalysisCache(), NewAnalysisStatsCollector(),
+		make(chan *ConfirmationRequest, 1), execContext, registries)
+}
+
+func TestNuGetRegistryInterceptor_ShouldIntercept(t *testing.T) {
+	interceptor := newTestNuGetInterceptor(nil, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_NUGET), InterceptorContext{})
+
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"https://api.nuget.org/v3/index.json", true},
+		{"https://api.nuget.org/v3-flatcontainer/newtonsoft.json/13.0.3/newtonsoft.json.13.0.3.nupkg", true},
+		{"https://registry.npmjs.org/newtonsoft", false},
+	}
+
+	for _, tc := range cases {
+		ctx := registryRequest(t, tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldIntercept(ctx), tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldMITM(ctx), tc.url)
+	}
+}
+
+func TestNuGetRegistryInterceptor_BlocksMaliciousPackage(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NUGET, Name: "evil.package"},
+			Version: "1.0.0",
+		},
+		Action:  analyzer.ActionBlock,
+		Summary: "Contains known malware",
+	}}
+	interceptor := newTestNuGetInterceptor(mock, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_NUGET), InterceptorContext{})
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://api.nuget.org/v3-flatcontainer/evil.package/1.0.0/evil.package.1.0.0.nupkg"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, mock.callCount)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+	require.NotNil(t, resp.BlockContext)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_NUGET, resp.BlockContext.Ecosystem)
+	assert.Equal(t, "evil.package", resp.BlockContext.PackageName)
+}
+
+func TestNuGetRegistryInterceptor_CooldownOnlyAppliesToFlatContainer(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: true, Days: 5})
+
+	interceptor := newTestNuGetInterceptor(&mockAnalyzer{}, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_NUGET), InterceptorContext{})
+
+	cases := []struct {
+		url  string
+		want proxy.ResponseAction
+	}{
+		{"https://api.nuget.org/v3-flatcontainer/newtonsoft.json/index.json", proxy.ActionModifyResponse},
+		{"https://api.nuget.org/v3/registration5-gz-semver2/newtonsoft.json/index.json", proxy.ActionAllow},
+		{"https://api.nuget.org/v3/index.json", proxy.ActionAllow},
+	}
+
+	for _, tc := range cases {
+		resp, err := interceptor.HandleRequest(makeTestRequestContext(tc.url))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, resp.Action, tc.url)
+	}
+}
+
+func TestNuGetRegistryInterceptor_NormalizesPinnedVersions(t *testing.T) {
+	interceptor := newTestNuGetInterceptor(nil, registrySet{}, InterceptorContext{
+		PinnedVersions: map[string]string{"Newtonsoft.Json": "13.0.4-Beta1"},
+	})
+
+	assert.Equal(t, "13.0.4-beta1", interceptor.execContext.PinnedVersions["newtonsoft.json"])
+}
+
+func TestInterceptorFactoryCreatesNuGetInterceptor(t *testing.T) {
+	assert.True(t, IsSupported(packagev1.Ecosystem_ECOSYSTEM_NUGET))
+
+	factory, err := NewInterceptorFactory(nil, nil, nil, nil, InterceptorContext{}, nil)
+	require.NoError(t, err)
+
+	got, err := factory.CreateInterceptor(packagev1.Ecosystem_ECOSYSTEM_NUGET)
+	require.NoError(t, err)
+	assert.Equal(t, "nuget-registry-interceptor", got.Name())
+}
@@ -0,0 +1,117 @@
+package interceptors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nugetPackageInfo is parsed package information from a NuGet v3 feed URL.
+// NuGet ids and versions are case-insensitive; both are kept lowercase, as
+// the flat container serves them.
+type nugetPackageInfo struct {
+	id         string
+	version    string
+	isDownload bool
+
+	// isIndex marks {base}/{id}/index.json. Flat container version lists and
+	// registration indexes share this shape; which resource it belongs to
+	// depends on the feed's service index.
+	isIndex bool
+}
+
+var _ packageInfo = (*nugetPackageInfo)(nil)
+
+func (n *nugetPackageInfo) GetName() string { return n.id }
+
+func (n *nugetPackageInfo) GetVersion() string { return n.version }
+
+func (n *nugetPackageInfo) IsFileDownload() bool { return n.isDownload }
+
+// nugetParser parses NuGet v3 protocol URLs. Resource base paths differ
+// between feeds (api.nuget.org serves /v3-flatcontainer, Azure Artifacts
+// /flat2), so only the package-specific tail of the path is parsed:
+//
+//	{base}/{id}/{version}/{id}.{version}.nupkg  -> package download
+//	{base}/{id}/{version}/{id}.nuspec           -> package manifest
+//	{base}/{id}/index.json                      -> flat container version list or registration index
+//	{base}/{id}/page/{lower}/{upper}.json       -> registration page
+//	{base}/{id}/{version}.json                  -> registration leaf
+//
+// The service index (index.json), search and catalog requests carry no
+// package and pass through.
+type nugetParser struct{}
+
+var _ registryURLParser = nugetParser{}
+
+func (p nugetParser) ParseURL(urlPath string) (packageInfo, error) {
+	urlPath = strings.Trim(urlPath, "/")
+	if urlPath == "" {
+		return nil, fmt.Errorf("empty NuGet URL path")
+	}
+
+	segments := strings.Split(strings.ToLower(urlPath), "/")
+	n := len(segments)
+	filename := segments[n-1]
+
+	switch {
+	case strings.HasSuffix(filename, ".nupkg") && n >= 3:
+		id, version := segments[n-3], segments[n-2]
+		if filename != id+"."+version+".nupkg" {
+			return nil, fmt.Errorf("NuGet package file %q does not match %s %s", filename, id, version)
+		}
+		if !isValidNuGetID(id) || !isValidNuGetVersion(version) {
+			return nil, fmt.Errorf("invalid NuGet package in URL: %q", urlPath)
+		}
+		return &nugetPackageInfo{id: id, version: version, isDownload: true}, nil
+
+	case strings.HasSuffix(filename, ".nuspec") && n >= 3:
+		return &nugetPackageInfo{id: segments[n-3], version: segments[n-2]}, nil
+
+	case filename == "index.json" && n >= 2 && isValidNuGetID(segments[n-2]):
+		// The service index at /v3/index.json parses as the index of a
+		// package named "v3" too. The interceptor only acts on indexes under
+		// the feed's flat container, which tells the two apart.
+		return &nugetPackageInfo{id: segments[n-2], isIndex: true}, nil
+
+	case n >= 4 && segments[n-3] == "page" && strings.HasSuffix(filename, ".json"):
+		return &nugetPackageInfo{id: segments[n-4]}, nil
+	}
+
+	return &nugetPackageInfo{}, nil
+}
+
+// isValidNuGetID reports whether id is a plausible NuGet package id:
+// letters, digits, '.', '-' and '_', not starting with a dot.
+func isValidNuGetID(id string) bool {
+	if id == "" || strings.HasPrefix(id, ".") {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isValidNuGetVersion reports whether version looks like a normalized NuGet
+// version: it starts with a digit and holds only SemVer characters.
+func isValidNuGetVersion(version string) bool {
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		return false
+	}
+
+	for _, r := range version {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '+':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
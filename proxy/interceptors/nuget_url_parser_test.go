@@ -0,0 +1,78 @@
+package interceptors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNuGetParser(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		id         string
+		version    string
+		isDownload bool
+		isIndex    bool
+		wantErr    bool
+	}{
+		{
+			name:       "nuget.org package download",
+			path:       "/v3-flatcontainer/newtonsoft.json/13.0.3/newtonsoft.json.13.0.3.nupkg",
+			id:         "newtonsoft.json",
+			version:    "13.0.3",
+			isDownload: true,
+		},
+		{
+			name:       "prerelease download is lowercased",
+			path:       "/flat2/Contoso.Lib/2.0.0-Beta.1/Contoso.Lib.2.0.0-Beta.1.nupkg",
+			id:         "contoso.lib",
+			version:    "2.0.0-beta.1",
+			isDownload: true,
+		},
+		{
+			name:    "nuspec is not a download",
+			path:    "/v3-flatcontainer/newtonsoft.json/13.0.3/newtonsoft.json.nuspec",
+			id:      "newtonsoft.json",
+			version: "13.0.3",
+		},
+		{
+			name:    "flat container version list",
+			path:    "/v3-flatcontainer/newtonsoft.json/index.json",
+			id:      "newtonsoft.json",
+			isIndex: true,
+		},
+		{
+			name:    "registration index",
+			path:    "/v3/registration5-gz-semver2/newtonsoft.json/index.json",
+			id:      "newtonsoft.json",
+			isIndex: true,
+		},
+		{
+			name: "registration page",
+			path: "/v3/registration5-gz-semver2/newtonsoft.json/page/3.5.8/13.0.3.json",
+			id:   "newtonsoft.json",
+		},
+		{name: "search", path: "/query"},
+		{name: "catalog", path: "/v3/catalog0/index.json/extra"},
+		{name: "download name mismatch", path: "/v3-flatcontainer/newtonsoft.json/13.0.3/evil.13.0.3.nupkg", wantErr: true},
+		{name: "download version mismatch", path: "/v3-flatcontainer/newtonsoft.json/13.0.3/newtonsoft.json.13.0.4.nupkg", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := nugetParser{}.ParseURL(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.id, info.GetName())
+			assert.Equal(t, tc.version, info.GetVersion())
+			assert.Equal(t, tc.isDownload, info.IsFileDownload())
+			assert.Equal(t, tc.isIndex, info.(*nugetPackageInfo).isIndex)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package interceptors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	gomodule "golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// pypiSimpleAPIBaseURL is where the release history of a package from the
+// public PyPI index is read. pip may request the HTML Simple API, which
+// carries no upload times, so the PEP 691 JSON document is fetched on the
+// side.
+var pypiSimpleAPIBaseURL = "https://pypi.org/simple"
+
+// maxReleaseHistorySize bounds a fetched release history.
+const maxReleaseHistorySize = 32 << 20
+
+// packageAgeHandler resolves when a package was first released, for the
+// package age policy. npm first releases come from the packuments the
+// interceptor already fetches where it can; other release histories are
+// fetched once per package.
+type packageAgeHandler struct {
+	mu            sync.Mutex
+	firstReleases map[string]time.Time
+}
+
+var _ analyzer.NpmPackumentObserver = (*packageAgeHandler)(nil)
+
+func newPackageAgeHandler() *packageAgeHandler {
+	return &packageAgeHandler{firstReleases: map[string]time.Time{}}
+}
+
+// ObserveNpmPackument records the earliest entry of the packument's time map,
+// including "created".
+func (h *packageAgeHandler) ObserveNpmPackument(name string, packument []byte) {
+	var metadata struct {
+		Time map[string]string `json:"time"`
+	}
+	if err := json.Unmarshal(packument, &metadata); err != nil {
+		log.Warnf("Package age: failed to read the release history of %s: %v", name, err)
+		return
+	}
+
+	var first time.Time
+	for key, value := range metadata.Time {
+		if key == "modified" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+	}
+
+	if !first.IsZero() {
+		h.remember("npm:"+name, first)
+	}
+}
+
+// NpmFirstRelease returns when an npm package was first released. A package
+// whose packument was not seen, such as one npm resolved from its own cache,
+// has its packument fetched from baseURL, the registry the artifact is
+// downloaded from.
+func (h *packageAgeHandler) NpmFirstRelease(baseURL, name string) (time.Time, error) {
+	key := "npm:" + name
+	if first, ok := h.lookup(key); ok || baseURL == "" {
+		return first, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(name))
+	body, err := fetchUpstream(endpoint, "application/json", maxReleaseHistorySize)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	h.ObserveNpmPackument(name, body)
+	first, _ := h.lookup(key)
+	return first, nil
+}
+
+// PyPIFirstRelease fetches the release history of a package from the PyPI
+// Simple API and returns its earliest upload time.
+func (h *packageAgeHandler) PyPIFirstRelease(name string) (time.Time, error) {
+	key := "pypi:" + name
+	if first, ok := h.lookup(key); ok {
+		return first, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/", strings.TrimSuffix(pypiSimpleAPIBaseURL, "/"), url.PathEscape(name))
+	body, err := fetchUpstream(endpoint, pypiSimpleAPIContentType, maxReleaseHistorySize)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	dates, err := new(pypiCooldownHandler).parsePEP691Files(body)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	_, first := cooldownOldestVersion(dates)
+	if !first.IsZero() {
+		h.remember(key, first)
+	}
+	return first, nil
+}
+
+// GoFirstRelease returns when a Go module was first released: the publish
+// time of the lowest version in its @v/list on baseURL, the module proxy go
+// downloads it from. A module with no tagged version is dated by the
+// requested pseudo-version, its only known release.
+func (h *packageAgeHandler) GoFirstRelease(baseURL, module, version string) (time.Time, error) {
+	if baseURL == "" {
+		return time.Time{}, nil
+	}
+
+	key := "go:" + module
+	if first, ok := h.lookup(key); ok {
+		return first, nil
+	}
+
+	escapedPath, err := gomodule.EscapePath(module)
+	if err != nil {
+		return time.Time{}, err
+	}
+	base := fmt.Sprintf("%s/%s/@v/", strings.TrimSuffix(baseURL, "/"), escapedPath)
+
+	list, err := fetchUpstream(base+"list", "", maxReleaseHistorySize)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var versions []string
+	for _, v := range strings.Fields(string(list)) {
+		if semver.IsValid(v) {
+			versions = append(versions, v)
+		}
+	}
+	semver.Sort(versions)
+
+	oldest := version
+	tagged := len(versions) > 0
+	if tagged {
+		oldest = versions[0]
+	}
+
+	escapedVersion, err := gomodule.EscapeVersion(oldest)
+	if err != nil {
+		return time.Time{}, err
+	}
+	body, err := fetchUpstream(base+escapedVersion+".info", "", 1<<20)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var info struct {
+		Time time.Time `json:"Time"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse %s.info: %w", oldest, err)
+	}
+
+	// An untagged module gains releases with every commit, so only a tagged
+	// first release is remembered.
+	if tagged && !info.Time.IsZero() {
+		h.remember(key, info.Time)
+	}
+	return info.Time, nil
+}
+
+func (h *packageAgeHandler) lookup(key string) (time.Time, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	first, ok := h.firstReleases[key]
+	return first, ok
+}
+
+func (h *packageAgeHandler) remember(key string, first time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.firstReleases[key] = first
+}
+
+// checkPackageAge applies package_age to an artifact download. resolve is
+// only called when the policy is enabled. A first release it cannot date
+// fails open: malware analysis still runs. It returns (response, true) when
+// the policy blocked the download.
+func (b *baseRegistryInterceptor) checkPackageAge(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	resolve func() (time.Time, error),
+) (*proxy.InterceptorResponse, bool) {
+	cfg := config.Get().Config.PackageAge
+	if !cfg.Enabled {
+		return nil, false
+	}
+
+	firstRelease, err := resolve()
+	if err != nil {
+		log.Warnf("[%s] Package age: failed to read the first release of %s/%s: %v; package age not enforced for this download",
+			ctx.RequestID, ecosystem.String(), packageName, err)
+		return nil, false
+	}
+	if firstRelease.IsZero() {
+		log.Debugf("[%s] Package age: no first release known for %s/%s", ctx.RequestID, ecosystem.String(), packageName)
+		return nil, false
+	}
+
+	within, daysAgo, daysLeft := cooldownIsWithinWindow(firstRelease, cfg.MinDays)
+	if !within {
+		return nil, false
+	}
+
+	pkgVersion := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: ecosystem, Name: packageName},
+		Version: packageVersion,
+	}
+	summary := fmt.Sprintf("Package first released %d day(s) ago; package_age requires %d day(s)", daysAgo, cfg.MinDays)
+	logPolicy := func(decision string) {
+		audit.LogPackageAge(pkgVersion, firstRelease, cfg.MinDays, daysAgo, decision)
+	}
+
+	switch b.applyPolicyAction(ctx, "Package age", pkgVersion, cfg.ActionName(), summary) {
+	case policyAllowed:
+		logPolicy(audit.PackageAgeAllowed)
+		return nil, false
+	case policyConfirmed:
+		logPolicy(audit.PackageAgeConfirmed)
+		return nil, false
+	}
+
+	logPolicy(audit.PackageAgeBlocked)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordPackageAgeBlocked(models.PackageAgeBlock{
+			Name:         packageName,
+			Version:      packageVersion,
+			FirstRelease: firstRelease,
+			DaysAgo:      daysAgo,
+			MinDays:      cfg.MinDays,
+		})
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:      proxy.ActionBlock,
+		BlockCode:   http.StatusForbidden,
+		BlockReason: proxy.BlockReasonPackageAge,
+		BlockContext: &proxy.BlockContext{
+			Ecosystem:        ecosystem,
+			PackageName:      packageName,
+			PackageVersion:   packageVersion,
+			CooldownDays:     cfg.MinDays,
+			CooldownDaysAgo:  daysAgo,
+			CooldownDaysLeft: daysLeft,
+		},
+	}, true
+}
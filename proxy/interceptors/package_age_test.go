@@ -0,0 +1,191 @@
+package interceptors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setPackageAgeConfig(t *testing.T, cfg pmgconfig.PackageAgeConfig) {
+	t.Helper()
+	orig := pmgconfig.Get().Config.PackageAge
+	t.Cleanup(func() { pmgconfig.Get().Config.PackageAge = orig })
+	pmgconfig.Get().Config.PackageAge = cfg
+}
+
+func releasedDaysAgo(days int) func() (time.Time, error) {
+	return func() (time.Time, error) {
+		return time.Now().Add(-time.Duration(days) * 24 * time.Hour), nil
+	}
+}
+
+func TestCheckPackageAge(t *testing.T) {
+	setPackageAgeConfig(t, pmgconfig.PackageAgeConfig{
+		Enabled: true,
+		MinDays: 14,
+		Action:  pmgconfig.HeuristicActionBlock,
+	})
+
+	base := &baseRegistryInterceptor{statsCollector: NewAnalysisStatsCollector()}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/expresss/-/expresss-0.0.1.tgz")
+
+	response, blocked := base.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "expresss", "0.0.1", releasedDaysAgo(3))
+	require.True(t, blocked)
+	assert.Equal(t, proxy.ActionBlock, response.Action)
+	assert.Equal(t, http.StatusForbidden, response.BlockCode)
+	assert.Equal(t, proxy.BlockReasonPackageAge, response.BlockReason)
+	require.NotNil(t, response.BlockContext)
+	assert.Equal(t, 14, response.BlockContext.CooldownDays)
+	assert.Equal(t, 3, response.BlockContext.CooldownDaysAgo)
+	assert.Equal(t, 11, response.BlockContext.CooldownDaysLeft)
+
+	_, blocked = base.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "express", "5.0.0", releasedDaysAgo(5000))
+	assert.False(t, blocked)
+
+	// A first release that cannot be dated fails open.
+	_, blocked = base.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "unseen", "1.0.0", func() (time.Time, error) {
+		return time.Time{}, nil
+	})
+	assert.False(t, blocked)
+
+	stats := base.statsCollector.GetStats()
+	assert.Equal(t, 1, stats.BlockedCount)
+	assert.Equal(t, 1, stats.PackageAgeBlockedCount)
+	blocks := base.statsCollector.GetPackageAgeBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "expresss", blocks[0].Name)
+	assert.Equal(t, 3, blocks[0].DaysAgo)
+	assert.Equal(t, 14, blocks[0].MinDays)
+}
+
+func TestCheckPackageAge_ConfirmAndDisabled(t *testing.T) {
+	setPackageAgeConfig(t, pmgconfig.PackageAgeConfig{Enabled: true, MinDays: 14})
+
+	for _, confirms := range []bool{true, false} {
+		confirmationChan := make(chan *ConfirmationRequest, 1)
+		base := &baseRegistryInterceptor{confirmationChan: confirmationChan}
+		ctx := makeTestRequestContext("https://registry.npmjs.org/fresh/-/fresh-1.0.0.tgz")
+
+		go func() {
+			req := <-confirmationChan
+			req.ResponseChan <- confirms
+			close(req.ResponseChan)
+		}()
+
+		_, blocked := base.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "fresh", "1.0.0", releasedDaysAgo(1))
+		assert.Equal(t, !confirms, blocked)
+	}
+
+	setPackageAgeConfig(t, pmgconfig.PackageAgeConfig{Enabled: false, MinDays: 14})
+	base := &baseRegistryInterceptor{}
+	ctx := makeTestRequestContext("https://registry.npmjs.org/fresh/-/fresh-1.0.0.tgz")
+	_, blocked := base.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "fresh", "1.0.0", func() (time.Time, error) {
+		t.Fatal("resolve called while package_age is disabled")
+		return time.Time{}, nil
+	})
+	assert.False(t, blocked)
+}
+
+func TestPackageAgeHandler_Npm(t *testing.T) {
+	h := newPackageAgeHandler()
+	h.ObserveNpmPackument("lib", []byte(`{"time": {
+		"modified": "2001-01-01T00:00:00.000Z",
+		"created": "2020-03-01T10:00:00.000Z",
+		"1.0.0": "2020-03-01T10:00:05.000Z",
+		"2.0.0": "2024-06-01T00:00:00.000Z"
+	}}`))
+
+	first, err := h.NpmFirstRelease("", "lib")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC), first.UTC())
+
+	first, err = h.NpmFirstRelease("", "other")
+	require.NoError(t, err)
+	assert.True(t, first.IsZero())
+}
+
+func TestPackageAgeHandler_NpmFetchesUnseenPackument(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.EscapedPath() != "/@scope%2Fnew" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"time": {"created": "2026-01-02T00:00:00.000Z", "1.0.0": "2026-01-02T00:00:01.000Z"}}`))
+	}))
+	defer server.Close()
+
+	h := newPackageAgeHandler()
+	for range 2 {
+		first, err := h.NpmFirstRelease(server.URL, "@scope/new")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), first.UTC())
+	}
+	assert.Equal(t, int32(1), hits.Load())
+
+	_, err := h.NpmFirstRelease(server.URL, "missing")
+	assert.Error(t, err)
+}
+
+func TestPackageAgeHandler_PyPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simple/requests/" || r.Header.Get("Accept") != pypiSimpleAPIContentType {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", pypiSimpleAPIContentType)
+		_, _ = w.Write([]byte(`{"files": [
+			{"filename": "requests-2.0.0.tar.gz", "upload-time": "2013-09-24T18:00:00.000000Z"},
+			{"filename": "requests-0.2.0.tar.gz", "upload-time": "2011-02-14T12:00:00.000000Z"},
+			{"filename": "requests-2.0.0-py3-none-any.whl"}
+		]}`))
+	}))
+	defer server.Close()
+
+	orig := pypiSimpleAPIBaseURL
+	pypiSimpleAPIBaseURL = server.URL + "/simple"
+	t.Cleanup(func() { pypiSimpleAPIBaseURL = orig })
+
+	h := newPackageAgeHandler()
+	first, err := h.PyPIFirstRelease("requests")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2011, 2, 14, 12, 0, 0, 0, time.UTC), first.UTC())
+
+	_, err = h.PyPIFirstRelease("missing")
+	assert.Error(t, err)
+}
+
+func TestPackageAgeHandler_Go(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/github.com/!example/lib/@v/list":
+			_, _ = w.Write([]byte("v1.10.0\nv1.2.0\nv0.9.0\n"))
+		case "/github.com/!example/lib/@v/v0.9.0.info":
+			_, _ = w.Write([]byte(`{"Version": "v0.9.0", "Time": "2019-05-01T00:00:00Z"}`))
+		case "/example.com/untagged/@v/list":
+		case "/example.com/untagged/@v/v0.0.0-20240101000000-abcdefabcdef.info":
+			_, _ = w.Write([]byte(`{"Version": "v0.0.0-20240101000000-abcdefabcdef", "Time": "2024-01-01T00:00:00Z"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	h := newPackageAgeHandler()
+	first, err := h.GoFirstRelease(server.URL, "github.com/Example/lib", "v1.10.0")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), first.UTC())
+
+	first, err = h.GoFirstRelease(server.URL, "example.com/untagged", "v0.0.0-20240101000000-abcdefabcdef")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), first.UTC())
+}
@@ -0,0 +1,95 @@
+package interceptors
+
+import (
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+)
+
+// publicGoProxyHost is the public Go module proxy. Go private namespaces
+// keep their modules off it; other GOPROXY entries are the user's own.
+const publicGoProxyHost = "proxy.golang.org"
+
+// checkPrivateNamespace refuses a request for a package of a private
+// namespace to registry, when the namespace does not name it. registry is
+// the endpoint's catalog name: the proxy.registries name of a custom
+// registry, the host of a built-in one.
+//
+// An artifact download is blocked. A metadata request is answered 404 so
+// the resolver moves on to its next index (pip --extra-index-url, the next
+// GOPROXY entry) and the registry never gets to describe, and so shadow, a
+// private name. Refusing metadata is not an install block: pip asks every
+// index for every name. It returns (response, true) when the request was
+// refused.
+func (b *baseRegistryInterceptor) checkPrivateNamespace(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	policyEcosystem string,
+	registry string,
+	packageName string,
+	packageVersion string,
+	download bool,
+) (*proxy.InterceptorResponse, bool) {
+	if config.Get().InsecureInstallation {
+		return nil, false
+	}
+
+	namespace, ok := config.PrivateNamespaceFor(config.Get().Config.PrivateNamespaces, policyEcosystem, packageName)
+	if !ok || namespace.AllowsRegistry(registry) {
+		return nil, false
+	}
+
+	if !download {
+		packageVersion = ""
+	}
+	pkgVersion := &packagev1.PackageVersion{
+		Package: &packagev1.Package{Ecosystem: ecosystem, Name: packageName},
+		Version: packageVersion,
+	}
+	blockContext := &proxy.BlockContext{
+		Ecosystem:        ecosystem,
+		PackageName:      packageName,
+		PackageVersion:   packageVersion,
+		Registry:         registry,
+		PrivateNamespace: namespace.Prefix,
+	}
+
+	if !download {
+		log.Infof("[%s] Refusing metadata for %s/%s from %s: private namespace %s",
+			ctx.RequestID, ecosystem.String(), packageName, registry, namespace.Prefix)
+		audit.LogDependencyConfusion(pkgVersion, namespace.Prefix, registry, audit.DependencyConfusionRefused)
+
+		return &proxy.InterceptorResponse{
+			Action:       proxy.ActionBlock,
+			BlockCode:    http.StatusNotFound,
+			BlockReason:  proxy.BlockReasonDependencyConfusion,
+			BlockContext: blockContext,
+		}, true
+	}
+
+	log.Warnf("[%s] Blocking %s/%s@%s from %s: private namespace %s",
+		ctx.RequestID, ecosystem.String(), packageName, packageVersion, registry, namespace.Prefix)
+	audit.LogDependencyConfusion(pkgVersion, namespace.Prefix, registry, audit.DependencyConfusionBlocked)
+
+	if b.statsCollector != nil {
+		b.statsCollector.RecordDependencyConfusionBlocked(models.DependencyConfusionBlock{
+			Ecosystem: ecosystem.String(),
+			Name:      packageName,
+			Version:   packageVersion,
+			Namespace: namespace.Prefix,
+			Registry:  registry,
+		})
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:       proxy.ActionBlock,
+		BlockCode:    http.StatusForbidden,
+		BlockReason:  proxy.BlockReasonDependencyConfusion,
+		BlockContext: blockContext,
+	}, true
+}
@@ -0,0 +1,104 @@
+package interceptors
+
+import (
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setPrivateNamespaces(t *testing.T, namespaces ...config.PrivateNamespace) {
+	t.Helper()
+	orig := config.Get().Config.PrivateNamespaces
+	t.Cleanup(func() { config.Get().Config.PrivateNamespaces = orig })
+	config.Get().Config.PrivateNamespaces = namespaces
+}
+
+func TestPrivateNamespace_Npm(t *testing.T) {
+	setPrivateNamespaces(t, config.PrivateNamespace{Ecosystem: "npm", Prefix: "@acme", Registries: []string{"custom-npm"}})
+
+	interceptor := newTestDefaultNpmInterceptor(t)
+
+	// Public metadata for a private name is refused, so it cannot shadow
+	// the private package.
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://registry.npmjs.org/@acme/widgets"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusNotFound, resp.BlockCode)
+	assert.Equal(t, proxy.BlockReasonDependencyConfusion, resp.BlockReason)
+	assert.Equal(t, "@acme", resp.BlockContext.PrivateNamespace)
+	assert.Zero(t, interceptor.statsCollector.GetStats().BlockedCount)
+
+	resp, err = interceptor.HandleRequest(makeTestRequestContext("https://registry.npmjs.org/@acme/widgets/-/widgets-1.0.0.tgz"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+	assert.Equal(t, "registry.npmjs.org", resp.BlockContext.Registry)
+	assert.Equal(t, []models.DependencyConfusionBlock{{
+		Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NPM.String(),
+		Name:      "@acme/widgets",
+		Version:   "1.0.0",
+		Namespace: "@acme",
+		Registry:  "registry.npmjs.org",
+	}}, interceptor.statsCollector.GetDependencyConfusionBlocks())
+	assert.Equal(t, 1, interceptor.statsCollector.GetStats().BlockedCount)
+
+	// The registries the namespace names serve it, and other names are
+	// not the policy's concern.
+	ctx := makeTestRequestContext("https://packages.test/npm/@acme/widgets")
+	_, refused := interceptor.checkPrivateNamespace(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, config.PrivateNamespaceEcosystemNpm,
+		"custom-npm", "@acme/widgets", "", false)
+	assert.False(t, refused)
+	_, refused = interceptor.checkPrivateNamespace(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, config.PrivateNamespaceEcosystemNpm,
+		"registry.npmjs.org", "@acme-corp/widgets", "", false)
+	assert.False(t, refused)
+}
+
+func TestPrivateNamespace_PyPI(t *testing.T) {
+	setPrivateNamespaces(t, config.PrivateNamespace{Ecosystem: "pypi", Prefix: "acme-", Registries: []string{"custom-pypi"}})
+
+	interceptor := newTestDefaultPypiInterceptor(t)
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://pypi.org/simple/acme-widgets/"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusNotFound, resp.BlockCode)
+
+	resp, err = interceptor.HandleRequest(makeTestRequestContext("https://files.pythonhosted.org/packages/ab/cd/acme_widgets-1.0.0-py3-none-any.whl"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+	assert.Equal(t, "files.pythonhosted.org", resp.BlockContext.Registry)
+	require.Len(t, interceptor.statsCollector.GetDependencyConfusionBlocks(), 1)
+}
+
+func TestPrivateNamespace_Go(t *testing.T) {
+	setPrivateNamespaces(t, config.PrivateNamespace{Ecosystem: "go", Prefix: "github.com/acme-corp"})
+
+	interceptor := NewGoRegistryInterceptor(nil, nil, NewAnalysisStatsCollector(), nil, InterceptorContext{
+		GoProxyBaseURLs: map[string]string{
+			"proxy.golang.org": "https://proxy.golang.org",
+			"corp.example.com": "https://corp.example.com/goproxy",
+		},
+	})
+
+	// A 404 sends go on to the next GOPROXY entry.
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://proxy.golang.org/github.com/acme-corp/widgets/@v/list"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusNotFound, resp.BlockCode)
+
+	resp, err = interceptor.HandleRequest(makeTestRequestContext("https://proxy.golang.org/github.com/acme-corp/widgets/@v/v1.0.0.zip"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+
+	resp, err = interceptor.HandleRequest(makeTestRequestContext("https://corp.example.com/goproxy/github.com/acme-corp/widgets/@v/list"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+}
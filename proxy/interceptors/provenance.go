@@ -0,0 +1,233 @@
+package interceptors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/internal/provenance"
+	"github.com/safedep/pmg/proxy"
+)
+
+// npmAttestationsBaseURL and pypiIntegrityBaseURL are where the provenance
+// of npm and PyPI package versions is fetched. Package managers do not
+// request it themselves, so it is fetched on the side, like license sources.
+var (
+	npmAttestationsBaseURL = "https://registry.npmjs.org/-/npm/v1/attestations"
+	pypiIntegrityBaseURL   = "https://pypi.org/integrity"
+)
+
+// maxProvenanceSize bounds a fetched attestation document.
+const maxProvenanceSize = 4 << 20
+
+// provenanceRoots holds the Sigstore trusted root shared by every registry
+// interceptor.
+var provenanceRoots = &provenanceRootLoader{}
+
+// provenanceRootLoader loads the trusted root once per configured path.
+type provenanceRootLoader struct {
+	mu   sync.Mutex
+	path string
+	root *provenance.TrustRoot
+}
+
+// TrustRoot returns the trusted root at path, or the built-in one when path
+// is empty.
+func (l *provenanceRootLoader) TrustRoot(path string) (*provenance.TrustRoot, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.root != nil && l.path == path {
+		return l.root, nil
+	}
+
+	root, err := provenance.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	l.path = path
+	l.root = root
+	return root, nil
+}
+
+// provenanceSource is where and how the provenance of one package version
+// is read.
+type provenanceSource struct {
+	ecosystem packagev1.Ecosystem
+
+	// policyEcosystem is the ecosystem name provenance.packages uses.
+	policyEcosystem string
+
+	endpoint string
+	verify   func(root *provenance.TrustRoot, doc []byte) (*provenance.Result, error)
+
+	// subjects are the names the attestation may give the artifact.
+	subjects []string
+}
+
+// npmProvenanceInspector returns the inspector that requires provenance for
+// an npm package version, or nil when provenance.packages does not select
+// it.
+func (b *baseRegistryInterceptor) npmProvenanceInspector(ctx *proxy.RequestContext, packageName, packageVersion string) *artifactInspector {
+	return b.provenanceInspector(ctx, packageName, packageVersion, provenanceSource{
+		ecosystem:       packagev1.Ecosystem_ECOSYSTEM_NPM,
+		policyEcosystem: config.ProvenanceEcosystemNpm,
+		endpoint: fmt.Sprintf("%s/%s@%s", strings.TrimSuffix(npmAttestationsBaseURL, "/"),
+			strings.ReplaceAll(packageName, "/", "%2f"), url.PathEscape(packageVersion)),
+		verify: func(root *provenance.TrustRoot, doc []byte) (*provenance.Result, error) {
+			return root.VerifyNpm(doc)
+		},
+		subjects: provenance.NpmSubjectNames(packageName, packageVersion),
+	})
+}
+
+// pypiProvenanceInspector returns the inspector that requires PEP 740
+// provenance for a PyPI distribution file, or nil when provenance.packages
+// does not select its project.
+func (b *baseRegistryInterceptor) pypiProvenanceInspector(ctx *proxy.RequestContext, packageName, packageVersion, filename string) *artifactInspector {
+	return b.provenanceInspector(ctx, packageName, packageVersion, provenanceSource{
+		ecosystem:       packagev1.Ecosystem_ECOSYSTEM_PYPI,
+		policyEcosystem: config.ProvenanceEcosystemPyPI,
+		endpoint: fmt.Sprintf("%s/%s/%s/%s/provenance", strings.TrimSuffix(pypiIntegrityBaseURL, "/"),
+			url.PathEscape(packageName), url.PathEscape(packageVersion), url.PathEscape(filename)),
+		verify: func(root *provenance.TrustRoot, doc []byte) (*provenance.Result, error) {
+			return root.VerifyPyPI(doc)
+		},
+		subjects: []string{filename},
+	})
+}
+
+// provenanceInspector returns the inspector that blocks an artifact unless
+// a provenance attestation from source verifies, covers the artifact's
+// bytes and names the expected source repository. A response that is not
+// the whole, unencoded artifact cannot be covered and is blocked.
+func (b *baseRegistryInterceptor) provenanceInspector(
+	ctx *proxy.RequestContext,
+	packageName string,
+	packageVersion string,
+	source provenanceSource,
+) *artifactInspector {
+	cfg := config.Get().Config.Provenance
+	required, ok := cfg.Match(source.policyEcosystem, packageName)
+	if !ok {
+		return nil
+	}
+
+	block := func(reason, repository string) *proxy.ResponseBlock {
+		pkgVersion := &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: source.ecosystem, Name: packageName},
+			Version: packageVersion,
+		}
+		log.Warnf("[%s] Blocking %s/%s@%s: %s", ctx.RequestID, source.ecosystem.String(), packageName, packageVersion, reason)
+		audit.LogProvenance(pkgVersion, reason, repository, required.Repository)
+
+		if b.statsCollector != nil {
+			b.statsCollector.RecordProvenanceBlocked(models.ProvenanceBlock{
+				Ecosystem:  source.ecosystem.String(),
+				Name:       packageName,
+				Version:    packageVersion,
+				Repository: repository,
+				Reason:     reason,
+			})
+		}
+
+		return &proxy.ResponseBlock{
+			Code:   http.StatusForbidden,
+			Reason: proxy.BlockReasonProvenance,
+			Context: &proxy.BlockContext{
+				Ecosystem:       source.ecosystem,
+				PackageName:     packageName,
+				PackageVersion:  packageVersion,
+				ProvenanceError: reason,
+			},
+		}
+	}
+
+	return &artifactInspector{
+		check: func(artifact []byte) *proxy.ResponseBlock {
+			repository, err := checkProvenance(cfg.TrustedRoot, source, required, artifact)
+			if err == nil {
+				log.Debugf("[%s] Provenance: %s/%s@%s verified, built from %s",
+					ctx.RequestID, source.ecosystem.String(), packageName, packageVersion, repository)
+				return nil
+			}
+			return block(err.Error(), repository)
+		},
+		unreadable: func(reason string) *proxy.ResponseBlock {
+			return block("the artifact could not be checked, the response is "+reason, "")
+		},
+	}
+}
+
+// checkProvenance fetches and verifies the provenance of an artifact and
+// returns the source repository it names. Any failure, including one to
+// fetch the attestation, is an error: the package requires provenance.
+func checkProvenance(trustedRoot string, source provenanceSource, required config.ProvenancePackage, artifact []byte) (string, error) {
+	root, err := provenanceRoots.TrustRoot(trustedRoot)
+	if err != nil {
+		return "", fmt.Errorf("trusted root could not be loaded: %w", err)
+	}
+
+	doc, err := fetchProvenance(source.endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := source.verify(root, doc)
+	if err != nil {
+		return "", err
+	}
+	if !result.Covers(artifact, source.subjects...) {
+		return result.SourceRepository, errors.New("provenance attestation does not cover this artifact")
+	}
+	if !required.RepositoryMatches(result.SourceRepository) {
+		built := result.SourceRepository
+		if built == "" {
+			built = "an unnamed repository"
+		}
+		return result.SourceRepository, fmt.Errorf("built from %s, expected %s", built, required.Repository)
+	}
+	return result.SourceRepository, nil
+}
+
+// fetchProvenance GETs an attestation document. A 404 means the version
+// was published without provenance.
+func fetchProvenance(endpoint string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := upstreamFetchClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("provenance could not be fetched: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, provenance.ErrNoProvenance
+	default:
+		return nil, fmt.Errorf("provenance could not be fetched: HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProvenanceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("provenance could not be fetched: %w", err)
+	}
+	if len(body) > maxProvenanceSize {
+		return nil, fmt.Errorf("provenance document is larger than %d bytes", maxProvenanceSize)
+	}
+	return body, nil
+}
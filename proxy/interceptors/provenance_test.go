@@ -0,0 +1,125 @@
+package interceptors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/internal/provenance"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setProvenanceConfig(t *testing.T, cfg config.ProvenanceConfig) {
+	t.Helper()
+
+	orig := config.Get().Config.Provenance
+	t.Cleanup(func() { config.Get().Config.Provenance = orig })
+	config.Get().Config.Provenance = cfg
+}
+
+// newTestAttestationServer serves documents by request path, and 404 for
+// any other path.
+func newTestAttestationServer(t *testing.T, documents map[string][]byte) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := documents[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(doc)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProvenanceInspector_Npm(t *testing.T) {
+	bundle, err := os.ReadFile("../../internal/provenance/testdata/sigstore-2.0.0.sigstore.json")
+	require.NoError(t, err)
+
+	server := newTestAttestationServer(t, map[string][]byte{
+		"/attestations/sigstore@2.0.0": []byte(`{"attestations":[{"predicateType":"https://slsa.dev/provenance/v1","bundle":` + string(bundle) + `}]}`),
+	})
+	orig := npmAttestationsBaseURL
+	npmAttestationsBaseURL = server.URL + "/attestations"
+	t.Cleanup(func() { npmAttestationsBaseURL = orig })
+
+	setProvenanceConfig(t, config.ProvenanceConfig{Packages: []config.ProvenancePackage{
+		{Ecosystem: "npm", Name: "sigstore", Repository: "https://github.com/sigstore/*"},
+		{Ecosystem: "npm", Name: "@acme/*"},
+	}})
+
+	interceptor := newTestDefaultNpmInterceptor(t)
+	ctx := makeTestRequestContext("https://registry.npmjs.org/sigstore/-/sigstore-2.0.0.tgz")
+
+	assert.Nil(t, interceptor.npmProvenanceInspector(ctx, "left-pad", "1.3.0"))
+
+	// The attestation verifies against the built-in trusted root, but is
+	// for the real tarball, not these bytes.
+	inspect := interceptor.npmProvenanceInspector(ctx, "sigstore", "2.0.0")
+	require.NotNil(t, inspect)
+	block := inspect.check([]byte("not the published tarball"))
+	require.NotNil(t, block)
+	assert.Equal(t, proxy.BlockReasonProvenance, block.Reason)
+	assert.Equal(t, "provenance attestation does not cover this artifact", block.Context.ProvenanceError)
+
+	// A version published without provenance is blocked.
+	block = interceptor.npmProvenanceInspector(ctx, "@acme/widgets", "1.0.0").check([]byte("tarball"))
+	require.NotNil(t, block)
+	assert.Equal(t, provenance.ErrNoProvenance.Error(), block.Context.ProvenanceError)
+
+	stats := interceptor.statsCollector.GetStats()
+	assert.Equal(t, 2, stats.BlockedCount)
+	assert.Equal(t, 2, stats.ProvenanceBlockedCount)
+	assert.Equal(t, models.ProvenanceBlock{
+		Ecosystem:  packagev1.Ecosystem_ECOSYSTEM_NPM.String(),
+		Name:       "sigstore",
+		Version:    "2.0.0",
+		Repository: "https://github.com/sigstore/sigstore-js",
+		Reason:     "provenance attestation does not cover this artifact",
+	}, interceptor.statsCollector.GetProvenanceBlocks()[0])
+}
+
+func TestProvenanceInspector_PyPI(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
+
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	orig := pypiIntegrityBaseURL
+	pypiIntegrityBaseURL = server.URL + "/integrity"
+	t.Cleanup(func() { pypiIntegrityBaseURL = orig })
+
+	setProvenanceConfig(t, config.ProvenanceConfig{Packages: []config.ProvenancePackage{
+		{Ecosystem: "pypi", Name: "lite_llm"},
+	}})
+
+	interceptor := newTestDefaultPypiInterceptor(t)
+	ctx := makeTestRequestContext("https://files.pythonhosted.org/packages/ab/cd/lite_llm-1.0.0-py3-none-any.whl")
+	response := interceptor.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, "lite-llm", "1.0.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		interceptor.pypiProvenanceInspector(ctx, "lite-llm", "1.0.0", "lite_llm-1.0.0-py3-none-any.whl"))
+	require.Equal(t, proxy.ActionModifyResponse, response.Action)
+
+	// Provenance that cannot be fetched is not verified, so the file is
+	// blocked.
+	_, _, _, err := response.ResponseModifier(http.StatusOK, http.Header{}, []byte("wheel"))
+	var block *proxy.ResponseBlock
+	require.True(t, errors.As(err, &block))
+	assert.Equal(t, proxy.BlockReasonProvenance, block.Reason)
+	assert.Equal(t, "provenance could not be fetched: HTTP 503", block.Context.ProvenanceError)
+	assert.Equal(t, "/integrity/lite-llm/1.0.0/lite_llm-1.0.0-py3-none-any.whl/provenance", requested)
+}
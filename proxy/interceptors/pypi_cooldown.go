@@ -161,21 +161,33 @@ func (h *pypiCooldownHandler) stripCooldownFiles(body []byte, dates map[string]t
 		return body, nil, remaining
 	}
 
+	result, ok := stripPEP691Files("Cooldown", body, tooNew)
+	if !ok {
+		return body, nil, remaining
+	}
+
+	return result, slices.Collect(maps.Keys(tooNew)), remaining
+}
+
+// stripPEP691Files removes all file entries for the versions in remove from a
+// PEP 691 JSON body. control names the caller in log lines. It returns false
+// when the body could not be rewritten.
+func stripPEP691Files(control string, body []byte, remove map[string]bool) ([]byte, bool) {
 	var resp map[string]json.RawMessage
 	if err := json.Unmarshal(body, &resp); err != nil {
-		log.Warnf("Cooldown: failed to unmarshal PEP 691 body for stripping: %v", err)
-		return body, nil, remaining
+		log.Warnf("%s: failed to unmarshal PEP 691 body for stripping: %v", control, err)
+		return body, false
 	}
 
 	rawFiles, ok := resp["files"]
 	if !ok {
-		return body, nil, remaining
+		return body, false
 	}
 
 	var files []json.RawMessage
 	if err := json.Unmarshal(rawFiles, &files); err != nil {
-		log.Warnf("Cooldown: failed to unmarshal files array: %v", err)
-		return body, nil, remaining
+		log.Warnf("%s: failed to unmarshal files array: %v", control, err)
+		return body, false
 	}
 
 	filtered := make([]json.RawMessage, 0, len(files))
@@ -195,7 +207,7 @@ func (h *pypiCooldownHandler) stripCooldownFiles(body []byte, dates map[string]t
 			filtered = append(filtered, rawFile)
 			continue
 		}
-		if tooNew[pkgInfo.GetVersion()] {
+		if remove[pkgInfo.GetVersion()] {
 			continue // strip
 		}
 		filtered = append(filtered, rawFile)
@@ -203,18 +215,18 @@ func (h *pypiCooldownHandler) stripCooldownFiles(body []byte, dates map[string]t
 
 	updatedFiles, err := json.Marshal(filtered)
 	if err != nil {
-		log.Warnf("Cooldown: failed to marshal filtered files array: %v", err)
-		return body, nil, remaining
+		log.Warnf("%s: failed to marshal filtered files array: %v", control, err)
+		return body, false
 	}
 	resp["files"] = updatedFiles
 
 	result, err := json.Marshal(resp)
 	if err != nil {
-		log.Warnf("Cooldown: failed to marshal final PEP 691 response: %v", err)
-		return body, nil, remaining
+		log.Warnf("%s: failed to marshal final PEP 691 response: %v", control, err)
+		return body, false
 	}
 
-	return result, slices.Collect(maps.Keys(tooNew)), remaining
+	return result, true
 }
 
 // parsePEP691UploadTime parses the ISO 8601 upload-time field from PEP 691 responses.
@@ -620,6 +620,7 @@ func TestPyPICooldown_InterceptorDelegation_CooldownEnabled(t *testing.T) {
 
 func TestPyPICooldown_InterceptorDelegation_CooldownEnabled_OldPip(t *testing.T) {
 	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: true, Days: 5})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
 
 	interceptor := newTestDefaultPypiInterceptor(t)
 
@@ -635,6 +636,7 @@ func TestPyPICooldown_InterceptorDelegation_CooldownEnabled_OldPip(t *testing.T)
 
 func TestPyPICooldown_InterceptorDelegation_CooldownDisabled(t *testing.T) {
 	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false, Days: 5})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
 
 	interceptor := newTestDefaultPypiInterceptor(t)
 
@@ -2,11 +2,15 @@ package interceptors
 
 import (
 	"net/http"
+	"path"
+	"time"
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 	"github.com/safedep/dry/log"
 	"github.com/safedep/pmg/analyzer"
 	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/contentscan"
+	"github.com/safedep/pmg/internal/license"
 	"github.com/safedep/pmg/proxy"
 )
 
@@ -21,8 +25,11 @@ var pypiRegistryEndpoints = []registryEndpoint{
 // It embeds baseRegistryInterceptor to reuse ecosystem agnostic functionality
 type PypiRegistryInterceptor struct {
 	baseRegistryInterceptor
-	cooldownHandler *pypiCooldownHandler
-	registries      registrySet
+	cooldownHandler   *pypiCooldownHandler
+	licenseHandler    *licenseHandler
+	packageAgeHandler *packageAgeHandler
+	integrityHandler  *registryIntegrityHandler
+	registries        registrySet
 }
 
 var _ proxy.Interceptor = (*PypiRegistryInterceptor)(nil)
@@ -52,8 +59,11 @@ func newPypiRegistryInterceptor(
 			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-pypi"),
 			execContext:      execContext,
 		},
-		cooldownHandler: newPypiCooldownHandler(statsCollector),
-		registries:      registries,
+		cooldownHandler:   newPypiCooldownHandler(statsCollector),
+		licenseHandler:    newLicenseHandler(),
+		packageAgeHandler: newPackageAgeHandler(),
+		integrityHandler:  newRegistryIntegrityHandler(),
+		registries:        registries,
 	}
 }
 
@@ -105,8 +115,25 @@ func (i *PypiRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*pro
 	// Parse URL using registry-specific strategy
 	pkgInfo, parseErr := endpoint.Parser.ParseURL(match.RelativePath)
 
+	if parseErr == nil {
+		if resp, refused := i.checkPrivateNamespace(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, pmgconfig.PrivateNamespaceEcosystemPyPI,
+			endpoint.Name, denormalizePyPIPackageName(pkgInfo.GetName()), pkgInfo.GetVersion(), packageInfoHasCompleteIdentity(pkgInfo)); refused {
+			return resp, nil
+		}
+	}
+
 	if parseErr == nil && packageInfoHasCompleteIdentity(pkgInfo) {
-		return i.handleArtifact(ctx, pkgInfo.GetName(), pkgInfo.GetVersion())
+		resp, err := i.handleArtifact(ctx, endpoint, pkgInfo.GetName(), pkgInfo.GetVersion())
+		if err != nil {
+			return resp, err
+		}
+		name, version := denormalizePyPIPackageName(pkgInfo.GetName()), pkgInfo.GetVersion()
+		resp = i.inspectArtifact(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, name, version, resp,
+			i.registryIntegrityInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, name, version, i.integrityHandler.PyPIHashes(path.Base(ctx.URL.Path))),
+			i.lockfileInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, name, version),
+			i.pypiProvenanceInspector(ctx, name, version, path.Base(ctx.URL.Path)),
+			i.contentScanInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, name, version, contentscan.FormatOf(ctx.URL.Path), ""))
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, endpoint.Name, name, version, resp), nil
 	}
 
 	if parseErr != nil {
@@ -125,21 +152,40 @@ func (i *PypiRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*pro
 	return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
 }
 
-// handleMetadataRequest applies dependency cooldown to a metadata request.
-// Cooldown applies only to Simple API requests, since pip uses those, not
-// the JSON API, for version resolution.
+// handleMetadataRequest applies dependency cooldown to a metadata request
+// and records the file hashes the page publishes for the registry integrity
+// check. Both apply only to Simple API requests, since pip uses those, not
+// the JSON API, for version resolution and downloads.
 func (i *PypiRegistryInterceptor) handleMetadataRequest(
 	ctx *proxy.RequestContext,
 	pkgInfo packageInfo,
 ) (*proxy.InterceptorResponse, error) {
-	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
-	if !depCooldownConfig.Enabled || !pypiIsSimpleAPIMetadataRequest(pkgInfo) ||
+	if !pypiIsSimpleAPIMetadataRequest(pkgInfo) ||
 		pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_PYPI, denormalizePyPIPackageName(pkgInfo.GetName())) {
 		log.Debugf("[%s] Skipping analysis for metadata request: %s", ctx.RequestID, pkgInfo.GetName())
 		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
 	}
 
-	return i.cooldownHandler.HandleMetadataRequest(ctx, pkgInfo.GetName(), depCooldownConfig.Days, i.execContext.PinnedVersions[pkgInfo.GetName()])
+	resp := &proxy.InterceptorResponse{Action: proxy.ActionAllow}
+	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
+	if depCooldownConfig.Enabled {
+		var err error
+		resp, err = i.cooldownHandler.HandleMetadataRequest(ctx, pkgInfo.GetName(), depCooldownConfig.Days, i.execContext.PinnedVersions[pkgInfo.GetName()])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pmgconfig.Get().Config.MalwareStripping.Enabled {
+		resp = i.stripMalwareVersions(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, denormalizePyPIPackageName(pkgInfo.GetName()),
+			i.execContext.PinnedVersions[pkgInfo.GetName()], pypiMalwareStripFormat, resp)
+	}
+
+	if pmgconfig.Get().Config.RegistryIntegrity.Enabled {
+		resp = observeRegistryMetadata(ctx, pkgInfo.GetName(), "Registry integrity", i.integrityHandler.ObservePyPIIndex, resp)
+	}
+
+	return resp, nil
 }
 
 // pypiIsSimpleAPIMetadataRequest reports whether a metadata request is
@@ -152,20 +198,44 @@ func pypiIsSimpleAPIMetadataRequest(pkgInfo packageInfo) bool {
 	return ok && info.IsSimpleAPI()
 }
 
-// handleArtifact runs the trust, analysis, and verdict pipeline for an
-// artifact download identified by canonical URL parsing. The canonical
+// handleArtifact runs the trust, package age, license, analysis, and verdict
+// pipeline for an artifact download identified by canonical URL parsing. The canonical
 // name is used for the trust check; the parsed name is kept for
 // analyzePackage.
-func (i *PypiRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, name, version string) (*proxy.InterceptorResponse, error) {
+func (i *PypiRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, endpoint *registryEndpoint, name, version string) (*proxy.InterceptorResponse, error) {
 	canonicalName := denormalizePyPIPackageName(name)
 	if resp, ok := i.fastAllow(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, canonicalName, version); ok {
 		return resp, nil
 	}
 
+	if resp, blocked := i.checkLockfile(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, canonicalName, version); blocked {
+		return resp, nil
+	}
+
+	// The release history and the license are read from the public index
+	// only: a package on a custom index may share its name with an unrelated
+	// public one.
+	if resp, blocked := i.checkPackageAge(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, canonicalName, version, func() (time.Time, error) {
+		if endpoint.Source != registrySourceBuiltIn {
+			return time.Time{}, nil
+		}
+		return i.packageAgeHandler.PyPIFirstRelease(canonicalName)
+	}); blocked {
+		return resp, nil
+	}
+
+	if resp, blocked := i.checkLicense(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, canonicalName, version, func() (*license.Expression, error) {
+		if endpoint.Source != registrySourceBuiltIn {
+			return nil, nil
+		}
+		return i.licenseHandler.PyPILicense(canonicalName, version)
+	}); blocked {
+		return resp, nil
+	}
+
 	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, name, version)
 	if err != nil {
-		log.Errorf("[%s] Failed to analyze package %s@%s: %v", ctx.RequestID, name, version, err)
-		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+		return i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, name, version, err), nil
 	}
 
 	return i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_PYPI, name, version, result)
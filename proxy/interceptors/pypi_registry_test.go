@@ -56,6 +56,7 @@ func TestPypiRegistryInterceptor_Custom_UnknownPathPassesThrough(t *testing.T) {
 
 func TestPypiRegistryInterceptor_Custom_ProjectSegmentIndexRequestIsMetadata(t *testing.T) {
 	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
 
 	mock := &mockAnalyzer{}
 	interceptor := newTestPypiCustomInterceptor(t, mock, "https://python.test/simple")
@@ -193,6 +194,7 @@ func TestPypiRegistryInterceptor_Custom_NonSimpleBaseDoesNotGuessArbitraryPaths(
 
 func TestPypiRegistryInterceptor_Custom_ProjectNameShapedLikeFilenameIsMetadataNotArtifact(t *testing.T) {
 	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
 
 	mock := &mockAnalyzer{}
 	interceptor := newTestPypiCustomInterceptor(t, mock, "https://python.test/simple")
@@ -245,6 +247,7 @@ func TestPypiRegistryInterceptor_Custom_UnparseablePathAllows(t *testing.T) {
 
 func TestPypiRegistryInterceptor_Custom_RetainsExistingSimpleAndJSONShapesWhenBaseIsHigher(t *testing.T) {
 	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
 
 	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{Action: analyzer.ActionBlock}}
 	interceptor := newTestPypiCustomInterceptor(t, mock, "https://python.test/python")
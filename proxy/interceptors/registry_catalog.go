@@ -33,7 +33,7 @@ func NewRegistryCatalog(registries []config.ProxyRegistryConfig) (*RegistryCatal
 				return nil, fmt.Errorf("invalid custom proxy registry %q endpoint: %w", registry.Name, err)
 			}
 			if endpoint := catalog.builtInForHostname(u.Hostname()); endpoint != nil {
-				return nil, fmt.Errorf("invalid custom %s registry %q endpoint: host %q is covered by the built-in npm/PyPI registries",
+				return nil, fmt.Errorf("invalid custom %s registry %q endpoint: host %q is covered by the built-in registries",
 					registry.Ecosystem, registry.Name, u.Hostname())
 			}
 
@@ -67,8 +67,13 @@ func NewRegistryCatalog(registries []config.ProxyRegistryConfig) (*RegistryCatal
 func newBuiltInRegistryCatalog() *RegistryCatalog {
 	return &RegistryCatalog{
 		byEcosystem: map[packagev1.Ecosystem]registrySet{
-			packagev1.Ecosystem_ECOSYSTEM_NPM:  {entries: append([]registryEndpoint(nil), npmRegistryEndpoints...)},
-			packagev1.Ecosystem_ECOSYSTEM_PYPI: {entries: append([]registryEndpoint(nil), pypiRegistryEndpoints...)},
+			packagev1.Ecosystem_ECOSYSTEM_NPM:       {entries: append([]registryEndpoint(nil), npmRegistryEndpoints...)},
+			packagev1.Ecosystem_ECOSYSTEM_PYPI:      {entries: append([]registryEndpoint(nil), pypiRegistryEndpoints...)},
+			packagev1.Ecosystem_ECOSYSTEM_CARGO:     {entries: append([]registryEndpoint(nil), cratesRegistryEndpoints...)},
+			packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS:  {entries: append([]registryEndpoint(nil), rubyGemsRegistryEndpoints...)},
+			packagev1.Ecosystem_ECOSYSTEM_MAVEN:     {entries: append([]registryEndpoint(nil), mavenRegistryEndpoints...)},
+			packagev1.Ecosystem_ECOSYSTEM_NUGET:     {entries: append([]registryEndpoint(nil), nugetRegistryEndpoints...)},
+			packagev1.Ecosystem_ECOSYSTEM_PACKAGIST: {entries: append([]registryEndpoint(nil), composerRegistryEndpoints...)},
 		},
 	}
 }
@@ -123,6 +128,11 @@ func (c *RegistryCatalog) builtInForHostname(hostname string) *registryEndpoint
 	for _, ecosystem := range []packagev1.Ecosystem{
 		packagev1.Ecosystem_ECOSYSTEM_NPM,
 		packagev1.Ecosystem_ECOSYSTEM_PYPI,
+		packagev1.Ecosystem_ECOSYSTEM_CARGO,
+		packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS,
+		packagev1.Ecosystem_ECOSYSTEM_MAVEN,
+		packagev1.Ecosystem_ECOSYSTEM_NUGET,
+		packagev1.Ecosystem_ECOSYSTEM_PACKAGIST,
 	} {
 		set := c.byEcosystem[ecosystem]
 		for index := range set.entries {
@@ -151,16 +161,34 @@ func registryEcosystem(ecosystem string) packagev1.Ecosystem {
 		return packagev1.Ecosystem_ECOSYSTEM_NPM
 	case "pypi":
 		return packagev1.Ecosystem_ECOSYSTEM_PYPI
+	case "rubygems":
+		return packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS
+	case "maven":
+		return packagev1.Ecosystem_ECOSYSTEM_MAVEN
+	case "nuget":
+		return packagev1.Ecosystem_ECOSYSTEM_NUGET
+	case "composer":
+		return packagev1.Ecosystem_ECOSYSTEM_PACKAGIST
 	default:
 		panic(fmt.Sprintf("unsupported validated registry ecosystem %q", ecosystem))
 	}
 }
 
 func customRegistryParser(ecosystem packagev1.Ecosystem, u *url.URL) registryURLParser {
-	if ecosystem == packagev1.Ecosystem_ECOSYSTEM_PYPI {
+	switch ecosystem {
+	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
 		return pypiCustomParser{baseEndsInSimple: pypiBaseEndsInSimple(u.EscapedPath())}
+	case packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS:
+		return rubyGemsParser{}
+	case packagev1.Ecosystem_ECOSYSTEM_MAVEN:
+		return mavenParser{}
+	case packagev1.Ecosystem_ECOSYSTEM_NUGET:
+		return nugetParser{}
+	case packagev1.Ecosystem_ECOSYSTEM_PACKAGIST:
+		return composerParser{}
+	default:
+		return npmParser{}
 	}
-	return npmParser{}
 }
 
 func builtInRegistryEndpoint(host string, analyze bool, parser registryURLParser) registryEndpoint {
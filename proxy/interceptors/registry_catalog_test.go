@@ -61,6 +61,6 @@ func TestRegistryCatalogReturnsIndependentSets(t *testing.T) {
 
 func TestRegistryEcosystemRejectsInvalidValue(t *testing.T) {
 	assert.Panics(t, func() {
-		registryEcosystem("maven")
+		registryEcosystem("conda")
 	})
 }
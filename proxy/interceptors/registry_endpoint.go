@@ -175,6 +175,19 @@ func registryAbsoluteRequestURL(ctx *proxy.RequestContext) *url.URL {
 	return &u
 }
 
+// registryURLWithoutQuery returns u without its query and fragment, for
+// keying and comparing registry URLs.
+func registryURLWithoutQuery(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	withoutQuery := *u
+	withoutQuery.RawQuery = ""
+	withoutQuery.Fragment = ""
+	return withoutQuery.String()
+}
+
 func relativePath(path, basePath string) string {
 	relative := strings.TrimPrefix(path, basePath)
 	if relative == "" {
@@ -0,0 +1,312 @@
+package interceptors
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/audit"
+	"github.com/safedep/pmg/internal/lockfile"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+)
+
+// registryIntegrityHandler remembers the artifact hashes registries publish
+// in the metadata PMG proxies: dist.integrity and dist.shasum of npm
+// packuments, and the file hashes of PyPI Simple API pages. Downloads are
+// checked against them, so a mirror serving an artifact other than the one
+// its own metadata describes is caught.
+type registryIntegrityHandler struct {
+	mu     sync.Mutex
+	hashes map[string][]lockfile.Hash
+}
+
+func newRegistryIntegrityHandler() *registryIntegrityHandler {
+	return &registryIntegrityHandler{hashes: map[string][]lockfile.Hash{}}
+}
+
+// ObserveNpmPackument records the dist hashes of every version in a full or
+// abbreviated packument.
+func (h *registryIntegrityHandler) ObserveNpmPackument(name string, packument []byte) error {
+	var metadata struct {
+		Versions map[string]struct {
+			Dist struct {
+				Integrity string `json:"integrity"`
+				Shasum    string `json:"shasum"`
+			} `json:"dist"`
+		} `json:"versions"`
+	}
+	if err := json.Unmarshal(packument, &metadata); err != nil {
+		return err
+	}
+
+	for version, v := range metadata.Versions {
+		hashes := lockfile.ParseSRI(v.Dist.Integrity)
+		if shasum, ok := lockfile.ParseHexHash("sha1:" + v.Dist.Shasum); ok {
+			hashes = append(hashes, shasum)
+		}
+		h.remember("npm:"+name+"@"+version, hashes)
+	}
+	return nil
+}
+
+// NpmHashes returns the hashes the registry published for an npm package
+// version, or nil when its packument was not seen.
+func (h *registryIntegrityHandler) NpmHashes(name, version string) []lockfile.Hash {
+	return h.lookup("npm:" + name + "@" + version)
+}
+
+// ObservePyPIIndex records the file hashes of a Simple API project page,
+// either PEP 691 JSON or the PEP 503 HTML whose links carry the hash in
+// their URL fragment.
+func (h *registryIntegrityHandler) ObservePyPIIndex(body []byte, contentType string) error {
+	if strings.Contains(contentType, "json") {
+		var index struct {
+			Files []struct {
+				Filename string            `json:"filename"`
+				Hashes   map[string]string `json:"hashes"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(body, &index); err != nil {
+			return err
+		}
+
+		for _, file := range index.Files {
+			var hashes []lockfile.Hash
+			for algorithm, digest := range file.Hashes {
+				if hash, ok := lockfile.ParseHexHash(algorithm + ":" + digest); ok {
+					hashes = append(hashes, hash)
+				}
+			}
+			h.remember("pypi:"+file.Filename, hashes)
+		}
+		return nil
+	}
+
+	for _, match := range pypiIndexHref.FindAllSubmatch(body, -1) {
+		link, err := url.Parse(html.UnescapeString(string(match[1])))
+		if err != nil || link.Fragment == "" {
+			continue
+		}
+		algorithm, digest, _ := strings.Cut(link.Fragment, "=")
+		if hash, ok := lockfile.ParseHexHash(algorithm + ":" + digest); ok {
+			h.remember("pypi:"+path.Base(link.Path), []lockfile.Hash{hash})
+		}
+	}
+	return nil
+}
+
+var pypiIndexHref = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+
+// PyPIHashes returns the hashes the index published for a distribution
+// file, or nil when no index page listing it was seen.
+func (h *registryIntegrityHandler) PyPIHashes(filename string) []lockfile.Hash {
+	return h.lookup("pypi:" + filename)
+}
+
+// remember replaces the hashes of an artifact: the metadata seen last is
+// the metadata the client resolved the download from.
+func (h *registryIntegrityHandler) remember(key string, hashes []lockfile.Hash) {
+	if len(hashes) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hashes[key] = hashes
+}
+
+func (h *registryIntegrityHandler) lookup(key string) []lockfile.Hash {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hashes[key]
+}
+
+// maxRegistryMetadataSize bounds a compressed metadata document once it is
+// decompressed for an observer.
+const maxRegistryMetadataSize = 256 << 20
+
+// observeRegistryMetadata hands the metadata response for a package to
+// observe, the check named by label, before resp's own response modifier runs, so observe sees what the
+// registry sent. It keeps the fetch as cheap as the client made it: the
+// response stays gzip-compressed when the client accepts gzip, and a
+// conditional request can still come back as a 304, which leaves the client
+// on its cached copy and nothing to observe. Unlike observeNpmPackument it
+// leaves the Accept header alone: abbreviated npm metadata carries the dist
+// hashes too. Responses other than allow or modify are returned unchanged.
+func observeRegistryMetadata(
+	ctx *proxy.RequestContext,
+	packageName string,
+	label string,
+	observe func(body []byte, contentType string) error,
+	resp *proxy.InterceptorResponse,
+) *proxy.InterceptorResponse {
+	if resp.Action != proxy.ActionAllow && resp.Action != proxy.ActionModifyResponse {
+		return resp
+	}
+
+	acceptDecodableEncoding(ctx.Headers)
+
+	next := resp.ResponseModifier
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode == http.StatusOK {
+			decoded, err := decodeRegistryMetadata(headers.Get("Content-Encoding"), body)
+			if err == nil {
+				err = observe(decoded, headers.Get("Content-Type"))
+			}
+			if err != nil {
+				log.Warnf("[%s] %s: failed to read the registry metadata of %s: %v", ctx.RequestID, label, packageName, err)
+			}
+		}
+
+		if next == nil {
+			return statusCode, headers, body, nil
+		}
+		return next(statusCode, headers, body)
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}
+}
+
+// acceptDecodableEncoding narrows the encodings a metadata request accepts
+// to one decodeRegistryMetadata reads: gzip when the client accepts it,
+// identity otherwise. A request without Accept-Encoding is left alone; the
+// upstream transport then negotiates gzip and decodes it itself.
+func acceptDecodableEncoding(headers http.Header) {
+	accepted := headers.Values("Accept-Encoding")
+	if len(accepted) == 0 {
+		return
+	}
+
+	for _, value := range accepted {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "gzip" && name != "x-gzip" {
+				continue
+			}
+			if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+				if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+					continue
+				}
+			}
+			headers.Set("Accept-Encoding", "gzip")
+			return
+		}
+	}
+	headers.Set("Accept-Encoding", "identity")
+}
+
+// decodeRegistryMetadata returns the metadata in a response body sent with
+// the given Content-Encoding.
+func decodeRegistryMetadata(contentEncoding string, body []byte) ([]byte, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(contentEncoding)); encoding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		defer reader.Close()
+
+		decoded, err := io.ReadAll(io.LimitReader(reader, maxRegistryMetadataSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress metadata: %w", err)
+		}
+		if len(decoded) > maxRegistryMetadataSize {
+			return nil, fmt.Errorf("metadata is over %d MB decompressed", maxRegistryMetadataSize>>20)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// registryIntegrityInspector returns the inspector that compares an artifact
+// with the hashes its registry published, or nil when the check is disabled
+// or no hash is known for the artifact. A response that is not the whole,
+// unencoded artifact cannot be compared and is blocked.
+func (b *baseRegistryInterceptor) registryIntegrityInspector(
+	ctx *proxy.RequestContext,
+	ecosystem packagev1.Ecosystem,
+	packageName string,
+	packageVersion string,
+	published []lockfile.Hash,
+) *artifactInspector {
+	if !config.Get().Config.RegistryIntegrity.Enabled || len(published) == 0 {
+		return nil
+	}
+
+	block := func(expected, actual, why string) *proxy.ResponseBlock {
+		pkgVersion := &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: ecosystem, Name: packageName},
+			Version: packageVersion,
+		}
+		log.Warnf("[%s] Blocking %s/%s@%s: %s", ctx.RequestID, ecosystem.String(), packageName, packageVersion, why)
+		audit.LogRegistryIntegrity(pkgVersion, ctx.Hostname, expected, actual)
+
+		if b.statsCollector != nil {
+			b.statsCollector.RecordRegistryIntegrityBlocked(models.RegistryIntegrityBlock{
+				Name:     packageName,
+				Version:  packageVersion,
+				Registry: ctx.Hostname,
+				Expected: expected,
+				Actual:   actual,
+			})
+		}
+
+		return &proxy.ResponseBlock{
+			Code:   http.StatusForbidden,
+			Reason: proxy.BlockReasonRegistryIntegrity,
+			Context: &proxy.BlockContext{
+				Ecosystem:      ecosystem,
+				PackageName:    packageName,
+				PackageVersion: packageVersion,
+				Registry:       ctx.Hostname,
+				ExpectedHash:   expected,
+				ActualHash:     actual,
+			},
+		}
+	}
+
+	return &artifactInspector{
+		check: func(artifact []byte) *proxy.ResponseBlock {
+			ok, actual := lockfile.Verify(published, artifact)
+			if ok {
+				log.Debugf("[%s] Registry integrity: %s/%s@%s matches its registry metadata", ctx.RequestID, ecosystem.String(), packageName, packageVersion)
+				return nil
+			}
+
+			expected := published[0]
+			for _, h := range published {
+				if h.Algorithm == actual.Algorithm {
+					expected = h
+					break
+				}
+			}
+			return block(expected.String(), actual.String(),
+				fmt.Sprintf("artifact hash %s from %s does not match %s published in its metadata", actual.String(), ctx.Hostname, expected.String()))
+		},
+		unreadable: func(reason string) *proxy.ResponseBlock {
+			return block(published[0].String(), "unknown, the response is "+reason,
+				fmt.Sprintf("the response from %s is %s, so it cannot be compared with the hash published in its metadata", ctx.Hostname, reason))
+		},
+	}
+}
@@ -0,0 +1,228 @@
+package interceptors
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/internal/models"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var publishedArtifact = []byte("demo artifact")
+
+func setRegistryIntegrityConfig(t *testing.T, cfg config.RegistryIntegrityConfig) {
+	t.Helper()
+
+	orig := config.Get().Config.RegistryIntegrity
+	t.Cleanup(func() { config.Get().Config.RegistryIntegrity = orig })
+	config.Get().Config.RegistryIntegrity = cfg
+}
+
+func TestRegistryIntegrity_NpmPackument(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: true})
+
+	interceptor := newTestNpmCustomInterceptor(t, &mockAnalyzer{}, "https://packages.test/npm")
+
+	ctx := makeTestRequestContext("https://packages.test/npm/demo")
+	ctx.Headers.Set("Accept", "application/vnd.npm.install-v1+json")
+	resp, err := interceptor.HandleRequest(ctx)
+	require.NoError(t, err)
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+	// Abbreviated metadata carries the dist hashes, so it is kept.
+	assert.Equal(t, "application/vnd.npm.install-v1+json", ctx.Headers.Get("Accept"))
+
+	sha512Sum := sha512.Sum512(publishedArtifact)
+	sha1Sum := sha1.Sum(publishedArtifact)
+	body := []byte(`{"name":"demo","versions":{"1.0.0":{"dist":{` +
+		`"integrity":"sha512-` + base64.StdEncoding.EncodeToString(sha512Sum[:]) + `",` +
+		`"shasum":"` + hex.EncodeToString(sha1Sum[:]) + `"}}}}`)
+	_, _, out, err := resp.ResponseModifier(http.StatusOK, http.Header{}, body)
+	require.NoError(t, err)
+	assert.Equal(t, body, out)
+	assert.Len(t, interceptor.integrityHandler.NpmHashes("demo", "1.0.0"), 2)
+	assert.Nil(t, interceptor.integrityHandler.NpmHashes("demo", "2.0.0"))
+
+	artifactCtx := makeTestRequestContext("https://packages.test/npm/demo/-/demo-1.0.0.tgz")
+	response := interceptor.inspectArtifact(artifactCtx, packagev1.Ecosystem_ECOSYSTEM_NPM, "demo", "1.0.0",
+		&proxy.InterceptorResponse{Action: proxy.ActionAllow},
+		interceptor.registryIntegrityInspector(artifactCtx, packagev1.Ecosystem_ECOSYSTEM_NPM, "demo", "1.0.0",
+			interceptor.integrityHandler.NpmHashes("demo", "1.0.0")))
+	require.Equal(t, proxy.ActionModifyResponse, response.Action)
+
+	_, _, out, err = response.ResponseModifier(http.StatusOK, http.Header{}, publishedArtifact)
+	require.NoError(t, err)
+	assert.Equal(t, publishedArtifact, out)
+
+	_, _, _, err = response.ResponseModifier(http.StatusOK, http.Header{}, []byte("tampered"))
+	var block *proxy.ResponseBlock
+	require.True(t, errors.As(err, &block))
+	assert.Equal(t, proxy.BlockReasonRegistryIntegrity, block.Reason)
+	assert.Equal(t, "packages.test", block.Context.Registry)
+	assert.Contains(t, block.Context.ExpectedHash, "sha512-")
+	assert.NotEqual(t, block.Context.ExpectedHash, block.Context.ActualHash)
+
+	stats := interceptor.statsCollector.GetStats()
+	assert.Equal(t, 1, stats.BlockedCount)
+	assert.Equal(t, 1, stats.RegistryIntegrityBlockedCount)
+	assert.Equal(t, []models.RegistryIntegrityBlock{{
+		Name:     "demo",
+		Version:  "1.0.0",
+		Registry: "packages.test",
+		Expected: block.Context.ExpectedHash,
+		Actual:   block.Context.ActualHash,
+	}}, interceptor.statsCollector.GetRegistryIntegrityBlocks())
+}
+
+func TestRegistryIntegrity_PyPIIndex(t *testing.T) {
+	sum := sha256.Sum256(publishedArtifact)
+	digest := hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "PEP 691 JSON",
+			contentType: pypiSimpleAPIContentType,
+			body:        `{"name":"demo","files":[{"filename":"demo-1.0.0.tar.gz","hashes":{"sha256":"` + digest + `"}}]}`,
+		},
+		{
+			name:        "PEP 503 HTML",
+			contentType: "text/html",
+			body: `<html><body><a href="../../files/ab/demo-1.0.0.tar.gz#sha256=` + digest + `">demo-1.0.0.tar.gz</a>` +
+				`<a href="../../files/cd/demo-0.9.0.tar.gz">demo-0.9.0.tar.gz</a></body></html>`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newRegistryIntegrityHandler()
+			require.NoError(t, handler.ObservePyPIIndex([]byte(tc.body), tc.contentType))
+
+			hashes := handler.PyPIHashes("demo-1.0.0.tar.gz")
+			require.Len(t, hashes, 1)
+			assert.Equal(t, "sha256", hashes[0].Algorithm)
+			assert.Nil(t, handler.PyPIHashes("demo-0.9.0.tar.gz"))
+		})
+	}
+
+	assert.Error(t, newRegistryIntegrityHandler().ObservePyPIIndex([]byte("not json"), pypiSimpleAPIContentType))
+}
+
+func TestRegistryIntegrity_PyPIMetadataRequest(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: false})
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: true})
+
+	interceptor := newTestPypiCustomInterceptor(t, &mockAnalyzer{}, "https://python.test/simple")
+
+	ctx := makeTestRequestContext("https://python.test/simple/demo/")
+	ctx.Headers.Set("Accept", "text/html")
+	ctx.Headers.Set("Accept-Encoding", "gzip, deflate, br")
+	ctx.Headers.Set("If-None-Match", `"etag-value"`)
+	resp, err := interceptor.HandleRequest(ctx)
+	require.NoError(t, err)
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+	assert.Equal(t, "text/html", ctx.Headers.Get("Accept"))
+	assert.Equal(t, "gzip", ctx.Headers.Get("Accept-Encoding"))
+	assert.Equal(t, `"etag-value"`, ctx.Headers.Get("If-None-Match"))
+
+	sum := sha256.Sum256(publishedArtifact)
+	headers := http.Header{}
+	headers.Set("Content-Type", "text/html")
+	body := []byte(`<a href="https://python.test/files/demo-1.0.0.tar.gz#sha256=` + hex.EncodeToString(sum[:]) + `">demo</a>`)
+	_, _, out, err := resp.ResponseModifier(http.StatusOK, headers, body)
+	require.NoError(t, err)
+	assert.Equal(t, body, out)
+	assert.Len(t, interceptor.integrityHandler.PyPIHashes("demo-1.0.0.tar.gz"), 1)
+}
+
+func TestRegistryIntegrity_CompressedAndNotModifiedMetadata(t *testing.T) {
+	handler := newRegistryIntegrityHandler()
+	ctx := makeTestRequestContext("https://registry.npmjs.org/demo")
+	resp := observeRegistryMetadata(ctx, "demo", "Registry integrity", func(body []byte, _ string) error {
+		return handler.ObserveNpmPackument("demo", body)
+	}, &proxy.InterceptorResponse{Action: proxy.ActionAllow})
+	require.Equal(t, proxy.ActionModifyResponse, resp.Action)
+
+	// A 304 leaves the client on its cached copy; there is nothing to read.
+	status, _, out, err := resp.ResponseModifier(http.StatusNotModified, http.Header{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, status)
+	assert.Empty(t, out)
+	assert.Nil(t, handler.NpmHashes("demo", "1.0.0"))
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err = gz.Write([]byte(`{"versions":{"1.0.0":{"dist":{"shasum":"` + hex.EncodeToString(make([]byte, 20)) + `"}}}}`))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	// A gzip response is read decompressed and forwarded as it came.
+	headers := http.Header{}
+	headers.Set("Content-Encoding", "gzip")
+	_, outHeaders, out, err := resp.ResponseModifier(http.StatusOK, headers, compressed.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, compressed.Bytes(), out)
+	assert.Equal(t, "gzip", outHeaders.Get("Content-Encoding"))
+	assert.Len(t, handler.NpmHashes("demo", "1.0.0"), 1)
+}
+
+func TestAcceptDecodableEncoding(t *testing.T) {
+	cases := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{"absent", "", ""},
+		{"gzip among others", "gzip, deflate, br", "gzip"},
+		{"gzip with weight", "br;q=1.0, gzip;q=0.8", "gzip"},
+		{"gzip refused", "gzip;q=0, br", "identity"},
+		{"no gzip", "br, zstd", "identity"},
+		{"identity", "identity", "identity"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tc.accept != "" {
+				headers.Set("Accept-Encoding", tc.accept)
+			}
+			acceptDecodableEncoding(headers)
+			assert.Equal(t, tc.expected, headers.Get("Accept-Encoding"))
+		})
+	}
+}
+
+func TestRegistryIntegrity_Disabled(t *testing.T) {
+	setRegistryIntegrityConfig(t, config.RegistryIntegrityConfig{Enabled: false})
+
+	base := &baseRegistryInterceptor{}
+	handler := newRegistryIntegrityHandler()
+	require.NoError(t, handler.ObserveNpmPackument("demo", []byte(`{"versions":{"1.0.0":{"dist":{"shasum":"`+
+		hex.EncodeToString(make([]byte, 20))+`"}}}}`)))
+	ctx := makeTestRequestContext("https://registry.npmjs.org/demo/-/demo-1.0.0.tgz")
+
+	assert.Nil(t, base.registryIntegrityInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "demo", "1.0.0",
+		handler.NpmHashes("demo", "1.0.0")))
+
+	config.Get().Config.RegistryIntegrity.Enabled = true
+	assert.NotNil(t, base.registryIntegrityInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "demo", "1.0.0",
+		handler.NpmHashes("demo", "1.0.0")))
+	// Nothing to compare against when the metadata was not seen.
+	assert.Nil(t, base.registryIntegrityInspector(ctx, packagev1.Ecosystem_ECOSYSTEM_NPM, "demo", "2.0.0",
+		handler.NpmHashes("demo", "2.0.0")))
+}
@@ -31,8 +31,17 @@ func newTestCustomRegistrySetFor(t *testing.T, ecosystem packagev1.Ecosystem, en
 		endpoints[index] = config.ProxyRegistryEndpointConfig{URL: endpointURL}
 	}
 	ecosystemName := "npm"
-	if ecosystem == packagev1.Ecosystem_ECOSYSTEM_PYPI {
+	switch ecosystem {
+	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
 		ecosystemName = "pypi"
+	case packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS:
+		ecosystemName = "rubygems"
+	case packagev1.Ecosystem_ECOSYSTEM_MAVEN:
+		ecosystemName = "maven"
+	case packagev1.Ecosystem_ECOSYSTEM_NUGET:
+		ecosystemName = "nuget"
+	case packagev1.Ecosystem_ECOSYSTEM_PACKAGIST:
+		ecosystemName = "composer"
 	}
 	return newTestRegistrySetFor(t, ecosystem, []config.ProxyRegistryConfig{{
 		Name:      "custom-" + ecosystemName,
@@ -0,0 +1,205 @@
+package interceptors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+// rubyGemsCooldownHandler handles dependency cooldown for gems. It strips
+// recently-created versions from compact index /info/{name} files so
+// Bundler's resolver falls back to the latest eligible version. The compact
+// index carries no timestamps, so creation times are fetched out-of-band from
+// the registry's /api/v1/versions/{name}.json; when that fails the /info file
+// passes through unmodified and malware analysis still runs on the download.
+type rubyGemsCooldownHandler struct {
+	statsCollector *AnalysisStatsCollector
+	client         *http.Client
+}
+
+// rubyGemsVersionsFetchClient fetches version creation times straight from
+// the upstream registry rather than back through PMG's own proxy.
+var rubyGemsVersionsFetchClient = &http.Client{Timeout: 10 * time.Second}
+
+func newRubyGemsCooldownHandler(statsCollector *AnalysisStatsCollector) *rubyGemsCooldownHandler {
+	return &rubyGemsCooldownHandler{
+		statsCollector: statsCollector,
+		client:         rubyGemsVersionsFetchClient,
+	}
+}
+
+// rubyGemsVersionEntry is the subset of a /api/v1/versions/{name}.json entry
+// cooldown needs.
+type rubyGemsVersionEntry struct {
+	Number    string    `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HandleMetadataRequest registers a response modifier that strips compact
+// index lines for versions within the cooldown window. apiBaseURL is the
+// registry root that serves /api/v1/versions.
+func (h *rubyGemsCooldownHandler) HandleMetadataRequest(ctx *proxy.RequestContext, apiBaseURL, gemName string, cooldownDays int, pinnedVersion string) (*proxy.InterceptorResponse, error) {
+	skip := pmgconfig.CooldownSkip(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, gemName)
+	if skip.SkipAll {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	log.Debugf("[%s] Cooldown: registering compact index modifier for %s", ctx.RequestID, gemName)
+
+	forceUncompressedNonConditionalResponse(ctx.Headers)
+
+	// Bundler appends to its cached /info file with a Range request. A
+	// partial body cannot be filtered, so always ask for the whole file.
+	ctx.Headers.Del("Range")
+	ctx.Headers.Del("If-Range")
+
+	modifier := func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+		if statusCode != http.StatusOK {
+			return statusCode, headers, body, nil
+		}
+
+		dates, err := h.fetchCreationTimes(apiBaseURL, gemName)
+		if err != nil {
+			log.Warnf("[%s] Cooldown: no creation times for %s; cooldown not enforced: %v", ctx.RequestID, gemName, err)
+			return statusCode, headers, body, nil
+		}
+
+		exempt := cooldownExemptVersions(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, gemName, skip, dates, cooldownDays)
+		auditCooldownSkips(ctx.RequestID, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, gemName, exempt)
+		strippedBody, stripped, remaining := h.stripCooldownLines(body, dates, cooldownDays, exempt.all)
+		if len(stripped) > 0 {
+			log.Infof("[%s] Cooldown: stripped %d version(s) from %s compact index (%d days, %d eligible remain)",
+				ctx.RequestID, len(stripped), gemName, cooldownDays, remaining)
+
+			recordCooldownStats(h.statsCollector, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, gemName, pinnedVersion, dates, stripped, remaining, cooldownDays)
+
+			// Bundler checks the body against ETag and Repr-Digest and
+			// retries on mismatch, and reuses the cached file for later
+			// Range requests. Drop the validators so the stripped file is
+			// accepted and refetched in full next time.
+			headers.Del("ETag")
+			headers.Del("Last-Modified")
+			headers.Del("Digest")
+			headers.Del("Repr-Digest")
+			headers.Set("Cache-Control", "no-store")
+
+			return statusCode, headers, strippedBody, nil
+		}
+
+		return statusCode, headers, body, nil
+	}
+
+	return &proxy.InterceptorResponse{
+		Action:           proxy.ActionModifyResponse,
+		ResponseModifier: modifier,
+	}, nil
+}
+
+// fetchCreationTimes returns the creation time of each version of a gem.
+// Platform builds of one version share a version number; the earliest
+// creation time wins.
+func (h *rubyGemsCooldownHandler) fetchCreationTimes(apiBaseURL, gemName string) (map[string]time.Time, error) {
+	if apiBaseURL == "" {
+		return nil, fmt.Errorf("no API base URL")
+	}
+
+	versionsURL := fmt.Sprintf("%s/api/v1/versions/%s.json", strings.TrimSuffix(apiBaseURL, "/"), url.PathEscape(gemName))
+
+	resp, err := h.client.Get(versionsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned HTTP %d", versionsURL, resp.StatusCode)
+	}
+
+	var entries []rubyGemsVersionEntry
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", versionsURL, err)
+	}
+
+	dates := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		if entry.Number == "" || entry.CreatedAt.IsZero() {
+			continue
+		}
+		if existing, ok := dates[entry.Number]; !ok || entry.CreatedAt.Before(existing) {
+			dates[entry.Number] = entry.CreatedAt
+		}
+	}
+
+	return dates, nil
+}
+
+// stripCooldownLines removes the compact index lines of versions within the
+// cooldown window, including every platform build of such a version. Returns
+// the modified body, the stripped versions, and the count of versions
+// remaining.
+func (h *rubyGemsCooldownHandler) stripCooldownLines(body []byte, dates map[string]time.Time, cooldownDays int, exemptVersions map[string]bool) ([]byte, []string, int) {
+	tooNew := make(map[string]bool)
+	for version, createdAt := range dates {
+		if exemptVersions[version] {
+			continue
+		}
+		if within, _, _ := cooldownIsWithinWindow(createdAt, cooldownDays); within {
+			tooNew[version] = true
+		}
+	}
+
+	lines := bytes.Split(body, []byte("\n"))
+	versions := make(map[string]bool)
+	for _, line := range lines {
+		if version, ok := parseRubyGemsInfoLine(line); ok {
+			versions[version] = true
+		}
+	}
+
+	stripped := make(map[string]bool)
+	kept := make([][]byte, 0, len(lines))
+	for _, line := range lines {
+		if version, ok := parseRubyGemsInfoLine(line); ok && tooNew[version] {
+			stripped[version] = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	remaining := len(versions) - len(stripped)
+	if len(stripped) == 0 {
+		return body, nil, remaining
+	}
+
+	return bytes.Join(kept, []byte("\n")), slices.Collect(maps.Keys(stripped)), remaining
+}
+
+// parseRubyGemsInfoLine returns the version of a compact index /info line,
+// which has the form "{version}[-{platform}] {deps}|{requirements}". The
+// leading "---" line and blank lines are not versions.
+func parseRubyGemsInfoLine(line []byte) (string, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || bytes.Equal(line, []byte("---")) {
+		return "", false
+	}
+
+	token, _, _ := bytes.Cut(line, []byte(" "))
+	version, _, _ := bytes.Cut(token, []byte("-"))
+	if len(version) == 0 || version[0] < '0' || version[0] > '9' {
+		return "", false
+	}
+
+	return string(version), true
+}
@@ -0,0 +1,159 @@
+package interceptors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRubyGemsVersionsServer serves /api/v1/versions/{name}.json with the
+// given creation times.
+func newTestRubyGemsVersionsServer(t *testing.T, name string, createdAt map[string]time.Time) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/versions/"+name+".json" {
+			http.NotFound(w, r)
+			return
+		}
+		entries := make([]map[string]any, 0, len(createdAt))
+		for number, t := range createdAt {
+			entries = append(entries, map[string]any{
+				"number":     number,
+				"platform":   "ruby",
+				"created_at": t.UTC().Format(time.RFC3339Nano),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(entries)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func rubyGemsInfoVersions(body []byte) []string {
+	var versions []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if v, ok := parseRubyGemsInfoLine([]byte(line)); ok {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+const testRubyGemsInfo = `---
+0.9.0 |checksum:aaa
+1.0.0 rack:>= 2.0|checksum:bbb,ruby:>= 2.7
+2.0.0 rack:>= 3.0|checksum:ccc
+2.0.0-java rack:>= 3.0|checksum:ddd
+`
+
+func TestRubyGemsCooldown_StripsRecentVersions(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	server := newTestRubyGemsVersionsServer(t, "testgem", map[string]time.Time{
+		"0.9.0": now.Add(-90 * day),
+		"1.0.0": now.Add(-30 * day),
+		"2.0.0": now.Add(-1 * day),
+	})
+
+	collector := NewAnalysisStatsCollector()
+	handler := newRubyGemsCooldownHandler(collector)
+	ctx := makeTestRequestContext("https://index.rubygems.org/info/testgem")
+	ctx.Headers.Set("Range", "bytes=120-")
+	ctx.Headers.Set("If-None-Match", `"abc"`)
+
+	resp, err := handler.HandleMetadataRequest(ctx, server.URL, "testgem", 5, "")
+	require.NoError(t, err)
+	require.NotNil(t, resp.ResponseModifier)
+	assert.Empty(t, ctx.Headers.Get("Range"))
+	assert.Empty(t, ctx.Headers.Get("If-None-Match"))
+
+	headers := http.Header{}
+	headers.Set("ETag", `"abc"`)
+	headers.Set("Repr-Digest", "sha-256=:abc:")
+
+	_, retHeaders, retBody, err := resp.ResponseModifier(http.StatusOK, headers, []byte(testRubyGemsInfo))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.9.0", "1.0.0"}, rubyGemsInfoVersions(retBody))
+	assert.True(t, strings.HasPrefix(string(retBody), "---\n"))
+	assert.Empty(t, retHeaders.Get("ETag"))
+	assert.Empty(t, retHeaders.Get("Repr-Digest"))
+	assert.Equal(t, "no-store", retHeaders.Get("Cache-Control"))
+
+	assert.Empty(t, collector.GetCooldownBlocks())
+	require.Len(t, collector.GetCooldownWithheld(), 1)
+}
+
+func TestRubyGemsCooldown_PinnedVersionInCooldown_RecordsBlock(t *testing.T) {
+	now := time.Now()
+	server := newTestRubyGemsVersionsServer(t, "testgem", map[string]time.Time{
+		"1.0.0": now.Add(-30 * 24 * time.Hour),
+		"2.0.0": now.Add(-1 * 24 * time.Hour),
+	})
+
+	collector := NewAnalysisStatsCollector()
+	handler := newRubyGemsCooldownHandler(collector)
+	resp, err := handler.HandleMetadataRequest(makeTestRequestContext("https://index.rubygems.org/info/testgem"),
+		server.URL, "testgem", 5, "2.0.0")
+	require.NoError(t, err)
+
+	_, _, _, err = resp.ResponseModifier(http.StatusOK, http.Header{}, []byte(testRubyGemsInfo))
+	require.NoError(t, err)
+
+	blocks := collector.GetCooldownBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "2.0.0", blocks[0].Version)
+}
+
+func TestRubyGemsCooldown_VersionsFetchFailure_BodyUnchanged(t *testing.T) {
+	server := newTestRubyGemsVersionsServer(t, "othergem", nil)
+
+	handler := newRubyGemsCooldownHandler(NewAnalysisStatsCollector())
+	resp, err := handler.HandleMetadataRequest(makeTestRequestContext("https://index.rubygems.org/info/testgem"),
+		server.URL, "testgem", 5, "")
+	require.NoError(t, err)
+
+	_, retHeaders, retBody, err := resp.ResponseModifier(http.StatusOK, http.Header{}, []byte(testRubyGemsInfo))
+	require.NoError(t, err)
+	assert.Equal(t, testRubyGemsInfo, string(retBody))
+	assert.Empty(t, retHeaders.Get("Cache-Control"))
+}
+
+func TestRubyGemsCooldown_EarliestPlatformBuildWins(t *testing.T) {
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"number": "2.0.0", "platform": "java", "created_at": now.Add(-1 * 24 * time.Hour).Format(time.RFC3339)},
+			{"number": "2.0.0", "platform": "ruby", "created_at": now.Add(-20 * 24 * time.Hour).Format(time.RFC3339)},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	dates, err := newRubyGemsCooldownHandler(nil).fetchCreationTimes(server.URL, "testgem")
+	require.NoError(t, err)
+	require.Contains(t, dates, "2.0.0")
+	assert.WithinDuration(t, now.Add(-20*24*time.Hour), dates["2.0.0"], time.Second)
+}
+
+func TestParseRubyGemsInfoLine(t *testing.T) {
+	cases := map[string]string{
+		"1.2.3 |checksum:abc":           "1.2.3",
+		"1.2.3-x86_64-linux dep:>= 1|x": "1.2.3",
+		"3.0.0.beta1 |checksum:abc":     "3.0.0.beta1",
+	}
+	for line, want := range cases {
+		got, ok := parseRubyGemsInfoLine([]byte(line))
+		assert.True(t, ok, line)
+		assert.Equal(t, want, got, line)
+	}
+
+	for _, line := range []string{"---", "", "   "} {
+		_, ok := parseRubyGemsInfoLine([]byte(line))
+		assert.False(t, ok, line)
+	}
+}
@@ -0,0 +1,159 @@
+package interceptors
+
+import (
+	"net/http"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/log"
+	"github.com/safedep/pmg/analyzer"
+	pmgconfig "github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+)
+
+// rubyGemsOrgAPIBaseURL serves /api/v1/versions for the built-in endpoints.
+// index.rubygems.org only serves the compact index.
+const rubyGemsOrgAPIBaseURL = "https://rubygems.org"
+
+var rubyGemsRegistryEndpoints = []registryEndpoint{
+	// Covers index.rubygems.org (compact index) as a subdomain.
+	builtInRegistryEndpoint("rubygems.org", true, rubyGemsParser{}),
+}
+
+// RubyGemsRegistryInterceptor intercepts RubyGems compact index and gem
+// download requests. /info files carry dependency cooldown; .gem downloads
+// are analyzed for malware.
+type RubyGemsRegistryInterceptor struct {
+	baseRegistryInterceptor
+	cooldownHandler *rubyGemsCooldownHandler
+	registries      registrySet
+}
+
+var _ proxy.Interceptor = (*RubyGemsRegistryInterceptor)(nil)
+var _ proxy.MITMDecider = (*RubyGemsRegistryInterceptor)(nil)
+
+func newRubyGemsRegistryInterceptor(
+	analyzer analyzer.PackageVersionAnalyzer,
+	cache AnalysisCache,
+	statsCollector *AnalysisStatsCollector,
+	confirmationChan chan *ConfirmationRequest,
+	execContext InterceptorContext,
+	registries registrySet,
+) *RubyGemsRegistryInterceptor {
+	return &RubyGemsRegistryInterceptor{
+		baseRegistryInterceptor: baseRegistryInterceptor{
+			analyzer:         analyzer,
+			cache:            cache,
+			statsCollector:   statsCollector,
+			confirmationChan: confirmationChan,
+			circuitBreaker:   newAnalyzerCircuitBreaker("malysis-analyzer-rubygems"),
+			execContext:      execContext,
+		},
+		cooldownHandler: newRubyGemsCooldownHandler(statsCollector),
+		registries:      registries,
+	}
+}
+
+func (i *RubyGemsRegistryInterceptor) Name() string {
+	return "rubygems-registry-interceptor"
+}
+
+func (i *RubyGemsRegistryInterceptor) ShouldMITM(ctx *proxy.RequestContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return registryHostSupportsAnalysis(i.registries, ctx.Hostname, ctx.Port)
+}
+
+func (i *RubyGemsRegistryInterceptor) ShouldIntercept(ctx *proxy.RequestContext) bool {
+	return registryRequestMatch(i.registries, ctx) != nil
+}
+
+// HandleRequest processes the request and returns response action.
+// We take a fail-open approach here, allowing requests that we can't parse the
+// gem information from the URL.
+func (i *RubyGemsRegistryInterceptor) HandleRequest(ctx *proxy.RequestContext) (*proxy.InterceptorResponse, error) {
+	log.Debugf("[%s] Handling RubyGems registry request: %s", ctx.RequestID, ctx.URL.Path)
+
+	match := registryRequestMatch(i.registries, ctx)
+	if match == nil {
+		log.Warnf("[%s] No registry config found for hostname: %s", ctx.RequestID, ctx.Hostname)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if ctx.Method != http.MethodGet && ctx.Method != http.MethodHead {
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	endpoint := match.Endpoint
+	if !endpoint.Analyze {
+		log.Debugf("[%s] Skipping analysis for %s registry (not supported for analysis): %s",
+			ctx.RequestID, endpoint.Host, ctx.URL.String())
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	pkgInfo, err := endpoint.Parser.ParseURL(match.RelativePath)
+	if err != nil {
+		logRegistryParseFailure(ctx, endpoint, "RubyGems", err)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	if packageInfoHasCompleteIdentity(pkgInfo) {
+		name, version := pkgInfo.GetName(), pkgInfo.GetVersion()
+		resp, err := i.handleArtifact(ctx, name, version)
+		if err != nil {
+			return resp, err
+		}
+		return i.recordFetch(ctx, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, endpoint.Name, name, version, resp), nil
+	}
+
+	if !pkgInfo.IsFileDownload() && pkgInfo.GetName() != "" {
+		return i.handleMetadataRequest(ctx, endpoint, pkgInfo.GetName())
+	}
+
+	return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+}
+
+// handleMetadataRequest applies dependency cooldown to a compact index file.
+func (i *RubyGemsRegistryInterceptor) handleMetadataRequest(ctx *proxy.RequestContext, endpoint *registryEndpoint, gemName string) (*proxy.InterceptorResponse, error) {
+	depCooldownConfig := pmgconfig.Get().Config.DependencyCooldown
+	if !depCooldownConfig.Enabled || pmgconfig.IsTrustedPackageAllVersions(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, gemName) {
+		log.Debugf("[%s] Skipping analysis for compact index request: %s", ctx.RequestID, gemName)
+		return &proxy.InterceptorResponse{Action: proxy.ActionAllow}, nil
+	}
+
+	return i.cooldownHandler.HandleMetadataRequest(ctx, rubyGemsAPIBaseURL(ctx, endpoint), gemName,
+		depCooldownConfig.Days, i.execContext.PinnedVersions[gemName])
+}
+
+// handleArtifact runs the trust, analysis, and verdict pipeline for a .gem
+// download.
+func (i *RubyGemsRegistryInterceptor) handleArtifact(ctx *proxy.RequestContext, name, version string) (*proxy.InterceptorResponse, error) {
+	if resp, ok := i.fastAllow(ctx, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, name, version); ok {
+		return resp, nil
+	}
+
+	result, err := i.analyzePackage(ctx, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, name, version)
+	if err != nil {
+		return i.handleAnalysisFailure(ctx, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, name, version, err), nil
+	}
+
+	return i.handleAnalysisResult(ctx, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, name, version, result)
+}
+
+// rubyGemsAPIBaseURL returns the registry root for out-of-band API requests.
+// Custom mirrors serve the API under the same base path as the index.
+func rubyGemsAPIBaseURL(ctx *proxy.RequestContext, endpoint *registryEndpoint) string {
+	if endpoint.Source == registrySourceBuiltIn {
+		return rubyGemsOrgAPIBaseURL
+	}
+	if ctx.URL == nil || ctx.URL.Host == "" {
+		return ""
+	}
+
+	scheme := ctx.URL.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + ctx.URL.Host + endpoint.BasePath
+}
@@ -0,0 +1,127 @@
+package interceptors
+
+import (
+	"net/http"
+	"testing"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/pmg/analyzer"
+	"github.com/safedep/pmg/config"
+	"github.com/safedep/pmg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRubyGemsInterceptor(a analyzer.PackageVersionAnalyzer, registries registrySet) *RubyGemsRegistryInterceptor {
+	return newRubyGemsRegistryInterceptor(a, NewInMemoryAnalysisCache(), NewAnalysisStatsCollector(),
+		make(chan *ConfirmationRequest, 1), InterceptorContext{}, registries)
+}
+
+func TestRubyGemsRegistryInterceptor_ShouldIntercept(t *testing.T) {
+	interceptor := newTestRubyGemsInterceptor(nil, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS))
+
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"https://rubygems.org/gems/rails-7.1.3.gem", true},
+		{"https://index.rubygems.org/info/rails", true},
+		{"https://rubygems.org.evil.test/gems/rails-7.1.3.gem", false},
+		{"https://registry.npmjs.org/rails", false},
+	}
+
+	for _, tc := range cases {
+		ctx := registryRequest(t, tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldIntercept(ctx), tc.url)
+		assert.Equal(t, tc.want, interceptor.ShouldMITM(ctx), tc.url)
+	}
+}
+
+func TestRubyGemsRegistryInterceptor_BlocksMaliciousGem(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{
+		PackageVersion: &packagev1.PackageVersion{
+			Package: &packagev1.Package{Ecosystem: packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, Name: "evil-gem"},
+			Version: "0.1.0",
+		},
+		Action:  analyzer.ActionBlock,
+		Summary: "Contains known malware",
+	}}
+	interceptor := newTestRubyGemsInterceptor(mock, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS))
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://rubygems.org/gems/evil-gem-0.1.0-x86_64-linux.gem"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, mock.callCount)
+	assert.Equal(t, proxy.ActionBlock, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.BlockCode)
+	require.NotNil(t, resp.BlockContext)
+	assert.Equal(t, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, resp.BlockContext.Ecosystem)
+	assert.Equal(t, "evil-gem", resp.BlockContext.PackageName)
+	assert.Equal(t, "0.1.0", resp.BlockContext.PackageVersion)
+}
+
+func TestRubyGemsRegistryInterceptor_InfoRequestAppliesCooldown(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	for _, enabled := range []bool{true, false} {
+		setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: enabled, Days: 5})
+
+		mock := &mockAnalyzer{}
+		interceptor := newTestRubyGemsInterceptor(mock, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS))
+
+		resp, err := interceptor.HandleRequest(makeTestRequestContext("https://index.rubygems.org/info/rails"))
+		require.NoError(t, err)
+		assert.Equal(t, 0, mock.callCount, "compact index requests are never analyzed")
+		if enabled {
+			assert.Equal(t, proxy.ActionModifyResponse, resp.Action)
+		} else {
+			assert.Equal(t, proxy.ActionAllow, resp.Action)
+		}
+	}
+}
+
+func TestRubyGemsRegistryInterceptor_LegacyDependencyAPIPassesThrough(t *testing.T) {
+	setCooldownConfig(t, config.DependencyCooldownConfig{Enabled: true, Days: 5})
+
+	mock := &mockAnalyzer{}
+	interceptor := newTestRubyGemsInterceptor(mock, newBuiltInRegistryCatalog().registrySet(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS))
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://rubygems.org/api/v1/dependencies?gems=rails,rack"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+	assert.Equal(t, 0, mock.callCount)
+}
+
+func TestRubyGemsRegistryInterceptor_CustomRegistry(t *testing.T) {
+	setTrustedPackagesForTest(t, nil)
+
+	mock := &mockAnalyzer{result: &analyzer.PackageVersionAnalysisResult{Action: analyzer.ActionAllow}}
+	registries := newTestCustomRegistrySetFor(t, packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, "https://gem.fury.io/acme/")
+	interceptor := newTestRubyGemsInterceptor(mock, registries)
+
+	assert.True(t, interceptor.ShouldIntercept(registryRequest(t, "https://gem.fury.io/acme/gems/internal-tool-1.2.0.gem")))
+	assert.False(t, interceptor.ShouldIntercept(registryRequest(t, "https://gem.fury.io/other/gems/internal-tool-1.2.0.gem")))
+
+	resp, err := interceptor.HandleRequest(makeTestRequestContext("https://gem.fury.io/acme/gems/internal-tool-1.2.0.gem"))
+	require.NoError(t, err)
+	assert.Equal(t, proxy.ActionAllow, resp.Action)
+	assert.Equal(t, 1, mock.callCount)
+
+	match := registryRequestMatch(registries, makeTestRequestContext("https://gem.fury.io/acme/info/internal-tool"))
+	require.NotNil(t, match)
+	assert.Equal(t, "https://gem.fury.io/acme",
+		rubyGemsAPIBaseURL(makeTestRequestContext("https://gem.fury.io/acme/info/internal-tool"), match.Endpoint))
+}
+
+func TestInterceptorFactoryCreatesRubyGemsInterceptor(t *testing.T) {
+	assert.True(t, IsSupported(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS))
+
+	factory, err := NewInterceptorFactory(nil, nil, nil, nil, InterceptorContext{}, nil)
+	require.NoError(t, err)
+
+	got, err := factory.CreateInterceptor(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS)
+	require.NoError(t, err)
+	assert.Equal(t, "rubygems-registry-interceptor", got.Name())
+}
@@ -0,0 +1,167 @@
+package interceptors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rubyGemsPackageInfo is parsed gem information from a RubyGems registry URL.
+type rubyGemsPackageInfo struct {
+	name       string
+	version    string
+	platform   string
+	isDownload bool
+}
+
+var _ packageInfo = (*rubyGemsPackageInfo)(nil)
+
+func (r *rubyGemsPackageInfo) GetName() string { return r.name }
+
+func (r *rubyGemsPackageInfo) GetVersion() string { return r.version }
+
+func (r *rubyGemsPackageInfo) IsFileDownload() bool { return r.isDownload }
+
+// rubyGemsParser parses URLs served by rubygems.org and by RubyGems-compatible
+// mirrors (Gemfury, Artifactory), relative to the registry base path:
+//
+//	/gems/{name}-{version}[-{platform}].gem  -> gem download
+//	/info/{name}                             -> compact index entry for one gem
+//	/versions                                -> compact index of all gems
+//	/api/v1/dependencies                     -> legacy dependency API (Marshal)
+//	/quick/Marshal.4.8/{file}.gemspec.rz     -> legacy gemspec
+//	/specs.4.8.gz and friends                -> legacy full index
+//
+// Only /info/{name} carries a gem name without a version; everything else
+// that is not a download is metadata passed through unmodified.
+type rubyGemsParser struct{}
+
+var _ registryURLParser = rubyGemsParser{}
+
+func (p rubyGemsParser) ParseURL(urlPath string) (packageInfo, error) {
+	urlPath = strings.Trim(urlPath, "/")
+	if urlPath == "" {
+		return nil, fmt.Errorf("empty RubyGems URL path")
+	}
+
+	segments := strings.Split(urlPath, "/")
+	switch {
+	case segments[0] == "gems" && len(segments) == 2:
+		return parseRubyGemsFilename(segments[1])
+	case segments[0] == "info" && len(segments) == 2:
+		if !isValidGemName(segments[1]) {
+			return nil, fmt.Errorf("invalid gem name in compact index URL: %q", segments[1])
+		}
+		return &rubyGemsPackageInfo{name: segments[1]}, nil
+	case urlPath == "versions", urlPath == "names", urlPath == "api/v1/dependencies":
+		return &rubyGemsPackageInfo{}, nil
+	case segments[0] == "quick":
+		return &rubyGemsPackageInfo{}, nil
+	case len(segments) == 1 && strings.HasSuffix(urlPath, ".4.8.gz"):
+		return &rubyGemsPackageInfo{}, nil
+	}
+
+	return nil, fmt.Errorf("unrecognized RubyGems URL path: %q", urlPath)
+}
+
+// parseRubyGemsFilename splits a gem file name into name, version and
+// platform. Gem names may contain hyphens and digit-leading segments
+// (e.g. ruby-2-openssl), so the file name is parsed from the right: the
+// version is the segment before a known platform suffix, or the last segment
+// when there is none, and must parse as a Gem::Version
+// (e.g. nokogiri-1.16.0-x86_64-linux.gem).
+func parseRubyGemsFilename(filename string) (*rubyGemsPackageInfo, error) {
+	stem, ok := strings.CutSuffix(filename, ".gem")
+	if !ok {
+		return nil, fmt.Errorf("RubyGems download URL is not a .gem file: %q", filename)
+	}
+
+	parts := strings.Split(stem, "-")
+	versionIndex := len(parts) - 1
+	for i := len(parts) - 1; i >= 2; i-- {
+		if isGemPlatform(parts[i:]) && isGemVersion(parts[i-1]) {
+			versionIndex = i - 1
+			break
+		}
+	}
+
+	if versionIndex < 1 || !isGemVersion(parts[versionIndex]) {
+		return nil, fmt.Errorf("gem file %q has no version", filename)
+	}
+
+	name := strings.Join(parts[:versionIndex], "-")
+	if !isValidGemName(name) {
+		return nil, fmt.Errorf("invalid gem name in download URL: %q", name)
+	}
+
+	return &rubyGemsPackageInfo{
+		name:       name,
+		version:    parts[versionIndex],
+		platform:   strings.Join(parts[versionIndex+1:], "-"),
+		isDownload: true,
+	}, nil
+}
+
+// isGemVersion reports whether s is a Gem::Version as it appears in a file
+// name: dot-separated alphanumeric segments, the first of them numeric.
+// Gem::Version rewrites '-' to ".pre.", so a file name version has no hyphen.
+func isGemVersion(s string) bool {
+	segments := strings.Split(s, ".")
+	for i, segment := range segments {
+		if segment == "" {
+			return false
+		}
+		for _, r := range segment {
+			switch {
+			case r >= '0' && r <= '9':
+			case i > 0 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'):
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// gemPlatformCPUs and gemPlatformOSes are the Gem::Platform cpu and os values
+// gems are published for.
+var (
+	gemPlatformCPUs = map[string]bool{
+		"x86": true, "x86_64": true, "x64": true, "i386": true, "i486": true, "i586": true, "i686": true,
+		"universal": true, "arm": true, "armv6": true, "armv7": true, "armv7l": true, "arm64": true,
+		"aarch64": true, "powerpc": true, "ppc": true, "ppc64": true, "ppc64le": true, "sparc": true,
+		"s390": true, "s390x": true, "riscv64": true, "mips": true, "mips64": true, "loongarch64": true,
+	}
+	gemPlatformOSes = map[string]bool{
+		"linux": true, "darwin": true, "mingw": true, "mingw32": true, "mswin32": true, "mswin64": true,
+		"java": true, "jruby": true, "dalvik": true, "dotnet": true, "cygwin": true, "freebsd": true,
+		"openbsd": true, "netbsd": true, "solaris": true, "aix": true, "macruby": true, "wasi": true,
+	}
+)
+
+// isGemPlatform reports whether parts is a Gem::Platform: an optional cpu,
+// an os, and at most two trailing qualifiers such as an os version or libc
+// (e.g. java, x86_64-linux-gnu, universal-darwin-21, x64-mingw-ucrt).
+func isGemPlatform(parts []string) bool {
+	if len(parts) > 0 && gemPlatformCPUs[parts[0]] {
+		parts = parts[1:]
+	}
+	return len(parts) >= 1 && len(parts) <= 3 && gemPlatformOSes[parts[0]]
+}
+
+// isValidGemName reports whether name uses only the characters rubygems.org
+// accepts: ASCII alphanumerics, '-', '_' and '.'.
+func isValidGemName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package interceptors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRubyGemsParser(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		gem        string
+		version    string
+		platform   string
+		isDownload bool
+		wantErr    bool
+	}{
+		{name: "gem download", path: "/gems/rails-7.1.3.gem", gem: "rails", version: "7.1.3", isDownload: true},
+		{name: "hyphenated gem name", path: "/gems/aws-sdk-s3-1.142.0.gem", gem: "aws-sdk-s3", version: "1.142.0", isDownload: true},
+		{name: "platform gem", path: "/gems/nokogiri-1.16.0-x86_64-linux.gem", gem: "nokogiri", version: "1.16.0", platform: "x86_64-linux", isDownload: true},
+		{name: "prerelease version", path: "/gems/rack-3.1.0.beta1.gem", gem: "rack", version: "3.1.0.beta1", isDownload: true},
+		{name: "digit-leading name segment", path: "/gems/ruby-2-openssl-1.0.0.gem", gem: "ruby-2-openssl", version: "1.0.0", isDownload: true},
+		{name: "digit-leading name segment with platform", path: "/gems/net-http2-0.18.5-java.gem", gem: "net-http2", version: "0.18.5", platform: "java", isDownload: true},
+		{name: "platform with os version", path: "/gems/ffi-1.16.3-universal-darwin-21.gem", gem: "ffi", version: "1.16.3", platform: "universal-darwin-21", isDownload: true},
+		{name: "platform with libc", path: "/gems/nokogiri-1.16.0-aarch64-linux-musl.gem", gem: "nokogiri", version: "1.16.0", platform: "aarch64-linux-musl", isDownload: true},
+		{name: "ucrt platform", path: "/gems/sqlite3-1.7.0-x64-mingw-ucrt.gem", gem: "sqlite3", version: "1.7.0", platform: "x64-mingw-ucrt", isDownload: true},
+		{name: "compact index info", path: "/info/rails", gem: "rails"},
+		{name: "compact index versions", path: "/versions"},
+		{name: "legacy dependency API", path: "/api/v1/dependencies"},
+		{name: "legacy gemspec", path: "/quick/Marshal.4.8/rails-7.1.3.gemspec.rz"},
+		{name: "legacy full index", path: "/specs.4.8.gz"},
+		{name: "not a gem file", path: "/gems/rails-7.1.3.tar.gz", wantErr: true},
+		{name: "gem file without version", path: "/gems/rails.gem", wantErr: true},
+		{name: "platform without version", path: "/gems/rails-x86_64-linux.gem", wantErr: true},
+		{name: "invalid info name", path: "/info/rails%20x", wantErr: true},
+		{name: "unknown path", path: "/api/v1/gems/rails.json", wantErr: true},
+		{name: "empty path", path: "/", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := rubyGemsParser{}.ParseURL(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.gem, info.GetName())
+			assert.Equal(t, tc.version, info.GetVersion())
+			assert.Equal(t, tc.isDownload, info.IsFileDownload())
+			assert.Equal(t, tc.platform, info.(*rubyGemsPackageInfo).platform)
+		})
+	}
+}
@@ -17,6 +17,55 @@ type AnalysisStats struct {
 	BlockedCount         int
 	UserCancelledCount   int
 	CooldownBlockedCount int
+
+	// VulnerableBlockedCount counts packages blocked by the vulnerability
+	// policy. These are included in BlockedCount.
+	VulnerableBlockedCount int
+
+	// LicenseBlockedCount counts packages blocked by the license policy.
+	// These are included in BlockedCount.
+	LicenseBlockedCount int
+
+	// PackageAgeBlockedCount counts packages blocked because their first
+	// release is too recent. These are included in BlockedCount.
+	PackageAgeBlockedCount int
+
+	// ContentRuleBlockedCount counts packages blocked by a content scan
+	// rule. These are included in BlockedCount.
+	ContentRuleBlockedCount int
+
+	// LockfileBlockedCount counts packages blocked because their artifact
+	// does not match the project's lockfile. These are included in
+	// BlockedCount.
+	LockfileBlockedCount int
+
+	// RegistryIntegrityBlockedCount counts packages blocked because their
+	// artifact does not match the registry's metadata. These are included
+	// in BlockedCount.
+	RegistryIntegrityBlockedCount int
+
+	// NpmSignatureBlockedCount counts npm packages blocked because their
+	// registry signature is missing or does not verify. These are included
+	// in BlockedCount.
+	NpmSignatureBlockedCount int
+
+	// ProvenanceBlockedCount counts packages blocked because they lack a
+	// valid provenance attestation. These are included in BlockedCount.
+	ProvenanceBlockedCount int
+
+	// DependencyConfusionBlockedCount counts packages of a private namespace
+	// blocked because a registry that may not serve them was asked for them.
+	// These are included in BlockedCount.
+	DependencyConfusionBlockedCount int
+
+	// UnverifiedCount counts packages installed without a malware verdict
+	// because analysis was unavailable (analysis.on_failure allow or confirm).
+	UnverifiedCount int
+
+	// AnalysisUnavailableBlockedCount counts packages blocked because
+	// analysis was unavailable (analysis.on_failure block, or a declined
+	// confirmation). These are included in BlockedCount.
+	AnalysisUnavailableBlockedCount int
 }
 
 // AnalysisStatsCollector tracks analysis statistics during proxy execution.
@@ -28,11 +77,31 @@ type AnalysisStatsCollector struct {
 	blockedPackages   []*analyzer.PackageVersionAnalysisResult
 	confirmedPackages []*analyzer.PackageVersionAnalysisResult
 	cooldownBlocks    []models.CooldownBlock
+	vulnerableBlocked []*analyzer.PackageVersionAnalysisResult
+	licenseBlocks     []models.LicenseBlock
+	packageAgeBlocks  []models.PackageAgeBlock
+	contentRuleBlocks []models.ContentRuleBlock
+	lockfileBlocks    []models.LockfileBlock
+	registryBlocks    []models.RegistryIntegrityBlock
+	signatureBlocks   []models.NpmSignatureBlock
+	provenanceBlocks  []models.ProvenanceBlock
+	confusionBlocks   []models.DependencyConfusionBlock
+
+	unverifiedPackages         []models.UnverifiedPackage
+	analysisUnavailableBlocked []models.UnverifiedPackage
 
 	// cooldownWithheld is keyed by package name, then version, holding days
 	// left. Metadata for one package can be fetched several times during an
 	// install, so recording must deduplicate rather than append.
 	cooldownWithheld map[string]map[string]int
+
+	// malwareStripped is keyed by ecosystem, name and version for the same
+	// reason.
+	malwareStripped map[string]models.MalwareStripped
+
+	// fetched is keyed by ecosystem, name and version: a package manager may
+	// download several artifacts of a version, or the same one twice.
+	fetched map[string]*models.FetchedPackage
 }
 
 // NewAnalysisStatsCollector creates a new stats collector
@@ -129,6 +198,214 @@ func (c *AnalysisStatsCollector) GetConfirmedPackages() []*analyzer.PackageVersi
 	return result
 }
 
+// RecordVulnerableBlocked records a package blocked by the vulnerability
+// policy. It is kept apart from malware blocks so the report can tell them
+// apart.
+func (c *AnalysisStatsCollector) RecordVulnerableBlocked(result *analyzer.PackageVersionAnalysisResult) {
+	if result == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.TotalAnalyzed++
+	c.stats.BlockedCount++
+	c.stats.VulnerableBlockedCount++
+	c.vulnerableBlocked = append(c.vulnerableBlocked, result)
+}
+
+// GetVulnerableBlockedPackages returns all packages blocked by the
+// vulnerability policy.
+func (c *AnalysisStatsCollector) GetVulnerableBlockedPackages() []*analyzer.PackageVersionAnalysisResult {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]*analyzer.PackageVersionAnalysisResult, len(c.vulnerableBlocked))
+	copy(result, c.vulnerableBlocked)
+	return result
+}
+
+// RecordLicenseBlocked records a package blocked by the license policy.
+func (c *AnalysisStatsCollector) RecordLicenseBlocked(block models.LicenseBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.TotalAnalyzed++
+	c.stats.BlockedCount++
+	c.stats.LicenseBlockedCount++
+	c.licenseBlocks = append(c.licenseBlocks, block)
+}
+
+// GetLicenseBlocks returns all packages blocked by the license policy.
+func (c *AnalysisStatsCollector) GetLicenseBlocks() []models.LicenseBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.LicenseBlock, len(c.licenseBlocks))
+	copy(result, c.licenseBlocks)
+	return result
+}
+
+// RecordPackageAgeBlocked records a package blocked by the package age
+// policy.
+func (c *AnalysisStatsCollector) RecordPackageAgeBlocked(block models.PackageAgeBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.TotalAnalyzed++
+	c.stats.BlockedCount++
+	c.stats.PackageAgeBlockedCount++
+	c.packageAgeBlocks = append(c.packageAgeBlocks, block)
+}
+
+// GetPackageAgeBlocks returns all packages blocked by the package age policy.
+func (c *AnalysisStatsCollector) GetPackageAgeBlocks() []models.PackageAgeBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.PackageAgeBlock, len(c.packageAgeBlocks))
+	copy(result, c.packageAgeBlocks)
+	return result
+}
+
+// RecordContentRuleBlocked records a package blocked by a content scan rule.
+// Its artifact was scanned after its malware verdict, which is already
+// counted, so only BlockedCount moves.
+func (c *AnalysisStatsCollector) RecordContentRuleBlocked(block models.ContentRuleBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.BlockedCount++
+	c.stats.ContentRuleBlockedCount++
+	c.contentRuleBlocks = append(c.contentRuleBlocks, block)
+}
+
+// GetContentRuleBlocks returns all packages blocked by a content scan rule.
+func (c *AnalysisStatsCollector) GetContentRuleBlocks() []models.ContentRuleBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.ContentRuleBlock, len(c.contentRuleBlocks))
+	copy(result, c.contentRuleBlocks)
+	return result
+}
+
+// RecordLockfileBlocked records a package blocked by the lockfile integrity
+// check. An unlisted package is blocked before analysis; a mismatch is found
+// in the artifact after its malware verdict was counted.
+func (c *AnalysisStatsCollector) RecordLockfileBlocked(block models.LockfileBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if block.Verdict == models.LockfileVerdictUnlisted {
+		c.stats.TotalAnalyzed++
+	}
+	c.stats.BlockedCount++
+	c.stats.LockfileBlockedCount++
+	c.lockfileBlocks = append(c.lockfileBlocks, block)
+}
+
+// GetLockfileBlocks returns all packages blocked by the lockfile integrity
+// check.
+func (c *AnalysisStatsCollector) GetLockfileBlocks() []models.LockfileBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.LockfileBlock, len(c.lockfileBlocks))
+	copy(result, c.lockfileBlocks)
+	return result
+}
+
+// RecordRegistryIntegrityBlocked records a package blocked by the registry
+// integrity check. The mismatch is found in the artifact after its malware
+// verdict was counted.
+func (c *AnalysisStatsCollector) RecordRegistryIntegrityBlocked(block models.RegistryIntegrityBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.BlockedCount++
+	c.stats.RegistryIntegrityBlockedCount++
+	c.registryBlocks = append(c.registryBlocks, block)
+}
+
+// GetRegistryIntegrityBlocks returns all packages blocked by the registry
+// integrity check.
+func (c *AnalysisStatsCollector) GetRegistryIntegrityBlocks() []models.RegistryIntegrityBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.RegistryIntegrityBlock, len(c.registryBlocks))
+	copy(result, c.registryBlocks)
+	return result
+}
+
+// RecordNpmSignatureBlocked records a package blocked by the npm signature
+// policy. The block happens before analysis.
+func (c *AnalysisStatsCollector) RecordNpmSignatureBlocked(block models.NpmSignatureBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.TotalAnalyzed++
+	c.stats.BlockedCount++
+	c.stats.NpmSignatureBlockedCount++
+	c.signatureBlocks = append(c.signatureBlocks, block)
+}
+
+// GetNpmSignatureBlocks returns all packages blocked by the npm signature
+// policy.
+func (c *AnalysisStatsCollector) GetNpmSignatureBlocks() []models.NpmSignatureBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.NpmSignatureBlock, len(c.signatureBlocks))
+	copy(result, c.signatureBlocks)
+	return result
+}
+
+// RecordProvenanceBlocked records a package blocked by the provenance
+// policy. The artifact is checked after its malware verdict was counted.
+func (c *AnalysisStatsCollector) RecordProvenanceBlocked(block models.ProvenanceBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.BlockedCount++
+	c.stats.ProvenanceBlockedCount++
+	c.provenanceBlocks = append(c.provenanceBlocks, block)
+}
+
+// GetProvenanceBlocks returns all packages blocked by the provenance policy.
+func (c *AnalysisStatsCollector) GetProvenanceBlocks() []models.ProvenanceBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.ProvenanceBlock, len(c.provenanceBlocks))
+	copy(result, c.provenanceBlocks)
+	return result
+}
+
+// RecordDependencyConfusionBlocked records a package blocked by the private
+// namespace policy.
+func (c *AnalysisStatsCollector) RecordDependencyConfusionBlocked(block models.DependencyConfusionBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.BlockedCount++
+	c.stats.DependencyConfusionBlockedCount++
+	c.confusionBlocks = append(c.confusionBlocks, block)
+}
+
+// GetDependencyConfusionBlocks returns all packages blocked by the private
+// namespace policy.
+func (c *AnalysisStatsCollector) GetDependencyConfusionBlocks() []models.DependencyConfusionBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.DependencyConfusionBlock, len(c.confusionBlocks))
+	copy(result, c.confusionBlocks)
+	return result
+}
+
 // RecordCooldownBlocked records a package blocked by the dependency cooldown policy.
 func (c *AnalysisStatsCollector) RecordCooldownBlocked(name, version string, publishDate time.Time, daysAgo, daysLeft, cooldownDays int) {
 	c.mu.Lock()
@@ -202,3 +479,142 @@ func (c *AnalysisStatsCollector) GetCooldownWithheld() []models.CooldownWithheld
 	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
 	return result
 }
+
+// RecordMalwareStripped records a malicious version removed from a package's
+// metadata. Stripped versions are not blocks: they do not count toward
+// analyzed or blocked totals.
+func (c *AnalysisStatsCollector) RecordMalwareStripped(entry models.MalwareStripped) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.malwareStripped == nil {
+		c.malwareStripped = make(map[string]models.MalwareStripped)
+	}
+	c.malwareStripped[entry.Ecosystem+":"+entry.Name+":"+entry.Version] = entry
+}
+
+// GetMalwareStripped returns the stripped versions sorted by package name and
+// version for stable rendering.
+func (c *AnalysisStatsCollector) GetMalwareStripped() []models.MalwareStripped {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.MalwareStripped, 0, len(c.malwareStripped))
+	for _, entry := range c.malwareStripped {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
+// RecordUnverified records a package installed without a malware verdict
+// because analysis was unavailable. It only tracks the missing verdict: the
+// caller also records the allow or confirm decision itself with
+// RecordAllowed or RecordConfirmed.
+func (c *AnalysisStatsCollector) RecordUnverified(name, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.UnverifiedCount++
+	c.unverifiedPackages = append(c.unverifiedPackages, models.UnverifiedPackage{Name: name, Version: version})
+}
+
+// RecordAnalysisUnavailableBlocked records a package blocked because analysis
+// was unavailable and the failure policy did not allow it through.
+func (c *AnalysisStatsCollector) RecordAnalysisUnavailableBlocked(name, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.TotalAnalyzed++
+	c.stats.BlockedCount++
+	c.stats.AnalysisUnavailableBlockedCount++
+	c.analysisUnavailableBlocked = append(c.analysisUnavailableBlocked, models.UnverifiedPackage{Name: name, Version: version})
+}
+
+// GetUnverifiedPackages returns all packages installed without a verdict.
+func (c *AnalysisStatsCollector) GetUnverifiedPackages() []models.UnverifiedPackage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.UnverifiedPackage, len(c.unverifiedPackages))
+	copy(result, c.unverifiedPackages)
+	return result
+}
+
+// GetAnalysisUnavailableBlocked returns all packages blocked because analysis
+// was unavailable.
+func (c *AnalysisStatsCollector) GetAnalysisUnavailableBlocked() []models.UnverifiedPackage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.UnverifiedPackage, len(c.analysisUnavailableBlocked))
+	copy(result, c.analysisUnavailableBlocked)
+	return result
+}
+
+// RecordFetched records the artifacts of a package version delivered to the
+// package manager. Artifacts of a version already recorded are merged into
+// it, and a recorded verdict is kept over an empty one.
+func (c *AnalysisStatsCollector) RecordFetched(entry models.FetchedPackage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fetched == nil {
+		c.fetched = make(map[string]*models.FetchedPackage)
+	}
+
+	key := entry.Ecosystem + ":" + entry.Name + ":" + entry.Version
+	existing, ok := c.fetched[key]
+	if !ok {
+		recorded := entry
+		recorded.Artifacts = nil
+		existing = &recorded
+		c.fetched[key] = existing
+	}
+	if existing.Verdict == "" {
+		existing.Verdict = entry.Verdict
+	}
+
+	for _, artifact := range entry.Artifacts {
+		known := false
+		for index := range existing.Artifacts {
+			if existing.Artifacts[index].URL == artifact.URL {
+				existing.Artifacts[index] = artifact
+				known = true
+				break
+			}
+		}
+		if !known {
+			existing.Artifacts = append(existing.Artifacts, artifact)
+		}
+	}
+}
+
+// GetFetchedPackages returns the fetched package versions sorted by
+// ecosystem, name and version for stable output.
+func (c *AnalysisStatsCollector) GetFetchedPackages() []models.FetchedPackage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]models.FetchedPackage, 0, len(c.fetched))
+	for _, entry := range c.fetched {
+		copied := *entry
+		copied.Artifacts = append([]models.FetchedArtifact(nil), entry.Artifacts...)
+		result = append(result, copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Ecosystem != result[j].Ecosystem {
+			return result[i].Ecosystem < result[j].Ecosystem
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -560,39 +561,8 @@ func (ps *proxyServer) registerHandlers() {
 
 			switch resp.Action {
 			case ActionBlock:
-				statusCode := resp.BlockCode
-				if statusCode == 0 {
-					statusCode = http.StatusForbidden
-				}
-
-				message := resp.BlockMessage
-				if message == "" && ps.config.BlockMessageRenderer != nil {
-					message = ps.config.BlockMessageRenderer(resp.BlockReason, resp.BlockContext)
-				}
-				if message == "" {
-					message = "Blocked by proxy interceptor"
-				}
-
 				log.Debugf("[%s] Blocked by %s: %s", reqCtx.RequestID, interceptor.Name(), req.URL.String())
-				r := goproxy.NewResponse(req, goproxy.ContentTypeText, statusCode, message)
-
-				// goproxy v1.8.x writes the response via (*http.Response).Write for MITM traffic.
-				// Ensure the protocol version is valid (defaults to HTTP/0.0 otherwise).
-				// Ref: https://github.com/elazarl/goproxy/issues/745
-				if req.ProtoMajor > 0 {
-					r.Proto = req.Proto
-					r.ProtoMajor = req.ProtoMajor
-					r.ProtoMinor = req.ProtoMinor
-				} else {
-					r.Proto = "HTTP/1.1"
-					r.ProtoMajor = 1
-					r.ProtoMinor = 1
-				}
-				r.Close = true
-				r.Header.Set("Connection", "close")
-				r.Header.Set("Proxy-Connection", "close")
-
-				return req, r
+				return req, ps.newBlockResponse(req, resp.BlockCode, resp.BlockMessage, resp.BlockReason, resp.BlockContext)
 
 			case ActionModifyRequest:
 				if resp.ModifiedHeaders != nil {
@@ -602,7 +572,11 @@ func (ps *proxyServer) registerHandlers() {
 				log.Debugf("[%s] Request modified by %s", reqCtx.RequestID, interceptor.Name())
 
 			case ActionModifyResponse:
-				ctx.UserData = resp.ResponseModifier
+				ctx.UserData = responseModification{
+					modify:   resp.ResponseModifier,
+					maxSize:  resp.MaxResponseSize,
+					oversize: resp.OversizeResponse,
+				}
 				log.Debugf("[%s] Response modifier registered by %s", reqCtx.RequestID, interceptor.Name())
 			}
 		}
@@ -635,12 +609,58 @@ func (ps *proxyServer) registerHandlers() {
 
 		log.Debugf("[%s] Response received for %s", reqCtx.RequestID, ctx.Req.URL.String())
 
-		modifier, ok := ctx.UserData.(ResponseModifierFunc)
-		if !ok || modifier == nil {
+		modification, ok := ctx.UserData.(responseModification)
+		if !ok || modification.modify == nil {
+			return resp
+		}
+		modifier := modification.modify
+
+		// oversize answers a response over the limit: a block, or nil to
+		// stream it unmodified.
+		oversize := func() *http.Response {
+			if modification.oversize == nil {
+				return nil
+			}
+			block := modification.oversize(resp.StatusCode, resp.Header)
+			if block == nil {
+				return nil
+			}
+			if closeErr := resp.Body.Close(); closeErr != nil {
+				log.Warnf("[%s] Failed to close response body: %v", reqCtx.RequestID, closeErr)
+			}
+			log.Debugf("[%s] Oversize response blocked: %s", reqCtx.RequestID, ctx.Req.URL.String())
+			return ps.newBlockResponse(ctx.Req, block.Code, "", block.Reason, block.Context)
+		}
+
+		maxSize := modification.maxSize
+		if maxSize > 0 && resp.ContentLength > maxSize {
+			if blocked := oversize(); blocked != nil {
+				return blocked
+			}
+			log.Warnf("[%s] Response for %s is %d bytes, over the %d byte limit, forwarding it unmodified",
+				reqCtx.RequestID, ctx.Req.URL.String(), resp.ContentLength, maxSize)
 			return resp
 		}
 
-		body, err := io.ReadAll(resp.Body)
+		reader := io.Reader(resp.Body)
+		if maxSize > 0 {
+			reader = io.LimitReader(resp.Body, maxSize+1)
+		}
+		body, err := io.ReadAll(reader)
+		if err == nil && maxSize > 0 && int64(len(body)) > maxSize {
+			// Without a Content-Length the size shows only while reading. What
+			// was read goes out ahead of the rest of the stream.
+			if blocked := oversize(); blocked != nil {
+				return blocked
+			}
+			log.Warnf("[%s] Response for %s is over the %d byte limit, forwarding it unmodified",
+				reqCtx.RequestID, ctx.Req.URL.String(), maxSize)
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+			return resp
+		}
 		if closeErr := resp.Body.Close(); closeErr != nil {
 			log.Warnf("[%s] Failed to close response body: %v", reqCtx.RequestID, closeErr)
 		}
@@ -655,6 +675,11 @@ func (ps *proxyServer) registerHandlers() {
 		}
 
 		newStatusCode, newHeaders, newBody, err := modifier(resp.StatusCode, resp.Header, body)
+		var block *ResponseBlock
+		if errors.As(err, &block) {
+			log.Debugf("[%s] Response blocked: %s", reqCtx.RequestID, ctx.Req.URL.String())
+			return ps.newBlockResponse(ctx.Req, block.Code, "", block.Reason, block.Context)
+		}
 		if err != nil {
 			log.Errorf("[%s] Response modifier error: %v", reqCtx.RequestID, err)
 			resp.Body = io.NopCloser(bytes.NewReader(body))
@@ -671,3 +696,48 @@ func (ps *proxyServer) registerHandlers() {
 		return resp
 	})
 }
+
+// responseModification is what an ActionModifyResponse decision leaves in
+// the goproxy context for the response handler.
+type responseModification struct {
+	modify   ResponseModifierFunc
+	maxSize  int64
+	oversize OversizeResponseFunc
+}
+
+// newBlockResponse renders a block decision as the response sent to the
+// client in place of the upstream one.
+func (ps *proxyServer) newBlockResponse(req *http.Request, statusCode int, message string,
+	reason BlockReason, blockCtx *BlockContext,
+) *http.Response {
+	if statusCode == 0 {
+		statusCode = http.StatusForbidden
+	}
+
+	if message == "" && ps.config.BlockMessageRenderer != nil {
+		message = ps.config.BlockMessageRenderer(reason, blockCtx)
+	}
+	if message == "" {
+		message = "Blocked by proxy interceptor"
+	}
+
+	r := goproxy.NewResponse(req, goproxy.ContentTypeText, statusCode, message)
+
+	// goproxy v1.8.x writes the response via (*http.Response).Write for MITM traffic.
+	// Ensure the protocol version is valid (defaults to HTTP/0.0 otherwise).
+	// Ref: https://github.com/elazarl/goproxy/issues/745
+	if req.ProtoMajor > 0 {
+		r.Proto = req.Proto
+		r.ProtoMajor = req.ProtoMajor
+		r.ProtoMinor = req.ProtoMinor
+	} else {
+		r.Proto = "HTTP/1.1"
+		r.ProtoMajor = 1
+		r.ProtoMinor = 1
+	}
+	r.Close = true
+	r.Header.Set("Connection", "close")
+	r.Header.Set("Proxy-Connection", "close")
+
+	return r
+}
@@ -2,9 +2,13 @@ package proxy
 
 import (
 	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -268,3 +272,217 @@ func TestResponseProtoNormalisedToHTTP11(t *testing.T) {
 	assert.Equal(t, 1, resp.ProtoMajor, "response ProtoMajor should be 1 (HTTP/1.1)")
 	assert.Equal(t, 1, resp.ProtoMinor, "response ProtoMinor should be 1 (HTTP/1.1)")
 }
+
+// modifierInterceptor registers a fixed response modifier for every request.
+type modifierInterceptor struct {
+	modifier ResponseModifierFunc
+	maxSize  int64
+	oversize OversizeResponseFunc
+}
+
+func (m *modifierInterceptor) Name() string { return "modifier" }
+
+func (m *modifierInterceptor) ShouldIntercept(*RequestContext) bool { return true }
+
+func (m *modifierInterceptor) HandleRequest(*RequestContext) (*InterceptorResponse, error) {
+	return &InterceptorResponse{
+		Action:           ActionModifyResponse,
+		ResponseModifier: m.modifier,
+		MaxResponseSize:  m.maxSize,
+		OversizeResponse: m.oversize,
+	}, nil
+}
+
+func TestResponseModifierBlock(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("artifact"))
+	}))
+	defer upstream.Close()
+
+	var rendered BlockReason
+	server, err := NewProxyServer(&ProxyConfig{
+		ListenAddr:     "127.0.0.1:0",
+		ConnectTimeout: 5 * time.Second,
+		RequestTimeout: 5 * time.Second,
+		BlockMessageRenderer: func(reason BlockReason, blockCtx *BlockContext) string {
+			rendered = reason
+			return "blocked by rule " + blockCtx.RuleName
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, server.AddInterceptor(&modifierInterceptor{
+		modifier: func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+			return 0, nil, nil, &ResponseBlock{
+				Reason:  BlockReasonContentRule,
+				Context: &BlockContext{RuleName: "dropper"},
+			}
+		},
+	}))
+
+	require.NoError(t, server.Start())
+	defer func() {
+		_ = server.Stop(t.Context())
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: server.Address()}),
+		},
+	}
+
+	resp, err := client.Get(upstream.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "blocked by rule dropper", string(body))
+	assert.Equal(t, BlockReasonContentRule, rendered)
+}
+
+func TestResponseModifierErrorForwardsOriginal(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("artifact"))
+	}))
+	defer upstream.Close()
+
+	server, err := NewProxyServer(&ProxyConfig{
+		ListenAddr:     "127.0.0.1:0",
+		ConnectTimeout: 5 * time.Second,
+		RequestTimeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, server.AddInterceptor(&modifierInterceptor{
+		modifier: func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+			return 0, nil, nil, errors.New("scan failed")
+		},
+	}))
+
+	require.NoError(t, server.Start())
+	defer func() {
+		_ = server.Stop(t.Context())
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: server.Address()}),
+		},
+	}
+
+	resp, err := client.Get(upstream.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "artifact", string(body))
+}
+
+func TestResponseModifierSkipsOversizeResponse(t *testing.T) {
+	const artifact = "an artifact over the limit"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// Flushing before the body is written leaves the length unknown.
+			w.(http.Flusher).Flush()
+		} else {
+			w.Header().Set("Content-Length", fmt.Sprint(len(artifact)))
+		}
+		_, _ = w.Write([]byte(artifact))
+	}))
+	defer upstream.Close()
+
+	server, err := NewProxyServer(&ProxyConfig{
+		ListenAddr:     "127.0.0.1:0",
+		ConnectTimeout: 5 * time.Second,
+		RequestTimeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, server.AddInterceptor(&modifierInterceptor{
+		maxSize: 8,
+		modifier: func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+			return 0, nil, nil, &ResponseBlock{Reason: BlockReasonContentRule}
+		},
+	}))
+
+	require.NoError(t, server.Start())
+	defer func() {
+		_ = server.Stop(t.Context())
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: server.Address()}),
+		},
+	}
+
+	for _, path := range []string{"/sized", "/chunked"} {
+		resp, err := client.Get(upstream.URL + path)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, artifact, string(body), path)
+	}
+}
+
+func TestResponseModifierBlocksOversizeResponse(t *testing.T) {
+	const artifact = "an artifact over the limit"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush()
+		} else {
+			w.Header().Set("Content-Length", fmt.Sprint(len(artifact)))
+		}
+		_, _ = w.Write([]byte(artifact))
+	}))
+	defer upstream.Close()
+
+	server, err := NewProxyServer(&ProxyConfig{
+		ListenAddr:     "127.0.0.1:0",
+		ConnectTimeout: 5 * time.Second,
+		RequestTimeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	var modified atomic.Int32
+	require.NoError(t, server.AddInterceptor(&modifierInterceptor{
+		maxSize: 8,
+		modifier: func(statusCode int, headers http.Header, body []byte) (int, http.Header, []byte, error) {
+			modified.Add(1)
+			return statusCode, headers, body, nil
+		},
+		oversize: func(statusCode int, _ http.Header) *ResponseBlock {
+			return &ResponseBlock{Reason: BlockReasonRegistryIntegrity}
+		},
+	}))
+
+	require.NoError(t, server.Start())
+	defer func() {
+		_ = server.Stop(t.Context())
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: server.Address()}),
+		},
+	}
+
+	for _, path := range []string{"/sized", "/chunked"} {
+		resp, err := client.Get(upstream.URL + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, path)
+	}
+	assert.Equal(t, int32(0), modified.Load())
+}